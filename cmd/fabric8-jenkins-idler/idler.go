@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
@@ -11,34 +12,45 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/api"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/callback"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/dlq"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/footprint"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/pressure"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/recovery"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/report"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/router"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
-	"github.com/julienschmidt/httprouter"
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	profilerPort = 6060
-)
-
 var idlerLogger = log.WithFields(log.Fields{"component": "idler"})
 
+// evictionInterval is the interval at which the user-idlers of inactive namespaces are evicted.
+const evictionInterval = time.Hour
+
 // Idler is responsible to create and control the various concurrent processes needed to implement the Jenkins idling
-// feature. An Idler instance creates two goroutines for watching all builds respectively deployment config changes of
-// the whole cluster. To do this it needs an access openshift access token which allows the Idler to do so (see Data.GetOpenShiftToken).
-// A third go routine is used to serve a HTTP REST API.
+// feature. For each cluster, an Idler instance runs a controller along with three goroutines for watching all builds,
+// deployment config respectively Jenkins pod changes of the whole cluster, supervised independently of the other
+// clusters. To do this it needs an access openshift access token which allows the Idler to do so (see Data.GetOpenShiftToken).
+// Two further goroutines serve the public respectively the admin HTTP REST API.
 type Idler struct {
-	featureService toggles.Features
-	tenantService  tenant.Service
-	clusterView    cluster.View
-	config         configuration.Configuration
-	disabledUsers  *model.StringSet
-	userIdlers     *openshift.UserIdlerMap
+	featureService   toggles.Features
+	tenantService    tenant.Service
+	clusterView      cluster.View
+	config           configuration.Configuration
+	disabledUsers    *model.StringSet
+	disabledClusters *model.StringSet
+	userIdlers       *openshift.UserIdlerMap
+	supervisor       *openshift.Supervisor
 }
 
 // struct used to pass in cancelable task
@@ -51,13 +63,17 @@ type task struct {
 // NewIdler creates a new instance of Idler. The configuration as well as feature toggle handler needs to be passed.
 func NewIdler(features toggles.Features, tenantService tenant.Service, clusterView cluster.View,
 	config configuration.Configuration) *Idler {
+	disabledClusters := model.NewStringSet()
+	disabledClusters.Add(config.GetDisabledClusters())
+
 	return &Idler{
-		featureService: features,
-		tenantService:  tenantService,
-		clusterView:    clusterView,
-		config:         config,
-		disabledUsers:  model.NewStringSet(),
-		userIdlers:     openshift.NewUserIdlerMap(),
+		featureService:   features,
+		tenantService:    tenantService,
+		clusterView:      clusterView,
+		config:           config,
+		disabledUsers:    model.NewStringSet(),
+		disabledClusters: disabledClusters,
+		userIdlers:       openshift.NewUserIdlerMap(),
 	}
 }
 
@@ -66,99 +82,385 @@ func (idler *Idler) Run() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Register the metrics before any worker records them
+	api.Recorder.Initialize()
+
 	var wg sync.WaitGroup
 	t := &task{ctx, cancel, &wg}
 	setupSignalChannel(t)
 
-	idler.startWorkers(t, idler.config.GetDebugMode())
+	idler.startWorkers(t)
 	wg.Wait()
 	idlerLogger.Info("Idler successfully shut down.")
 }
 
-func (idler *Idler) startWorkers(t *task, addProfiler bool) {
+func (idler *Idler) startWorkers(t *task) {
 	idlerLogger.Info("Starting all Idler workers")
 
 	// Start the controllers to monitor the OpenShift clusters
 	idler.watchOpenshiftEvents(t)
 
-	// Start API router
+	// Evict the user-idlers of inactive namespaces
+	idler.evictInactiveUsers(t)
+
+	// Monitor the resource pressure of the clusters for adaptive idling
+	idler.monitorPressure(t)
+
+	// Measure the resource usage of the Jenkins instances for right-sizing them
+	footprint.New(idler.config, client.NewOpenShift(), idler.clusterView, clock.New()).Start(t.ctx, t.wg, idler.userIdlers)
+
+	// Call back the registered URLs, e.g. of the Jenkins Proxy, once Jenkins is running again
+	callback.Default.Start(t.ctx, t.wg, pidler.Events)
+
+	// Record the activity of the Idler for the digests and send them as scheduled
+	report.Default.Start(t.ctx, t.wg, pidler.Events, idler.userIdlers)
+
+	// Retry the events whose handling failed, e.g. due to a transient tenant lookup failure
+	dlq.Default.Start(t.ctx, t.wg)
+
+	// Monitor the expiry of the cluster tokens, failing readiness once one has expired. Readiness fails as well
+	// once the watches of all clusters are degraded.
+	expiryMonitor := cluster.NewExpiryMonitor(idler.clusterView,
+		time.Duration(idler.config.GetTokenExpiryWarning())*time.Minute, clock.New())
+	expiryMonitor.Start(t.ctx, t.wg)
+
+	// Check whether the Jenkins routes of the clusters resolve and serve TLS correctly, annotating the DNS view
+	if interval := idler.config.GetRouteCheckInterval(); interval > 0 {
+		cluster.NewRouteChecker(idler.clusterView, cluster.DefaultRouteVerifier,
+			time.Duration(interval)*time.Minute, clock.New()).Start(t.ctx, t.wg)
+	}
+
+	// Start API routers
 	go func() {
-		// Create and start a Router instance to serve the REST API
+		// Create and start the Router instances to serve the public and the admin REST API
 		idlerAPI := api.NewIdlerAPI(
 			idler.userIdlers,
 			idler.clusterView,
 			idler.tenantService,
-			idler.disabledUsers)
-		apirouter := router.CreateAPIRouter(idlerAPI)
-		router := router.NewRouter(apirouter)
-		router.AddMetrics(apirouter)
-		router.Start(t.ctx, t.wg, t.cancel)
-	}()
+			idler.featureService,
+			idler.disabledUsers,
+			idler.disabledClusters,
+			idler.config)
 
-	if addProfiler {
-		go func() {
-			idlerLogger.Infof("Starting profiler on port %d", profilerPort)
-			router := router.NewRouterWithPort(httprouter.New(), profilerPort)
-			router.Start(t.ctx, t.wg, t.cancel)
-		}()
-	}
+		apiRouter := router.CreateAPIRouter(idlerAPI, idler.config)
+		publicRouter := router.NewRouterWithAddress(apiRouter, idler.config.GetAPIAddress(),
+			router.WithServerLimits(idler.config))
+		publicRouter.AddMetrics(apiRouter)
+		publicRouter.AddReadiness(apiRouter, func() error {
+			if err := expiryMonitor.Ready(); err != nil {
+				return err
+			}
+			return cluster.DefaultHealth.Ready(idler.clusterView.GetClusters())
+		}, cluster.DefaultHealth.Degradations)
+		publicRouter.Start(t.ctx, t.wg, t.cancel)
+
+		adminRouter := router.NewRouterWithAddress(router.CreateAdminRouter(idlerAPI, idler.config), idler.config.GetAdminAPIAddress(),
+			router.WithServerLimits(idler.config))
+		adminRouter.Start(t.ctx, t.wg, t.cancel)
+
+		// Serve the API via gRPC as well, if enabled
+		if idler.config.GetGRPCAddress() != "" {
+			grpcServer, err := api.NewGRPCServer(idlerAPI, idler.config)
+			if err != nil {
+				log.WithField("err", err).Error("Unable to create the gRPC server")
+				t.cancel()
+				return
+			}
+			grpcServer.Start(t.ctx, t.wg, t.cancel)
+		}
+	}()
 }
 
 func (idler *Idler) watchOpenshiftEvents(t *task) {
-	oc := client.NewOpenShift()
+	oc := client.NewOpenShiftWithSelectors(client.WatchSelectors{
+		Builds: client.Selector{
+			Label: idler.config.GetBuildLabelSelector(),
+			Field: idler.config.GetBuildFieldSelector(),
+		},
+		DeploymentConfigs: client.Selector{
+			Label: idler.config.GetDCLabelSelector(),
+			Field: idler.config.GetDCFieldSelector(),
+		},
+		Pods: client.Selector{
+			Label: idler.config.GetPodLabelSelector(),
+			Field: idler.config.GetPodFieldSelector(),
+		},
+	})
+
+	idler.supervisor = openshift.NewSupervisor(t.ctx, t.wg, idler.userIdlers, idler.watchCluster(oc, t.wg), clock.New())
+	idler.supervisor.Sync(idler.clusterView.GetClusters())
+}
+
+// watchCluster returns the runner of the controller and the watches of a single cluster. Cancelling the context of
+// a cluster stops its watches and user idlers only. The runner returns once the watches ended, so that a restart of
+// the cluster does not run them twice.
+func (idler *Idler) watchCluster(oc client.OpenShiftClient, wg *sync.WaitGroup) openshift.ClusterRunner {
+	return func(ctx context.Context, cancel context.CancelFunc, c cluster.Cluster) {
+		ct := &task{ctx, cancel, wg}
 
-	for _, c := range idler.clusterView.GetClusters() {
 		// Create Controller
 		ctrl := openshift.NewController(
-			t.ctx,
+			ct.ctx,
 			c.APIURL,
 			c.Token,
 			idler.userIdlers,
 			idler.tenantService,
 			idler.featureService,
 			idler.config,
-			t.wg,
-			t.cancel,
+			ct.wg,
+			ct.cancel,
 			idler.disabledUsers,
+			idler.disabledClusters,
+			clock.New(),
 		)
 
-		t.wg.Add(2)
-		go idler.watchDC(t, oc, c, ctrl.HandleDeploymentConfig)
-		go idler.watchBC(t, oc, c, ctrl.HandleBuild)
+		idler.warmUp(oc, c, ctrl)
+
+		var watches sync.WaitGroup
+		wt := &task{ctx, cancel, &watches}
+		wt.wg.Add(3)
+		go idler.watchDC(wt, oc, c, guardDC(wt.ctx, retryDC(wt.ctx, c.APIURL, ctrl.HandleDeploymentConfig)))
+		go idler.watchBC(wt, oc, c, guardBC(wt.ctx, retryBC(wt.ctx, c.APIURL, ctrl.HandleBuild)))
+		go idler.watchPods(wt, oc, c, guardPod(wt.ctx, ctrl.HandlePod))
+		if idler.config.GetWatchIdlerConfigs() {
+			wt.wg.Add(1)
+			go idler.watchIdlerConfigs(wt, oc, c, guardIdlerConfig(wt.ctx, ctrl.HandleIdlerConfig))
+		}
+
+		<-wt.ctx.Done()
+		watches.Wait()
+	}
+}
+
+// warmUpItem is the warm-up of a single Jenkins namespace.
+type warmUpItem struct {
+	namespace string
+	warmUp    func() error
+}
+
+// warmUp creates the user-idlers of all Jenkins namespaces of the given cluster right away instead of waiting for
+// events concerning them, so that idling is enforced immediately after a restart. The user-idlers of namespaces with
+// a Jenkins deployment config are seeded with its current state. The namespaces are warmed up concurrently.
+func (idler *Idler) warmUp(oc client.OpenShiftClient, c cluster.Cluster, ctrl openshift.Controller) {
+	clusterLogger := idlerLogger.WithField("cluster", c.APIURL)
+	start := time.Now()
+
+	var items []warmUpItem
+	reconciled := make(map[string]bool)
+	dcs, err := oc.ListDeploymentConfigs(c.APIURL, c.Token, namespace.JenkinsSuffix)
+	if err != nil {
+		clusterLogger.WithField("err", err).Error("Unable to list deployment configs for startup warm-up")
+	}
+	for _, dc := range dcs {
+		dc := dc
+		reconciled[dc.Metadata.Namespace] = true
+		items = append(items, warmUpItem{dc.Metadata.Namespace, func() error { return ctrl.Reconcile(dc) }})
+	}
+
+	namespaces, err := oc.ListNamespaces(c.APIURL, c.Token, namespace.JenkinsSuffix)
+	if err != nil {
+		clusterLogger.WithField("err", err).Error("Unable to list Jenkins namespaces for startup warm-up")
+	}
+	for _, ns := range namespaces {
+		ns := ns
+		if !reconciled[ns] {
+			items = append(items, warmUpItem{ns, func() error { return ctrl.WarmUp(ns) }})
+		}
 	}
+
+	concurrency := idler.config.GetWarmUpConcurrency()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	queue := make(chan warmUpItem)
+	var failed int32
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				if err := recovery.Guard("controller", item.warmUp); err != nil {
+					clusterLogger.WithFields(log.Fields{"namespace": item.namespace, "err": err}).Warn("Unable to warm up namespace")
+					atomic.AddInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	for _, item := range items {
+		queue <- item
+	}
+	close(queue)
+	wg.Wait()
+
+	clusterLogger.WithField("duration", time.Since(start)).Infof("Warmed up %d of %d Jenkins namespaces, %d of them with deployment config.",
+		len(items)-int(failed), len(items), len(dcs))
 }
 
 type dcHandler func(model.DCObject) error
 type bcHandler func(model.Object) error
+type podHandler func(model.PodObject) error
+type idlerConfigHandler func(model.IdlerConfigObject) error
 
-func (idler *Idler) watchDC(t *task, oc client.OpenShiftClient, c cluster.Cluster, handler dcHandler) {
-	defer t.wg.Done()
-	go func() {
-		idlerLogger.Info("Starting to watch openshift deployment configuration changes.")
-		err := oc.WatchDeploymentConfigs(c.APIURL, c.Token, "-jenkins", handler)
+// guardDC recovers from panics during the handling of a deployment config event, so that a single malformed
+// event cannot take down the watch. Once the context is done, the watch is stopped instead.
+func guardDC(ctx context.Context, handler dcHandler) dcHandler {
+	return func(dc model.DCObject) error {
+		if ctx.Err() != nil {
+			return client.ErrStopWatch
+		}
+		return recovery.Guard("controller", func() error { return handler(dc) })
+	}
+}
+
+// guardBC recovers from panics during the handling of a build event, so that a single malformed
+// event cannot take down the watch. Once the context is done, the watch is stopped instead.
+func guardBC(ctx context.Context, handler bcHandler) bcHandler {
+	return func(build model.Object) error {
+		if ctx.Err() != nil {
+			return client.ErrStopWatch
+		}
+		return recovery.Guard("controller", func() error { return handler(build) })
+	}
+}
+
+// guardPod recovers from panics during the handling of a pod event, so that a single malformed
+// event cannot take down the watch. Once the context is done, the watch is stopped instead.
+func guardPod(ctx context.Context, handler podHandler) podHandler {
+	return func(pod model.PodObject) error {
+		if ctx.Err() != nil {
+			return client.ErrStopWatch
+		}
+		return recovery.Guard("controller", func() error { return handler(pod) })
+	}
+}
+
+// guardIdlerConfig recovers from panics during the handling of an idler config event, so that a single malformed
+// event cannot take down the watch. Once the context is done, the watch is stopped instead.
+func guardIdlerConfig(ctx context.Context, handler idlerConfigHandler) idlerConfigHandler {
+	return func(cfg model.IdlerConfigObject) error {
+		if ctx.Err() != nil {
+			return client.ErrStopWatch
+		}
+		return recovery.Guard("controller", func() error { return handler(cfg) })
+	}
+}
+
+// retryDC queues the deployment config events whose handling failed in the dead-letter queue, so that they are
+// retried with backoff instead of being dropped. Retries are discarded once the context is done.
+func retryDC(ctx context.Context, apiURL string, handler dcHandler) dcHandler {
+	return func(dc model.DCObject) error {
+		err := handler(dc)
 		if err != nil {
-			t.cancel()
+			meta := dc.Object.Metadata
+			dlq.Default.Add(apiURL, "deploymentconfigs", meta.Namespace, meta.Name, err, func() error {
+				if ctx.Err() != nil {
+					return dlq.ErrDiscard
+				}
+				return handler(dc)
+			})
 		}
-	}()
+		return err
+	}
+}
 
-	<-t.ctx.Done()
+// retryBC queues the build events whose handling failed in the dead-letter queue, so that they are retried with
+// backoff instead of being dropped. Retries are discarded once the context is done.
+func retryBC(ctx context.Context, apiURL string, handler bcHandler) bcHandler {
+	return func(build model.Object) error {
+		err := handler(build)
+		if err != nil {
+			meta := build.Object.Metadata
+			dlq.Default.Add(apiURL, "builds", meta.Namespace, meta.Name, err, func() error {
+				if ctx.Err() != nil {
+					return dlq.ErrDiscard
+				}
+				return handler(build)
+			})
+		}
+		return err
+	}
+}
+
+func (idler *Idler) watchDC(t *task, oc client.OpenShiftClient, c cluster.Cluster, handler dcHandler) {
+	defer t.wg.Done()
+	idlerLogger.Info("Starting to watch openshift deployment configuration changes.")
+	err := oc.WatchDeploymentConfigs(t.ctx, c.APIURL, c.Token, namespace.JenkinsSuffix, handler)
+	if err != nil {
+		t.cancel()
+	}
 	idlerLogger.Infof("Stopping to watch openshift deployment configuration changes.")
-	t.cancel()
 }
 
 func (idler *Idler) watchBC(t *task, oc client.OpenShiftClient, c cluster.Cluster, handler bcHandler) {
 	defer t.wg.Done()
+	idlerLogger.Info("Starting to watch openshift build configuration changes.")
+	err := oc.WatchBuilds(t.ctx, c.APIURL, c.Token, model.JenkinsPipelineStrategy, handler)
+	if err != nil {
+		t.cancel()
+	}
+	idlerLogger.Infof("Stopping to watch openshift build configuration changes.")
+}
+
+func (idler *Idler) watchPods(t *task, oc client.OpenShiftClient, c cluster.Cluster, handler podHandler) {
+	defer t.wg.Done()
+	idlerLogger.Info("Starting to watch openshift pod changes.")
+	err := oc.WatchPods(t.ctx, c.APIURL, c.Token, namespace.JenkinsSuffix, handler)
+	if err != nil {
+		t.cancel()
+	}
+	idlerLogger.Infof("Stopping to watch openshift pod changes.")
+}
+
+// watchIdlerConfigs watches the JenkinsIdlerConfig custom resources of the cluster. Unlike the other watches, giving
+// up on it, e.g. since the custom resource definition is not installed, leaves the cluster managed with the overrides
+// applied so far.
+func (idler *Idler) watchIdlerConfigs(t *task, oc client.OpenShiftClient, c cluster.Cluster, handler idlerConfigHandler) {
+	defer t.wg.Done()
+	idlerLogger.Info("Starting to watch openshift idler config changes.")
+	err := oc.WatchIdlerConfigs(t.ctx, c.APIURL, c.Token, namespace.JenkinsSuffix, handler)
+	if err != nil {
+		idlerLogger.WithField("cluster", c.APIURL).Errorf("Stopped watching idler configs: %s", err)
+		return
+	}
+	idlerLogger.Infof("Stopping to watch openshift idler config changes.")
+}
+
+// evictInactiveUsers periodically evicts the user-idlers of namespaces without any activity for the configured
+// number of days, so that the Idler does not accumulate every namespace it has ever seen.
+func (idler *Idler) evictInactiveUsers(t *task) {
+	days := idler.config.GetEvictInactiveAfter()
+	if days <= 0 {
+		idlerLogger.Info("Eviction of inactive user-idlers disabled.")
+		return
+	}
+	inactivity := time.Duration(days) * 24 * time.Hour
+
+	t.wg.Add(1)
 	go func() {
-		idlerLogger.Info("Starting to watch openshift build configuration changes.")
-		err := oc.WatchBuilds(c.APIURL, c.Token, "JenkinsPipeline", handler)
-		if err != nil {
-			t.cancel()
+		defer t.wg.Done()
+		ticker := time.NewTicker(evictionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				idlerLogger.Info("Stopping to evict inactive user-idlers.")
+				return
+			case <-ticker.C:
+				evicted := idler.userIdlers.EvictInactive(time.Now().Add(-inactivity))
+				idlerLogger.WithField("evicted", evicted).Infof("Evicted %d user-idlers inactive for %d days.", evicted, days)
+			}
 		}
 	}()
+}
 
-	<-t.ctx.Done()
-	idlerLogger.Infof("Stopping to watch openshift build configuration changes.")
-	t.cancel()
+// monitorPressure queries the resource pressure of all clusters, provided adaptive idling is enabled.
+func (idler *Idler) monitorPressure(t *task) {
+	var clusters []string
+	for _, c := range idler.clusterView.GetClusters() {
+		clusters = append(clusters, c.APIURL)
+	}
+	pressure.Default.Start(t.ctx, t.wg, clusters)
 }
 
 // setupSignalChannel registers a listener for Unix signals for a ordered shutdown
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
 	log "github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -17,10 +24,18 @@ import (
 type mockFeatureToggle struct {
 }
 
-func (m *mockFeatureToggle) IsIdlerEnabled(uid string) (bool, error) {
+func (m *mockFeatureToggle) IsIdlerEnabled(target toggles.Target) (bool, error) {
 	return true, nil
 }
 
+func (m *mockFeatureToggle) IsCheIdlerEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
+func (m *mockFeatureToggle) IsOOMLimitBumpEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
 type mockClusterView struct {
 	*mock.ClusterView
 }
@@ -60,6 +75,91 @@ func Test_graceful_shutdown(t *testing.T) {
 	assert.Contains(t, logMessages, "Idler successfully shut down.", "Idler shutdown completion should have been logged")
 }
 
+// flappingClient fails watching builds right away, while its other watches take a while to end once cancelled.
+type flappingClient struct {
+	mock.OpenShiftClient
+	watching int32
+}
+
+func (c *flappingClient) WatchBuilds(ctx context.Context, apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error {
+	return errors.New("token rejected watching builds")
+}
+
+func (c *flappingClient) WatchDeploymentConfigs(ctx context.Context, apiURL string, bearerToken string, nsSuffix string, callback func(model.DCObject) error) error {
+	return c.watch(ctx)
+}
+
+func (c *flappingClient) WatchPods(ctx context.Context, apiURL string, bearerToken string, nsSuffix string, callback func(model.PodObject) error) error {
+	return c.watch(ctx)
+}
+
+func (c *flappingClient) watch(ctx context.Context) error {
+	atomic.AddInt32(&c.watching, 1)
+	defer atomic.AddInt32(&c.watching, -1)
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	return nil
+}
+
+func Test_cluster_run_ends_with_its_watches(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	config, _ := configuration.New("")
+	idler := NewIdler(&mockFeatureToggle{}, &mock.TenantService{}, &mockClusterView{}, config)
+	oc := &flappingClient{}
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	idler.watchCluster(oc, wg)(ctx, cancel, cluster.Cluster{APIURL: "https://api.example.com/", Token: "abc"})
+	assert.Equal(t, int32(0), atomic.LoadInt32(&oc.watching), "The run should not end before its watches")
+	wg.Wait()
+}
+
+type warmUpController struct {
+	openshift.Controller
+	sync.Mutex
+	reconciled []string
+	warmedUp   []string
+}
+
+func (c *warmUpController) Reconcile(dc model.DeploymentConfig) error {
+	c.Lock()
+	defer c.Unlock()
+	c.reconciled = append(c.reconciled, dc.Metadata.Namespace)
+	return nil
+}
+
+func (c *warmUpController) WarmUp(namespace string) error {
+	c.Lock()
+	defer c.Unlock()
+	c.warmedUp = append(c.warmedUp, namespace)
+	if namespace == "broken-jenkins" {
+		return errors.New("tenant lookup failed")
+	}
+	return nil
+}
+
+func Test_warm_up(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	hook := test.NewGlobal()
+
+	oc := &mock.OpenShiftClient{
+		DCs: []model.DeploymentConfig{
+			{Metadata: model.Metadata{Namespace: "foo-jenkins"}},
+			{Metadata: model.Metadata{Namespace: "bar-jenkins"}},
+		},
+		Namespaces: []string{"foo-jenkins", "bar-jenkins", "baz-jenkins", "broken-jenkins"},
+	}
+	ctrl := &warmUpController{}
+	idler := &Idler{config: &mock.Config{WarmUpConcurrency: 2}}
+	idler.warmUp(oc, cluster.Cluster{APIURL: "https://api.example.com/"}, ctrl)
+
+	assert.ElementsMatch(t, []string{"foo-jenkins", "bar-jenkins"}, ctrl.reconciled, "Namespaces with deployment config should be reconciled")
+	assert.ElementsMatch(t, []string{"baz-jenkins", "broken-jenkins"}, ctrl.warmedUp, "Namespaces without deployment config should be warmed up")
+	assert.Contains(t, extractLogMessages(hook.AllEntries()), "Warmed up 3 of 4 Jenkins namespaces, 2 of them with deployment config.")
+}
+
 func extractLogMessages(entries []*log.Entry) []string {
 	var messages []string
 	for _, logEntry := range entries {
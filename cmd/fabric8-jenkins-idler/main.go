@@ -1,40 +1,39 @@
 package main
 
 import (
-	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"context"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/dlq"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/lock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/logging"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/notify"
 	openShiftClient "github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/pressure"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/report"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/token"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/version"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
 )
 
 var mainLogger = log.WithFields(log.Fields{"component": "main"})
 
 func init() {
-	log.SetFormatter(&log.JSONFormatter{})
-
-	level := log.InfoLevel
-	switch levelStr, _ := os.LookupEnv("JC_LOG_LEVEL"); levelStr {
-	case "info":
-		level = log.InfoLevel
-	case "debug":
-		level = log.DebugLevel
-	case "warning":
-		level = log.WarnLevel
-	case "error":
-		level = log.ErrorLevel
-	default:
-		level = log.InfoLevel
-	}
-	log.SetLevel(level)
+	log.SetFormatter(logging.NewFormatter(&log.JSONFormatter{}))
 }
 
 func main() {
@@ -42,11 +41,72 @@ func main() {
 
 	// Init configuration
 	config := createAndValidateConfiguration()
+	err := logging.Configure(config.GetLogFormat(), config.GetLogLevel(), config.GetLogComponentLevels())
+	if err != nil {
+		// Fatal with exit program
+		mainLogger.WithField("err", err).Fatal("Unable to configure logging")
+	}
 	mainLogger.Infof("Idler configuration: %s", config.String())
 
+	// Notify operators about notable events, if configured
+	notify.Default = notify.New(config, clock.New())
+
+	// Shorten the idle timeouts while clusters are under resource pressure, if enabled
+	pressure.Default = pressure.New(config, clock.New())
+
+	// Serialize the mutations of each Jenkins namespace across replicas and API clients, if enabled
+	lock.Default = lock.New(config, openShiftClient.NewOpenShift(), clock.New())
+
+	// Retry the events whose handling failed instead of dropping them, if enabled
+	dlq.Default = dlq.New(config, clock.New())
+
+	// Recommend memory limit increases for Jenkins instances which keep running out of memory, if enabled
+	remediation.DefaultAdvisor = remediation.NewAdvisor(config, clock.New())
+
+	// Restrict the callers of the public API presenting a scoped token to their namespaces resp. clusters, if configured
+	if path := config.GetAPIScopesFile(); path != "" {
+		scopes, err := scope.Load(path)
+		if err != nil {
+			// Fatal with exit program
+			mainLogger.WithField("err", err).Fatal("Unable to read API scopes")
+		}
+		scope.Default = scopes
+	}
+
+	// Limit the requests of each caller of the public API, if enabled
+	ratelimit.Default = ratelimit.New(config, clock.New())
+
+	// Send a daily or weekly digest of the activity of the Idler, if enabled
+	report.Default = report.New(config, clock.New())
+
+	// Map the Jenkins namespaces to their users according to the tenant layout
+	namespace.JenkinsSuffix = config.GetJenkinsNamespaceSuffix()
+
+	// Name the Jenkins routes according to the naming scheme of the clusters
+	cluster.RouteTemplate = config.GetRouteTemplate()
+	cluster.RouteSource = config.GetRouteSource()
+	cluster.DefaultRouteVerifier.Timeout = time.Duration(config.GetRouteCheckTimeout()) * time.Second
+
+	// Read the cluster tokens from a projected service account token volume, if configured
+	if dir := config.GetClusterTokenDir(); dir != "" {
+		token.Default = token.NewProjected(dir, clock.New())
+	}
+
 	// Get OSIO service account token from Auth
 	osioToken := osioToken(config)
 
+	// Exchange the cluster tokens with Auth on demand, if enabled and not read from a projected volume
+	if token.Default == nil && config.GetClusterTokenExchange() {
+		token.Default = token.NewExchanger(
+			token.NewResolve(config.GetAuthURL()),
+			token.NewPGPDecrypter(config.GetAuthTokenKey()),
+			osioToken,
+			func() (string, error) { return token.GetServiceAccountToken(config) },
+			time.Duration(config.GetClusterTokenTTL())*time.Minute,
+			clock.New(),
+		)
+	}
+
 	// Get the view over the clusters
 	clusterView := clusterView(osioToken, config)
 	mainLogger.Infof("Cluster view: %s", clusterView.String())
@@ -54,8 +114,11 @@ func main() {
 	// Create Toggle (Unleash) Service
 	featuresService := createFeatureToggle(config)
 
-	// Create Tenant Service
-	tenantService := tenant.NewTenantService(config.GetTenantURL(), osioToken)
+	// Create Tenant Service, indexing the tenants it resolves
+	var tenantService tenant.Service = tenant.NewIndexedService(createTenantService(config, osioToken, clusterView))
+	if ttl := config.GetCapacityCacheTTL(); ttl > 0 {
+		tenantService = tenant.NewCapacityCache(tenantService, time.Duration(ttl)*time.Second, clock.New())
+	}
 
 	idler := NewIdler(featuresService, tenantService, clusterView, config)
 	idler.Run()
@@ -64,25 +127,26 @@ func main() {
 func createAndValidateConfiguration() configuration.Configuration {
 	var configFilePath string
 	var printConfig bool
-	flag.StringVar(&configFilePath, "config", "", "Path to the config file to read")
-	flag.BoolVar(&printConfig, "printConfig", false, "Prints the config (including merged environment variables) and exits")
-	flag.Parse()
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	flags.StringVar(&configFilePath, "config", "", "Path to the config file to read")
+	flags.BoolVar(&printConfig, "printConfig", false, "Prints the effective config and the source of each setting and exits")
+	configuration.AddFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Each configuration flag overrides the environment variable given in its description.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
 
 	// Override default -config switch with environment variable only if -config switch was
 	// not explicitly given via the command line.
-	configSwitchIsSet := false
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "config" {
-			configSwitchIsSet = true
-		}
-	})
-	if !configSwitchIsSet {
+	if !flags.Changed("config") {
 		if envConfigPath, ok := os.LookupEnv("F8_CONFIG_FILE_PATH"); ok {
 			configFilePath = envConfigPath
 		}
 	}
 
-	config, err := configuration.New(configFilePath)
+	config, err := configuration.NewLayered(configFilePath, configuration.FlagValues(flags))
 	if err != nil {
 		log.Panic(nil, map[string]interface{}{
 			"config_file_path": configFilePath,
@@ -91,6 +155,9 @@ func createAndValidateConfiguration() configuration.Configuration {
 	}
 
 	if printConfig {
+		for _, setting := range config.Effective() {
+			fmt.Printf("%s=%v (%s)\n", setting.Key, setting.Value, setting.Source)
+		}
 		os.Exit(0)
 	}
 
@@ -105,15 +172,13 @@ func createAndValidateConfiguration() configuration.Configuration {
 }
 
 func createFeatureToggle(config configuration.Configuration) toggles.Features {
-	var err error
-	var features toggles.Features
-	if len(config.GetFixedUuids()) > 0 {
+	provider := toggles.ProviderName(config)
+	if provider == toggles.StaticProvider {
 		mainLogger.Warnf("Using fixed UUID list for toggle feature: %s", config.GetFixedUuids())
-		features, err = toggles.NewFixedUUIDToggle(config.GetFixedUuids())
 	} else {
-		mainLogger.Warn("Using feature toggle through unleash client")
-		features, err = toggles.NewUnleashToggle(config.GetToggleURL())
+		mainLogger.Warnf("Using feature toggle through %s provider", provider)
 	}
+	features, err := toggles.New(config)
 	if err != nil {
 		// Fatal with exit program
 		mainLogger.WithField("err", err).Fatal("Unable to create feature toggles")
@@ -121,12 +186,33 @@ func createFeatureToggle(config configuration.Configuration) toggles.Features {
 	return features
 }
 
+func createTenantService(config configuration.Configuration, osioToken string, clusterView cluster.View) tenant.Service {
+	switch config.GetTenantBackend() {
+	case tenant.FileBackend:
+		mainLogger.Infof("Using tenants listed in %s", config.GetTenantFile())
+		tenantService, err := tenant.NewFileService(config.GetTenantFile())
+		if err != nil {
+			// Fatal with exit program
+			mainLogger.WithField("err", err).Fatal("Unable to read tenants")
+		}
+		return tenantService
+	case tenant.KubernetesBackend:
+		mainLogger.Infof("Using tenants labeled with %s", config.GetTenantUserLabel())
+		return tenant.NewNamespaceLabelService(openShiftClient.NewOpenShift(), clusterView.GetToken, config.GetTenantUserLabel())
+	default:
+		return tenant.NewTenantService(config.GetTenantURL(), osioToken, config.GetTenantMaxPages())
+	}
+}
+
 func osioToken(config configuration.Configuration) string {
 	osioToken, err := token.GetServiceAccountToken(config)
 	if err != nil {
+		notify.Default.Notify(notify.Event{Class: notify.TokenFailure, Message: fmt.Sprintf("Unable to retrieve service account token: %s", err)})
+		notify.Default.Wait()
 		// Fatal with exit program
 		mainLogger.WithField("err", err).Fatal("Unable to retrieve service account token")
 	}
+	redact.Register(osioToken)
 	return osioToken
 }
 
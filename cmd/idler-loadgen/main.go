@@ -0,0 +1,75 @@
+// Command idler-loadgen replays synthetic build and deployment config event streams against the controller of the
+// Idler, backed by an in-memory tenant service and a fake OpenShift API, and reports the event throughput, the
+// decision latency and the memory use. It is meant to catch performance regressions before they hit production
+// clusters, e.g.
+//
+//	idler-loadgen --namespaces 5000 --rate 2000 --duration 1m
+//
+// The configuration flags of the Idler are accepted as well, so that the controller can be tuned as in production.
+// Only errors are logged unless --log-level is given.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/loadgen"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	var opts loadgen.Options
+	var configFilePath string
+	var asJSON bool
+
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	flags.IntVar(&opts.Namespaces, "namespaces", 1000, "number of synthetic user namespaces the events are spread across")
+	flags.IntVar(&opts.Rate, "rate", 500, "events per second replayed against the controller, 0 as fast as possible")
+	flags.IntVar(&opts.Events, "events", 0, "number of events to replay, 0 for no limit")
+	flags.DurationVar(&opts.Duration, "duration", 30*time.Second, "duration of the run, 0 for no limit")
+	flags.IntVar(&opts.Workers, "workers", 8, "number of goroutines handing the events to the controller")
+	flags.Float64Var(&opts.DCRatio, "dc-ratio", 0.3, "fraction (0.0 - 1.0) of the events being deployment config events")
+	flags.StringVar(&configFilePath, "config", "", "Path to the config file of the Idler to read")
+	flags.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	configuration.AddFlags(flags)
+	flags.Parse(os.Args[1:])
+
+	config, err := configuration.NewLayered(configFilePath, configuration.FlagValues(flags))
+	if err != nil {
+		log.WithField("err", err).Fatal("Unable to read the configuration")
+	}
+	// the per event logging of the Idler would dominate the run unless asked for
+	level := log.ErrorLevel
+	if flags.Changed(configuration.FlagName("JC_LOG_LEVEL")) {
+		if level, err = log.ParseLevel(config.GetLogLevel()); err != nil {
+			log.WithField("err", err).Fatal("Invalid log level")
+		}
+	}
+	log.SetLevel(level)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	report, err := loadgen.Run(ctx, config, opts)
+	if err != nil {
+		log.WithField("err", err).Fatal("Load generation failed")
+	}
+
+	if asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+		return
+	}
+	fmt.Println(report)
+}
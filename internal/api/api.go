@@ -5,15 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
 	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/logging"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/lock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	pnamespace "github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/notify"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/pressure"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/report"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/version"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
 	"github.com/julienschmidt/httprouter"
@@ -22,8 +35,24 @@ import (
 
 const (
 	// OpenShiftAPIParam is the parameter name under which the OpenShift cluster API URL is passed using
-	// Idle, UnIdle and IsIdle.
+	// Idle, UnIdle and IsIdle. It is optional for the namespaces whose cluster is known to the Idler.
 	OpenShiftAPIParam = "openshift_api_url"
+
+	// ServiceParam is the parameter name under which the service to reset is passed, jenkins by default.
+	ServiceParam = "service"
+
+	// StrategyParam is the parameter name under which the reset strategy is passed, ResetStrategyDelete by default.
+	StrategyParam = "strategy"
+
+	// TimeoutParam is the parameter name under which the number of seconds to wait for the replacement pods of a reset
+	// is passed.
+	TimeoutParam = "timeout"
+
+	// ResetStrategyDelete resets a service by deleting its pods.
+	ResetStrategyDelete = "delete"
+
+	// ResetStrategyRollout resets a service by triggering a new rollout of its deployment config.
+	ResetStrategyRollout = "rollout"
 )
 
 var (
@@ -52,10 +81,34 @@ type IdlerAPI interface {
 	// If an error occurs a response with the HTTP status 400 or 500 is returned.
 	Status(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 
+	// EventStream streams the state changes of the Jenkins instances of a single resp. all namespaces as
+	// Server-Sent Events.
+	EventStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Reserve un-idles Jenkins of the namespace and keeps it from being idled until the returned reservation
+	// expires or gets released.
+	Reserve(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Reservation tells whether the reservation is valid and Jenkins is running, i.e. whether it is safe to replay
+	// buffered webhooks.
+	Reservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// ReleaseReservation releases a reservation before it expires.
+	ReleaseReservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// RegisterCallback registers a URL which gets called back once Jenkins of the namespace is running again.
+	RegisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// UnregisterCallback removes the callback registered for the namespace.
+	UnregisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
 	// ClusterDNSView writes a JSON representation of the current cluster state to the response writer.
 	ClusterDNSView(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 
-	// Reset deletes a pod and starts a new one
+	// JenkinsRoute writes the URL of the Jenkins route of the namespace to the response writer.
+	JenkinsRoute(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Reset deletes the pods of a service or triggers a new rollout of its deployment config
 	Reset(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 
 	// SetUserIdlerStatus set users status for idler.
@@ -63,14 +116,67 @@ type IdlerAPI interface {
 
 	// GetDisabledUserIdlers gets the user status for idler.
 	GetDisabledUserIdlers(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// SetClusterStatus enables resp. disables idling for whole clusters.
+	SetClusterStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// GetDisabledClusters writes the API URLs of the clusters for which idling is disabled to the response writer.
+	GetDisabledClusters(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// JenkinsVersions writes the Jenkins versions last observed per namespace to the response writer.
+	JenkinsVersions(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// DeadLetters writes the events whose handling failed to the response writer.
+	DeadLetters(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// MemoryRecommendations writes the recommended memory limit increases to the response writer.
+	MemoryRecommendations(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// RateLimits writes the rate limit usage of the callers of the public API to the response writer.
+	RateLimits(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Faults writes the fault injection rules to the response writer.
+	Faults(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// SetFault adds a fault injection rule.
+	SetFault(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// ClearFaults removes fault injection rules.
+	ClearFaults(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// AggregateStatus writes the number of Jenkins instances per state and cluster to the response writer.
+	AggregateStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// IdlerTimers writes the countdown until Jenkins gets idled, the last idler decision and the resource footprint
+	// per namespace to the response writer.
+	IdlerTimers(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// LogLevel writes the global log level as well as the per component overrides to the response writer.
+	LogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// SetLogLevel changes the global log level or the log level of a single component.
+	SetLogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Version writes the build and runtime information of the Idler to the response writer.
+	Version(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// Toggles writes the feature toggle definitions currently in use to the response writer.
+	Toggles(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
+
+	// EffectiveConfig writes the effective configuration with secrets masked to the response writer.
+	EffectiveConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 }
 
 type idler struct {
-	userIdlers      *openshift.UserIdlerMap
-	clusterView     cluster.View
-	openShiftClient client.OpenShiftClient
-	tenantService   tenant.Service
-	disabledUsers   *model.StringSet
+	userIdlers       *openshift.UserIdlerMap
+	clusterView      cluster.View
+	openShiftClient  client.OpenShiftClient
+	tenantService    tenant.Service
+	features         toggles.Features
+	disabledUsers    *model.StringSet
+	disabledClusters *model.StringSet
+	config           configuration.Configuration
+	startTime        time.Time
 }
 
 type status struct {
@@ -82,107 +188,212 @@ type userStatus struct {
 	Enable  []string `json:"enable"`
 }
 
+type clusterStatus struct {
+	Disable []string `json:"disable"`
+	Enable  []string `json:"enable"`
+}
+
+type disabledClustersResponse struct {
+	Clusters []string `json:"clusters"`
+}
+
+type logLevelResponse struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+type logLevelRequest struct {
+	Level     string `json:"level"`
+	Component string `json:"component,omitempty"`
+}
+
+type versionResponse struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	BuildTime     string `json:"build_time"`
+	GoVersion     string `json:"go_version"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	TrackedUsers  int    `json:"tracked_users"`
+	Profile       string `json:"profile"`
+}
+
 // NewIdlerAPI creates a new instance of IdlerAPI.
 func NewIdlerAPI(
 	userIdlers *openshift.UserIdlerMap,
 	clusterView cluster.View,
 	ts tenant.Service,
-	du *model.StringSet) IdlerAPI {
+	features toggles.Features,
+	du *model.StringSet,
+	dc *model.StringSet,
+	config configuration.Configuration) IdlerAPI {
 	// Initialize metrics
 	Recorder.Initialize()
 	return &idler{
-		userIdlers:      userIdlers,
-		clusterView:     clusterView,
-		openShiftClient: client.NewOpenShift(),
-		tenantService:   ts,
-		disabledUsers:   du,
+		userIdlers:       userIdlers,
+		clusterView:      clusterView,
+		openShiftClient:  client.NewOpenShift(),
+		tenantService:    ts,
+		features:         features,
+		disabledUsers:    du,
+		disabledClusters: dc,
+		config:           config,
+		startTime:        time.Now(),
 	}
 }
 
 func (api *idler) Idle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r)
+	openShiftAPI, err := api.getURL(r, ps.ByName("namespace"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err)
+		respondWithError(w, errorStatus(err), err)
 		return
 	}
 
-	for _, service := range pidler.JenkinsServices {
+	results, err := api.idle(openShiftAPI, ps.ByName("namespace"))
+	if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+	respondWithServiceResults(w, results)
+}
+
+// idle idles the Jenkins services of the namespace. Refusals are reported as error, whereas the failures of single
+// services are reported by the returned results.
+func (api *idler) idle(openShiftAPI, namespace string) (pidler.ServiceResults, error) {
+	openShiftBearerToken, err := api.getToken(openShiftAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	if api.clusterDisabled(openShiftAPI) {
+		return nil, withStatus(http.StatusServiceUnavailable, fmt.Errorf("Idling is disabled for %s", openShiftAPI))
+	}
+
+	if api.config != nil && pidler.UnidleOnly(api.config, openShiftAPI) {
+		return nil, withStatus(http.StatusServiceUnavailable, fmt.Errorf("%s is in unidle-only mode", openShiftAPI))
+	}
+
+	held, err := lockNamespace(openShiftAPI, openShiftBearerToken, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer held.Unlock()
+
+	return pidler.IdleServices(pidler.JenkinsServices, func(service string) error {
 		startTime := time.Now()
-		err = api.openShiftClient.Idle(openShiftAPI, openShiftBearerToken, ps.ByName("namespace"), service)
+		err := api.openShiftClient.Idle(openShiftAPI, openShiftBearerToken, namespace, service)
 		elapsedTime := time.Since(startTime).Seconds()
 
 		if err != nil {
 			Recorder.RecordReqDuration(service, "Idle", http.StatusInternalServerError, elapsedTime)
-			respondWithError(w, http.StatusInternalServerError, err)
-			return
+			return err
 		}
 
 		Recorder.RecordReqDuration(service, "Idle", http.StatusOK, elapsedTime)
-	}
-
-	w.WriteHeader(http.StatusOK)
+		pidler.RecordProvenance(api.openShiftClient, openShiftAPI, openShiftBearerToken, namespace, service, model.Provenance{
+			Action:      model.IdleAction,
+			TriggeredBy: model.TriggeredByAPI,
+			Reason:      "idle requested via API",
+			Timestamp:   time.Now(),
+		})
+		return nil
+	}), nil
 }
 
 func (api *idler) UnIdle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-
-	openshiftURL, openshiftToken, err := api.getURLAndToken(r)
+	openshiftURL, err := api.getURL(r, ps.ByName("namespace"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err)
+		respondWithError(w, errorStatus(err), err)
 		return
 	}
 
-	ns := strings.TrimSpace(ps.ByName("namespace"))
-	if ns == "" {
-		err = errors.New("Missing mandatory param namespace")
-		respondWithError(w, http.StatusBadRequest, err)
+	results, err := api.unIdle(openshiftURL, ps.ByName("namespace"))
+	if ce, ok := asCapacityError(err); ok {
+		api.respondWithCapacityError(w, ce)
+		return
+	} else if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	} else if results == nil {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
+	respondWithServiceResults(w, results)
+}
+
+// unIdle un-idles the Jenkins services of the namespace. No results are returned if Jenkins is already starting
+// or running.
+func (api *idler) unIdle(openshiftURL, namespace string) (pidler.ServiceResults, error) {
+	openshiftToken, err := api.getToken(openshiftURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := strings.TrimSpace(namespace)
+	if ns == "" {
+		return nil, withStatus(http.StatusBadRequest, errors.New("Missing mandatory param namespace"))
+	}
+
+	if api.clusterDisabled(openshiftURL) {
+		return nil, withStatus(http.StatusServiceUnavailable, fmt.Errorf("Idling is disabled for %s", openshiftURL))
+	}
+
+	held, err := lockNamespace(openshiftURL, openshiftToken, ns)
+	if err != nil {
+		return nil, err
+	}
+	defer held.Unlock()
 
 	// may be jenkins is already running and in that case we don't have to do unidle it
 	running, err := api.isJenkinsUnIdled(openshiftURL, openshiftToken, ns)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err)
-		return
+		return nil, err
 	} else if running {
 		log.Infof("Jenkins is already starting/running on %s", ns)
-		w.WriteHeader(http.StatusOK)
-		return
+		return nil, nil
 	}
 
 	// now that jenkins isn't running we need to check if the cluster has reached
 	// its maximum capacity
 	clusterFull, err := api.tenantService.HasReachedMaxCapacity(openshiftURL, ns)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err)
-		return
+		return nil, err
 	} else if clusterFull {
-		err := fmt.Errorf("Maximum Resource limit reached on %s for %s", openshiftURL, ns)
-		respondWithError(w, http.StatusServiceUnavailable, err)
-		return
+		notify.Default.CapacityRefused(openshiftURL)
+		report.Default.CapacityRefused()
+		pressure.Default.Signal(openshiftURL, "un-idle refused due to the cluster capacity")
+		return nil, withStatus(http.StatusServiceUnavailable, capacityError{
+			err:         fmt.Errorf("Maximum Resource limit reached on %s for %s", openshiftURL, ns),
+			cluster:     openshiftURL,
+			utilization: api.utilization(openshiftURL),
+		})
 	}
 
 	// unidle now
-	for _, service := range pidler.JenkinsServices {
+	return pidler.UnIdleServices(pidler.JenkinsServices, func(service string) error {
 		startTime := time.Now()
 
-		err = api.openShiftClient.UnIdle(openshiftURL, openshiftToken, ns, service)
+		err := api.openShiftClient.UnIdle(openshiftURL, openshiftToken, ns, service)
 		elapsedTime := time.Since(startTime).Seconds()
 		if err != nil {
 			Recorder.RecordReqDuration(service, "UnIdle", http.StatusInternalServerError, elapsedTime)
-			respondWithError(w, http.StatusInternalServerError, err)
-			return
+			return err
 		}
 
 		Recorder.RecordReqDuration(service, "UnIdle", http.StatusOK, elapsedTime)
-	}
-
-	w.WriteHeader(http.StatusOK)
+		pidler.RecordProvenance(api.openShiftClient, openshiftURL, openshiftToken, ns, service, model.Provenance{
+			Action:      model.UnidleAction,
+			TriggeredBy: model.TriggeredByAPI,
+			Reason:      "un-idle requested via API, e.g. by a user accessing Jenkins",
+			Timestamp:   time.Now(),
+		})
+		return nil
+	}), nil
 }
 
 func (api *idler) IsIdle(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r)
+	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r, ps.ByName("namespace"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err)
+		respondWithError(w, errorStatus(err), err)
 		return
 	}
 
@@ -194,59 +405,147 @@ func (api *idler) IsIdle(w http.ResponseWriter, r *http.Request, ps httprouter.P
 
 	s := status{}
 	s.IsIdle = state < model.PodRunning
-	writeResponse(w, http.StatusOK, s)
+	writeNegotiatedResponse(w, r, http.StatusOK, s)
 }
 
 func (api *idler) Status(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	openshiftURL, err := api.getURL(r, ps.ByName("namespace"))
+	if err != nil {
+		response := &statusResponse{}
+		if errorStatus(err) == http.StatusForbidden {
+			response.AppendError(outOfScope, err.Error())
+		} else {
+			response.AppendError(tokenFetchFailed, "failed to obtain openshift token: "+err.Error())
+		}
+		writeNegotiatedResponse(w, r, errorStatus(err), *response)
+		return
+	}
+
+	response, status := api.status(openshiftURL, ps.ByName("namespace"))
+	writeNegotiatedResponse(w, r, status, *response)
+}
+
+// status determines the state of Jenkins in the namespace along with the HTTP status to report it with.
+func (api *idler) status(openshiftURL, namespace string) (*statusResponse, int) {
 	response := &statusResponse{}
 
-	openshiftURL, openshiftToken, err := api.getURLAndToken(r)
+	openshiftToken, err := api.getToken(openshiftURL)
 	if err != nil {
 		response.AppendError(tokenFetchFailed, "failed to obtain openshift token: "+err.Error())
-		writeResponse(w, http.StatusBadRequest, *response)
-		return
+		return response, http.StatusBadRequest
 	}
 
-	state, err := api.openShiftClient.State(
-		openshiftURL, openshiftToken,
-		ps.ByName("namespace"),
-		"jenkins",
-	)
+	state, err := api.jenkinsState(openshiftURL, openshiftToken, namespace)
 	if err != nil {
 		response.AppendError(openShiftClientError, "openshift client error: "+err.Error())
-		writeResponse(w, http.StatusInternalServerError, *response)
-		return
+		return response, http.StatusInternalServerError
 	}
 
 	response.SetState(state)
-	writeResponse(w, http.StatusOK, *response)
+	if failure, ok := podFailures[state]; ok {
+		response.AppendError(failure.code, failure.description)
+	}
+	if userIdler, ok := api.userIdlerOf(namespace); ok {
+		user := userIdler.GetUser()
+		response.SetIdleDuration(user, time.Now())
+		response.SetRestarts(user.Pod.Restarts)
+		response.SetJenkinsVersion(userIdler.JenkinsVersion())
+		if readyIn, ok := userIdler.EstimatedReadyIn(); ok {
+			response.SetEstimatedReadyIn(readyIn)
+		}
+		if idleIn, ok := userIdler.IdleIn(); ok {
+			response.SetIdleIn(idleIn)
+		}
+		response.SetTimeouts(userIdler.IdleAfter(), userIdler.CheckInterval())
+		response.SetIdlingDisabled(api.idlingDisabled(openshiftURL, user))
+		response.SetLastBuild(user)
+		response.SetLastIdlerAction(user.IdleStatus)
+		if lastRequest, ok := userIdler.ContentRepositoryActivity(); ok {
+			response.SetContentRepositoryActivity(lastRequest)
+		}
+	}
+	return response, http.StatusOK
 }
 
 func (api *idler) ClusterDNSView(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	writeResponse(w, http.StatusOK, api.clusterView.GetDNSView())
+	writeNegotiatedResponse(w, r, http.StatusOK, api.clusterView.GetDNSView())
 }
 
 func (api *idler) Reset(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-
-	logger := log.WithFields(log.Fields{"component": "api", "function": "Reset"})
-
-	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r)
+	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r, ps.ByName("namespace"))
 	if err != nil {
-		logger.Error(err)
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(fmt.Sprintf("{\"error\": \"%s\"}", err)))
+		respondWithError(w, errorStatus(err), err)
 		return
 	}
 
-	err = api.openShiftClient.Reset(openShiftAPI, openShiftBearerToken, ps.ByName("namespace"))
+	service := r.URL.Query().Get(ServiceParam)
+	if service == "" {
+		service = "jenkins"
+	}
+
+	held, err := lockNamespace(openShiftAPI, openShiftBearerToken, ps.ByName("namespace"))
 	if err != nil {
-		logger.Error(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(fmt.Sprintf("{\"error\": \"%s\"}", err)))
+		respondWithError(w, errorStatus(err), err)
 		return
 	}
+	defer held.Unlock()
 
-	w.WriteHeader(http.StatusOK)
+	switch strategy := r.URL.Query().Get(StrategyParam); strategy {
+	case "", ResetStrategyDelete:
+		options, err := api.resetOptions(r)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = api.openShiftClient.Reset(openShiftAPI, openShiftBearerToken, ps.ByName("namespace"), service, options)
+		switch err.(type) {
+		case nil:
+			w.WriteHeader(http.StatusOK)
+		case *client.DisruptionError:
+			respondWithError(w, http.StatusConflict, err)
+		case *client.ReplacementTimeoutError:
+			respondWithError(w, http.StatusGatewayTimeout, err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, err)
+		}
+	case ResetStrategyRollout:
+		rollout, err := api.openShiftClient.Rollout(openShiftAPI, openShiftBearerToken, ps.ByName("namespace"), service)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeResponse(w, http.StatusOK, resetResponse{Rollout: rollout})
+	default:
+		respondWithError(w, http.StatusBadRequest, fmt.Errorf("unknown reset strategy %q", strategy))
+	}
+}
+
+// resetOptions returns the options of a reset deleting pods, waiting for the replacement pods as long as requested
+// by the timeout parameter or else configured. As the reset holds the lock of the namespace, it waits for at most
+// lock.MaxHold if locking is enabled.
+func (api *idler) resetOptions(r *http.Request) (client.ResetOptions, error) {
+	options := client.ResetOptions{
+		GracePeriod: time.Duration(api.config.GetResetGracePeriod()) * time.Second,
+		Timeout:     time.Duration(api.config.GetResetTimeout()) * time.Second,
+	}
+	if value := r.URL.Query().Get(TimeoutParam); value != "" {
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout < 0 {
+			return options, fmt.Errorf("%s needs to be a non-negative number of seconds", TimeoutParam)
+		}
+		options.Timeout = time.Duration(timeout) * time.Second
+	}
+	if lock.Default != nil && options.Timeout > lock.MaxHold {
+		options.Timeout = lock.MaxHold
+	}
+	return options, nil
+}
+
+// resetResponse names the rollout triggered by a reset using the rollout strategy.
+type resetResponse struct {
+	Rollout string `json:"rollout"`
 }
 
 //SetUserIdlerStatus sets the user status
@@ -270,26 +569,333 @@ type idlerStatusResponse struct {
 //GetDisabledUserIdlers set the user status
 func (api *idler) GetDisabledUserIdlers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	users := &idlerStatusResponse{Users: api.disabledUsers.Keys()}
-	writeResponse(w, http.StatusOK, users)
+	writeNegotiatedResponse(w, r, http.StatusOK, users)
+}
+
+// jenkinsVersionsResponse maps the namespaces to the Jenkins version last observed in them.
+type jenkinsVersionsResponse struct {
+	Versions map[string]string `json:"versions"`
+}
+
+// JenkinsVersions writes the Jenkins versions last observed per namespace. Namespaces whose Jenkins version was not
+// observed yet are omitted.
+func (api *idler) JenkinsVersions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	response := jenkinsVersionsResponse{Versions: map[string]string{}}
+	api.userIdlers.Range(func(namespace string, userIdler *pidler.UserIdler) bool {
+		if version := userIdler.JenkinsVersion(); version != "" {
+			response.Versions[namespace] = version
+		}
+		return true
+	})
+	writeNegotiatedResponse(w, r, http.StatusOK, response)
+}
+
+// aggregateStatusResponse counts the Jenkins instances per state, keyed against the API URL of their cluster resp. in
+// total.
+type aggregateStatusResponse struct {
+	Clusters map[string]map[string]int `json:"clusters"`
+	Total    map[string]int            `json:"total"`
+}
+
+// aggregateStates are the states counted by AggregateStatus, as published to clients.
+var aggregateStates = []pidler.State{
+	pidler.StateIdled, pidler.StateIdling, pidler.StateUnIdling, pidler.StateRunning, pidler.StateError, pidler.StateUnknown,
+}
+
+// AggregateStatus writes the number of Jenkins instances per state and cluster, as tracked by the user idlers fed
+// by the watches. All known clusters are listed, even if they host no Jenkins instance yet.
+func (api *idler) AggregateStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	counts := func() map[string]int {
+		c := make(map[string]int, len(aggregateStates))
+		for _, state := range aggregateStates {
+			c[pidler.PublicState(state)] = 0
+		}
+		return c
+	}
+
+	response := aggregateStatusResponse{Clusters: map[string]map[string]int{}, Total: counts()}
+	for _, c := range api.clusterView.GetClusters() {
+		response.Clusters[c.APIURL] = counts()
+	}
+	api.userIdlers.Range(func(namespace string, userIdler *pidler.UserIdler) bool {
+		cluster := userIdler.OpenShiftAPI()
+		if _, ok := response.Clusters[cluster]; !ok {
+			response.Clusters[cluster] = counts()
+		}
+		state := pidler.PublicState(userIdler.State())
+		response.Clusters[cluster][state]++
+		response.Total[state]++
+		return true
+	})
+	writeNegotiatedResponse(w, r, http.StatusOK, response)
+}
+
+// idlerTimer is the countdown of the user idler of a namespace until Jenkins gets idled, along with the last idle
+// resp. un-idle decision and the resources Jenkins requests, is limited to and uses, if known.
+type idlerTimer struct {
+	Cluster        string           `json:"cluster"`
+	State          string           `json:"state"`
+	IdleInSeconds  *int64           `json:"idle_in_seconds,omitempty"`
+	IdleAt         *time.Time       `json:"idle_at,omitempty"`
+	IdleAfter      int64            `json:"idle_after_seconds"`
+	CheckInterval  int64            `json:"check_interval_seconds"`
+	IdlingDisabled bool             `json:"idling_disabled"`
+	LastActivity   *time.Time       `json:"last_activity,omitempty"`
+	LastDecision   *idlerInfo       `json:"last_decision,omitempty"`
+	Resources      *model.Resources `json:"resources,omitempty"`
+	Usage          *model.Usage     `json:"usage,omitempty"`
+}
+
+// idlerTimersResponse maps the namespaces to the timers of their user idlers as of the given time.
+type idlerTimersResponse struct {
+	Time   time.Time             `json:"time"`
+	Idlers map[string]idlerTimer `json:"idlers"`
+}
+
+// IdlerTimers writes the countdown until Jenkins gets idled as well as the last idle resp. un-idle decision of each
+// user idler, e.g. for the proxy to tell users when their Jenkins is going to be idled. The countdown is only given
+// for running Jenkins instances without active builds. Callers restricted to a scope only get the user idlers in scope.
+func (api *idler) IdlerTimers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	now := time.Now().UTC()
+	response := idlerTimersResponse{Time: now, Idlers: map[string]idlerTimer{}}
+	api.userIdlers.Range(func(namespace string, userIdler *pidler.UserIdler) bool {
+		if authorize(r.Context(), namespace, userIdler.OpenShiftAPI()) != nil {
+			return true
+		}
+		user := userIdler.GetUser()
+		timer := idlerTimer{
+			Cluster:        userIdler.OpenShiftAPI(),
+			State:          pidler.PublicState(userIdler.State()),
+			IdleAfter:      int64(userIdler.IdleAfter().Seconds()),
+			CheckInterval:  int64(userIdler.CheckInterval().Seconds()),
+			IdlingDisabled: api.idlingDisabled(userIdler.OpenShiftAPI(), user),
+		}
+		if idleIn, ok := userIdler.IdleIn(); ok && userIdler.State() == pidler.StateRunning && !timer.IdlingDisabled {
+			seconds := int64(idleIn.Seconds())
+			idleAt := now.Add(idleIn)
+			timer.IdleInSeconds, timer.IdleAt = &seconds, &idleAt
+		}
+		if !user.JenkinsLastUpdate.IsZero() {
+			lastActivity := user.JenkinsLastUpdate.UTC()
+			timer.LastActivity = &lastActivity
+		}
+		if status := user.IdleStatus; !status.Timestamp.IsZero() {
+			timer.LastDecision = &idlerInfo{
+				Action:    status.Action,
+				Timestamp: status.Timestamp.UTC(),
+				Success:   status.Success,
+				Reason:    status.Reason,
+			}
+		}
+		if resources, ok := userIdler.Resources(); ok {
+			timer.Resources = &resources
+		}
+		if usage, ok := userIdler.Usage(); ok {
+			timer.Usage = &usage
+		}
+		response.Idlers[namespace] = timer
+		return true
+	})
+	writeNegotiatedResponse(w, r, http.StatusOK, response)
+}
+
+// SetClusterStatus enables resp. disables idling for the given clusters. Enabled clusters take precedence over
+// disabled ones.
+func (api *idler) SetClusterStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var clusters clusterStatus
+	if err := json.NewDecoder(r.Body).Decode(&clusters); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, apiURL := range append(clusters.Disable, clusters.Enable...) {
+		if _, ok := api.clusterView.GetToken(apiURL); !ok {
+			respondWithError(w, http.StatusBadRequest, fmt.Errorf("Unknown or invalid OpenShift API URL: %s", apiURL))
+			return
+		}
+	}
+
+	for _, apiURL := range clusters.Disable {
+		if !api.disabledClusters.Has(apiURL) && !util.Contains(clusters.Enable, apiURL) {
+			notify.Default.Notify(notify.Event{Class: notify.ClusterDrained, Cluster: apiURL, Message: fmt.Sprintf("Idling got disabled for %s", apiURL)})
+		}
+	}
+	api.disabledClusters.Add(clusters.Disable)
+	api.disabledClusters.Remove(clusters.Enable)
+	log.WithFields(log.Fields{"disabled": clusters.Disable, "enabled": clusters.Enable}).Info("Cluster status changed.")
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetDisabledClusters writes the API URLs of the clusters for which idling is disabled.
+func (api *idler) GetDisabledClusters(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeNegotiatedResponse(w, r, http.StatusOK, disabledClustersResponse{Clusters: api.disabledClusters.Keys()})
+}
+
+// clusterDisabled returns whether idling is disabled for the cluster with the given API URL.
+func (api *idler) clusterDisabled(apiURL string) bool {
+	return api.disabledClusters != nil && api.disabledClusters.Has(apiURL)
 }
 
-func (api *idler) getURLAndToken(r *http.Request) (string, string, error) {
-	var openShiftAPIURL string
-	values, ok := r.URL.Query()[OpenShiftAPIParam]
-	if !ok || len(values) < 1 {
-		return "", "", fmt.Errorf("OpenShift API URL needs to be specified")
+// idlingDisabled returns true if the Idler does not idle the Jenkins of the user, as the user or the cluster got
+// disabled via the API or idling is skipped via deployment config annotation resp. disabled via JenkinsIdlerConfig.
+func (api *idler) idlingDisabled(apiURL string, user model.User) bool {
+	return user.SkipIdling || user.Overrides.Disabled || api.clusterDisabled(apiURL) || (api.disabledUsers != nil && api.disabledUsers.Has(user.Name))
+}
+
+func (api *idler) LogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	response := logLevelResponse{
+		Level:      logging.Level().String(),
+		Components: make(map[string]string),
+	}
+	for component, level := range logging.ComponentLevels() {
+		response.Components[component] = level.String()
 	}
+	writeResponse(w, http.StatusOK, response)
+}
 
-	openShiftAPIURL = values[0]
+func (api *idler) SetLogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	logger := log.WithFields(log.Fields{"component": "api", "function": "SetLogLevel", "target": req.Component})
+
+	// an empty level removes the override of the given component
+	if req.Level == "" && req.Component != "" {
+		logging.ResetComponentLevel(req.Component)
+		logger.Info("Log level override removed")
+		api.LogLevel(w, r, ps)
+		return
+	}
+
+	level, err := log.ParseLevel(req.Level)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Component == "" {
+		logging.SetLevel(level)
+	} else {
+		logging.SetComponentLevel(req.Component, level)
+	}
+	logger.WithField("level", level).Info("Log level changed")
+	api.LogLevel(w, r, ps)
+}
+
+func (api *idler) Version(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	response := versionResponse{
+		Version:       version.GetVersion(),
+		Commit:        version.GetCommit(),
+		BuildTime:     version.GetBuildTime(),
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: int64(time.Since(api.startTime).Seconds()),
+		TrackedUsers:  api.userIdlers.Len(),
+		Profile:       api.config.GetProfile(),
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+// configResponse lists the effective configuration settings.
+type configResponse struct {
+	Profile  string                  `json:"profile"`
+	Settings []configuration.Setting `json:"settings"`
+}
+
+// EffectiveConfig writes the effective value of each configuration option along with the layer it got set by, i.e.
+// the built-in defaults, the profile, the config file, the environment or the flags. Secrets are masked.
+func (api *idler) EffectiveConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	response := configResponse{Profile: api.config.GetProfile(), Settings: api.config.Effective()}
+	if response.Settings == nil {
+		response.Settings = []configuration.Setting{}
+	}
+	writeNegotiatedResponse(w, r, http.StatusOK, response)
+}
+
+// togglesResponse lists the feature toggle definitions.
+type togglesResponse struct {
+	Toggles []toggles.Definition `json:"toggles"`
+}
+
+// Toggles writes the feature toggle definitions currently in use, i.e. the ones last fetched from Unleash resp. the
+// fixed UUID list. Feature toggles unable to list their definitions result in an empty list.
+func (api *idler) Toggles(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	response := togglesResponse{Toggles: []toggles.Definition{}}
+	if inspector, ok := api.features.(toggles.Inspector); ok {
+		response.Toggles = inspector.Definitions()
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+func (api *idler) getURLAndToken(r *http.Request, namespace string) (string, string, error) {
+	openShiftAPIURL, err := api.getURL(r, namespace)
+	if err != nil {
+		return "", "", err
+	}
+
+	bearerToken, err := api.getToken(openShiftAPIURL)
+	if err != nil {
+		return "", "", err
+	}
+	return openShiftAPIURL, bearerToken, nil
+}
+
+// getURL returns the OpenShift API URL passed as query parameter of the request. If it is omitted, the API URL of
+// the cluster the namespace lives on is resolved. It fails with status 403 if the namespace on the cluster is out of
+// the scope of the caller.
+func (api *idler) getURL(r *http.Request, namespace string) (string, error) {
+	openShiftAPIURL, err := api.resolveURL(r.URL.Query().Get(OpenShiftAPIParam), namespace)
+	if err != nil {
+		return "", withStatus(http.StatusBadRequest, err)
+	}
+	if err := authorize(r.Context(), namespace, openShiftAPIURL); err != nil {
+		return "", err
+	}
+	return openShiftAPIURL, nil
+}
+
+// resolveURL returns the given OpenShift API URL unless it is empty, in which case the API URL of the cluster the
+// namespace lives on is looked up, first from the user idlers and then from the tenant index. Passing the API URL
+// explicitly overrides the lookup, e.g. while a tenant migrates between clusters.
+func (api *idler) resolveURL(openShiftAPIURL, namespace string) (string, error) {
+	if openShiftAPIURL != "" {
+		return openShiftAPIURL, nil
+	}
+
+	namespace = strings.TrimSpace(namespace)
+	if userIdler, ok := api.userIdlerOf(namespace); ok && userIdler.OpenShiftAPI() != "" {
+		return userIdler.OpenShiftAPI(), nil
+	}
+	if locator, ok := api.tenantService.(tenant.Locator); ok && namespace != "" {
+		if entry, ok := locator.Lookup(namespace); ok && entry.ClusterURL != "" {
+			return entry.ClusterURL, nil
+		}
+	}
+	return "", fmt.Errorf("OpenShift API URL needs to be specified")
+}
+
+// userIdlerOf returns the user idler of the user owning the given Jenkins namespace, if any.
+func (api *idler) userIdlerOf(namespace string) (*pidler.UserIdler, bool) {
+	user, ok := pnamespace.User(namespace)
+	if !ok || api.userIdlers == nil {
+		return nil, false
+	}
+	return api.userIdlers.Load(user)
+}
+
+// getToken returns the bearer token for the OpenShift cluster with the given API URL.
+func (api *idler) getToken(openShiftAPIURL string) (string, error) {
 	bearerToken, ok := api.clusterView.GetToken(openShiftAPIURL)
 	if ok {
-		return openShiftAPIURL, bearerToken, nil
+		return bearerToken, nil
 	}
-	return "", "", fmt.Errorf("Unknown or invalid OpenShift API URL: %s", openShiftAPIURL)
+	return "", withStatus(http.StatusBadRequest, fmt.Errorf("Unknown or invalid OpenShift API URL: %s", openShiftAPIURL))
 }
 
 func (api idler) isJenkinsUnIdled(openshiftURL, openshiftToken, namespace string) (bool, error) {
-	state, err := api.openShiftClient.State(openshiftURL, openshiftToken, namespace, "jenkins")
+	state, err := api.jenkinsState(openshiftURL, openshiftToken, namespace)
 	if err != nil {
 		return false, err
 	}
@@ -298,11 +904,156 @@ func (api idler) isJenkinsUnIdled(openshiftURL, openshiftToken, namespace string
 	return status, nil
 }
 
+// jenkinsState returns the state of the Jenkins pod in the given namespace. If the health probe is enabled, a running
+// Jenkins which does not serve requests yet, e.g. since it is still initializing its plugins, is reported as starting.
+func (api idler) jenkinsState(openshiftURL, openshiftToken, namespace string) (model.PodState, error) {
+	state, err := api.openShiftClient.State(openshiftURL, openshiftToken, namespace, "jenkins")
+	if err != nil || state != model.PodRunning || api.config == nil || !api.config.GetJenkinsHealthProbe() {
+		return state, err
+	}
+
+	health, err := api.openShiftClient.Probe(openshiftURL, openshiftToken, namespace, "jenkins", api.config.GetJenkinsHealthPath())
+	if err != nil {
+		// without a route, the probe cannot tell more than the pod phase
+		log.WithFields(log.Fields{"namespace": namespace, "err": err}).Warn("Unable to probe the health of Jenkins")
+		return state, nil
+	}
+
+	if health.Version != "" {
+		if userIdler, ok := api.userIdlerOf(namespace); ok {
+			userIdler.SetJenkinsVersion(health.Version)
+		}
+	}
+	if !health.Serving {
+		return model.PodStarting, nil
+	}
+	return state, nil
+}
+
+// statusError is an error together with the HTTP status it is to be reported with.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e statusError) Error() string {
+	return e.err.Error()
+}
+
+// withStatus attaches the HTTP status to report err with.
+func withStatus(status int, err error) error {
+	return statusError{status: status, err: err}
+}
+
+// errorStatus returns the HTTP status to report err with, 500 unless stated otherwise using withStatus.
+func errorStatus(err error) int {
+	if e, ok := err.(statusError); ok {
+		return e.status
+	}
+	return http.StatusInternalServerError
+}
+
+// capacityError reports that Jenkins cannot be un-idled as its cluster reached maximum capacity.
+type capacityError struct {
+	err         error
+	cluster     string
+	utilization clusterUtilization
+}
+
+func (e capacityError) Error() string {
+	return e.err.Error()
+}
+
+// lockNamespace takes the mutation lock of the namespace, failing with status 409 if other mutations kept holding it.
+func lockNamespace(openShiftAPI, openShiftBearerToken, namespace string) (*lock.Held, error) {
+	held, err := lock.Default.Lock(openShiftAPI, openShiftBearerToken, namespace)
+	if err == lock.ErrContended {
+		return nil, withStatus(http.StatusConflict, fmt.Errorf("Unable to lock namespace %s: %s", namespace, err))
+	}
+	return held, err
+}
+
+// asCapacityError returns the capacityError err reports, if any.
+func asCapacityError(err error) (capacityError, bool) {
+	if e, ok := err.(statusError); ok {
+		err = e.err
+	}
+	ce, ok := err.(capacityError)
+	return ce, ok
+}
+
+// clusterUtilization counts the Jenkins instances tracked on a cluster by their state.
+type clusterUtilization struct {
+	Tracked int `json:"tracked"`
+	Running int `json:"running"`
+	Idled   int `json:"idled"`
+}
+
+// capacityResponse is the body of the response to an un-idle request refused due to the cluster being at capacity.
+type capacityResponse struct {
+	Error             string             `json:"error"`
+	Cluster           string             `json:"cluster"`
+	RetryAfterSeconds int                `json:"retry_after_seconds,omitempty"`
+	Utilization       clusterUtilization `json:"utilization"`
+}
+
+// utilization counts the Jenkins instances on the given cluster known to the user idlers.
+func (api *idler) utilization(openshiftURL string) clusterUtilization {
+	u := clusterUtilization{}
+	if api.userIdlers == nil {
+		return u
+	}
+	cluster := util.EnsureSuffix(openshiftURL, "/")
+	api.userIdlers.Range(func(namespace string, userIdler *pidler.UserIdler) bool {
+		if util.EnsureSuffix(userIdler.OpenShiftAPI(), "/") != cluster {
+			return true
+		}
+		u.Tracked++
+		switch userIdler.State() {
+		case pidler.StateRunning, pidler.StateUnIdling:
+			u.Running++
+		case pidler.StateIdled:
+			u.Idled++
+		}
+		return true
+	})
+	return u
+}
+
+// respondWithCapacityError responds with status 503, asking the client to retry after the configured period.
+func (api *idler) respondWithCapacityError(w http.ResponseWriter, ce capacityError) {
+	log.Error(ce)
+	response := capacityResponse{Error: ce.Error(), Cluster: ce.cluster, Utilization: ce.utilization}
+	if api.config != nil && api.config.GetCapacityRetryAfter() > 0 {
+		response.RetryAfterSeconds = api.config.GetCapacityRetryAfter()
+		w.Header().Set("Retry-After", strconv.Itoa(response.RetryAfterSeconds))
+	}
+	writeResponse(w, http.StatusServiceUnavailable, response)
+}
+
 func respondWithError(w http.ResponseWriter, status int, err error) {
 	log.Error(err)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	w.Write([]byte(fmt.Sprintf("{\"error\": \"%s\"}", err)))
+	// errors of the OpenShift client may echo the request including the token
+	w.Write([]byte(fmt.Sprintf("{\"error\": \"%s\"}", redact.String(err.Error()))))
+}
+
+// respondWithServiceResults writes the per-service results of an idle resp. un-idle request. If any service failed,
+// the response has status 500 and carries the bundled errors of the failed services.
+func respondWithServiceResults(w http.ResponseWriter, results pidler.ServiceResults) {
+	if err := results.Err(); err != nil {
+		log.WithField("failed", results.Failed()).Error(err)
+		writeResponse(w, http.StatusInternalServerError, serviceResultsResponse{Error: redact.String(err.Error()), Services: results})
+		return
+	}
+	writeResponse(w, http.StatusOK, serviceResultsResponse{Services: results})
+}
+
+// serviceResultsResponse is the body of the responses to idle resp. un-idle requests.
+type serviceResultsResponse struct {
+	Error    string                `json:"error,omitempty"`
+	Services pidler.ServiceResults `json:"services"`
 }
 
 type responseError struct {
@@ -311,7 +1062,36 @@ type responseError struct {
 }
 
 type jenkinsInfo struct {
-	State string `json:"state"`
+	State                    string     `json:"state"`
+	IdledSince               *time.Time `json:"idled_since,omitempty"`
+	IdleDurationSeconds      int64      `json:"idle_duration_seconds,omitempty"`
+	TotalIdleDurationSeconds int64      `json:"total_idle_duration_seconds,omitempty"`
+	Restarts                 int        `json:"restarts,omitempty"`
+	OOMKilled                bool       `json:"oom_killed,omitempty"`
+	JenkinsVersion           string     `json:"jenkins_version,omitempty"`
+	EstimatedReadyInSeconds  *int64     `json:"estimated_ready_in_seconds,omitempty"`
+	IdleInSeconds            *int64     `json:"idle_in_seconds,omitempty"`
+	IdleAfterSeconds         int64      `json:"idle_after_seconds,omitempty"`
+	CheckIntervalSeconds     int64      `json:"check_interval_seconds,omitempty"`
+	IdlingDisabled           bool       `json:"idling_disabled,omitempty"`
+	LastBuild                *buildInfo `json:"last_build,omitempty"`
+	LastIdlerAction          *idlerInfo `json:"last_idler_action,omitempty"`
+	LastRepositoryRequest    *time.Time `json:"content_repository_last_request,omitempty"`
+}
+
+// buildInfo describes the last Jenkins build of a namespace.
+type buildInfo struct {
+	Name      string     `json:"name"`
+	Phase     string     `json:"phase"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// idlerInfo describes the last idle or un-idle operation of the Idler on a namespace.
+type idlerInfo struct {
+	Action    string    `json:"action"`
+	Timestamp time.Time `json:"timestamp"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
 }
 
 type statusResponse struct {
@@ -325,8 +1105,132 @@ type errorCode uint32
 const (
 	tokenFetchFailed     errorCode = 1
 	openShiftClientError errorCode = 2
+	podCrashLooping      errorCode = 3
+	podImagePullFailed   errorCode = 4
+	podUnschedulable     errorCode = 5
+	podQuotaExceeded     errorCode = 6
+	outOfScope           errorCode = 7
 )
 
+type podFailure struct {
+	code        errorCode
+	description string
+}
+
+// podFailures maps the failure states of Jenkins to the errors reported by Status.
+var podFailures = map[model.PodState]podFailure{
+	model.PodCrashLooping: {
+		code:        podCrashLooping,
+		description: "jenkins keeps crashing after being started",
+	},
+	model.PodImagePullFailed: {
+		code:        podImagePullFailed,
+		description: "the jenkins image cannot be pulled",
+	},
+	model.PodUnschedulable: {
+		code:        podUnschedulable,
+		description: "jenkins cannot be scheduled on any node of the cluster",
+	},
+	model.PodQuotaExceeded: {
+		code:        podQuotaExceeded,
+		description: "jenkins cannot be started since the resource quota of the namespace is exceeded",
+	},
+}
+
+// SetRestarts adds how often the Jenkins pod restarted and whether it ran out of memory.
+func (s *statusResponse) SetRestarts(restarts model.PodRestarts) *statusResponse {
+	if s.Data != nil {
+		s.Data.Restarts = restarts.Count
+		s.Data.OOMKilled = restarts.OOMKilled
+	}
+	return s
+}
+
+// SetJenkinsVersion adds the Jenkins version last observed for the user, if known.
+func (s *statusResponse) SetJenkinsVersion(version string) *statusResponse {
+	if s.Data != nil {
+		s.Data.JenkinsVersion = version
+	}
+	return s
+}
+
+// SetEstimatedReadyIn adds how long Jenkins is expected to take until it is ready, provided it is starting.
+func (s *statusResponse) SetEstimatedReadyIn(readyIn time.Duration) *statusResponse {
+	if s.Data != nil && s.Data.State == model.PodState(model.PodStarting).String() {
+		seconds := int64(readyIn.Seconds())
+		s.Data.EstimatedReadyInSeconds = &seconds
+	}
+	return s
+}
+
+// SetIdleIn adds how long Jenkins keeps running without further activity until it gets idled, provided it is running.
+func (s *statusResponse) SetIdleIn(idleIn time.Duration) *statusResponse {
+	if s.Data != nil && s.Data.State == model.PodState(model.PodRunning).String() {
+		seconds := int64(idleIn.Seconds())
+		s.Data.IdleInSeconds = &seconds
+	}
+	return s
+}
+
+// SetTimeouts adds how long Jenkins keeps running after its last activity and how often the idle conditions get
+// checked.
+func (s *statusResponse) SetTimeouts(idleAfter, checkInterval time.Duration) *statusResponse {
+	if s.Data != nil {
+		s.Data.IdleAfterSeconds = int64(idleAfter.Seconds())
+		s.Data.CheckIntervalSeconds = int64(checkInterval.Seconds())
+	}
+	return s
+}
+
+// SetContentRepositoryActivity adds when the content-repository last got requests, provided it is idled on its own.
+func (s *statusResponse) SetContentRepositoryActivity(lastRequest time.Time) *statusResponse {
+	if s.Data != nil {
+		s.Data.LastRepositoryRequest = &lastRequest
+	}
+	return s
+}
+
+// SetIdlingDisabled adds whether the Idler refrains from idling Jenkins.
+func (s *statusResponse) SetIdlingDisabled(disabled bool) *statusResponse {
+	if s.Data != nil {
+		s.Data.IdlingDisabled = disabled
+	}
+	return s
+}
+
+// SetLastBuild adds the active build of the user or else its last completed build, if any.
+func (s *statusResponse) SetLastBuild(user model.User) *statusResponse {
+	if s.Data == nil || !user.HasBuilds() {
+		return s
+	}
+
+	build := user.LastBuild()
+	info := &buildInfo{Name: build.Metadata.Name, Phase: build.Status.Phase}
+	timestamp := build.Status.CompletionTimestamp.Time
+	if timestamp.IsZero() {
+		timestamp = build.Status.StartTimestamp.Time
+	}
+	if !timestamp.IsZero() {
+		timestamp = timestamp.UTC()
+		info.Timestamp = &timestamp
+	}
+	s.Data.LastBuild = info
+	return s
+}
+
+// SetLastIdlerAction adds the last idle or un-idle operation of the Idler, if any.
+func (s *statusResponse) SetLastIdlerAction(status model.IdleStatus) *statusResponse {
+	if s.Data != nil && !status.Timestamp.IsZero() {
+		s.Data.LastIdlerAction = &idlerInfo{
+			Action:    status.Action,
+			Timestamp: status.Timestamp.UTC(),
+			Success:   status.Success,
+			Reason:    status.Reason,
+		}
+	}
+	return s
+}
+
 func (s *statusResponse) AppendError(code errorCode, description string) *statusResponse {
 	s.Errors = append(s.Errors, responseError{
 		Code:        code,
@@ -340,6 +1244,24 @@ func (s *statusResponse) SetState(state model.PodState) *statusResponse {
 	return s
 }
 
+// SetIdleDuration adds since when and for how long Jenkins is idled as well as the total idle duration
+// tracked for the user.
+func (s *statusResponse) SetIdleDuration(user model.User, now time.Time) *statusResponse {
+	if s.Data == nil {
+		return s
+	}
+
+	total := user.TotalIdleDuration
+	if s.Data.State == model.PodState(model.PodIdled).String() && !user.IdledAt.IsZero() {
+		idledAt := user.IdledAt.UTC()
+		s.Data.IdledSince = &idledAt
+		s.Data.IdleDurationSeconds = int64(user.IdleDuration(now).Seconds())
+		total += user.IdleDuration(now)
+	}
+	s.Data.TotalIdleDurationSeconds = int64(total.Seconds())
+	return s
+}
+
 type any interface{}
 
 func writeResponse(w http.ResponseWriter, status int, response any) {
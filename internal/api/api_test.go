@@ -4,12 +4,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
 	"testing"
-
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/lock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/logging"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
 	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,9 +39,11 @@ type ReqFuncType func(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 func Test_success(t *testing.T) {
 	mosc := &mock.OpenShiftClient{}
 	mockidle := idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
 		openShiftClient: mosc,
 		clusterView:     &mock.ClusterView{},
 		tenantService:   &mock.TenantService{},
+		config:          &mock.Config{},
 	}
 	functions := []ReqFuncType{
 		mockidle.Idle, mockidle.UnIdle,
@@ -48,6 +67,8 @@ func Test_success(t *testing.T) {
 		require.Equal(t, http.StatusOK, writer.WriterStatus, fmt.Sprintf("Bad Error Code: %d", writer.WriterStatus))
 		require.Equal(t, mosc.IdleCallCount, 1, fmt.Sprintf("Idle was not called for 1 times but %d", mosc.IdleCallCount))
 	}
+	require.Equal(t, model.TriggeredByAPI, mosc.Annotations["jenkins"][model.TriggeredByAnnotation])
+	require.Equal(t, model.UnidleAction, mosc.Annotations["jenkins"][model.LastActionAnnotation])
 }
 
 func Test_fail(t *testing.T) {
@@ -96,6 +117,417 @@ func Test_fail(t *testing.T) {
 	}
 }
 
+func Test_idle_aggregates_service_results(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	services := pidler.JenkinsServices
+	pidler.JenkinsServices = []string{"jenkins", "content-repository"}
+	defer func() { pidler.JenkinsServices = services }()
+
+	mosc := &mock.OpenShiftClient{IdleError: "Error when Idling"}
+	mockIdler := &idler{
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	mockIdler.Idle(w, r, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar"}})
+
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, 2, mosc.IdleCallCount, "Idling should continue past the failure of a service")
+
+	resp := serviceResultsResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, pidler.ServiceResults{
+		{Service: "content-repository", Error: "Error when Idling"},
+		{Service: "jenkins", Error: "Error when Idling"},
+	}, resp.Services)
+}
+
+func Test_Status_running_requires_healthy_jenkins(t *testing.T) {
+	mosc := &mock.OpenShiftClient{IdleState: model.PodRunning, Unhealthy: true}
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		config:          &mock.Config{JenkinsHealthProbe: true, JenkinsHealthPath: "/login"},
+	}
+
+	status := func() string {
+		req, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+		w := httptest.NewRecorder()
+		mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		sr := &statusResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+		return sr.Data.State
+	}
+
+	require.Equal(t, "starting", status(), "A Jenkins not serving requests yet should be reported as starting")
+
+	mosc.Unhealthy = false
+	require.Equal(t, "running", status())
+}
+
+func Test_jenkins_version_observed_by_probe(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foobar", pidler.NewUserIdler(model.NewUser("42", "foobar"), "", "", &mock.Config{},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodRunning, JenkinsVersion: "2.107.3"},
+		clusterView:     &mock.ClusterView{},
+		config:          &mock.Config{JenkinsHealthProbe: true, JenkinsHealthPath: "/login"},
+	}
+
+	req, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar"}})
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Empty(t, sr.Data.JenkinsVersion, "Namespace without Jenkins suffix should not be mapped to a user")
+	w = httptest.NewRecorder()
+	mockIdler.JenkinsVersions(w, httptest.NewRequest("GET", "/api/idler/jenkinsversions", nil), nil)
+	require.JSONEq(t, `{"versions": {}}`, w.Body.String(), "Version of a namespace without Jenkins suffix should not be recorded")
+
+	w = httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+	sr = &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "2.107.3", sr.Data.JenkinsVersion)
+
+	w = httptest.NewRecorder()
+	mockIdler.JenkinsVersions(w, httptest.NewRequest("GET", "/api/idler/jenkinsversions", nil), nil)
+	require.JSONEq(t, `{"versions": {"foobar": "2.107.3"}}`, w.Body.String())
+}
+
+func Test_AggregateStatus(t *testing.T) {
+	userIdlers := openshift.NewUserIdlerMap()
+	for _, u := range []struct {
+		name    string
+		cluster string
+		state   model.PodState
+	}{
+		{"foo", "https://api.cluster1.example.com/", model.PodRunning},
+		{"bar", "https://api.cluster1.example.com/", model.PodIdled},
+		{"baz", "https://api.cluster1.example.com/", model.PodStarting},
+		{"qux", "https://api.cluster3.example.com/", model.PodIdled},
+	} {
+		userIdler := pidler.NewUserIdler(model.NewUser(u.name, u.name), u.cluster, "", &mock.Config{},
+			mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New())
+		userIdler.Observe(u.state)
+		userIdlers.Store(u.name, userIdler)
+	}
+	mockIdler := &idler{
+		userIdlers: userIdlers,
+		clusterView: cluster.NewView([]cluster.Cluster{
+			{APIURL: "https://api.cluster1.example.com/"},
+			{APIURL: "https://api.cluster2.example.com/"},
+		}),
+	}
+
+	w := httptest.NewRecorder()
+	mockIdler.AggregateStatus(w, httptest.NewRequest("GET", "/api/status/aggregate", nil), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"clusters": {
+			"https://api.cluster1.example.com/": {"idled": 1, "idling": 0, "starting": 1, "running": 1, "error": 0, "unknown": 0},
+			"https://api.cluster2.example.com/": {"idled": 0, "idling": 0, "starting": 0, "running": 0, "error": 0, "unknown": 0},
+			"https://api.cluster3.example.com/": {"idled": 1, "idling": 0, "starting": 0, "running": 0, "error": 0, "unknown": 0}
+		},
+		"total": {"idled": 2, "idling": 0, "starting": 1, "running": 1, "error": 0, "unknown": 0}
+	}`, w.Body.String())
+}
+
+func Test_IdlerTimers(t *testing.T) {
+	config := &mock.Config{IdleAfter: 60}
+	running := model.NewUser("1", "running")
+	running.JenkinsLastUpdate = time.Now().Add(-10 * time.Minute)
+	idled := model.NewUser("2", "idled")
+	idled.JenkinsLastUpdate = time.Now().Add(-2 * time.Hour)
+	idled.IdleStatus = model.NewIdleStatus(nil)
+
+	userIdlers := openshift.NewUserIdlerMap()
+	for user, state := range map[*model.User]model.PodState{&running: model.PodRunning, &idled: model.PodIdled} {
+		userIdler := pidler.NewUserIdler(*user, "https://api.cluster1.example.com/", "", config,
+			mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New())
+		userIdler.Observe(state)
+		userIdlers.Store(user.Name, userIdler)
+	}
+	runningIdler, _ := userIdlers.Load("running")
+	runningIdler.SetResources(model.Resources{CPURequest: 0.5, MemoryLimit: 1 << 30})
+	runningIdler.SetUsage(model.Usage{CPU: 0.1, Memory: 512 << 20})
+	mockIdler := &idler{userIdlers: userIdlers, clusterView: &mock.ClusterView{}, config: config}
+
+	w := httptest.NewRecorder()
+	mockIdler.IdlerTimers(w, httptest.NewRequest("GET", "/api/metrics/idlers", nil), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := idlerTimersResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Idlers, 2)
+
+	timer := response.Idlers["running"]
+	require.Equal(t, "running", timer.State)
+	require.Equal(t, "https://api.cluster1.example.com/", timer.Cluster)
+	require.NotNil(t, timer.IdleInSeconds)
+	require.InDelta(t, 50*60, *timer.IdleInSeconds, 5)
+	require.WithinDuration(t, response.Time.Add(50*time.Minute), *timer.IdleAt, 5*time.Second)
+	require.Equal(t, int64(3600), timer.IdleAfter)
+	require.Nil(t, timer.LastDecision)
+	require.Equal(t, &model.Resources{CPURequest: 0.5, MemoryLimit: 1 << 30}, timer.Resources)
+	require.Equal(t, int64(512<<20), timer.Usage.Memory)
+
+	timer = response.Idlers["idled"]
+	require.Equal(t, "idled", timer.State)
+	require.Nil(t, timer.IdleInSeconds, "only running Jenkins has a countdown")
+	require.NotNil(t, timer.LastDecision)
+	require.Equal(t, model.IdleAction, timer.LastDecision.Action)
+	require.True(t, timer.LastDecision.Success)
+	require.Nil(t, timer.Resources, "the resources are unknown until the deployment config is observed")
+}
+
+func Test_Toggles(t *testing.T) {
+	features, err := toggles.NewFixedUUIDToggle([]string{"42", "1001"})
+	require.NoError(t, err)
+	mockIdler := idler{features: features}
+
+	w := httptest.NewRecorder()
+	mockIdler.Toggles(w, httptest.NewRequest("GET", "/api/toggles", nil), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"toggles": [{"name": "jenkins.idler", "description": "Fixed list of user IDs", "enabled": true, "strategies": [{"name": "userWithId", "parameters": {"userIds": "42,1001"}}]}]}`, w.Body.String())
+
+	mockIdler = idler{features: mock.NewMockFeatureToggle(nil)}
+	w = httptest.NewRecorder()
+	mockIdler.Toggles(w, httptest.NewRequest("GET", "/api/toggles", nil), nil)
+	require.JSONEq(t, `{"toggles": []}`, w.Body.String())
+}
+
+func Test_EffectiveConfig(t *testing.T) {
+	mockIdler := idler{config: &mock.Config{Profile: "prod", Settings: []configuration.Setting{
+		{Key: "JC_API_TOKEN", Value: "***", Source: configuration.SourceEnv},
+		{Key: "JC_IDLE_AFTER", Value: 60, Source: configuration.SourceFlag},
+	}}}
+
+	w := httptest.NewRecorder()
+	mockIdler.EffectiveConfig(w, httptest.NewRequest("GET", "/api/config", nil), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"profile": "prod", "settings": [{"key": "JC_API_TOKEN", "value": "***", "source": "env"}, {"key": "JC_IDLE_AFTER", "value": 60, "source": "flag"}]}`, w.Body.String())
+}
+
+func Test_Status_estimated_ready_in(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	c := clock.NewFake(time.Now())
+	userIdler := pidler.NewUserIdler(model.NewUser("42", "foobar"), "", "", &mock.Config{},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, c)
+	userIdler.Observe(model.PodStarting)
+	c.Advance(2 * time.Minute)
+	userIdler.Observe(model.PodRunning)
+	userIdler.Observe(model.PodIdled)
+	userIdler.Observe(model.PodStarting)
+	c.Advance(30 * time.Second)
+
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foobar", userIdler)
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodStarting},
+		clusterView:     &mock.ClusterView{},
+	}
+
+	req, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "starting", sr.Data.State)
+	require.NotNil(t, sr.Data.EstimatedReadyInSeconds)
+	require.Equal(t, int64(90), *sr.Data.EstimatedReadyInSeconds)
+}
+
+func Test_Status_content_repository_activity(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	started := time.Date(2018, 6, 4, 10, 0, 0, 0, time.UTC)
+	config := &mock.Config{PrometheusURL: "http://prometheus", ContentRepositoryIdleAfter: 60}
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foobar", pidler.NewUserIdler(model.NewUser("42", "foobar"), "", "", config,
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.NewFake(started)))
+	userIdlers.Store("other", pidler.NewUserIdler(model.NewUser("43", "other"), "", "", &mock.Config{},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.NewFake(started)))
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodRunning},
+		clusterView:     &mock.ClusterView{},
+	}
+
+	for ns, expected := range map[string]*time.Time{"foobar-jenkins": &started, "other-jenkins": nil} {
+		req, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+		w := httptest.NewRecorder()
+		mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: ns}})
+
+		sr := &statusResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+		require.Equal(t, expected, sr.Data.LastRepositoryRequest, "Unexpected content-repository activity of %s", ns)
+	}
+}
+
+func Test_Status_idle_duration(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	idledAt := time.Now().Add(-2 * time.Hour).UTC()
+	user := model.NewUser("42", "foobar")
+	user.IdledAt = idledAt
+	user.TotalIdleDuration = time.Hour
+
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foobar", pidler.NewUserIdler(user, "", "", &mock.Config{},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodIdled},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	query := req.URL.Query()
+	query.Add(OpenShiftAPIParam, "http://localhost")
+	req.URL.RawQuery = query.Encode()
+
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "idled", sr.Data.State)
+	require.NotNil(t, sr.Data.IdledSince)
+	require.True(t, idledAt.Equal(*sr.Data.IdledSince))
+	require.InDelta(t, 2*3600, sr.Data.IdleDurationSeconds, 5)
+	require.InDelta(t, 3*3600, sr.Data.TotalIdleDurationSeconds, 5)
+}
+
+func Test_Status_build_and_idler_context(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	user := model.NewUser("42", "foobar")
+	user.JenkinsLastUpdate = now.Add(-10 * time.Minute)
+	user.DoneBuild.Metadata.Name = "app-3"
+	user.DoneBuild.Status.Phase = "Complete"
+	user.DoneBuild.Status.CompletionTimestamp.Time = now.Add(-5 * time.Minute)
+	user.IdleStatus = model.NewUnidleStatus(nil)
+
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foobar", pidler.NewUserIdler(user, "", "", &mock.Config{IdleAfter: 30},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.NewFake(now)))
+	disabledUsers := model.NewStringSet()
+	disabledUsers.Add([]string{"foobar"})
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodRunning},
+		clusterView:     &mock.ClusterView{},
+		disabledUsers:   disabledUsers,
+	}
+
+	req, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "running", sr.Data.State)
+	require.NotNil(t, sr.Data.IdleInSeconds)
+	require.Equal(t, int64(25*60), *sr.Data.IdleInSeconds, "idle timeout should count from the last build")
+	require.True(t, sr.Data.IdlingDisabled)
+	require.NotNil(t, sr.Data.LastBuild)
+	require.Equal(t, "app-3", sr.Data.LastBuild.Name)
+	require.Equal(t, "Complete", sr.Data.LastBuild.Phase)
+	require.True(t, now.Add(-5*time.Minute).Equal(*sr.Data.LastBuild.Timestamp))
+	require.NotNil(t, sr.Data.LastIdlerAction)
+	require.Equal(t, model.UnidleAction, sr.Data.LastIdlerAction.Action)
+	require.True(t, sr.Data.LastIdlerAction.Success)
+
+	disabledUsers.Remove([]string{"foobar"})
+	user.ActiveBuild.Metadata.Name = "app-4"
+	user.ActiveBuild.Status.Phase = "Running"
+	user.ActiveBuild.Status.StartTimestamp.Time = now.Add(-time.Minute)
+	userIdlers.Store("foobar", pidler.NewUserIdler(user, "", "", &mock.Config{IdleAfter: 30},
+		mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.NewFake(now)))
+
+	w = httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+
+	sr = &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Nil(t, sr.Data.IdleInSeconds, "Jenkins with an active build should not count down")
+	require.False(t, sr.Data.IdlingDisabled)
+	require.Equal(t, "app-4", sr.Data.LastBuild.Name)
+	require.True(t, now.Add(-time.Minute).Equal(*sr.Data.LastBuild.Timestamp))
+}
+
+func Test_Status_reports_pod_failure(t *testing.T) {
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodCrashLooping},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	query := req.URL.Query()
+	query.Add(OpenShiftAPIParam, "http://localhost")
+	req.URL.RawQuery = query.Encode()
+
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "crash_looping", sr.Data.State)
+	require.Equal(t, 1, len(sr.Errors), "Errors must be present")
+	require.Equal(t, podCrashLooping, sr.Errors[0].Code)
+}
+
+func Test_Status_reports_quota_exceeded(t *testing.T) {
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodQuotaExceeded},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	query := req.URL.Query()
+	query.Add(OpenShiftAPIParam, "http://localhost")
+	req.URL.RawQuery = query.Encode()
+
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, req, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}})
+
+	sr := &statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), sr))
+	require.Equal(t, "quota_exceeded", sr.Data.State)
+	require.Equal(t, 1, len(sr.Errors), "Errors must be present")
+	require.Equal(t, podQuotaExceeded, sr.Errors[0].Code)
+	require.Contains(t, sr.Errors[0].Description, "resource quota")
+}
+
 func Test_Status_InternalError_fail(t *testing.T) {
 	mockIdler := &idler{
 		openShiftClient: &mock.OpenShiftClient{
@@ -151,6 +583,268 @@ func Test_Status_BadRequest_fail(t *testing.T) {
 		"failed to obtain openshift token", "Error must have a description")
 }
 
+func Test_Version(t *testing.T) {
+	mockIdler := &idler{
+		userIdlers: openshift.NewUserIdlerMap(),
+		config:     &mock.Config{Profile: "staging"},
+		startTime:  time.Now().Add(-10 * time.Second),
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/version", nil)
+	mockIdler.Version(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := versionResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "unset", resp.Commit)
+	require.Equal(t, runtime.Version(), resp.GoVersion)
+	require.Equal(t, "staging", resp.Profile)
+	require.Equal(t, 0, resp.TrackedUsers)
+	require.True(t, resp.UptimeSeconds >= 10, "uptime must cover the time since start")
+}
+
+func Test_SetLogLevel(t *testing.T) {
+	mockIdler := &idler{}
+	defer logging.SetLevel(log.InfoLevel)
+	defer logging.ResetComponentLevel("controller")
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/api/logging", strings.NewReader(`{"level": "debug", "component": "controller"}`))
+	mockIdler.SetLogLevel(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp := logLevelResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "info", resp.Level)
+	require.Equal(t, map[string]string{"controller": "debug"}, resp.Components)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/api/logging", strings.NewReader(`{"component": "controller"}`))
+	mockIdler.SetLogLevel(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, logging.ComponentLevels(), "override should have been removed")
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/api/logging", strings.NewReader(`{"level": "verbose"}`))
+	mockIdler.SetLogLevel(w, r, nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_cluster_status(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stdout)
+
+	mosc := &mock.OpenShiftClient{}
+	mockIdler := &idler{
+		openShiftClient:  mosc,
+		clusterView:      &mock.ClusterView{},
+		tenantService:    &mock.TenantService{},
+		disabledClusters: model.NewStringSet(),
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/idler/clusterstatus", strings.NewReader(`{"disable": ["http://localhost"]}`))
+	mockIdler.SetClusterStatus(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/api/idler/clusterstatus", nil)
+	mockIdler.GetDisabledClusters(w, r, nil)
+	require.JSONEq(t, `{"clusters": ["http://localhost"]}`, w.Body.String())
+
+	params := httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar"}}
+	for _, function := range []ReqFuncType{mockIdler.Idle, mockIdler.UnIdle} {
+		w = httptest.NewRecorder()
+		r, _ = http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+		function(w, r, params)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, "Idling should be refused for a disabled cluster")
+	}
+	require.Equal(t, 0, mosc.IdleCallCount)
+	require.Equal(t, 0, mosc.UnIdleCallCount)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/api/idler/clusterstatus", strings.NewReader(`{"enable": ["http://localhost"]}`))
+	mockIdler.SetClusterStatus(w, r, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.False(t, mockIdler.clusterDisabled("http://localhost"))
+}
+
+func Test_idle_refused_in_unidle_only_mode(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stdout)
+
+	mosc := &mock.OpenShiftClient{}
+	mockIdler := &idler{
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		config:          &mock.Config{UnidleOnly: true},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	mockIdler.Idle(w, r, httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar"}})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code, "Idling should be refused in unidle-only mode")
+	require.Equal(t, 0, mosc.IdleCallCount)
+}
+
+func Test_unidle_refused_at_capacity(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stdout)
+
+	userIdlers := openshift.NewUserIdlerMap()
+	config := &mock.Config{CapacityRetryAfter: 120}
+	for ns, apiURL := range map[string]string{"foo": "http://localhost", "bar": "http://localhost/", "baz": "http://other"} {
+		userIdler := pidler.NewUserIdler(model.User{Name: ns}, apiURL, "", config, mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New())
+		userIdler.Observe(model.PodRunning)
+		userIdlers.Store(ns, userIdler)
+	}
+
+	mosc := &mock.OpenShiftClient{IdleState: model.PodIdled}
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{MaxCapacityReached: true},
+		config:          config,
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	mockIdler.UnIdle(w, r, httprouter.Params{httprouter.Param{Key: "namespace", Value: "qux-jenkins"}})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Equal(t, "120", w.Header().Get("Retry-After"))
+	require.Equal(t, 0, mosc.UnIdleCallCount)
+
+	response := capacityResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, "http://localhost", response.Cluster)
+	require.Equal(t, 120, response.RetryAfterSeconds)
+	require.Equal(t, clusterUtilization{Tracked: 2, Running: 2}, response.Utilization)
+}
+
+// locatingTenantService is a tenant service knowing the cluster of the bar-jenkins namespace.
+type locatingTenantService struct {
+	mock.TenantService
+}
+
+func (s *locatingTenantService) Lookup(namespace string) (tenant.IndexEntry, bool) {
+	if namespace != "bar-jenkins" {
+		return tenant.IndexEntry{}, false
+	}
+	return tenant.IndexEntry{UserID: "bar-id", ClusterURL: "https://api.cluster2.example.com/"}, true
+}
+
+func Test_cluster_resolved_for_namespace(t *testing.T) {
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("foo", pidler.NewUserIdler(model.NewUser("foo-id", "foo"), "https://api.cluster1.example.com/", "",
+		&mock.Config{}, mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	mockIdler := &idler{userIdlers: userIdlers, tenantService: &locatingTenantService{}}
+
+	get := func(query, namespace string) (string, error) {
+		r, _ := http.NewRequest("GET", "/"+query, nil)
+		return mockIdler.getURL(r, namespace)
+	}
+
+	apiURL, err := get("", "foo-jenkins")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.cluster1.example.com/", apiURL, "Cluster of the user idler should be used")
+
+	apiURL, err = get("", "bar-jenkins")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.cluster2.example.com/", apiURL, "Cluster of the tenant index should be used")
+
+	apiURL, err = get("?"+OpenShiftAPIParam+"=https://api.cluster3.example.com/", "foo-jenkins")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.cluster3.example.com/", apiURL, "Passed API URL should override the lookup")
+
+	_, err = get("", "baz-jenkins")
+	require.EqualError(t, err, "OpenShift API URL needs to be specified")
+}
+
+// tenantBackend is a tenant service owning the bar-jenkins namespace.
+type tenantBackend struct {
+	mock.TenantService
+}
+
+func (s *tenantBackend) GetTenantInfoByNamespace(apiURL string, ns string) (tenant.InfoList, error) {
+	tenantInfo := tenant.InfoList{Data: []tenant.InfoData{{
+		ID: "bar-id",
+		Attributes: tenant.Attributes{Namespaces: []tenant.Namespace{
+			{Name: "bar-jenkins", ClusterURL: "https://api.cluster2.example.com"},
+		}},
+	}}}
+	tenantInfo.Meta.TotalCount = 1
+	return tenantInfo, nil
+}
+
+func Test_cluster_resolved_for_namespace_through_capacity_cache(t *testing.T) {
+	// the tenant service as set up by main
+	indexed := tenant.NewIndexedService(&tenantBackend{})
+	tenantService := tenant.NewCapacityCache(indexed, 30*time.Second, clock.New())
+	mockIdler := &idler{userIdlers: openshift.NewUserIdlerMap(), tenantService: tenantService}
+
+	_, err := mockIdler.resolveURL("", "bar-jenkins")
+	require.EqualError(t, err, "OpenShift API URL needs to be specified", "Namespace should not be indexed before a lookup")
+
+	_, err = tenantService.GetTenantInfoByNamespace("https://api.cluster2.example.com", "bar-jenkins")
+	require.NoError(t, err)
+	apiURL, err := mockIdler.resolveURL("", "bar-jenkins")
+	require.NoError(t, err)
+	require.Equal(t, "https://api.cluster2.example.com/", apiURL, "Cluster of the tenant index should be used")
+}
+
+func Test_Reset_strategies(t *testing.T) {
+	mosc := &mock.OpenShiftClient{}
+	mockIdler := &idler{
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		config:          &mock.Config{ResetGracePeriod: 30, ResetTimeout: 45},
+	}
+	params := httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	mockIdler.Reset(w, r, params)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, mosc.ResetCallCount, "Pods should be deleted by default")
+	require.Equal(t, client.ResetOptions{GracePeriod: 30 * time.Second, Timeout: 45 * time.Second}, mosc.ResetOptions)
+	require.Equal(t, 0, mosc.RolloutCount)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost&timeout=0", nil)
+	mockIdler.Reset(w, r, params)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, time.Duration(0), mosc.ResetOptions.Timeout, "Timeout parameter should override the configured timeout")
+
+	locker := lock.Default
+	lock.Default = lock.New(&mock.Config{MutationLock: true, MutationLockTimeout: 30}, mosc, clock.New())
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost&timeout=3600", nil)
+	mockIdler.Reset(w, r, params)
+	lock.Default = locker
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, lock.MaxHold, mosc.ResetOptions.Timeout, "Timeout should not exceed the lease of the lock")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost&strategy=rollout&service=content-repository", nil)
+	mockIdler.Reset(w, r, params)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"rollout": "content-repository-1"}`, w.Body.String())
+	require.Equal(t, 3, mosc.ResetCallCount)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost&strategy=recreate", nil)
+	mockIdler.Reset(w, r, params)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/", nil)
+	mockIdler.Reset(w, r, params)
+	require.Equal(t, http.StatusBadRequest, w.Code, "Reset needs the cluster of the namespace")
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
 func Test_writeFunctions(t *testing.T) {
 	w := httptest.NewRecorder()
 	testStatus := http.StatusBadRequest
@@ -163,4 +857,7 @@ func Test_writeFunctions(t *testing.T) {
 	respondWithError(w, testStatus, err)
 	require.Equal(t, testStatus, w.Code, "in respondWithError, response was written before setting the HTTP status code")
 
+	w = httptest.NewRecorder()
+	respondWithError(w, testStatus, errors.New("request failed: Authorization: Bearer s3cr3t-t0ken"))
+	require.Equal(t, `{"error": "request failed: Authorization: ***"}`, w.Body.String(), "tokens should be redacted from errors")
 }
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/callback"
+	pnamespace "github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/julienschmidt/httprouter"
+)
+
+type callbackRequest struct {
+	URL string `json:"url"`
+}
+
+// RegisterCallback registers the URL passed in the request body to be called back with the JSON encoded
+// events.Event once Jenkins of the namespace transitions to running.
+func (api *idler) RegisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req callbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ns := util.EnsureSuffix(ps.ByName("namespace"), pnamespace.JenkinsSuffix)
+	if err := api.authorizeNamespace(r.Context(), r.URL.Query().Get(OpenShiftAPIParam), ns); err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+	if err := callback.Default.Register(ns, req.URL); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeNegotiatedResponse(w, r, http.StatusOK, callback.Registration{Namespace: ns, URL: req.URL})
+}
+
+// UnregisterCallback removes the callback registered for the namespace.
+func (api *idler) UnregisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ns := util.EnsureSuffix(ps.ByName("namespace"), pnamespace.JenkinsSuffix)
+	if err := api.authorizeNamespace(r.Context(), r.URL.Query().Get(OpenShiftAPIParam), ns); err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+	if !callback.Default.Unregister(ns) {
+		respondWithError(w, http.StatusNotFound, fmt.Errorf("No callback registered for namespace %s", ns))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
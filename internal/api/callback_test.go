@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/callback"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_register_callback(t *testing.T) {
+	registry := callback.Default
+	callback.Default = callback.NewRegistry()
+	defer func() { callback.Default = registry }()
+
+	api := &idler{}
+	ps := httprouter.Params{{Key: "namespace", Value: "john"}}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/api/idler/callback/john", strings.NewReader(`{"url": "/relative"}`))
+	api.RegisterCallback(w, r, ps)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Relative callback URLs should be refused")
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("POST", "/api/idler/callback/john", strings.NewReader(`{"url": "http://proxy/released"}`))
+	api.RegisterCallback(w, r, ps)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"namespace": "john-jenkins", "url": "http://proxy/released"}`, w.Body.String())
+	assert.Equal(t, []callback.Registration{{Namespace: "john-jenkins", URL: "http://proxy/released"}}, callback.Default.Registrations())
+
+	w = httptest.NewRecorder()
+	api.UnregisterCallback(w, httptest.NewRequest("DELETE", "/api/idler/callback/john-jenkins", nil),
+		httprouter.Params{{Key: "namespace", Value: "john-jenkins"}})
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Empty(t, callback.Default.Registrations())
+
+	w = httptest.NewRecorder()
+	api.UnregisterCallback(w, httptest.NewRequest("DELETE", "/api/idler/callback/john", nil), ps)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Unexpected HTTP status code")
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/dlq"
+	"github.com/julienschmidt/httprouter"
+)
+
+type deadLettersResponse struct {
+	Entries []dlq.Entry `json:"entries"`
+}
+
+// DeadLetters writes the events whose handling failed and which are retried, or whose retries are exhausted.
+func (api *idler) DeadLetters(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeNegotiatedResponse(w, r, http.StatusOK, deadLettersResponse{Entries: dlq.Default.Entries()})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/dlq"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dead_letters(t *testing.T) {
+	queue := dlq.Default
+	dlq.Default = dlq.New(&mock.Config{DLQMaxRetries: 5, DLQSize: 10}, clock.New())
+	defer func() { dlq.Default = queue }()
+
+	api := &idler{}
+	w := httptest.NewRecorder()
+	api.DeadLetters(w, httptest.NewRequest("GET", "/api/idler/deadletters", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"entries": []}`, w.Body.String())
+
+	dlq.Default.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("tenant lookup failed"), func() error { return nil })
+	w = httptest.NewRecorder()
+	api.DeadLetters(w, httptest.NewRequest("GET", "/api/idler/deadletters", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Contains(t, w.Body.String(), `"namespace":"john"`)
+	assert.Contains(t, w.Body.String(), `"error":"tenant lookup failed"`)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	pnamespace "github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// EventNamespaceParam is the query parameter restricting the event stream to a single namespace.
+	EventNamespaceParam = "namespace"
+
+	// streamKeepAlive is the interval of the comments sent to keep idle event streams open through proxies.
+	streamKeepAlive = 15 * time.Second
+
+	// streamRetry is the reconnection delay (ms) suggested to the clients of the event stream.
+	streamRetry = 3000
+
+	// streamTimeoutMargin ends an event stream this long before the write timeout of the server would cut it off.
+	streamTimeoutMargin = 5 * time.Second
+)
+
+// EventStream streams the state changes of the Jenkins instances as Server-Sent Events. Each change is sent as
+// event "state" with the JSON encoded events.Event as data. If the write timeout of the API server is set, the
+// stream ends shortly before it and the client is expected to reconnect, as EventSource clients do automatically.
+// Callers restricted to a scope only get the changes of the namespaces in scope.
+func (api *idler) EventStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, errors.New("Streaming is not supported"))
+		return
+	}
+
+	ns := strings.TrimSpace(r.URL.Query().Get(EventNamespaceParam))
+	if ns != "" {
+		ns = util.EnsureSuffix(ns, pnamespace.JenkinsSuffix)
+		if err := api.authorizeNamespace(r.Context(), "", ns); err != nil {
+			respondWithError(w, errorStatus(err), err)
+			return
+		}
+	}
+	events, cancel := pidler.Events.Subscribe(ns)
+	defer cancel()
+
+	var timeout <-chan time.Time
+	if api.config != nil && api.config.GetHTTPWriteTimeout() > 0 {
+		d := time.Duration(api.config.GetHTTPWriteTimeout())*time.Second - streamTimeoutMargin
+		if d <= 0 {
+			d = time.Duration(api.config.GetHTTPWriteTimeout()) * time.Second / 2
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout:
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case e := <-events:
+			if api.authorizeNamespace(r.Context(), "", e.Namespace) != nil {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
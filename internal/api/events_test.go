@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_event_stream(t *testing.T) {
+	hub := pidler.Events
+	pidler.Events = events.NewHub()
+	defer func() { pidler.Events = hub }()
+
+	api := &idler{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.EventStream(w, r, httprouter.Params{})
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?namespace=foo")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewReader(resp.Body)
+	line, err := lines.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "retry: 3000\n", line)
+
+	// the subscription is in place once the retry hint got sent
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	pidler.Events.Publish(events.Event{Namespace: "bar-jenkins", State: "idled", Time: now})
+	pidler.Events.Publish(events.Event{Namespace: "foo-jenkins", State: "starting", Previous: "idled", Time: now})
+
+	var received []string
+	for len(received) < 2 {
+		line, err := lines.ReadString('\n')
+		require.NoError(t, err)
+		if strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "data:") {
+			received = append(received, strings.TrimSpace(line))
+		}
+	}
+	require.Equal(t, []string{
+		"event: state",
+		`data: {"namespace":"foo-jenkins","state":"starting","previous":"idled","time":"2018-04-11T08:27:15Z"}`,
+	}, received)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/fault"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// FaultTargetParam is the query parameter restricting the removal of fault injection rules to a single target.
+const FaultTargetParam = "target"
+
+// errFaultsDisabled is returned by the fault injection endpoints of builds without fault injection.
+var errFaultsDisabled = errors.New("fault injection is not available in this build")
+
+type faultsResponse struct {
+	Rules []fault.Rule `json:"rules"`
+}
+
+type clearFaultsResponse struct {
+	Removed int `json:"removed"`
+}
+
+// Faults writes the rules by which faults are injected into the OpenShift and tenant clients.
+func (api *idler) Faults(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !fault.Enabled {
+		respondWithError(w, http.StatusNotFound, errFaultsDisabled)
+		return
+	}
+	writeNegotiatedResponse(w, r, http.StatusOK, faultsResponse{Rules: fault.Default.Rules()})
+}
+
+// SetFault adds a fault injection rule, replacing the one of the same target and host.
+func (api *idler) SetFault(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !fault.Enabled {
+		respondWithError(w, http.StatusNotFound, errFaultsDisabled)
+		return
+	}
+
+	var rule fault.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := fault.Default.Set(rule); err != nil {
+		respondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	log.WithFields(log.Fields{"component": "api", "function": "SetFault", "target": rule.Target, "host": rule.Host}).
+		Warn("Fault injection enabled")
+	api.Faults(w, r, ps)
+}
+
+// ClearFaults removes the fault injection rules of the target given as query parameter, or all rules.
+func (api *idler) ClearFaults(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if !fault.Enabled {
+		respondWithError(w, http.StatusNotFound, errFaultsDisabled)
+		return
+	}
+	writeResponse(w, http.StatusOK, clearFaultsResponse{Removed: fault.Default.Clear(r.URL.Query().Get(FaultTargetParam))})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/fault"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_faults(t *testing.T) {
+	injector := fault.Default
+	fault.Default = fault.NewInjector(clock.New())
+	defer func() { fault.Default = injector }()
+
+	api := &idler{}
+	w := httptest.NewRecorder()
+	api.SetFault(w, httptest.NewRequest("PUT", "/api/idler/faults", strings.NewReader(`{"target": "openshift", "error_rate": 0.5}`)), nil)
+	if !fault.Enabled {
+		assert.Equal(t, http.StatusNotFound, w.Code, "Fault injection should not be available")
+		assert.Empty(t, fault.Default.Rules(), "No rule should be set")
+		return
+	}
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"rules": [{"target": "openshift", "latency_ms": 0, "error_rate": 0.5, "injected": 0}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.SetFault(w, httptest.NewRequest("PUT", "/api/idler/faults", strings.NewReader(`{"target": "auth"}`)), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Unknown target should be rejected")
+
+	w = httptest.NewRecorder()
+	api.ClearFaults(w, httptest.NewRequest("DELETE", "/api/idler/faults?target=tenant", nil), nil)
+	assert.JSONEq(t, `{"removed": 0}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.ClearFaults(w, httptest.NewRequest("DELETE", "/api/idler/faults", nil), nil)
+	assert.JSONEq(t, `{"removed": 1}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.Faults(w, httptest.NewRequest("GET", "/api/idler/faults", nil), nil)
+	assert.JSONEq(t, `{"rules": []}`, w.Body.String())
+}
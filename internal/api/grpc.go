@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/api/idlerpb"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	pnamespace "github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/validation"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var grpcLogger = log.WithFields(log.Fields{"component": "grpc"})
+
+var namespacePattern = regexp.MustCompile(validation.DNS1123LabelPattern)
+
+// GRPCServer offers the idling operations of the API via gRPC as defined in idlerpb/idler.proto. It shares the
+// logic of the REST handlers, but saves the jenkins-proxy the per-request overhead and pushes state changes
+// rather than having them polled.
+type GRPCServer struct {
+	idlerpb.UnimplementedIdlerServer
+	api        *idler
+	server     *grpc.Server
+	address    string
+	apiToken   string
+	adminToken string
+	scopes     *scope.Registry
+	namespaces *pnamespace.Filter
+	done       <-chan struct{}
+}
+
+// NewGRPCServer creates a gRPC server for the given IdlerAPI listening on the configured gRPC address. Like on the
+// REST API, Idle requires the admin API token whereas the other calls require the public API token, if set, or one of
+// the scoped tokens.
+func NewGRPCServer(idlerAPI IdlerAPI, config configuration.Configuration) (*GRPCServer, error) {
+	api, ok := idlerAPI.(*idler)
+	if !ok {
+		return nil, errors.New("gRPC requires an IdlerAPI created by NewIdlerAPI")
+	}
+
+	s := &GRPCServer{
+		api:        api,
+		address:    config.GetGRPCAddress(),
+		apiToken:   config.GetAPIToken(),
+		adminToken: config.GetAdminAPIToken(),
+		scopes:     scope.Default,
+		namespaces: pnamespace.NewFilter(config.GetNamespaceAllowlist(), config.GetNamespaceDenylist()),
+	}
+	s.server = grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := s.authorize(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := s.authorize(ss.Context(), info.FullMethod)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &scopedStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+	idlerpb.RegisterIdlerServer(s.server, s)
+	return s, nil
+}
+
+// Start serves gRPC requests until the context is done.
+func (s *GRPCServer) Start(ctx context.Context, wg *sync.WaitGroup, cancel context.CancelFunc) {
+	s.done = ctx.Done()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l, err := net.Listen("tcp", s.address)
+		if err != nil {
+			grpcLogger.WithField("err", err).Errorf("Unable to listen on %s", s.address)
+			cancel()
+			return
+		}
+
+		go func() {
+			<-ctx.Done()
+			grpcLogger.Infof("Shutting down gRPC server on %s.", s.address)
+			s.server.GracefulStop()
+		}()
+
+		grpcLogger.Infof("Starting gRPC server on %s.", s.address)
+		if err := s.server.Serve(l); err != nil {
+			grpcLogger.WithField("err", err).Error("gRPC server failed")
+			cancel()
+		}
+	}()
+}
+
+// scopedStream is a grpc.ServerStream whose context carries the scope of the caller.
+type scopedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream carrying the scope of the caller.
+func (s *scopedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize checks the bearer token passed in the authorization metadata against the token required by the method.
+// Except for Idle, scoped tokens are accepted as well, in which case the returned context carries their scope.
+func (s *GRPCServer) authorize(ctx context.Context, method string) (context.Context, error) {
+	token := s.apiToken
+	scopes := s.scopes
+	if method == idlerpb.Idler_Idle_FullMethodName {
+		token, scopes = s.adminToken, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if !strings.HasPrefix(value, "Bearer ") {
+			continue
+		}
+		if sc, ok := scopes.Lookup(value[len("Bearer "):]); ok {
+			return scope.NewContext(ctx, sc), nil
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(value[len("Bearer "):]), []byte(token)) == 1 {
+			return ctx, nil
+		}
+	}
+	if token == "" {
+		return ctx, nil
+	}
+	return ctx, grpcstatus.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// Idle idles the Jenkins services of a namespace.
+func (s *GRPCServer) Idle(ctx context.Context, req *idlerpb.IdleRequest) (*idlerpb.ServiceResults, error) {
+	if err := s.checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	openShiftAPI, err := s.api.resolveURL(req.GetOpenshiftApiUrl(), req.GetNamespace())
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := authorize(ctx, req.GetNamespace(), openShiftAPI); err != nil {
+		return nil, grpcError(err)
+	}
+
+	results, err := s.api.idle(openShiftAPI, req.GetNamespace())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return serviceResults(results)
+}
+
+// UnIdle un-idles the Jenkins services of a namespace.
+func (s *GRPCServer) UnIdle(ctx context.Context, req *idlerpb.IdleRequest) (*idlerpb.ServiceResults, error) {
+	if err := s.checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	openShiftAPI, err := s.api.resolveURL(req.GetOpenshiftApiUrl(), req.GetNamespace())
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := authorize(ctx, req.GetNamespace(), openShiftAPI); err != nil {
+		return nil, grpcError(err)
+	}
+
+	results, err := s.api.unIdle(openShiftAPI, req.GetNamespace())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return serviceResults(results)
+}
+
+// Status returns the state of Jenkins in a namespace.
+func (s *GRPCServer) Status(ctx context.Context, req *idlerpb.StatusRequest) (*idlerpb.StatusResponse, error) {
+	if err := s.checkNamespace(req.GetNamespace()); err != nil {
+		return nil, err
+	}
+
+	openShiftAPI, err := s.api.resolveURL(req.GetOpenshiftApiUrl(), req.GetNamespace())
+	if err != nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := authorize(ctx, req.GetNamespace(), openShiftAPI); err != nil {
+		return nil, grpcError(err)
+	}
+
+	response, httpStatus := s.api.status(openShiftAPI, req.GetNamespace())
+	if httpStatus != http.StatusOK {
+		description := http.StatusText(httpStatus)
+		if len(response.Errors) > 0 {
+			description = response.Errors[0].Description
+		}
+		return nil, grpcstatus.Error(grpcCode(httpStatus), description)
+	}
+
+	result := &idlerpb.StatusResponse{}
+	for _, e := range response.Errors {
+		result.Errors = append(result.Errors, &idlerpb.StatusError{Code: uint32(e.Code), Description: e.Description})
+	}
+	if data := response.Data; data != nil {
+		result.State = data.State
+		result.IdleDurationSeconds = data.IdleDurationSeconds
+		result.TotalIdleDurationSeconds = data.TotalIdleDurationSeconds
+		result.Restarts = int32(data.Restarts)
+		result.OomKilled = data.OOMKilled
+		result.JenkinsVersion = data.JenkinsVersion
+		result.EstimatedReadyInSeconds = data.EstimatedReadyInSeconds
+		if data.IdledSince != nil {
+			result.IdledSince = timestamppb.New(*data.IdledSince)
+		}
+	}
+	return result, nil
+}
+
+// WatchStatus streams the state changes of Jenkins until the client cancels the call or the server shuts down.
+func (s *GRPCServer) WatchStatus(req *idlerpb.WatchStatusRequest, stream idlerpb.Idler_WatchStatusServer) error {
+	ns := strings.TrimSpace(req.GetNamespace())
+	if ns != "" {
+		ns = util.EnsureSuffix(ns, pnamespace.JenkinsSuffix)
+		if !s.namespaces.Manages(ns) {
+			return grpcstatus.Errorf(codes.PermissionDenied, "namespace %s is not managed by the idler", ns)
+		}
+		if err := s.api.authorizeNamespace(stream.Context(), "", ns); err != nil {
+			return grpcError(err)
+		}
+	}
+	events, cancel := pidler.Events.Subscribe(ns)
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return grpcstatus.Error(codes.Unavailable, "server is shutting down")
+		case e := <-events:
+			if !s.namespaces.Manages(e.Namespace) || s.api.authorizeNamespace(stream.Context(), "", e.Namespace) != nil {
+				continue
+			}
+			err := stream.Send(&idlerpb.StateEvent{
+				Namespace: e.Namespace,
+				State:     e.State,
+				Previous:  e.Previous,
+				Time:      timestamppb.New(e.Time),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkNamespace validates the namespace of a request and refuses the namespaces which are not managed by the Idler,
+// like the REST API does.
+func (s *GRPCServer) checkNamespace(namespace string) error {
+	if namespace == "" {
+		return grpcstatus.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if len(namespace) > validation.DNS1123LabelMaxLength || !namespacePattern.MatchString(namespace) {
+		return grpcstatus.Error(codes.InvalidArgument, "namespace must be a DNS-1123 label")
+	}
+	if !s.namespaces.Manages(namespace) {
+		return grpcstatus.Errorf(codes.PermissionDenied, "namespace %s is not managed by the idler", namespace)
+	}
+	return nil
+}
+
+// serviceResults converts the results of idling resp. un-idling. If any service failed, the call fails as a whole,
+// just like the REST API responds with status 500.
+func serviceResults(results pidler.ServiceResults) (*idlerpb.ServiceResults, error) {
+	if err := results.Err(); err != nil {
+		grpcLogger.WithField("failed", results.Failed()).Error(err)
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+
+	converted := &idlerpb.ServiceResults{}
+	for _, r := range results {
+		converted.Services = append(converted.Services, &idlerpb.ServiceResult{Service: r.Service})
+	}
+	return converted, nil
+}
+
+func grpcError(err error) error {
+	return grpcstatus.Error(grpcCode(errorStatus(err)), err.Error())
+}
+
+// grpcCode maps the HTTP status of an API error to the corresponding gRPC code.
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
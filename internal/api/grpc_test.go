@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/api/idlerpb"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func Test_grpc(t *testing.T) {
+	hub := pidler.Events
+	pidler.Events = events.NewHub()
+	defer func() { pidler.Events = hub }()
+
+	mosc := &mock.OpenShiftClient{}
+	api := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+	server, err := NewGRPCServer(api, &mock.Config{APIToken: "public-token", AdminAPIToken: "admin-token"})
+	require.NoError(t, err)
+
+	l := bufconn.Listen(1024 * 1024)
+	go server.server.Serve(l)
+	defer server.server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := idlerpb.NewIdlerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	req := &idlerpb.IdleRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-jenkins"}
+
+	_, err = client.Idle(ctx, req)
+	require.Equal(t, codes.Unauthenticated, grpcstatus.Code(err), "Unauthenticated call should be refused")
+
+	_, err = client.Idle(withToken("public-token"), req)
+	require.Equal(t, codes.Unauthenticated, grpcstatus.Code(err), "Idle should require the admin token")
+
+	results, err := client.Idle(withToken("admin-token"), req)
+	require.NoError(t, err)
+	require.Len(t, results.Services, len(pidler.JenkinsServices))
+	require.Equal(t, 1, mosc.IdleCallCount)
+
+	_, err = client.UnIdle(withToken("public-token"), &idlerpb.IdleRequest{OpenshiftApiUrl: "http://localhost", Namespace: "Foo_Jenkins"})
+	require.Equal(t, codes.InvalidArgument, grpcstatus.Code(err), "Malformed namespace should be rejected")
+
+	response, err := client.Status(withToken("public-token"), &idlerpb.StatusRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-jenkins"})
+	require.NoError(t, err)
+	require.NotEmpty(t, response.State)
+
+	stream, err := client.WatchStatus(withToken("public-token"), &idlerpb.WatchStatusRequest{Namespace: "foo"})
+	require.NoError(t, err)
+	for pidler.Events.Len() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	pidler.Events.Publish(events.Event{Namespace: "bar-jenkins", State: "idled", Time: now})
+	pidler.Events.Publish(events.Event{Namespace: "foo-jenkins", State: "starting", Previous: "idled", Time: now})
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "foo-jenkins", event.Namespace)
+	require.Equal(t, "starting", event.State)
+	require.Equal(t, "idled", event.Previous)
+	require.Equal(t, now, event.Time.AsTime())
+}
+
+func Test_grpc_scoped_token(t *testing.T) {
+	scopes := scope.Default
+	defer func() { scope.Default = scopes }()
+	var err error
+	scope.Default, err = scope.NewRegistry([]scope.Scope{{Name: "team-a", Token: "team-token", Namespaces: []string{"foo-jenkins"}}})
+	require.NoError(t, err)
+
+	api := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: &mock.OpenShiftClient{},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+	server, err := NewGRPCServer(api, &mock.Config{APIToken: "public-token", AdminAPIToken: "admin-token"})
+	require.NoError(t, err)
+
+	l := bufconn.Listen(1024 * 1024)
+	go server.server.Serve(l)
+	defer server.server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := idlerpb.NewIdlerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer team-token")
+
+	_, err = client.Status(ctx, &idlerpb.StatusRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-jenkins"})
+	require.NoError(t, err, "Namespace in scope should be served")
+
+	_, err = client.Status(ctx, &idlerpb.StatusRequest{OpenshiftApiUrl: "http://localhost", Namespace: "bar-jenkins"})
+	require.Equal(t, codes.PermissionDenied, grpcstatus.Code(err), "Namespace out of scope should be refused")
+
+	_, err = client.Idle(ctx, &idlerpb.IdleRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-jenkins"})
+	require.Equal(t, codes.Unauthenticated, grpcstatus.Code(err), "Idle should require the admin token")
+}
+
+func Test_grpc_namespace_filter(t *testing.T) {
+	mosc := &mock.OpenShiftClient{}
+	api := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+	}
+	server, err := NewGRPCServer(api, &mock.Config{NamespaceDenylist: []string{"*-preview"}})
+	require.NoError(t, err)
+
+	l := bufconn.Listen(1024 * 1024)
+	go server.server.Serve(l)
+	defer server.server.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := idlerpb.NewIdlerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req := &idlerpb.IdleRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-preview-jenkins"}
+
+	_, err = client.Idle(ctx, req)
+	require.Equal(t, codes.PermissionDenied, grpcstatus.Code(err), "Idling a denylisted namespace should be refused")
+	_, err = client.UnIdle(ctx, req)
+	require.Equal(t, codes.PermissionDenied, grpcstatus.Code(err), "Un-idling a denylisted namespace should be refused")
+	_, err = client.Status(ctx, &idlerpb.StatusRequest{OpenshiftApiUrl: "http://localhost", Namespace: "foo-preview-jenkins"})
+	require.Equal(t, codes.PermissionDenied, grpcstatus.Code(err), "Status of a denylisted namespace should be refused")
+	require.Equal(t, 0, mosc.IdleCallCount+mosc.UnIdleCallCount)
+
+	stream, err := client.WatchStatus(ctx, &idlerpb.WatchStatusRequest{Namespace: "foo-preview"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.PermissionDenied, grpcstatus.Code(err), "Watching a denylisted namespace should be refused")
+}
@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: idler.proto
+
+package idlerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Idler_Idle_FullMethodName        = "/idler.v1.Idler/Idle"
+	Idler_UnIdle_FullMethodName      = "/idler.v1.Idler/UnIdle"
+	Idler_Status_FullMethodName      = "/idler.v1.Idler/Status"
+	Idler_WatchStatus_FullMethodName = "/idler.v1.Idler/WatchStatus"
+)
+
+// IdlerClient is the client API for Idler service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IdlerClient interface {
+	// Idle idles the Jenkins services of a namespace. The call fails if idling any of the services fails.
+	Idle(ctx context.Context, in *IdleRequest, opts ...grpc.CallOption) (*ServiceResults, error)
+	// UnIdle un-idles the Jenkins services of a namespace unless Jenkins is already starting or running,
+	// in which case no service results are returned.
+	UnIdle(ctx context.Context, in *IdleRequest, opts ...grpc.CallOption) (*ServiceResults, error)
+	// Status returns the state of Jenkins in a namespace.
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	// WatchStatus streams the state changes of Jenkins in a single namespace resp. in all namespaces.
+	WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (Idler_WatchStatusClient, error)
+}
+
+type idlerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIdlerClient(cc grpc.ClientConnInterface) IdlerClient {
+	return &idlerClient{cc}
+}
+
+func (c *idlerClient) Idle(ctx context.Context, in *IdleRequest, opts ...grpc.CallOption) (*ServiceResults, error) {
+	out := new(ServiceResults)
+	err := c.cc.Invoke(ctx, Idler_Idle_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *idlerClient) UnIdle(ctx context.Context, in *IdleRequest, opts ...grpc.CallOption) (*ServiceResults, error) {
+	out := new(ServiceResults)
+	err := c.cc.Invoke(ctx, Idler_UnIdle_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *idlerClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, Idler_Status_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *idlerClient) WatchStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (Idler_WatchStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Idler_ServiceDesc.Streams[0], Idler_WatchStatus_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &idlerWatchStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Idler_WatchStatusClient interface {
+	Recv() (*StateEvent, error)
+	grpc.ClientStream
+}
+
+type idlerWatchStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *idlerWatchStatusClient) Recv() (*StateEvent, error) {
+	m := new(StateEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IdlerServer is the server API for Idler service.
+// All implementations must embed UnimplementedIdlerServer
+// for forward compatibility
+type IdlerServer interface {
+	// Idle idles the Jenkins services of a namespace. The call fails if idling any of the services fails.
+	Idle(context.Context, *IdleRequest) (*ServiceResults, error)
+	// UnIdle un-idles the Jenkins services of a namespace unless Jenkins is already starting or running,
+	// in which case no service results are returned.
+	UnIdle(context.Context, *IdleRequest) (*ServiceResults, error)
+	// Status returns the state of Jenkins in a namespace.
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	// WatchStatus streams the state changes of Jenkins in a single namespace resp. in all namespaces.
+	WatchStatus(*WatchStatusRequest, Idler_WatchStatusServer) error
+	mustEmbedUnimplementedIdlerServer()
+}
+
+// UnimplementedIdlerServer must be embedded to have forward compatible implementations.
+type UnimplementedIdlerServer struct {
+}
+
+func (UnimplementedIdlerServer) Idle(context.Context, *IdleRequest) (*ServiceResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Idle not implemented")
+}
+func (UnimplementedIdlerServer) UnIdle(context.Context, *IdleRequest) (*ServiceResults, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnIdle not implemented")
+}
+func (UnimplementedIdlerServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedIdlerServer) WatchStatus(*WatchStatusRequest, Idler_WatchStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
+func (UnimplementedIdlerServer) mustEmbedUnimplementedIdlerServer() {}
+
+// UnsafeIdlerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IdlerServer will
+// result in compilation errors.
+type UnsafeIdlerServer interface {
+	mustEmbedUnimplementedIdlerServer()
+}
+
+func RegisterIdlerServer(s grpc.ServiceRegistrar, srv IdlerServer) {
+	s.RegisterService(&Idler_ServiceDesc, srv)
+}
+
+func _Idler_Idle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdlerServer).Idle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Idler_Idle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdlerServer).Idle(ctx, req.(*IdleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Idler_UnIdle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdlerServer).UnIdle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Idler_UnIdle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdlerServer).UnIdle(ctx, req.(*IdleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Idler_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdlerServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Idler_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdlerServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Idler_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IdlerServer).WatchStatus(m, &idlerWatchStatusServer{stream})
+}
+
+type Idler_WatchStatusServer interface {
+	Send(*StateEvent) error
+	grpc.ServerStream
+}
+
+type idlerWatchStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *idlerWatchStatusServer) Send(m *StateEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Idler_ServiceDesc is the grpc.ServiceDesc for Idler service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Idler_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "idler.v1.Idler",
+	HandlerType: (*IdlerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Idle",
+			Handler:    _Idler_Idle_Handler,
+		},
+		{
+			MethodName: "UnIdle",
+			Handler:    _Idler_UnIdle_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Idler_Status_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _Idler_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "idler.proto",
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	contentTypeJSON = "application/json"
+	contentTypeYAML = "application/yaml"
+	contentTypeText = "text/plain"
+)
+
+// mediaTypes maps the media types accepted by the read endpoints to the content type they are answered with.
+var mediaTypes = map[string]string{
+	"application/json":   contentTypeJSON,
+	"application/yaml":   contentTypeYAML,
+	"application/x-yaml": contentTypeYAML,
+	"text/yaml":          contentTypeYAML,
+	"text/plain":         contentTypeText,
+}
+
+// negotiate returns the content type preferred by the Accept header of the request among JSON, YAML and plain text.
+// Media ranges are weighted by their quality, ties are resolved in the order of the header. It defaults to JSON.
+func negotiate(r *http.Request) string {
+	best, bestQuality := contentTypeJSON, 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		contentType, ok := mediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > bestQuality {
+			best, bestQuality = contentType, quality
+		}
+	}
+	return best
+}
+
+// writeNegotiatedResponse writes the response like writeResponse, but as YAML or as plain text if preferred by the
+// client. Both are derived from the JSON representation, so that all formats share the same field names.
+func writeNegotiatedResponse(w http.ResponseWriter, r *http.Request, status int, response any) {
+	contentType := negotiate(r)
+	if contentType == contentTypeJSON {
+		writeResponse(w, status, response)
+		return
+	}
+
+	body, err := encode(contentType, response)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Errorf("Could not serialize the response: %s", err))
+		return
+	}
+	w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// encode serializes the response as YAML or plain text.
+func encode(contentType string, response any) ([]byte, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	value = normalize(value)
+
+	if contentType == contentTypeYAML {
+		return yaml.Marshal(value)
+	}
+	buf := &bytes.Buffer{}
+	writeText(buf, "", value)
+	return buf.Bytes(), nil
+}
+
+// normalize converts the numbers of a decoded JSON value to integers where possible, so that they are not rendered
+// in exponent notation.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, element := range v {
+			v[key] = normalize(element)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = normalize(element)
+		}
+	}
+	return value
+}
+
+// writeText writes a decoded JSON value as lines of key=value pairs sorted by key, the keys being the dot-separated
+// paths of the values, e.g. data.state=running. This output can be processed by grep and cut.
+func writeText(buf *bytes.Buffer, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeText(buf, join(path, key), v[key])
+		}
+	case []interface{}:
+		for i, element := range v {
+			writeText(buf, join(path, strconv.Itoa(i)), element)
+		}
+	case nil:
+	default:
+		fmt.Fprintf(buf, "%s=%v\n", path, v)
+	}
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_negotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                                   contentTypeJSON,
+		"*/*":                                contentTypeJSON,
+		"application/json":                   contentTypeJSON,
+		"application/yaml":                   contentTypeYAML,
+		"application/x-yaml":                 contentTypeYAML,
+		"text/plain; charset=utf-8":          contentTypeText,
+		"text/html, text/plain":              contentTypeText,
+		"application/json, application/yaml": contentTypeJSON,
+		"application/json;q=0.5, application/yaml":   contentTypeYAML,
+		"text/plain;q=0.2, application/json;q=0.9":   contentTypeJSON,
+		"text/plain;q=invalid, application/json;q=0": contentTypeText,
+	}
+
+	for accept, expected := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", accept)
+		assert.Equal(t, expected, negotiate(r), "Unexpected content type for Accept: %s", accept)
+	}
+}
+
+func Test_Status_negotiates_content_type(t *testing.T) {
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodCrashLooping},
+		clusterView:     &mock.ClusterView{},
+	}
+	params := httprouter.Params{httprouter.Param{Key: "namespace", Value: "foobar-jenkins"}}
+
+	r := httptest.NewRequest("GET", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	r.Header.Set("Accept", "application/yaml")
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, r, params)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, `data:
+  state: crash_looping
+errors:
+- code: 3
+  description: jenkins keeps crashing after being started
+`, w.Body.String())
+
+	r.Header.Set("Accept", "text/plain")
+	w = httptest.NewRecorder()
+	mockIdler.Status(w, r, params)
+	require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, `data.state=crash_looping
+errors.0.code=3
+errors.0.description=jenkins keeps crashing after being started
+`, w.Body.String())
+
+	r.Header.Del("Accept")
+	w = httptest.NewRecorder()
+	mockIdler.Status(w, r, params)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func Test_ClusterDNSView_as_text(t *testing.T) {
+	mockIdler := &idler{clusterView: cluster.NewView([]cluster.Cluster{
+		{APIURL: "https://api.starter-us-east-2.openshift.com/", AppDNS: "8a09.starter-us-east-2.openshiftapps.com"},
+	})}
+
+	r := httptest.NewRequest("GET", "/api/idler/cluster", nil)
+	r.Header.Set("Accept", "text/plain")
+	w := httptest.NewRecorder()
+	mockIdler.ClusterDNSView(w, r, nil)
+	require.Equal(t, "0.APIURL=https://api.starter-us-east-2.openshift.com/\n0.AppDNS=8a09.starter-us-east-2.openshiftapps.com\n"+
+		"0.RouteSource=template\n0.RouteTemplate=jenkins-{namespace}.8a09.starter-us-east-2.openshiftapps.com\n", w.Body.String())
+}
@@ -0,0 +1,444 @@
+package api
+
+import (
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/callback"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/fault"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openapi"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/validation"
+)
+
+// errorResponse is the body written by respondWithError resp. in case of a failed request validation.
+type errorResponse struct {
+	Error  string            `json:"error"`
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+var (
+	namespaceParam = openapi.Parameter{
+		Name:        "namespace",
+		In:          "path",
+		Description: "The namespace of the Jenkins service.",
+		Required:    true,
+		Schema: &openapi.Schema{
+			Type:      "string",
+			Pattern:   validation.DNS1123LabelPattern,
+			MaxLength: validation.DNS1123LabelMaxLength,
+		},
+	}
+	eventNamespaceParam = openapi.Parameter{
+		Name:        EventNamespaceParam,
+		In:          "query",
+		Description: "The namespace to stream the events of. Events of all namespaces are streamed if omitted.",
+		Schema: &openapi.Schema{
+			Type:      "string",
+			Pattern:   validation.DNS1123LabelPattern,
+			MaxLength: validation.DNS1123LabelMaxLength,
+		},
+	}
+	clusterParam = openapi.Parameter{
+		Name:        OpenShiftAPIParam,
+		In:          "query",
+		Description: "The API URL of the OpenShift cluster hosting the namespace, resolved by the Idler if omitted.",
+		Schema:      &openapi.Schema{Type: "string", Format: "uri"},
+	}
+
+	reservationTokenParam = openapi.Parameter{
+		Name:        ReservationTokenParam,
+		In:          "path",
+		Description: "The token of the un-idle reservation.",
+		Required:    true,
+		Schema:      &openapi.Schema{Type: "string", Pattern: "^[0-9a-f]+$"},
+	}
+
+	serviceParam = openapi.Parameter{
+		Name:        ServiceParam,
+		In:          "query",
+		Description: "The service to reset, jenkins if omitted.",
+		Schema: &openapi.Schema{
+			Type:      "string",
+			Pattern:   validation.DNS1123LabelPattern,
+			MaxLength: validation.DNS1123LabelMaxLength,
+		},
+	}
+	strategyParam = openapi.Parameter{
+		Name:        StrategyParam,
+		In:          "query",
+		Description: "Whether to delete the pods of the service or to trigger a new rollout of its deployment config, delete if omitted.",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{ResetStrategyDelete, ResetStrategyRollout}},
+	}
+
+	faultTargetParam = openapi.Parameter{
+		Name:        FaultTargetParam,
+		In:          "query",
+		Description: "The target to remove the rules of. All rules are removed if omitted.",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{fault.OpenShift, fault.Tenant}},
+	}
+
+	verifyParam = openapi.Parameter{
+		Name:        VerifyParam,
+		In:          "query",
+		Description: "Whether to check that the route resolves and serves TLS correctly.",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{"true", "false"}},
+	}
+
+	timeoutParam = openapi.Parameter{
+		Name:        TimeoutParam,
+		In:          "query",
+		Description: "The number of seconds to wait for the replacement pods to get scheduled when deleting pods, 0 to return right away. Defaults to JC_RESET_TIMEOUT.",
+		Schema:      &openapi.Schema{Type: "string", Pattern: "^[0-9]+$"},
+	}
+
+	errorContent = openapi.JSON(openapi.Ref("Error"))
+
+	serviceResultsContent = openapi.JSON(openapi.Ref("ServiceResults"))
+)
+
+// Schemas contains the schemas of all types exchanged via the IdlerAPI keyed against their component name.
+var Schemas = map[string]*openapi.Schema{
+	"Error":            openapi.SchemaOf(errorResponse{}),
+	"IdleStatus":       openapi.SchemaOf(status{}),
+	"ServiceResults":   openapi.SchemaOf(serviceResultsResponse{}),
+	"StatusResponse":   openapi.SchemaOf(statusResponse{}),
+	"UserStatus":       openapi.SchemaOf(userStatus{}),
+	"DisabledUsers":    openapi.SchemaOf(idlerStatusResponse{}),
+	"ClusterStatus":    openapi.SchemaOf(clusterStatus{}),
+	"DisabledClusters": openapi.SchemaOf(disabledClustersResponse{}),
+	"JenkinsVersions":  openapi.SchemaOf(jenkinsVersionsResponse{}),
+	"AggregateStatus":  openapi.SchemaOf(aggregateStatusResponse{}),
+	"DeadLetters":      openapi.SchemaOf(deadLettersResponse{}),
+	"Recommendations":  openapi.SchemaOf(recommendationsResponse{}),
+	"RateLimits":       openapi.SchemaOf(rateLimitsResponse{}),
+	"Faults":           openapi.SchemaOf(faultsResponse{}),
+	"Fault":            openapi.SchemaOf(fault.Rule{}),
+	"ClearedFaults":    openapi.SchemaOf(clearFaultsResponse{}),
+	"IdlerTimers":      openapi.SchemaOf(idlerTimersResponse{}),
+	"Event":            openapi.SchemaOf(events.Event{}),
+	"Reserved":         openapi.SchemaOf(reserveResponse{}),
+	"Reservation":      openapi.SchemaOf(reservationResponse{}),
+	"CallbackRequest":  openapi.SchemaOf(callbackRequest{}),
+	"Callback":         openapi.SchemaOf(callback.Registration{}),
+	"DNSView":          openapi.SchemaOf([]cluster.DNSView{}),
+	"JenkinsRoute":     openapi.SchemaOf(routeResponse{}),
+	"Version":          openapi.SchemaOf(versionResponse{}),
+	"LogLevel":         openapi.SchemaOf(logLevelResponse{}),
+	"LogLevelChange":   openapi.SchemaOf(logLevelRequest{}),
+	"Capacity":         openapi.SchemaOf(capacityResponse{}),
+	"Reset":            openapi.SchemaOf(resetResponse{}),
+	"Toggles":          openapi.SchemaOf(togglesResponse{}),
+	"Config":           openapi.SchemaOf(configResponse{}),
+}
+
+// Operations documents the handlers of the IdlerAPI keyed against the handler name.
+var Operations = map[string]openapi.Operation{
+	"Idle": {
+		OperationID: "idle",
+		Summary:     "Idles the Jenkins service of the namespace.",
+		Parameters:  []openapi.Parameter{namespaceParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Jenkins got idled.", Content: serviceResultsContent},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"500": {Description: "Idling failed for at least one service.", Content: serviceResultsContent},
+		},
+	},
+	"UnIdle": {
+		OperationID: "unidle",
+		Summary:     "Un-idles the Jenkins service of the namespace.",
+		Parameters:  []openapi.Parameter{namespaceParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Jenkins got un-idled or is already starting/running.", Content: serviceResultsContent},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"500": {Description: "Un-idling failed for at least one service.", Content: serviceResultsContent},
+			"503": {Description: "The cluster has reached its maximum capacity, see the Retry-After header.", Content: openapi.JSON(openapi.Ref("Capacity"))},
+		},
+	},
+	"IsIdle": {
+		OperationID: "isIdle",
+		Summary:     "Returns whether the Jenkins service of the namespace is idled.",
+		Parameters:  []openapi.Parameter{namespaceParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The idle status.", Content: openapi.Negotiable(openapi.Ref("IdleStatus"))},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"500": {Description: "The state could not be determined.", Content: errorContent},
+		},
+	},
+	"Status": {
+		OperationID: "status",
+		Summary:     "Returns the state of the Jenkins service of the namespace.",
+		Parameters:  []openapi.Parameter{namespaceParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The Jenkins state.", Content: openapi.Negotiable(openapi.Ref("StatusResponse"))},
+			"400": {Description: "Missing or invalid parameters.", Content: openapi.Negotiable(openapi.Ref("StatusResponse"))},
+			"500": {Description: "The state could not be determined.", Content: openapi.Negotiable(openapi.Ref("StatusResponse"))},
+		},
+	},
+	"ClusterDNSView": {
+		OperationID: "clusterDNSView",
+		Summary:     "Returns the API URL and application DNS of all clusters.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The cluster DNS view.", Content: openapi.Negotiable(openapi.Ref("DNSView"))},
+		},
+	},
+	"JenkinsRoute": {
+		OperationID: "jenkinsRoute",
+		Summary:     "Returns the URL of the Jenkins route of the namespace.",
+		Description: "The URL is derived from JC_ROUTE_TEMPLATE and the application domain of the cluster, or read from " +
+			"the jenkins Route object of the namespace if JC_ROUTE_SOURCE is route.",
+		Parameters: []openapi.Parameter{namespaceParam, clusterParam, verifyParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The route URL and how it got determined.", Content: openapi.Negotiable(openapi.Ref("JenkinsRoute"))},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"500": {Description: "The Route object could not be read.", Content: errorContent},
+		},
+	},
+	"Reset": {
+		OperationID: "reset",
+		Summary:     "Deletes the pods of a service of the namespace so that new ones get started, or triggers a new rollout of its deployment config.",
+		Parameters:  []openapi.Parameter{namespaceParam, clusterParam, serviceParam, strategyParam, timeoutParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The pods got deleted resp. the rollout got triggered. The rollout is named in the body.", Content: openapi.JSON(openapi.Ref("Reset"))},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"409": {Description: "Deleting the pods would violate a PodDisruptionBudget.", Content: errorContent},
+			"500": {Description: "Reset failed.", Content: errorContent},
+			"504": {Description: "The replacement pods did not get scheduled within the timeout.", Content: errorContent},
+		},
+	},
+	"SetUserIdlerStatus": {
+		OperationID: "setUserIdlerStatus",
+		Summary:     "Enables resp. disables the idler for the given users.",
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSON(openapi.Ref("UserStatus")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The user status got updated."},
+			"400": {Description: "Invalid or too large request body.", Content: errorContent},
+		},
+	},
+	"GetDisabledUserIdlers": {
+		OperationID: "getDisabledUserIdlers",
+		Summary:     "Returns the users for which the idler is disabled.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The disabled users.", Content: openapi.Negotiable(openapi.Ref("DisabledUsers"))},
+		},
+	},
+	"SetClusterStatus": {
+		OperationID: "setClusterStatus",
+		Summary:     "Enables resp. disables idling for the given clusters.",
+		Description: "While idling is disabled for a cluster, its Jenkins instances are neither idled nor un-idled.",
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSON(openapi.Ref("ClusterStatus")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The cluster status got updated."},
+			"400": {Description: "Invalid or too large request body, or unknown cluster.", Content: errorContent},
+		},
+	},
+	"GetDisabledClusters": {
+		OperationID: "getDisabledClusters",
+		Summary:     "Returns the API URLs of the clusters for which idling is disabled.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The disabled clusters.", Content: openapi.Negotiable(openapi.Ref("DisabledClusters"))},
+		},
+	},
+	"JenkinsVersions": {
+		OperationID: "jenkinsVersions",
+		Summary:     "Returns the Jenkins versions last observed per namespace.",
+		Description: "The versions are observed by the health probes of running Jenkins instances.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The Jenkins versions keyed against the namespace.", Content: openapi.Negotiable(openapi.Ref("JenkinsVersions"))},
+		},
+	},
+	"DeadLetters": {
+		OperationID: "deadLetters",
+		Summary:     "Returns the events whose handling failed.",
+		Description: "The events are retried with backoff until their handling succeeds. Events whose retries are exhausted are kept for inspection until they get pushed out by newer ones.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The failed events in the order they got queued.", Content: openapi.Negotiable(openapi.Ref("DeadLetters"))},
+		},
+	},
+	"MemoryRecommendations": {
+		OperationID: "memoryRecommendations",
+		Summary:     "Returns the recommended memory limit increases of Jenkins instances which keep running out of memory.",
+		Description: "An increase is recommended once Jenkins got killed for running out of memory JC_OOM_KILL_THRESHOLD times within JC_OOM_KILL_WINDOW minutes. For users with the jenkins.idler.oom-limit-bump feature enabled, the recommendations are applied to the deployment config of Jenkins in the background.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The latest recommendation per namespace, ordered by namespace.", Content: openapi.Negotiable(openapi.Ref("Recommendations"))},
+		},
+	},
+	"RateLimits": {
+		OperationID: "rateLimits",
+		Summary:     "Returns the usage of the rate limit of the public API per caller.",
+		Description: "Each caller, identified by the scope of its token resp. its address, may make JC_RATE_LIMIT requests within JC_RATE_LIMIT_WINDOW seconds. Callers are listed until an hour after their last window ended. The public API states the usage of the caller in the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of its responses.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The rate limit along with the usage per caller, ordered by caller.", Content: openapi.Negotiable(openapi.Ref("RateLimits"))},
+		},
+	},
+	"Faults": {
+		OperationID: "faults",
+		Summary:     "Returns the rules by which faults are injected into the requests to OpenShift and the tenant service.",
+		Description: "Fault injection is only available in builds with the faultinjection tag, which are meant for staging.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The rules along with the number of requests they affected.", Content: openapi.Negotiable(openapi.Ref("Faults"))},
+			"404": {Description: "Fault injection is not available in this build.", Content: errorContent},
+		},
+	},
+	"SetFault": {
+		OperationID: "setFault",
+		Summary:     "Injects latency and errors into the requests to OpenShift or the tenant service.",
+		Description: "Matching requests are delayed by latency_ms, then the fraction given by error_rate fails, with a " +
+			"transport error or, if given, a response of the HTTP status. A rule restricted to a host takes precedence " +
+			"over the one of the whole target and replaces an earlier rule of the same target and host.",
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSON(openapi.Ref("Fault")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The updated rules.", Content: openapi.JSON(openapi.Ref("Faults"))},
+			"400": {Description: "Invalid request body or rule.", Content: errorContent},
+			"404": {Description: "Fault injection is not available in this build.", Content: errorContent},
+		},
+	},
+	"ClearFaults": {
+		OperationID: "clearFaults",
+		Summary:     "Removes the fault injection rules of a target or all of them.",
+		Parameters:  []openapi.Parameter{faultTargetParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The number of removed rules.", Content: openapi.JSON(openapi.Ref("ClearedFaults"))},
+			"400": {Description: "Invalid parameters.", Content: errorContent},
+			"404": {Description: "Fault injection is not available in this build.", Content: errorContent},
+		},
+	},
+	"AggregateStatus": {
+		OperationID: "aggregateStatus",
+		Summary:     "Returns the number of Jenkins instances per state and cluster.",
+		Description: "The states are tracked by the user idlers fed by the watches, so that no cluster is queried.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The counts per state keyed against the API URL of the cluster, and in total.", Content: openapi.Negotiable(openapi.Ref("AggregateStatus"))},
+		},
+	},
+	"IdlerTimers": {
+		OperationID: "idlerTimers",
+		Summary:     "Returns the countdown until Jenkins gets idled, the last idler decision and the resource footprint per namespace.",
+		Description: "The countdown is only given for running Jenkins instances without active builds for which idling is enabled. It is computed from the state tracked by the user idlers, so that no cluster is queried. The resources are taken from the last observed deployment config, the usage from the last measurement if JC_FOOTPRINT_INTERVAL is set.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The timers keyed against the namespace.", Content: openapi.Negotiable(openapi.Ref("IdlerTimers"))},
+		},
+	},
+	"LogLevel": {
+		OperationID: "logLevel",
+		Summary:     "Returns the global log level and the per component overrides.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The log levels.", Content: openapi.JSON(openapi.Ref("LogLevel"))},
+		},
+	},
+	"SetLogLevel": {
+		OperationID: "setLogLevel",
+		Summary:     "Changes the global log level or, if a component is given, the log level of the component.",
+		Description: "An empty level together with a component removes the override of the component.",
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSON(openapi.Ref("LogLevelChange")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The updated log levels.", Content: openapi.JSON(openapi.Ref("LogLevel"))},
+			"400": {Description: "Invalid request body or unknown level.", Content: errorContent},
+		},
+	},
+	"EventStream": {
+		OperationID: "eventStream",
+		Summary:     "Streams the state changes of the Jenkins instances as Server-Sent Events.",
+		Description: "Each state change (e.g. idled, starting, running, error) is sent as event `state` carrying the " +
+			"namespace, the new and the previous state as JSON data. The stream ends shortly before the write " +
+			"timeout of the API server; clients are expected to reconnect.",
+		Parameters: []openapi.Parameter{eventNamespaceParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The event stream.", Content: map[string]openapi.MediaType{"text/event-stream": {Schema: openapi.Ref("Event")}}},
+			"400": {Description: "Invalid parameters.", Content: errorContent},
+		},
+	},
+	"Reserve": {
+		OperationID: "reserve",
+		Summary:     "Un-idles Jenkins of the namespace and keeps it from being idled until the reservation expires or gets released.",
+		Description: "The namespace is reserved before Jenkins gets un-idled, for JC_RESERVATION_TTL seconds. The Jenkins " +
+			"Proxy passes the returned token when asking whether it is safe to replay the buffered webhooks, so that " +
+			"Jenkins cannot get idled while they are replayed.",
+		Parameters: []openapi.Parameter{namespaceParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The reservation; services are listed if Jenkins got un-idled.", Content: openapi.Negotiable(openapi.Ref("Reserved"))},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"500": {Description: "Un-idling Jenkins failed, no reservation got made.", Content: openapi.JSON(openapi.Ref("ServiceResults"))},
+			"503": {Description: "Idling is disabled for the cluster or the cluster reached its capacity, no reservation got made.", Content: openapi.JSON(openapi.Ref("Capacity"))},
+		},
+	},
+	"Reservation": {
+		OperationID: "reservation",
+		Summary:     "Tells whether it is safe to replay buffered webhooks, i.e. whether the reservation is valid and Jenkins is running.",
+		Parameters:  []openapi.Parameter{namespaceParam, reservationTokenParam, clusterParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The reservation and whether it is safe to replay.", Content: openapi.Negotiable(openapi.Ref("Reservation"))},
+			"400": {Description: "Missing or invalid parameters.", Content: errorContent},
+			"404": {Description: "The reservation does not exist or expired.", Content: errorContent},
+			"500": {Description: "The state of Jenkins could not be determined.", Content: errorContent},
+		},
+	},
+	"ReleaseReservation": {
+		OperationID: "releaseReservation",
+		Summary:     "Releases the reservation, e.g. once the buffered webhooks got replayed.",
+		Parameters:  []openapi.Parameter{namespaceParam, reservationTokenParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The reservation got released."},
+			"404": {Description: "The reservation does not exist or expired.", Content: errorContent},
+		},
+	},
+	"RegisterCallback": {
+		OperationID: "registerCallback",
+		Summary:     "Registers a URL to be called back once Jenkins of the namespace is running.",
+		Description: "Whenever Jenkins of the namespace transitions to running, the URL is called with a POST request " +
+			"carrying the state change as JSON, like the events of the event stream. This allows the Jenkins Proxy to " +
+			"release buffered webhooks without polling the idle status. A registration replaces the previous one of " +
+			"the namespace and stays until it gets removed.",
+		Parameters: []openapi.Parameter{namespaceParam},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content:  openapi.JSON(openapi.Ref("CallbackRequest")),
+		},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The callback got registered.", Content: openapi.Negotiable(openapi.Ref("Callback"))},
+			"400": {Description: "Invalid request body or callback URL.", Content: errorContent},
+		},
+	},
+	"UnregisterCallback": {
+		OperationID: "unregisterCallback",
+		Summary:     "Removes the callback registered for the namespace.",
+		Parameters:  []openapi.Parameter{namespaceParam},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The callback got removed."},
+			"404": {Description: "No callback is registered for the namespace.", Content: errorContent},
+		},
+	},
+	"Version": {
+		OperationID: "version",
+		Summary:     "Returns the build and runtime information of the Idler.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The version information.", Content: openapi.JSON(openapi.Ref("Version"))},
+		},
+	},
+	"Toggles": {
+		OperationID: "toggles",
+		Summary:     "Returns the feature toggle definitions currently in use.",
+		Description: "These are the definitions last fetched from Unleash resp. the fixed list of user IDs.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The feature toggles and their strategies.", Content: openapi.JSON(openapi.Ref("Toggles"))},
+		},
+	},
+	"EffectiveConfig": {
+		OperationID: "effectiveConfig",
+		Summary:     "Returns the effective configuration.",
+		Description: "Each option is listed with the layer it got set by: default, profile, file, env or flag. Secrets are masked.",
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The profile and the effective settings sorted by key.", Content: openapi.Negotiable(openapi.Ref("Config"))},
+		},
+	},
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/julienschmidt/httprouter"
+)
+
+type rateLimitsResponse struct {
+	Enabled       bool              `json:"enabled"`
+	Limit         int               `json:"limit"`
+	WindowSeconds int               `json:"window_seconds"`
+	Callers       []ratelimit.Usage `json:"callers"`
+}
+
+// RateLimits writes the rate limit of the public API along with the usage of each caller seen within the last hour,
+// so that clients can tune their polling.
+func (api *idler) RateLimits(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	limiter := ratelimit.Default
+	writeNegotiatedResponse(w, r, http.StatusOK, rateLimitsResponse{
+		Enabled:       limiter != nil,
+		Limit:         limiter.Limit(),
+		WindowSeconds: int(limiter.Window().Seconds()),
+		Callers:       limiter.Usage(),
+	})
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rate_limits(t *testing.T) {
+	limiter := ratelimit.Default
+	defer func() { ratelimit.Default = limiter }()
+
+	ratelimit.Default = nil
+	api := &idler{}
+	w := httptest.NewRecorder()
+	api.RateLimits(w, httptest.NewRequest("GET", "/api/idler/ratelimits", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"enabled": false, "limit": 0, "window_seconds": 0, "callers": []}`, w.Body.String())
+
+	fake := clock.NewFake(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+	ratelimit.Default = ratelimit.NewLimiter(2, time.Minute, fake)
+	ratelimit.Default.Allow("10.0.0.1")
+	w = httptest.NewRecorder()
+	api.RateLimits(w, httptest.NewRequest("GET", "/api/idler/ratelimits", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"enabled": true, "limit": 2, "window_seconds": 60, "callers": [
+		{"caller": "10.0.0.1", "limit": 2, "remaining": 1, "reset": "2018-06-01T00:01:00Z", "requests": 1, "limited": 0}
+	]}`, w.Body.String())
+}
@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
+	"github.com/julienschmidt/httprouter"
+)
+
+type recommendationsResponse struct {
+	Recommendations []remediation.Recommendation `json:"recommendations"`
+}
+
+// MemoryRecommendations writes the latest memory limit increase recommended per namespace after repeated OOM kills of
+// its Jenkins, and whether it got applied.
+func (api *idler) MemoryRecommendations(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeNegotiatedResponse(w, r, http.StatusOK, recommendationsResponse{Recommendations: remediation.DefaultAdvisor.Recommendations()})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_memory_recommendations(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	advisor := remediation.DefaultAdvisor
+	remediation.DefaultAdvisor = remediation.NewAdvisor(&mock.Config{OOMKillThreshold: 1, OOMKillWindow: 60,
+		OOMLimitIncrease: 50, OOMMaxMemoryLimit: 4096}, clock.New())
+	defer func() { remediation.DefaultAdvisor = advisor }()
+
+	api := &idler{}
+	w := httptest.NewRecorder()
+	api.MemoryRecommendations(w, httptest.NewRequest("GET", "/api/idler/recommendations", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"recommendations": []}`, w.Body.String())
+
+	pod := model.Pod{Name: "jenkins-1-abcde", Namespace: "john-jenkins", Restarts: model.PodRestarts{Count: 1, OOMKilled: true}}
+	remediation.DefaultAdvisor.OOMKilled(&mock.OpenShiftClient{}, "https://api.example.com/", "", "jenkins", pod, 1<<30, false)
+	w = httptest.NewRecorder()
+	api.MemoryRecommendations(w, httptest.NewRequest("GET", "/api/idler/recommendations", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Contains(t, w.Body.String(), `"namespace":"john-jenkins"`)
+	assert.Contains(t, w.Body.String(), `"suggested_limit_bytes":1610612736`)
+	assert.Contains(t, w.Body.String(), `"applied":false`)
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	pnamespace "github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/julienschmidt/httprouter"
+)
+
+// ReservationTokenParam is the path parameter carrying the token of an un-idle reservation.
+const ReservationTokenParam = "token"
+
+// reserveResponse is the body of the response to an un-idle reservation.
+type reserveResponse struct {
+	pidler.Reservation
+	Services pidler.ServiceResults `json:"services,omitempty"`
+}
+
+// reservationResponse is the body of the response to the check of an un-idle reservation.
+type reservationResponse struct {
+	pidler.Reservation
+	Safe bool `json:"safe"`
+}
+
+// Reserve un-idles Jenkins of the namespace and reserves it, so that it does not get idled until the reservation
+// expires or gets released. The namespace is reserved before Jenkins gets un-idled, so that no idle check can sneak
+// in between.
+func (api *idler) Reserve(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ns := util.EnsureSuffix(ps.ByName("namespace"), pnamespace.JenkinsSuffix)
+	if err := api.authorizeNamespace(r.Context(), r.URL.Query().Get(OpenShiftAPIParam), ns); err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+	openshiftURL, err := api.getURL(r, ns)
+	if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+
+	reservation, err := pidler.Reservations.Reserve(ns, time.Duration(api.config.GetReservationTTL())*time.Second)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	results, err := api.unIdle(openshiftURL, ns)
+	if err != nil || results.Err() != nil {
+		pidler.Reservations.Release(ns, reservation.Token)
+	}
+
+	if ce, ok := asCapacityError(err); ok {
+		api.respondWithCapacityError(w, ce)
+		return
+	} else if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	} else if results.Err() != nil {
+		respondWithServiceResults(w, results)
+		return
+	}
+	writeNegotiatedResponse(w, r, http.StatusOK, reserveResponse{Reservation: reservation, Services: results})
+}
+
+// Reservation tells whether it is safe to replay the webhooks buffered for the namespace, i.e. whether the
+// reservation is still valid and Jenkins is running.
+func (api *idler) Reservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ns := util.EnsureSuffix(ps.ByName("namespace"), pnamespace.JenkinsSuffix)
+	if err := api.authorizeNamespace(r.Context(), r.URL.Query().Get(OpenShiftAPIParam), ns); err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+
+	reservation, ok := pidler.Reservations.Get(ns, ps.ByName(ReservationTokenParam))
+	if !ok {
+		respondWithError(w, http.StatusNotFound, fmt.Errorf("No reservation of namespace %s with the given token", ns))
+		return
+	}
+
+	openshiftURL, openshiftToken, err := api.getURLAndToken(r, ns)
+	if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+
+	state, err := api.jenkinsState(openshiftURL, openshiftToken, ns)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeNegotiatedResponse(w, r, http.StatusOK, reservationResponse{Reservation: reservation, Safe: state == model.PodRunning})
+}
+
+// ReleaseReservation releases the reservation of the namespace, e.g. once the buffered webhooks got replayed.
+func (api *idler) ReleaseReservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ns := util.EnsureSuffix(ps.ByName("namespace"), pnamespace.JenkinsSuffix)
+	if err := api.authorizeNamespace(r.Context(), r.URL.Query().Get(OpenShiftAPIParam), ns); err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+
+	if !pidler.Reservations.Release(ns, ps.ByName(ReservationTokenParam)) {
+		respondWithError(w, http.StatusNotFound, fmt.Errorf("No reservation of namespace %s with the given token", ns))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
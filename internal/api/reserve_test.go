@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Reserve(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stdout)
+
+	reservations := pidler.Reservations
+	pidler.Reservations = pidler.NewReservationStore(clock.New())
+	defer func() { pidler.Reservations = reservations }()
+
+	mosc := &mock.OpenShiftClient{IdleState: model.PodIdled}
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: mosc,
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+		config:          &mock.Config{ReservationTTL: 300},
+	}
+	query := "/?" + OpenShiftAPIParam + "=http://localhost"
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", query, nil)
+	mockIdler.Reserve(w, r, httprouter.Params{{Key: "namespace", Value: "john"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, 1, mosc.UnIdleCallCount, "Jenkins should be un-idled")
+
+	var reserved reserveResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reserved))
+	require.Equal(t, "john-jenkins", reserved.Namespace)
+	require.NotEmpty(t, reserved.Token)
+	_, ok := pidler.Reservations.Reserved("john-jenkins")
+	require.True(t, ok, "Namespace should be reserved")
+
+	params := httprouter.Params{{Key: "namespace", Value: "john-jenkins"}, {Key: ReservationTokenParam, Value: reserved.Token}}
+	check := func() reservationResponse {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", query, nil)
+		mockIdler.Reservation(w, r, params)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response reservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	require.False(t, check().Safe, "Replaying should not be safe while Jenkins is starting")
+	mosc.IdleState = model.PodRunning
+	require.True(t, check().Safe, "Replaying should be safe once Jenkins is running")
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("DELETE", "/", nil)
+	mockIdler.ReleaseReservation(w, r, params)
+	require.Equal(t, http.StatusOK, w.Code)
+	_, ok = pidler.Reservations.Reserved("john-jenkins")
+	require.False(t, ok, "Namespace should no longer be reserved")
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", query, nil)
+	mockIdler.Reservation(w, r, params)
+	require.Equal(t, http.StatusNotFound, w.Code, "Released reservations should be unknown")
+}
+
+func Test_Reserve_released_if_unidle_refused(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stdout)
+
+	reservations := pidler.Reservations
+	pidler.Reservations = pidler.NewReservationStore(clock.New())
+	defer func() { pidler.Reservations = reservations }()
+
+	mockIdler := &idler{
+		userIdlers:      openshift.NewUserIdlerMap(),
+		openShiftClient: &mock.OpenShiftClient{IdleState: model.PodIdled},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{MaxCapacityReached: true},
+		config:          &mock.Config{ReservationTTL: 300},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/?"+OpenShiftAPIParam+"=http://localhost", nil)
+	mockIdler.Reserve(w, r, httprouter.Params{{Key: "namespace", Value: "john-jenkins"}})
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	_, ok := pidler.Reservations.Reserved("john-jenkins")
+	require.False(t, ok, "Reservation should be released if Jenkins cannot be un-idled")
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/julienschmidt/httprouter"
+)
+
+// VerifyParam is the query parameter which, if true, has the Jenkins route checked for whether it resolves and serves
+// TLS correctly.
+const VerifyParam = "verify"
+
+type routeResponse struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	URL       string `json:"url"`
+	Source    string `json:"source"`
+	Reachable *bool  `json:"reachable,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// verify checks whether the route resolves and serves TLS correctly if requested, annotating the response.
+func (response *routeResponse) verify(r *http.Request) {
+	if r.URL.Query().Get(VerifyParam) != "true" {
+		return
+	}
+
+	reachable := false
+	response.Reachable = &reachable
+	u, err := url.Parse(response.URL)
+	if err == nil {
+		err = cluster.DefaultRouteVerifier.Verify(r.Context(), u.Hostname())
+	}
+	if err != nil {
+		response.Error = err.Error()
+		return
+	}
+	reachable = true
+}
+
+// JenkinsRoute writes the URL of the Jenkins route of the namespace, derived from the route template or read from the
+// Route object of the namespace, depending on the configured route source. With verify=true, the response tells
+// whether the route resolves and serves TLS correctly.
+func (api *idler) JenkinsRoute(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	ns := ps.ByName("namespace")
+	openShiftAPI, openShiftBearerToken, err := api.getURLAndToken(r, ns)
+	if err != nil {
+		respondWithError(w, errorStatus(err), err)
+		return
+	}
+
+	response := routeResponse{Namespace: ns, Cluster: openShiftAPI, Source: cluster.RouteSource}
+	if cluster.RouteSource == cluster.RouteFromObject {
+		response.URL, err = api.openShiftClient.RouteURL(openShiftAPI, openShiftBearerToken, ns, "jenkins")
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+		response.verify(r)
+		writeNegotiatedResponse(w, r, http.StatusOK, response)
+		return
+	}
+
+	for _, c := range api.clusterView.GetClusters() {
+		if util.EnsureSuffix(c.APIURL, "/") == util.EnsureSuffix(openShiftAPI, "/") {
+			response.URL = "https://" + cluster.JenkinsHost(ns, c.AppDNS)
+			response.verify(r)
+			writeNegotiatedResponse(w, r, http.StatusOK, response)
+			return
+		}
+	}
+	respondWithError(w, http.StatusBadRequest, fmt.Errorf("Unknown OpenShift API URL: %s", openShiftAPI))
+}
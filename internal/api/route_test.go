@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jenkins_route(t *testing.T) {
+	defer func(source string) { cluster.RouteSource = source }(cluster.RouteSource)
+
+	api := &idler{
+		clusterView: cluster.NewView([]cluster.Cluster{
+			{APIURL: "https://api.example.com/", AppDNS: "apps.example.com", Token: "token"},
+		}),
+		openShiftClient: &mock.OpenShiftClient{RouteURLs: map[string]string{"john-jenkins": "http://ci.john.example.org"}},
+	}
+	params := httprouter.Params{{Key: "namespace", Value: "john-jenkins"}}
+	request := func() *http.Request {
+		return httptest.NewRequest("GET", "/api/idler/route/john-jenkins?openshift_api_url=https://api.example.com/", nil)
+	}
+
+	cluster.RouteSource = cluster.RouteFromTemplate
+	w := httptest.NewRecorder()
+	api.JenkinsRoute(w, request(), params)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"namespace": "john-jenkins", "cluster": "https://api.example.com/",
+		"url": "https://jenkins-john-jenkins.apps.example.com", "source": "template"}`, w.Body.String())
+
+	cluster.RouteSource = cluster.RouteFromObject
+	w = httptest.NewRecorder()
+	api.JenkinsRoute(w, request(), params)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Contains(t, w.Body.String(), `"url":"http://ci.john.example.org"`, "The URL of the Route object should be used")
+
+	w = httptest.NewRecorder()
+	api.JenkinsRoute(w, request(), httprouter.Params{{Key: "namespace", Value: "jane-jenkins"}})
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "A missing route should be reported")
+}
+
+func Test_jenkins_route_verification(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	host, port, _ := net.SplitHostPort(u.Host)
+
+	defer func(verifier cluster.RouteVerifier) { cluster.DefaultRouteVerifier = verifier }(cluster.DefaultRouteVerifier)
+	cluster.DefaultRouteVerifier = cluster.RouteVerifier{
+		RootCAs: ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		Port:    port,
+		Lookup: func(ctx context.Context, name string) ([]string, error) {
+			return []string{host}, nil
+		},
+	}
+
+	api := &idler{
+		clusterView: cluster.NewView([]cluster.Cluster{
+			{APIURL: "https://api.example.com/", AppDNS: "example.com", Token: "token"},
+			{APIURL: "https://api.example.org/", AppDNS: "example.org", Token: "token"},
+		}),
+	}
+	params := httprouter.Params{{Key: "namespace", Value: "john-jenkins"}}
+
+	w := httptest.NewRecorder()
+	api.JenkinsRoute(w, httptest.NewRequest("GET", "/api/idler/route/john-jenkins?openshift_api_url=https://api.example.com/&verify=true", nil), params)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.JSONEq(t, `{"namespace": "john-jenkins", "cluster": "https://api.example.com/",
+		"url": "https://jenkins-john-jenkins.example.com", "source": "template", "reachable": true}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	api.JenkinsRoute(w, httptest.NewRequest("GET", "/api/idler/route/john-jenkins?openshift_api_url=https://api.example.org/&verify=true", nil), params)
+	assert.Equal(t, http.StatusOK, w.Code, "A broken route should not fail the request")
+	assert.Contains(t, w.Body.String(), `"reachable":false`)
+	assert.Contains(t, w.Body.String(), "does not serve TLS correctly")
+}
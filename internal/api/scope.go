@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+)
+
+// authorize returns an error with status 403 unless the scope the caller is restricted to, if any, covers the
+// namespace on the cluster with the given API URL.
+func authorize(ctx context.Context, namespace, openShiftAPIURL string) error {
+	if s := scope.FromContext(ctx); !s.Allows(namespace, openShiftAPIURL) {
+		return withStatus(http.StatusForbidden, fmt.Errorf("namespace %s is out of the scope of token %s", namespace, s.Name))
+	}
+	return nil
+}
+
+// authorizeNamespace is authorize for the resources which are keyed by namespace only, e.g. callbacks and reservations.
+// If the scope of the caller restricts clusters, the namespace needs to live on one of them, as resolved by resolveURL
+// regardless of the given API URL, so that a caller cannot claim a namespace of another cluster to be on its own. A
+// given API URL differing from the resolved one is rejected.
+func (api *idler) authorizeNamespace(ctx context.Context, openShiftAPIURL, namespace string) error {
+	s := scope.FromContext(ctx)
+	if s == nil || len(s.Clusters) == 0 {
+		return authorize(ctx, namespace, "")
+	}
+
+	resolved, _ := api.resolveURL("", namespace)
+	if openShiftAPIURL != "" && util.EnsureSuffix(openShiftAPIURL, "/") != util.EnsureSuffix(resolved, "/") {
+		return withStatus(http.StatusForbidden, fmt.Errorf("namespace %s does not live on %s", namespace, openShiftAPIURL))
+	}
+	return authorize(ctx, namespace, resolved)
+}
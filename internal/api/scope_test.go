@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	pidler "github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopedRequest creates a request of a caller restricted to the given scope.
+func scopedRequest(method, target string, s *scope.Scope) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	return r.WithContext(scope.NewContext(r.Context(), s))
+}
+
+func Test_handlers_enforce_scope(t *testing.T) {
+	userIdlers := openshift.NewUserIdlerMap()
+	for user, cluster := range map[string]string{"alice": "https://api.cluster1.example.com/", "bob": "https://api.cluster2.example.com/"} {
+		userIdlers.Store(user, pidler.NewUserIdler(model.NewUser(user, user), cluster, "", &mock.Config{},
+			mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	}
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+		config:          &mock.Config{},
+	}
+	team := &scope.Scope{Name: "team-a", Namespaces: []string{"alice-*"}}
+	cluster := &scope.Scope{Name: "cluster1", Clusters: []string{"https://api.cluster1.example.com"}}
+
+	var requests = []struct {
+		scope  *scope.Scope
+		handle httprouter.Handle
+		target string
+		ps     httprouter.Params
+		status int
+	}{
+		{team, mockIdler.Status, "/api/idler/status/alice-jenkins", httprouter.Params{{Key: "namespace", Value: "alice-jenkins"}}, http.StatusOK},
+		{team, mockIdler.Status, "/api/idler/status/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusForbidden},
+		{team, mockIdler.UnIdle, "/api/idler/unidle/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusForbidden},
+		{team, mockIdler.IsIdle, "/api/idler/isidle/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusForbidden},
+		{cluster, mockIdler.Status, "/api/idler/status/alice-jenkins", httprouter.Params{{Key: "namespace", Value: "alice-jenkins"}}, http.StatusOK},
+		{cluster, mockIdler.Status, "/api/idler/status/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusForbidden},
+		{cluster, mockIdler.Status, "/api/idler/status/bob-jenkins?openshift_api_url=https://api.cluster1.example.com/", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusOK},
+		{cluster, mockIdler.Reservation, "/api/unidle/carol/reserve/token", httprouter.Params{{Key: "namespace", Value: "carol"}, {Key: ReservationTokenParam, Value: "token"}}, http.StatusForbidden},
+		{team, mockIdler.UnregisterCallback, "/api/idler/callback/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusForbidden},
+		{nil, mockIdler.Status, "/api/idler/status/bob-jenkins", httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}}, http.StatusOK},
+	}
+
+	for _, request := range requests {
+		w := httptest.NewRecorder()
+		request.handle(w, scopedRequest("GET", request.target, request.scope), request.ps)
+		assert.Equal(t, request.status, w.Code, "Unexpected status for %s", request.target)
+	}
+
+	w := httptest.NewRecorder()
+	mockIdler.Status(w, scopedRequest("GET", "/api/idler/status/bob-jenkins", team), httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}})
+	sr := statusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sr))
+	require.Len(t, sr.Errors, 1)
+	assert.Equal(t, outOfScope, sr.Errors[0].Code)
+}
+
+func Test_IdlerTimers_in_scope(t *testing.T) {
+	userIdlers := openshift.NewUserIdlerMap()
+	for _, user := range []string{"alice", "bob"} {
+		userIdlers.Store(user, pidler.NewUserIdler(model.NewUser(user, user), "https://api.cluster1.example.com/", "",
+			&mock.Config{}, mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	}
+	mockIdler := &idler{userIdlers: userIdlers, clusterView: &mock.ClusterView{}, config: &mock.Config{}}
+
+	w := httptest.NewRecorder()
+	mockIdler.IdlerTimers(w, scopedRequest("GET", "/api/metrics/idlers", &scope.Scope{Name: "team-a", Namespaces: []string{"alice-jenkins"}}), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response := idlerTimersResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Idlers, 1, "Only the user idlers in scope should be listed")
+	assert.Contains(t, response.Idlers, "alice")
+}
+
+func Test_namespace_keyed_handlers_resolve_cluster(t *testing.T) {
+	userIdlers := openshift.NewUserIdlerMap()
+	userIdlers.Store("bob", pidler.NewUserIdler(model.NewUser("bob", "bob"), "https://api.cluster2.example.com/", "",
+		&mock.Config{}, mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New()))
+	mockIdler := &idler{
+		userIdlers:      userIdlers,
+		openShiftClient: &mock.OpenShiftClient{},
+		clusterView:     &mock.ClusterView{},
+		tenantService:   &mock.TenantService{},
+		config:          &mock.Config{},
+	}
+	cluster := &scope.Scope{Name: "cluster1", Clusters: []string{"https://api.cluster1.example.com"}}
+	ps := httprouter.Params{{Key: "namespace", Value: "bob-jenkins"}, {Key: ReservationTokenParam, Value: "token"}}
+
+	var requests = []struct {
+		handle httprouter.Handle
+		method string
+		target string
+	}{
+		{mockIdler.RegisterCallback, "POST", "/api/idler/callback/bob-jenkins?openshift_api_url=https://api.cluster1.example.com/"},
+		{mockIdler.UnregisterCallback, "DELETE", "/api/idler/callback/bob-jenkins?openshift_api_url=https://api.cluster1.example.com/"},
+		{mockIdler.UnregisterCallback, "DELETE", "/api/idler/callback/bob-jenkins"},
+		{mockIdler.Reserve, "POST", "/api/idler/reserve/bob-jenkins?openshift_api_url=https://api.cluster1.example.com/"},
+		{mockIdler.Reservation, "GET", "/api/idler/reserve/bob-jenkins/token?openshift_api_url=https://api.cluster1.example.com/"},
+		{mockIdler.ReleaseReservation, "DELETE", "/api/idler/reserve/bob-jenkins/token?openshift_api_url=https://api.cluster1.example.com/"},
+	}
+
+	for _, request := range requests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(request.method, request.target, strings.NewReader(`{"url": "https://hooks.example.com/"}`))
+		request.handle(w, r.WithContext(scope.NewContext(r.Context(), cluster)), ps)
+		assert.Equal(t, http.StatusForbidden, w.Code, "Unexpected status for %s %s", request.method, request.target)
+	}
+	_, reserved := pidler.Reservations.Reserved("bob-jenkins")
+	assert.False(t, reserved, "The namespace of another cluster should not get reserved")
+}
@@ -0,0 +1,172 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ReadyState is the published state on whose transition the callbacks get called.
+	ReadyState = "running"
+
+	callbackTimeout = 10 * time.Second
+)
+
+var logger = logrus.WithField("component", "callback")
+
+// Default is the Registry used by the Idler.
+var Default = NewRegistry()
+
+// Registration is the callback URL registered for the Jenkins namespace.
+type Registration struct {
+	Namespace string `json:"namespace"`
+	URL       string `json:"url"`
+}
+
+// Registry keeps a callback URL per Jenkins namespace, e.g. of the Jenkins Proxy, which gets called once Jenkins of
+// the namespace transitions to running. This way the proxy can release the webhooks it buffered while Jenkins was
+// idled right away instead of polling the idle status. Callbacks stay registered until they get unregistered.
+type Registry struct {
+	sync.Mutex
+	callbacks  map[string]string
+	httpClient *http.Client
+	wg         sync.WaitGroup
+}
+
+// NewRegistry creates a Registry without callbacks.
+func NewRegistry() *Registry {
+	return &Registry{
+		callbacks:  make(map[string]string),
+		httpClient: &http.Client{Timeout: callbackTimeout},
+	}
+}
+
+// Register registers the callback URL for the Jenkins namespace, replacing the one registered before. The URL needs
+// to be an absolute http or https URL.
+func (r *Registry) Register(namespace string, callbackURL string) error {
+	if namespace == "" {
+		return errors.New("namespace needs to be specified")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback URL: %s", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("callback URL needs to be an absolute http or https URL: %s", callbackURL)
+	}
+
+	r.Lock()
+	r.callbacks[namespace] = callbackURL
+	r.Unlock()
+	logger.WithFields(logrus.Fields{"namespace": namespace, "url": callbackURL}).Info("Registered callback")
+	return nil
+}
+
+// Unregister removes the callback of the Jenkins namespace. It returns false if none was registered.
+func (r *Registry) Unregister(namespace string) bool {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.callbacks[namespace]; !ok {
+		return false
+	}
+	delete(r.callbacks, namespace)
+	logger.WithField("namespace", namespace).Info("Unregistered callback")
+	return true
+}
+
+// Registrations returns the registered callbacks sorted by namespace.
+func (r *Registry) Registrations() []Registration {
+	r.Lock()
+	defer r.Unlock()
+
+	registrations := make([]Registration, 0, len(r.callbacks))
+	for namespace, callbackURL := range r.callbacks {
+		registrations = append(registrations, Registration{Namespace: namespace, URL: callbackURL})
+	}
+	sort.Slice(registrations, func(i, j int) bool { return registrations[i].Namespace < registrations[j].Namespace })
+	return registrations
+}
+
+// Start subscribes to the state changes published by the hub and calls the callback of the namespace whenever its
+// Jenkins transitions to running, until the context is done.
+func (r *Registry) Start(ctx context.Context, wg *sync.WaitGroup, hub *events.Hub) {
+	changes, cancel := hub.Subscribe("")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				r.Wait()
+				return
+			case e := <-changes:
+				r.Notify(e)
+			}
+		}
+	}()
+}
+
+// Notify calls the callback registered for the namespace of the event in the background, provided Jenkins
+// transitioned to running. A failed call is logged only, as the proxy falls back to polling the idle status.
+func (r *Registry) Notify(e events.Event) {
+	if e.State != ReadyState || e.Previous == ReadyState {
+		return
+	}
+
+	r.Lock()
+	callbackURL, ok := r.callbacks[e.Namespace]
+	r.Unlock()
+	if !ok {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		log := logger.WithFields(logrus.Fields{"namespace": e.Namespace, "url": callbackURL})
+		if err := r.post(callbackURL, e); err != nil {
+			log.WithField("err", err).Warn("Unable to call back")
+			return
+		}
+		log.Debug("Called back")
+	}()
+}
+
+// Wait waits for the callbacks in flight to be called.
+func (r *Registry) Wait() {
+	r.wg.Wait()
+}
+
+// post sends the event as JSON to the callback URL.
+func (r *Registry) post(callbackURL string, e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.httpClient.Post(callbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_register(t *testing.T) {
+	r := NewRegistry()
+	assert.Error(t, r.Register("", "http://proxy/callback"))
+	assert.Error(t, r.Register("john-jenkins", "/callback"))
+	assert.Error(t, r.Register("john-jenkins", "ftp://proxy/callback"))
+
+	require.NoError(t, r.Register("john-jenkins", "http://proxy/callback"))
+	require.NoError(t, r.Register("jane-jenkins", "http://proxy/callback"))
+	require.NoError(t, r.Register("john-jenkins", "https://proxy/callback/john"))
+	assert.Equal(t, []Registration{
+		{Namespace: "jane-jenkins", URL: "http://proxy/callback"},
+		{Namespace: "john-jenkins", URL: "https://proxy/callback/john"},
+	}, r.Registrations())
+
+	assert.True(t, r.Unregister("jane-jenkins"))
+	assert.False(t, r.Unregister("jane-jenkins"))
+	assert.Len(t, r.Registrations(), 1)
+}
+
+func Test_notify_on_running(t *testing.T) {
+	var mu sync.Mutex
+	var received []events.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var e events.Event
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&e))
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	r := NewRegistry()
+	require.NoError(t, r.Register("john-jenkins", server.URL))
+
+	hub := events.NewHub()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	r.Start(ctx, &wg, hub)
+
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	hub.Publish(events.Event{Namespace: "john-jenkins", State: "starting", Previous: "idled", Time: now})
+	hub.Publish(events.Event{Namespace: "jane-jenkins", State: "running", Previous: "starting", Time: now})
+	hub.Publish(events.Event{Namespace: "john-jenkins", State: "running", Previous: "starting", Time: now})
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1, "Only the transition of a registered namespace to running should be called back")
+	assert.Equal(t, "john-jenkins", received[0].Namespace)
+	assert.Equal(t, "running", received[0].State)
+	assert.Equal(t, "starting", received[0].Previous)
+}
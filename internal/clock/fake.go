@@ -0,0 +1,157 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock for tests. Its time only moves when advanced explicitly, firing the timers and tickers
+// which expire in the meantime.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFake creates a Fake clock set to the given time.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the current time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel receiving the fake time once the clock got advanced by at least d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer creates a Timer firing once the clock got advanced by at least d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	return fakeTimer{f.addWaiter(d, 0)}
+}
+
+// NewTicker creates a Ticker firing each time the clock got advanced by another period d. Like time.Ticker,
+// ticks are dropped for slow receivers.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.addWaiter(d, d)}
+}
+
+// Advance moves the clock forward by d, firing all timers and tickers expiring until then.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	active := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			active = append(active, w)
+			continue
+		}
+
+		select {
+		case w.c <- f.now:
+		default:
+		}
+
+		if w.period > 0 {
+			for !w.deadline.After(f.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			active = append(active, w)
+		}
+	}
+	f.waiters = active
+	f.cond.Broadcast()
+}
+
+// BlockUntil blocks until at least n timers and tickers are waiting on the clock. It allows tests to wait for
+// the code under test to set up its timers before advancing the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+func (f *Fake) addWaiter(d, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &fakeWaiter{
+		fake:     f,
+		deadline: f.now.Add(d),
+		period:   period,
+		c:        make(chan time.Time, 1),
+	}
+	if d <= 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+func (f *Fake) removeWaiter(w *fakeWaiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, waiter := range f.waiters {
+		if waiter == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// fakeWaiter is a timer or ticker waiting on the Fake clock.
+type fakeWaiter struct {
+	fake     *Fake
+	deadline time.Time
+	period   time.Duration
+	c        chan time.Time
+}
+
+type fakeTimer struct {
+	*fakeWaiter
+}
+
+func (t fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t fakeTimer) Stop() bool {
+	return t.fake.removeWaiter(t.fakeWaiter)
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t fakeTicker) Stop() {
+	t.fake.removeWaiter(t.fakeWaiter)
+}
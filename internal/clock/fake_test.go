@@ -0,0 +1,82 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
+func fired(c <-chan time.Time) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func Test_fake_timer_fires_once_deadline_is_reached(t *testing.T) {
+	clock := NewFake(epoch)
+	timer := clock.NewTimer(time.Minute)
+
+	clock.Advance(59 * time.Second)
+	assert.False(t, fired(timer.C()), "Timer should not have fired yet")
+	assert.Equal(t, epoch.Add(59*time.Second), clock.Now())
+
+	clock.Advance(time.Second)
+	assert.True(t, fired(timer.C()), "Timer should have fired")
+	assert.False(t, timer.Stop(), "Expired timer should not be stoppable")
+
+	clock.Advance(time.Hour)
+	assert.False(t, fired(timer.C()), "Timer should fire only once")
+}
+
+func Test_fake_timer_can_be_stopped(t *testing.T) {
+	clock := NewFake(epoch)
+	timer := clock.NewTimer(time.Minute)
+
+	assert.True(t, timer.Stop())
+	clock.Advance(time.Hour)
+	assert.False(t, fired(timer.C()), "Stopped timer should not fire")
+}
+
+func Test_fake_ticker_fires_each_period(t *testing.T) {
+	clock := NewFake(epoch)
+	ticker := clock.NewTicker(time.Minute)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Minute)
+		assert.True(t, fired(ticker.C()), "Ticker should have fired")
+	}
+
+	clock.Advance(5 * time.Minute)
+	assert.True(t, fired(ticker.C()))
+	assert.False(t, fired(ticker.C()), "Ticks should be dropped for slow receivers")
+
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	assert.False(t, fired(ticker.C()), "Stopped ticker should not fire")
+}
+
+func Test_fake_block_until_waits_for_timers(t *testing.T) {
+	clock := NewFake(epoch)
+
+	done := make(chan struct{})
+	go func() {
+		clock.BlockUntil(2)
+		close(done)
+	}()
+
+	clock.NewTimer(time.Minute)
+	clock.After(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil should return once two timers are waiting")
+	}
+	assert.Equal(t, time.Minute, clock.Since(epoch.Add(-time.Minute)))
+}
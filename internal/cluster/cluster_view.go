@@ -1,6 +1,11 @@
 package cluster
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/token"
+)
 
 // View provides a view over the current cluster topology.
 type View interface {
@@ -11,14 +16,27 @@ type View interface {
 }
 
 // DNSView is a view of the cluster topology which only includes the OpenShift API URL and the application DNS for this
-// cluster.
+// cluster, along with whether the watches of the cluster are degraded. RouteTemplate is the host name of the Jenkins
+// routes with the application domain filled in, {namespace} resp. {user} being left to the client. If RouteSource is
+// route, the actual routes need to be requested per namespace, as they do not necessarily follow the template.
+// RouteReachable tells whether the Jenkins routes of the cluster resolved and served TLS correctly on the last route
+// check, with RouteError giving the reason if not. It is omitted unless route checks are enabled.
 type DNSView struct {
-	APIURL string
-	AppDNS string
+	APIURL         string
+	AppDNS         string
+	RouteTemplate  string
+	RouteSource    string
+	Degraded       bool   `json:",omitempty"`
+	DegradedReason string `json:",omitempty"`
+	RouteReachable *bool  `json:",omitempty"`
+	RouteError     string `json:",omitempty"`
 }
 
-// NewView returns a new instance of View.
+// NewView returns a new instance of View. The tokens of the clusters get redacted from logs and error responses.
 func NewView(clusters []Cluster) View {
+	for _, cluster := range clusters {
+		redact.Register(cluster.Token)
+	}
 	return &clusterView{
 		clusters: clusters,
 	}
@@ -37,9 +55,13 @@ func (c clusterView) GetDNSView() []DNSView {
 
 	for _, cluster := range c.clusters {
 		dnsCluster := DNSView{
-			APIURL: cluster.APIURL,
-			AppDNS: cluster.AppDNS,
+			APIURL:        cluster.APIURL,
+			AppDNS:        cluster.AppDNS,
+			RouteTemplate: appDomainTemplate(cluster.AppDNS),
+			RouteSource:   RouteSource,
 		}
+		dnsCluster.DegradedReason, dnsCluster.Degraded = DefaultHealth.Degraded(cluster.APIURL)
+		dnsCluster.RouteReachable, dnsCluster.RouteError = DefaultReachability.Reachable(cluster.APIURL)
 		dnsClusters = append(dnsClusters, dnsCluster)
 	}
 	return dnsClusters
@@ -48,6 +70,10 @@ func (c clusterView) GetDNSView() []DNSView {
 func (c clusterView) GetToken(openShiftAPIURL string) (string, bool) {
 	for _, cluster := range c.clusters {
 		if cluster.APIURL == openShiftAPIURL {
+			// a projected or exchanged token is more recent than the one obtained at startup
+			if current, ok := token.ClusterToken(openShiftAPIURL); ok {
+				return current, true
+			}
 			return cluster.Token, true
 		}
 	}
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/token"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+)
+
+// expiryCheckInterval is the interval at which the expiry of the cluster tokens is checked.
+const expiryCheckInterval = time.Minute
+
+var logger = logrus.WithField("component", "cluster")
+
+// Recorder to capture the expiry of the cluster tokens
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// ExpiryMonitor watches the expiry of the bearer tokens of the clusters, as expired tokens otherwise only show up as
+// requests failing with 401. Only tokens carrying their expiry, i.e. JWTs, are monitored.
+type ExpiryMonitor struct {
+	view       View
+	warnBefore time.Duration
+	clock      clock.Clock
+}
+
+// NewExpiryMonitor creates an ExpiryMonitor for the clusters of the view, warning about tokens expiring within the
+// given duration.
+func NewExpiryMonitor(view View, warnBefore time.Duration, clock clock.Clock) *ExpiryMonitor {
+	return &ExpiryMonitor{view: view, warnBefore: warnBefore, clock: clock}
+}
+
+// Start checks the expiry of the cluster tokens every minute until the context is done, recording the remaining
+// seconds and logging warnings as the expiry approaches.
+func (m *ExpiryMonitor) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := m.clock.NewTicker(expiryCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			m.check()
+			select {
+			case <-ctx.Done():
+				logger.Info("Shutting down token expiry monitor.")
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+}
+
+// check records the expiry of the cluster tokens and logs the tokens which expired or are about to.
+func (m *ExpiryMonitor) check() {
+	for _, cluster := range m.view.GetClusters() {
+		expiry, ok := m.expiry(cluster.APIURL)
+		if !ok {
+			continue
+		}
+
+		remaining := expiry.Sub(m.clock.Now())
+		Recorder.RecordTokenExpiry(cluster.APIURL, remaining.Seconds())
+		log := logger.WithFields(logrus.Fields{"cluster": cluster.APIURL, "expiry": expiry})
+		if remaining <= 0 {
+			log.Error("Token of the cluster has expired")
+		} else if remaining < m.warnBefore {
+			log.WithField("remaining", remaining).Warn("Token of the cluster expires soon")
+		}
+	}
+}
+
+// Ready returns an error listing the clusters whose token has expired, nil if there are none.
+func (m *ExpiryMonitor) Ready() error {
+	var expired []string
+	for _, cluster := range m.view.GetClusters() {
+		if expiry, ok := m.expiry(cluster.APIURL); ok && !m.clock.Now().Before(expiry) {
+			expired = append(expired, fmt.Sprintf("%s (expired at %s)", cluster.APIURL, expiry.Format(time.RFC3339)))
+		}
+	}
+	if len(expired) > 0 {
+		return fmt.Errorf("token of cluster expired: %s", strings.Join(expired, ", "))
+	}
+	return nil
+}
+
+// expiry returns the expiry of the current token of the cluster, false if it is unknown.
+func (m *ExpiryMonitor) expiry(apiURL string) (time.Time, bool) {
+	t, ok := m.view.GetToken(apiURL)
+	if !ok {
+		return time.Time{}, false
+	}
+	return token.Expiry(t)
+}
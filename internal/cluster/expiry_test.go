@@ -0,0 +1,34 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jwt(expiry time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sub":"idler","exp":%d}`, expiry.Unix())))
+	return "eyJhbGciOiJSUzI1NiJ9." + payload + ".signature"
+}
+
+func Test_expiry_monitor_fails_readiness_on_expired_token(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	view := NewView([]Cluster{
+		{APIURL: "https://api.starter-us-east-2.openshift.com/", Token: jwt(now.Add(time.Hour))},
+		{APIURL: "https://api.starter-us-east-1.openshift.com/", Token: "opaque-oauth-token"},
+	})
+	fakeClock := clock.NewFake(now)
+	monitor := NewExpiryMonitor(view, 10*time.Minute, fakeClock)
+
+	require.NoError(t, monitor.Ready())
+
+	fakeClock.Advance(time.Hour)
+	err := monitor.Ready()
+	require.Error(t, err)
+	assert.Equal(t, "token of cluster expired: https://api.starter-us-east-2.openshift.com/ (expired at 2018-06-01T13:00:00Z)", err.Error())
+}
@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultHealth tracks the health of the clusters watched by the Idler.
+var DefaultHealth = NewHealth()
+
+// Health tracks the clusters which are degraded, e.g. since their watches keep failing, along with the reason. A
+// degraded cluster does not affect the other clusters.
+type Health struct {
+	sync.RWMutex
+	degraded map[string]string
+}
+
+// NewHealth creates a Health without degraded clusters.
+func NewHealth() *Health {
+	return &Health{degraded: make(map[string]string)}
+}
+
+// Degrade marks the cluster with the given API URL as degraded for the given reason.
+func (h *Health) Degrade(apiURL, reason string) {
+	h.Lock()
+	defer h.Unlock()
+	h.degraded[apiURL] = reason
+}
+
+// Recover marks the cluster with the given API URL as healthy again.
+func (h *Health) Recover(apiURL string) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.degraded, apiURL)
+}
+
+// Degraded returns the reason the cluster with the given API URL is degraded for, false if it is healthy.
+func (h *Health) Degraded(apiURL string) (string, bool) {
+	h.RLock()
+	defer h.RUnlock()
+	reason, ok := h.degraded[apiURL]
+	return reason, ok
+}
+
+// Degradations returns the degraded clusters along with their reason, sorted by API URL.
+func (h *Health) Degradations() []string {
+	h.RLock()
+	defer h.RUnlock()
+
+	degradations := make([]string, 0, len(h.degraded))
+	for apiURL, reason := range h.degraded {
+		degradations = append(degradations, fmt.Sprintf("%s (%s)", apiURL, reason))
+	}
+	sort.Strings(degradations)
+	return degradations
+}
+
+// Ready returns an error if all of the given clusters are degraded, nil if at least one of them is healthy.
+func (h *Health) Ready(clusters []Cluster) error {
+	for _, c := range clusters {
+		if _, ok := h.Degraded(c.APIURL); !ok {
+			return nil
+		}
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	return fmt.Errorf("all clusters degraded: %s", strings.Join(h.Degradations(), ", "))
+}
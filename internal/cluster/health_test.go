@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_health(t *testing.T) {
+	h := NewHealth()
+	clusters := []Cluster{{APIURL: "https://api.one.example.com/"}, {APIURL: "https://api.two.example.com/"}}
+	assert.NoError(t, h.Ready(clusters))
+
+	h.Degrade("https://api.one.example.com/", "token rejected watching builds")
+	assert.NoError(t, h.Ready(clusters), "a single degraded cluster should not fail the readiness")
+	assert.Equal(t, []string{"https://api.one.example.com/ (token rejected watching builds)"}, h.Degradations())
+
+	h.Degrade("https://api.two.example.com/", "watches ended unexpectedly")
+	assert.EqualError(t, h.Ready(clusters), "all clusters degraded: https://api.one.example.com/ (token rejected watching builds), "+
+		"https://api.two.example.com/ (watches ended unexpectedly)")
+
+	h.Recover("https://api.one.example.com/")
+	_, degraded := h.Degraded("https://api.one.example.com/")
+	assert.False(t, degraded)
+	assert.NoError(t, h.Ready(clusters))
+}
+
+func Test_dns_view_reports_degraded_cluster(t *testing.T) {
+	view := NewView([]Cluster{{APIURL: "https://api.degraded.example.com/", AppDNS: "example.com"}})
+	DefaultHealth.Degrade("https://api.degraded.example.com/", "token rejected watching pods")
+	defer DefaultHealth.Recover("https://api.degraded.example.com/")
+
+	assert.Equal(t, []DNSView{{
+		APIURL:         "https://api.degraded.example.com/",
+		AppDNS:         "example.com",
+		RouteTemplate:  "jenkins-{namespace}.example.com",
+		RouteSource:    "template",
+		Degraded:       true,
+		DegradedReason: "token rejected watching pods",
+	}}, view.GetDNSView())
+}
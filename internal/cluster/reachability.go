@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// probeNamespace is the Jenkins namespace whose route host is checked per cluster. No such route needs to exist, as
+// the router of the cluster serves all hosts of its application domain with its wildcard certificate.
+const probeNamespace = "idler-route-check"
+
+// DefaultRouteVerifier verifies the Jenkins routes on behalf of the route checks and the API.
+var DefaultRouteVerifier = RouteVerifier{Timeout: 5 * time.Second}
+
+// DefaultReachability tracks the reachability of the Jenkins routes of the clusters watched by the Idler.
+var DefaultReachability = NewReachability()
+
+// Reachability tracks whether the Jenkins routes of the clusters resolve and serve TLS correctly, along with the
+// reason they do not. Clusters which have not been checked yet are unknown.
+type Reachability struct {
+	sync.RWMutex
+	results map[string]error
+}
+
+// NewReachability creates a Reachability without checked clusters.
+func NewReachability() *Reachability {
+	return &Reachability{results: make(map[string]error)}
+}
+
+// Record records the result of the check of the Jenkins routes of the cluster with the given API URL.
+func (r *Reachability) Record(apiURL string, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.results[apiURL] = err
+}
+
+// Reachable returns whether the Jenkins routes of the cluster with the given API URL are reachable, along with the
+// reason if not. It returns nil if the cluster has not been checked.
+func (r *Reachability) Reachable(apiURL string) (*bool, string) {
+	r.RLock()
+	defer r.RUnlock()
+	err, ok := r.results[apiURL]
+	if !ok {
+		return nil, ""
+	}
+	reachable := err == nil
+	if reachable {
+		return &reachable, ""
+	}
+	return &reachable, err.Error()
+}
+
+// RouteVerifier checks whether a route host resolves and completes a TLS handshake with a certificate valid for it.
+type RouteVerifier struct {
+	// Timeout limits the time a check may take.
+	Timeout time.Duration
+
+	// RootCAs verifies the certificates of the routes, the system pool if nil.
+	RootCAs *x509.CertPool
+
+	// Port is the port the TLS handshake is attempted on, 443 if empty.
+	Port string
+
+	// Lookup resolves the host, net.DefaultResolver if nil.
+	Lookup func(ctx context.Context, host string) ([]string, error)
+}
+
+// Verify returns an error telling whether the host failed to resolve or to complete the TLS handshake, nil if it is
+// reachable.
+func (v RouteVerifier) Verify(ctx context.Context, host string) error {
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+
+	lookup, port := v.Lookup, v.Port
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	if port == "" {
+		port = "443"
+	}
+
+	addrs, err := lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("route %s does not resolve: %s", host, err)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("route %s does not resolve to any address", host)
+	}
+
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, RootCAs: v.RootCAs}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(addrs[0], port))
+	if err != nil {
+		return fmt.Errorf("route %s does not serve TLS correctly: %s", host, err)
+	}
+	return conn.Close()
+}
+
+// RouteChecker periodically checks whether the Jenkins routes of the clusters resolve and serve TLS correctly,
+// recording the results in DefaultReachability, so that the proxy can avoid routing users to broken routes.
+type RouteChecker struct {
+	view     View
+	verifier RouteVerifier
+	interval time.Duration
+	clock    clock.Clock
+}
+
+// NewRouteChecker creates a RouteChecker checking the Jenkins routes of the clusters of the view at the given interval.
+func NewRouteChecker(view View, verifier RouteVerifier, interval time.Duration, clock clock.Clock) *RouteChecker {
+	return &RouteChecker{view: view, verifier: verifier, interval: interval, clock: clock}
+}
+
+// Start checks the Jenkins routes of the clusters at the interval until the context is done.
+func (c *RouteChecker) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := c.clock.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.check(ctx)
+			select {
+			case <-ctx.Done():
+				logger.Info("Shutting down route checker.")
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+}
+
+// check verifies the route host of the probeNamespace on each cluster and records the results.
+func (c *RouteChecker) check(ctx context.Context) {
+	for _, cluster := range c.view.GetClusters() {
+		host := JenkinsHost(probeNamespace, cluster.AppDNS)
+		err := c.verifier.Verify(ctx, host)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log := logger.WithFields(logrus.Fields{"cluster": cluster.APIURL, "host": host})
+		if err != nil {
+			log.WithField("err", err).Warn("Jenkins routes of the cluster are unreachable")
+		} else if reachable, _ := DefaultReachability.Reachable(cluster.APIURL); reachable != nil && !*reachable {
+			log.Info("Jenkins routes of the cluster are reachable again")
+		}
+		DefaultReachability.Record(cluster.APIURL, err)
+	}
+}
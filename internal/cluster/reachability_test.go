@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVerifier returns a RouteVerifier resolving all hosts to the given TLS server and trusting its certificate,
+// which is valid for *.example.com.
+func testVerifier(t *testing.T, ts *httptest.Server) RouteVerifier {
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	return RouteVerifier{
+		Timeout: 5 * time.Second,
+		RootCAs: ts.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		Port:    port,
+		Lookup: func(ctx context.Context, name string) ([]string, error) {
+			return []string{host}, nil
+		},
+	}
+}
+
+func Test_route_verifier(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	verifier := testVerifier(t, ts)
+
+	assert.NoError(t, verifier.Verify(context.Background(), "jenkins-john-jenkins.example.com"))
+
+	err := verifier.Verify(context.Background(), "jenkins-john-jenkins.example.org")
+	require.Error(t, err, "A certificate not valid for the host should be rejected")
+	assert.Contains(t, err.Error(), "does not serve TLS correctly")
+
+	untrusted := verifier
+	untrusted.RootCAs = nil
+	assert.Error(t, untrusted.Verify(context.Background(), "jenkins-john-jenkins.example.com"), "An untrusted certificate should be rejected")
+
+	unresolved := verifier
+	unresolved.Lookup = func(ctx context.Context, host string) ([]string, error) {
+		return nil, errors.New("no such host")
+	}
+	assert.EqualError(t, unresolved.Verify(context.Background(), "jenkins-john-jenkins.example.com"),
+		"route jenkins-john-jenkins.example.com does not resolve: no such host")
+}
+
+func Test_route_checker_annotates_dns_view(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	view := NewView([]Cluster{
+		{APIURL: "https://api.reachable.example.com/", AppDNS: "example.com"},
+		{APIURL: "https://api.broken.example.com/", AppDNS: "example.org"},
+	})
+	defer func() { DefaultReachability = NewReachability() }()
+
+	assert.Nil(t, view.GetDNSView()[0].RouteReachable, "Reachability should be omitted before the first check")
+
+	NewRouteChecker(view, testVerifier(t, ts), time.Minute, clock.New()).check(context.Background())
+
+	dnsView := view.GetDNSView()
+	require.NotNil(t, dnsView[0].RouteReachable)
+	assert.True(t, *dnsView[0].RouteReachable)
+	assert.Empty(t, dnsView[0].RouteError)
+
+	require.NotNil(t, dnsView[1].RouteReachable)
+	assert.False(t, *dnsView[1].RouteReachable)
+	assert.Contains(t, dnsView[1].RouteError, "route jenkins-idler-route-check.example.org does not serve TLS correctly")
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"strings"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+)
+
+// Sources of the Jenkins routes.
+const (
+	// RouteFromTemplate derives the Jenkins routes from the RouteTemplate.
+	RouteFromTemplate = "template"
+
+	// RouteFromObject reads the actual Route objects of the Jenkins namespaces.
+	RouteFromObject = "route"
+)
+
+// DefaultRouteTemplate is the naming scheme of the Jenkins routes on OpenShift Online.
+const DefaultRouteTemplate = "jenkins-{namespace}.{appDomain}"
+
+// RouteTemplate is the template of the host names of the Jenkins routes. {namespace} stands for the Jenkins
+// namespace, {user} for the name of the user and {appDomain} for the application domain of the cluster.
+var RouteTemplate = DefaultRouteTemplate
+
+// RouteSource tells whether the Jenkins routes are derived from the RouteTemplate or read from the Route objects.
+var RouteSource = RouteFromTemplate
+
+// appDomainTemplate returns the RouteTemplate with the application domain of the cluster filled in, so that clients
+// only need to fill in the namespace.
+func appDomainTemplate(appDNS string) string {
+	return strings.Replace(RouteTemplate, "{appDomain}", strings.Trim(appDNS, "./"), -1)
+}
+
+// JenkinsHost returns the host name of the Jenkins route of the given Jenkins namespace on a cluster with the given
+// application domain according to the RouteTemplate. A namespace lacking the Jenkins suffix stands for its user as is.
+func JenkinsHost(jenkinsNamespace string, appDNS string) string {
+	user, ok := namespace.User(jenkinsNamespace)
+	if !ok {
+		user = jenkinsNamespace
+	}
+	return strings.NewReplacer("{namespace}", jenkinsNamespace, "{user}", user).Replace(appDomainTemplate(appDNS))
+}
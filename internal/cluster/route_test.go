@@ -0,0 +1,19 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jenkins_host(t *testing.T) {
+	defer func(template string) { RouteTemplate = template }(RouteTemplate)
+
+	assert.Equal(t, "jenkins-john-jenkins.8a09.starter-us-east-2.openshiftapps.com",
+		JenkinsHost("john-jenkins", "8a09.starter-us-east-2.openshiftapps.com/"), "Default scheme mismatch")
+
+	RouteTemplate = "{user}.ci.{appDomain}"
+	assert.Equal(t, "john.ci.apps.example.com", JenkinsHost("john-jenkins", "apps.example.com"), "Custom scheme mismatch")
+	assert.Equal(t, "john-ci.ci.apps.example.com", JenkinsHost("john-ci", "apps.example.com"),
+		"Namespace without Jenkins suffix should stand for the user as is")
+}
@@ -95,17 +95,24 @@ func (s *clusterService) GetClusterView(ctx context.Context) (View, error) {
 		if cluster.Type != osioType {
 			continue
 		}
-		// resolve/obtain the cluster token
-		clusterUser, clusterToken, err := s.resolveToken(ctx, cluster.APIURL, s.serviceToken, false, s.decode) // can't use "forcePull=true" to validate the `tenant service account` token since it's encrypted on auth
-		if err != nil {
-			return nil, errors.Wrapf(err, "unable to resolve token for cluster %v", cluster.APIURL)
+		// use the token of a projected service account token volume, or else resolve/obtain the cluster token
+		clusterToken, projected := token.ClusterToken(cluster.APIURL)
+		var clusterUser string
+		if !projected {
+			clusterUser, clusterToken, err = s.resolveToken(ctx, cluster.APIURL, s.serviceToken, false, s.decode) // can't use "forcePull=true" to validate the `tenant service account` token since it's encrypted on auth
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to resolve token for cluster %v", cluster.APIURL)
+			}
 		}
 
 		// verify the token
-		_, err = s.ocClient.WhoAmI(cluster.APIURL, clusterToken)
+		whoAmI, err := s.ocClient.WhoAmI(cluster.APIURL, clusterToken)
 		if err != nil {
 			return nil, errors.Wrapf(err, "token retrieved for cluster %v is invalid", cluster.APIURL)
 		}
+		if projected {
+			clusterUser = whoAmI
+		}
 
 		if err != nil {
 			return nil, errors.Wrapf(err, "token retrieved for cluster %v is invalid", cluster.APIURL)
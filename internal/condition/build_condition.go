@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	"github.com/sirupsen/logrus"
 )
@@ -12,12 +13,13 @@ import (
 type BuildCondition struct {
 	idleAfter     time.Duration
 	idleLongBuild time.Duration
+	clock         clock.Clock
 }
 
 // NewBuildCondition creates a new instance of BuildCondition given
 // idleAfter(time after which jenkins should be idled).
-func NewBuildCondition(idleAfter time.Duration, idleLongBuild time.Duration) Condition {
-	b := &BuildCondition{idleAfter: idleAfter, idleLongBuild: idleLongBuild}
+func NewBuildCondition(idleAfter time.Duration, idleLongBuild time.Duration, clock clock.Clock) Condition {
+	b := &BuildCondition{idleAfter: idleAfter, idleLongBuild: idleLongBuild, clock: clock}
 	return b
 }
 
@@ -41,7 +43,7 @@ func (c *BuildCondition) Eval(object interface{}) (Action, error) {
 		return Idle, nil
 	}
 
-	now := time.Now().UTC()
+	now := c.clock.Now().UTC()
 
 	log.WithField("check", "active-builds").Infof("Checking active builds")
 	if u.HasActiveBuilds() {
@@ -86,19 +88,20 @@ func (c *BuildCondition) Eval(object interface{}) (Action, error) {
 		return Idle, nil
 	}
 
+	idleAfter := u.GetIdleAfter(c.idleAfter)
 	completionTime := u.DoneBuild.Status.CompletionTimestamp.Time
-	terminateTime := completionTime.Add(c.idleAfter)
+	terminateTime := completionTime.Add(idleAfter)
 
 	log.WithField("check", "done-builds").Infof(
 		"Check if completion time %v is past terminate time: %v", now, terminateTime)
 
 	if now.After(terminateTime) {
 		log.WithField("action", "idle").Infof(
-			"%v has elapsed after last done-build at %v ", c.idleAfter, completionTime)
+			"%v has elapsed after last done-build at %v ", idleAfter, completionTime)
 		return Idle, nil
 	}
 
 	log.WithField("action", "none").Infof(
-		"%v has not yet elapsed after last done-build at %v ", c.idleAfter, completionTime)
+		"%v has not yet elapsed after last done-build at %v ", idleAfter, completionTime)
 	return UnIdle, nil
 }
@@ -4,20 +4,21 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_non_user_creates_error(t *testing.T) {
 	user := "foo"
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute, clock.New())
 	_, err := condition.Eval(user)
 	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
 }
 
 func Test_eval_idle_if_there_are_no_builds(t *testing.T) {
 	user := model.NewUser("123", "foo")
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, Idle, result, "Condition should evaluate to Idle.")
@@ -33,7 +34,7 @@ func Test_eval_unidle_when_active_build_exists(t *testing.T) {
 			StartTimestamp: model.BuildTime{Time: time.Now()},
 		},
 	}
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, UnIdle, result, "Condition should evaluate to UnIdle.")
@@ -52,7 +53,7 @@ func Test_eval_idle_when_activebuild_is_old(t *testing.T) {
 			StartTimestamp: model.BuildTime{Time: oldTime},
 		},
 	}
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(10)*time.Hour)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(10)*time.Hour, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, Idle, result, "Condition should evaluate to Idle.")
@@ -68,7 +69,7 @@ func Test_eval_completion_before_idletime_expires(t *testing.T) {
 			CompletionTimestamp: model.BuildTime{Time: time.Now()},
 		},
 	}
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(5)*time.Minute, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, UnIdle, result, "Condition should evaluate to UnIdle.")
@@ -88,7 +89,7 @@ func Test_eval_completion_after_idletime_expires(t *testing.T) {
 			CompletionTimestamp: model.BuildTime{Time: oldTime},
 		},
 	}
-	condition := NewBuildCondition(5*time.Minute, 5*time.Minute)
+	condition := NewBuildCondition(5*time.Minute, 5*time.Minute, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, Idle, result, "Condition should evaluate to Idle.")
@@ -108,8 +109,33 @@ func Test_eval_ignore_pesky_jenkins_sync_plugin(t *testing.T) {
 			CompletionTimestamp: model.BuildTime{Time: completionTime},
 		},
 	}
-	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(10)*time.Hour)
+	condition := NewBuildCondition(time.Duration(5)*time.Minute, time.Duration(10)*time.Hour, clock.New())
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, Idle, result, "Condition should evaluate to Idle.")
 }
+
+func Test_eval_done_build_idles_once_idle_after_elapsed(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC))
+	user := model.NewUser("123", "foo")
+	user.DoneBuild = model.Build{
+		Metadata: model.Metadata{
+			Name: "test build",
+		},
+		Status: model.Status{
+			Phase:               "Complete",
+			CompletionTimestamp: model.BuildTime{Time: fakeClock.Now()},
+		},
+	}
+	condition := NewBuildCondition(5*time.Minute, 10*time.Hour, fakeClock)
+
+	fakeClock.Advance(5 * time.Minute)
+	result, err := condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, UnIdle, result, "Condition should evaluate to UnIdle until idle after elapsed.")
+
+	fakeClock.Advance(time.Second)
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, Idle, result, "Condition should evaluate to Idle once idle after elapsed.")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	"github.com/sirupsen/logrus"
 )
@@ -11,12 +12,14 @@ import (
 // DeploymentConfigCondition covers changes to DeploymentConfigs.
 type DeploymentConfigCondition struct {
 	idleAfter time.Duration
+	clock     clock.Clock
 }
 
 // NewDCCondition creates a new instance of DeploymentConfigCondition.
-func NewDCCondition(idleAfter time.Duration) Condition {
+func NewDCCondition(idleAfter time.Duration, clock clock.Clock) Condition {
 	return &DeploymentConfigCondition{
 		idleAfter: idleAfter,
+		clock:     clock,
 	}
 }
 
@@ -40,17 +43,18 @@ func (c *DeploymentConfigCondition) Eval(object interface{}) (Action, error) {
 		return NoAction, nil
 	}
 
-	now := time.Now().UTC()
-	terminateTime := lastUpdated.Add(c.idleAfter)
+	idleAfter := u.GetIdleAfter(c.idleAfter)
+	now := c.clock.Now().UTC()
+	terminateTime := lastUpdated.Add(idleAfter)
 
 	if now.After(terminateTime) {
 		log.WithField("action", "idle").Infof("%v (%v) has elapsed after last update at %v",
-			c.idleAfter, terminateTime, lastUpdated)
+			idleAfter, terminateTime, lastUpdated)
 		return Idle, nil
 	}
 
 	log.WithField("action", "unidle").Infof(
 		"%v (%v) has not elapsed after jenkins last update at %v",
-		c.idleAfter, terminateTime, lastUpdated)
+		idleAfter, terminateTime, lastUpdated)
 	return UnIdle, nil
 }
@@ -4,21 +4,24 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	"github.com/stretchr/testify/assert"
 )
 
+var now = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
 func Test_non_user_creates_error_in_build_condition(t *testing.T) {
 	user := "foo"
-	condition := NewDCCondition(time.Duration(5) * time.Minute)
+	condition := NewDCCondition(time.Duration(5)*time.Minute, clock.NewFake(now))
 	_, err := condition.Eval(user)
 	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
 }
 
 func Test_eval_idle_for_deployment_config_condition_if_last_change_is_older_than_g_time(t *testing.T) {
 	user := model.NewUser("123", "foo")
-	user.JenkinsLastUpdate = time.Now().Add(-6 * time.Minute)
-	condition := NewDCCondition(time.Duration(5) * time.Minute)
+	user.JenkinsLastUpdate = now.Add(-6 * time.Minute)
+	condition := NewDCCondition(time.Duration(5)*time.Minute, clock.NewFake(now))
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, Idle, result, "Condition should evaluate to Idle.")
@@ -26,8 +29,18 @@ func Test_eval_idle_for_deployment_config_condition_if_last_change_is_older_than
 
 func Test_eval_unidle_for_deployment_config_condition_if_last_change_is_younger_than_g_time(t *testing.T) {
 	user := model.NewUser("123", "foo")
-	user.JenkinsLastUpdate = time.Now().Add(-4 * time.Minute)
-	condition := NewDCCondition(time.Duration(5) * time.Minute)
+	user.JenkinsLastUpdate = now.Add(-4 * time.Minute)
+	condition := NewDCCondition(time.Duration(5)*time.Minute, clock.NewFake(now))
+	result, err := condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, UnIdle, result, "Condition should evaluate to UnIdle")
+}
+
+func Test_eval_deployment_config_condition_uses_idle_after_of_user(t *testing.T) {
+	user := model.NewUser("123", "foo")
+	user.JenkinsLastUpdate = now.Add(-6 * time.Minute)
+	user.IdleAfter = 4 * time.Hour
+	condition := NewDCCondition(time.Duration(5)*time.Minute, clock.NewFake(now))
 	result, err := condition.Eval(user)
 	assert.NoError(t, err)
 	assert.Equal(t, UnIdle, result, "Condition should evaluate to UnIdle")
@@ -0,0 +1,52 @@
+package condition
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ManualUnIdleCondition keeps Jenkins running for a grace period after it got un-idled manually, e.g. by the
+// user scaling up the deployment config for debugging.
+type ManualUnIdleCondition struct {
+	gracePeriod time.Duration
+	clock       clock.Clock
+}
+
+// NewManualUnIdleCondition creates a new instance of ManualUnIdleCondition.
+func NewManualUnIdleCondition(gracePeriod time.Duration, clock clock.Clock) Condition {
+	return &ManualUnIdleCondition{
+		gracePeriod: gracePeriod,
+		clock:       clock,
+	}
+}
+
+// Eval returns UnIdle as long as the grace period after a manual un-idle has not elapsed, NoAction otherwise.
+func (c *ManualUnIdleCondition) Eval(object interface{}) (Action, error) {
+	u, ok := object.(model.User)
+	if !ok {
+		return NoAction, fmt.Errorf("%T is not of type User", object)
+	}
+
+	if u.ManualUnIdleAt.IsZero() {
+		return NoAction, nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"id":        u.ID,
+		"name":      u.Name,
+		"component": "manual-unidle-condition",
+	})
+
+	graceEnd := u.ManualUnIdleAt.Add(c.gracePeriod)
+	if c.clock.Now().UTC().Before(graceEnd) {
+		log.WithField("action", "unidle").Infof(
+			"grace period of %v (%v) after manual un-idle at %v has not elapsed", c.gracePeriod, graceEnd, u.ManualUnIdleAt)
+		return UnIdle, nil
+	}
+
+	return NoAction, nil
+}
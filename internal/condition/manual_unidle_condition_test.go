@@ -0,0 +1,37 @@
+package condition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_non_user_creates_error_in_manual_unidle_condition(t *testing.T) {
+	condition := NewManualUnIdleCondition(time.Hour, clock.NewFake(now))
+	_, err := condition.Eval("foo")
+	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
+}
+
+func Test_eval_manual_unidle_condition(t *testing.T) {
+	tests := []struct {
+		name           string
+		manualUnIdleAt time.Time
+		action         Action
+	}{
+		{name: "no manual un-idle", action: NoAction},
+		{name: "within grace period", manualUnIdleAt: now.Add(-59 * time.Minute), action: UnIdle},
+		{name: "grace period elapsed", manualUnIdleAt: now.Add(-61 * time.Minute), action: NoAction},
+	}
+
+	condition := NewManualUnIdleCondition(time.Hour, clock.NewFake(now))
+	for _, test := range tests {
+		user := model.NewUser("123", "foo")
+		user.ManualUnIdleAt = test.manualUnIdleAt
+		result, err := condition.Eval(user)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.action, result, test.name)
+	}
+}
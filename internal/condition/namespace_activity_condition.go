@@ -0,0 +1,56 @@
+package condition
+
+import (
+	"fmt"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// NamespaceActivityCondition keeps Jenkins running while there is activity in the other namespaces of the user,
+// e.g. a running Che workspace, rather than judging the build namespace in isolation.
+type NamespaceActivityCondition struct {
+	types       []string
+	runningPods func(namespace model.Namespace) (int, error)
+}
+
+// NewNamespaceActivityCondition creates a new instance of NamespaceActivityCondition considering the user namespaces
+// of the given types active as long as runningPods reports running pods in them.
+func NewNamespaceActivityCondition(types []string, runningPods func(namespace model.Namespace) (int, error)) Condition {
+	return &NamespaceActivityCondition{
+		types:       types,
+		runningPods: runningPods,
+	}
+}
+
+// Eval returns UnIdle if a running Jenkins should be kept running due to pods running in one of the namespaces,
+// NoAction otherwise. An idled Jenkins is not woken up for activity in other namespaces.
+func (c *NamespaceActivityCondition) Eval(object interface{}) (Action, error) {
+	u, ok := object.(model.User)
+	if !ok {
+		return NoAction, fmt.Errorf("%T is not of type User", object)
+	}
+
+	if !u.IdledAt.IsZero() {
+		return NoAction, nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"id":        u.ID,
+		"name":      u.Name,
+		"component": "namespace-activity-condition",
+	})
+
+	for _, ns := range u.NamespacesOfType(c.types...) {
+		running, err := c.runningPods(ns)
+		if err != nil {
+			return NoAction, fmt.Errorf("unable to determine activity in namespace %s: %s", ns.Name, err)
+		}
+		if running > 0 {
+			log.WithField("action", "unidle").Infof("%d pods are running in %s namespace %s", running, ns.Type, ns.Name)
+			return UnIdle, nil
+		}
+	}
+
+	return NoAction, nil
+}
@@ -0,0 +1,55 @@
+package condition
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_non_user_creates_error_in_namespace_activity_condition(t *testing.T) {
+	condition := NewNamespaceActivityCondition([]string{"che"}, nil)
+	_, err := condition.Eval("foo")
+	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
+}
+
+func Test_eval_namespace_activity_condition(t *testing.T) {
+	pods := map[string]int{"foo-che": 1}
+	var queried []string
+	condition := NewNamespaceActivityCondition([]string{"che"}, func(ns model.Namespace) (int, error) {
+		queried = append(queried, ns.Name)
+		if ns.Name == "broken-che" {
+			return 0, errors.New("forbidden")
+		}
+		return pods[ns.Name], nil
+	})
+
+	user := model.NewUser("123", "foo")
+	user.Namespaces = []model.Namespace{
+		{Name: "foo", Type: "user"},
+		{Name: "foo-jenkins", Type: "jenkins"},
+		{Name: "foo-che", Type: "che"},
+	}
+	result, err := condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, UnIdle, result, "Running Che workspace should keep Jenkins running")
+	assert.Equal(t, []string{"foo-che"}, queried, "Only namespaces of the configured types should be queried")
+
+	pods["foo-che"] = 0
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, result)
+
+	pods["foo-che"] = 1
+	user.IdledAt = now.Add(-time.Hour)
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, result, "Idled Jenkins should not be woken up")
+
+	user.IdledAt = time.Time{}
+	user.Namespaces = []model.Namespace{{Name: "broken-che", Type: "che"}}
+	_, err = condition.Eval(user)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package condition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// namespacePlaceholder stands for the Jenkins namespace in the activity query.
+const namespacePlaceholder = "{{namespace}}"
+
+// PrometheusCondition covers the activity of Jenkins as recorded by Prometheus, e.g. UI usage or API polling, which
+// leaves no trace in the OpenShift objects.
+type PrometheusCondition struct {
+	client    *prometheus.Client
+	query     string
+	threshold float64
+}
+
+// NewPrometheusCondition creates a new instance of PrometheusCondition evaluating the given PromQL query, in which
+// {{namespace}} stands for the Jenkins namespace, against the Prometheus instance at prometheusURL.
+func NewPrometheusCondition(prometheusURL string, query string, threshold float64) Condition {
+	return &PrometheusCondition{
+		client:    prometheus.NewClient(prometheusURL),
+		query:     query,
+		threshold: threshold,
+	}
+}
+
+// Eval returns UnIdle if the activity of a running Jenkins exceeds the threshold, NoAction otherwise. As Prometheus
+// is an optional source of activity, failing queries are logged rather than keeping the other conditions from
+// deciding.
+func (c *PrometheusCondition) Eval(object interface{}) (Action, error) {
+	u, ok := object.(model.User)
+	if !ok {
+		return NoAction, fmt.Errorf("%T is not of type User", object)
+	}
+
+	if !u.IdledAt.IsZero() {
+		return NoAction, nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"id":        u.ID,
+		"name":      u.Name,
+		"component": "prometheus-condition",
+	})
+
+	activity, err := c.activity(namespace.Jenkins(u.Name))
+	if err != nil {
+		log.WithField("action", "none").Warnf("querying Prometheus failed: %s", err)
+		return NoAction, nil
+	}
+
+	if activity > c.threshold {
+		log.WithField("action", "unidle").Infof("activity %v exceeds threshold %v", activity, c.threshold)
+		return UnIdle, nil
+	}
+	return NoAction, nil
+}
+
+// activity returns the sum of the samples the query yields for the namespace.
+func (c *PrometheusCondition) activity(namespace string) (float64, error) {
+	return c.client.Sum(strings.Replace(c.query, namespacePlaceholder, namespace, -1))
+}
@@ -0,0 +1,50 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_non_user_creates_error_in_prometheus_condition(t *testing.T) {
+	condition := NewPrometheusCondition("http://localhost", "up", 0)
+	_, err := condition.Eval("foo")
+	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
+}
+
+func Test_eval_prometheus_condition(t *testing.T) {
+	value := "0.2"
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, `jenkins_busy{namespace="foo-jenkins"}`, r.URL.Query().Get("query"))
+		if value == "" {
+			fmt.Fprint(w, `{"status": "error", "error": "parse error"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {}, "value": [1523434035.5, "%s"]}]}}`, value)
+	}))
+	defer prometheus.Close()
+
+	condition := NewPrometheusCondition(prometheus.URL+"/", `jenkins_busy{namespace="{{namespace}}"}`, 0.1)
+	user := model.NewUser("123", "foo")
+
+	tests := []struct {
+		name   string
+		value  string
+		action Action
+	}{
+		{name: "active", value: "0.2", action: UnIdle},
+		{name: "below threshold", value: "0.1", action: NoAction},
+		{name: "query failed", value: "", action: NoAction},
+	}
+	for _, test := range tests {
+		value = test.value
+		result, err := condition.Eval(user)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.action, result, test.name)
+	}
+}
@@ -0,0 +1,70 @@
+package condition
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// RolloutCondition keeps Jenkins running after a build completed while deployment configs in the other namespaces
+// of the user, e.g. stage and run, are rolled out, since these rollouts are usually triggered by the pipeline whose
+// post-deploy verification steps break if Jenkins gets idled meanwhile.
+type RolloutCondition struct {
+	types    []string
+	hold     time.Duration
+	clock    clock.Clock
+	rollouts func(namespace model.Namespace) ([]string, error)
+}
+
+// NewRolloutCondition creates a new instance of RolloutCondition considering the rollouts, as reported by rollouts,
+// in the user namespaces of the given types during the hold period after the completion of the latest build.
+func NewRolloutCondition(types []string, hold time.Duration, clock clock.Clock, rollouts func(namespace model.Namespace) ([]string, error)) Condition {
+	return &RolloutCondition{
+		types:    types,
+		hold:     hold,
+		clock:    clock,
+		rollouts: rollouts,
+	}
+}
+
+// Eval returns UnIdle if a running Jenkins should be kept running since deployment configs are rolled out after its
+// latest build completed, NoAction otherwise. An idled Jenkins is not woken up for a rollout.
+func (c *RolloutCondition) Eval(object interface{}) (Action, error) {
+	u, ok := object.(model.User)
+	if !ok {
+		return NoAction, fmt.Errorf("%T is not of type User", object)
+	}
+
+	if !u.IdledAt.IsZero() || u.HasActiveBuilds() {
+		return NoAction, nil
+	}
+
+	completionTime := u.DoneBuild.Status.CompletionTimestamp.Time
+	if completionTime.IsZero() || c.clock.Now().UTC().After(completionTime.Add(c.hold)) {
+		return NoAction, nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"id":        u.ID,
+		"name":      u.Name,
+		"component": "rollout-condition",
+	})
+
+	for _, ns := range u.NamespacesOfType(c.types...) {
+		names, err := c.rollouts(ns)
+		if err != nil {
+			return NoAction, fmt.Errorf("unable to determine rollouts in namespace %s: %s", ns.Name, err)
+		}
+		if len(names) > 0 {
+			log.WithField("action", "unidle").Infof("%s in %s namespace %s rolled out after build %s completed at %v",
+				strings.Join(names, ", "), ns.Type, ns.Name, u.DoneBuild.Metadata.Name, completionTime)
+			return UnIdle, nil
+		}
+	}
+
+	return NoAction, nil
+}
@@ -0,0 +1,66 @@
+package condition
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_non_user_creates_error_in_rollout_condition(t *testing.T) {
+	condition := NewRolloutCondition([]string{"stage"}, time.Hour, clock.NewFake(now), nil)
+	_, err := condition.Eval("foo")
+	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
+}
+
+func Test_eval_rollout_condition(t *testing.T) {
+	rollouts := map[string][]string{"foo-stage": {"app"}}
+	var queried []string
+	condition := NewRolloutCondition([]string{"stage", "run"}, 30*time.Minute, clock.NewFake(now), func(ns model.Namespace) ([]string, error) {
+		queried = append(queried, ns.Name)
+		if ns.Name == "broken-run" {
+			return nil, errors.New("forbidden")
+		}
+		return rollouts[ns.Name], nil
+	})
+
+	user := model.NewUser("123", "foo")
+	user.Namespaces = []model.Namespace{
+		{Name: "foo-jenkins", Type: "jenkins"},
+		{Name: "foo-stage", Type: "stage"},
+		{Name: "foo-run", Type: "run"},
+	}
+	user.DoneBuild = model.Build{
+		Metadata: model.Metadata{Name: "foo-1"},
+		Status:   model.Status{Phase: "Complete", CompletionTimestamp: model.BuildTime{Time: now.Add(-10 * time.Minute)}},
+	}
+	result, err := condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, UnIdle, result, "Rollout following the build should keep Jenkins running")
+	assert.Equal(t, []string{"foo-stage"}, queried, "Only namespaces of the configured types should be queried")
+
+	delete(rollouts, "foo-stage")
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, result, "Finished rollouts should not keep Jenkins running")
+
+	rollouts["foo-run"] = []string{"app"}
+	user.DoneBuild.Status.CompletionTimestamp.Time = now.Add(-31 * time.Minute)
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, result, "Rollouts after the hold period should not keep Jenkins running")
+
+	user.DoneBuild.Status.CompletionTimestamp.Time = now
+	user.IdledAt = now
+	result, err = condition.Eval(user)
+	assert.NoError(t, err)
+	assert.Equal(t, NoAction, result, "Idled Jenkins should not be woken up")
+
+	user.IdledAt = time.Time{}
+	user.Namespaces = []model.Namespace{{Name: "broken-run", Type: "run"}}
+	_, err = condition.Eval(user)
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package condition
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+// StageCondition keeps Jenkins running while a pipeline is in one of the hold stages, e.g. waiting for the approval
+// to promote, even if the build phase looks complete or the build has been running for longer than the long build
+// timeout.
+type StageCondition struct {
+	patterns []string
+	maxHold  time.Duration
+	clock    clock.Clock
+}
+
+// NewStageCondition creates a new instance of StageCondition holding Jenkins during the pipeline stages whose names
+// match any of the given case-insensitive shell patterns, for at most maxHold after the start of the build. A zero
+// maxHold holds Jenkins for as long as the stage runs.
+func NewStageCondition(patterns []string, maxHold time.Duration, clock clock.Clock) Condition {
+	lower := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lower[i] = strings.ToLower(pattern)
+	}
+	return &StageCondition{
+		patterns: lower,
+		maxHold:  maxHold,
+		clock:    clock,
+	}
+}
+
+// Eval returns UnIdle if the latest active or completed build of the user is in a hold stage, NoAction otherwise. An
+// idled Jenkins is not woken up for a hold stage.
+func (c *StageCondition) Eval(object interface{}) (Action, error) {
+	u, ok := object.(model.User)
+	if !ok {
+		return NoAction, fmt.Errorf("%T is not of type User", object)
+	}
+
+	if !u.IdledAt.IsZero() {
+		return NoAction, nil
+	}
+
+	log := logrus.WithFields(logrus.Fields{
+		"id":        u.ID,
+		"name":      u.Name,
+		"component": "stage-condition",
+	})
+
+	for _, build := range []model.Build{u.ActiveBuild, u.DoneBuild} {
+		stage, running := build.RunningStage()
+		if !running || !c.holds(stage.Name) {
+			continue
+		}
+
+		startTime := build.Status.StartTimestamp.Time
+		if c.maxHold > 0 && !startTime.IsZero() && c.clock.Now().UTC().After(startTime.Add(c.maxHold)) {
+			log.WithField("check", "hold-stage-expired").Infof(
+				"stage %q of build %s started at %v has exceeded the maximum hold of %v", stage.Name, build.Metadata.Name, startTime, c.maxHold)
+			continue
+		}
+
+		log.WithField("action", "unidle").Infof("build %s is in stage %q (%s)", build.Metadata.Name, stage.Name, stage.Status)
+		return UnIdle, nil
+	}
+
+	return NoAction, nil
+}
+
+// holds returns whether the stage with the given name matches any of the patterns.
+func (c *StageCondition) holds(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range c.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
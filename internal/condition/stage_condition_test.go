@@ -0,0 +1,62 @@
+package condition
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const approvalStatus = `{"status": "PAUSED_PENDING_INPUT", "stages": [
+	{"name": "Build Release", "status": "SUCCESS"},
+	{"name": "Rollout to Stage", "status": "SUCCESS"},
+	{"name": "Approve to promote", "status": "PAUSED_PENDING_INPUT"}]}`
+
+func Test_non_user_creates_error_in_stage_condition(t *testing.T) {
+	condition := NewStageCondition([]string{"approve*"}, time.Hour, clock.NewFake(now))
+	_, err := condition.Eval("foo")
+	assert.Error(t, err, "Passing non User instances to Eval should return an error.")
+}
+
+func Test_eval_stage_condition(t *testing.T) {
+	tests := []struct {
+		name    string
+		phase   string
+		status  string
+		started time.Time
+		idled   bool
+		action  Action
+	}{
+		{name: "no status annotation", phase: "Running", started: now, action: NoAction},
+		{name: "waiting for approval", phase: "Running", status: approvalStatus, started: now.Add(-2 * time.Hour), action: UnIdle},
+		{name: "phase looks complete", phase: "Complete", status: approvalStatus, started: now, action: UnIdle},
+		{name: "other stage running", phase: "Running", started: now,
+			status: `{"stages": [{"name": "Approve", "status": "SUCCESS"}, {"name": "Test", "status": "IN_PROGRESS"}]}`, action: NoAction},
+		{name: "maximum hold exceeded", phase: "Running", status: approvalStatus, started: now.Add(-25 * time.Hour), action: NoAction},
+		{name: "idled", phase: "Running", status: approvalStatus, started: now, idled: true, action: NoAction},
+		{name: "malformed annotation", phase: "Running", status: `{"stages": `, started: now, action: NoAction},
+	}
+
+	condition := NewStageCondition([]string{"Approve*", "rollout*"}, 24*time.Hour, clock.NewFake(now))
+	for _, test := range tests {
+		user := model.NewUser("123", "foo")
+		build := model.Build{
+			Metadata: model.Metadata{Name: "foo-1", Annotations: model.Annotations{JenkinsStatus: test.status}},
+			Status:   model.Status{Phase: test.phase, StartTimestamp: model.BuildTime{Time: test.started}},
+		}
+		if test.phase == "Running" {
+			user.ActiveBuild = build
+		} else {
+			user.DoneBuild = build
+		}
+		if test.idled {
+			user.IdledAt = now
+		}
+
+		result, err := condition.Eval(user)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.action, result, test.name)
+	}
+}
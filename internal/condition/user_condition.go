@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,13 +28,15 @@ type ProxyResponse struct {
 type UserCondition struct {
 	idleAfter time.Duration
 	proxyURL  string
+	clock     clock.Clock
 }
 
 // NewUserCondition creates a new instance of Condition given a proxyURL and idleAfter.
-func NewUserCondition(proxyURL string, idleAfter time.Duration) Condition {
+func NewUserCondition(proxyURL string, idleAfter time.Duration, clock clock.Clock) Condition {
 	b := &UserCondition{
 		proxyURL:  proxyURL,
 		idleAfter: idleAfter,
+		clock:     clock,
 	}
 	return b
 }
@@ -63,13 +67,14 @@ func (c *UserCondition) Eval(object interface{}) (Action, error) {
 		return UnIdle, nil
 	}
 
+	idleAfter := u.GetIdleAfter(c.idleAfter)
 	lv := time.Unix(proxyResponse.LastVisit, 0)
-	visitIdleTime := lv.Add(c.idleAfter)
+	visitIdleTime := lv.Add(idleAfter)
 
 	lr := time.Unix(proxyResponse.LastRequest, 0)
-	reqIdleTime := lr.Add(c.idleAfter)
+	reqIdleTime := lr.Add(idleAfter)
 
-	now := time.Now().UTC()
+	now := c.clock.Now().UTC()
 
 	log.WithField("check", "proxy:last-visit").Infof(
 		"check if %v has gone past last visit %v - %v, last request %v - %v ",
@@ -78,18 +83,18 @@ func (c *UserCondition) Eval(object interface{}) (Action, error) {
 	if now.After(visitIdleTime) && now.After(reqIdleTime) {
 		log.WithField("action", "idle").Infof(
 			"%v (%v) has elapsed after last visit: %v last request: %v",
-			c.idleAfter, now, lv, lr)
+			idleAfter, now, lv, lr)
 		return Idle, nil
 	}
 
 	log.WithField("action", "idle").Infof(
 		"%v (%v) has not elapsed after last visit: %v last request: %v",
-		c.idleAfter, now, lv, lr)
+		idleAfter, now, lv, lr)
 	return UnIdle, nil
 }
 
 func (c *UserCondition) getProxyResponse(userName string) (*ProxyResponse, error) {
-	url := fmt.Sprintf("%s/api/info/%s-jenkins", c.proxyURL, userName)
+	url := fmt.Sprintf("%s/api/info/%s", c.proxyURL, namespace.Jenkins(userName))
 	logger.WithField("url", url).Debug("Accessing Proxy API.")
 	resp, err := http.Get(url)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/common"
 	"github.com/stretchr/testify/assert"
 )
@@ -20,6 +21,7 @@ func Test_get_proxy_response(t *testing.T) {
 
 	uc := UserCondition{
 		proxyURL: srv.URL,
+		clock:    clock.New(),
 	}
 
 	response, err := uc.getProxyResponse("test")
@@ -12,15 +12,128 @@ type Configuration interface {
 	// GetTenantURL returns the F8 Tenant API URL.
 	GetTenantURL() string
 
+	// GetTenantBackend returns the source of the tenant information: fabric8, file or kubernetes.
+	GetTenantBackend() string
+
+	// GetTenantFile returns the path of the YAML file listing the tenants for the file tenant backend.
+	GetTenantFile() string
+
+	// GetTenantUserLabel returns the namespace label carrying the user ID for the kubernetes tenant backend.
+	GetTenantUserLabel() string
+
+	// GetTenantMaxPages returns the maximum number of result pages followed per lookup at the fabric8-tenant service.
+	GetTenantMaxPages() int
+
+	// GetCapacityCacheTTL returns the number of seconds the capacity of a cluster is cached.
+	GetCapacityCacheTTL() int
+
+	// GetCapacityRetryAfter returns the number of seconds clients are asked to wait before retrying an un-idle
+	// refused due to the cluster being at capacity.
+	GetCapacityRetryAfter() int
+
+	// GetToggleProvider returns the name of the feature toggle provider.
+	GetToggleProvider() string
+
 	// GetToggleURL returns the Toggle Service URL.
 	GetToggleURL() string
 
+	// GetToggleBackupPath returns the directory the toggle definitions are backed up to.
+	GetToggleBackupPath() string
+
+	// GetToggleBootstrapFile returns the file the toggle definitions are loaded from unless backed up before.
+	GetToggleBootstrapFile() string
+
+	// GetToggleFile returns the YAML file defining the features of the configmap toggle provider.
+	GetToggleFile() string
+
+	// GetLaunchDarklyURL returns the base URL of the LaunchDarkly client-side SDK endpoints.
+	GetLaunchDarklyURL() string
+
+	// GetLaunchDarklyClientID returns the client-side ID of the LaunchDarkly environment.
+	GetLaunchDarklyClientID() string
+
 	// GetIdleAfter returns the number of minutes before Jenkins is idled.
 	GetIdleAfter() int
 
+	// GetManualUnIdleGracePeriod returns the number of minutes Jenkins is not idled after it got un-idled manually.
+	GetManualUnIdleGracePeriod() int
+
+	// GetEvictInactiveAfter returns the number of days after which the user-idler of a namespace without any
+	// activity is evicted. 0 disables the eviction.
+	GetEvictInactiveAfter() int
+
+	// GetWarmUpConcurrency returns the number of Jenkins namespaces of a cluster warmed up concurrently at startup.
+	GetWarmUpConcurrency() int
+
+	// GetNamespaceAllowlist returns the patterns of the namespaces managed by the Idler. If no pattern is
+	// configured, all namespaces not matching the denylist are managed.
+	GetNamespaceAllowlist() []string
+
+	// GetNamespaceDenylist returns the patterns of the namespaces ignored by the Idler.
+	GetNamespaceDenylist() []string
+
+	// GetDisabledClusters returns the API URLs of the clusters for which idling is disabled on startup.
+	GetDisabledClusters() []string
+
+	// GetMaxIdlesPerMinute returns the maximum number of idle operations per minute and cluster. 0 disables the limit.
+	GetMaxIdlesPerMinute() int
+
+	// GetJenkinsHealthProbe returns `true` if a running Jenkins is only considered running once it actually serves
+	// requests.
+	GetJenkinsHealthProbe() bool
+
+	// GetJenkinsHealthPath returns the path probed on the Jenkins route to check whether a running Jenkins is
+	// actually serving requests.
+	GetJenkinsHealthPath() string
+
+	// GetRouteTemplate returns the template of the host names of the Jenkins routes.
+	GetRouteTemplate() string
+
+	// GetRouteSource returns how the Jenkins routes are determined, template or route.
+	GetRouteSource() string
+
+	// GetRouteCheckInterval returns the number of minutes between the checks of the Jenkins routes.
+	GetRouteCheckInterval() int
+
+	// GetRouteCheckTimeout returns the number of seconds a check of a Jenkins route may take.
+	GetRouteCheckTimeout() int
+
+	// GetUnidleOnly returns `true` if Jenkins instances are un-idled on demand but never idled, on all clusters.
+	GetUnidleOnly() bool
+
+	// GetUnidleOnlyClusters returns the API URLs of the clusters on which Jenkins instances are un-idled on demand
+	// but never idled.
+	GetUnidleOnlyClusters() []string
+
+	// GetActivityNamespaceTypes returns the types of the user namespaces in which running pods keep Jenkins from
+	// being idled.
+	GetActivityNamespaceTypes() []string
+
+	// GetRolloutNamespaceTypes returns the types of the user namespaces whose rollouts keep Jenkins from being idled.
+	GetRolloutNamespaceTypes() []string
+
+	// GetRolloutHold returns the number of minutes after a build during which rollouts keep Jenkins from being idled.
+	GetRolloutHold() int
+
+	// GetJenkinsNamespaceSuffix returns the suffix appended to the name of a user to form the name of its Jenkins
+	// namespace.
+	GetJenkinsNamespaceSuffix() string
+
+	// GetActiveBuildPhases returns the build phases in which a build counts as activity.
+	GetActiveBuildPhases() []string
+
+	// GetHoldStages returns the patterns of the pipeline stages during which Jenkins is not idled.
+	GetHoldStages() []string
+
+	// GetHoldStageMax returns the number of hours a pipeline stage keeps Jenkins from being idled at most.
+	GetHoldStageMax() int
+
 	// GetIdleLongBuild returns how long it waits in hours for a long running build before idling
 	GetIdleLongBuild() int
 
+	// GetQuietDownTimeout returns the number of minutes running jobs are waited for before idling Jenkins.
+	GetQuietDownTimeout() int
+
 	// GetMaxRetries returns the maximum number of retries to idle resp. un-idle the Jenkins service.
 	GetMaxRetries() int
 
@@ -30,6 +143,17 @@ type Configuration interface {
 	// GetCheckInterval returns the number of minutes after which a regular idle check occurs.
 	GetCheckInterval() int
 
+	// GetDriftCheckInterval returns the number of minutes between the checks whether the believed state of Jenkins
+	// diverged from the actual one, 0 if disabled.
+	GetDriftCheckInterval() int
+
+	// GetClusterCheckIntervals returns the number of minutes between the regular idle checks keyed against the API
+	// URL of the clusters deviating from GetCheckInterval.
+	GetClusterCheckIntervals() map[string]int
+
+	// GetCheckJitter returns the percentage by which the check interval of each user idler is randomly shifted.
+	GetCheckJitter() int
+
 	// GetDebugMode returns if debug mode should be enabled.
 	GetDebugMode() bool
 
@@ -49,14 +173,255 @@ type Configuration interface {
 	// GetAuthTokenKey returns the key to decrypt OpenShift API tokens obtained via the Cluster API.
 	GetAuthTokenKey() string
 
+	// GetClusterTokenDir returns the directory of the per-cluster token files, e.g. a projected service account
+	// token volume.
+	GetClusterTokenDir() string
+
+	// GetClusterTokenExchange returns whether the cluster tokens are exchanged with the Auth service on demand.
+	GetClusterTokenExchange() bool
+
+	// GetClusterTokenTTL returns the number of minutes an exchanged cluster token is cached.
+	GetClusterTokenTTL() int
+
+	// GetTokenExpiryWarning returns the number of minutes before the expiry of a cluster token at which warnings
+	// are logged.
+	GetTokenExpiryWarning() int
+
 	// GetAuthGrantType returns the fabric8-auth Grant type used while retrieving
 	// user account token
 	GetAuthGrantType() string
 
+	// GetProfile returns the name of the configuration profile the Idler is running with.
+	GetProfile() string
+
+	// GetLogLevel returns the global log level.
+	GetLogLevel() string
+
+	// GetLogFormat returns the log output format, either 'json' or 'text'.
+	GetLogFormat() string
+
+	// GetLogComponentLevels returns the log level overrides keyed against the component name.
+	GetLogComponentLevels() map[string]string
+
+	// GetAccessLogSampleRate returns the fraction (0.0 - 1.0) of successful API requests which get access logged.
+	// Failed requests are always logged.
+	GetAccessLogSampleRate() float64
+
+	// GetAccessLogMetricsOnly returns `true` if API requests should only be recorded as metrics instead of access log entries.
+	GetAccessLogMetricsOnly() bool
+
+	// GetMaxRequestBodyBytes returns the maximum size in bytes of an API request body.
+	GetMaxRequestBodyBytes() int
+
+	// GetCORSAllowedOrigins returns the origins allowed to make cross-origin requests against the API.
+	// If no origin is configured, CORS is disabled.
+	GetCORSAllowedOrigins() []string
+
+	// GetCORSAllowedMethods returns the HTTP methods allowed for cross-origin requests.
+	GetCORSAllowedMethods() []string
+
+	// GetCORSAllowedHeaders returns the request headers allowed for cross-origin requests.
+	GetCORSAllowedHeaders() []string
+
+	// GetAPIAddress returns the address, [host]:port, the public API (status, idle state and un-idling) listens on.
+	GetAPIAddress() string
+
+	// GetAPIToken returns the bearer token required to call the public API. If empty, no authentication is required.
+	GetAPIToken() string
+
+	// GetAPIScopesFile returns the path of the YAML file defining the scoped tokens of the public API.
+	GetAPIScopesFile() string
+
+	// GetAdminAPIAddress returns the address, [host]:port, the admin API (idling, reset, user idler status,
+	// clusters, logging and profiling) listens on.
+	GetAdminAPIAddress() string
+
+	// GetAdminAPIToken returns the bearer token required to call the admin API. If empty, no authentication is required.
+	GetAdminAPIToken() string
+
+	// GetGRPCAddress returns the address, [host]:port, the gRPC API listens on. If empty, the gRPC API is disabled.
+	GetGRPCAddress() string
+
+	// GetRemediationEnabled returns `true` if crash-looping Jenkins pods should be reset automatically.
+	GetRemediationEnabled() bool
+
+	// GetRemediationMaxRestarts returns the number of restarts of a crash-looping Jenkins pod after which it gets reset.
+	GetRemediationMaxRestarts() int
+
+	// GetRemediationWebhookURL returns the URL notified about remediation actions. If empty, no notification is sent.
+	GetRemediationWebhookURL() string
+
+	// GetOOMKillThreshold returns the number of OOM kills after which a memory limit increase is recommended.
+	GetOOMKillThreshold() int
+
+	// GetOOMKillWindow returns the number of minutes within which the OOM kills of Jenkins are counted.
+	GetOOMKillWindow() int
+
+	// GetOOMLimitIncrease returns the percentage by which the memory limit of Jenkins is recommended to be increased.
+	GetOOMLimitIncrease() int
+
+	// GetOOMMaxMemoryLimit returns the maximum memory limit in MiB recommended for Jenkins.
+	GetOOMMaxMemoryLimit() int
+
+	// GetWatchIdlerConfigs returns whether the JenkinsIdlerConfig custom resources are watched.
+	GetWatchIdlerConfigs() bool
+
+	// GetResetGracePeriod returns the number of seconds the containers of a reset pod get to terminate gracefully.
+	GetResetGracePeriod() int
+
+	// GetResetTimeout returns the number of seconds a reset requested via the API waits for the replacement pods to
+	// get scheduled by default.
+	GetResetTimeout() int
+
+	// GetReservationTTL returns the number of seconds an un-idle reservation keeps Jenkins of its namespace from
+	// being idled.
+	GetReservationTTL() int
+
+	// GetMutationLock returns `true` if idling, un-idling and resetting Jenkins is serialized per namespace.
+	GetMutationLock() bool
+
+	// GetMutationLockTimeout returns the number of seconds a mutation waits for the lock of its namespace.
+	GetMutationLockTimeout() int
+
+	// GetDLQMaxRetries returns the number of times an event whose handling failed is retried.
+	GetDLQMaxRetries() int
+
+	// GetDLQSize returns the maximum number of failed events kept in the dead-letter queue.
+	GetDLQSize() int
+
+	// GetPrometheusURL returns the URL of the Prometheus instance queried for the activity of Jenkins. If empty, no
+	// Prometheus is queried.
+	GetPrometheusURL() string
+
+	// GetActivityQuery returns the PromQL query yielding the activity of the Jenkins in the namespace {{namespace}}.
+	GetActivityQuery() string
+
+	// GetActivityThreshold returns the value of the activity query above which Jenkins is considered active.
+	GetActivityThreshold() float64
+
+	// GetContentRepositoryIdleAfter returns the minutes without requests after which the content-repository is idled.
+	GetContentRepositoryIdleAfter() int
+
+	// GetContentRepositoryQuery returns the PromQL query yielding the requests to the content-repository in {{namespace}}
+	// within {{interval}}.
+	GetContentRepositoryQuery() string
+
+	// GetAdaptiveIdling returns true if idle timeouts are shortened while a cluster is under resource pressure.
+	GetAdaptiveIdling() bool
+
+	// GetPressureIdleAfter returns the number of minutes of inactivity after which Jenkins is idled while its cluster
+	// is under resource pressure.
+	GetPressureIdleAfter() int
+
+	// GetPressureRelaxAfter returns the number of minutes without pressure signal after which a cluster is no longer
+	// considered under pressure.
+	GetPressureRelaxAfter() int
+
+	// GetPressureQuery returns the PromQL query yielding the resource pressure of the cluster {{cluster}}. If empty,
+	// Prometheus is not queried for the pressure.
+	GetPressureQuery() string
+
+	// GetPressureThreshold returns the value of the pressure query from which on a cluster is considered under pressure.
+	GetPressureThreshold() float64
+
+	// GetFootprintInterval returns the number of minutes between the measurements of the resource usage of Jenkins.
+	GetFootprintInterval() int
+
+	// GetFootprintCPUQuery returns the PromQL query yielding the CPU cores consumed by Jenkins.
+	GetFootprintCPUQuery() string
+
+	// GetFootprintMemoryQuery returns the PromQL query yielding the bytes of memory consumed by Jenkins.
+	GetFootprintMemoryQuery() string
+
+	// GetNotifyWebhookURL returns the URL of the webhook notified about notable events. If empty, no notifications
+	// are sent.
+	GetNotifyWebhookURL() string
+
+	// GetNotifyFormat returns the format of the notifications, either "slack" or "json".
+	GetNotifyFormat() string
+
+	// GetNotifyEvents returns the classes of the events to notify about.
+	GetNotifyEvents() []string
+
+	// GetNotifyCapacitySpike returns the number of un-idle requests refused within five minutes due to the capacity
+	// of a cluster which is notified about.
+	GetNotifyCapacitySpike() int
+
+	// GetDigestSchedule returns how often a digest of the activity of the Idler is sent: daily, weekly or never.
+	GetDigestSchedule() string
+
+	// GetDigestTop returns the number of never idling namespaces listed in a digest.
+	GetDigestTop() int
+
+	// GetDigestWebhookURL returns the URL of the webhook the digests are posted to.
+	GetDigestWebhookURL() string
+
+	// GetDigestEmailTo returns the addresses the digests are emailed to.
+	GetDigestEmailTo() []string
+
+	// GetDigestEmailFrom returns the sender address of the digest emails.
+	GetDigestEmailFrom() string
+
+	// GetDigestSMTPAddress returns the address of the SMTP server the digest emails are sent through.
+	GetDigestSMTPAddress() string
+
+	// GetDigestSMTPUsername returns the username authenticating the Idler to the SMTP server.
+	GetDigestSMTPUsername() string
+
+	// GetDigestSMTPPassword returns the password authenticating the Idler to the SMTP server.
+	GetDigestSMTPPassword() string
+
+	// GetBuildLabelSelector returns the label selector restricting the watched builds. If empty, all builds are watched.
+	GetBuildLabelSelector() string
+
+	// GetBuildFieldSelector returns the field selector restricting the watched builds. If empty, all builds are watched.
+	GetBuildFieldSelector() string
+
+	// GetDCLabelSelector returns the label selector restricting the watched deployment configs.
+	GetDCLabelSelector() string
+
+	// GetDCFieldSelector returns the field selector restricting the watched deployment configs. If empty, all deployment configs are watched.
+	GetDCFieldSelector() string
+
+	// GetPodLabelSelector returns the label selector restricting the watched pods.
+	GetPodLabelSelector() string
+
+	// GetPodFieldSelector returns the field selector restricting the watched pods. If empty, all pods are watched.
+	GetPodFieldSelector() string
+
+	// GetHTTPReadTimeout returns the number of seconds the API server waits for a complete request, including its body.
+	GetHTTPReadTimeout() int
+
+	// GetHTTPWriteTimeout returns the number of seconds after reading the request headers within which the API server
+	// needs to have written the response.
+	GetHTTPWriteTimeout() int
+
+	// GetHTTPIdleTimeout returns the number of seconds the API server keeps an idle keep-alive connection open.
+	GetHTTPIdleTimeout() int
+
+	// GetHTTPMaxHeaderBytes returns the maximum size in bytes of the request headers accepted by the API server.
+	GetHTTPMaxHeaderBytes() int
+
+	// GetHTTPMaxConnections returns the maximum number of concurrent connections per API listener. 0 means unlimited.
+	GetHTTPMaxConnections() int
+
+	// GetRateLimit returns the number of requests each caller may make to the public API per rate limit window.
+	GetRateLimit() int
+
+	// GetRateLimitWindow returns the number of seconds of the window the requests of each caller are counted in.
+	GetRateLimitWindow() int
+
+	// GetTrustedProxies returns the addresses resp. CIDR ranges of the proxies whose X-Forwarded-For header is trusted.
+	GetTrustedProxies() []string
+
 	// Verify validates the configuration and returns an error in case the configuration is missing required settings
 	// or contains invalid settings. If the configuration is correct nil is returned.
 	Verify() util.MultiError
 
 	// String returns the current configuration as string.
 	String() string
+
+	// Effective returns the effective value of each configuration option along with the layer it got set by, with
+	// secrets masked.
+	Effective() []Setting
 }
@@ -0,0 +1,187 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// usages documents the configuration options in the command line help.
+var usages = map[string]string{
+	proxyURL:                   "URL of the Jenkins Proxy API",
+	tenantURL:                  "URL of the fabric8-tenant API",
+	tenantBackend:              "source of the tenant information: fabric8, file or kubernetes",
+	tenantFile:                 "YAML file listing the tenants for the file tenant backend",
+	tenantUserLabel:            "namespace label carrying the user ID for the kubernetes tenant backend",
+	tenantMaxPages:             "maximum number of result pages followed per tenant lookup, 0 for no limit",
+	capacityCacheTTL:           "seconds the capacity of a cluster is cached, 0 disables the cache",
+	capacityRetryAfter:         "seconds clients are asked to wait before retrying an un-idle refused due to the capacity",
+	toggleProvider:             "feature toggle provider: unleash, configmap, launchdarkly or static",
+	toggleURL:                  "URL of the Unleash API",
+	toggleBackupPath:           "directory the toggle definitions fetched from Unleash are backed up to",
+	toggleBootstrapFile:        "file the toggle definitions are loaded from at startup",
+	toggleFile:                 "YAML file defining the features of the configmap toggle provider",
+	launchDarklyURL:            "base URL of the LaunchDarkly client-side SDK endpoints",
+	launchDarklyClientID:       "client-side ID of the LaunchDarkly environment",
+	authURL:                    "URL of the Auth API",
+	serviceAccountID:           "ID of the service account authenticating the Idler to the Auth service",
+	serviceAccountSecret:       "secret of the service account authenticating the Idler to the Auth service",
+	authTokenKey:               "key to decrypt the OpenShift API tokens obtained via the Cluster API",
+	clusterTokenDir:            "directory of the per-cluster token files, e.g. a projected service account token volume",
+	clusterTokenExchange:       "exchange the cluster tokens with the Auth service on demand",
+	clusterTokenTTL:            "minutes an exchanged cluster token is cached",
+	tokenExpiryWarning:         "minutes before the expiry of a cluster token at which warnings are logged",
+	authGrantType:              "grant type used to obtain the service account token",
+	idleAfter:                  "minutes of inactivity after which Jenkins is idled",
+	idleLongBuild:              "hours after which Jenkins running a build is idled anyway",
+	quietDownTimeout:           "minutes Jenkins running jobs is put in quiet-down mode to let them finish before it gets idled, 0 idles right away",
+	maxRetries:                 "maximum number of retries to idle resp. un-idle Jenkins",
+	maxRetriesQuietInterval:    "minutes no retry occurs after the maximum retry count is reached",
+	checkInterval:              "minutes between the regular idle checks",
+	clusterCheckIntervals:      "whitespace separated <api url>=<minutes> check intervals of the clusters deviating from the global one",
+	checkJitter:                "percentage by which the check interval of each user-idler is randomly shifted",
+	driftCheckInterval:         "minutes between the checks whether the believed state of Jenkins diverged from the actual one, 0 disables them",
+	manualUnIdleGracePeriod:    "minutes Jenkins is not idled after it got un-idled manually",
+	evictInactiveAfter:         "days after which the user-idler of an inactive namespace is evicted, 0 disables eviction",
+	warmUpConcurrency:          "number of namespaces per cluster whose user-idlers are created concurrently at startup",
+	namespaceAllowlist:         "whitespace separated patterns of the namespaces managed by the Idler",
+	namespaceDenylist:          "whitespace separated patterns of the namespaces ignored by the Idler",
+	disabledClusters:           "whitespace separated API URLs of the clusters for which idling is disabled on startup",
+	unidleOnly:                 "un-idle Jenkins on demand but never idle it",
+	unidleOnlyClusters:         "whitespace separated API URLs of the clusters on which Jenkins is never idled",
+	activityNamespaceTypes:     "whitespace separated types of the user namespaces whose running pods keep Jenkins active",
+	rolloutNamespaceTypes:      "whitespace separated types of the user namespaces whose rollouts following a completed build keep Jenkins running",
+	rolloutHold:                "minutes after the completion of a build during which rollouts in the user namespaces keep Jenkins running, 0 disables the check",
+	jenkinsNamespaceSuffix:     "suffix appended to the name of a user to form the name of its Jenkins namespace",
+	activeBuildPhases:          "whitespace separated build phases in which a build keeps Jenkins active",
+	holdStages:                 "whitespace separated case-insensitive patterns of the pipeline stages during which Jenkins is not idled",
+	holdStageMax:               "hours after the start of a build after which its pipeline stage no longer keeps Jenkins running, 0 for no limit",
+	maxIdlesPerMinute:          "maximum number of idle operations per minute and cluster, 0 for no limit",
+	jenkinsHealthProbe:         "consider Jenkins running only once it serves requests",
+	jenkinsHealthPath:          "path probed on the Jenkins route to check whether Jenkins serves requests",
+	routeTemplate:              "template of the Jenkins route hosts, {namespace}, {user} and {appDomain} standing for the Jenkins namespace, the user and the application domain of the cluster",
+	routeSource:                "source of the Jenkins routes: template or route, reading the Route objects of the Jenkins namespaces",
+	routeCheckInterval:         "minutes between the checks whether the Jenkins routes of the clusters resolve and serve TLS correctly, 0 disables the checks",
+	routeCheckTimeout:          "seconds within which a Jenkins route needs to resolve and complete the TLS handshake",
+	debugMode:                  "enable development related features",
+	fixedUuids:                 "whitespace separated user IDs for which idling is enabled, bypassing the feature toggles",
+	profile:                    "configuration profile: " + strings.Join(Profiles(), ", "),
+	logLevel:                   "global log level",
+	logFormat:                  "log output format: json or text",
+	logComponentLevels:         "whitespace separated component=level log level overrides",
+	accessLogSampleRate:        "fraction (0.0 - 1.0) of the successful API requests which get access logged",
+	accessLogMetricsOnly:       "record API requests as metrics only instead of access log entries",
+	maxRequestBodyBytes:        "maximum size in bytes of an API request body",
+	corsAllowedOrigins:         "whitespace separated origins allowed to make cross-origin requests",
+	corsAllowedMethods:         "whitespace separated HTTP methods allowed for cross-origin requests",
+	corsAllowedHeaders:         "whitespace separated request headers allowed for cross-origin requests",
+	apiAddress:                 "address, [host]:port, the public API listens on",
+	apiToken:                   "bearer token required to call the public API",
+	apiScopesFile:              "path of the YAML file defining tokens of the public API restricted to a set of namespaces resp. clusters",
+	adminAPIAddress:            "address, [host]:port, the admin API listens on",
+	adminAPIToken:              "bearer token required to call the admin API",
+	grpcAddress:                "address, [host]:port, the gRPC API listens on, empty disables it",
+	httpReadTimeout:            "seconds the API server waits for a complete request",
+	httpWriteTimeout:           "seconds within which the API server needs to have written the response",
+	httpIdleTimeout:            "seconds the API server keeps an idle keep-alive connection open",
+	httpMaxHeaderBytes:         "maximum size in bytes of the request headers",
+	httpMaxConnections:         "maximum number of concurrent connections per API listener, 0 for no limit",
+	rateLimit:                  "number of requests each caller may make to the public API per rate limit window, 0 disables rate limiting",
+	rateLimitWindow:            "seconds of the window the requests of each caller to the public API are counted in",
+	trustedProxies:             "whitespace separated addresses resp. CIDR ranges of the proxies whose X-Forwarded-For header identifies the callers of the public API",
+	remediationEnabled:         "reset crash-looping Jenkins pods automatically",
+	remediationMaxRestarts:     "number of restarts of a crash-looping Jenkins pod after which it gets reset",
+	remediationWebhookURL:      "URL notified about remediation actions",
+	oomKillThreshold:           "number of OOM kills of Jenkins within the OOM kill window after which an increase of its memory limit is recommended, 0 disables the recommendations",
+	oomKillWindow:              "minutes within which the OOM kills of Jenkins are counted",
+	oomLimitIncrease:           "percentage by which the memory limit of Jenkins is recommended to be increased after repeated OOM kills",
+	oomMaxMemoryLimit:          "maximum memory limit in MiB recommended for Jenkins",
+	watchIdlerConfigs:          "watch the JenkinsIdlerConfig custom resources in the Jenkins namespaces and apply the overrides they specify",
+	resetGracePeriod:           "seconds the containers of a reset pod get to terminate gracefully",
+	resetTimeout:               "seconds a reset requested via the API waits for the replacement pods",
+	reservationTTL:             "seconds an un-idle reservation keeps Jenkins from being idled",
+	mutationLock:               "serialize idling, un-idling and resetting Jenkins per namespace via a Lease",
+	mutationLockTimeout:        "seconds a mutation waits for the lock of its namespace",
+	dlqMaxRetries:              "number of times an event whose handling failed is retried with backoff, 0 disables the retries",
+	dlqSize:                    "maximum number of failed events kept in the dead-letter queue",
+	prometheusURL:              "URL of the Prometheus instance queried for the activity and the pressure",
+	activityQuery:              "PromQL query yielding the activity of Jenkins, {{namespace}} standing for its namespace and {{interval}} for the time between two checks",
+	activityThreshold:          "value of the activity query above which Jenkins is considered active",
+	contentRepositoryIdleAfter: "minutes without requests after which the content-repository service is idled on its own, 0 idles it along with Jenkins",
+	contentRepositoryQuery:     "PromQL query yielding the requests to the content-repository service, {{namespace}} standing for its namespace and {{interval}} for the time between two checks",
+	adaptiveIdling:             "shorten the idle timeouts while a cluster is under resource pressure",
+	pressureIdleAfter:          "minutes of inactivity after which Jenkins is idled while its cluster is under pressure",
+	pressureRelaxAfter:         "minutes without pressure signal after which a cluster is no longer under pressure",
+	pressureQuery:              "PromQL query yielding the resource pressure of a cluster, {{cluster}} standing for its API URL",
+	pressureThreshold:          "value of the pressure query from which on a cluster is under pressure",
+	footprintInterval:          "minutes between the measurements of the resource usage of the Jenkins instances, 0 disables them",
+	footprintCPUQuery:          "PromQL query yielding the CPU cores consumed by Jenkins, {{namespace}} standing for its namespace and {{interval}} for the time between two checks",
+	footprintMemoryQuery:       "PromQL query yielding the bytes of memory consumed by Jenkins, {{namespace}} standing for its namespace and {{interval}} for the time between two checks",
+	notifyWebhookURL:           "URL of the Slack or generic webhook notified about notable events",
+	notifyFormat:               "format of the notifications: slack or json",
+	notifyEvents:               "whitespace separated classes of the events to notify about",
+	notifyCapacitySpike:        "number of un-idles refused within five minutes due to the capacity which is notified",
+	digestSchedule:             "how often a digest of the activity of the Idler is sent: daily or weekly, empty disables the digests",
+	digestTop:                  "number of never idling namespaces listed in a digest",
+	digestWebhookURL:           "URL of the Slack or generic webhook the digests are posted to, in the format of the notifications",
+	digestEmailTo:              "whitespace separated addresses the digests are emailed to",
+	digestEmailFrom:            "sender address of the digest emails",
+	digestSMTPAddress:          "address, host:port, of the SMTP server the digest emails are sent through",
+	digestSMTPUsername:         "username authenticating the Idler to the SMTP server, empty for no authentication",
+	digestSMTPPassword:         "password authenticating the Idler to the SMTP server",
+	buildLabelSelector:         "label selector restricting the watched builds",
+	buildFieldSelector:         "field selector restricting the watched builds",
+	dcLabelSelector:            "label selector restricting the watched deployment configs",
+	dcFieldSelector:            "field selector restricting the watched deployment configs",
+	podLabelSelector:           "label selector restricting the watched pods",
+	podFieldSelector:           "field selector restricting the watched pods",
+}
+
+// FlagName returns the name of the command line flag of the configuration option, e.g. idle-after for JC_IDLE_AFTER.
+func FlagName(key string) string {
+	return strings.Replace(strings.ToLower(strings.TrimPrefix(key, "JC_")), "_", "-", -1)
+}
+
+// AddFlags defines a flag for each configuration option on the flag set. The flags default to the built-in defaults
+// and are typed after them; lists are whitespace separated, as in the environment.
+func AddFlags(flags *pflag.FlagSet) {
+	defaults := Config{v: viper.New()}
+	defaults.setConfigDefaults()
+
+	keys := make([]string, 0, len(usages))
+	for key := range usages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, usage := FlagName(key), fmt.Sprintf("%s (%s)", usages[key], key)
+		switch value := defaults.v.Get(key).(type) {
+		case bool:
+			flags.Bool(name, value, usage)
+		case int:
+			flags.Int(name, value, usage)
+		case float64:
+			flags.Float64(name, value, usage)
+		case []string:
+			flags.String(name, strings.Join(value, " "), usage)
+		default:
+			flags.String(name, fmt.Sprintf("%v", value), usage)
+		}
+	}
+}
+
+// FlagValues returns the configuration options set on the command line keyed against their names, to be passed to
+// NewLayered. Flags left at their default do not override the config file and the environment.
+func FlagValues(flags *pflag.FlagSet) map[string]string {
+	values := make(map[string]string)
+	for key := range usages {
+		if f := flags.Lookup(FlagName(key)); f != nil && f.Changed {
+			values[key] = f.Value.String()
+		}
+	}
+	return values
+}
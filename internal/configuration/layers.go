@@ -0,0 +1,124 @@
+package configuration
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+)
+
+// Sources of the configuration values, in ascending order of precedence.
+const (
+	// SourceDefault marks a value being the built-in default.
+	SourceDefault = "default"
+
+	// SourceProfile marks a value being the default of the selected profile.
+	SourceProfile = "profile"
+
+	// SourceFile marks a value being read from the config file.
+	SourceFile = "file"
+
+	// SourceEnv marks a value being read from an environment variable.
+	SourceEnv = "env"
+
+	// SourceFlag marks a value being set via the command line.
+	SourceFlag = "flag"
+)
+
+// profiles are the defaults of the named configuration profiles, overriding the built-in defaults. The default
+// profile keeps the built-in defaults.
+var profiles = map[string]map[string]interface{}{
+	defaultProfile: {},
+	"dev": {
+		logFormat:          "text",
+		logLevel:           "debug",
+		debugMode:          true,
+		idleAfter:          10,
+		capacityRetryAfter: 10,
+	},
+	"staging": {
+		logLevel:  "debug",
+		debugMode: false,
+	},
+	"prod": {
+		logFormat: "json",
+		logLevel:  "info",
+		debugMode: false,
+	},
+}
+
+// Profiles returns the names of the configuration profiles.
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Setting is the effective value of a configuration option together with the layer it got set by.
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// applyProfile sets the defaults of the selected profile, so that they are overridden by the config file, the
+// environment and the flags only. Unknown profiles are rejected by Verify.
+func (c *Config) applyProfile() {
+	c.profileKeys = profiles[c.v.GetString(profile)]
+	for key, value := range c.profileKeys {
+		c.v.SetDefault(key, value)
+	}
+}
+
+// source returns the layer the effective value of the given option got set by.
+func (c *Config) source(key string) string {
+	key = strings.ToUpper(key)
+	if _, ok := c.flags[key]; ok {
+		return SourceFlag
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		return SourceEnv
+	}
+	if c.v.InConfig(strings.ToLower(key)) {
+		return SourceFile
+	}
+	if _, ok := c.profileKeys[key]; ok {
+		return SourceProfile
+	}
+	return SourceDefault
+}
+
+// Effective returns the effective value of each configuration option sorted by key, along with the layer it got set
+// by. Tokens, secrets and credentials are masked.
+func (c *Config) Effective() []Setting {
+	all := c.v.AllSettings()
+	settings := make([]Setting, 0, len(all))
+	for key, value := range all {
+		key = strings.ToUpper(key)
+		if secret(key) {
+			value = redact.Mask
+		} else if s, ok := value.(string); ok {
+			value = redact.String(s)
+		}
+		settings = append(settings, Setting{Key: key, Value: value, Source: c.source(key)})
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// secretKeys are the options holding secrets, which must not be echoed. Slack webhook URLs embed a token.
+var secretKeys = []string{serviceAccountSecret, authTokenKey, apiToken, adminAPIToken, notifyWebhookURL, remediationWebhookURL, digestWebhookURL}
+
+// secret returns whether the option with the given key holds a secret.
+func secret(key string) bool {
+	for _, k := range secretKeys {
+		if k == key {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "_SECRET") || strings.HasSuffix(key, "_PASSWORD")
+}
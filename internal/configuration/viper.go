@@ -2,45 +2,234 @@ package configuration
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	errs "github.com/pkg/errors"
 	"github.com/spf13/viper"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
 )
 
 const (
 	// Constants for viper variable names. Will be used to set
 	// default values as well as to get each value
-	proxyURL                = "JC_JENKINS_PROXY_API_URL"
-	tenantURL               = "JC_F8TENANT_API_URL"
-	toggleURL               = "JC_TOGGLE_API_URL"
-	authURL                 = "JC_AUTH_URL"
-	serviceAccountID        = "JC_SERVICE_ACCOUNT_ID"
-	serviceAccountSecret    = "JC_SERVICE_ACCOUNT_SECRET"
-	authTokenKey            = "JC_AUTH_TOKEN_KEY"
-	authGrantType           = "JC_AUTH_GRANT_TYPE"
-	idleAfter               = "JC_IDLE_AFTER"
-	idleLongBuild           = "JC_IDLE_LONG_BUILD"
-	maxRetries              = "JC_MAX_RETRIES"
-	maxRetriesQuietInterval = "JC_MAX_RETRIES_QUIET_INTERVAL"
-	checkInterval           = "JC_CHECK_INTERVAL"
-	debugMode               = "JC_DEBUG_MODE"
-	fixedUuids              = "JC_FIXED_UUIDS"
-
-	defaultIdleLongBuild           = 3
-	defaultIdleAfter               = 45
-	defaultMaxRetries              = 10
-	defaultMaxRetriesQuietInterval = 30
-	defaultCheckInterval           = 15
+	proxyURL                   = "JC_JENKINS_PROXY_API_URL"
+	tenantURL                  = "JC_F8TENANT_API_URL"
+	tenantBackend              = "JC_TENANT_BACKEND"
+	tenantFile                 = "JC_TENANT_FILE"
+	tenantUserLabel            = "JC_TENANT_USER_LABEL"
+	tenantMaxPages             = "JC_TENANT_MAX_PAGES"
+	capacityCacheTTL           = "JC_CAPACITY_CACHE_TTL"
+	capacityRetryAfter         = "JC_CAPACITY_RETRY_AFTER"
+	toggleProvider             = "JC_TOGGLE_PROVIDER"
+	toggleURL                  = "JC_TOGGLE_API_URL"
+	toggleBackupPath           = "JC_TOGGLE_BACKUP_PATH"
+	toggleBootstrapFile        = "JC_TOGGLE_BOOTSTRAP_FILE"
+	toggleFile                 = "JC_TOGGLE_FILE"
+	launchDarklyURL            = "JC_LAUNCHDARKLY_URL"
+	launchDarklyClientID       = "JC_LAUNCHDARKLY_CLIENT_ID"
+	authURL                    = "JC_AUTH_URL"
+	serviceAccountID           = "JC_SERVICE_ACCOUNT_ID"
+	serviceAccountSecret       = "JC_SERVICE_ACCOUNT_SECRET"
+	authTokenKey               = "JC_AUTH_TOKEN_KEY"
+	clusterTokenDir            = "JC_CLUSTER_TOKEN_DIR"
+	clusterTokenExchange       = "JC_CLUSTER_TOKEN_EXCHANGE"
+	clusterTokenTTL            = "JC_CLUSTER_TOKEN_TTL"
+	tokenExpiryWarning         = "JC_TOKEN_EXPIRY_WARNING"
+	authGrantType              = "JC_AUTH_GRANT_TYPE"
+	idleAfter                  = "JC_IDLE_AFTER"
+	idleLongBuild              = "JC_IDLE_LONG_BUILD"
+	quietDownTimeout           = "JC_QUIET_DOWN_TIMEOUT"
+	maxRetries                 = "JC_MAX_RETRIES"
+	maxRetriesQuietInterval    = "JC_MAX_RETRIES_QUIET_INTERVAL"
+	checkInterval              = "JC_CHECK_INTERVAL"
+	clusterCheckIntervals      = "JC_CLUSTER_CHECK_INTERVALS"
+	checkJitter                = "JC_CHECK_JITTER"
+	driftCheckInterval         = "JC_DRIFT_CHECK_INTERVAL"
+	manualUnIdleGracePeriod    = "JC_MANUAL_UNIDLE_GRACE_PERIOD"
+	evictInactiveAfter         = "JC_EVICT_INACTIVE_AFTER"
+	warmUpConcurrency          = "JC_WARMUP_CONCURRENCY"
+	namespaceAllowlist         = "JC_NAMESPACE_ALLOWLIST"
+	namespaceDenylist          = "JC_NAMESPACE_DENYLIST"
+	disabledClusters           = "JC_DISABLED_CLUSTERS"
+	unidleOnly                 = "JC_UNIDLE_ONLY"
+	unidleOnlyClusters         = "JC_UNIDLE_ONLY_CLUSTERS"
+	activityNamespaceTypes     = "JC_ACTIVITY_NAMESPACE_TYPES"
+	rolloutNamespaceTypes      = "JC_ROLLOUT_NAMESPACE_TYPES"
+	rolloutHold                = "JC_ROLLOUT_HOLD"
+	jenkinsNamespaceSuffix     = "JC_JENKINS_NAMESPACE_SUFFIX"
+	activeBuildPhases          = "JC_ACTIVE_BUILD_PHASES"
+	holdStages                 = "JC_HOLD_STAGES"
+	holdStageMax               = "JC_HOLD_STAGE_MAX"
+	maxIdlesPerMinute          = "JC_MAX_IDLES_PER_MINUTE"
+	jenkinsHealthProbe         = "JC_JENKINS_HEALTH_PROBE"
+	jenkinsHealthPath          = "JC_JENKINS_HEALTH_PATH"
+	routeTemplate              = "JC_ROUTE_TEMPLATE"
+	routeSource                = "JC_ROUTE_SOURCE"
+	routeCheckInterval         = "JC_ROUTE_CHECK_INTERVAL"
+	routeCheckTimeout          = "JC_ROUTE_CHECK_TIMEOUT"
+	debugMode                  = "JC_DEBUG_MODE"
+	fixedUuids                 = "JC_FIXED_UUIDS"
+	profile                    = "JC_PROFILE"
+	logLevel                   = "JC_LOG_LEVEL"
+	logFormat                  = "JC_LOG_FORMAT"
+	logComponentLevels         = "JC_LOG_COMPONENT_LEVELS"
+	accessLogSampleRate        = "JC_ACCESS_LOG_SAMPLE_RATE"
+	accessLogMetricsOnly       = "JC_ACCESS_LOG_METRICS_ONLY"
+	maxRequestBodyBytes        = "JC_MAX_REQUEST_BODY_BYTES"
+	corsAllowedOrigins         = "JC_CORS_ALLOWED_ORIGINS"
+	corsAllowedMethods         = "JC_CORS_ALLOWED_METHODS"
+	corsAllowedHeaders         = "JC_CORS_ALLOWED_HEADERS"
+	apiAddress                 = "JC_API_ADDRESS"
+	apiToken                   = "JC_API_TOKEN"
+	apiScopesFile              = "JC_API_SCOPES_FILE"
+	adminAPIAddress            = "JC_ADMIN_API_ADDRESS"
+	adminAPIToken              = "JC_ADMIN_API_TOKEN"
+	grpcAddress                = "JC_GRPC_ADDRESS"
+	httpReadTimeout            = "JC_HTTP_READ_TIMEOUT"
+	httpWriteTimeout           = "JC_HTTP_WRITE_TIMEOUT"
+	httpIdleTimeout            = "JC_HTTP_IDLE_TIMEOUT"
+	httpMaxHeaderBytes         = "JC_HTTP_MAX_HEADER_BYTES"
+	httpMaxConnections         = "JC_HTTP_MAX_CONNECTIONS"
+	rateLimit                  = "JC_RATE_LIMIT"
+	rateLimitWindow            = "JC_RATE_LIMIT_WINDOW"
+	trustedProxies             = "JC_TRUSTED_PROXIES"
+	remediationEnabled         = "JC_REMEDIATION_ENABLED"
+	remediationMaxRestarts     = "JC_REMEDIATION_MAX_RESTARTS"
+	remediationWebhookURL      = "JC_REMEDIATION_WEBHOOK_URL"
+	oomKillThreshold           = "JC_OOM_KILL_THRESHOLD"
+	oomKillWindow              = "JC_OOM_KILL_WINDOW"
+	oomLimitIncrease           = "JC_OOM_LIMIT_INCREASE"
+	oomMaxMemoryLimit          = "JC_OOM_MAX_MEMORY_LIMIT"
+	watchIdlerConfigs          = "JC_WATCH_IDLER_CONFIGS"
+	resetGracePeriod           = "JC_RESET_GRACE_PERIOD"
+	resetTimeout               = "JC_RESET_TIMEOUT"
+	reservationTTL             = "JC_RESERVATION_TTL"
+	mutationLock               = "JC_MUTATION_LOCK"
+	mutationLockTimeout        = "JC_MUTATION_LOCK_TIMEOUT"
+	dlqMaxRetries              = "JC_DLQ_MAX_RETRIES"
+	dlqSize                    = "JC_DLQ_SIZE"
+	prometheusURL              = "JC_PROMETHEUS_URL"
+	activityQuery              = "JC_PROMETHEUS_ACTIVITY_QUERY"
+	activityThreshold          = "JC_PROMETHEUS_ACTIVITY_THRESHOLD"
+	contentRepositoryIdleAfter = "JC_CONTENT_REPOSITORY_IDLE_AFTER"
+	contentRepositoryQuery     = "JC_PROMETHEUS_CONTENT_REPOSITORY_QUERY"
+	adaptiveIdling             = "JC_ADAPTIVE_IDLING"
+	pressureIdleAfter          = "JC_PRESSURE_IDLE_AFTER"
+	pressureRelaxAfter         = "JC_PRESSURE_RELAX_AFTER"
+	pressureQuery              = "JC_PROMETHEUS_PRESSURE_QUERY"
+	pressureThreshold          = "JC_PROMETHEUS_PRESSURE_THRESHOLD"
+	footprintInterval          = "JC_FOOTPRINT_INTERVAL"
+	footprintCPUQuery          = "JC_FOOTPRINT_CPU_QUERY"
+	footprintMemoryQuery       = "JC_FOOTPRINT_MEMORY_QUERY"
+	notifyWebhookURL           = "JC_NOTIFY_WEBHOOK_URL"
+	notifyFormat               = "JC_NOTIFY_FORMAT"
+	notifyEvents               = "JC_NOTIFY_EVENTS"
+	notifyCapacitySpike        = "JC_NOTIFY_CAPACITY_SPIKE"
+	digestSchedule             = "JC_DIGEST_SCHEDULE"
+	digestTop                  = "JC_DIGEST_TOP"
+	digestWebhookURL           = "JC_DIGEST_WEBHOOK_URL"
+	digestEmailTo              = "JC_DIGEST_EMAIL_TO"
+	digestEmailFrom            = "JC_DIGEST_EMAIL_FROM"
+	digestSMTPAddress          = "JC_DIGEST_SMTP_ADDRESS"
+	digestSMTPUsername         = "JC_DIGEST_SMTP_USERNAME"
+	digestSMTPPassword         = "JC_DIGEST_SMTP_PASSWORD"
+	buildLabelSelector         = "JC_BUILD_LABEL_SELECTOR"
+	buildFieldSelector         = "JC_BUILD_FIELD_SELECTOR"
+	dcLabelSelector            = "JC_DC_LABEL_SELECTOR"
+	dcFieldSelector            = "JC_DC_FIELD_SELECTOR"
+	podLabelSelector           = "JC_POD_LABEL_SELECTOR"
+	podFieldSelector           = "JC_POD_FIELD_SELECTOR"
+
+	defaultTenantBackend              = "fabric8"
+	defaultTenantUserLabel            = "idler.fabric8.io/user-id"
+	defaultTenantMaxPages             = 20
+	defaultCapacityCacheTTL           = 30
+	defaultCapacityRetryAfter         = 120
+	defaultClusterTokenTTL            = 30
+	defaultTokenExpiryWarning         = 10
+	defaultToggleProvider             = "unleash"
+	defaultLaunchDarklyURL            = "https://clientsdk.launchdarkly.com"
+	defaultNotifyFormat               = "json"
+	defaultNotifyCapacitySpike        = 10
+	defaultActivityQuery              = `(sum(rate(http_requests_count{namespace="{{namespace}}"}[5m])) or vector(0)) + (sum(default_jenkins_executors_busy{namespace="{{namespace}}"}) or vector(0))`
+	defaultPressureIdleAfter          = 15
+	defaultPressureRelaxAfter         = 10
+	defaultPressureThreshold          = 0.9
+	defaultIdleLongBuild              = 3
+	defaultIdleAfter                  = 45
+	defaultMaxRetries                 = 10
+	defaultMaxRetriesQuietInterval    = 30
+	defaultDriftCheckInterval         = 10
+	defaultCheckInterval              = 15
+	defaultCheckJitter                = 10
+	defaultManualUnIdleGracePeriod    = 180
+	defaultEvictInactiveAfter         = 30
+	defaultWarmUpConcurrency          = 10
+	defaultMaxIdlesPerMinute          = 60
+	defaultJenkinsHealthPath          = "/login"
+	defaultProfile                    = "default"
+	defaultLogLevel                   = "info"
+	defaultLogFormat                  = "json"
+	defaultAccessLogSampleRate        = 1.0
+	defaultMaxRequestBodyBytes        = 64 * 1024
+	defaultAPIAddress                 = ":8080"
+	defaultAdminAPIAddress            = ":8081"
+	defaultHTTPReadTimeout            = 15
+	defaultHTTPWriteTimeout           = 60
+	defaultHTTPIdleTimeout            = 120
+	defaultHTTPMaxHeaderBytes         = 64 * 1024
+	defaultHTTPMaxConnections         = 512
+	defaultRemediationMaxRestarts     = 5
+	defaultResetGracePeriod           = 30
+	defaultResetTimeout               = 45
+	defaultReservationTTL             = 300
+	defaultMutationLockTimeout        = 30
+	defaultDLQMaxRetries              = 5
+	defaultDLQSize                    = 1000
+	defaultHoldStageMax               = 24
+	defaultRolloutHold                = 30
+	defaultRouteTemplate              = "jenkins-{namespace}.{appDomain}"
+	defaultRouteSource                = "template"
+	defaultRouteCheckInterval         = 0
+	defaultRouteCheckTimeout          = 5
+	defaultFootprintInterval          = 0
+	defaultFootprintCPUQuery          = `sum(rate(container_cpu_usage_seconds_total{namespace="{{namespace}}",container!="",container!="POD"}[5m]))`
+	defaultFootprintMemoryQuery       = `sum(container_memory_working_set_bytes{namespace="{{namespace}}",container!="",container!="POD"})`
+	defaultOOMKillThreshold           = 3
+	defaultOOMKillWindow              = 60
+	defaultOOMLimitIncrease           = 50
+	defaultOOMMaxMemoryLimit          = 4096
+	defaultDigestTop                  = 10
+	defaultRateLimit                  = 0
+	defaultRateLimitWindow            = 60
+	defaultQuietDownTimeout           = 0
+	defaultContentRepositoryIdleAfter = 0
+	defaultContentRepositoryQuery     = `sum(increase(haproxy_backend_http_responses_total{exported_namespace="{{namespace}}",route="content-repository"}[{{interval}}])) or vector(0)`
+	defaultDCLabelSelector            = "app=jenkins"
+	defaultPodLabelSelector           = "deploymentconfig=jenkins"
 )
 
+// notifyEventClasses are the classes of events which can be notified about, see the notify package.
+var notifyEventClasses = []string{"unidle-failures", "cluster-drained", "capacity-spike", "token-failure"}
+
 // New creates a configuration reader object using a configurable configuration
 // file path.
 func New(configFilePath string) (Configuration, error) {
+	return NewLayered(configFilePath, nil)
+}
+
+// NewLayered creates a configuration reader object layering the built-in defaults, the defaults of the profile
+// selected by JC_PROFILE, the config file, the environment and the given flags, each overriding the former. The flags
+// map the names of the options, e.g. JC_IDLE_AFTER, to their values.
+func NewLayered(configFilePath string, flags map[string]string) (Configuration, error) {
 	c := Config{
-		v: viper.New(),
+		v:     viper.New(),
+		flags: make(map[string]string, len(flags)),
 	}
 	c.v.AutomaticEnv()
 	c.v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -55,33 +244,155 @@ func New(configFilePath string) (Configuration, error) {
 			return nil, errs.Errorf("Fatal error config file: %s \n", err)
 		}
 	}
+
+	for key, value := range flags {
+		key = strings.ToUpper(key)
+		c.flags[key] = value
+		c.v.Set(key, value)
+	}
+
+	c.applyProfile()
 	return &c, nil
 }
 
 // Config encapsulates the Viper configuration registry which stores the
 // configuration data in-memory.
 type Config struct {
-	v *viper.Viper
+	v           *viper.Viper
+	flags       map[string]string
+	profileKeys map[string]interface{}
 }
 
 func (c *Config) setConfigDefaults() {
 
 	c.v.SetDefault(proxyURL, "")
 	c.v.SetDefault(tenantURL, "")
+	c.v.SetDefault(tenantBackend, defaultTenantBackend)
+	c.v.SetDefault(tenantFile, "")
+	c.v.SetDefault(tenantUserLabel, defaultTenantUserLabel)
+	c.v.SetDefault(tenantMaxPages, defaultTenantMaxPages)
+	c.v.SetDefault(capacityCacheTTL, defaultCapacityCacheTTL)
+	c.v.SetDefault(capacityRetryAfter, defaultCapacityRetryAfter)
+	c.v.SetDefault(toggleProvider, defaultToggleProvider)
 	c.v.SetDefault(toggleURL, "")
+	c.v.SetDefault(toggleBackupPath, "")
+	c.v.SetDefault(toggleBootstrapFile, "")
+	c.v.SetDefault(toggleFile, "")
+	c.v.SetDefault(launchDarklyURL, defaultLaunchDarklyURL)
+	c.v.SetDefault(launchDarklyClientID, "")
 	c.v.SetDefault(authURL, "authur")
 	c.v.SetDefault(serviceAccountID, "")
 	c.v.SetDefault(serviceAccountSecret, "")
 	c.v.SetDefault(authTokenKey, "")
+	c.v.SetDefault(clusterTokenDir, "")
+	c.v.SetDefault(clusterTokenExchange, false)
+	c.v.SetDefault(clusterTokenTTL, defaultClusterTokenTTL)
+	c.v.SetDefault(tokenExpiryWarning, defaultTokenExpiryWarning)
 	c.v.SetDefault(authGrantType, "client_credentials")
 	c.v.SetDefault(idleAfter, defaultIdleAfter)
 	c.v.SetDefault(idleLongBuild, defaultIdleLongBuild)
+	c.v.SetDefault(quietDownTimeout, defaultQuietDownTimeout)
 	c.v.SetDefault(maxRetries, defaultMaxRetries)
 	c.v.SetDefault(maxRetriesQuietInterval, defaultMaxRetriesQuietInterval)
 	c.v.SetDefault(checkInterval, defaultCheckInterval)
+	c.v.SetDefault(clusterCheckIntervals, []string{})
+	c.v.SetDefault(checkJitter, defaultCheckJitter)
+	c.v.SetDefault(driftCheckInterval, defaultDriftCheckInterval)
+	c.v.SetDefault(manualUnIdleGracePeriod, defaultManualUnIdleGracePeriod)
+	c.v.SetDefault(evictInactiveAfter, defaultEvictInactiveAfter)
+	c.v.SetDefault(warmUpConcurrency, defaultWarmUpConcurrency)
+	c.v.SetDefault(namespaceAllowlist, []string{})
+	c.v.SetDefault(namespaceDenylist, []string{})
+	c.v.SetDefault(disabledClusters, []string{})
+	c.v.SetDefault(unidleOnly, false)
+	c.v.SetDefault(unidleOnlyClusters, []string{})
+	c.v.SetDefault(activityNamespaceTypes, []string{})
+	c.v.SetDefault(rolloutNamespaceTypes, []string{"stage", "run"})
+	c.v.SetDefault(rolloutHold, defaultRolloutHold)
+	c.v.SetDefault(jenkinsNamespaceSuffix, namespace.DefaultJenkinsSuffix)
+	c.v.SetDefault(activeBuildPhases, model.ActivePhases())
+	c.v.SetDefault(holdStages, []string{"approve*", "promote*", "rollout*", "deploy*"})
+	c.v.SetDefault(holdStageMax, defaultHoldStageMax)
+	c.v.SetDefault(maxIdlesPerMinute, defaultMaxIdlesPerMinute)
+	c.v.SetDefault(jenkinsHealthProbe, true)
+	c.v.SetDefault(jenkinsHealthPath, defaultJenkinsHealthPath)
+	c.v.SetDefault(routeTemplate, defaultRouteTemplate)
+	c.v.SetDefault(routeSource, defaultRouteSource)
+	c.v.SetDefault(routeCheckInterval, defaultRouteCheckInterval)
+	c.v.SetDefault(routeCheckTimeout, defaultRouteCheckTimeout)
 
 	c.v.SetDefault(debugMode, false)
 	c.v.SetDefault(fixedUuids, []string{})
+	c.v.SetDefault(profile, defaultProfile)
+	c.v.SetDefault(logLevel, defaultLogLevel)
+	c.v.SetDefault(logFormat, defaultLogFormat)
+	c.v.SetDefault(logComponentLevels, []string{})
+	c.v.SetDefault(accessLogSampleRate, defaultAccessLogSampleRate)
+	c.v.SetDefault(accessLogMetricsOnly, false)
+	c.v.SetDefault(maxRequestBodyBytes, defaultMaxRequestBodyBytes)
+	c.v.SetDefault(corsAllowedOrigins, []string{})
+	c.v.SetDefault(corsAllowedMethods, []string{"GET", "POST", "PUT"})
+	c.v.SetDefault(corsAllowedHeaders, []string{"Content-Type", "Authorization"})
+	c.v.SetDefault(apiAddress, defaultAPIAddress)
+	c.v.SetDefault(apiToken, "")
+	c.v.SetDefault(apiScopesFile, "")
+	c.v.SetDefault(adminAPIAddress, defaultAdminAPIAddress)
+	c.v.SetDefault(adminAPIToken, "")
+	c.v.SetDefault(grpcAddress, "")
+	c.v.SetDefault(httpReadTimeout, defaultHTTPReadTimeout)
+	c.v.SetDefault(httpWriteTimeout, defaultHTTPWriteTimeout)
+	c.v.SetDefault(httpIdleTimeout, defaultHTTPIdleTimeout)
+	c.v.SetDefault(httpMaxHeaderBytes, defaultHTTPMaxHeaderBytes)
+	c.v.SetDefault(httpMaxConnections, defaultHTTPMaxConnections)
+	c.v.SetDefault(rateLimit, defaultRateLimit)
+	c.v.SetDefault(rateLimitWindow, defaultRateLimitWindow)
+	c.v.SetDefault(trustedProxies, []string{})
+	c.v.SetDefault(remediationEnabled, false)
+	c.v.SetDefault(remediationMaxRestarts, defaultRemediationMaxRestarts)
+	c.v.SetDefault(remediationWebhookURL, "")
+	c.v.SetDefault(oomKillThreshold, defaultOOMKillThreshold)
+	c.v.SetDefault(oomKillWindow, defaultOOMKillWindow)
+	c.v.SetDefault(oomLimitIncrease, defaultOOMLimitIncrease)
+	c.v.SetDefault(oomMaxMemoryLimit, defaultOOMMaxMemoryLimit)
+	c.v.SetDefault(watchIdlerConfigs, false)
+	c.v.SetDefault(resetGracePeriod, defaultResetGracePeriod)
+	c.v.SetDefault(resetTimeout, defaultResetTimeout)
+	c.v.SetDefault(reservationTTL, defaultReservationTTL)
+	c.v.SetDefault(mutationLock, false)
+	c.v.SetDefault(mutationLockTimeout, defaultMutationLockTimeout)
+	c.v.SetDefault(dlqMaxRetries, defaultDLQMaxRetries)
+	c.v.SetDefault(dlqSize, defaultDLQSize)
+	c.v.SetDefault(prometheusURL, "")
+	c.v.SetDefault(activityQuery, defaultActivityQuery)
+	c.v.SetDefault(activityThreshold, 0.0)
+	c.v.SetDefault(contentRepositoryIdleAfter, defaultContentRepositoryIdleAfter)
+	c.v.SetDefault(contentRepositoryQuery, defaultContentRepositoryQuery)
+	c.v.SetDefault(adaptiveIdling, false)
+	c.v.SetDefault(pressureIdleAfter, defaultPressureIdleAfter)
+	c.v.SetDefault(pressureRelaxAfter, defaultPressureRelaxAfter)
+	c.v.SetDefault(pressureQuery, "")
+	c.v.SetDefault(pressureThreshold, defaultPressureThreshold)
+	c.v.SetDefault(footprintInterval, defaultFootprintInterval)
+	c.v.SetDefault(footprintCPUQuery, defaultFootprintCPUQuery)
+	c.v.SetDefault(footprintMemoryQuery, defaultFootprintMemoryQuery)
+	c.v.SetDefault(notifyWebhookURL, "")
+	c.v.SetDefault(notifyFormat, defaultNotifyFormat)
+	c.v.SetDefault(notifyEvents, notifyEventClasses)
+	c.v.SetDefault(notifyCapacitySpike, defaultNotifyCapacitySpike)
+	c.v.SetDefault(digestSchedule, "")
+	c.v.SetDefault(digestTop, defaultDigestTop)
+	c.v.SetDefault(digestWebhookURL, "")
+	c.v.SetDefault(digestEmailTo, []string{})
+	c.v.SetDefault(digestEmailFrom, "jenkins-idler@localhost")
+	c.v.SetDefault(digestSMTPAddress, "localhost:25")
+	c.v.SetDefault(digestSMTPUsername, "")
+	c.v.SetDefault(digestSMTPPassword, "")
+	c.v.SetDefault(buildLabelSelector, "")
+	c.v.SetDefault(buildFieldSelector, "")
+	c.v.SetDefault(dcLabelSelector, defaultDCLabelSelector)
+	c.v.SetDefault(dcFieldSelector, "")
+	c.v.SetDefault(podLabelSelector, defaultPodLabelSelector)
+	c.v.SetDefault(podFieldSelector, "")
 }
 
 // GetDebugMode returns `true` if development related features (as set via default, config file, or environment variable),
@@ -100,11 +411,78 @@ func (c *Config) GetTenantURL() string {
 	return c.v.GetString(tenantURL)
 }
 
+// GetTenantBackend returns the source of the tenant information, one of "fabric8" (the fabric8-tenant service),
+// "file" (the static file returned by GetTenantFile) or "kubernetes" (the namespace label returned by GetTenantUserLabel).
+func (c *Config) GetTenantBackend() string {
+	return c.v.GetString(tenantBackend)
+}
+
+// GetTenantFile returns the path of the YAML file listing the tenants for the "file" tenant backend.
+func (c *Config) GetTenantFile() string {
+	return c.v.GetString(tenantFile)
+}
+
+// GetTenantUserLabel returns the namespace label carrying the user ID for the "kubernetes" tenant backend.
+func (c *Config) GetTenantUserLabel() string {
+	return c.v.GetString(tenantUserLabel)
+}
+
+// GetTenantMaxPages returns the maximum number of result pages followed per lookup at the fabric8-tenant service.
+// 0 disables the limit.
+func (c *Config) GetTenantMaxPages() int {
+	return c.v.GetInt(tenantMaxPages)
+}
+
+// GetCapacityCacheTTL returns the number of seconds the capacity of a cluster is cached before it gets refreshed in
+// the background. 0 disables the cache.
+func (c *Config) GetCapacityCacheTTL() int {
+	return c.v.GetInt(capacityCacheTTL)
+}
+
+// GetCapacityRetryAfter returns the number of seconds clients are asked to wait before retrying an un-idle refused
+// due to the cluster being at capacity. 0 omits the Retry-After header.
+func (c *Config) GetCapacityRetryAfter() int {
+	return c.v.GetInt(capacityRetryAfter)
+}
+
+// GetToggleProvider returns the name of the feature toggle provider, i.e. unleash, configmap, launchdarkly or static.
+func (c *Config) GetToggleProvider() string {
+	return c.v.GetString(toggleProvider)
+}
+
 // GetToggleURL returns the Toggle Service URL as set via default, config file, or environment variable.
 func (c *Config) GetToggleURL() string {
 	return c.v.GetString(toggleURL)
 }
 
+// GetToggleBackupPath returns the directory the toggle definitions fetched from Unleash are backed up to. If empty,
+// the temporary directory is used.
+func (c *Config) GetToggleBackupPath() string {
+	return c.v.GetString(toggleBackupPath)
+}
+
+// GetToggleBootstrapFile returns the path of the file the toggle definitions are loaded from at startup, unless
+// they were backed up before.
+func (c *Config) GetToggleBootstrapFile() string {
+	return c.v.GetString(toggleBootstrapFile)
+}
+
+// GetToggleFile returns the path of the YAML file defining the features of the configmap toggle provider, usually
+// mounted from a ConfigMap.
+func (c *Config) GetToggleFile() string {
+	return c.v.GetString(toggleFile)
+}
+
+// GetLaunchDarklyURL returns the base URL of the LaunchDarkly client-side SDK endpoints, e.g. of a relay proxy.
+func (c *Config) GetLaunchDarklyURL() string {
+	return c.v.GetString(launchDarklyURL)
+}
+
+// GetLaunchDarklyClientID returns the client-side ID of the LaunchDarkly environment.
+func (c *Config) GetLaunchDarklyClientID() string {
+	return c.v.GetString(launchDarklyClientID)
+}
+
 // GetAuthURL returns the Auth API URL as set via default, config file, or environment variable
 func (c *Config) GetAuthURL() string {
 	return c.v.GetString(authURL)
@@ -125,6 +503,30 @@ func (c *Config) GetAuthTokenKey() string {
 	return c.v.GetString(authTokenKey)
 }
 
+// GetClusterTokenDir returns the directory of the per-cluster token files, e.g. a projected service account token
+// volume. The files are named after the hosts of the cluster API URLs. If empty, the cluster tokens are obtained via
+// the Auth service.
+func (c *Config) GetClusterTokenDir() string {
+	return c.v.GetString(clusterTokenDir)
+}
+
+// GetClusterTokenExchange returns whether the cluster tokens are exchanged with the Auth service on demand rather
+// than obtained once at startup.
+func (c *Config) GetClusterTokenExchange() bool {
+	return c.v.GetBool(clusterTokenExchange)
+}
+
+// GetClusterTokenTTL returns the number of minutes an exchanged cluster token is cached before being exchanged anew.
+func (c *Config) GetClusterTokenTTL() int {
+	return c.v.GetInt(clusterTokenTTL)
+}
+
+// GetTokenExpiryWarning returns the number of minutes before the expiry of a cluster token at which warnings are
+// logged.
+func (c *Config) GetTokenExpiryWarning() int {
+	return c.v.GetInt(tokenExpiryWarning)
+}
+
 // GetAuthGrantType returns the fabric8-auth Grant type used while retrieving
 // user account token
 func (c *Config) GetAuthGrantType() string {
@@ -136,16 +538,161 @@ func (c *Config) GetIdleAfter() int {
 	return c.v.GetInt(idleAfter)
 }
 
+// GetManualUnIdleGracePeriod returns the number of minutes Jenkins is not idled after it got un-idled manually, e.g.
+// scaled up via `oc scale`, as set via default, config file, or environment variable.
+func (c *Config) GetManualUnIdleGracePeriod() int {
+	return c.v.GetInt(manualUnIdleGracePeriod)
+}
+
+// GetEvictInactiveAfter returns the number of days after which the user-idler of a namespace without any activity
+// is evicted, as set via default, config file, or environment variable. 0 disables the eviction.
+func (c *Config) GetEvictInactiveAfter() int {
+	return c.v.GetInt(evictInactiveAfter)
+}
+
+// GetWarmUpConcurrency returns the number of Jenkins namespaces of a cluster for which user-idlers are created
+// concurrently during the warm-up at startup.
+func (c *Config) GetWarmUpConcurrency() int {
+	return c.v.GetInt(warmUpConcurrency)
+}
+
+// GetNamespaceAllowlist returns the patterns of the namespaces managed by the Idler. The patterns are whitespace
+// separated in the environment variable JC_NAMESPACE_ALLOWLIST. If no pattern is configured, all namespaces
+// not matching the denylist are managed.
+func (c *Config) GetNamespaceAllowlist() []string {
+	return c.v.GetStringSlice(namespaceAllowlist)
+}
+
+// GetNamespaceDenylist returns the patterns of the namespaces ignored by the Idler. The patterns are whitespace
+// separated in the environment variable JC_NAMESPACE_DENYLIST.
+func (c *Config) GetNamespaceDenylist() []string {
+	return c.v.GetStringSlice(namespaceDenylist)
+}
+
+// GetDisabledClusters returns the API URLs of the clusters for which idling is disabled on startup. The URLs are
+// whitespace separated in the environment variable JC_DISABLED_CLUSTERS.
+func (c *Config) GetDisabledClusters() []string {
+	return c.v.GetStringSlice(disabledClusters)
+}
+
+// GetMaxIdlesPerMinute returns the maximum number of idle operations per minute and cluster, as set via default,
+// config file, or environment variable. Exceeding idle operations are queued. 0 disables the limit.
+func (c *Config) GetMaxIdlesPerMinute() int {
+	return c.v.GetInt(maxIdlesPerMinute)
+}
+
+// GetJenkinsHealthProbe returns `true` if a running Jenkins is only considered running once it actually serves
+// requests, as checked by probing its route. Otherwise a running pod is considered serving.
+func (c *Config) GetJenkinsHealthProbe() bool {
+	return c.v.GetBool(jenkinsHealthProbe)
+}
+
+// GetJenkinsHealthPath returns the path probed on the Jenkins route to check whether a running Jenkins is actually
+// serving requests, as set via default, config file, or environment variable.
+func (c *Config) GetJenkinsHealthPath() string {
+	return c.v.GetString(jenkinsHealthPath)
+}
+
+// GetRouteTemplate returns the template of the host names of the Jenkins routes, {namespace} standing for the Jenkins
+// namespace, {user} for the name of the user and {appDomain} for the application domain of the cluster.
+func (c *Config) GetRouteTemplate() string {
+	return c.v.GetString(routeTemplate)
+}
+
+// GetRouteSource returns how the Jenkins routes are determined: template derives them from the route template,
+// route reads the actual Route objects of the Jenkins namespaces.
+func (c *Config) GetRouteSource() string {
+	return c.v.GetString(routeSource)
+}
+
+// GetRouteCheckInterval returns the number of minutes between the checks whether the Jenkins routes of the clusters
+// resolve and serve TLS correctly. 0 disables the checks.
+func (c *Config) GetRouteCheckInterval() int {
+	return c.v.GetInt(routeCheckInterval)
+}
+
+// GetRouteCheckTimeout returns the number of seconds within which a Jenkins route needs to resolve and complete the
+// TLS handshake to be considered reachable.
+func (c *Config) GetRouteCheckTimeout() int {
+	return c.v.GetInt(routeCheckTimeout)
+}
+
+// GetUnidleOnly returns `true` if Jenkins instances are un-idled on demand but never idled, on all clusters.
+func (c *Config) GetUnidleOnly() bool {
+	return c.v.GetBool(unidleOnly)
+}
+
+// GetUnidleOnlyClusters returns the API URLs of the clusters on which Jenkins instances are un-idled on demand but
+// never idled. The URLs are whitespace separated in the environment variable JC_UNIDLE_ONLY_CLUSTERS.
+func (c *Config) GetUnidleOnlyClusters() []string {
+	return c.v.GetStringSlice(unidleOnlyClusters)
+}
+
+// GetActivityNamespaceTypes returns the types of the user namespaces, e.g. che, in which running pods keep Jenkins
+// from being idled. The types are whitespace separated in the environment variable JC_ACTIVITY_NAMESPACE_TYPES.
+func (c *Config) GetActivityNamespaceTypes() []string {
+	return c.v.GetStringSlice(activityNamespaceTypes)
+}
+
+// GetRolloutNamespaceTypes returns the types of the user namespaces, e.g. stage and run, whose deployment config
+// rollouts following a completed build keep Jenkins from being idled.
+func (c *Config) GetRolloutNamespaceTypes() []string {
+	return c.v.GetStringSlice(rolloutNamespaceTypes)
+}
+
+// GetRolloutHold returns the number of minutes after the completion of a build during which rollouts in the user
+// namespaces keep Jenkins from being idled. 0 disables the check.
+func (c *Config) GetRolloutHold() int {
+	return c.v.GetInt(rolloutHold)
+}
+
+// GetJenkinsNamespaceSuffix returns the suffix appended to the name of a user to form the name of its Jenkins
+// namespace, -jenkins by default.
+func (c *Config) GetJenkinsNamespaceSuffix() string {
+	return c.v.GetString(jenkinsNamespaceSuffix)
+}
+
+// GetActiveBuildPhases returns the build phases in which a build counts as activity, keeping Jenkins from being
+// idled. They are whitespace separated in the environment variable JC_ACTIVE_BUILD_PHASES, New, Pending and Running
+// by default.
+func (c *Config) GetActiveBuildPhases() []string {
+	return c.v.GetStringSlice(activeBuildPhases)
+}
+
+// GetHoldStages returns the case-insensitive shell patterns of the pipeline stages, e.g. `approve*`, during which
+// Jenkins is not idled.
+func (c *Config) GetHoldStages() []string {
+	return c.v.GetStringSlice(holdStages)
+}
+
+// GetHoldStageMax returns the number of hours after the start of a build after which a pipeline stage no longer keeps
+// Jenkins from being idled. 0 for no limit.
+func (c *Config) GetHoldStageMax() int {
+	return c.v.GetInt(holdStageMax)
+}
+
 // GetIdleLongBuild returns the number of minutes before Jenkins is idled as set via default, config file, or environment variable.
 func (c *Config) GetIdleLongBuild() int {
 	return c.v.GetInt(idleLongBuild)
 }
 
+// GetQuietDownTimeout returns the number of minutes Jenkins is given to finish its running jobs in quiet-down mode
+// before it gets idled. 0 idles Jenkins right away, killing the running jobs.
+func (c *Config) GetQuietDownTimeout() int {
+	return c.v.GetInt(quietDownTimeout)
+}
+
 // GetMaxRetries returns the maximum number of retries to idle resp. un-idle the Jenkins service.
 func (c *Config) GetMaxRetries() int {
 	return c.v.GetInt(maxRetries)
 }
 
+// GetDriftCheckInterval returns the number of minutes between the checks whether the state of Jenkins believed by a
+// user idler diverged from the actual one, e.g. since Jenkins got idled via `oc idle`. 0 disables the checks.
+func (c *Config) GetDriftCheckInterval() int {
+	return c.v.GetInt(driftCheckInterval)
+}
+
 // GetMaxRetriesQuietInterval returns the number of minutes no retry occurs after the maximum retry count is reached.
 func (c *Config) GetMaxRetriesQuietInterval() int {
 	return c.v.GetInt(maxRetriesQuietInterval)
@@ -156,6 +703,41 @@ func (c *Config) GetCheckInterval() int {
 	return c.v.GetInt(checkInterval)
 }
 
+// GetClusterCheckIntervals returns the number of minutes between the regular idle checks keyed against the API URL
+// of the clusters deviating from GetCheckInterval. The intervals are whitespace separated <api url>=<minutes> pairs
+// in the environment variable JC_CLUSTER_CHECK_INTERVALS. Malformed pairs are ignored.
+func (c *Config) GetClusterCheckIntervals() map[string]int {
+	intervals := make(map[string]int)
+	for _, pair := range c.v.GetStringSlice(clusterCheckIntervals) {
+		cluster, minutes, ok := parseClusterInterval(pair)
+		if !ok {
+			continue
+		}
+		intervals[cluster] = minutes
+	}
+	return intervals
+}
+
+// parseClusterInterval parses an <api url>=<minutes> pair with a positive number of minutes.
+func parseClusterInterval(pair string) (string, int, bool) {
+	i := strings.LastIndex(pair, "=")
+	if i <= 0 {
+		return "", 0, false
+	}
+	minutes, err := strconv.Atoi(pair[i+1:])
+	if err != nil || minutes <= 0 {
+		return "", 0, false
+	}
+	return util.EnsureSuffix(pair[:i], "/"), minutes, true
+}
+
+// GetCheckJitter returns the percentage by which the check interval of each user idler is randomly shifted, as set
+// via default, config file, or environment variable, so that the checks of the user idlers do not align. 0 disables
+// the jitter.
+func (c *Config) GetCheckJitter() int {
+	return c.v.GetInt(checkJitter)
+}
+
 // GetFixedUuids returns a slice of fixed user uuids.
 // The uuids are whitespace separated in the environment variable.
 // JC_FIXED_UUIDS.
@@ -163,19 +745,408 @@ func (c *Config) GetFixedUuids() []string {
 	return c.v.GetStringSlice(fixedUuids)
 }
 
+// GetProfile returns the name of the configuration profile the Idler is running with.
+func (c *Config) GetProfile() string {
+	return c.v.GetString(profile)
+}
+
+// GetLogLevel returns the global log level.
+func (c *Config) GetLogLevel() string {
+	return c.v.GetString(logLevel)
+}
+
+// GetLogFormat returns the log output format, either 'json' or 'text'.
+func (c *Config) GetLogFormat() string {
+	return c.v.GetString(logFormat)
+}
+
+// GetLogComponentLevels returns the log level overrides keyed against the component name.
+// The overrides are whitespace separated <component>=<level> pairs in the environment variable
+// JC_LOG_COMPONENT_LEVELS.
+func (c *Config) GetLogComponentLevels() map[string]string {
+	levels := make(map[string]string)
+	for _, pair := range c.v.GetStringSlice(logComponentLevels) {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		levels[parts[0]] = parts[1]
+	}
+	return levels
+}
+
+// GetAccessLogSampleRate returns the fraction (0.0 - 1.0) of successful API requests which get access logged.
+func (c *Config) GetAccessLogSampleRate() float64 {
+	return c.v.GetFloat64(accessLogSampleRate)
+}
+
+// GetAccessLogMetricsOnly returns `true` if API requests should only be recorded as metrics instead of access log entries.
+func (c *Config) GetAccessLogMetricsOnly() bool {
+	return c.v.GetBool(accessLogMetricsOnly)
+}
+
+// GetMaxRequestBodyBytes returns the maximum size in bytes of an API request body.
+func (c *Config) GetMaxRequestBodyBytes() int {
+	return c.v.GetInt(maxRequestBodyBytes)
+}
+
+// GetCORSAllowedOrigins returns the origins allowed to make cross-origin requests against the API.
+// The origins are whitespace separated in the environment variable JC_CORS_ALLOWED_ORIGINS, '*' allows
+// all origins. If no origin is configured, CORS is disabled.
+func (c *Config) GetCORSAllowedOrigins() []string {
+	return c.v.GetStringSlice(corsAllowedOrigins)
+}
+
+// GetCORSAllowedMethods returns the HTTP methods allowed for cross-origin requests.
+func (c *Config) GetCORSAllowedMethods() []string {
+	return c.v.GetStringSlice(corsAllowedMethods)
+}
+
+// GetCORSAllowedHeaders returns the request headers allowed for cross-origin requests.
+func (c *Config) GetCORSAllowedHeaders() []string {
+	return c.v.GetStringSlice(corsAllowedHeaders)
+}
+
+// GetAPIAddress returns the address, [host]:port, the public API listens on.
+func (c *Config) GetAPIAddress() string {
+	return c.v.GetString(apiAddress)
+}
+
+// GetAPIToken returns the bearer token required to call the public API. If empty, no authentication is required.
+func (c *Config) GetAPIToken() string {
+	return c.v.GetString(apiToken)
+}
+
+// GetAPIScopesFile returns the path of the YAML file defining the scoped tokens of the public API, which restrict
+// their callers to a set of namespaces resp. clusters. If empty, no scoped tokens are accepted.
+func (c *Config) GetAPIScopesFile() string {
+	return c.v.GetString(apiScopesFile)
+}
+
+// GetAdminAPIAddress returns the address, [host]:port, the admin API listens on.
+func (c *Config) GetAdminAPIAddress() string {
+	return c.v.GetString(adminAPIAddress)
+}
+
+// GetAdminAPIToken returns the bearer token required to call the admin API. If empty, no authentication is required.
+func (c *Config) GetAdminAPIToken() string {
+	return c.v.GetString(adminAPIToken)
+}
+
+// GetGRPCAddress returns the address, [host]:port, the gRPC API listens on. If empty, the gRPC API is disabled.
+func (c *Config) GetGRPCAddress() string {
+	return c.v.GetString(grpcAddress)
+}
+
+// GetHTTPReadTimeout returns the number of seconds the API server waits for a complete request, including its body.
+func (c *Config) GetHTTPReadTimeout() int {
+	return c.v.GetInt(httpReadTimeout)
+}
+
+// GetHTTPWriteTimeout returns the number of seconds after reading the request headers within which the API server
+// needs to have written the response.
+func (c *Config) GetHTTPWriteTimeout() int {
+	return c.v.GetInt(httpWriteTimeout)
+}
+
+// GetHTTPIdleTimeout returns the number of seconds the API server keeps an idle keep-alive connection open.
+func (c *Config) GetHTTPIdleTimeout() int {
+	return c.v.GetInt(httpIdleTimeout)
+}
+
+// GetHTTPMaxHeaderBytes returns the maximum size in bytes of the request headers accepted by the API server.
+func (c *Config) GetHTTPMaxHeaderBytes() int {
+	return c.v.GetInt(httpMaxHeaderBytes)
+}
+
+// GetHTTPMaxConnections returns the maximum number of concurrent connections per API listener. 0 means unlimited.
+func (c *Config) GetHTTPMaxConnections() int {
+	return c.v.GetInt(httpMaxConnections)
+}
+
+// GetRateLimit returns the number of requests each caller may make to the public API per rate limit window. 0
+// disables rate limiting.
+func (c *Config) GetRateLimit() int {
+	return c.v.GetInt(rateLimit)
+}
+
+// GetRateLimitWindow returns the number of seconds of the window the requests of each caller are counted in.
+func (c *Config) GetRateLimitWindow() int {
+	return c.v.GetInt(rateLimitWindow)
+}
+
+// GetTrustedProxies returns the addresses resp. CIDR ranges of the proxies in front of the public API, whose
+// X-Forwarded-For header is trusted in order to identify the callers of the public API. The proxies are whitespace
+// separated in the environment variable JC_TRUSTED_PROXIES.
+func (c *Config) GetTrustedProxies() []string {
+	return c.v.GetStringSlice(trustedProxies)
+}
+
+// GetRemediationEnabled returns `true` if crash-looping Jenkins pods should be reset automatically.
+func (c *Config) GetRemediationEnabled() bool {
+	return c.v.GetBool(remediationEnabled)
+}
+
+// GetRemediationMaxRestarts returns the number of restarts of a crash-looping Jenkins pod after which it gets reset.
+func (c *Config) GetRemediationMaxRestarts() int {
+	return c.v.GetInt(remediationMaxRestarts)
+}
+
+// GetRemediationWebhookURL returns the URL notified about remediation actions. If empty, no notification is sent.
+func (c *Config) GetRemediationWebhookURL() string {
+	return c.v.GetString(remediationWebhookURL)
+}
+
+// GetOOMKillThreshold returns the number of times Jenkins needs to get killed for running out of memory within the
+// OOM kill window for an increase of its memory limit to be recommended. 0 disables the recommendations.
+func (c *Config) GetOOMKillThreshold() int {
+	return c.v.GetInt(oomKillThreshold)
+}
+
+// GetOOMKillWindow returns the number of minutes within which the OOM kills of Jenkins are counted.
+func (c *Config) GetOOMKillWindow() int {
+	return c.v.GetInt(oomKillWindow)
+}
+
+// GetOOMLimitIncrease returns the percentage by which the memory limit of Jenkins is recommended to be increased after
+// repeated OOM kills.
+func (c *Config) GetOOMLimitIncrease() int {
+	return c.v.GetInt(oomLimitIncrease)
+}
+
+// GetOOMMaxMemoryLimit returns the maximum memory limit in MiB recommended for Jenkins. Increases are bounded by it.
+func (c *Config) GetOOMMaxMemoryLimit() int {
+	return c.v.GetInt(oomMaxMemoryLimit)
+}
+
+// GetWatchIdlerConfigs returns whether the JenkinsIdlerConfig custom resources in the Jenkins namespaces are watched
+// and the overrides they specify applied. It requires the custom resource definition to be installed on the clusters.
+func (c *Config) GetWatchIdlerConfigs() bool {
+	return c.v.GetBool(watchIdlerConfigs)
+}
+
+// GetResetGracePeriod returns the number of seconds the containers of a reset pod get to terminate gracefully.
+func (c *Config) GetResetGracePeriod() int {
+	return c.v.GetInt(resetGracePeriod)
+}
+
+// GetResetTimeout returns the number of seconds a reset requested via the API waits for the replacement pods to get
+// scheduled by default. 0 returns right after deleting the pods.
+func (c *Config) GetResetTimeout() int {
+	return c.v.GetInt(resetTimeout)
+}
+
+// GetReservationTTL returns the number of seconds an un-idle reservation keeps Jenkins of its namespace from being
+// idled, unless it gets released earlier.
+func (c *Config) GetReservationTTL() int {
+	return c.v.GetInt(reservationTTL)
+}
+
+// GetMutationLock returns `true` if idling, un-idling and resetting Jenkins is serialized per namespace via a Lease
+// in the namespace, so that several Idler replicas and API clients do not interleave their mutations.
+func (c *Config) GetMutationLock() bool {
+	return c.v.GetBool(mutationLock)
+}
+
+// GetMutationLockTimeout returns the number of seconds a mutation waits for the lock of its namespace before it
+// gives up.
+func (c *Config) GetMutationLockTimeout() int {
+	return c.v.GetInt(mutationLockTimeout)
+}
+
+// GetDLQMaxRetries returns the number of times an event whose handling failed is retried with backoff before it is
+// given up. 0 disables the retries.
+func (c *Config) GetDLQMaxRetries() int {
+	return c.v.GetInt(dlqMaxRetries)
+}
+
+// GetDLQSize returns the maximum number of failed events kept in the dead-letter queue.
+func (c *Config) GetDLQSize() int {
+	return c.v.GetInt(dlqSize)
+}
+
+// GetPrometheusURL returns the URL of the Prometheus instance queried for the activity of Jenkins. If empty, no
+// Prometheus is queried.
+func (c *Config) GetPrometheusURL() string {
+	return c.v.GetString(prometheusURL)
+}
+
+// GetActivityQuery returns the PromQL query yielding the activity of a Jenkins instance, with {{namespace}} standing
+// for its namespace. By default it adds the HTTP request rate and the number of busy executors.
+func (c *Config) GetActivityQuery() string {
+	return c.v.GetString(activityQuery)
+}
+
+// GetActivityThreshold returns the value of the activity query above which Jenkins is considered active.
+func (c *Config) GetActivityThreshold() float64 {
+	return c.v.GetFloat64(activityThreshold)
+}
+
+// GetContentRepositoryIdleAfter returns the number of minutes without requests after which the content-repository
+// service is idled on its own rather than along with Jenkins. 0 idles it along with Jenkins, if at all.
+func (c *Config) GetContentRepositoryIdleAfter() int {
+	return c.v.GetInt(contentRepositoryIdleAfter)
+}
+
+// GetContentRepositoryQuery returns the PromQL query yielding the requests to the content-repository service, e.g.
+// from the metrics of its route, with {{namespace}} standing for the Jenkins namespace and {{interval}} for the longest
+// time between two checks, which the query should range over.
+func (c *Config) GetContentRepositoryQuery() string {
+	return c.v.GetString(contentRepositoryQuery)
+}
+
+// GetAdaptiveIdling returns true if idle timeouts are shortened while a cluster is under resource pressure.
+func (c *Config) GetAdaptiveIdling() bool {
+	return c.v.GetBool(adaptiveIdling)
+}
+
+// GetPressureIdleAfter returns the number of minutes of inactivity after which Jenkins is idled while its cluster is
+// under resource pressure, unless its regular idle timeout is shorter.
+func (c *Config) GetPressureIdleAfter() int {
+	return c.v.GetInt(pressureIdleAfter)
+}
+
+// GetPressureRelaxAfter returns the number of minutes without pressure signal after which a cluster is no longer
+// considered under pressure.
+func (c *Config) GetPressureRelaxAfter() int {
+	return c.v.GetInt(pressureRelaxAfter)
+}
+
+// GetPressureQuery returns the PromQL query yielding the resource pressure of a cluster, with {{cluster}} standing
+// for its API URL. If empty, the pressure is only signalled by un-idle requests refused due to the cluster capacity.
+func (c *Config) GetPressureQuery() string {
+	return c.v.GetString(pressureQuery)
+}
+
+// GetPressureThreshold returns the value of the pressure query from which on a cluster is considered under pressure.
+func (c *Config) GetPressureThreshold() float64 {
+	return c.v.GetFloat64(pressureThreshold)
+}
+
+// GetFootprintInterval returns the number of minutes between the measurements of the resource usage of the Jenkins
+// instances. 0 disables the measurements.
+func (c *Config) GetFootprintInterval() int {
+	return c.v.GetInt(footprintInterval)
+}
+
+// GetFootprintCPUQuery returns the PromQL query yielding the CPU cores consumed by Jenkins, {{namespace}} standing for
+// its namespace.
+func (c *Config) GetFootprintCPUQuery() string {
+	return c.v.GetString(footprintCPUQuery)
+}
+
+// GetFootprintMemoryQuery returns the PromQL query yielding the bytes of memory consumed by Jenkins, {{namespace}}
+// standing for its namespace.
+func (c *Config) GetFootprintMemoryQuery() string {
+	return c.v.GetString(footprintMemoryQuery)
+}
+
+// GetNotifyWebhookURL returns the URL of the Slack or generic webhook notified about notable events. If empty, no
+// notifications are sent.
+func (c *Config) GetNotifyWebhookURL() string {
+	return c.v.GetString(notifyWebhookURL)
+}
+
+// GetNotifyFormat returns the format of the notifications, either "slack" or "json".
+func (c *Config) GetNotifyFormat() string {
+	return c.v.GetString(notifyFormat)
+}
+
+// GetNotifyEvents returns the classes of the events to notify about. The classes are whitespace separated in the
+// environment variable JC_NOTIFY_EVENTS.
+func (c *Config) GetNotifyEvents() []string {
+	return c.v.GetStringSlice(notifyEvents)
+}
+
+// GetNotifyCapacitySpike returns the number of un-idle requests refused within five minutes due to the capacity of
+// a cluster which is notified about. 0 disables these notifications.
+func (c *Config) GetNotifyCapacitySpike() int {
+	return c.v.GetInt(notifyCapacitySpike)
+}
+
+// GetDigestSchedule returns how often a digest of the activity of the Idler is sent: daily or weekly. If empty, no
+// digest is sent.
+func (c *Config) GetDigestSchedule() string {
+	return c.v.GetString(digestSchedule)
+}
+
+// GetDigestTop returns the number of never idling namespaces listed in a digest.
+func (c *Config) GetDigestTop() int {
+	return c.v.GetInt(digestTop)
+}
+
+// GetDigestWebhookURL returns the URL of the Slack or generic webhook the digests are posted to. If empty, the
+// digests are not posted.
+func (c *Config) GetDigestWebhookURL() string {
+	return c.v.GetString(digestWebhookURL)
+}
+
+// GetDigestEmailTo returns the addresses the digests are emailed to. If empty, the digests are not emailed.
+func (c *Config) GetDigestEmailTo() []string {
+	return c.v.GetStringSlice(digestEmailTo)
+}
+
+// GetDigestEmailFrom returns the sender address of the digest emails.
+func (c *Config) GetDigestEmailFrom() string {
+	return c.v.GetString(digestEmailFrom)
+}
+
+// GetDigestSMTPAddress returns the address, host:port, of the SMTP server the digest emails are sent through.
+func (c *Config) GetDigestSMTPAddress() string {
+	return c.v.GetString(digestSMTPAddress)
+}
+
+// GetDigestSMTPUsername returns the username authenticating the Idler to the SMTP server. If empty, the Idler does
+// not authenticate.
+func (c *Config) GetDigestSMTPUsername() string {
+	return c.v.GetString(digestSMTPUsername)
+}
+
+// GetDigestSMTPPassword returns the password authenticating the Idler to the SMTP server.
+func (c *Config) GetDigestSMTPPassword() string {
+	return c.v.GetString(digestSMTPPassword)
+}
+
+// GetBuildLabelSelector returns the label selector restricting the watched builds. If empty, all builds are watched.
+func (c *Config) GetBuildLabelSelector() string {
+	return c.v.GetString(buildLabelSelector)
+}
+
+// GetBuildFieldSelector returns the field selector restricting the watched builds. If empty, all builds are watched.
+func (c *Config) GetBuildFieldSelector() string {
+	return c.v.GetString(buildFieldSelector)
+}
+
+// GetDCLabelSelector returns the label selector restricting the watched deployment configs.
+func (c *Config) GetDCLabelSelector() string {
+	return c.v.GetString(dcLabelSelector)
+}
+
+// GetDCFieldSelector returns the field selector restricting the watched deployment configs. If empty, all deployment configs are watched.
+func (c *Config) GetDCFieldSelector() string {
+	return c.v.GetString(dcFieldSelector)
+}
+
+// GetPodLabelSelector returns the label selector restricting the watched pods.
+func (c *Config) GetPodLabelSelector() string {
+	return c.v.GetString(podLabelSelector)
+}
+
+// GetPodFieldSelector returns the field selector restricting the watched pods. If empty, all pods are watched.
+func (c *Config) GetPodFieldSelector() string {
+	return c.v.GetString(podFieldSelector)
+}
+
 // String returns string representation of configuration
 func (c *Config) String() string {
 	all := c.v.AllSettings()
-	for k := range all {
-		// don't echo tokens or secret
-		if strings.Contains(k, "TOKEN") ||
-			strings.Contains(k, "token") {
-			all[k] = "***"
-		}
-
-		if strings.Contains(k, "SECRET") ||
-			strings.Contains(k, "secret") {
-			all[k] = "***"
+	for k, v := range all {
+		// don't echo tokens, secrets or webhook URLs embedding them
+		key := strings.ToUpper(k)
+		if secret(key) || strings.Contains(key, "TOKEN") || strings.Contains(key, "SECRET") || strings.Contains(key, "PASSWORD") {
+			all[k] = redact.Mask
+		} else if s, ok := v.(string); ok {
+			all[k] = redact.String(s)
 		}
 	}
 	return fmt.Sprintf("%v", all)
@@ -191,8 +1162,14 @@ func (c *Config) Verify() util.MultiError {
 			continue
 		case tenantURL:
 			continue
+		case tenantBackend:
+			errors.Collect(util.IsOneOf(v, k, "fabric8", "file", "kubernetes"))
 		case toggleURL:
 			continue
+		case toggleProvider:
+			errors.Collect(util.IsOneOf(v, k, "unleash", "configmap", "launchdarkly", "static"))
+		case launchDarklyURL:
+			errors.Collect(util.IsURL(v, k))
 		case authURL:
 			errors.Collect(util.IsURL(v, k))
 		case serviceAccountID:
@@ -201,9 +1178,115 @@ func (c *Config) Verify() util.MultiError {
 			continue
 		case authTokenKey:
 			continue
+		case jenkinsHealthPath, jenkinsNamespaceSuffix:
+			errors.Collect(util.IsNotEmpty(v, k))
 		case authGrantType:
 			errors.Collect(util.IsNotEmpty(v, k))
+		case logFormat:
+			errors.Collect(util.IsOneOf(v, k, "json", "text"))
+		case profile:
+			errors.Collect(util.IsOneOf(v, k, Profiles()...))
+		case apiAddress, adminAPIAddress:
+			errors.Collect(util.IsNotEmpty(v, k))
+		case notifyFormat:
+			errors.Collect(util.IsOneOf(v, k, "slack", "json"))
+		case digestSchedule:
+			if v != "" {
+				errors.Collect(util.IsOneOf(v, k, "daily", "weekly"))
+			}
+		case routeSource:
+			errors.Collect(util.IsOneOf(v, k, "template", "route"))
+		case remediationWebhookURL, prometheusURL, notifyWebhookURL, digestWebhookURL:
+			if v != "" {
+				errors.Collect(util.IsURL(v, k))
+			}
+		case tenantMaxPages, capacityCacheTTL, capacityRetryAfter, notifyCapacitySpike, checkJitter, driftCheckInterval, manualUnIdleGracePeriod, evictInactiveAfter, maxIdlesPerMinute, pressureRelaxAfter, remediationMaxRestarts, resetGracePeriod, resetTimeout, reservationTTL, mutationLockTimeout, dlqMaxRetries, dlqSize, holdStageMax, rolloutHold, routeCheckInterval, routeCheckTimeout, footprintInterval, oomKillThreshold, oomKillWindow, oomLimitIncrease, oomMaxMemoryLimit, digestTop, rateLimit, rateLimitWindow, quietDownTimeout, contentRepositoryIdleAfter, httpReadTimeout, httpWriteTimeout, httpIdleTimeout, httpMaxHeaderBytes, httpMaxConnections, tokenExpiryWarning:
+			errors.Collect(util.IsNotNegative(v, k))
 		}
 	}
+
+	if pattern, ok := namespace.Valid(c.GetNamespaceAllowlist()); !ok {
+		errors.Collect(fmt.Errorf("value for %s contains the malformed pattern %s", namespaceAllowlist, pattern))
+	}
+	if pattern, ok := namespace.Valid(c.GetNamespaceDenylist()); !ok {
+		errors.Collect(fmt.Errorf("value for %s contains the malformed pattern %s", namespaceDenylist, pattern))
+	}
+
+	for _, phase := range c.GetActiveBuildPhases() {
+		if _, ok := model.Phases[phase]; !ok {
+			errors.Collect(fmt.Errorf("value for %s contains the unknown build phase %s", activeBuildPhases, phase))
+		}
+	}
+
+	if _, err := util.ParseNetworks(c.GetTrustedProxies()); err != nil {
+		errors.Collect(fmt.Errorf("value for %s contains a malformed proxy: %s", trustedProxies, err))
+	}
+
+	if pattern, ok := namespace.Valid(c.GetHoldStages()); !ok {
+		errors.Collect(fmt.Errorf("value for %s contains the malformed pattern %s", holdStages, pattern))
+	}
+
+	if template := c.GetRouteTemplate(); !strings.Contains(template, "{namespace}") && !strings.Contains(template, "{user}") {
+		errors.Collect(fmt.Errorf("value for %s needs to contain {namespace} or {user}", routeTemplate))
+	}
+
+	for _, pair := range c.v.GetStringSlice(clusterCheckIntervals) {
+		if _, _, ok := parseClusterInterval(pair); !ok {
+			errors.Collect(fmt.Errorf("value for %s contains the malformed interval %s", clusterCheckIntervals, pair))
+		}
+	}
+
+	for _, class := range c.GetNotifyEvents() {
+		if !util.Contains(notifyEventClasses, class) {
+			errors.Collect(fmt.Errorf("value for %s contains the unknown event class %s", notifyEvents, class))
+		}
+	}
+
+	if c.GetToggleProvider() == "configmap" && c.GetToggleFile() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by the configmap toggle provider", toggleFile))
+	}
+	if c.GetToggleProvider() == "launchdarkly" && c.GetLaunchDarklyClientID() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by the launchdarkly toggle provider", launchDarklyClientID))
+	}
+
+	if c.GetClusterTokenExchange() && c.GetClusterTokenTTL() <= 0 {
+		errors.Collect(fmt.Errorf("value for %s needs to be positive for the cluster token exchange", clusterTokenTTL))
+	}
+
+	if c.GetTenantBackend() == "file" && c.GetTenantFile() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by the file tenant backend", tenantFile))
+	}
+	if c.GetTenantBackend() == "kubernetes" && c.GetTenantUserLabel() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by the kubernetes tenant backend", tenantUserLabel))
+	}
+
+	if c.GetAdaptiveIdling() && c.GetPressureIdleAfter() <= 0 {
+		errors.Collect(fmt.Errorf("value for %s needs to be positive for adaptive idling", pressureIdleAfter))
+	}
+	if c.GetPressureQuery() != "" && c.GetPrometheusURL() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by %s", prometheusURL, pressureQuery))
+	}
+	if c.GetContentRepositoryIdleAfter() > 0 && c.GetPrometheusURL() == "" {
+		errors.Collect(fmt.Errorf("value for %s is required by %s", prometheusURL, contentRepositoryIdleAfter))
+	}
+
+	if c.GetWarmUpConcurrency() <= 0 {
+		errors.Collect(fmt.Errorf("value for %s needs to be positive", warmUpConcurrency))
+	}
+
+	if writeTimeout := c.GetHTTPWriteTimeout(); writeTimeout > 0 && c.GetResetTimeout() >= writeTimeout {
+		errors.Collect(fmt.Errorf("value for %s needs to be less than %s", resetTimeout, httpWriteTimeout))
+	}
+
+	if c.GetCheckJitter() > 100 {
+		errors.Collect(fmt.Errorf("value for %s must not exceed 100", checkJitter))
+	}
+
+	if c.GetAPIAddress() == c.GetAdminAPIAddress() {
+		errors.Collect(fmt.Errorf("value for %s needs to differ from %s", adminAPIAddress, apiAddress))
+	}
+	if grpc := c.GetGRPCAddress(); grpc != "" && (grpc == c.GetAPIAddress() || grpc == c.GetAdminAPIAddress()) {
+		errors.Collect(fmt.Errorf("value for %s needs to differ from %s and %s", grpcAddress, apiAddress, adminAPIAddress))
+	}
 	return errors
 }
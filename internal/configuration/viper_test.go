@@ -1,13 +1,16 @@
 package configuration
 
 import (
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_GetDebugMode(t *testing.T) {
@@ -79,6 +82,102 @@ func TestConfig_GetIdleAfter(t *testing.T) {
 	assert.Equal(t, c.GetIdleAfter(), want, "Default Idle After Not Set")
 }
 
+func TestConfig_GetManualUnIdleGracePeriod(t *testing.T) {
+	want := defaultManualUnIdleGracePeriod
+	c, _ := New("")
+	assert.Equal(t, c.GetManualUnIdleGracePeriod(), want, "Default Manual Unidle Grace Period Not Set")
+}
+
+func TestConfig_GetEvictInactiveAfter(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultEvictInactiveAfter, c.GetEvictInactiveAfter(), "Default eviction period mismatch")
+
+	os.Setenv(evictInactiveAfter, "-1")
+	defer os.Unsetenv(evictInactiveAfter)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_evict_inactive_after cannot be negative", "Negative period should be rejected")
+}
+
+func TestConfig_GetNamespaceFilter(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetNamespaceAllowlist(), "No namespace should be allowlisted by default")
+	assert.Empty(t, c.GetNamespaceDenylist(), "No namespace should be denylisted by default")
+
+	os.Setenv(namespaceDenylist, "*-preview [-stage")
+	defer os.Unsetenv(namespaceDenylist)
+	c, _ = New("")
+	assert.Equal(t, []string{"*-preview", "[-stage"}, c.GetNamespaceDenylist())
+	assert.Contains(t, c.Verify().ToError().Error(), "JC_NAMESPACE_DENYLIST contains the malformed pattern [-stage", "Malformed pattern should be rejected")
+}
+
+func TestConfig_GetDisabledClusters(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetDisabledClusters(), "No cluster should be disabled by default")
+
+	os.Setenv(disabledClusters, "https://api.starter-us-east-2.openshift.com/ https://api.starter-us-east-2a.openshift.com/")
+	defer os.Unsetenv(disabledClusters)
+	c, _ = New("")
+	assert.Equal(t, []string{"https://api.starter-us-east-2.openshift.com/", "https://api.starter-us-east-2a.openshift.com/"}, c.GetDisabledClusters())
+}
+
+func TestConfig_GetMaxIdlesPerMinute(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultMaxIdlesPerMinute, c.GetMaxIdlesPerMinute(), "Max idles per minute")
+
+	os.Setenv(maxIdlesPerMinute, "-1")
+	defer os.Unsetenv(maxIdlesPerMinute)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_max_idles_per_minute cannot be negative", "A negative idle rate should be rejected")
+}
+
+func TestConfig_GetJenkinsHealthPath(t *testing.T) {
+	c, _ := New("")
+	assert.True(t, c.GetJenkinsHealthProbe(), "The health probe should be enabled by default")
+	assert.Equal(t, "/login", c.GetJenkinsHealthPath(), "Jenkins Health Path Mismatch")
+
+	os.Setenv(jenkinsHealthProbe, "false")
+	defer os.Unsetenv(jenkinsHealthProbe)
+	os.Setenv(jenkinsHealthPath, "/api/json")
+	defer os.Unsetenv(jenkinsHealthPath)
+	c, _ = New("")
+	assert.False(t, c.GetJenkinsHealthProbe())
+	assert.Equal(t, "/api/json", c.GetJenkinsHealthPath())
+}
+
+func TestConfig_GetUnidleOnly(t *testing.T) {
+	c, _ := New("")
+	assert.False(t, c.GetUnidleOnly(), "Idling should be allowed by default")
+	assert.Empty(t, c.GetUnidleOnlyClusters(), "Idling should be allowed on all clusters by default")
+
+	os.Setenv(unidleOnly, "true")
+	defer os.Unsetenv(unidleOnly)
+	os.Setenv(unidleOnlyClusters, "https://api.starter-us-east-2a.openshift.com/")
+	defer os.Unsetenv(unidleOnlyClusters)
+	c, _ = New("")
+	assert.True(t, c.GetUnidleOnly())
+	assert.Equal(t, []string{"https://api.starter-us-east-2a.openshift.com/"}, c.GetUnidleOnlyClusters())
+}
+
+func TestConfig_GetJenkinsNamespaceSuffix(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, "-jenkins", c.GetJenkinsNamespaceSuffix())
+
+	os.Setenv(jenkinsNamespaceSuffix, "-ci")
+	defer os.Unsetenv(jenkinsNamespaceSuffix)
+	c, _ = New("")
+	assert.Equal(t, "-ci", c.GetJenkinsNamespaceSuffix())
+}
+
+func TestConfig_GetActivityNamespaceTypes(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetActivityNamespaceTypes(), "Activity in other namespaces should be ignored by default")
+
+	os.Setenv(activityNamespaceTypes, "che stage")
+	defer os.Unsetenv(activityNamespaceTypes)
+	c, _ = New("")
+	assert.Equal(t, []string{"che", "stage"}, c.GetActivityNamespaceTypes())
+}
+
 func TestConfig_GetIdleLongBuild(t *testing.T) {
 	want := defaultIdleLongBuild
 	c, _ := New("")
@@ -104,6 +203,399 @@ func TestConfig_GetCheckInterval(t *testing.T) {
 	assert.Equal(t, c.GetCheckInterval(), want, "Check Interval Mismatch")
 }
 
+func TestConfig_GetActiveBuildPhases(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, []string{"New", "Pending", "Running"}, c.GetActiveBuildPhases())
+
+	os.Setenv(activeBuildPhases, "Pending Running Timeout Exploded")
+	defer os.Unsetenv(activeBuildPhases)
+	c, _ = New("")
+	assert.Equal(t, []string{"Pending", "Running", "Timeout", "Exploded"}, c.GetActiveBuildPhases())
+	assert.Contains(t, c.Verify().ToError().Error(), "value for JC_ACTIVE_BUILD_PHASES contains the unknown build phase Exploded")
+}
+
+func TestConfig_GetClusterCheckIntervals(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetClusterCheckIntervals(), "No cluster check intervals expected")
+
+	os.Setenv(clusterCheckIntervals, "https://api.free.example.com=5 https://api.dedicated.example.com/=60 https://api.other.com=0")
+	defer os.Unsetenv(clusterCheckIntervals)
+	c, _ = New("")
+	assert.Equal(t, map[string]int{"https://api.free.example.com/": 5, "https://api.dedicated.example.com/": 60}, c.GetClusterCheckIntervals())
+	assert.Contains(t, c.Verify().ToError().Error(), "value for JC_CLUSTER_CHECK_INTERVALS contains the malformed interval https://api.other.com=0")
+}
+
+func TestConfig_GetCheckJitter(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultCheckJitter, c.GetCheckJitter(), "Check Jitter Mismatch")
+
+	os.Setenv(checkJitter, "150")
+	defer os.Unsetenv(checkJitter)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "JC_CHECK_JITTER must not exceed 100", "Jitter above 100% should be rejected")
+}
+
+func TestConfig_GetResetTimeout(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultResetGracePeriod, c.GetResetGracePeriod(), "Reset grace period mismatch")
+	assert.Equal(t, defaultResetTimeout, c.GetResetTimeout(), "Reset timeout mismatch")
+
+	os.Setenv(resetTimeout, "60")
+	defer os.Unsetenv(resetTimeout)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "JC_RESET_TIMEOUT needs to be less than JC_HTTP_WRITE_TIMEOUT", "Reset timeout should be rejected unless the response can be written in time")
+}
+
+func TestConfig_GetProfile(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultProfile, c.GetProfile(), "Default profile not set")
+
+	want := "staging"
+	os.Setenv(profile, want)
+	defer os.Unsetenv(profile)
+	c, _ = New("")
+	assert.Equal(t, want, c.GetProfile(), "Profile Mismatch")
+}
+
+func TestConfig_profile_defaults(t *testing.T) {
+	os.Setenv(profile, "dev")
+	defer os.Unsetenv(profile)
+	c, _ := New("")
+	assert.Equal(t, "debug", c.GetLogLevel(), "Profile should override the built-in default")
+	assert.Equal(t, "text", c.GetLogFormat(), "Profile should override the built-in default")
+
+	os.Setenv(logLevel, "warn")
+	defer os.Unsetenv(logLevel)
+	c, _ = New("")
+	assert.Equal(t, "warn", c.GetLogLevel(), "Environment should override the profile")
+
+	os.Setenv(profile, "qa")
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_profile", "Unknown profile should be rejected")
+}
+
+func TestConfig_Effective(t *testing.T) {
+	file, err := ioutil.TempFile("", "config")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("JC_MAX_RETRIES: 5\nJC_CHECK_INTERVAL: 20\n")
+	file.Close()
+
+	os.Setenv(profile, "prod")
+	defer os.Unsetenv(profile)
+	os.Setenv(checkInterval, "25")
+	defer os.Unsetenv(checkInterval)
+	os.Setenv(serviceAccountSecret, "s3cr3t")
+	defer os.Unsetenv(serviceAccountSecret)
+	os.Setenv(remediationWebhookURL, "https://hooks.example.com/s3cr3t")
+	defer os.Unsetenv(remediationWebhookURL)
+
+	c, err := NewLayered(file.Name(), map[string]string{"jc_idle_after": "60"})
+	require.NoError(t, err)
+	assert.Equal(t, 60, c.GetIdleAfter(), "Flag should override the default")
+	assert.Equal(t, 25, c.GetCheckInterval(), "Environment should override the config file")
+	assert.Equal(t, 5, c.GetMaxRetries(), "Config file should override the default")
+
+	settings := make(map[string]Setting)
+	for _, setting := range c.Effective() {
+		settings[setting.Key] = setting
+	}
+	assert.Equal(t, Setting{Key: idleAfter, Value: 60, Source: SourceFlag}, settings[idleAfter])
+	assert.Equal(t, SourceEnv, settings[checkInterval].Source)
+	assert.Equal(t, SourceFile, settings[maxRetries].Source)
+	assert.Equal(t, SourceProfile, settings[logFormat].Source)
+	assert.Equal(t, SourceDefault, settings[tenantBackend].Source)
+	assert.Equal(t, Setting{Key: serviceAccountSecret, Value: "***", Source: SourceEnv}, settings[serviceAccountSecret])
+	assert.Equal(t, Setting{Key: remediationWebhookURL, Value: "***", Source: SourceEnv}, settings[remediationWebhookURL])
+}
+
+func TestAddFlags(t *testing.T) {
+	c, _ := New("")
+	for key := range c.(*Config).v.AllSettings() {
+		assert.NotEmpty(t, usages[strings.ToUpper(key)], "Option %s should have a flag", strings.ToUpper(key))
+	}
+
+	flags := pflag.NewFlagSet("idler", pflag.ContinueOnError)
+	AddFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--idle-after=60", "--unidle-only", "--namespace-denylist", "ci-* test-*"}))
+
+	os.Setenv(checkInterval, "25")
+	defer os.Unsetenv(checkInterval)
+	c, err := NewLayered("", FlagValues(flags))
+	require.NoError(t, err)
+	assert.Equal(t, 60, c.GetIdleAfter())
+	assert.True(t, c.GetUnidleOnly())
+	assert.Equal(t, []string{"ci-*", "test-*"}, c.GetNamespaceDenylist())
+	assert.Equal(t, 25, c.GetCheckInterval(), "Environment should apply to options not set via flags")
+}
+
+func TestConfig_GetLogSettings(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultLogLevel, c.GetLogLevel(), "Default log level not set")
+	assert.Equal(t, defaultLogFormat, c.GetLogFormat(), "Default log format not set")
+	assert.Empty(t, c.GetLogComponentLevels(), "No component levels expected")
+
+	os.Setenv(logFormat, "text")
+	os.Setenv(logComponentLevels, "controller=debug openshift-client=warn invalid")
+	defer os.Unsetenv(logFormat)
+	defer os.Unsetenv(logComponentLevels)
+	c, _ = New("")
+	assert.Equal(t, "text", c.GetLogFormat(), "Log format mismatch")
+	assert.Equal(t, map[string]string{"controller": "debug", "openshift-client": "warn"}, c.GetLogComponentLevels(),
+		"Component levels mismatch")
+}
+
+func TestConfig_GetAccessLogSettings(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultAccessLogSampleRate, c.GetAccessLogSampleRate(), "Default sample rate not set")
+	assert.False(t, c.GetAccessLogMetricsOnly(), "Access log should be enabled by default")
+
+	os.Setenv(accessLogSampleRate, "0.1")
+	os.Setenv(accessLogMetricsOnly, "true")
+	defer os.Unsetenv(accessLogSampleRate)
+	defer os.Unsetenv(accessLogMetricsOnly)
+	c, _ = New("")
+	assert.Equal(t, 0.1, c.GetAccessLogSampleRate(), "Sample rate mismatch")
+	assert.True(t, c.GetAccessLogMetricsOnly(), "Metrics only mismatch")
+}
+
+func TestConfig_GetMaxRequestBodyBytes(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultMaxRequestBodyBytes, c.GetMaxRequestBodyBytes(), "Default max request body size not set")
+}
+
+func TestConfig_GetCORSSettings(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetCORSAllowedOrigins(), "CORS should be disabled by default")
+	assert.Equal(t, []string{"GET", "POST", "PUT"}, c.GetCORSAllowedMethods(), "Default methods mismatch")
+	assert.Equal(t, []string{"Content-Type", "Authorization"}, c.GetCORSAllowedHeaders(), "Default headers mismatch")
+
+	os.Setenv(corsAllowedOrigins, "https://openshift.io https://dashboard.example.com")
+	defer os.Unsetenv(corsAllowedOrigins)
+	c, _ = New("")
+	assert.Equal(t, []string{"https://openshift.io", "https://dashboard.example.com"}, c.GetCORSAllowedOrigins(), "Origins mismatch")
+}
+
+func TestConfig_GetAPIListeners(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, ":8080", c.GetAPIAddress(), "Default API address mismatch")
+	assert.Equal(t, ":8081", c.GetAdminAPIAddress(), "Default admin API address mismatch")
+	assert.Empty(t, c.GetAPIToken(), "API should not require authentication by default")
+	assert.Empty(t, c.GetAdminAPIToken(), "Admin API should not require authentication by default")
+
+	os.Setenv(adminAPIAddress, "127.0.0.1:9090")
+	os.Setenv(adminAPIToken, "s3cr3t")
+	defer func() {
+		os.Unsetenv(adminAPIAddress)
+		os.Unsetenv(adminAPIToken)
+	}()
+	c, _ = New("")
+	assert.Equal(t, "127.0.0.1:9090", c.GetAdminAPIAddress(), "Admin API address mismatch")
+	assert.Equal(t, "s3cr3t", c.GetAdminAPIToken(), "Admin API token mismatch")
+	assert.NotContains(t, c.String(), "s3cr3t", "Admin API token should not be echoed")
+}
+
+func TestConfig_GetTenantBackend(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, "fabric8", c.GetTenantBackend(), "Default tenant backend mismatch")
+	assert.Equal(t, "idler.fabric8.io/user-id", c.GetTenantUserLabel(), "Default tenant user label mismatch")
+	assert.Equal(t, 20, c.GetTenantMaxPages(), "Default tenant page limit mismatch")
+
+	os.Setenv(tenantBackend, "file")
+	defer os.Unsetenv(tenantBackend)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "value for JC_TENANT_FILE is required by the file tenant backend")
+
+	os.Setenv(tenantMaxPages, "-1")
+	defer os.Unsetenv(tenantMaxPages)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_tenant_max_pages cannot be negative")
+
+	os.Setenv(tenantBackend, "ldap")
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_tenant_backend", "Unknown tenant backend should be rejected")
+}
+
+func TestConfig_GetClusterTokenExchange(t *testing.T) {
+	c, _ := New("")
+	assert.False(t, c.GetClusterTokenExchange(), "Cluster token exchange should be disabled by default")
+	assert.Equal(t, 30, c.GetClusterTokenTTL(), "Default cluster token TTL mismatch")
+	assert.Equal(t, 10, c.GetTokenExpiryWarning(), "Default token expiry warning mismatch")
+
+	os.Setenv(clusterTokenExchange, "true")
+	defer os.Unsetenv(clusterTokenExchange)
+	os.Setenv(clusterTokenTTL, "0")
+	defer os.Unsetenv(clusterTokenTTL)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "value for JC_CLUSTER_TOKEN_TTL needs to be positive for the cluster token exchange")
+}
+
+func TestConfig_GetCapacityCacheTTL(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, 30, c.GetCapacityCacheTTL(), "Default capacity cache TTL mismatch")
+	assert.Equal(t, 120, c.GetCapacityRetryAfter(), "Default capacity Retry-After mismatch")
+
+	os.Setenv(capacityCacheTTL, "-1")
+	defer os.Unsetenv(capacityCacheTTL)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_capacity_cache_ttl cannot be negative")
+}
+
+func TestConfig_GetGRPCAddress(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetGRPCAddress(), "gRPC API should be disabled by default")
+
+	os.Setenv(grpcAddress, ":8080")
+	defer os.Unsetenv(grpcAddress)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "value for JC_GRPC_ADDRESS needs to differ", "gRPC API must not share the address of the public API")
+
+	os.Setenv(grpcAddress, ":8082")
+	c, _ = New("")
+	assert.Equal(t, ":8082", c.GetGRPCAddress(), "gRPC API address mismatch")
+	if err := c.Verify().ToError(); err != nil {
+		assert.NotContains(t, err.Error(), "JC_GRPC_ADDRESS", "gRPC API address should be accepted")
+	}
+}
+
+func TestConfig_GetHTTPServerLimits(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, 15, c.GetHTTPReadTimeout(), "Default read timeout mismatch")
+	assert.Equal(t, 60, c.GetHTTPWriteTimeout(), "Default write timeout mismatch")
+	assert.Equal(t, 120, c.GetHTTPIdleTimeout(), "Default idle timeout mismatch")
+	assert.Equal(t, 64*1024, c.GetHTTPMaxHeaderBytes(), "Default max header bytes mismatch")
+	assert.Equal(t, 512, c.GetHTTPMaxConnections(), "Default max connections mismatch")
+
+	os.Setenv(httpMaxConnections, "-1")
+	defer os.Unsetenv(httpMaxConnections)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_http_max_connections cannot be negative", "Negative limit should be rejected")
+}
+
+func TestConfig_GetRemediationSettings(t *testing.T) {
+	c, _ := New("")
+	assert.False(t, c.GetRemediationEnabled(), "Remediation should be disabled by default")
+	assert.Equal(t, defaultRemediationMaxRestarts, c.GetRemediationMaxRestarts(), "Default max restarts mismatch")
+	assert.Equal(t, "", c.GetRemediationWebhookURL(), "Default webhook URL mismatch")
+
+	os.Setenv(remediationWebhookURL, "not-a-url")
+	defer os.Unsetenv(remediationWebhookURL)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_remediation_webhook_url needs to be a valid URL", "Invalid webhook URL should be rejected")
+}
+
+func TestConfig_GetPrometheusSettings(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetPrometheusURL(), "Prometheus should not be queried by default")
+	assert.Contains(t, c.GetActivityQuery(), `namespace="{{namespace}}"`, "Default activity query should select the namespace")
+	assert.Equal(t, 0.0, c.GetActivityThreshold(), "Default activity threshold mismatch")
+
+	os.Setenv(prometheusURL, "not-a-url")
+	defer os.Unsetenv(prometheusURL)
+	os.Setenv(activityThreshold, "0.5")
+	defer os.Unsetenv(activityThreshold)
+	c, _ = New("")
+	assert.Equal(t, 0.5, c.GetActivityThreshold())
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_prometheus_url needs to be a valid URL", "Invalid Prometheus URL should be rejected")
+}
+
+func TestConfig_GetNotifySettings(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetNotifyWebhookURL(), "Notifications should be disabled by default")
+	assert.Equal(t, "json", c.GetNotifyFormat(), "Default notification format mismatch")
+	assert.Equal(t, notifyEventClasses, c.GetNotifyEvents(), "All event classes should be notified by default")
+	assert.Equal(t, 10, c.GetNotifyCapacitySpike(), "Default capacity spike mismatch")
+
+	os.Setenv(notifyEvents, "unidle-failures disk-full")
+	defer os.Unsetenv(notifyEvents)
+	os.Setenv(notifyFormat, "irc")
+	defer os.Unsetenv(notifyFormat)
+	c, _ = New("")
+	assert.Equal(t, []string{"unidle-failures", "disk-full"}, c.GetNotifyEvents())
+	assert.Contains(t, c.Verify().ToError().Error(), "disk-full", "Unknown event class should be rejected")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_notify_format", "Unknown format should be rejected")
+}
+
+func TestConfig_GetToggleCache(t *testing.T) {
+	c, _ := New("")
+	assert.Empty(t, c.GetToggleBackupPath(), "Unleash default backup path should be used by default")
+	assert.Empty(t, c.GetToggleBootstrapFile(), "No bootstrap file should be set by default")
+
+	os.Setenv(toggleBackupPath, "/var/lib/idler")
+	defer os.Unsetenv(toggleBackupPath)
+	os.Setenv(toggleBootstrapFile, "/etc/idler/toggles.json")
+	defer os.Unsetenv(toggleBootstrapFile)
+	c, _ = New("")
+	assert.Equal(t, "/var/lib/idler", c.GetToggleBackupPath())
+	assert.Equal(t, "/etc/idler/toggles.json", c.GetToggleBootstrapFile())
+}
+
+func TestConfig_GetToggleProvider(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, "unleash", c.GetToggleProvider(), "Unleash should be the default toggle provider")
+	assert.Equal(t, "https://clientsdk.launchdarkly.com", c.GetLaunchDarklyURL(), "Default LaunchDarkly URL mismatch")
+
+	os.Setenv(toggleProvider, "launchdarkly")
+	defer os.Unsetenv(toggleProvider)
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), launchDarklyClientID, "LaunchDarkly should require the client-side ID")
+
+	os.Setenv(toggleProvider, "configmap")
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), toggleFile, "ConfigMap provider should require the toggle file")
+
+	os.Setenv(toggleProvider, "flipper")
+	c, _ = New("")
+	assert.Contains(t, c.Verify().ToError().Error(), "jc_toggle_provider", "Unknown toggle provider should be rejected")
+}
+
+func TestConfig_GetRouteSettings(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, defaultRouteTemplate, c.GetRouteTemplate(), "Default route template mismatch")
+	assert.Equal(t, "template", c.GetRouteSource(), "Routes should be derived from the template by default")
+
+	os.Setenv(routeTemplate, "jenkins.{appDomain}")
+	defer os.Unsetenv(routeTemplate)
+	os.Setenv(routeSource, "dns")
+	defer os.Unsetenv(routeSource)
+	c, _ = New("")
+	err := c.Verify().ToError().Error()
+	assert.Contains(t, err, routeTemplate, "Template without namespace should be rejected")
+	assert.Contains(t, err, "jc_route_source", "Unknown route source should be rejected")
+}
+
+func TestConfig_GetAdaptiveIdling(t *testing.T) {
+	c, _ := New("")
+	assert.False(t, c.GetAdaptiveIdling(), "Adaptive idling should be disabled by default")
+	assert.Equal(t, 15, c.GetPressureIdleAfter(), "Default pressure idle timeout mismatch")
+	assert.Equal(t, 10, c.GetPressureRelaxAfter(), "Default relax period mismatch")
+	assert.Equal(t, 0.9, c.GetPressureThreshold(), "Default pressure threshold mismatch")
+
+	os.Setenv(adaptiveIdling, "true")
+	defer os.Unsetenv(adaptiveIdling)
+	os.Setenv(pressureIdleAfter, "0")
+	defer os.Unsetenv(pressureIdleAfter)
+	os.Setenv(pressureQuery, `sum(cluster:memory_usage:ratio{cluster="{{cluster}}"})`)
+	defer os.Unsetenv(pressureQuery)
+	c, _ = New("")
+	assert.True(t, c.GetAdaptiveIdling(), "Adaptive idling should be enabled")
+	err := c.Verify().ToError().Error()
+	assert.Contains(t, err, pressureIdleAfter, "Adaptive idling should require a positive idle timeout")
+	assert.Contains(t, err, prometheusURL, "Pressure query should require the Prometheus URL")
+}
+
+func TestConfig_ContentRepository(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, 0, c.GetContentRepositoryIdleAfter(), "The content-repository should be idled along with Jenkins by default")
+	assert.Contains(t, c.GetContentRepositoryQuery(), `route="content-repository"`, "Default query mismatch")
+
+	os.Setenv(contentRepositoryIdleAfter, "120")
+	defer os.Unsetenv(contentRepositoryIdleAfter)
+	c, _ = New("")
+	assert.Equal(t, 120, c.GetContentRepositoryIdleAfter(), "Content-repository idle timeout mismatch")
+	assert.Contains(t, c.Verify().ToError().Error(), prometheusURL, "The content-repository idle timeout should require the Prometheus URL")
+}
+
 func TestConfig_GetFixedUuids_None(t *testing.T) {
 	os.Setenv(fixedUuids, "")
 	c, _ := New("")
@@ -162,4 +654,23 @@ func TestConfig_String(t *testing.T) {
 	assert.True(t, strings.Contains(c.String(),
 		"jc_service_account_secret:***"),
 		"Service Account Secret isn't ***")
+
+	for _, key := range []string{notifyWebhookURL, remediationWebhookURL, digestWebhookURL} {
+		os.Setenv(key, "https://hooks.slack.com/services/T000/B000/s3cr3t")
+		defer os.Unsetenv(key)
+	}
+	c, _ = New("")
+	assert.NotContains(t, c.String(), "s3cr3t", "Webhook URLs should not be echoed")
+}
+
+func TestConfig_GetWatchSelectors(t *testing.T) {
+	c, _ := New("")
+	assert.Equal(t, "", c.GetBuildLabelSelector(), "Builds should not be restricted by default")
+	assert.Equal(t, defaultDCLabelSelector, c.GetDCLabelSelector(), "Default deployment config label selector mismatch")
+	assert.Equal(t, defaultPodLabelSelector, c.GetPodLabelSelector(), "Default pod label selector mismatch")
+
+	os.Setenv(buildLabelSelector, "openshift.io/build.strategy=jenkinspipeline")
+	defer os.Unsetenv(buildLabelSelector)
+	c, _ = New("")
+	assert.Equal(t, "openshift.io/build.strategy=jenkinspipeline", c.GetBuildLabelSelector(), "Build label selector mismatch")
 }
@@ -0,0 +1,235 @@
+package dlq
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/recovery"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// retryInterval is the interval at which the entries due for a retry are retried.
+	retryInterval = 5 * time.Second
+
+	// minRetryBackoff is the delay before the first retry of an event.
+	minRetryBackoff = 15 * time.Second
+
+	// maxRetryBackoff is the maximum delay between the retries of an event.
+	maxRetryBackoff = 10 * time.Minute
+)
+
+var logger = logrus.WithField("component", "dlq")
+
+// ErrDiscard is returned by a retry to drop its entry without further retries, e.g. since the watch of the cluster
+// the event stems from got stopped.
+var ErrDiscard = errors.New("event discarded")
+
+// Default is the Queue used by the Idler, nil unless retries are enabled.
+var Default *Queue
+
+// Entry is an event whose handling failed. It is retried with backoff until it succeeds or the retries are exhausted,
+// after which it is kept for inspection until it gets pushed out by newer entries.
+type Entry struct {
+	ID        int64  `json:"id"`
+	Cluster   string `json:"cluster"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Attempts  int    `json:"attempts"`
+	// Error is the last error handling the event failed with, with secrets redacted since the entries are served
+	// by the API.
+	Error     string    `json:"error"`
+	FailedAt  time.Time `json:"failed_at"`
+	NextRetry time.Time `json:"next_retry"`
+	Exhausted bool      `json:"exhausted"`
+
+	retry func() error
+}
+
+// Queue is the dead-letter queue of the events whose handling failed, e.g. since the tenant lookup of a namespace
+// seen for the first time failed transiently. Instead of being dropped, such events are retried with exponential
+// backoff. A later failed event of the same object replaces the pending one. A nil Queue drops the events.
+type Queue struct {
+	sync.Mutex
+	maxRetries int
+	size       int
+	clock      clock.Clock
+	lastID     int64
+	entries    map[string]*Entry
+}
+
+// New creates a Queue as configured. It returns nil if retries are disabled.
+func New(config configuration.Configuration, clock clock.Clock) *Queue {
+	if config.GetDLQMaxRetries() == 0 {
+		return nil
+	}
+
+	size := config.GetDLQSize()
+	if size < 1 {
+		size = 1
+	}
+	return &Queue{
+		maxRetries: config.GetDLQMaxRetries(),
+		size:       size,
+		clock:      clock,
+		entries:    make(map[string]*Entry),
+	}
+}
+
+// Add queues the event of the given object whose handling failed with the given error. The retry function handles
+// the event anew.
+func (q *Queue) Add(cluster, resource, namespace, name string, err error, retry func() error) {
+	if q == nil {
+		return
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	key := cluster + "/" + resource + "/" + namespace + "/" + name
+	if _, ok := q.entries[key]; !ok && len(q.entries) >= q.size {
+		q.evict()
+	}
+
+	q.lastID++
+	now := q.clock.Now()
+	q.entries[key] = &Entry{
+		ID:        q.lastID,
+		Cluster:   cluster,
+		Resource:  resource,
+		Namespace: namespace,
+		Name:      name,
+		Attempts:  1,
+		Error:     redact.String(err.Error()),
+		FailedAt:  now,
+		NextRetry: now.Add(backoff(1)),
+		retry:     retry,
+	}
+	logger.WithFields(logrus.Fields{"cluster": cluster, "resource": resource, "namespace": namespace, "name": name, "err": err}).
+		Info("Queued failed event for retry")
+}
+
+// evict removes the oldest entry, preferring the ones whose retries are exhausted. It needs to be called with the
+// lock held.
+func (q *Queue) evict() {
+	var oldest string
+	for key, e := range q.entries {
+		o, ok := q.entries[oldest]
+		if !ok || (e.Exhausted && !o.Exhausted) || (e.Exhausted == o.Exhausted && e.ID < o.ID) {
+			oldest = key
+		}
+	}
+	e := q.entries[oldest]
+	delete(q.entries, oldest)
+	logger.WithFields(logrus.Fields{"cluster": e.Cluster, "resource": e.Resource, "namespace": e.Namespace, "name": e.Name}).
+		Warn("Dead-letter queue is full, dropped its oldest event")
+}
+
+// backoff returns the delay before the retry following the given number of attempts.
+func backoff(attempts int) time.Duration {
+	d := minRetryBackoff
+	for i := 1; i < attempts && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return d
+}
+
+// Start retries the entries which are due every retryInterval until the context is done.
+func (q *Queue) Start(ctx context.Context, wg *sync.WaitGroup) {
+	if q == nil {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := q.clock.NewTicker(retryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				q.RetryDue()
+			}
+		}
+	}()
+}
+
+// RetryDue retries the entries whose backoff elapsed. Entries whose retry succeeds are removed, failed ones are
+// retried again after twice the backoff, until maxRetries retries failed.
+func (q *Queue) RetryDue() {
+	if q == nil {
+		return
+	}
+
+	q.Lock()
+	now := q.clock.Now()
+	due := make(map[string]*Entry)
+	for key, e := range q.entries {
+		if !e.Exhausted && !now.Before(e.NextRetry) {
+			due[key] = e
+		}
+	}
+	q.Unlock()
+
+	for key, e := range due {
+		err := recovery.Guard("dlq", e.retry)
+		log := logger.WithFields(logrus.Fields{"cluster": e.Cluster, "resource": e.Resource, "namespace": e.Namespace, "name": e.Name})
+
+		q.Lock()
+		if q.entries[key] != e {
+			// replaced by a later event of the object meanwhile
+			q.Unlock()
+			continue
+		}
+		switch {
+		case err == nil:
+			delete(q.entries, key)
+			log.WithField("attempts", e.Attempts+1).Info("Retried failed event successfully")
+		case err == ErrDiscard:
+			delete(q.entries, key)
+			log.Debug("Discarded failed event")
+		default:
+			e.Attempts++
+			e.Error = redact.String(err.Error())
+			if e.Attempts > q.maxRetries {
+				e.Exhausted = true
+				e.NextRetry = time.Time{}
+				log.WithFields(logrus.Fields{"attempts": e.Attempts, "err": err}).Warn("Giving up retrying failed event")
+			} else {
+				e.NextRetry = q.clock.Now().Add(backoff(e.Attempts))
+				log.WithFields(logrus.Fields{"attempts": e.Attempts, "err": err}).Debug("Retry of failed event failed")
+			}
+		}
+		q.Unlock()
+	}
+}
+
+// Entries returns the queued entries ordered by the time they got queued.
+func (q *Queue) Entries() []Entry {
+	if q == nil {
+		return []Entry{}
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	entries := make([]Entry, 0, len(q.entries))
+	for _, e := range q.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
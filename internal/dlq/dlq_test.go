@@ -0,0 +1,124 @@
+package dlq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
+func Test_nil_queue(t *testing.T) {
+	q := New(&mock.Config{}, clock.NewFake(now))
+	assert.Nil(t, q, "Queue should be disabled without retries")
+
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("tenant lookup failed"), func() error { return nil })
+	q.RetryDue()
+	assert.Empty(t, q.Entries())
+}
+
+func Test_failed_event_is_retried_with_backoff(t *testing.T) {
+	c := clock.NewFake(now)
+	q := New(&mock.Config{DLQMaxRetries: 5, DLQSize: 10}, c)
+	require.NotNil(t, q)
+
+	failures := 2
+	retries := 0
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("tenant lookup failed"), func() error {
+		retries++
+		if retries <= failures {
+			return errors.New("tenant lookup failed again")
+		}
+		return nil
+	})
+
+	q.RetryDue()
+	assert.Equal(t, 0, retries, "Event should not be retried before its backoff elapsed")
+
+	c.Advance(minRetryBackoff)
+	q.RetryDue()
+	assert.Equal(t, 1, retries)
+	entries := q.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.Equal(t, "tenant lookup failed again", entries[0].Error)
+	assert.Equal(t, c.Now().Add(2*minRetryBackoff), entries[0].NextRetry, "Backoff should double")
+
+	c.Advance(minRetryBackoff)
+	q.RetryDue()
+	assert.Equal(t, 1, retries, "Event should not be retried before its doubled backoff elapsed")
+
+	c.Advance(minRetryBackoff)
+	q.RetryDue()
+	c.Advance(4 * minRetryBackoff)
+	q.RetryDue()
+	assert.Equal(t, 3, retries)
+	assert.Empty(t, q.Entries(), "Successfully retried event should be removed")
+}
+
+func Test_retries_get_exhausted(t *testing.T) {
+	c := clock.NewFake(now)
+	q := New(&mock.Config{DLQMaxRetries: 2, DLQSize: 10}, c)
+
+	retries := 0
+	q.Add("https://api.example.com/", "deploymentconfigs", "john-jenkins", "jenkins", errors.New("failed"), func() error {
+		retries++
+		return errors.New("failed")
+	})
+	for i := 0; i < 5; i++ {
+		c.Advance(maxRetryBackoff)
+		q.RetryDue()
+	}
+
+	assert.Equal(t, 2, retries)
+	entries := q.Entries()
+	require.Len(t, entries, 1, "Exhausted event should be kept for inspection")
+	assert.True(t, entries[0].Exhausted)
+	assert.True(t, entries[0].NextRetry.IsZero())
+}
+
+func Test_discarded_and_replaced_events(t *testing.T) {
+	c := clock.NewFake(now)
+	q := New(&mock.Config{DLQMaxRetries: 5, DLQSize: 2}, c)
+
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("first"), func() error { return ErrDiscard })
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("second"), func() error { return ErrDiscard })
+	entries := q.Entries()
+	require.Len(t, entries, 1, "Later event of the same object should replace the pending one")
+	assert.Equal(t, "second", entries[0].Error)
+
+	q.Add("https://api.example.com/", "builds", "jane", "jane-1", errors.New("third"), func() error { return nil })
+	q.Add("https://api.example.com/", "builds", "jim", "jim-1", errors.New("fourth"), func() error { return nil })
+	entries = q.Entries()
+	require.Len(t, entries, 2, "Oldest event should be dropped once the queue is full")
+	assert.Equal(t, "jane", entries[0].Namespace)
+	assert.Equal(t, "jim", entries[1].Namespace)
+
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("fifth"), func() error { return ErrDiscard })
+	c.Advance(minRetryBackoff)
+	q.RetryDue()
+	assert.Empty(t, q.Entries(), "Discarded and successfully retried events should be removed")
+}
+
+func Test_errors_are_redacted(t *testing.T) {
+	c := clock.NewFake(now)
+	q := New(&mock.Config{DLQMaxRetries: 5, DLQSize: 10}, c)
+
+	q.Add("https://api.example.com/", "builds", "john", "john-1", errors.New("GET /oapi/v1?access_token=s3cr3t failed"), func() error {
+		return errors.New("request with Authorization: Bearer s3cr3t failed")
+	})
+	entries := q.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "GET /oapi/v1?access_token=*** failed", entries[0].Error)
+
+	c.Advance(minRetryBackoff)
+	q.RetryDue()
+	entries = q.Entries()
+	require.Len(t, entries, 1)
+	assert.NotContains(t, entries[0].Error, "s3cr3t", "Error of a failed retry should be redacted")
+}
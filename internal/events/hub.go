@@ -0,0 +1,75 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of events buffered per subscriber. A subscriber lagging further behind misses
+// events rather than blocking the publisher.
+const subscriberBuffer = 64
+
+// Event is a change of the state of the Jenkins instance of a namespace.
+type Event struct {
+	Namespace string    `json:"namespace"`
+	State     string    `json:"state"`
+	Previous  string    `json:"previous,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Hub fans out the published events to its subscribers.
+type Hub struct {
+	sync.RWMutex
+	subscribers map[chan Event]string
+}
+
+// NewHub creates a Hub without subscribers.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[chan Event]string)}
+}
+
+// Subscribe returns a channel receiving the events of the given namespace, or of all namespaces if the namespace
+// is empty, as well as a function cancelling the subscription.
+func (h *Hub) Subscribe(namespace string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.Lock()
+	h.subscribers[ch] = namespace
+	h.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.Lock()
+			delete(h.subscribers, ch)
+			h.Unlock()
+		})
+	}
+}
+
+// Publish passes the event on to all subscribers of its namespace without blocking. It returns the number of
+// subscribers which missed the event since their buffer was full.
+func (h *Hub) Publish(e Event) int {
+	h.RLock()
+	defer h.RUnlock()
+
+	missed := 0
+	for ch, namespace := range h.subscribers {
+		if namespace != "" && namespace != e.Namespace {
+			continue
+		}
+		select {
+		case ch <- e:
+		default:
+			missed++
+		}
+	}
+	return missed
+}
+
+// Len returns the number of subscribers.
+func (h *Hub) Len() int {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_hub_delivers_events_per_namespace(t *testing.T) {
+	hub := NewHub()
+	all, cancelAll := hub.Subscribe("")
+	defer cancelAll()
+	foo, cancelFoo := hub.Subscribe("foo-jenkins")
+	defer cancelFoo()
+
+	hub.Publish(Event{Namespace: "bar-jenkins", State: "idled"})
+	hub.Publish(Event{Namespace: "foo-jenkins", State: "starting"})
+
+	require.Len(t, all, 2)
+	require.Len(t, foo, 1)
+	assert.Equal(t, "starting", (<-foo).State)
+}
+
+func Test_hub_does_not_block_on_slow_subscribers(t *testing.T) {
+	hub := NewHub()
+	_, cancel := hub.Subscribe("")
+
+	for i := 0; i < subscriberBuffer; i++ {
+		assert.Equal(t, 0, hub.Publish(Event{Namespace: "foo-jenkins"}))
+	}
+	assert.Equal(t, 1, hub.Publish(Event{Namespace: "foo-jenkins"}), "The event should be missed by the full subscriber")
+
+	cancel()
+	cancel()
+	assert.Equal(t, 0, hub.Len())
+}
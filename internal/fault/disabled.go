@@ -0,0 +1,8 @@
+//go:build !faultinjection
+// +build !faultinjection
+
+package fault
+
+// Enabled tells whether fault injection is compiled in. It is only in builds with the faultinjection tag, which must
+// never be deployed to production.
+const Enabled = false
@@ -0,0 +1,215 @@
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/sirupsen/logrus"
+)
+
+// Targets of the rules, i.e. the clients faults can be injected into.
+const (
+	// OpenShift marks the requests to the OpenShift API of the clusters.
+	OpenShift = "openshift"
+
+	// Tenant marks the requests to the fabric8-tenant API.
+	Tenant = "tenant"
+)
+
+// maxLatency is the maximum latency a rule may inject, so that a forgotten rule cannot stall the Idler entirely.
+const maxLatency = 5 * time.Minute
+
+var logger = logrus.WithField("component", "fault")
+
+// ErrInjected is the error an injected fault fails a request with unless the rule asks for an HTTP status.
+var ErrInjected = errors.New("injected fault")
+
+// Default is the Injector the OpenShift and tenant clients are wrapped with in builds with fault injection.
+var Default = NewInjector(clock.New())
+
+// Rule injects faults into the requests of a target, optionally restricted to the requests to a single host, e.g.
+// one cluster. Each matching request is delayed by the latency, then a fraction of them given by the error rate
+// fails, either with ErrInjected or, if a status is given, with a response carrying that HTTP status.
+type Rule struct {
+	Target    string  `json:"target"`
+	Host      string  `json:"host,omitempty"`
+	LatencyMS int     `json:"latency_ms"`
+	ErrorRate float64 `json:"error_rate"`
+	Status    int     `json:"status,omitempty"`
+	Injected  int64   `json:"injected"`
+}
+
+// key identifies the rule; a later rule with the same key replaces the former one.
+func (r Rule) key() string {
+	return r.Target + " " + r.Host
+}
+
+// Validate returns an error if the rule has an unknown target or out of range values.
+func (r Rule) Validate() error {
+	switch {
+	case r.Target != OpenShift && r.Target != Tenant:
+		return fmt.Errorf("unknown target '%s', expected %s or %s", r.Target, OpenShift, Tenant)
+	case r.LatencyMS < 0 || time.Duration(r.LatencyMS)*time.Millisecond > maxLatency:
+		return fmt.Errorf("latency_ms needs to be within 0 and %d", maxLatency/time.Millisecond)
+	case r.ErrorRate < 0 || r.ErrorRate > 1:
+		return errors.New("error_rate needs to be within 0.0 and 1.0")
+	case r.Status != 0 && (r.Status < 400 || r.Status > 599):
+		return errors.New("status needs to be an HTTP error status")
+	}
+	return nil
+}
+
+// Injector holds the rules by which faults are injected into the requests of the wrapped clients.
+type Injector struct {
+	sync.Mutex
+	clock  clock.Clock
+	random func() float64
+	rules  map[string]*Rule
+}
+
+// NewInjector creates an Injector without rules.
+func NewInjector(clock clock.Clock) *Injector {
+	return &Injector{
+		clock:  clock,
+		random: rand.Float64,
+		rules:  make(map[string]*Rule),
+	}
+}
+
+// Set adds the rule, replacing the one of the same target and host. It returns an error if the rule is invalid.
+func (i *Injector) Set(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	rule.Injected = 0
+	i.rules[rule.key()] = &rule
+	logger.WithFields(logrus.Fields{"target": rule.Target, "host": rule.Host, "latency_ms": rule.LatencyMS, "error_rate": rule.ErrorRate, "status": rule.Status}).
+		Warn("Fault injection rule set")
+	return nil
+}
+
+// Clear removes the rules of the given target, or all rules if the target is empty. It returns the number of
+// removed rules.
+func (i *Injector) Clear(target string) int {
+	i.Lock()
+	defer i.Unlock()
+
+	removed := 0
+	for key, rule := range i.rules {
+		if target == "" || rule.Target == target {
+			delete(i.rules, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		logger.WithFields(logrus.Fields{"target": target, "rules": removed}).Warn("Fault injection rules cleared")
+	}
+	return removed
+}
+
+// Rules returns the rules ordered by target and host.
+func (i *Injector) Rules() []Rule {
+	i.Lock()
+	defer i.Unlock()
+
+	rules := make([]Rule, 0, len(i.rules))
+	for _, rule := range i.rules {
+		rules = append(rules, *rule)
+	}
+	sort.Slice(rules, func(a, b int) bool { return rules[a].key() < rules[b].key() })
+	return rules
+}
+
+// match returns a copy of the rule applying to the request of the target, preferring the rule of its host, and
+// whether the request fails. The injected count of the rule is incremented if so.
+func (i *Injector) match(target string, req *http.Request) (Rule, bool, bool) {
+	i.Lock()
+	defer i.Unlock()
+
+	rule, ok := i.rules[target+" "+req.URL.Host]
+	if !ok {
+		rule, ok = i.rules[target+" "]
+	}
+	if !ok {
+		return Rule{}, false, false
+	}
+
+	fail := rule.ErrorRate > 0 && i.random() < rule.ErrorRate
+	if fail || rule.LatencyMS > 0 {
+		rule.Injected++
+	}
+	return *rule, fail, true
+}
+
+// RoundTripper wraps the given round tripper so that the rules of the target are applied to its requests.
+func (i *Injector) RoundTripper(target string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{injector: i, target: target, next: next}
+}
+
+type roundTripper struct {
+	injector *Injector
+	target   string
+	next     http.RoundTripper
+}
+
+// RoundTrip delays and fails the request as demanded by the matching rule, if any, and passes it on otherwise.
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule, fail, ok := t.injector.match(t.target, req)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	log := logger.WithFields(logrus.Fields{"target": t.target, "method": req.Method, "url": req.URL.String()})
+	if rule.LatencyMS > 0 {
+		log.WithField("latency_ms", rule.LatencyMS).Debug("Delaying request")
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-t.injector.clock.After(time.Duration(rule.LatencyMS) * time.Millisecond):
+		}
+	}
+
+	if !fail {
+		return t.next.RoundTrip(req)
+	}
+	if rule.Status == 0 {
+		log.Debug("Failing request")
+		return nil, ErrInjected
+	}
+
+	log.WithField("status", rule.Status).Debug("Answering request with error status")
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", rule.Status, http.StatusText(rule.Status)),
+		StatusCode: rule.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader(ErrInjected.Error())),
+		Request:    req,
+	}, nil
+}
+
+// Transport wraps the given round tripper with the Default Injector for the given target if fault injection is
+// compiled in. Otherwise it returns the round tripper as is.
+func Transport(target string, next http.RoundTripper) http.RoundTripper {
+	if !Enabled {
+		return next
+	}
+	return Default.RoundTripper(target, next)
+}
@@ -0,0 +1,112 @@
+package fault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rule_validation(t *testing.T) {
+	assert.NoError(t, Rule{Target: OpenShift, LatencyMS: 100, ErrorRate: 0.5, Status: 503}.Validate())
+	assert.NoError(t, Rule{Target: Tenant, ErrorRate: 1}.Validate())
+
+	assert.Error(t, Rule{Target: "auth"}.Validate(), "Unknown target should be rejected")
+	assert.Error(t, Rule{Target: OpenShift, LatencyMS: -1}.Validate(), "Negative latency should be rejected")
+	assert.Error(t, Rule{Target: OpenShift, LatencyMS: 10 * 60 * 1000}.Validate(), "Excessive latency should be rejected")
+	assert.Error(t, Rule{Target: OpenShift, ErrorRate: 1.5}.Validate(), "Error rate above 1 should be rejected")
+	assert.Error(t, Rule{Target: OpenShift, Status: 200}.Validate(), "Success status should be rejected")
+}
+
+func Test_injector_rules(t *testing.T) {
+	i := NewInjector(clock.New())
+	assert.NoError(t, i.Set(Rule{Target: Tenant, ErrorRate: 1}))
+	assert.NoError(t, i.Set(Rule{Target: OpenShift, Host: "api.b.example.com", ErrorRate: 1}))
+	assert.NoError(t, i.Set(Rule{Target: OpenShift, ErrorRate: 0.5}))
+	assert.NoError(t, i.Set(Rule{Target: OpenShift, ErrorRate: 0.2}))
+	assert.Error(t, i.Set(Rule{Target: "auth"}))
+
+	rules := i.Rules()
+	assert.Len(t, rules, 3, "A rule should replace the one of the same target and host")
+	assert.Equal(t, Rule{Target: OpenShift, ErrorRate: 0.2}, rules[0])
+	assert.Equal(t, "api.b.example.com", rules[1].Host)
+	assert.Equal(t, Tenant, rules[2].Target)
+
+	assert.Equal(t, 2, i.Clear(OpenShift))
+	assert.Len(t, i.Rules(), 1)
+	assert.Equal(t, 1, i.Clear(""))
+	assert.Empty(t, i.Rules())
+}
+
+func Test_round_tripper_injects_faults(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	i := NewInjector(clock.New())
+	random := 0.0
+	i.random = func() float64 { return random }
+	client := &http.Client{Transport: i.RoundTripper(OpenShift, nil)}
+
+	resp, err := client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Requests should pass without rules")
+
+	i.Set(Rule{Target: Tenant, ErrorRate: 1})
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Rules of other targets should not apply")
+
+	i.Set(Rule{Target: OpenShift, ErrorRate: 0.5})
+	_, err = client.Get(ts.URL)
+	assert.Error(t, err, "Request should fail if the random number is below the error rate")
+
+	random = 0.7
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Request should pass if the random number exceeds the error rate")
+
+	i.Set(Rule{Target: OpenShift, ErrorRate: 1, Status: http.StatusServiceUnavailable})
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "Request should be answered with the status of the rule")
+	assert.Equal(t, int64(1), i.Rules()[0].Injected)
+
+	i.Set(Rule{Target: OpenShift, Host: "other.example.com", ErrorRate: 0})
+	resp, err = client.Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "Rules of other hosts should not apply")
+}
+
+func Test_round_tripper_injects_latency(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	fake := clock.NewFake(time.Now())
+	i := NewInjector(fake)
+	i.Set(Rule{Target: Tenant, LatencyMS: 2000})
+	client := &http.Client{Transport: i.RoundTripper(Tenant, nil)}
+
+	done := make(chan int)
+	go func() {
+		resp, err := client.Get(ts.URL)
+		assert.NoError(t, err)
+		done <- resp.StatusCode
+	}()
+
+	fake.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("Request should be delayed")
+	default:
+	}
+	fake.Advance(2 * time.Second)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, int64(1), i.Rules()[0].Injected)
+}
@@ -0,0 +1,182 @@
+package footprint
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/prometheus"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+)
+
+// namespacePlaceholder stands for the Jenkins namespace in the usage queries.
+const namespacePlaceholder = "{{namespace}}"
+
+var logger = logrus.WithField("component", "footprint")
+
+// Recorder to capture the footprint of the Jenkins instances
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// UsageSource measures the resources the pods of a Jenkins namespace currently consume.
+type UsageSource interface {
+	Usage(apiURL string, bearerToken string, namespace string) (model.Usage, error)
+}
+
+// prometheusSource measures the usage by evaluating the configured PromQL queries.
+type prometheusSource struct {
+	client      *prometheus.Client
+	cpuQuery    string
+	memoryQuery string
+	clock       clock.Clock
+}
+
+// Usage returns the sums of the samples the CPU and the memory query yield for the namespace.
+func (s prometheusSource) Usage(apiURL string, bearerToken string, namespace string) (model.Usage, error) {
+	cpu, err := s.client.Sum(strings.Replace(s.cpuQuery, namespacePlaceholder, namespace, -1))
+	if err != nil {
+		return model.Usage{}, err
+	}
+	memory, err := s.client.Sum(strings.Replace(s.memoryQuery, namespacePlaceholder, namespace, -1))
+	if err != nil {
+		return model.Usage{}, err
+	}
+	return model.Usage{CPU: cpu, Memory: int64(memory), Time: s.clock.Now()}, nil
+}
+
+// metricsServerSource measures the usage via the metrics-server of the cluster.
+type metricsServerSource struct {
+	client client.OpenShiftClient
+}
+
+// Usage returns the usage of the pods of the namespace as reported by the metrics-server.
+func (s metricsServerSource) Usage(apiURL string, bearerToken string, namespace string) (model.Usage, error) {
+	return s.client.PodUsage(apiURL, bearerToken, namespace)
+}
+
+// Collector periodically measures the resource usage of the running Jenkins instances and records their footprint,
+// i.e. the resources they request, are limited to and use, on their user idlers as well as in metrics, so that
+// over-provisioned Jenkins instances can be right-sized.
+type Collector struct {
+	source   UsageSource
+	view     cluster.View
+	interval time.Duration
+	clock    clock.Clock
+	recorded map[string]bool
+}
+
+// New creates a Collector as configured. The usage is queried from Prometheus if its URL is configured, from the
+// metrics-server of the clusters otherwise. It returns nil if the measurements are disabled.
+func New(config configuration.Configuration, openShiftClient client.OpenShiftClient, view cluster.View, clock clock.Clock) *Collector {
+	if config.GetFootprintInterval() == 0 {
+		return nil
+	}
+
+	var source UsageSource = metricsServerSource{client: openShiftClient}
+	if config.GetPrometheusURL() != "" {
+		source = prometheusSource{
+			client:      prometheus.NewClient(config.GetPrometheusURL()),
+			cpuQuery:    config.GetFootprintCPUQuery(),
+			memoryQuery: config.GetFootprintMemoryQuery(),
+			clock:       clock,
+		}
+	}
+	return NewCollector(source, view, time.Duration(config.GetFootprintInterval())*time.Minute, clock)
+}
+
+// NewCollector creates a Collector measuring the usage via the given source at the given interval.
+func NewCollector(source UsageSource, view cluster.View, interval time.Duration, clock clock.Clock) *Collector {
+	return &Collector{
+		source:   source,
+		view:     view,
+		interval: interval,
+		clock:    clock,
+		recorded: make(map[string]bool),
+	}
+}
+
+// Start measures the footprint of the Jenkins instances of the given user idlers at the interval until the context
+// is done.
+func (c *Collector) Start(ctx context.Context, wg *sync.WaitGroup, userIdlers *openshift.UserIdlerMap) {
+	if c == nil {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := c.clock.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.collect(ctx, userIdlers)
+			select {
+			case <-ctx.Done():
+				logger.Info("Shutting down footprint collector.")
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+}
+
+// collect measures the usage of the running Jenkins instances, idled ones using no resources, and records the
+// footprint of all of them. The metrics of the namespaces whose user idler is gone are removed.
+func (c *Collector) collect(ctx context.Context, userIdlers *openshift.UserIdlerMap) {
+	seen := make(map[string]bool)
+	userIdlers.Range(func(namespace string, userIdler *idler.UserIdler) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		seen[namespace] = true
+
+		usage := model.Usage{Time: c.clock.Now()}
+		if userIdler.State() != idler.StateIdled {
+			apiURL := userIdler.OpenShiftAPI()
+			token, ok := c.view.GetToken(apiURL)
+			if !ok {
+				return true
+			}
+			var err error
+			usage, err = c.source.Usage(apiURL, token, namespace)
+			if err != nil {
+				logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL, "err": err}).
+					Warn("Unable to measure the resource usage of Jenkins")
+				return true
+			}
+		}
+		userIdler.SetUsage(usage)
+		c.record(namespace, userIdler)
+		return true
+	})
+
+	for namespace := range c.recorded {
+		if !seen[namespace] {
+			Recorder.RecordJenkinsResourcesRemoved(namespace)
+			delete(c.recorded, namespace)
+		}
+	}
+}
+
+// record records the footprint of the Jenkins of the user idler in the metrics.
+func (c *Collector) record(namespace string, userIdler *idler.UserIdler) {
+	if resources, ok := userIdler.Resources(); ok {
+		Recorder.RecordJenkinsResources(namespace, "cpu", "request", resources.CPURequest)
+		Recorder.RecordJenkinsResources(namespace, "cpu", "limit", resources.CPULimit)
+		Recorder.RecordJenkinsResources(namespace, "memory", "request", float64(resources.MemoryRequest))
+		Recorder.RecordJenkinsResources(namespace, "memory", "limit", float64(resources.MemoryLimit))
+	}
+	if usage, ok := userIdler.Usage(); ok {
+		Recorder.RecordJenkinsResources(namespace, "cpu", "usage", usage.CPU)
+		Recorder.RecordJenkinsResources(namespace, "memory", "usage", float64(usage.Memory))
+	}
+	c.recorded[namespace] = true
+}
@@ -0,0 +1,113 @@
+package footprint
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// footprintRecorder captures the recorded footprints keyed against namespace/resource/type.
+type footprintRecorder struct {
+	metric.PrometheusRecorder
+	values map[string]float64
+}
+
+func (r *footprintRecorder) RecordJenkinsResources(namespace, resource, kind string, value float64) {
+	r.values[namespace+"/"+resource+"/"+kind] = value
+}
+
+func (r *footprintRecorder) RecordJenkinsResourcesRemoved(namespace string) {
+	for key := range r.values {
+		if strings.HasPrefix(key, namespace+"/") {
+			delete(r.values, key)
+		}
+	}
+}
+
+// usageSource returns the usage configured per namespace, failing for the others.
+type usageSource map[string]model.Usage
+
+func (s usageSource) Usage(apiURL string, bearerToken string, namespace string) (model.Usage, error) {
+	usage, ok := s[namespace]
+	if !ok {
+		return model.Usage{}, errors.New("no metrics")
+	}
+	return usage, nil
+}
+
+func Test_collector_records_footprint(t *testing.T) {
+	recorder := &footprintRecorder{values: map[string]float64{}}
+	defer func(r metric.Recorder) { Recorder = r }(Recorder)
+	Recorder = recorder
+
+	const apiURL = "https://api.example.com/"
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	view := cluster.NewView([]cluster.Cluster{{APIURL: apiURL, Token: "token"}})
+	userIdlers := openshift.NewUserIdlerMap()
+	newUserIdler := func(name string, state model.PodState) *idler.UserIdler {
+		userIdler := idler.NewUserIdler(model.NewUser(name, name), apiURL, "", &mock.Config{},
+			mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New())
+		userIdler.Observe(state)
+		userIdlers.Store(name+"-jenkins", userIdler)
+		return userIdler
+	}
+
+	running := newUserIdler("foo", model.PodRunning)
+	running.SetResources(model.Resources{CPURequest: 0.5, CPULimit: 2, MemoryRequest: 1 << 30, MemoryLimit: 2 << 30})
+	idled := newUserIdler("bar", model.PodIdled)
+	newUserIdler("baz", model.PodRunning)
+
+	source := usageSource{"foo-jenkins": {CPU: 0.25, Memory: 768 << 20, Time: now}}
+	collector := NewCollector(source, view, time.Minute, clock.NewFake(now))
+	collector.collect(context.Background(), userIdlers)
+
+	usage, ok := running.Usage()
+	require.True(t, ok)
+	assert.Equal(t, model.Usage{CPU: 0.25, Memory: 768 << 20, Time: now}, usage)
+	usage, ok = idled.Usage()
+	require.True(t, ok, "An idled Jenkins should be recorded as using nothing")
+	assert.Equal(t, model.Usage{Time: now}, usage)
+
+	assert.Equal(t, map[string]float64{
+		"foo-jenkins/cpu/request":    0.5,
+		"foo-jenkins/cpu/limit":      2,
+		"foo-jenkins/cpu/usage":      0.25,
+		"foo-jenkins/memory/request": 1 << 30,
+		"foo-jenkins/memory/limit":   2 << 30,
+		"foo-jenkins/memory/usage":   768 << 20,
+		"bar-jenkins/cpu/usage":      0,
+		"bar-jenkins/memory/usage":   0,
+	}, recorder.values, "Jenkins whose usage cannot be measured should be skipped")
+
+	userIdlers.Delete("foo-jenkins")
+	collector.collect(context.Background(), userIdlers)
+	assert.Equal(t, map[string]float64{
+		"bar-jenkins/cpu/usage":    0,
+		"bar-jenkins/memory/usage": 0,
+	}, recorder.values, "The footprint of evicted user idlers should be removed")
+}
+
+func Test_new_collector_is_disabled_by_default(t *testing.T) {
+	assert.Nil(t, New(&mock.Config{}, &mock.OpenShiftClient{}, cluster.NewView(nil), clock.New()))
+
+	collector := New(&mock.Config{FootprintInterval: 5}, &mock.OpenShiftClient{}, cluster.NewView(nil), clock.New())
+	require.NotNil(t, collector)
+	assert.IsType(t, metricsServerSource{}, collector.source, "The metrics-server should be used without Prometheus")
+
+	collector = New(&mock.Config{FootprintInterval: 5, PrometheusURL: "http://prometheus:9090"}, &mock.OpenShiftClient{},
+		cluster.NewView(nil), clock.New())
+	require.NotNil(t, collector)
+	assert.IsType(t, prometheusSource{}, collector.source)
+}
@@ -0,0 +1,183 @@
+package idler
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/lock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/prometheus"
+)
+
+const (
+	// contentRepositoryService is the service caching the artifacts of the builds of a user.
+	contentRepositoryService = "content-repository"
+	// namespacePlaceholder stands for the Jenkins namespace in the content-repository query.
+	namespacePlaceholder = "{{namespace}}"
+	// intervalPlaceholder stands for the longest time between two checks in the content-repository query, so that
+	// its range covers all requests since the previous check.
+	intervalPlaceholder = "{{interval}}"
+)
+
+// contentRepository tracks the requests to the content-repository service of a user, which is frequently needed while
+// Jenkins is idled, e.g. by builds outside Jenkins resolving artifacts. If it is tracked, it is idled on its own once
+// it got no requests for its idle timeout, instead of along with Jenkins. As OpenShift un-idles it upon the next
+// request to its route, it is only un-idled along with Jenkins.
+type contentRepository struct {
+	idleAfter   time.Duration
+	query       string
+	prometheus  *prometheus.Client
+	lastRequest int64
+	// idled is set once the content-repository is known to be idled, until it gets requests again.
+	idled bool
+}
+
+// newContentRepository creates the tracker of the content-repository as configured. It returns nil if the
+// content-repository is idled along with Jenkins.
+func newContentRepository(config configuration.Configuration, clock clock.Clock) *contentRepository {
+	idleAfter := time.Duration(config.GetContentRepositoryIdleAfter()) * time.Minute
+	if idleAfter <= 0 || config.GetPrometheusURL() == "" {
+		return nil
+	}
+	return &contentRepository{
+		idleAfter:   idleAfter,
+		query:       config.GetContentRepositoryQuery(),
+		prometheus:  prometheus.NewClient(config.GetPrometheusURL()),
+		lastRequest: clock.Now().UnixNano(),
+	}
+}
+
+// observe records a request to the content-repository, resp. its un-idle, at the given time.
+func (r *contentRepository) observe(t time.Time) {
+	atomic.StoreInt64(&r.lastRequest, t.UnixNano())
+	r.idled = false
+}
+
+// LastRequest returns when the content-repository last got requests, resp. when its tracking started.
+func (r *contentRepository) LastRequest() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&r.lastRequest))
+}
+
+// ContentRepositoryActivity returns when the content-repository of the user last got requests, provided it is idled
+// on its own rather than along with Jenkins.
+func (idler *UserIdler) ContentRepositoryActivity() (time.Time, bool) {
+	if idler.contentRepository == nil {
+		return time.Time{}, false
+	}
+	return idler.contentRepository.LastRequest().UTC(), true
+}
+
+// servicesToIdle returns the services idled along with Jenkins, i.e. JenkinsServices without the content-repository
+// if it is idled on its own.
+func (idler *UserIdler) servicesToIdle() []string {
+	if idler.contentRepository == nil {
+		return JenkinsServices
+	}
+
+	var services []string
+	for _, service := range JenkinsServices {
+		if service != contentRepositoryService {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// servicesToUnIdle returns the services un-idled along with Jenkins, i.e. JenkinsServices including the
+// content-repository if it is idled on its own, as the builds of Jenkins depend on it.
+func (idler *UserIdler) servicesToUnIdle() []string {
+	if idler.contentRepository == nil {
+		return JenkinsServices
+	}
+
+	for _, service := range JenkinsServices {
+		if service == contentRepositoryService {
+			return JenkinsServices
+		}
+	}
+	return append(append([]string(nil), JenkinsServices...), contentRepositoryService)
+}
+
+// checkContentRepository idles the content-repository of the user once it got no requests for its idle timeout,
+// provided it is idled on its own. Like Jenkins, it is not idled while idling of the namespace is skipped, disabled
+// or kept from by a reservation or the schedule of the namespace.
+func (idler *UserIdler) checkContentRepository() error {
+	repo := idler.contentRepository
+	if repo == nil {
+		return nil
+	}
+
+	ns := namespace.Jenkins(idler.user.Name)
+	now := idler.clock.Now()
+	query := strings.NewReplacer(namespacePlaceholder, ns, intervalPlaceholder, idler.queryInterval()).Replace(repo.query)
+	requests, err := repo.prometheus.Sum(query)
+	if err != nil {
+		// without knowing its activity, the content-repository is kept running
+		return fmt.Errorf("querying the requests to the content-repository failed: %s", err)
+	}
+	if requests > 0 {
+		repo.observe(now)
+		return nil
+	}
+
+	idle := now.Sub(repo.LastRequest())
+	if repo.idled || idle < repo.idleAfter {
+		return nil
+	}
+
+	if idler.user.SkipIdling || idler.user.Overrides.Disabled || idler.user.Overrides.Schedule.Active(now) ||
+		UnidleOnly(idler.config, idler.openShiftAPI) {
+		return nil
+	}
+	if _, reserved := Reservations.Reserved(ns); reserved {
+		return nil
+	}
+
+	state, err := idler.openShiftClient.State(idler.openShiftAPI, idler.openShiftBearerToken, ns, contentRepositoryService)
+	if err != nil {
+		return err
+	}
+	if state <= model.PodIdled {
+		repo.idled = true
+		return nil
+	}
+
+	held, err := lock.Default.Lock(idler.openShiftAPI, idler.openShiftBearerToken, ns)
+	if err != nil {
+		return err
+	}
+	defer held.Unlock()
+	if held.Contended {
+		return nil
+	}
+
+	reason := fmt.Sprintf("No requests to the content-repository for %v", idle.Round(time.Minute))
+	idler.logger.Infof("Idling %s: %s", contentRepositoryService, reason)
+	if err := idler.openShiftClient.Idle(idler.openShiftAPI, idler.openShiftBearerToken, ns, contentRepositoryService); err != nil {
+		return err
+	}
+	repo.idled = true
+	RecordProvenance(idler.openShiftClient, idler.openShiftAPI, idler.openShiftBearerToken, ns, contentRepositoryService, model.Provenance{
+		Action:      model.IdleAction,
+		TriggeredBy: model.TriggeredByIdler,
+		Reason:      reason,
+		Timestamp:   now,
+	})
+	return nil
+}
+
+// queryInterval returns the longest time between two checks of the user idler as PromQL duration, i.e. its check
+// interval shifted by the full jitter.
+func (idler *UserIdler) queryInterval() string {
+	interval := idler.CheckInterval()
+	interval += interval * time.Duration(idler.config.GetCheckJitter()) / 100
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	return fmt.Sprintf("%ds", int64(interval/time.Second))
+}
@@ -0,0 +1,96 @@
+package idler
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_content_repository_idled_on_its_own(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	requests := "0"
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `increase(repository_requests{namespace="john-jenkins"}[1080s])`, r.URL.Query().Get("query"))
+		fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {}, "value": [1523434035.5, "%s"]}]}}`, requests)
+	}))
+	defer prometheus.Close()
+
+	start := time.Date(2018, 6, 4, 10, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	config := &mock.Config{
+		MaxRetries:                 5,
+		PrometheusURL:              prometheus.URL,
+		ContentRepositoryIdleAfter: 60,
+		ContentRepositoryQuery:     `increase(repository_requests{namespace="{{namespace}}"}[{{interval}}])`,
+		CheckInterval:              15,
+		CheckJitter:                20,
+	}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	userIdler := NewUserIdler(
+		model.User{ID: "42", Name: "john"}, "https://api.example.com/", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		fake,
+	)
+	userIdler.openShiftClient = openShiftClient
+
+	assert.NoError(t, userIdler.checkContentRepository())
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "The content-repository should not be idled right after the start.")
+
+	fake.Advance(30 * time.Minute)
+	requests = "1.5"
+	assert.NoError(t, userIdler.checkContentRepository())
+	lastRequest, ok := userIdler.ContentRepositoryActivity()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(30*time.Minute), lastRequest)
+
+	fake.Advance(45 * time.Minute)
+	requests = "0"
+	assert.NoError(t, userIdler.checkContentRepository())
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "The content-repository should not be idled within its idle timeout.")
+
+	fake.Advance(15 * time.Minute)
+	assert.NoError(t, userIdler.checkContentRepository())
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "The content-repository should be idled after its idle timeout.")
+
+	assert.NoError(t, userIdler.checkContentRepository())
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "The content-repository should be idled only once.")
+}
+
+func Test_content_repository_services(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	services := JenkinsServices
+	defer func() { JenkinsServices = services }()
+
+	userIdler := NewUserIdler(
+		model.User{ID: "42", Name: "john"}, "", "", &mock.Config{},
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	JenkinsServices = []string{"jenkins", "content-repository"}
+	assert.Equal(t, JenkinsServices, userIdler.servicesToIdle(), "Without tracking, the content-repository goes along with Jenkins")
+	assert.Equal(t, JenkinsServices, userIdler.servicesToUnIdle(), "Without tracking, the content-repository goes along with Jenkins")
+	_, ok := userIdler.ContentRepositoryActivity()
+	assert.False(t, ok)
+
+	userIdler.contentRepository = &contentRepository{}
+	assert.Equal(t, []string{"jenkins"}, userIdler.servicesToIdle())
+	assert.Equal(t, []string{"jenkins", "content-repository"}, userIdler.servicesToUnIdle())
+
+	JenkinsServices = []string{"jenkins"}
+	assert.Equal(t, []string{"jenkins"}, userIdler.servicesToIdle())
+	assert.Equal(t, []string{"jenkins", "content-repository"}, userIdler.servicesToUnIdle())
+	assert.Equal(t, []string{"jenkins"}, JenkinsServices, "The services should not be modified")
+}
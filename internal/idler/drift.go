@@ -0,0 +1,63 @@
+package idler
+
+import (
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/sirupsen/logrus"
+)
+
+var auditLogger = logrus.WithField("component", "audit")
+
+// drifted returns whether the observed state of the Jenkins pod contradicts the settled state believed by the
+// idler. Transitional states are left alone, as they are expected to lag behind the pod.
+func drifted(believed State, observed model.PodState) bool {
+	switch believed {
+	case StateRunning:
+		return observed == model.PodIdled
+	case StateIdled:
+		return observed == model.PodStarting || observed == model.PodRunning
+	}
+	return false
+}
+
+// checkDrift compares the state believed by the idler with the replica count of the Jenkins deployment config.
+// If they diverged, e.g. since Jenkins got idled via `oc idle` or scaled up via `oc scale` and the watch event got
+// missed, the divergence is audited and recorded and the believed state is corrected.
+func (idler *UserIdler) checkDrift() error {
+	believed := idler.State()
+	if believed != StateRunning && believed != StateIdled {
+		return nil
+	}
+
+	observed, err := idler.getJenkinsState()
+	if err != nil {
+		return err
+	}
+	if !drifted(believed, observed) {
+		return nil
+	}
+
+	ns := namespace.Jenkins(idler.user.Name)
+	idler.Observe(observed)
+	corrected := idler.State()
+	Recorder.RecordStateDrift(idler.openShiftAPI, string(believed), string(corrected))
+
+	log := auditLogger.WithFields(logrus.Fields{
+		"namespace": ns,
+		"cluster":   idler.openShiftAPI,
+		"believed":  believed,
+		"observed":  corrected,
+	})
+	if observed != model.PodIdled {
+		log.Warn("Jenkins got started outside of the idler, correcting its state")
+		return nil
+	}
+
+	idledAt, err := idler.openShiftClient.EndpointsIdledAt(idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName)
+	if err == nil && !idledAt.IsZero() {
+		log.WithField("idled_at", idledAt).Warn("Jenkins got idled outside of the idler, e.g. via oc idle, correcting its state")
+	} else {
+		log.Warn("Jenkins got scaled down outside of the idler, correcting its state")
+	}
+	return nil
+}
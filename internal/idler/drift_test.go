@@ -0,0 +1,55 @@
+package idler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_drifted(t *testing.T) {
+	assert.True(t, drifted(StateRunning, model.PodIdled))
+	assert.True(t, drifted(StateIdled, model.PodRunning))
+	assert.True(t, drifted(StateIdled, model.PodStarting))
+	assert.False(t, drifted(StateRunning, model.PodRunning))
+	assert.False(t, drifted(StateIdling, model.PodRunning), "Transitional states should be expected to lag behind")
+	assert.False(t, drifted(StateUnIdling, model.PodIdled), "Transitional states should be expected to lag behind")
+}
+
+func Test_drift_corrected(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	logger := auditLogger
+	auditLogger = testLogger.WithField("component", "audit")
+	defer func() { auditLogger = logger }()
+
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	userIdler := NewUserIdler(
+		model.User{ID: "42", Name: "john"}, "https://api.example.com/", "", &mock.Config{},
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+	userIdler.Observe(model.PodRunning)
+
+	require.NoError(t, userIdler.checkDrift())
+	assert.Equal(t, StateRunning, userIdler.State())
+	assert.Empty(t, hook.Entries, "No drift should be audited while the states agree")
+
+	openShiftClient.IdleState = model.PodIdled
+	openShiftClient.EndpointsIdled = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	require.NoError(t, userIdler.checkDrift())
+	assert.Equal(t, StateIdled, userIdler.State(), "Believed state should be corrected")
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, "oc idle")
+	assert.Equal(t, "john-jenkins", hook.LastEntry().Data["namespace"])
+	assert.Equal(t, StateRunning, hook.LastEntry().Data["believed"])
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Correcting the state should not idle Jenkins")
+}
@@ -0,0 +1,76 @@
+package idler
+
+import (
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/sirupsen/logrus"
+)
+
+// quietDownPollInterval is how often Jenkins in quiet-down mode is checked for having finished its running jobs.
+const quietDownPollInterval = time.Minute
+
+// quietingDown returns whether Jenkins got put in quiet-down mode in order to be idled once its running jobs finished.
+func (idler *UserIdler) quietingDown() bool {
+	return !idler.quietDownSince.IsZero()
+}
+
+// quietDown lets the running jobs of Jenkins finish before it gets idled, provided JC_QUIET_DOWN_TIMEOUT is set. It
+// returns whether Jenkins is to be idled now, i.e. runs no jobs or exceeded the timeout. Otherwise, Jenkins is put in
+// quiet-down mode, so that it does not start queued jobs, and checked again after the poll interval. If Jenkins cannot
+// be asked for its jobs, it is idled right away.
+func (idler *UserIdler) quietDown(log *logrus.Entry) bool {
+	timeout := time.Duration(idler.config.GetQuietDownTimeout()) * time.Minute
+	if timeout <= 0 {
+		return true
+	}
+
+	ns := namespace.Jenkins(idler.user.Name)
+	busy, err := idler.openShiftClient.BusyExecutors(idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName)
+	if err != nil {
+		log.Warnf("Unable to determine the running jobs of Jenkins, idling anyway: %s", err)
+		return true
+	}
+	if busy == 0 {
+		return true
+	}
+
+	now := idler.clock.Now()
+	if !idler.quietingDown() {
+		if err := idler.openShiftClient.QuietDown(idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName); err != nil {
+			log.Warnf("Unable to put Jenkins in quiet-down mode, idling anyway: %s", err)
+			return true
+		}
+		log.Infof("Put Jenkins in quiet-down mode to let %d running jobs finish within %v before idling", busy, timeout)
+		idler.quietDownSince = now
+	}
+
+	if now.Sub(idler.quietDownSince) >= timeout {
+		log.Warnf("Idling Jenkins with %d jobs still running after %v in quiet-down mode", busy, timeout)
+		return true
+	}
+
+	idler.quietDownPoll = idler.clock.After(quietDownPollInterval)
+	return false
+}
+
+// cancelAbandonedQuietDown ends the quiet-down mode of Jenkins if it is neither waiting for its running jobs nor got
+// idled anymore, e.g. since the namespace got reserved meanwhile, so that Jenkins starts queued jobs again.
+func (idler *UserIdler) cancelAbandonedQuietDown() {
+	if !idler.quietingDown() || idler.quietDownPoll != nil {
+		return
+	}
+	idler.cancelQuietDown("it is not going to be idled")
+}
+
+// cancelQuietDown ends the quiet-down mode of Jenkins for the given reason.
+func (idler *UserIdler) cancelQuietDown(reason string) {
+	idler.quietDownSince = time.Time{}
+	idler.quietDownPoll = nil
+	ns := namespace.Jenkins(idler.user.Name)
+	if err := idler.openShiftClient.CancelQuietDown(idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName); err != nil {
+		idler.logger.Warnf("Unable to cancel the quiet-down mode of Jenkins: %s", err)
+		return
+	}
+	idler.logger.Infof("Cancelled the quiet-down mode of Jenkins as %s", reason)
+}
@@ -0,0 +1,113 @@
+package idler
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuietDownIdler(t *testing.T, timeout int, jobs int) (*UserIdler, *mock.OpenShiftClient, *clock.Fake) {
+	log.SetOutput(ioutil.Discard)
+
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning, RunningJobs: jobs}
+	fake := clock.NewFake(time.Date(2018, 6, 4, 10, 0, 0, 0, time.UTC))
+	userIdler := NewUserIdler(
+		model.User{ID: "42", Name: "john"}, "https://api.example.com/", "",
+		&mock.Config{MaxRetries: 5, QuietDownTimeout: timeout},
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		fake,
+	)
+	userIdler.openShiftClient = openShiftClient
+	return userIdler, openShiftClient, fake
+}
+
+func Test_quiet_down_disabled_idles_right_away(t *testing.T) {
+	userIdler, openShiftClient, _ := newQuietDownIdler(t, 0, 2)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled right away.")
+	assert.Equal(t, 0, openShiftClient.QuietDownCount, "Jenkins should not be put in quiet-down mode.")
+}
+
+func Test_quiet_down_without_running_jobs_idles_right_away(t *testing.T) {
+	userIdler, openShiftClient, _ := newQuietDownIdler(t, 30, 0)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled right away.")
+	assert.Equal(t, 0, openShiftClient.QuietDownCount, "Jenkins should not be put in quiet-down mode.")
+}
+
+func Test_quiet_down_waits_for_running_jobs(t *testing.T) {
+	userIdler, openShiftClient, _ := newQuietDownIdler(t, 30, 2)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while jobs are running.")
+	assert.Equal(t, 1, openShiftClient.QuietDownCount, "Jenkins should be put in quiet-down mode.")
+	assert.NotNil(t, userIdler.quietDownPoll, "Jenkins should be checked again.")
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while jobs are running.")
+	assert.Equal(t, 1, openShiftClient.QuietDownCount, "Jenkins should be put in quiet-down mode only once.")
+
+	openShiftClient.RunningJobs = 0
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once its jobs finished.")
+	assert.True(t, openShiftClient.QuietingDown, "The quiet-down mode ends with the idle.")
+	assert.False(t, userIdler.quietingDown())
+	assert.Nil(t, userIdler.quietDownPoll)
+}
+
+func Test_quiet_down_gets_cancelled_by_new_build(t *testing.T) {
+	userIdler, openShiftClient, fake := newQuietDownIdler(t, 30, 2)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	require.True(t, openShiftClient.QuietingDown, "Jenkins should be put in quiet-down mode.")
+
+	userIdler.user.ActiveBuild = model.Build{
+		Metadata: model.Metadata{Name: "build-1"},
+		Status:   model.Status{Phase: "New", StartTimestamp: model.BuildTime{Time: fake.Now()}},
+	}
+	openShiftClient.RunningJobs = 0
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled once a build arrived.")
+	assert.False(t, openShiftClient.QuietingDown, "The quiet-down mode should be cancelled.")
+	assert.False(t, userIdler.quietingDown())
+	assert.Nil(t, userIdler.quietDownPoll)
+}
+
+func Test_quiet_down_times_out(t *testing.T) {
+	userIdler, openShiftClient, fake := newQuietDownIdler(t, 30, 2)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while jobs are running.")
+
+	fake.Advance(30 * time.Minute)
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once the timeout passed.")
+}
+
+func Test_quiet_down_gets_cancelled_if_idle_is_abandoned(t *testing.T) {
+	reservations := Reservations
+	Reservations = NewReservationStore(clock.New())
+	defer func() { Reservations = reservations }()
+
+	userIdler, openShiftClient, _ := newQuietDownIdler(t, 30, 2)
+
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	require.True(t, openShiftClient.QuietingDown, "Jenkins should be put in quiet-down mode.")
+
+	_, err := Reservations.Reserve("john-jenkins", time.Minute)
+	require.NoError(t, err)
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while the namespace is reserved.")
+	assert.False(t, openShiftClient.QuietingDown, "The quiet-down mode should be cancelled.")
+	assert.False(t, userIdler.quietingDown())
+}
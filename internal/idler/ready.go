@@ -0,0 +1,57 @@
+package idler
+
+import (
+	"sync"
+	"time"
+)
+
+// maxReadySamples limits the number of past un-idle durations kept per namespace.
+const maxReadySamples = 10
+
+// readyHistory tracks how long the Jenkins of a user took to become ready after un-idling, in order to estimate
+// how long a Jenkins which is currently starting will take.
+type readyHistory struct {
+	sync.Mutex
+	samples       []time.Duration
+	unIdlingSince time.Time
+}
+
+// observe records the start resp. the end of an un-idling from the given transition.
+func (h *readyHistory) observe(t Transition, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+
+	switch {
+	case t.To == StateUnIdling:
+		h.unIdlingSince = now
+	case t.From == StateUnIdling && t.To == StateRunning && !h.unIdlingSince.IsZero():
+		h.samples = append(h.samples, now.Sub(h.unIdlingSince))
+		if len(h.samples) > maxReadySamples {
+			h.samples = h.samples[len(h.samples)-maxReadySamples:]
+		}
+		h.unIdlingSince = time.Time{}
+	case t.From == StateUnIdling:
+		h.unIdlingSince = time.Time{}
+	}
+}
+
+// estimate returns how long the current un-idling is expected to take from now on, based on the average of the
+// past un-idle durations. It returns false if Jenkins is not un-idling or there are no past un-idle durations.
+func (h *readyHistory) estimate(now time.Time) (time.Duration, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.unIdlingSince.IsZero() || len(h.samples) == 0 {
+		return 0, false
+	}
+
+	var total time.Duration
+	for _, sample := range h.samples {
+		total += sample
+	}
+	remaining := total/time.Duration(len(h.samples)) - now.Sub(h.unIdlingSince)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
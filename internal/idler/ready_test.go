@@ -0,0 +1,41 @@
+package idler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ready_estimate(t *testing.T) {
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	h := &readyHistory{}
+
+	h.observe(Transition{From: StateIdled, To: StateUnIdling}, now)
+	_, ok := h.estimate(now)
+	assert.False(t, ok, "No estimate expected without past un-idle durations")
+
+	h.observe(Transition{From: StateUnIdling, To: StateRunning}, now.Add(2*time.Minute))
+	h.observe(Transition{From: StateIdled, To: StateUnIdling}, now.Add(time.Hour))
+	h.observe(Transition{From: StateUnIdling, To: StateRunning}, now.Add(time.Hour+4*time.Minute))
+	_, ok = h.estimate(now.Add(2 * time.Hour))
+	assert.False(t, ok, "No estimate expected while Jenkins is not un-idling")
+
+	h.observe(Transition{From: StateIdled, To: StateUnIdling}, now.Add(2*time.Hour))
+	remaining, ok := h.estimate(now.Add(2*time.Hour + time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, remaining, "The average un-idle duration of 3m should be used")
+
+	remaining, _ = h.estimate(now.Add(3 * time.Hour))
+	assert.Equal(t, time.Duration(0), remaining, "An overdue un-idling should be expected to be ready any moment")
+}
+
+func Test_ready_samples_are_capped(t *testing.T) {
+	now := time.Now()
+	h := &readyHistory{}
+	for i := 0; i < 2*maxReadySamples; i++ {
+		h.observe(Transition{From: StateIdled, To: StateUnIdling}, now)
+		h.observe(Transition{From: StateUnIdling, To: StateRunning}, now.Add(time.Minute))
+	}
+	assert.Len(t, h.samples, maxReadySamples)
+}
@@ -0,0 +1,117 @@
+package idler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+)
+
+// reservationTokenBytes is the number of random bytes of a reservation token.
+const reservationTokenBytes = 16
+
+// Reservations are the un-idle reservations of all Jenkins namespaces, consulted before a user idler idles Jenkins.
+var Reservations = NewReservationStore(clock.New())
+
+// Reservation keeps Jenkins of a namespace from being idled until it expires or gets released, e.g. while the
+// Jenkins Proxy replays the webhooks it buffered while Jenkins was idled.
+type Reservation struct {
+	Namespace string    `json:"namespace"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ReservationStore keeps the un-idle reservations per Jenkins namespace. A namespace may have several reservations,
+// each identified by its token. Expired reservations are dropped on access.
+type ReservationStore struct {
+	sync.Mutex
+	clock        clock.Clock
+	reservations map[string]map[string]time.Time
+}
+
+// NewReservationStore creates a ReservationStore without reservations.
+func NewReservationStore(clock clock.Clock) *ReservationStore {
+	return &ReservationStore{
+		clock:        clock,
+		reservations: make(map[string]map[string]time.Time),
+	}
+}
+
+// Reserve creates a reservation of the namespace expiring after the given duration.
+func (s *ReservationStore) Reserve(namespace string, ttl time.Duration) (Reservation, error) {
+	b := make([]byte, reservationTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return Reservation{}, err
+	}
+	token := hex.EncodeToString(b)
+
+	s.Lock()
+	defer s.Unlock()
+
+	expiresAt := s.clock.Now().UTC().Add(ttl)
+	if s.reservations[namespace] == nil {
+		s.reservations[namespace] = make(map[string]time.Time)
+	}
+	s.reservations[namespace][token] = expiresAt
+	return Reservation{Namespace: namespace, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Get returns the reservation of the namespace with the given token, false if it does not exist or expired.
+func (s *ReservationStore) Get(namespace, token string) (Reservation, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.prune(namespace)
+	expiresAt, ok := s.reservations[namespace][token]
+	if !ok {
+		return Reservation{}, false
+	}
+	return Reservation{Namespace: namespace, Token: token, ExpiresAt: expiresAt}, true
+}
+
+// Release removes the reservation of the namespace with the given token. It returns false if it does not exist or
+// expired.
+func (s *ReservationStore) Release(namespace, token string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	s.prune(namespace)
+	if _, ok := s.reservations[namespace][token]; !ok {
+		return false
+	}
+	delete(s.reservations[namespace], token)
+	if len(s.reservations[namespace]) == 0 {
+		delete(s.reservations, namespace)
+	}
+	return true
+}
+
+// Reserved returns until when the namespace is reserved, false if it has no unexpired reservation.
+func (s *ReservationStore) Reserved(namespace string) (time.Time, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.prune(namespace)
+	var until time.Time
+	for _, expiresAt := range s.reservations[namespace] {
+		if expiresAt.After(until) {
+			until = expiresAt
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// prune drops the expired reservations of the namespace. It needs to be called with the lock held.
+func (s *ReservationStore) prune(namespace string) {
+	now := s.clock.Now()
+	for token, expiresAt := range s.reservations[namespace] {
+		if !now.Before(expiresAt) {
+			delete(s.reservations[namespace], token)
+		}
+	}
+	if len(s.reservations[namespace]) == 0 {
+		delete(s.reservations, namespace)
+	}
+}
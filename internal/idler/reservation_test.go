@@ -0,0 +1,48 @@
+package idler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_reservations(t *testing.T) {
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	c := clock.NewFake(now)
+	s := NewReservationStore(c)
+
+	_, ok := s.Reserved("john-jenkins")
+	assert.False(t, ok, "Namespace should not be reserved initially")
+
+	first, err := s.Reserve("john-jenkins", 5*time.Minute)
+	require.NoError(t, err)
+	assert.Len(t, first.Token, 2*reservationTokenBytes)
+	assert.Equal(t, now.Add(5*time.Minute), first.ExpiresAt)
+
+	c.Advance(time.Minute)
+	second, err := s.Reserve("john-jenkins", 5*time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Token, second.Token, "Tokens should be unique")
+
+	until, ok := s.Reserved("john-jenkins")
+	assert.True(t, ok)
+	assert.Equal(t, second.ExpiresAt, until, "Namespace should be reserved until the last reservation expires")
+
+	_, ok = s.Get("jane-jenkins", first.Token)
+	assert.False(t, ok, "Token should be bound to its namespace")
+	got, ok := s.Get("john-jenkins", first.Token)
+	assert.True(t, ok)
+	assert.Equal(t, first, got)
+
+	assert.True(t, s.Release("john-jenkins", second.Token))
+	assert.False(t, s.Release("john-jenkins", second.Token), "Reservation should be released once only")
+
+	c.Advance(4 * time.Minute)
+	_, ok = s.Get("john-jenkins", first.Token)
+	assert.False(t, ok, "Reservation should expire")
+	_, ok = s.Reserved("john-jenkins")
+	assert.False(t, ok, "Namespace should no longer be reserved")
+}
@@ -0,0 +1,104 @@
+package idler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+// idleOrder ranks the services which depend on each other in the order they get idled. They get un-idled in
+// reverse order. Services without a rank are idled last resp. un-idled first.
+var idleOrder = map[string]int{
+	"content-repository": 0,
+	"jenkins":            1,
+}
+
+// ServiceResult is the outcome of idling resp. un-idling a single service.
+type ServiceResult struct {
+	Service string `json:"service"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ServiceResults are the outcomes of idling resp. un-idling a set of services, in the order the services were
+// processed.
+type ServiceResults []ServiceResult
+
+// Err returns the errors of all failed services bundled in a single error, or nil if all services succeeded.
+func (r ServiceResults) Err() error {
+	var errs util.MultiError
+	for _, result := range r {
+		if result.Error != "" {
+			errs.Collect(fmt.Errorf("%s", result.Error))
+		}
+	}
+	return errs.ToError()
+}
+
+// Failed returns the names of the services which could not be idled resp. un-idled.
+func (r ServiceResults) Failed() []string {
+	var failed []string
+	for _, result := range r {
+		if result.Error != "" {
+			failed = append(failed, result.Service)
+		}
+	}
+	return failed
+}
+
+// IdleServices applies the idle operation to the given services in idle order. A failure of one service does not
+// abort idling the remaining services.
+func IdleServices(services []string, idle func(service string) error) ServiceResults {
+	return apply(sortServices(services, false), idle)
+}
+
+// UnIdleServices applies the un-idle operation to the given services in reverse idle order. A failure of one service
+// does not abort un-idling the remaining services.
+func UnIdleServices(services []string, unIdle func(service string) error) ServiceResults {
+	return apply(sortServices(services, true), unIdle)
+}
+
+func apply(services []string, operation func(service string) error) ServiceResults {
+	results := make(ServiceResults, 0, len(services))
+	for _, service := range services {
+		result := ServiceResult{Service: service}
+		if err := operation(service); err != nil {
+			result.Error = redact.String(err.Error())
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// sortServices returns a copy of the given services sorted in idle order, resp. reverse idle order.
+func sortServices(services []string, reverse bool) []string {
+	rank := func(service string) int {
+		if r, ok := idleOrder[service]; ok {
+			return r
+		}
+		return len(idleOrder)
+	}
+
+	sorted := append([]string(nil), services...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if reverse {
+			return rank(sorted[i]) > rank(sorted[j])
+		}
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+	return sorted
+}
+
+// RecordProvenance annotates the deployment config of the service with the provenance of its idle resp. un-idle. As
+// the annotations are informational only, a failure is logged instead of failing the operation.
+func RecordProvenance(oc client.OpenShiftClient, apiURL, bearerToken, namespace, service string, provenance model.Provenance) {
+	err := oc.Annotate(apiURL, bearerToken, namespace, service, provenance.Annotations())
+	if err != nil {
+		logger.WithFields(logrus.Fields{"namespace": namespace, "service": service, "err": err}).Warnf(
+			"Unable to record the provenance of the %s", provenance.Action)
+	}
+}
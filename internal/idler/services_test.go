@@ -0,0 +1,47 @@
+package idler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_services_are_idled_in_order(t *testing.T) {
+	services := []string{"jenkins", "content-repository"}
+
+	var idled []string
+	results := IdleServices(services, func(service string) error {
+		idled = append(idled, service)
+		return nil
+	})
+	assert.Equal(t, []string{"content-repository", "jenkins"}, idled)
+	assert.NoError(t, results.Err())
+
+	var unIdled []string
+	UnIdleServices(services, func(service string) error {
+		unIdled = append(unIdled, service)
+		return nil
+	})
+	assert.Equal(t, []string{"jenkins", "content-repository"}, unIdled)
+	assert.Equal(t, []string{"jenkins", "content-repository"}, services, "The given services should not be reordered")
+}
+
+func Test_services_continue_past_failures(t *testing.T) {
+	var idled []string
+	results := IdleServices([]string{"content-repository", "jenkins"}, func(service string) error {
+		idled = append(idled, service)
+		if service == "content-repository" {
+			return errors.New("scale down failed")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"content-repository", "jenkins"}, idled, "Jenkins should be idled despite the failure")
+	assert.Equal(t, ServiceResults{
+		{Service: "content-repository", Error: "scale down failed"},
+		{Service: "jenkins"},
+	}, results)
+	assert.Equal(t, []string{"content-repository"}, results.Failed())
+	assert.EqualError(t, results.Err(), "scale down failed")
+}
@@ -0,0 +1,174 @@
+package idler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+)
+
+// State is the state of the Jenkins instance of a user as tracked by its UserIdler.
+type State string
+
+const (
+	// StateUnknown is the initial state, before the Jenkins pod got observed.
+	StateUnknown State = "unknown"
+	// StateRunning means Jenkins is up and running.
+	StateRunning State = "running"
+	// StateIdling means Jenkins got requested to idle, but is not observed to be idled yet.
+	StateIdling State = "idling"
+	// StateIdled means Jenkins is idled.
+	StateIdled State = "idled"
+	// StateUnIdling means Jenkins got requested to un-idle resp. is starting, but is not observed to be running yet.
+	StateUnIdling State = "unidling"
+	// StateError means the last attempt to determine the state of Jenkins or to (un-)idle it failed.
+	StateError State = "error"
+)
+
+// Event triggers a state transition.
+type Event string
+
+const (
+	// EventPodIdled is fired when the Jenkins pod is observed to be idled.
+	EventPodIdled Event = "pod_idled"
+	// EventPodStarting is fired when the Jenkins pod is observed to be starting.
+	EventPodStarting Event = "pod_starting"
+	// EventPodRunning is fired when the Jenkins pod is observed to be running.
+	EventPodRunning Event = "pod_running"
+	// EventIdleRequested is fired when Jenkins got successfully requested to idle.
+	EventIdleRequested Event = "idle_requested"
+	// EventUnIdleRequested is fired when Jenkins got successfully requested to un-idle.
+	EventUnIdleRequested Event = "unidle_requested"
+	// EventFailed is fired when determining the state of Jenkins or (un-)idling it failed.
+	EventFailed Event = "failed"
+)
+
+// TransitionTable maps a state and an event to the state to transition to. Events not listed for a state are
+// not allowed in that state.
+type TransitionTable map[State]map[Event]State
+
+// Transitions is the transition table of the UserIdler.
+//
+// While idling, Jenkins can still be observed running, since the deployment config takes a while to scale down.
+// Likewise Jenkins can still be observed idled while un-idling. Those observations do not change the state.
+var Transitions = TransitionTable{
+	StateUnknown: {
+		EventPodIdled:    StateIdled,
+		EventPodStarting: StateUnIdling,
+		EventPodRunning:  StateRunning,
+		EventFailed:      StateError,
+	},
+	StateRunning: {
+		EventPodIdled:      StateIdled,
+		EventPodStarting:   StateUnIdling,
+		EventPodRunning:    StateRunning,
+		EventIdleRequested: StateIdling,
+		EventFailed:        StateError,
+	},
+	StateIdling: {
+		EventPodIdled:        StateIdled,
+		EventPodStarting:     StateUnIdling,
+		EventPodRunning:      StateIdling,
+		EventIdleRequested:   StateIdling,
+		EventUnIdleRequested: StateUnIdling,
+		EventFailed:          StateError,
+	},
+	StateIdled: {
+		EventPodIdled:        StateIdled,
+		EventPodStarting:     StateUnIdling,
+		EventPodRunning:      StateRunning,
+		EventUnIdleRequested: StateUnIdling,
+		EventFailed:          StateError,
+	},
+	StateUnIdling: {
+		EventPodIdled:        StateUnIdling,
+		EventPodStarting:     StateUnIdling,
+		EventPodRunning:      StateRunning,
+		EventIdleRequested:   StateIdling,
+		EventUnIdleRequested: StateUnIdling,
+		EventFailed:          StateError,
+	},
+	StateError: {
+		EventPodIdled:        StateIdled,
+		EventPodStarting:     StateUnIdling,
+		EventPodRunning:      StateRunning,
+		EventIdleRequested:   StateIdling,
+		EventUnIdleRequested: StateUnIdling,
+		EventFailed:          StateError,
+	},
+}
+
+// podEvents maps the observed state of the Jenkins pod to the event to fire.
+var podEvents = map[model.PodState]Event{
+	model.PodIdled:    EventPodIdled,
+	model.PodStarting: EventPodStarting,
+	model.PodRunning:  EventPodRunning,
+
+	model.PodCrashLooping:    EventFailed,
+	model.PodImagePullFailed: EventFailed,
+	model.PodUnschedulable:   EventFailed,
+	model.PodQuotaExceeded:   EventFailed,
+}
+
+// Transition describes a change from one state to another caused by an event.
+type Transition struct {
+	From  State
+	To    State
+	Event Event
+}
+
+// TransitionHook gets called after each transition which changed the state.
+type TransitionHook func(t Transition)
+
+// StateMachine keeps track of the current state and moves between states according to a transition table.
+type StateMachine struct {
+	sync.RWMutex
+	state State
+	table TransitionTable
+	hooks []TransitionHook
+}
+
+// NewStateMachine creates a StateMachine starting in the given state.
+func NewStateMachine(initial State, table TransitionTable) *StateMachine {
+	return &StateMachine{
+		state: initial,
+		table: table,
+	}
+}
+
+// State returns the current state.
+func (m *StateMachine) State() State {
+	m.RLock()
+	defer m.RUnlock()
+	return m.state
+}
+
+// OnTransition registers a hook which gets called after each transition changing the state.
+func (m *StateMachine) OnTransition(hook TransitionHook) {
+	m.Lock()
+	defer m.Unlock()
+	m.hooks = append(m.hooks, hook)
+}
+
+// Fire moves to the state the transition table lists for the current state and the given event. An error
+// is returned and the state is kept if the event is not allowed in the current state.
+func (m *StateMachine) Fire(event Event) (Transition, error) {
+	m.Lock()
+	from := m.state
+	to, ok := m.table[from][event]
+	if !ok {
+		m.Unlock()
+		return Transition{From: from, To: from, Event: event}, fmt.Errorf("event %s is not allowed in state %s", event, from)
+	}
+	m.state = to
+	hooks := m.hooks
+	m.Unlock()
+
+	t := Transition{From: from, To: to, Event: event}
+	if from != to {
+		for _, hook := range hooks {
+			hook(t)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,82 @@
+package idler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_state_transitions(t *testing.T) {
+	var transitions = []struct {
+		from  State
+		event Event
+		to    State
+	}{
+		{StateUnknown, EventPodRunning, StateRunning},
+		{StateUnknown, EventPodIdled, StateIdled},
+		{StateRunning, EventIdleRequested, StateIdling},
+		{StateIdling, EventPodRunning, StateIdling},
+		{StateIdling, EventPodIdled, StateIdled},
+		{StateIdled, EventUnIdleRequested, StateUnIdling},
+		{StateUnIdling, EventPodIdled, StateUnIdling},
+		{StateUnIdling, EventPodStarting, StateUnIdling},
+		{StateUnIdling, EventPodRunning, StateRunning},
+		{StateRunning, EventFailed, StateError},
+		{StateError, EventPodIdled, StateIdled},
+	}
+
+	for _, transition := range transitions {
+		m := NewStateMachine(transition.from, Transitions)
+		tr, err := m.Fire(transition.event)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Transition{From: transition.from, To: transition.to, Event: transition.event}, tr)
+		assert.Equal(t, transition.to, m.State(), "Unexpected state after %s in %s", transition.event, transition.from)
+	}
+}
+
+func Test_invalid_transitions_keep_state(t *testing.T) {
+	var invalid = []struct {
+		from  State
+		event Event
+	}{
+		{StateUnknown, EventIdleRequested},
+		{StateUnknown, EventUnIdleRequested},
+		{StateRunning, EventUnIdleRequested},
+		{StateIdled, EventIdleRequested},
+	}
+
+	for _, transition := range invalid {
+		m := NewStateMachine(transition.from, Transitions)
+		_, err := m.Fire(transition.event)
+
+		assert.Error(t, err, "%s should not be allowed in %s", transition.event, transition.from)
+		assert.Equal(t, transition.from, m.State())
+	}
+}
+
+func Test_every_state_handles_failures_and_observations(t *testing.T) {
+	for state, events := range Transitions {
+		for _, event := range []Event{EventFailed, EventPodIdled, EventPodStarting, EventPodRunning} {
+			assert.Contains(t, events, event, "State %s should handle %s", state, event)
+		}
+	}
+}
+
+func Test_hooks_are_called_on_state_change(t *testing.T) {
+	m := NewStateMachine(StateRunning, Transitions)
+	var calls []Transition
+	m.OnTransition(func(t Transition) {
+		calls = append(calls, t)
+	})
+
+	m.Fire(EventPodRunning)
+	m.Fire(EventIdleRequested)
+	m.Fire(EventIdleRequested)
+	m.Fire(EventPodIdled)
+
+	assert.Equal(t, []Transition{
+		{From: StateRunning, To: StateIdling, Event: EventIdleRequested},
+		{From: StateIdling, To: StateIdled, Event: EventPodIdled},
+	}, calls)
+}
@@ -0,0 +1,84 @@
+package idler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+)
+
+// Throttle limits the rate of the idle operations on a cluster, so that a bug or a mass policy change cannot idle
+// thousands of Jenkins instances within seconds. Idle operations exceeding the rate are queued, i.e. delayed until
+// they are permitted.
+type Throttle struct {
+	sync.Mutex
+	cluster  string
+	interval time.Duration
+	next     time.Time
+	clock    clock.Clock
+}
+
+// NewThrottle creates a Throttle permitting perMinute idle operations per minute on the given cluster. A
+// non-positive perMinute permits any rate.
+func NewThrottle(cluster string, perMinute int, clock clock.Clock) *Throttle {
+	var interval time.Duration
+	if perMinute > 0 {
+		interval = time.Minute / time.Duration(perMinute)
+	}
+	return &Throttle{cluster: cluster, interval: interval, clock: clock}
+}
+
+// reserve reserves the next permitted slot and returns how long to wait for it.
+func (t *Throttle) reserve() time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.clock.Now()
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	t.next = slot.Add(t.interval)
+	return slot.Sub(now)
+}
+
+// Wait blocks until the next idle operation is permitted. It returns false if one of the given channels got closed
+// before, in which case the idle operation must not be performed. A nil Throttle permits any rate.
+func (t *Throttle) Wait(done, stop <-chan struct{}) bool {
+	timer := t.Queue()
+	if timer == nil {
+		return true
+	}
+	defer t.Dequeue(timer)
+
+	select {
+	case <-timer.C():
+		return true
+	case <-done:
+		return false
+	case <-stop:
+		return false
+	}
+}
+
+// Queue reserves the next permitted slot without blocking. It returns nil if the idle operation is permitted right
+// away, otherwise a timer firing once its slot arrived. A queued idle operation needs to be released via Dequeue,
+// whether it got performed or abandoned.
+func (t *Throttle) Queue() clock.Timer {
+	if t == nil || t.interval == 0 {
+		return nil
+	}
+
+	delay := t.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	Recorder.RecordIdleQueued(t.cluster)
+	return t.clock.NewTimer(delay)
+}
+
+// Dequeue releases the idle operation queued with the given timer.
+func (t *Throttle) Dequeue(timer clock.Timer) {
+	timer.Stop()
+	Recorder.RecordIdleDequeued(t.cluster)
+}
@@ -0,0 +1,50 @@
+package idler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_throttle_spaces_idle_operations(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	throttle := NewThrottle("https://api.example.com/", 2, c)
+
+	assert.True(t, throttle.Wait(nil, nil), "The first idle operation should be permitted immediately.")
+
+	permitted := make(chan bool)
+	go func() {
+		permitted <- throttle.Wait(nil, nil)
+	}()
+	c.BlockUntil(1)
+	c.Advance(29 * time.Second)
+	select {
+	case <-permitted:
+		t.Fatal("The second idle operation should be queued for 30s.")
+	default:
+	}
+	c.Advance(time.Second)
+	assert.True(t, <-permitted)
+}
+
+func Test_throttle_wait_aborted_on_stop(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	throttle := NewThrottle("https://api.example.com/", 1, c)
+	throttle.Wait(nil, nil)
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.False(t, throttle.Wait(nil, stop), "A queued idle operation should be abandoned once the idler stops.")
+}
+
+func Test_throttle_disabled(t *testing.T) {
+	var nilThrottle *Throttle
+	assert.True(t, nilThrottle.Wait(nil, nil))
+
+	throttle := NewThrottle("https://api.example.com/", 0, clock.NewFake(time.Now()))
+	for i := 0; i < 100; i++ {
+		assert.True(t, throttle.Wait(nil, nil))
+	}
+}
@@ -3,35 +3,62 @@ package idler
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/condition"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/lock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/notify"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/pressure"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/recovery"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
 	logrus "github.com/sirupsen/logrus"
 )
 
 var logger = logrus.WithField("component", "user-idler")
 
+// Recorder to capture the state transitions of the user idlers
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// Events publishes the state changes of the Jenkins instances of all user idlers.
+var Events = events.NewHub()
+
+// eventStates maps the states of the user idler to the states published as events, where they differ.
+var eventStates = map[State]string{
+	StateUnIdling: "starting",
+}
+
 // JenkinsServices is an array of all the services getting idled or unidled
 // they go along the main build detection logic of jenkins and don't have
-// any specific scenarios.
+// any specific scenarios. They get idled resp. un-idled in the order
+// defined by idleOrder, regardless of their order in this array.
 var JenkinsServices = []string{"jenkins"}
 
 const (
-	bufferSize             = 10
-	jenkinsNamespaceSuffix = "-jenkins"
-	jenkinsServiceName     = "jenkins"
+	bufferSize         = 10
+	jenkinsServiceName = "jenkins"
+	cheNamespaceSuffix = "-che"
+	// cheWorkspaceSelector selects the deployments of the Che workspaces in the che namespace
+	cheWorkspaceSelector = "che.workspace_id"
 )
 
 // UserIdler is created for each monitored user/namespace.
 // Each UserIdler runs in its own goroutine. The task of the UserIdler is to keep track
 // of the Jenkins instance of the user and idle resp. un-idle depending on the evaluation
-// of the given conditions for this UserIdler.
+// of the given conditions for this UserIdler. The state of the Jenkins instance is tracked
+// by a StateMachine following the Transitions table.
 type UserIdler struct {
 	openShiftAPI         string
 	openShiftBearerToken string
@@ -40,12 +67,32 @@ type UserIdler struct {
 	idleAttempts         int
 	unIdleAttempts       int
 	Conditions           *condition.Conditions
+	DisabledClusters     *model.StringSet
+	Throttle             *Throttle
 	logger               *logrus.Entry
 	userChan             chan model.User
 	user                 model.User
+	userLock             sync.RWMutex
 	config               configuration.Configuration
 	features             toggles.Features
 	tenantService        tenant.Service
+	machine              *StateMachine
+	clock                clock.Clock
+	remediator           *remediation.Remediator
+	random               func() float64
+	lastActivity         int64
+	checkInterval        int64
+	jenkinsVersion       atomic.Value
+	resources            atomic.Value
+	usage                atomic.Value
+	ready                readyHistory
+	quietDownSince       time.Time
+	quietDownPoll        <-chan time.Time
+	idleSlot             clock.Timer
+	idleSlotArrived      bool
+	contentRepository    *contentRepository
+	stop                 chan struct{}
+	stopOnce             sync.Once
 }
 
 // NewUserIdler creates an instance of UserIdler.
@@ -55,16 +102,19 @@ func NewUserIdler(
 	openShiftAPI, openShiftBearerToken string,
 	config configuration.Configuration,
 	features toggles.Features,
-	tenantService tenant.Service) *UserIdler {
+	tenantService tenant.Service,
+	clock clock.Clock) *UserIdler {
 
 	logEntry := logger.WithFields(logrus.Fields{
-		"name": user.Name,
-		"ns":   user.Name + jenkinsNamespaceSuffix,
-		"id":   user.ID,
+		"name":      user.Name,
+		"namespace": namespace.Jenkins(user.Name),
+		"cluster":   openShiftAPI,
+		"id":        user.ID,
 	})
 	logEntry.Info("UserIdler created.")
 
-	conditions := createWatchConditions(config.GetProxyURL(), config.GetIdleAfter(), config.GetIdleLongBuild(), logEntry)
+	conditions := createWatchConditions(config.GetProxyURL(), config.GetIdleAfter(), config.GetIdleLongBuild(),
+		config.GetManualUnIdleGracePeriod(), clock, logEntry)
 
 	userChan := make(chan model.User, bufferSize)
 
@@ -82,15 +132,129 @@ func NewUserIdler(
 		config:               config,
 		features:             features,
 		tenantService:        tenantService,
+		machine:              NewStateMachine(StateUnknown, Transitions),
+		clock:                clock,
+		remediator:           remediation.New(config, clock),
+		contentRepository:    newContentRepository(config, clock),
+		random:               rand.Float64,
+		lastActivity:         clock.Now().UnixNano(),
+		stop:                 make(chan struct{}),
+	}
+	if prometheusURL := config.GetPrometheusURL(); prometheusURL != "" {
+		conditions.Add("prometheus", condition.NewPrometheusCondition(
+			prometheusURL, config.GetActivityQuery(), config.GetActivityThreshold()))
+	}
+	if types := config.GetActivityNamespaceTypes(); len(types) > 0 {
+		conditions.Add("namespace-activity", condition.NewNamespaceActivityCondition(types, userIdler.runningPods))
 	}
+	if types := config.GetRolloutNamespaceTypes(); len(types) > 0 && config.GetRolloutHold() > 0 {
+		conditions.Add("rollout", condition.NewRolloutCondition(
+			types, time.Duration(config.GetRolloutHold())*time.Minute, clock, userIdler.rolloutsInProgress))
+	}
+	if patterns := config.GetHoldStages(); len(patterns) > 0 {
+		conditions.Add("stage", condition.NewStageCondition(
+			patterns, time.Duration(config.GetHoldStageMax())*time.Hour, clock))
+	}
+	userIdler.machine.OnTransition(userIdler.logTransition)
+	userIdler.machine.OnTransition(recordTransition(user.Variant))
+	userIdler.machine.OnTransition(userIdler.trackReadiness)
+	userIdler.machine.OnTransition(userIdler.publishTransition)
+	Recorder.RecordStateTransition("", string(StateUnknown), "", user.Variant)
 	return &userIdler
 }
 
+// runningPods returns the number of pods running in the given namespace of the user. Namespaces on other clusters
+// are not accessible with the token of this cluster and thus considered inactive.
+func (idler *UserIdler) runningPods(ns model.Namespace) (int, error) {
+	if ns.ClusterURL != "" && util.EnsureSuffix(ns.ClusterURL, "/") != util.EnsureSuffix(idler.openShiftAPI, "/") {
+		return 0, nil
+	}
+	return idler.openShiftClient.RunningPods(idler.openShiftAPI, idler.openShiftBearerToken, ns.Name)
+}
+
+// rolloutsInProgress returns the names of the deployment configs being rolled out in the given namespace of the user.
+// Namespaces on other clusters are not accessible with the token of this cluster and thus considered settled.
+func (idler *UserIdler) rolloutsInProgress(ns model.Namespace) ([]string, error) {
+	if ns.ClusterURL != "" && util.EnsureSuffix(ns.ClusterURL, "/") != util.EnsureSuffix(idler.openShiftAPI, "/") {
+		return nil, nil
+	}
+	return idler.openShiftClient.RolloutsInProgress(idler.openShiftAPI, idler.openShiftBearerToken, ns.Name)
+}
+
+// OpenShiftAPI returns the API URL of the cluster the Jenkins of the user runs on.
+func (idler *UserIdler) OpenShiftAPI() string {
+	return idler.openShiftAPI
+}
+
 // GetUser returns the model.User of this idler.
 func (idler *UserIdler) GetUser() model.User {
+	idler.userLock.RLock()
+	defer idler.userLock.RUnlock()
 	return idler.user
 }
 
+// updateUser applies the given change to the user. The user is only changed by the goroutine started by Run, which
+// hence reads it without locking, while GetUser is called concurrently, e.g. by the controller.
+func (idler *UserIdler) updateUser(change func(user *model.User)) {
+	idler.userLock.Lock()
+	defer idler.userLock.Unlock()
+	change(&idler.user)
+}
+
+// LastActivity returns when this idler last received user data, i.e. when the last event concerning the
+// namespace got observed.
+func (idler *UserIdler) LastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&idler.lastActivity))
+}
+
+// JenkinsVersion returns the version of the Jenkins of the user as last observed by a health probe, or the empty
+// string if it is not known yet.
+func (idler *UserIdler) JenkinsVersion() string {
+	version, _ := idler.jenkinsVersion.Load().(string)
+	return version
+}
+
+// SetJenkinsVersion records the version of the Jenkins of the user as observed by a health probe.
+func (idler *UserIdler) SetJenkinsVersion(version string) {
+	idler.jenkinsVersion.Store(version)
+}
+
+// Resources returns the resources requested by resp. limited for Jenkins as last observed on its deployment config,
+// false if its deployment config was not observed yet.
+func (idler *UserIdler) Resources() (model.Resources, bool) {
+	resources, ok := idler.resources.Load().(model.Resources)
+	return resources, ok
+}
+
+// SetResources records the resources requested by resp. limited for Jenkins as observed on its deployment config.
+func (idler *UserIdler) SetResources(resources model.Resources) {
+	idler.resources.Store(resources)
+}
+
+// Usage returns the resource usage of Jenkins as last measured, false if it was not measured yet.
+func (idler *UserIdler) Usage() (model.Usage, bool) {
+	usage, ok := idler.usage.Load().(model.Usage)
+	return usage, ok
+}
+
+// SetUsage records the resource usage of Jenkins as measured.
+func (idler *UserIdler) SetUsage(usage model.Usage) {
+	idler.usage.Store(usage)
+}
+
+// Stop stops the goroutine of this idler without cancelling the other idlers, e.g. to evict it once its
+// namespace became inactive.
+func (idler *UserIdler) Stop() {
+	idler.stopOnce.Do(func() {
+		close(idler.stop)
+	})
+}
+
+// State returns the current state of the Jenkins instance of the user.
+func (idler *UserIdler) State() State {
+	return idler.machine.State()
+}
+
 // GetChannel gets channel of model.User type of this UserIdler.
 func (idler *UserIdler) GetChannel() chan model.User {
 	return idler.userChan
@@ -99,6 +263,9 @@ func (idler *UserIdler) GetChannel() chan model.User {
 // checkIdle verifies the state of conditions and decides if we should idle/unidle
 // and performs the required action if needed.
 func (idler *UserIdler) checkIdle() error {
+	// any wait for the running jobs of Jenkins in quiet-down mode is re-armed by the idle below
+	idler.quietDownPoll = nil
+	defer idler.cancelAbandonedQuietDown()
 
 	enabled, err := idler.isIdlerEnabled()
 	if err != nil {
@@ -111,6 +278,18 @@ func (idler *UserIdler) checkIdle() error {
 		return nil
 	}
 
+	if idler.DisabledClusters != nil && idler.DisabledClusters.Has(idler.openShiftAPI) {
+		idler.logger.Infof("idler disabled for cluster %s - skipping", idler.openShiftAPI)
+		return nil
+	}
+
+	pressureIdleAfter := pressure.Default.IdleAfter(idler.openShiftAPI)
+	idler.updateUser(func(user *model.User) { user.PressureIdleAfter = pressureIdleAfter })
+
+	if err := idler.checkContentRepository(); err != nil {
+		idler.logger.Warnf("Checking the content-repository failed: %s", err)
+	}
+
 	idler.logger.Infof("Evaluating conditions for user %s", idler.user.Name)
 
 	action, errors := idler.Conditions.Eval(idler.user)
@@ -119,27 +298,136 @@ func (idler *UserIdler) checkIdle() error {
 		return errors.ToError()
 	}
 
-	log := idler.logger.WithField("action", action)
+	log := idler.logger.WithFields(logrus.Fields{"action": action, "state": idler.State()})
 	log.Infof("jenkins idle conditions eval result: %v", action)
 
+	if action == condition.UnIdle && idler.quietingDown() {
+		// new activity, e.g. a build, abandons the idle Jenkins waited for in quiet-down mode
+		idler.cancelQuietDown("there is new activity")
+	} else if action != condition.Idle && idler.quietingDown() {
+		// the idle got decided before, Jenkins merely waited for its running jobs to finish
+		log.Info("idling since Jenkins is in quiet-down mode")
+		action = condition.Idle
+	}
+
+	if action == condition.Idle && idler.user.SkipIdling {
+		log.Info("not idling since idling is skipped via deployment config annotation")
+		return nil
+	}
+
+	if action == condition.Idle && idler.user.Overrides.Disabled {
+		log.Info("not idling since idling is disabled via JenkinsIdlerConfig")
+		return nil
+	}
+
+	if action == condition.Idle && idler.user.Overrides.Schedule.Active(idler.clock.Now()) {
+		log.Info("not idling within the schedule of the JenkinsIdlerConfig")
+		return nil
+	}
+
+	if action == condition.Idle && UnidleOnly(idler.config, idler.openShiftAPI) {
+		log.Infof("not idling since cluster %s is in unidle-only mode", idler.openShiftAPI)
+		return nil
+	}
+
+	if action == condition.Idle {
+		if until, ok := Reservations.Reserved(namespace.Jenkins(idler.user.Name)); ok {
+			log.Infof("not idling since the namespace is reserved until %v", until)
+			return nil
+		}
+	}
+
 	if action == condition.Idle {
 		if err := idler.doIdle(); err != nil {
 			log.Errorf("Idling jenkins failed:  %s", err)
 			return err
 		}
+		if idler.idleSlot != nil {
+			// the idle got queued and is decided anew once it is permitted
+			return nil
+		}
 		// TODO: find a better way to update IdleStatus inside doIdle()
-		idler.user.IdleStatus = model.NewIdleStatus(err)
+		idler.updateUser(func(user *model.User) { user.IdleStatus = model.NewIdleStatus(err) })
+		if idler.idledUnderPressure() {
+			log.Infof("idled with idle timeout %v shortened due to resource pressure", idler.user.PressureIdleAfter)
+			Recorder.RecordPressureIdle(idler.openShiftAPI)
+		}
+		idler.idleChe()
 	} else if action == condition.UnIdle {
 		if err := idler.doUnIdle(); err != nil {
 			log.Errorf("UnIdling jenkins failed:  %s", err)
 			return err
 		}
 		// TODO: find a better way to update IdleStatus inside doUnIdle()
-		idler.user.IdleStatus = model.NewUnidleStatus(err)
+		idler.updateUser(func(user *model.User) { user.IdleStatus = model.NewUnidleStatus(err) })
 	}
 	return nil
 }
 
+// idleChe idles the Che workspaces of the user along with Jenkins, provided the user opted in. They are not
+// un-idled by the Idler, but started on demand by Che itself.
+func (idler *UserIdler) idleChe() {
+	enabled, err := idler.features.IsCheIdlerEnabled(idler.toggleTarget())
+	if err != nil {
+		idler.logger.Errorf("Failed to check if Che idling is enabled for user: %s", err)
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	namespace := idler.user.Name + cheNamespaceSuffix
+	for _, ns := range idler.user.NamespacesOfType("che") {
+		if ns.ClusterURL == "" || util.EnsureSuffix(ns.ClusterURL, "/") == util.EnsureSuffix(idler.openShiftAPI, "/") {
+			namespace = ns.Name
+			break
+		}
+	}
+
+	log := idler.logger.WithField("che-namespace", namespace)
+	idled, err := idler.openShiftClient.IdleDeployments(idler.openShiftAPI, idler.openShiftBearerToken, namespace, cheWorkspaceSelector)
+	if err != nil {
+		log.Errorf("Idling Che workspaces failed: %s", err)
+	}
+	if len(idled) > 0 {
+		log.Infof("Idled Che workspaces %v", idled)
+	}
+}
+
+// UnidleOnly returns whether Jenkins instances on the cluster with the given API URL are un-idled on demand but
+// never idled, either because the unidle-only mode is enabled globally or for this cluster.
+func UnidleOnly(config configuration.Configuration, openShiftAPI string) bool {
+	if config.GetUnidleOnly() {
+		return true
+	}
+	for _, cluster := range config.GetUnidleOnlyClusters() {
+		if cluster == openShiftAPI {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckInterval returns the interval between the regular idle checks of the Jenkins instances on the cluster with the
+// given API URL, which is either configured for this cluster or the global one.
+func CheckInterval(config configuration.Configuration, openShiftAPI string) time.Duration {
+	if minutes, ok := config.GetClusterCheckIntervals()[util.EnsureSuffix(openShiftAPI, "/")]; ok {
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Duration(config.GetCheckInterval()) * time.Minute
+}
+
+// jitter randomly shifts the given check interval by up to the configured percentage in either direction, so that
+// the checks of the user idlers spread instead of hitting the OpenShift API at the same time.
+func (idler *UserIdler) jitter(interval time.Duration) time.Duration {
+	percent := idler.config.GetCheckJitter()
+	if percent <= 0 {
+		return interval
+	}
+	spread := float64(interval) * float64(percent) / 100
+	return interval + time.Duration((2*idler.random()-1)*spread)
+}
+
 // Run runs/starts the Idler
 // It checks if Jenkins is idle at every interval duration.
 func (idler *UserIdler) Run(
@@ -151,38 +439,90 @@ func (idler *UserIdler) Run(
 		"maxRetriesQuietInterval": fmt.Sprintf("%.0fm", maxRetriesQuietInterval.Minutes()),
 	}).Info("UserIdler started.")
 
+	atomic.StoreInt64(&idler.checkInterval, int64(interval))
 	wg.Add(1)
 	go func() {
-		ticker := time.Tick(maxRetriesQuietInterval)
-		timer := time.After(interval)
-		defer wg.Done()
+		// like time.Tick, a non-positive quiet interval never resets the counters
+		var tick <-chan time.Time
+		if maxRetriesQuietInterval > 0 {
+			ticker := idler.clock.NewTicker(maxRetriesQuietInterval)
+			defer ticker.Stop()
+			tick = ticker.C()
+		}
+		// regularly correct divergences of the believed state from the actual one
+		var driftTick <-chan time.Time
+		if driftInterval := time.Duration(idler.config.GetDriftCheckInterval()) * time.Minute; driftInterval > 0 {
+			ticker := idler.clock.NewTicker(driftInterval)
+			defer ticker.Stop()
+			driftTick = ticker.C()
+		}
+		timer := idler.clock.NewTimer(idler.jitter(interval))
+		defer func() {
+			timer.Stop()
+			wg.Done()
+		}()
+		defer idler.releaseIdleSlot()
 		for {
+			var idleSlot <-chan time.Time
+			if idler.idleSlot != nil {
+				idleSlot = idler.idleSlot.C()
+			}
+
 			select {
 			case <-ctx.Done():
 				idler.logger.Info("Shutting down user idler.")
 				cancel()
 				return
-			case idler.user = <-idler.userChan:
+			case <-idler.stop:
+				idler.logger.Info("UserIdler stopped.")
+				return
+			case user := <-idler.userChan:
+				idler.updateUser(func(current *model.User) { *current = user })
 				idler.logger.WithField("state", idler.user.StateDump()).Debug("Received user data.")
+				Recorder.RecordChannelBacklog(idler.user.Name, len(idler.userChan))
+				atomic.StoreInt64(&idler.lastActivity, idler.clock.Now().UnixNano())
 
-				err := idler.checkIdle()
+				err := recovery.Guard("user-idler", idler.checkIdle)
 				if err != nil {
 					idler.logger.WithField("error", err.Error()).Warnf("Error during idle check: %s", err)
 				}
 				// Resetting the timer
-				timer = time.After(interval)
-			case <-timer:
+				timer.Stop()
+				timer = idler.clock.NewTimer(idler.jitter(interval))
+			case <-timer.C():
 				// Timer handles the case where there are no OpenShift events received
 				// for the user for the checkIdle duration.
 				// This ensures checkIdle will be called regularly.
 
 				idler.logger.WithField("state", idler.user.StateDump()).Info("Time based idle check.")
-				err := idler.checkIdle()
+				err := recovery.Guard("user-idler", idler.checkIdle)
 				if err != nil {
 					idler.logger.WithField("error", err.Error()).Warn("Error during idle check.")
 				}
 
-			case <-ticker:
+			case <-idleSlot:
+				// the queued idle operation is permitted now, it is decided anew as the user might be active again
+				idler.releaseIdleSlot()
+				idler.idleSlotArrived = true
+				err := recovery.Guard("user-idler", idler.checkIdle)
+				idler.idleSlotArrived = false
+				if err != nil {
+					idler.logger.WithField("error", err.Error()).Warn("Error during idle check.")
+				}
+
+			case <-idler.quietDownPoll:
+				// Jenkins is in quiet-down mode, waiting for its running jobs to finish before it gets idled
+				err := recovery.Guard("user-idler", idler.checkIdle)
+				if err != nil {
+					idler.logger.WithField("error", err.Error()).Warn("Error during idle check.")
+				}
+
+			case <-driftTick:
+				if err := recovery.Guard("user-idler", idler.checkDrift); err != nil {
+					idler.logger.WithField("error", err.Error()).Warn("Error during drift check.")
+				}
+
+			case <-tick:
 				// Using ticker for the resetting of counters to ensure it occurs
 				idler.logger.Debug("Resetting retry counters.")
 				idler.resetCounters()
@@ -191,6 +531,14 @@ func (idler *UserIdler) Run(
 	}()
 }
 
+// releaseIdleSlot releases the idle operation queued by the Throttle, if any.
+func (idler *UserIdler) releaseIdleSlot() {
+	if idler.idleSlot != nil {
+		idler.Throttle.Dequeue(idler.idleSlot)
+		idler.idleSlot = nil
+	}
+}
+
 func (idler *UserIdler) doIdle() error {
 
 	if idler.idleAttempts >= idler.maxRetries {
@@ -201,18 +549,46 @@ func (idler *UserIdler) doIdle() error {
 	state, err := idler.getJenkinsState()
 	if err != nil {
 		idler.logger.Errorf("failed to get status of jenkins: %s", err)
+		idler.fire(EventFailed)
 		return err
 	}
+	idler.Observe(state)
 
 	if state <= model.PodIdled {
 		idler.logger.Infof("not idling pod since it is already in state %s", state)
 		return nil
 	}
 
+	if !idler.quietDown(idler.logger) {
+		return nil
+	}
+
+	if idler.idleSlot != nil {
+		idler.logger.Info("not idling yet since the idle operation is queued")
+		return nil
+	}
+	if !idler.idleSlotArrived {
+		if idler.idleSlot = idler.Throttle.Queue(); idler.idleSlot != nil {
+			idler.logger.Info("queued the idle operation since the rate of idle operations on the cluster is exceeded")
+			return nil
+		}
+	}
+
+	held, err := lock.Default.Lock(idler.openShiftAPI, idler.openShiftBearerToken, namespace.Jenkins(idler.user.Name))
+	if err != nil {
+		return err
+	}
+	defer held.Unlock()
+	if held.Contended {
+		// another mutation, e.g. an un-idle via the API, got in first, so the idle decision might be outdated
+		idler.logger.Info("not idling since another operation on the namespace got in first, the next check decides anew")
+		return nil
+	}
+
 	idler.logger.Infof("Idling services, attempts: %d/%d", idler.idleAttempts, idler.maxRetries)
 
 	idler.incrementIdleAttempts()
-	for _, service := range JenkinsServices {
+	results := IdleServices(idler.servicesToIdle(), func(service string) error {
 
 		log := idler.logger.WithField(
 			"attempt", fmt.Sprintf("(%d/%d)", idler.idleAttempts, idler.maxRetries))
@@ -224,13 +600,26 @@ func (idler *UserIdler) doIdle() error {
 
 		log.Infof("About to idle %s, reason %s", service, reason)
 
-		err := idler.openShiftClient.Idle(idler.openShiftAPI, idler.openShiftBearerToken, idler.user.Name+jenkinsNamespaceSuffix, service)
+		err := idler.openShiftClient.Idle(idler.openShiftAPI, idler.openShiftBearerToken, namespace.Jenkins(idler.user.Name), service)
 		if err != nil {
 			log.Errorf("Idling of %s returned error:  %s", service, err)
 			return err
 		}
 		log.Infof("sucessfully idled %s", service)
+		RecordProvenance(idler.openShiftClient, idler.openShiftAPI, idler.openShiftBearerToken, namespace.Jenkins(idler.user.Name), service, model.Provenance{
+			Action:      model.IdleAction,
+			TriggeredBy: model.TriggeredByIdler,
+			Reason:      idler.idleReason(reason),
+			Timestamp:   idler.clock.Now(),
+		})
+		return nil
+	})
+	if err := results.Err(); err != nil {
+		idler.fire(EventFailed)
+		return err
 	}
+	idler.quietDownSince = time.Time{}
+	idler.fire(EventIdleRequested)
 	return nil
 }
 
@@ -242,6 +631,12 @@ func (idler *UserIdler) doUnIdle() error {
 		return nil
 	}
 
+	held, err := lock.Default.Lock(idler.openShiftAPI, idler.openShiftBearerToken, namespace.Jenkins(idler.user.Name))
+	if err != nil {
+		return err
+	}
+	defer held.Unlock()
+
 	// The state can still return idled even though Jenkins is un-idled,
 	// because we check for dc.status.replicas to determine if jenkins
 	// is un-idled, which can still be 0 for some time after un-idling
@@ -249,28 +644,33 @@ func (idler *UserIdler) doUnIdle() error {
 	// change state from idled to un-idled, after a manual un-idling
 	state, err := idler.getJenkinsState()
 	if err != nil {
+		idler.fire(EventFailed)
 		return err
 
 	}
+	idler.Observe(state)
 
 	idler.logger.Infof("Current Jenkins' pod's state is %s", state)
 	if state != model.PodIdled {
 		idler.logger.Infof("not unidling pod since it is already in state %s", state)
+		idler.remediate(state)
 		return nil
 	}
 
-	ns := idler.user.Name + jenkinsNamespaceSuffix
+	ns := namespace.Jenkins(idler.user.Name)
 	clusterFull, err := idler.tenantService.HasReachedMaxCapacity(idler.openShiftAPI, ns)
 	if err != nil {
+		idler.fire(EventFailed)
 		return err
 	}
 	if clusterFull {
 		err := fmt.Errorf("Maximum Resource limit reached on %s for %s", idler.openShiftAPI, ns)
+		idler.fire(EventFailed)
 		return err
 	}
 
 	idler.incrementUnIdleAttempts()
-	for _, service := range JenkinsServices {
+	results := UnIdleServices(idler.servicesToUnIdle(), func(service string) error {
 		// Let's add some more reasons, we probably want to
 		reasonString := fmt.Sprintf("DoneBuild BuildName:%s Last:%s", idler.user.DoneBuild.Metadata.Name, idler.user.DoneBuild.Status.StartTimestamp.Time)
 		if idler.user.ActiveBuild.Metadata.Name != "" {
@@ -284,6 +684,30 @@ func (idler *UserIdler) doUnIdle() error {
 			return err
 		}
 		idler.logger.Infof("Successfully un-idled service %v in namespace %v (un-idle attempt: %v)", service, ns, idler.unIdleAttempts)
+		RecordProvenance(idler.openShiftClient, idler.openShiftAPI, idler.openShiftBearerToken, ns, service, model.Provenance{
+			Action:      model.UnidleAction,
+			TriggeredBy: model.TriggeredByIdler,
+			Reason:      reasonString,
+			Timestamp:   idler.clock.Now(),
+		})
+		return nil
+	})
+	if err := results.Err(); err != nil {
+		idler.fire(EventFailed)
+		if idler.unIdleAttempts >= idler.maxRetries {
+			notify.Default.Notify(notify.Event{
+				Class:     notify.UnIdleFailures,
+				Cluster:   idler.openShiftAPI,
+				Namespace: ns,
+				Message:   fmt.Sprintf("Un-idling Jenkins failed %d times, giving up: %s", idler.unIdleAttempts, err),
+			})
+		}
+		return err
+	}
+	idler.fire(EventUnIdleRequested)
+	if idler.contentRepository != nil {
+		// the content-repository got un-idled along with Jenkins, which is going to request artifacts
+		idler.contentRepository.observe(idler.clock.Now())
 	}
 
 	// NOTE: sometimes bc events get fired/handled before a DC event and the
@@ -291,7 +715,7 @@ func (idler *UserIdler) doUnIdle() error {
 	// to Idle, and if this isn't set, dc conditions would not evaluate to "UnIdle"
 	// there by idling jenkins even though a build is in progress
 	if idler.user.JenkinsLastUpdate.IsZero() {
-		idler.user.JenkinsLastUpdate = time.Now().UTC()
+		idler.updateUser(func(user *model.User) { user.JenkinsLastUpdate = idler.clock.Now().UTC() })
 		idler.logger.Infof("Resetting LastUpdate time to now  %v", idler.user.JenkinsLastUpdate)
 
 	}
@@ -299,8 +723,17 @@ func (idler *UserIdler) doUnIdle() error {
 
 }
 
+// toggleTarget returns the target the features are evaluated for, i.e. the user and its Jenkins namespace.
+func (idler *UserIdler) toggleTarget() toggles.Target {
+	return toggles.Target{
+		UserID:     idler.user.ID,
+		Namespace:  namespace.Jenkins(idler.user.Name),
+		ClusterURL: idler.openShiftAPI,
+	}
+}
+
 func (idler *UserIdler) isIdlerEnabled() (bool, error) {
-	enabled, err := idler.features.IsIdlerEnabled(idler.user.ID)
+	enabled, err := idler.features.IsIdlerEnabled(idler.toggleTarget())
 	if err != nil {
 		return false, err
 	}
@@ -318,7 +751,7 @@ func (idler *UserIdler) isIdlerEnabled() (bool, error) {
 }
 
 func (idler *UserIdler) getJenkinsState() (model.PodState, error) {
-	ns := idler.user.Name + jenkinsNamespaceSuffix
+	ns := namespace.Jenkins(idler.user.Name)
 	state, err := idler.openShiftClient.State(idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName)
 	if err != nil {
 		return model.PodStateUnknown, err
@@ -326,6 +759,145 @@ func (idler *UserIdler) getJenkinsState() (model.PodState, error) {
 	return state, nil
 }
 
+// remediate resets Jenkins if it keeps crashing and remediation is enabled.
+func (idler *UserIdler) remediate(state model.PodState) {
+	ns := namespace.Jenkins(idler.user.Name)
+	reset, err := idler.remediator.Remediate(idler.openShiftClient, idler.openShiftAPI, idler.openShiftBearerToken, ns, jenkinsServiceName, state)
+	if err != nil {
+		idler.logger.Errorf("Remediation of jenkins failed: %s", err)
+		return
+	}
+	if reset {
+		idler.logger.Info("Reset crashing jenkins.")
+	}
+}
+
+// fire fires the event on the state machine of the idler. Events not allowed in the current state are ignored.
+func (idler *UserIdler) fire(event Event) {
+	if _, err := idler.machine.Fire(event); err != nil {
+		idler.logger.WithField("event", event).Warnf("Ignoring event: %s", err)
+	}
+}
+
+// Observe fires the event matching the observed state of the Jenkins pod.
+func (idler *UserIdler) Observe(state model.PodState) {
+	if event, ok := podEvents[state]; ok {
+		idler.fire(event)
+	}
+}
+
+func (idler *UserIdler) logTransition(t Transition) {
+	idler.logger.WithFields(logrus.Fields{
+		"from":  t.From,
+		"to":    t.To,
+		"event": t.Event,
+	}).Infof("Jenkins state changed to %s.", t.To)
+}
+
+// PublicState returns the state as published to clients, e.g. starting instead of unidling.
+func PublicState(s State) string {
+	if state, ok := eventStates[s]; ok {
+		return state
+	}
+	return string(s)
+}
+
+func (idler *UserIdler) publishTransition(t Transition) {
+	missed := Events.Publish(events.Event{
+		Namespace: namespace.Jenkins(idler.user.Name),
+		State:     PublicState(t.To),
+		Previous:  PublicState(t.From),
+		Time:      idler.clock.Now().UTC(),
+	})
+	if missed > 0 {
+		idler.logger.WithField("subscribers", missed).Warn("State change missed by lagging event subscribers.")
+	}
+}
+
+func (idler *UserIdler) trackReadiness(t Transition) {
+	idler.ready.observe(t, idler.clock.Now())
+}
+
+// EstimatedReadyIn returns how long the Jenkins of the user is expected to take until it is ready, based on how
+// long it took to un-idle in the past. It returns false if Jenkins is not un-idling or was never un-idled before.
+func (idler *UserIdler) EstimatedReadyIn() (time.Duration, bool) {
+	return idler.ready.estimate(idler.clock.Now())
+}
+
+// IdleAfter returns how long the Jenkins of the user keeps running after its last activity until it gets idled, i.e.
+// JC_IDLE_AFTER unless overridden by the JenkinsIdlerConfig or the annotation of Jenkins or the idle timeout
+// experiment, or shortened due to resource pressure. It is independent of how often the idle conditions are checked,
+// see CheckInterval.
+func (idler *UserIdler) IdleAfter() time.Duration {
+	return idler.user.GetIdleAfter(time.Duration(idler.config.GetIdleAfter()) * time.Minute)
+}
+
+// CheckInterval returns the interval between the regular idle checks of the Jenkins of the user, the configured one
+// for its cluster as long as the user idler is not running.
+func (idler *UserIdler) CheckInterval() time.Duration {
+	if interval := atomic.LoadInt64(&idler.checkInterval); interval > 0 {
+		return time.Duration(interval)
+	}
+	return CheckInterval(idler.config, idler.openShiftAPI)
+}
+
+// IdleIn returns how long the Jenkins of the user keeps running without further activity until it gets idled,
+// mirroring the idle conditions: it is idled once the idle timeout elapsed after its last update and its last
+// completed build as well as the grace period after a manual un-idle. It returns false if Jenkins has an active
+// build or it is unknown when it was last updated.
+func (idler *UserIdler) IdleIn() (time.Duration, bool) {
+	user := idler.user
+	if user.JenkinsLastUpdate.IsZero() || user.HasActiveBuilds() {
+		return 0, false
+	}
+
+	idleAfter := idler.IdleAfter()
+	deadline := user.JenkinsLastUpdate.Add(idleAfter)
+	if user.HasCompletedBuilds() {
+		if completed := user.DoneBuild.Status.CompletionTimestamp.Time.Add(idleAfter); completed.After(deadline) {
+			deadline = completed
+		}
+	}
+	if !user.ManualUnIdleAt.IsZero() {
+		grace := time.Duration(idler.config.GetManualUnIdleGracePeriod()) * time.Minute
+		if graceEnd := user.ManualUnIdleAt.Add(grace); graceEnd.After(deadline) {
+			deadline = graceEnd
+		}
+	}
+
+	idleIn := deadline.Sub(idler.clock.Now())
+	if idleIn < 0 {
+		idleIn = 0
+	}
+	return idleIn, true
+}
+
+// idledUnderPressure returns true if the idle timeout of the user is shortened due to resource pressure.
+func (idler *UserIdler) idledUnderPressure() bool {
+	regular := idler.user
+	regular.PressureIdleAfter = 0
+	defaultIdleAfter := time.Duration(idler.config.GetIdleAfter()) * time.Minute
+	return idler.user.GetIdleAfter(defaultIdleAfter) < regular.GetIdleAfter(defaultIdleAfter)
+}
+
+// idleReason describes why Jenkins gets idled for the provenance annotations, given the last build.
+func (idler *UserIdler) idleReason(build string) string {
+	idleAfter := idler.IdleAfter()
+	reason := fmt.Sprintf("inactive for %v, %s", idleAfter, build)
+	if idler.idledUnderPressure() {
+		reason += ", idle timeout shortened due to resource pressure"
+	}
+	return reason
+}
+
+// recordTransition returns a transition listener recording the transitions tagged with the variant of the idle
+// timeout experiment the user is assigned to.
+func recordTransition(variant string) func(t Transition) {
+	return func(t Transition) {
+		Recorder.RecordStateTransition(string(t.From), string(t.To), string(t.Event), variant)
+	}
+}
+
 func (idler *UserIdler) incrementIdleAttempts() {
 	idler.idleAttempts++
 }
@@ -339,15 +911,20 @@ func (idler *UserIdler) resetCounters() {
 	idler.unIdleAttempts = 0
 }
 
-func createWatchConditions(proxyURL string, idleAfter int, idleLongBuild int, log *logrus.Entry) *condition.Conditions {
+func createWatchConditions(proxyURL string, idleAfter int, idleLongBuild int, manualUnIdleGracePeriod int, clock clock.Clock, log *logrus.Entry) *condition.Conditions {
 	conditions := condition.NewConditions()
 
-	conditions.Add("dc", condition.NewDCCondition(time.Duration(idleAfter)*time.Minute))
+	conditions.Add("dc", condition.NewDCCondition(time.Duration(idleAfter)*time.Minute, clock))
 
 	// Add a Build condition.
 	conditions.Add("build", condition.NewBuildCondition(
 		time.Duration(idleAfter)*time.Minute,
-		time.Duration(idleLongBuild)*time.Hour))
+		time.Duration(idleLongBuild)*time.Hour,
+		clock))
+
+	// Keep Jenkins running for a while after a manual un-idle.
+	conditions.Add("manual-unidle", condition.NewManualUnIdleCondition(
+		time.Duration(manualUnIdleGracePeriod)*time.Minute, clock))
 
 	return &conditions
 }
@@ -8,12 +8,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/condition"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
 	log "github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type ErrorCondition struct {
@@ -42,6 +44,7 @@ func Test_idle_check_skipped_if_feature_not_enabled(t *testing.T) {
 		user, "", "", &mock.Config{},
 		mock.NewMockFeatureToggle([]string{"42"}),
 		&mock.TenantService{},
+		clock.New(),
 	)
 
 	err := userIdler.checkIdle()
@@ -59,6 +62,7 @@ func Test_idle_check_returns_error_on_evaluation_failure(t *testing.T) {
 		user, "", "", &mock.Config{},
 		mock.NewMockFeatureToggle([]string{"42"}),
 		&mock.TenantService{},
+		clock.New(),
 	)
 	userIdler.Conditions.Add("error", &ErrorCondition{})
 
@@ -67,6 +71,253 @@ func Test_idle_check_returns_error_on_evaluation_failure(t *testing.T) {
 	assert.Equal(t, "eval error", err.Error(), "Unexpected error message.")
 }
 
+func Test_idle_check_skipped_if_idling_skipped_via_annotation(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "John Doe", SkipIdling: true}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	config := &mock.Config{MaxRetries: 5}
+	userIdler := NewUserIdler(
+		user, "", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled.")
+}
+
+func Test_idle_check_skipped_via_idler_config(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	schedule, err := model.ParseSchedule([]string{"Mon-Fri 08:00-18:00"})
+	require.NoError(t, err)
+	// 2018-06-04 is a Monday.
+	monday := time.Date(2018, 6, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		overrides model.Overrides
+	}{
+		{"disabled", model.Overrides{Disabled: true}},
+		{"within schedule", model.Overrides{Schedule: schedule}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := model.User{ID: "42", Name: "John Doe", Overrides: test.overrides}
+			openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+			config := &mock.Config{MaxRetries: 5}
+			userIdler := NewUserIdler(
+				user, "", "", config,
+				mock.NewMockFeatureToggle([]string{"42"}),
+				&mock.TenantService{},
+				clock.NewFake(monday),
+			)
+			userIdler.openShiftClient = openShiftClient
+
+			err := userIdler.checkIdle()
+			assert.NoError(t, err, "No error expected.")
+			assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled.")
+		})
+	}
+}
+
+func Test_idle_check_skipped_if_cluster_disabled(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "John Doe"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	config := &mock.Config{MaxRetries: 5}
+	userIdler := NewUserIdler(
+		user, "https://api.example.com/", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+	userIdler.DisabledClusters = model.NewStringSet()
+	userIdler.DisabledClusters.Add([]string{"https://api.example.com/"})
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled.")
+
+	userIdler.DisabledClusters.Remove([]string{"https://api.example.com/"})
+	err = userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once the cluster is enabled again.")
+}
+
+func Test_idle_check_skipped_in_unidle_only_mode(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "John Doe"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	config := &mock.Config{MaxRetries: 5, UnidleOnlyClusters: []string{"https://api.example.com/"}}
+	userIdler := NewUserIdler(
+		user, "https://api.example.com/", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled in unidle-only mode.")
+
+	config.UnidleOnlyClusters = nil
+	err = userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once the unidle-only mode is lifted.")
+}
+
+func Test_idle_check_skipped_while_reserved(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	reservations := Reservations
+	Reservations = NewReservationStore(clock.New())
+	defer func() { Reservations = reservations }()
+
+	user := model.User{ID: "42", Name: "john"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	userIdler := NewUserIdler(
+		user, "https://api.example.com/", "", &mock.Config{MaxRetries: 5},
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+
+	reservation, err := Reservations.Reserve("john-jenkins", time.Minute)
+	require.NoError(t, err)
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while the namespace is reserved.")
+
+	Reservations.Release("john-jenkins", reservation.Token)
+	assert.NoError(t, userIdler.checkIdle(), "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once the reservation got released.")
+}
+
+func Test_idle_check_blocked_by_activity_in_other_namespaces(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "john", Namespaces: []model.Namespace{
+		{Name: "john-che", Type: "che", ClusterURL: "https://api.example.com"},
+		{Name: "john-stage", Type: "stage", ClusterURL: "https://api.other.com/"},
+	}}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning, PodsRunning: map[string]int{"john-che": 1, "john-stage": 1}}
+	config := &mock.Config{MaxRetries: 5, ActivityNsTypes: []string{"che", "stage"}}
+	userIdler := NewUserIdler(
+		user, "https://api.example.com/", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}),
+		&mock.TenantService{},
+		clock.New(),
+	)
+	userIdler.openShiftClient = openShiftClient
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 0, openShiftClient.IdleCallCount, "Jenkins should not be idled while the Che workspace runs.")
+
+	openShiftClient.PodsRunning["john-che"] = 0
+	err = userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Activity on other clusters should not be considered.")
+}
+
+func Test_idle_records_provenance(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "john"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	fakeClock := clock.NewFake(time.Date(2018, 4, 11, 8, 0, 0, 0, time.UTC))
+	userIdler := NewUserIdler(user, "https://api.example.com/", "", &mock.Config{MaxRetries: 5, IdleAfter: 45},
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, fakeClock)
+	userIdler.openShiftClient = openShiftClient
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	annotations := openShiftClient.Annotations["jenkins"]
+	assert.Equal(t, model.IdleAction, annotations[model.LastActionAnnotation])
+	assert.Equal(t, model.TriggeredByIdler, annotations[model.TriggeredByAnnotation])
+	assert.Contains(t, annotations[model.ReasonAnnotation], "inactive for 45m0s")
+	assert.Equal(t, fakeClock.Now().UTC().Format(time.RFC3339), annotations[model.TimestampAnnotation])
+}
+
+func Test_che_idled_along_with_jenkins_if_enabled(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "john", Namespaces: []model.Namespace{{Name: "john-workspaces", Type: "che"}}}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	userIdler := NewUserIdler(user, "https://api.example.com/", "", &mock.Config{MaxRetries: 5},
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, clock.New())
+	userIdler.openShiftClient = openShiftClient
+
+	err := userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, 1, openShiftClient.IdleCallCount)
+	assert.Empty(t, openShiftClient.IdledNamespaces, "Che should not be idled unless enabled.")
+
+	userIdler.features = mock.NewMockCheFeatureToggle([]string{"42"})
+	err = userIdler.checkIdle()
+	assert.NoError(t, err, "No error expected.")
+	assert.Equal(t, []string{"john-workspaces"}, openShiftClient.IdledNamespaces)
+}
+
+func Test_unidle_only(t *testing.T) {
+	assert.False(t, UnidleOnly(&mock.Config{}, "https://api.example.com/"))
+	assert.True(t, UnidleOnly(&mock.Config{UnidleOnly: true}, "https://api.example.com/"))
+	assert.True(t, UnidleOnly(&mock.Config{UnidleOnlyClusters: []string{"https://api.example.com/"}}, "https://api.example.com/"))
+	assert.False(t, UnidleOnly(&mock.Config{UnidleOnlyClusters: []string{"https://api.example.com/"}}, "https://api.other.com/"))
+}
+
+func Test_check_interval(t *testing.T) {
+	config := &mock.Config{CheckInterval: 15, ClusterCheckIntervals: map[string]int{"https://api.free.example.com/": 5}}
+	assert.Equal(t, 5*time.Minute, CheckInterval(config, "https://api.free.example.com"))
+	assert.Equal(t, 15*time.Minute, CheckInterval(config, "https://api.dedicated.example.com/"))
+}
+
+func Test_idle_after_is_independent_of_check_interval(t *testing.T) {
+	config := &mock.Config{IdleAfter: 60, CheckInterval: 15}
+	user := model.User{ID: "42", Name: "John Doe"}
+	userIdler := NewUserIdler(user, "", "", config, mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, clock.New())
+	assert.Equal(t, 60*time.Minute, userIdler.IdleAfter())
+	assert.Equal(t, 15*time.Minute, userIdler.CheckInterval(), "the configured interval is expected before the idler runs")
+
+	user.IdleAfter = 4 * time.Hour
+	userIdler = NewUserIdler(user, "", "", config, mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, clock.New())
+	assert.Equal(t, 4*time.Hour, userIdler.IdleAfter(), "the annotation of Jenkins should override the idle timeout")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	userIdler.Run(ctx, &wg, cancel, 5*time.Minute, time.Hour)
+	assert.Equal(t, 5*time.Minute, userIdler.CheckInterval())
+	assert.Equal(t, 4*time.Hour, userIdler.IdleAfter(), "the check interval should not affect the idle timeout")
+	cancel()
+	wg.Wait()
+}
+
+func Test_jitter(t *testing.T) {
+	config := &mock.Config{}
+	userIdler := NewUserIdler(model.User{ID: "42", Name: "John Doe"}, "", "", config,
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, clock.New())
+	assert.Equal(t, 15*time.Minute, userIdler.jitter(15*time.Minute), "No jitter expected if disabled")
+
+	config.CheckJitter = 10
+	for random, expected := range map[float64]time.Duration{
+		0:   13*time.Minute + 30*time.Second,
+		0.5: 15 * time.Minute,
+		1:   16*time.Minute + 30*time.Second,
+	} {
+		userIdler.random = func() float64 { return random }
+		assert.Equal(t, expected, userIdler.jitter(15*time.Minute))
+	}
+}
+
 func Test_idle_check_occurs_even_without_openshift_events(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 	log.SetLevel(log.DebugLevel)
@@ -76,7 +327,7 @@ func Test_idle_check_occurs_even_without_openshift_events(t *testing.T) {
 	config := &mock.Config{}
 	features := mock.NewMockFeatureToggle([]string{"42"})
 	tenantService := &mock.TenantService{}
-	userIdler := NewUserIdler(user, "", "", config, features, tenantService)
+	userIdler := NewUserIdler(user, "", "", config, features, tenantService, clock.New())
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
@@ -130,7 +381,7 @@ func Test_number_of_idle_calls_are_capped(t *testing.T) {
 	config.MaxRetries = maxRetry
 	features := mock.NewMockFeatureToggle([]string{"42"})
 	tenantService := &mock.TenantService{}
-	userIdler := NewUserIdler(user, "", "", config, features, tenantService)
+	userIdler := NewUserIdler(user, "", "", config, features, tenantService, clock.New())
 	userIdler.openShiftClient = openShiftClient
 
 	var wg sync.WaitGroup
@@ -179,7 +430,7 @@ func Test_number_of_unidle_calls_are_capped(t *testing.T) {
 	features := mock.NewMockFeatureToggle([]string{"42"})
 	tenantService := &mock.TenantService{}
 
-	userIdler := NewUserIdler(user, "", "", config, features, tenantService)
+	userIdler := NewUserIdler(user, "", "", config, features, tenantService, clock.New())
 	userIdler.openShiftClient = openShiftClient
 	conditions := condition.NewConditions()
 	conditions.Add("unidle", &UnIdleCondition{})
@@ -215,6 +466,171 @@ func Test_number_of_unidle_calls_are_capped(t *testing.T) {
 	assert.Equal(t, 0, openShiftClient.IdleCallCount, "There should be no idle calls.")
 }
 
+func Test_idle_check_tracks_jenkins_state(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	user := model.User{ID: "42", Name: "John Doe"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodIdled}
+	userIdler := NewUserIdler(user, "", "", &mock.Config{MaxRetries: 5},
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, clock.New())
+	userIdler.openShiftClient = openShiftClient
+	conditions := condition.NewConditions()
+	conditions.Add("unidle", &UnIdleCondition{})
+	userIdler.Conditions = &conditions
+
+	assert.Equal(t, StateUnknown, userIdler.State())
+
+	assert.NoError(t, userIdler.checkIdle())
+	assert.Equal(t, StateUnIdling, userIdler.State(), "Jenkins should be un-idling after the un-idle request")
+
+	openShiftClient.IdleState = model.PodRunning
+	assert.NoError(t, userIdler.checkIdle())
+	assert.Equal(t, StateRunning, userIdler.State(), "Jenkins should be running once the pod is observed running")
+
+	openShiftClient.IdleError = "connection refused"
+	assert.Error(t, userIdler.checkIdle())
+	assert.Equal(t, StateError, userIdler.State())
+}
+
+func Test_time_based_idle_check_with_fake_clock(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	fakeClock := clock.NewFake(time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC))
+	user := model.User{ID: "42", Name: "John Doe"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodIdled}
+	userIdler := NewUserIdler(user, "", "", &mock.Config{MaxRetries: 5},
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, fakeClock)
+	userIdler.openShiftClient = openShiftClient
+	conditions := condition.NewConditions()
+	conditions.Add("unidle", &UnIdleCondition{})
+	userIdler.Conditions = &conditions
+
+	transitions := make(chan Transition, 10)
+	userIdler.machine.OnTransition(func(t Transition) {
+		transitions <- t
+	})
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	userIdler.Run(ctx, &wg, cancel, 15*time.Minute, 30*time.Minute)
+
+	// wait for the check interval timer and the retry counter ticker
+	fakeClock.BlockUntil(2)
+	fakeClock.Advance(14 * time.Minute)
+	assert.Len(t, transitions, 0, "No idle check should occur before the check interval elapsed")
+
+	fakeClock.Advance(time.Minute)
+	for _, expected := range []State{StateIdled, StateUnIdling} {
+		select {
+		case transition := <-transitions:
+			assert.Equal(t, expected, transition.To)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Time based idle check should have occurred")
+		}
+	}
+
+	cancel()
+	wg.Wait()
+	assert.Equal(t, 1, openShiftClient.UnIdleCallCount, "Jenkins should have been un-idled once")
+}
+
+// activityCondition idles Jenkins unless the user runs a build.
+type activityCondition struct {
+}
+
+func (c *activityCondition) Eval(object interface{}) (condition.Action, error) {
+	if object.(model.User).ActiveBuild.Metadata.Name != "" {
+		return condition.NoAction, nil
+	}
+	return condition.Idle, nil
+}
+
+// messageHook records the messages logged.
+type messageHook struct {
+	sync.Mutex
+	messages []string
+}
+
+func (h *messageHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *messageHook) Fire(entry *log.Entry) error {
+	h.Lock()
+	defer h.Unlock()
+	h.messages = append(h.messages, entry.Message)
+	return nil
+}
+
+// logged returns a function telling whether the given message got logged since the hook got reset.
+func (h *messageHook) logged(message string) func() bool {
+	return func() bool {
+		h.Lock()
+		defer h.Unlock()
+		for _, m := range h.messages {
+			if m == message {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (h *messageHook) reset() {
+	h.Lock()
+	defer h.Unlock()
+	h.messages = nil
+}
+
+func Test_queued_idle_is_decided_anew(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC))
+	user := model.User{ID: "42", Name: "john"}
+	openShiftClient := &mock.OpenShiftClient{IdleState: model.PodRunning}
+	userIdler := NewUserIdler(user, "https://api.example.com/", "", &mock.Config{MaxRetries: 5},
+		mock.NewMockFeatureToggle([]string{"42"}), &mock.TenantService{}, fakeClock)
+	userIdler.openShiftClient = openShiftClient
+	testLogger := log.New()
+	testLogger.Out = ioutil.Discard
+	hook := &messageHook{}
+	testLogger.Hooks.Add(hook)
+	userIdler.logger = testLogger.WithField("name", user.Name)
+	conditions := condition.NewConditions()
+	conditions.Add("activity", &activityCondition{})
+	userIdler.Conditions = &conditions
+	userIdler.Throttle = NewThrottle("https://api.example.com/", 1, fakeClock)
+	require.True(t, userIdler.Throttle.Wait(nil, nil), "The slot of another idle operation should be taken")
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	userIdler.Run(ctx, &wg, cancel, time.Hour, time.Hour)
+
+	userIdler.GetChannel() <- user
+	require.Eventually(t, hook.logged("queued the idle operation since the rate of idle operations on the cluster is exceeded"),
+		5*time.Second, 10*time.Millisecond, "Idle should be queued rather than block the user idler")
+
+	active := user
+	active.ActiveBuild.Metadata.Name = "build-1"
+	userIdler.GetChannel() <- active
+	require.Eventually(t, func() bool { return userIdler.GetUser().ActiveBuild.Metadata.Name == "build-1" },
+		5*time.Second, 10*time.Millisecond, "Events should be handled while the idle is queued")
+
+	hook.reset()
+	fakeClock.Advance(time.Minute)
+	require.Eventually(t, hook.logged("jenkins idle conditions eval result: no action"), 5*time.Second, 10*time.Millisecond,
+		"Conditions should be evaluated anew once the queued idle is permitted")
+
+	userIdler.GetChannel() <- user
+	require.Eventually(t, hook.logged("queued the idle operation since the rate of idle operations on the cluster is exceeded"),
+		5*time.Second, 10*time.Millisecond)
+	fakeClock.Advance(time.Minute)
+	require.Eventually(t, hook.logged("sucessfully idled jenkins"), 5*time.Second, 10*time.Millisecond,
+		"Queued idle should be performed once permitted if the user is still inactive")
+
+	cancel()
+	wg.Wait()
+	assert.Equal(t, 1, openShiftClient.IdleCallCount, "Jenkins should be idled once")
+}
+
 func extractLogMessages(entries []*log.Entry) []string {
 	messages := []string{}
 	for _, logEntry := range entries {
@@ -222,3 +638,21 @@ func extractLogMessages(entries []*log.Entry) []string {
 	}
 	return messages
 }
+
+func Test_stop_ends_user_idler_without_cancelling_context(t *testing.T) {
+	user := model.User{ID: "100", Name: "John Doe"}
+	c := clock.NewFake(time.Now())
+	userIdler := NewUserIdler(user, "", "", &mock.Config{}, mock.NewMockFeatureToggle([]string{}), &mock.TenantService{}, c)
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.True(t, c.Now().Equal(userIdler.LastActivity()), "A new user idler should count as active")
+	userIdler.Run(ctx, &wg, cancel, time.Hour, time.Hour)
+
+	userIdler.Stop()
+	userIdler.Stop()
+	wg.Wait()
+
+	assert.NoError(t, ctx.Err(), "Stopping a single user idler should not cancel the others")
+}
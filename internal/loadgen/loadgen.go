@@ -0,0 +1,435 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
+)
+
+// drainTimeout is how long a run waits for the user idlers to pick up the events still buffered once all events got
+// handled by the controller.
+const drainTimeout = 10 * time.Second
+
+// pacing is the interval at which the events of a rate limited run are released in batches.
+const pacing = 10 * time.Millisecond
+
+// Options configure a load generation run.
+type Options struct {
+	// Namespaces is the number of synthetic user namespaces the events are spread across.
+	Namespaces int
+	// Rate is the number of events per second replayed against the controller, 0 replaying them as fast as possible.
+	Rate int
+	// Events is the number of events to replay, 0 for no limit.
+	Events int
+	// Duration limits the run, 0 for no limit. Either Events or Duration needs to be given.
+	Duration time.Duration
+	// Workers is the number of goroutines handing the events to the controller. Each namespace is served by a single
+	// worker, as each watch hands its events over one by one.
+	Workers int
+	// DCRatio is the fraction (0.0 - 1.0) of the events being deployment config events, the others being build events.
+	DCRatio float64
+}
+
+// Validate returns an error if the options are out of range.
+func (o Options) Validate() error {
+	switch {
+	case o.Namespaces < 1:
+		return errors.New("at least one namespace is needed")
+	case o.Workers < 1:
+		return errors.New("at least one worker is needed")
+	case o.Rate < 0 || o.Events < 0 || o.Duration < 0:
+		return errors.New("rate, events and duration must not be negative")
+	case o.Events == 0 && o.Duration == 0:
+		return errors.New("either the number of events or the duration needs to be given")
+	case o.DCRatio < 0 || o.DCRatio > 1:
+		return errors.New("the deployment config ratio needs to be within 0.0 and 1.0")
+	}
+	return nil
+}
+
+// Report summarizes a load generation run.
+type Report struct {
+	Events        int64         `json:"events"`
+	BuildEvents   int64         `json:"build_events"`
+	DCEvents      int64         `json:"dc_events"`
+	Errors        int64         `json:"errors"`
+	Elapsed       time.Duration `json:"elapsed"`
+	Throughput    float64       `json:"throughput"`
+	Decisions     int64         `json:"decisions"`
+	LatencyP50    time.Duration `json:"latency_p50"`
+	LatencyP95    time.Duration `json:"latency_p95"`
+	LatencyP99    time.Duration `json:"latency_p99"`
+	LatencyMax    time.Duration `json:"latency_max"`
+	UserIdlers    int           `json:"user_idlers"`
+	Goroutines    int           `json:"goroutines"`
+	HeapAlloc     uint64        `json:"heap_alloc"`
+	BytesPerEvent uint64        `json:"bytes_per_event"`
+	NumGC         uint32        `json:"num_gc"`
+}
+
+// String formats the report for the console.
+func (r Report) String() string {
+	return fmt.Sprintf("events: %d (builds %d, dcs %d, errors %d) in %v, %.0f events/s\n"+
+		"decisions: %d, latency p50 %v, p95 %v, p99 %v, max %v\n"+
+		"user idlers: %d, goroutines: %d, heap: %d KiB, allocated per event: %d B, GCs: %d",
+		r.Events, r.BuildEvents, r.DCEvents, r.Errors, r.Elapsed.Round(time.Millisecond), r.Throughput,
+		r.Decisions, r.LatencyP50, r.LatencyP95, r.LatencyP99, r.LatencyMax,
+		r.UserIdlers, r.Goroutines, r.HeapAlloc/1024, r.BytesPerEvent, r.NumGC)
+}
+
+// Run replays a synthetic stream of build and deployment config events against a controller backed by an in-memory
+// tenant service and a fake OpenShift API, until the number of events got replayed, the duration elapsed or the
+// context is done. Builds of each namespace cycle through the New, Running and Complete phases, deployment configs
+// toggle their availability, so that every event reaches the user idler of its namespace.
+//
+// The decision latency is the time from handing an event to the controller until the user idler evaluates it, which
+// it starts with checking the feature toggle. The toggle reports idling as disabled, so that no Jenkins gets idled
+// resp. un-idled and only the event processing of the Idler itself is measured.
+func Run(ctx context.Context, config configuration.Configuration, opts Options) (Report, error) {
+	if err := opts.Validate(); err != nil {
+		return Report{}, err
+	}
+
+	api := httptest.NewServer(http.HandlerFunc(fakeOpenShift))
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	probe := newProbe()
+	userIdlers := openshift.NewUserIdlerMap()
+	controller := openshift.NewController(ctx, api.URL, "loadgen", userIdlers, newTenantService(api.URL), probe,
+		config, &wg, cancel, model.NewStringSet(), model.NewStringSet(), clock.New())
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	g := &generator{opts: opts, controller: controller, probe: probe, sequences: make([]int, opts.Namespaces)}
+	start := time.Now()
+	g.run(ctx)
+	elapsed := time.Since(start)
+	probe.drain(ctx, drainTimeout)
+
+	runtime.GC()
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	report := Report{
+		Events:      atomic.LoadInt64(&g.builds) + atomic.LoadInt64(&g.dcs),
+		BuildEvents: atomic.LoadInt64(&g.builds),
+		DCEvents:    atomic.LoadInt64(&g.dcs),
+		Errors:      atomic.LoadInt64(&g.errors),
+		Elapsed:     elapsed,
+		UserIdlers:  userIdlers.Len(),
+		Goroutines:  runtime.NumGoroutine(),
+		NumGC:       after.NumGC - before.NumGC,
+	}
+	if after.HeapAlloc > before.HeapAlloc {
+		report.HeapAlloc = after.HeapAlloc - before.HeapAlloc
+	}
+	if report.Events > 0 {
+		report.Throughput = float64(report.Events) / elapsed.Seconds()
+		report.BytesPerEvent = (after.TotalAlloc - before.TotalAlloc) / uint64(report.Events)
+	}
+	probe.summarize(&report)
+	return report, nil
+}
+
+// generator produces the events and hands them to the controller.
+type generator struct {
+	opts       Options
+	controller openshift.Controller
+	probe      *probe
+	sequences  []int
+	builds     int64
+	dcs        int64
+	errors     int64
+}
+
+// run releases the events to the workers at the configured rate until the run is over.
+func (g *generator) run(ctx context.Context) {
+	queues := make([]chan int, g.opts.Workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan int, 100)
+		wg.Add(1)
+		go func(queue chan int) {
+			defer wg.Done()
+			for ns := range queue {
+				g.replay(ns)
+			}
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	var deadline <-chan time.Time
+	if g.opts.Duration > 0 {
+		timer := time.NewTimer(g.opts.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var tick <-chan time.Time
+	if g.opts.Rate > 0 {
+		ticker := time.NewTicker(pacing)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	start, released := time.Now(), 0
+	for g.opts.Events == 0 || released < g.opts.Events {
+		due := released + 1
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				return
+			case <-tick:
+			}
+			due = int(float64(g.opts.Rate) * time.Since(start).Seconds())
+		}
+
+		for ; released < due && (g.opts.Events == 0 || released < g.opts.Events); released++ {
+			ns := released % g.opts.Namespaces
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				return
+			case queues[ns%g.opts.Workers] <- ns:
+			}
+		}
+	}
+}
+
+// replay hands the next event of the namespace with the given index to the controller.
+func (g *generator) replay(index int) {
+	seq := g.sequences[index]
+	g.sequences[index]++
+	name := userName(index)
+
+	var err error
+	// every namespace starts with a build, the deployment config events are spread evenly across the later ones
+	if seq > 0 && int(float64(seq+1)*g.opts.DCRatio) > int(float64(seq)*g.opts.DCRatio) {
+		atomic.AddInt64(&g.dcs, 1)
+		g.probe.sent(name)
+		err = g.controller.HandleDeploymentConfig(deploymentConfigEvent(name, seq))
+	} else {
+		atomic.AddInt64(&g.builds, 1)
+		g.probe.sent(name)
+		err = g.controller.HandleBuild(buildEvent(name, seq))
+	}
+	if err != nil {
+		atomic.AddInt64(&g.errors, 1)
+		g.probe.unsent(name)
+	}
+}
+
+// userName returns the name of the user of the namespace with the given index.
+func userName(index int) string {
+	return "loadgen-" + strconv.Itoa(index)
+}
+
+// phases are the phases the builds of a namespace cycle through.
+var phases = []string{"New", "Running", "Complete"}
+
+// buildEvent returns the event of the build of the given user following the given number of events.
+func buildEvent(user string, seq int) model.Object {
+	now := time.Now()
+	build := model.Build{
+		Metadata: model.Metadata{
+			Name:            fmt.Sprintf("%s-%d", user, seq/len(phases)+1),
+			Namespace:       user,
+			ResourceVersion: strconv.Itoa(seq),
+		},
+		Status: model.Status{
+			Phase:          phases[seq%len(phases)],
+			StartTimestamp: model.BuildTime{Time: now},
+		},
+		Spec: model.Spec{Strategy: model.Strategy{Type: model.JenkinsPipelineStrategy}},
+	}
+	if build.Status.Phase == "Complete" {
+		build.Status.CompletionTimestamp = model.BuildTime{Time: now}
+	}
+	return model.Object{Type: "MODIFIED", Object: build}
+}
+
+// deploymentConfigEvent returns the event of the Jenkins deployment config of the given user following the given
+// number of events.
+func deploymentConfigEvent(user string, seq int) model.DCObject {
+	return model.DCObject{
+		Type: "MODIFIED",
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Name:            "jenkins",
+				Namespace:       namespace.Jenkins(user),
+				ResourceVersion: strconv.Itoa(seq),
+			},
+			Status: model.DCStatus{
+				Replicas:      1,
+				ReadyReplicas: seq % 2,
+				Conditions: []model.Condition{{
+					Type:           "Available",
+					Status:         strconv.FormatBool(seq%2 == 1),
+					LastUpdateTime: time.Now(),
+				}},
+			},
+			Spec: model.Spec{Replicas: 1},
+		},
+	}
+}
+
+// fakeOpenShift answers all requests of the Idler with an empty list, e.g. when backfilling the builds of a new
+// namespace.
+func fakeOpenShift(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"kind": "List", "items": []}`))
+}
+
+// tenantService resolves each namespace to a tenant of the same name whose Jenkins runs on the fake cluster.
+type tenantService struct {
+	apiURL string
+}
+
+func newTenantService(apiURL string) tenant.Service {
+	return &tenantService{apiURL: apiURL}
+}
+
+// GetTenantInfoByNamespace returns the tenant of the given namespace.
+func (t *tenantService) GetTenantInfoByNamespace(apiURL string, ns string) (tenant.InfoList, error) {
+	list := tenant.InfoList{Data: []tenant.InfoData{t.info(ns)}}
+	list.Meta.TotalCount = 1
+	return list, nil
+}
+
+// GetTenantInfoByUserID returns the tenant of the given ID, which is the name of its namespace.
+func (t *tenantService) GetTenantInfoByUserID(userID string) (tenant.Info, error) {
+	return tenant.Info{Data: t.info(userID)}, nil
+}
+
+// HasReachedMaxCapacity returns false, the fake cluster never reaches its capacity.
+func (t *tenantService) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	return false, nil
+}
+
+func (t *tenantService) info(user string) tenant.InfoData {
+	return tenant.InfoData{
+		ID:   user,
+		Type: "tenants",
+		Attributes: tenant.Attributes{Namespaces: []tenant.Namespace{
+			{Name: user, Type: "user", ClusterURL: t.apiURL},
+			{Name: namespace.Jenkins(user), Type: "jenkins", ClusterURL: t.apiURL},
+		}},
+	}
+}
+
+// probe measures the decision latency. It acts as the feature toggles consulted by the user idlers as soon as they
+// evaluate a user, reporting idling as disabled.
+type probe struct {
+	sync.Mutex
+	pending   map[string][]time.Time
+	latencies []time.Duration
+}
+
+func newProbe() *probe {
+	return &probe{pending: make(map[string][]time.Time)}
+}
+
+// sent records that an event of the given user got handed to the controller.
+func (p *probe) sent(user string) {
+	p.Lock()
+	defer p.Unlock()
+	p.pending[user] = append(p.pending[user], time.Now())
+}
+
+// unsent forgets the last event of the given user, its handling having failed.
+func (p *probe) unsent(user string) {
+	p.Lock()
+	defer p.Unlock()
+	if pending := p.pending[user]; len(pending) > 0 {
+		p.pending[user] = pending[:len(pending)-1]
+	}
+}
+
+// IsIdlerEnabled records the latency of the oldest event of the user not evaluated yet and returns false.
+func (p *probe) IsIdlerEnabled(target toggles.Target) (bool, error) {
+	now := time.Now()
+	p.Lock()
+	defer p.Unlock()
+	if pending := p.pending[target.UserID]; len(pending) > 0 {
+		p.latencies = append(p.latencies, now.Sub(pending[0]))
+		p.pending[target.UserID] = pending[1:]
+	}
+	return false, nil
+}
+
+// IsCheIdlerEnabled returns false.
+func (p *probe) IsCheIdlerEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
+// IsOOMLimitBumpEnabled returns false.
+func (p *probe) IsOOMLimitBumpEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
+// drain waits until the user idlers evaluated all events handed to the controller, or the timeout elapsed.
+func (p *probe) drain(ctx context.Context, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		p.Lock()
+		pending := 0
+		for _, times := range p.pending {
+			pending += len(times)
+		}
+		p.Unlock()
+		if pending == 0 {
+			return
+		}
+		time.Sleep(pacing)
+	}
+}
+
+// summarize adds the number of decisions and the latency percentiles to the report.
+func (p *probe) summarize(report *Report) {
+	p.Lock()
+	defer p.Unlock()
+
+	report.Decisions = int64(len(p.latencies))
+	if len(p.latencies) == 0 {
+		return
+	}
+	sort.Slice(p.latencies, func(i, j int) bool { return p.latencies[i] < p.latencies[j] })
+	percentile := func(q float64) time.Duration {
+		return p.latencies[int(q*float64(len(p.latencies)-1))]
+	}
+	report.LatencyP50 = percentile(0.50)
+	report.LatencyP95 = percentile(0.95)
+	report.LatencyP99 = percentile(0.99)
+	report.LatencyMax = p.latencies[len(p.latencies)-1]
+}
@@ -0,0 +1,90 @@
+package loadgen
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_options_validation(t *testing.T) {
+	valid := Options{Namespaces: 10, Workers: 2, Events: 100, DCRatio: 0.3}
+	assert.NoError(t, valid.Validate())
+
+	for name, modify := range map[string]func(o *Options){
+		"namespaces": func(o *Options) { o.Namespaces = 0 },
+		"workers":    func(o *Options) { o.Workers = 0 },
+		"rate":       func(o *Options) { o.Rate = -1 },
+		"limit":      func(o *Options) { o.Events = 0 },
+		"ratio":      func(o *Options) { o.DCRatio = 1.5 },
+	} {
+		o := valid
+		modify(&o)
+		assert.Error(t, o.Validate(), "Options with invalid %s should be rejected", name)
+	}
+}
+
+func Test_run_replays_events(t *testing.T) {
+	out := log.StandardLogger().Out
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(out)
+
+	config, err := configuration.New("")
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), config, Options{Namespaces: 5, Workers: 2, Events: 50, DCRatio: 0.2})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(50), report.Events)
+	assert.Equal(t, int64(0), report.Errors)
+	assert.Equal(t, int64(10), report.DCEvents, "Deployment config events should follow the ratio")
+	assert.Equal(t, 5, report.UserIdlers, "A user idler should be created per namespace")
+	assert.Equal(t, int64(50), report.Decisions, "Each event should reach the user idler")
+	assert.True(t, report.LatencyP50 <= report.LatencyP99 && report.LatencyP99 <= report.LatencyMax)
+	assert.True(t, report.Throughput > 0)
+}
+
+func Test_run_is_rate_limited(t *testing.T) {
+	out := log.StandardLogger().Out
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(out)
+
+	config, err := configuration.New("")
+	require.NoError(t, err)
+
+	report, err := Run(context.Background(), config, Options{Namespaces: 5, Workers: 1, Rate: 200, Duration: 250 * time.Millisecond})
+	require.NoError(t, err)
+	assert.InDelta(t, 50, report.Events, 10, "Events should be replayed at the rate")
+	assert.Equal(t, int64(0), report.DCEvents)
+}
+
+func benchmarkReplay(b *testing.B, dcRatio float64) {
+	out := log.StandardLogger().Out
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(out)
+
+	config, err := configuration.New("")
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	report, err := Run(context.Background(), config, Options{Namespaces: 1000, Workers: 8, Events: b.N, DCRatio: dcRatio})
+	b.StopTimer()
+	require.NoError(b, err)
+
+	b.ReportMetric(report.Throughput, "events/s")
+	b.ReportMetric(float64(report.LatencyP99.Microseconds()), "p99-µs")
+}
+
+func BenchmarkReplay_Builds(b *testing.B) {
+	benchmarkReplay(b, 0)
+}
+
+func BenchmarkReplay_Mixed(b *testing.B) {
+	benchmarkReplay(b, 0.3)
+}
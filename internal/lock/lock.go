@@ -0,0 +1,136 @@
+package lock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// LeaseName is the name of the Lease serializing the mutations of a Jenkins namespace.
+	LeaseName = "jenkins-idler-mutation"
+
+	// leaseDuration is the time after which the lease of a holder which did not release it, e.g. since its replica
+	// crashed, can be taken over. It exceeds MaxHold by a margin for the calls around a mutation.
+	leaseDuration = 2 * time.Minute
+
+	// MaxHold is the longest a mutation may wait while holding the lock, e.g. a reset awaiting its pods, so that its
+	// lease does not expire meanwhile.
+	MaxHold = 90 * time.Second
+
+	// retryInterval is the interval at which a contended lease is tried to be acquired again.
+	retryInterval = time.Second
+)
+
+var logger = logrus.WithField("component", "lock")
+
+// ErrContended is returned if the lock of a namespace could not be taken within the configured timeout, since
+// other mutations of the namespace kept holding it.
+var ErrContended = errors.New("another operation on the namespace is in progress")
+
+// Default is the Locker used by the Idler, nil until configured.
+var Default *Locker
+
+// Leaser acquires and releases Leases, e.g. the OpenShift client.
+type Leaser interface {
+	AcquireLease(apiURL string, bearerToken string, namespace string, name string, holder string, duration time.Duration) (bool, error)
+	ReleaseLease(apiURL string, bearerToken string, namespace string, name string, holder string) error
+}
+
+// Locker takes a lock per Jenkins namespace, backed by a Lease in the namespace, around the mutations of Jenkins,
+// i.e. idling, un-idling and resetting it. This way the mutations driven by several Idler replicas and by API
+// clients serialize instead of interleaving their scale-up and scale-down calls. A nil Locker does not lock.
+type Locker struct {
+	leaser   Leaser
+	identity string
+	timeout  time.Duration
+	clock    clock.Clock
+}
+
+// Held is a lock taken by Lock.
+type Held struct {
+	// Contended tells whether the lock was held by another mutation when it got requested, in which case the
+	// state of Jenkins might have changed in the meantime.
+	Contended bool
+
+	unlock func()
+}
+
+// Unlock releases the lock.
+func (h *Held) Unlock() {
+	if h.unlock != nil {
+		h.unlock()
+	}
+}
+
+// New creates a Locker as configured. It returns nil if locking is disabled.
+func New(config configuration.Configuration, leaser Leaser, clock clock.Clock) *Locker {
+	if !config.GetMutationLock() {
+		return nil
+	}
+
+	identity, err := os.Hostname()
+	if err != nil || identity == "" {
+		identity = "jenkins-idler"
+	}
+	return &Locker{
+		leaser:   leaser,
+		identity: identity,
+		timeout:  time.Duration(config.GetMutationLockTimeout()) * time.Second,
+		clock:    clock,
+	}
+}
+
+// Lock takes the lock of the namespace, waiting for up to the configured timeout while it is held by another
+// mutation. It returns ErrContended if the lock could not be taken in time.
+func (l *Locker) Lock(apiURL string, bearerToken string, namespace string) (*Held, error) {
+	if l == nil {
+		return &Held{}, nil
+	}
+
+	holder, err := l.holder()
+	if err != nil {
+		return nil, err
+	}
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL, "holder": holder})
+
+	started := l.clock.Now()
+	contended := false
+	for {
+		acquired, err := l.leaser.AcquireLease(apiURL, bearerToken, namespace, LeaseName, holder, leaseDuration)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			log.WithField("contended", contended).Debug("Locked namespace")
+			return &Held{Contended: contended, unlock: func() {
+				if err := l.leaser.ReleaseLease(apiURL, bearerToken, namespace, LeaseName, holder); err != nil {
+					log.WithField("err", err).Warn("Unable to unlock namespace, the lock expires instead")
+				}
+			}}, nil
+		}
+
+		contended = true
+		if l.clock.Since(started) >= l.timeout {
+			log.Warn("Giving up waiting for the lock of the namespace")
+			return nil, ErrContended
+		}
+		<-l.clock.After(retryInterval)
+	}
+}
+
+// holder returns a unique holder identity per lock, so that concurrent mutations of the same replica serialize as
+// well.
+func (l *Locker) holder() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return l.identity + "-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,73 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
+func Test_nil_locker(t *testing.T) {
+	l := New(&mock.Config{}, &mock.OpenShiftClient{}, clock.NewFake(now))
+	assert.Nil(t, l, "Locker should be disabled unless configured")
+
+	held, err := l.Lock("https://api.example.com/", "token", "john-jenkins")
+	require.NoError(t, err)
+	assert.False(t, held.Contended)
+	held.Unlock()
+}
+
+func Test_lock_serializes_mutations(t *testing.T) {
+	leaser := &mock.OpenShiftClient{}
+	c := clock.NewFake(now)
+	l := New(&mock.Config{MutationLock: true, MutationLockTimeout: 30}, leaser, c)
+	require.NotNil(t, l)
+
+	first, err := l.Lock("https://api.example.com/", "token", "john-jenkins")
+	require.NoError(t, err)
+	assert.False(t, first.Contended)
+
+	other, err := l.Lock("https://api.example.com/", "token", "jane-jenkins")
+	require.NoError(t, err, "Namespaces should be locked independently")
+	other.Unlock()
+
+	done := make(chan *Held)
+	go func() {
+		held, err := l.Lock("https://api.example.com/", "token", "john-jenkins")
+		assert.NoError(t, err)
+		done <- held
+	}()
+
+	c.BlockUntil(1)
+	first.Unlock()
+	c.Advance(retryInterval)
+
+	second := <-done
+	assert.True(t, second.Contended, "Second lock should have waited for the first one")
+	second.Unlock()
+	assert.Empty(t, leaser.LeaseHolders, "All locks should be released")
+}
+
+func Test_lock_gives_up(t *testing.T) {
+	leaser := &mock.OpenShiftClient{LeaseHolders: map[string]string{"john-jenkins": "other-replica"}}
+	c := clock.NewFake(now)
+	l := New(&mock.Config{MutationLock: true, MutationLockTimeout: 2}, leaser, c)
+
+	errs := make(chan error)
+	go func() {
+		_, err := l.Lock("https://api.example.com/", "token", "john-jenkins")
+		errs <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		c.BlockUntil(1)
+		c.Advance(retryInterval)
+	}
+	assert.Equal(t, ErrContended, <-errs)
+	assert.Equal(t, "other-replica", leaser.LeaseHolders["john-jenkins"], "Lock of another holder should be kept")
+}
@@ -0,0 +1,196 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ComponentField is the name of the log field identifying the component which emitted a log entry.
+	ComponentField = "component"
+
+	// NamespaceField is the name of the log field identifying the namespace a log entry refers to.
+	NamespaceField = "namespace"
+
+	// ClusterField is the name of the log field identifying the OpenShift cluster a log entry refers to.
+	ClusterField = "cluster"
+
+	// FormatJSON selects JSON formatted log output.
+	FormatJSON = "json"
+
+	// FormatText selects plain text log output.
+	FormatText = "text"
+
+	unknownComponent = "unknown"
+)
+
+var levels = &levelRegistry{
+	global:     log.InfoLevel,
+	components: make(map[string]log.Level),
+}
+
+// levelRegistry keeps track of the global log level as well as the per component overrides.
+type levelRegistry struct {
+	sync.RWMutex
+	global     log.Level
+	components map[string]log.Level
+}
+
+// enabled returns whether an entry of the given level and component should be logged.
+func (l *levelRegistry) enabled(level log.Level, component string) bool {
+	l.RLock()
+	defer l.RUnlock()
+
+	if componentLevel, ok := l.components[component]; ok {
+		return level <= componentLevel
+	}
+	return level <= l.global
+}
+
+// mostVerbose returns the most verbose of all configured levels.
+func (l *levelRegistry) mostVerbose() log.Level {
+	l.RLock()
+	defer l.RUnlock()
+
+	level := l.global
+	for _, componentLevel := range l.components {
+		if componentLevel > level {
+			level = componentLevel
+		}
+	}
+	return level
+}
+
+// Level returns the global log level.
+func Level() log.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+	return levels.global
+}
+
+// ComponentLevels returns a copy of the per component log level overrides.
+func ComponentLevels() map[string]log.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+
+	result := make(map[string]log.Level, len(levels.components))
+	for component, level := range levels.components {
+		result[component] = level
+	}
+	return result
+}
+
+// SetLevel sets the global log level which applies to all components without an explicit override.
+func SetLevel(level log.Level) {
+	levels.Lock()
+	levels.global = level
+	levels.Unlock()
+	apply()
+}
+
+// SetComponentLevel overrides the log level of the given component.
+func SetComponentLevel(component string, level log.Level) {
+	levels.Lock()
+	levels.components[component] = level
+	levels.Unlock()
+	apply()
+}
+
+// ResetComponentLevel removes the log level override of the given component.
+func ResetComponentLevel(component string) {
+	levels.Lock()
+	delete(levels.components, component)
+	levels.Unlock()
+	apply()
+}
+
+// Configure sets up the standard logger using the given output format, global log level and per component
+// log level overrides. Existing overrides are replaced.
+func Configure(format string, level string, componentLevels map[string]string) error {
+	var formatter log.Formatter
+	switch format {
+	case FormatJSON, "":
+		formatter = &log.JSONFormatter{}
+	case FormatText:
+		formatter = &log.TextFormatter{}
+	default:
+		return fmt.Errorf("unknown log format '%s'", format)
+	}
+
+	globalLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	components := make(map[string]log.Level, len(componentLevels))
+	for component, componentLevel := range componentLevels {
+		l, err := log.ParseLevel(componentLevel)
+		if err != nil {
+			return fmt.Errorf("invalid log level for component '%s': %s", component, err)
+		}
+		components[component] = l
+	}
+
+	levels.Lock()
+	levels.global = globalLevel
+	levels.components = components
+	levels.Unlock()
+
+	log.SetFormatter(NewFormatter(formatter))
+	apply()
+	return nil
+}
+
+// apply sets the level of the standard logger to the most verbose configured level. Entries not
+// enabled for their component are dropped by the Formatter.
+func apply() {
+	log.SetLevel(levels.mostVerbose())
+}
+
+// Formatter wraps a logrus formatter and drops all entries which are not enabled for the
+// component they got logged by. Entries without component field get logged as component 'unknown',
+// so that every line can be attributed to a component. Secrets like bearer tokens are redacted from the
+// message and the fields.
+type Formatter struct {
+	log.Formatter
+}
+
+// NewFormatter wraps the given formatter into a Formatter.
+func NewFormatter(formatter log.Formatter) *Formatter {
+	return &Formatter{Formatter: formatter}
+}
+
+// Format formats the given entry using the wrapped formatter if the entry's level is enabled for its component.
+func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
+	component, ok := entry.Data[ComponentField].(string)
+	if !levels.enabled(entry.Level, component) {
+		return []byte{}, nil
+	}
+
+	// the data map is shared with the entry the log call was made on, hence copy before modifying it
+	data := make(log.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = redactValue(v)
+	}
+	if !ok {
+		data[ComponentField] = unknownComponent
+	}
+	redacted := *entry
+	redacted.Data = data
+	redacted.Message = redact.String(entry.Message)
+	return f.Formatter.Format(&redacted)
+}
+
+// redactValue redacts secrets from string and error field values.
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redact.String(v)
+	case error:
+		return redact.String(v.Error())
+	}
+	return value
+}
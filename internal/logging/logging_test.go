@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_component_level_overrides_global_level(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFormatter(NewFormatter(&log.TextFormatter{DisableTimestamp: true}))
+	defer SetLevel(log.InfoLevel)
+	defer ResetComponentLevel("controller")
+
+	SetLevel(log.InfoLevel)
+	SetComponentLevel("controller", log.DebugLevel)
+	assert.Equal(t, log.DebugLevel, log.GetLevel(), "standard logger should use the most verbose level")
+
+	log.WithField(ComponentField, "controller").Debug("controller debug")
+	log.WithField(ComponentField, "router").Debug("router debug")
+	log.WithField(ComponentField, "router").Info("router info")
+
+	assert.Contains(t, buf.String(), "controller debug")
+	assert.NotContains(t, buf.String(), "router debug")
+	assert.Contains(t, buf.String(), "router info")
+
+	ResetComponentLevel("controller")
+	assert.Equal(t, log.InfoLevel, log.GetLevel())
+	assert.Empty(t, ComponentLevels())
+}
+
+func Test_component_level_can_be_less_verbose(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFormatter(NewFormatter(&log.TextFormatter{DisableTimestamp: true}))
+	defer ResetComponentLevel("metrics")
+
+	SetLevel(log.InfoLevel)
+	SetComponentLevel("metrics", log.ErrorLevel)
+
+	log.WithField(ComponentField, "metrics").Warn("metrics warning")
+	log.WithField(ComponentField, "main").Warn("main warning")
+
+	assert.NotContains(t, buf.String(), "metrics warning")
+	assert.Contains(t, buf.String(), "main warning")
+	assert.Equal(t, map[string]log.Level{"metrics": log.ErrorLevel}, ComponentLevels())
+}
+
+func Test_secrets_are_redacted(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFormatter(NewFormatter(&log.TextFormatter{DisableTimestamp: true}))
+
+	log.WithFields(log.Fields{
+		"request": "GET /oapi/v1/namespaces HTTP/1.1\r\nAuthorization: Bearer s3cr3t-t0ken\r\n",
+		"err":     errors.New("Get https://api.example.com/?access_token=s3cr3t-t0ken: 401"),
+	}).Error("Request with bearer s3cr3t-t0ken failed")
+
+	assert.NotContains(t, buf.String(), "s3cr3t-t0ken")
+	assert.Contains(t, buf.String(), "Request with bearer ***")
+}
+
+func Test_configure(t *testing.T) {
+	defer Configure(FormatJSON, "info", nil)
+
+	err := Configure("xml", "info", nil)
+	assert.EqualError(t, err, "unknown log format 'xml'")
+
+	err = Configure(FormatJSON, "info", map[string]string{"controller": "chatty"})
+	assert.Error(t, err, "invalid component level should be rejected")
+
+	err = Configure(FormatJSON, "warning", map[string]string{"controller": "debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, log.WarnLevel, Level())
+	assert.Equal(t, map[string]log.Level{"controller": log.DebugLevel}, ComponentLevels())
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.WithField(NamespaceField, "foo-jenkins").Warn("no component")
+
+	entry := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, unknownComponent, entry[ComponentField], "missing component should be added")
+	assert.Equal(t, "foo-jenkins", entry[NamespaceField])
+}
@@ -0,0 +1,177 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// IdlerConfigGroupVersion is the API group and version of the JenkinsIdlerConfig custom resource.
+	IdlerConfigGroupVersion = "idler.fabric8.io/v1alpha1"
+	// IdlerConfigResource is the plural resource name of the JenkinsIdlerConfig custom resource.
+	IdlerConfigResource = "jenkinsidlerconfigs"
+	// IdlerConfigName is the name of the JenkinsIdlerConfig applied to the Jenkins of its namespace. Configs of other
+	// names are ignored, so that the overrides of a namespace are unambiguous.
+	IdlerConfigName = "jenkins"
+)
+
+// IdlerConfigObject is a watch event of a JenkinsIdlerConfig.
+type IdlerConfigObject struct {
+	Type   string      `json:"type"`
+	Object IdlerConfig `json:"object"`
+}
+
+// IdlerConfig is the JenkinsIdlerConfig custom resource, by which users configure the idling of the Jenkins in their
+// namespace, e.g. via `oc apply`.
+type IdlerConfig struct {
+	Metadata Metadata        `json:"metadata"`
+	Spec     IdlerConfigSpec `json:"spec"`
+}
+
+// IdlerConfigSpec is the specification of a JenkinsIdlerConfig.
+type IdlerConfigSpec struct {
+	// IdleTimeout is the time Jenkins keeps running after its last activity, e.g. "4h".
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// Schedule lists the windows within which Jenkins is kept running, e.g. "Mon-Fri 08:00-18:00" (UTC).
+	Schedule []string `json:"schedule,omitempty"`
+	// Disabled disables idling altogether.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Overrides is the idling configuration of a namespace set by its JenkinsIdlerConfig. It takes precedence over the
+// idler.openshift.io annotations of the deployment config.
+type Overrides struct {
+	IdleAfter time.Duration
+	Schedule  Schedule
+	Disabled  bool
+}
+
+// Overrides returns the overrides specified by the config.
+func (c IdlerConfig) Overrides() (Overrides, error) {
+	overrides := Overrides{Disabled: c.Spec.Disabled}
+	if c.Spec.IdleTimeout != "" {
+		d, err := time.ParseDuration(c.Spec.IdleTimeout)
+		if err != nil {
+			return Overrides{}, err
+		}
+		if d <= 0 {
+			return Overrides{}, fmt.Errorf("idle timeout %s is not positive", c.Spec.IdleTimeout)
+		}
+		overrides.IdleAfter = d
+	}
+
+	schedule, err := ParseSchedule(c.Spec.Schedule)
+	if err != nil {
+		return Overrides{}, err
+	}
+	overrides.Schedule = schedule
+	return overrides, nil
+}
+
+// Schedule is a set of weekly recurring windows.
+type Schedule []Window
+
+// Window is a weekly recurring window of time in UTC, starting on the given days. A window ending before it starts
+// ends on the following day.
+type Window struct {
+	Days  [7]bool
+	Start time.Duration
+	End   time.Duration
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseSchedule parses windows of the form "[days ]HH:MM-HH:MM", where days is a comma separated list of days resp.
+// ranges of days, e.g. "Mon-Fri 08:00-18:00" or "Sat,Sun 10:00-12:00". Without days, the window recurs daily.
+func ParseSchedule(windows []string) (Schedule, error) {
+	var schedule Schedule
+	for _, w := range windows {
+		window, err := parseWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule window %q: %s", w, err)
+		}
+		schedule = append(schedule, window)
+	}
+	return schedule, nil
+}
+
+func parseWindow(s string) (Window, error) {
+	w := Window{}
+	fields := strings.Fields(s)
+	switch len(fields) {
+	case 1:
+		w.Days = [7]bool{true, true, true, true, true, true, true}
+	case 2:
+		for _, days := range strings.Split(strings.ToLower(fields[0]), ",") {
+			bounds := strings.SplitN(days, "-", 2)
+			from, ok := weekdays[bounds[0]]
+			if !ok {
+				return w, fmt.Errorf("unknown day %s", bounds[0])
+			}
+			to := from
+			if len(bounds) == 2 {
+				if to, ok = weekdays[bounds[1]]; !ok {
+					return w, fmt.Errorf("unknown day %s", bounds[1])
+				}
+			}
+			for d := from; ; d = (d + 1) % 7 {
+				w.Days[d] = true
+				if d == to {
+					break
+				}
+			}
+		}
+	default:
+		return w, fmt.Errorf("expected [days ]HH:MM-HH:MM")
+	}
+
+	times := strings.SplitN(fields[len(fields)-1], "-", 2)
+	if len(times) != 2 {
+		return w, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+	var err error
+	if w.Start, err = parseTimeOfDay(times[0]); err != nil {
+		return w, err
+	}
+	if w.End, err = parseTimeOfDay(times[1]); err != nil {
+		return w, err
+	}
+	if w.Start == w.End {
+		return w, fmt.Errorf("window is empty")
+	}
+	return w, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %s", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active returns whether the given time lies within any of the windows of the schedule.
+func (s Schedule) Active(t time.Time) bool {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := t.Sub(midnight)
+	yesterday := (t.Weekday() + 6) % 7
+
+	for _, w := range s {
+		if w.Start < w.End {
+			if w.Days[t.Weekday()] && offset >= w.Start && offset < w.End {
+				return true
+			}
+			continue
+		}
+		// The window crosses midnight.
+		if (w.Days[t.Weekday()] && offset >= w.Start) || (w.Days[yesterday] && offset < w.End) {
+			return true
+		}
+	}
+	return false
+}
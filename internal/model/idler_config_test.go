@@ -0,0 +1,69 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parse_schedule(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"Mon-Fri 08:00-18:00", "Sat,Sun 22:00-02:00", "12:00-13:00"})
+	require.NoError(t, err)
+	require.Len(t, schedule, 3)
+
+	assert.Equal(t, [7]bool{false, true, true, true, true, true, false}, schedule[0].Days)
+	assert.Equal(t, 8*time.Hour, schedule[0].Start)
+	assert.Equal(t, 18*time.Hour, schedule[0].End)
+	assert.Equal(t, [7]bool{true, false, false, false, false, false, true}, schedule[1].Days)
+	assert.Equal(t, [7]bool{true, true, true, true, true, true, true}, schedule[2].Days)
+
+	for _, invalid := range []string{"", "Mon", "Someday 08:00-18:00", "Mon 08:00", "Mon 08:00-25:00", "10:00-10:00"} {
+		_, err := ParseSchedule([]string{invalid})
+		assert.Error(t, err, "Expected %q to be rejected", invalid)
+	}
+}
+
+func Test_schedule_active(t *testing.T) {
+	schedule, err := ParseSchedule([]string{"Mon-Fri 08:00-18:00", "Sat 22:00-02:00"})
+	require.NoError(t, err)
+
+	// 2018-06-04 is a Monday.
+	monday := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at     time.Time
+		active bool
+	}{
+		{monday.Add(7 * time.Hour), false},
+		{monday.Add(8 * time.Hour), true},
+		{monday.Add(18 * time.Hour), false},
+		{monday.Add(5*24*time.Hour + 9*time.Hour), false},
+		{monday.Add(5*24*time.Hour + 23*time.Hour), true},
+		{monday.Add(6*24*time.Hour + time.Hour), true},
+		{monday.Add(6*24*time.Hour + 3*time.Hour), false},
+		{monday.Add(-time.Hour), false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.active, schedule.Active(test.at), "Unexpected activity at %s", test.at.Format(time.RFC1123))
+	}
+	assert.False(t, Schedule(nil).Active(monday))
+}
+
+func Test_idler_config_overrides(t *testing.T) {
+	cfg := IdlerConfig{Spec: IdlerConfigSpec{IdleTimeout: "90m", Schedule: []string{"09:00-17:00"}, Disabled: true}}
+	overrides, err := cfg.Overrides()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, overrides.IdleAfter)
+	assert.Len(t, overrides.Schedule, 1)
+	assert.True(t, overrides.Disabled)
+
+	overrides, err = IdlerConfig{}.Overrides()
+	require.NoError(t, err)
+	assert.Equal(t, Overrides{}, overrides)
+
+	for _, timeout := range []string{"soon", "-1h", "0s"} {
+		_, err := IdlerConfig{Spec: IdlerConfigSpec{IdleTimeout: timeout}}.Overrides()
+		assert.Error(t, err, "Expected idle timeout %s to be rejected", timeout)
+	}
+}
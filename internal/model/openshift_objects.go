@@ -3,8 +3,12 @@ package model
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"k8s.io/api/core/v1"
 )
 
 // OpenShift related structs
@@ -21,6 +25,14 @@ const (
 	PodStarting = 2
 	// PodRunning state is when Pods are running.
 	PodRunning = 3
+	// PodCrashLooping state is when Pods keep crashing after being started.
+	PodCrashLooping = 4
+	// PodImagePullFailed state is when the image of the Pods cannot be pulled.
+	PodImagePullFailed = 5
+	// PodUnschedulable state is when Pods are pending since they cannot be scheduled on any node.
+	PodUnschedulable = 6
+	// PodQuotaExceeded state is when Pods cannot be created since the resource quota of the namespace is exceeded.
+	PodQuotaExceeded = 7
 )
 
 func (state PodState) String() string {
@@ -29,13 +41,39 @@ func (state PodState) String() string {
 		"idled",
 		"starting",
 		"running",
+		"crash_looping",
+		"image_pull_failed",
+		"unschedulable",
+		"quota_exceeded",
 	}
-	if state < PodStateUnknown || state > PodRunning {
+	if state < PodStateUnknown || state > PodQuotaExceeded {
 		state = 0
 	}
 	return states[state]
 }
 
+// PodRestarts describes how often the containers of the Pods of a service got restarted.
+type PodRestarts struct {
+	Count     int
+	OOMKilled bool
+}
+
+// PodObject is Pod Object.
+type PodObject struct {
+	Type   string
+	Object Pod
+}
+
+// Pod summarizes the state of a Jenkins Pod as observed by the pod watch.
+type Pod struct {
+	Name      string
+	Namespace string
+	Phase     string
+	Restarts  PodRestarts
+	// Failure is the reason the Pod fails to start or PodStateUnknown if it is not failing.
+	Failure PodState
+}
+
 // Object is Build Object.
 type Object struct {
 	Type   string `json:"type"`
@@ -54,20 +92,70 @@ type BuildList struct {
 	Items []Build `json:"items"`
 }
 
+// API versions of the builds served by the legacy OpenShift 3.x API (/oapi/v1) resp. the group-based API
+// (/apis/build.openshift.io/v1), the only one left in OpenShift 4.x.
+const (
+	LegacyBuildAPIVersion = "v1"
+	BuildAPIVersion       = "build.openshift.io/v1"
+)
+
+// JenkinsPipelineStrategy is the strategy of the builds run by Jenkins, the only ones the Idler takes into account.
+const JenkinsPipelineStrategy = "JenkinsPipeline"
+
 // Build encapsulates the inputs needed to produce a new deployable image,
 // as well as the status of the execution and a reference to the Pod which executed the build.
 type Build struct {
-	Metadata Metadata `json:"metadata"`
-	Status   Status   `json:"status"`
-	Spec     Spec     `json:"spec"`
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Kind       string   `json:"kind,omitempty"`
+	Metadata   Metadata `json:"metadata"`
+	Status     Status   `json:"status"`
+	Spec       Spec     `json:"spec"`
+}
+
+// PipelineStage is a stage of the pipeline run by a build, as reported by the Jenkins sync plugin.
+type PipelineStage struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// pipelineStatus is the status of the pipeline run by a build, as annotated by the Jenkins sync plugin.
+type pipelineStatus struct {
+	Stages []PipelineStage `json:"stages"`
+}
+
+// runningStageStatuses are the statuses of a pipeline stage which has not finished yet, including stages waiting
+// for an input, e.g. an approval.
+var runningStageStatuses = map[string]bool{
+	"IN_PROGRESS":          true,
+	"PAUSED_PENDING_INPUT": true,
+}
+
+// RunningStage returns the pipeline stage the build is in, according to the openshift.io/jenkins-status-json
+// annotation of the Jenkins sync plugin, and false if no stage is running or the annotation is missing.
+func (b Build) RunningStage() (PipelineStage, bool) {
+	if b.Metadata.Annotations.JenkinsStatus == "" {
+		return PipelineStage{}, false
+	}
+
+	var status pipelineStatus
+	if err := json.Unmarshal([]byte(b.Metadata.Annotations.JenkinsStatus), &status); err != nil {
+		return PipelineStage{}, false
+	}
+	for i := len(status.Stages) - 1; i >= 0; i-- {
+		if runningStageStatuses[status.Stages[i].Status] {
+			return status.Stages[i], true
+		}
+	}
+	return PipelineStage{}, false
 }
 
 // Metadata used in Build.
 type Metadata struct {
-	Name        string      `json:"name,omitempty"`
-	Namespace   string      `json:"namespace,omitempty"`
-	Annotations Annotations `json:"annotations"`
-	Generation  int
+	Name            string      `json:"name,omitempty"`
+	Namespace       string      `json:"namespace,omitempty"`
+	ResourceVersion string      `json:"resourceVersion,omitempty"`
+	Annotations     Annotations `json:"annotations"`
+	Generation      int
 }
 
 // Annotations is a set of key, value pairs added to custom deployer and lifecycle pre/post hook pods.
@@ -79,6 +167,47 @@ type Annotations struct {
 	IdledAt          string `json:"idling.alpha.openshift.io/idled-at,omitempty"`
 	UnidleTargets    string `json:"idling.alpha.openshift.io/unidle-targets,omitempty"`
 	PrevScale        string `json:"idling.alpha.openshift.io/previous-scale,omitempty"`
+	UnIdledAt        string `json:"jenkins-idler.fabric8.io/unidled-at,omitempty"`
+	IdlerSkip        string `json:"idler.openshift.io/skip,omitempty"`
+	IdlerTimeout     string `json:"idler.openshift.io/timeout,omitempty"`
+	JenkinsStatus    string `json:"openshift.io/jenkins-status-json,omitempty"`
+}
+
+// UnIdledAtAnnotation is the annotation of the deployment config recording when the idler un-idled Jenkins.
+const UnIdledAtAnnotation = "jenkins-idler.fabric8.io/unidled-at"
+
+// The annotations of the deployment config recording the provenance of the last idle resp. un-idle, so that cluster
+// admins can tell why a service got scaled without access to the logs of the idler.
+const (
+	LastActionAnnotation  = "idler.fabric8.io/last-action"
+	TriggeredByAnnotation = "idler.fabric8.io/triggered-by"
+	ReasonAnnotation      = "idler.fabric8.io/reason"
+	TimestampAnnotation   = "idler.fabric8.io/timestamp"
+)
+
+const (
+	// TriggeredByIdler denotes actions triggered by the idler itself, i.e. by evaluating the idle conditions.
+	TriggeredByIdler = "idler"
+	// TriggeredByAPI denotes actions triggered via the API of the idler, e.g. by the proxy.
+	TriggeredByAPI = "api"
+)
+
+// Provenance describes an idle resp. un-idle of a service, what triggered it and why.
+type Provenance struct {
+	Action      string
+	TriggeredBy string
+	Reason      string
+	Timestamp   time.Time
+}
+
+// Annotations returns the deployment config annotations recording the provenance.
+func (p Provenance) Annotations() map[string]string {
+	return map[string]string{
+		LastActionAnnotation:  p.Action,
+		TriggeredByAnnotation: p.TriggeredBy,
+		ReasonAnnotation:      p.Reason,
+		TimestampAnnotation:   p.Timestamp.UTC().Format(time.RFC3339),
+	}
 }
 
 // Endpoint is the how a service is getting accessed.
@@ -87,11 +216,13 @@ type Endpoint struct {
 	Metadata Metadata `json:"metadata"`
 }
 
-// Status is the current status of the build.
+// Status is the current status of the build. Builds of newer clusters additionally carry a condition per phase,
+// the one with status True being the current phase.
 type Status struct {
-	Phase               string    `json:"phase"`
-	StartTimestamp      BuildTime `json:"startTimestamp"`
-	CompletionTimestamp BuildTime `json:"completionTimestamp"`
+	Phase               string      `json:"phase"`
+	StartTimestamp      BuildTime   `json:"startTimestamp"`
+	CompletionTimestamp BuildTime   `json:"completionTimestamp"`
+	Conditions          []Condition `json:"conditions,omitempty"`
 }
 
 // DeploymentConfig define the template for a pod and manages deploying new images or configuration changes.
@@ -102,6 +233,101 @@ type DeploymentConfig struct {
 	Spec     Spec     `json:"spec,omitempty"`
 }
 
+// PodState returns the state of the pods of the deployment config, derived from its replica counts.
+func (dc DeploymentConfig) PodState() PodState {
+	if dc.Status.Replicas == 0 {
+		return PodIdled
+	}
+	if dc.Status.ReadyReplicas == 0 {
+		return PodStarting
+	}
+	return PodRunning
+}
+
+// IdledAt returns the time the deployment config got idled or the zero time if it is not annotated as idled.
+func (dc DeploymentConfig) IdledAt() time.Time {
+	idledAt, err := time.Parse(time.RFC3339, dc.Metadata.Annotations.IdledAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return idledAt
+}
+
+// ManuallyUnIdled returns true if the deployment config got scaled up after it got idled, without being
+// un-idled by the idler, e.g. by the user running `oc scale`.
+func (dc DeploymentConfig) ManuallyUnIdled() bool {
+	idledAt := dc.IdledAt()
+	if dc.Spec.Replicas == 0 || idledAt.IsZero() {
+		return false
+	}
+
+	unIdledAt, err := time.Parse(time.RFC3339, dc.Metadata.Annotations.UnIdledAt)
+	if err != nil {
+		return true
+	}
+	return unIdledAt.Before(idledAt)
+}
+
+// rolloutCompleteReason is the reason of the Progressing condition of a deployment config whose latest rollout
+// completed.
+const rolloutCompleteReason = "NewReplicationControllerAvailable"
+
+// RolloutInProgress returns true if the latest rollout of the deployment config has neither completed nor failed
+// yet, according to its Progressing condition.
+func (dc DeploymentConfig) RolloutInProgress() bool {
+	if dc.Spec.Replicas == 0 {
+		return false
+	}
+	progressing, err := dc.Status.GetByType("Progressing")
+	if err != nil {
+		return false
+	}
+	return progressing.Status == "True" && progressing.Reason != rolloutCompleteReason
+}
+
+// SkipIdling returns true if the deployment config opts out of idling via the idler.openshift.io/skip annotation.
+func (dc DeploymentConfig) SkipIdling() bool {
+	skip, err := strconv.ParseBool(dc.Metadata.Annotations.IdlerSkip)
+	return err == nil && skip
+}
+
+// IdleTimeout returns the idle timeout set via the idler.openshift.io/timeout annotation, e.g. "4h", or zero if
+// the annotation is not set.
+func (dc DeploymentConfig) IdleTimeout() (time.Duration, error) {
+	timeout := dc.Metadata.Annotations.IdlerTimeout
+	if timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("idle timeout %s is not positive", timeout)
+	}
+	return d, nil
+}
+
+// Resources returns the resources requested by resp. limited for the containers of the pods of the deployment
+// config, summed up.
+func (dc DeploymentConfig) Resources() Resources {
+	var r Resources
+	for _, c := range dc.Spec.Template.Spec.Containers {
+		r.CPURequest += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		r.CPULimit += c.Resources.Limits.Cpu().AsApproximateFloat64()
+		r.MemoryRequest += c.Resources.Requests.Memory().Value()
+		r.MemoryLimit += c.Resources.Limits.Memory().Value()
+	}
+	return r
+}
+
+// DeploymentConfigList is list of all DeploymentConfig.
+type DeploymentConfigList struct {
+	Kind  string
+	Items []DeploymentConfig `json:"items"`
+}
+
 // DCStatus represents the current deployment state.
 type DCStatus struct {
 	Replicas            int
@@ -109,19 +335,55 @@ type DCStatus struct {
 	Conditions          []Condition
 	ObservedGeneration  int `json:"observedGeneration,omitempty"`
 	UnavailableReplicas int `json:"unavailableReplicas,omitempty"`
+	LatestVersion       int `json:"latestVersion,omitempty"`
 }
 
 // Condition covers changes to Build.
 type Condition struct {
-	Type           string
-	LastUpdateTime time.Time
-	Status         string
+	Type               string
+	LastUpdateTime     time.Time
+	LastTransitionTime time.Time
+	Status             string
+	Reason             string
 }
 
-// Spec holds all the input necessary to produce a new build, and the conditions when to trigger them.
+// Spec holds all the input necessary to produce a new build, and the conditions when to trigger them. For deployment
+// configs, it holds the template of their pods.
 type Spec struct {
 	Replicas int `json:"replicas"`
 	Strategy Strategy
+	Template PodTemplate `json:"template"`
+}
+
+// PodTemplate is the template the pods of a deployment config are created from.
+type PodTemplate struct {
+	Spec PodSpec `json:"spec"`
+}
+
+// PodSpec lists the containers of a pod.
+type PodSpec struct {
+	Containers []Container `json:"containers"`
+}
+
+// Container is a container of a pod along with the resources it requests and is limited to.
+type Container struct {
+	Name      string                  `json:"name"`
+	Resources v1.ResourceRequirements `json:"resources"`
+}
+
+// Resources are the CPU in cores and the memory in bytes requested by resp. limited for the containers of Jenkins.
+type Resources struct {
+	CPURequest    float64 `json:"cpu_request_cores"`
+	CPULimit      float64 `json:"cpu_limit_cores"`
+	MemoryRequest int64   `json:"memory_request_bytes"`
+	MemoryLimit   int64   `json:"memory_limit_bytes"`
+}
+
+// Usage is the CPU in cores and the memory in bytes the containers of Jenkins consumed as of the given time.
+type Usage struct {
+	CPU    float64   `json:"cpu_cores"`
+	Memory int64     `json:"memory_bytes"`
+	Time   time.Time `json:"time"`
 }
 
 // Strategy defines how to perform a build.
@@ -182,10 +444,31 @@ func (s *Status) UnmarshalJSON(b []byte) (err error) {
 	}
 
 	*s = Status(*ns)
+	if s.Phase == "" {
+		s.Phase = s.conditionPhase()
+	}
 
 	return
 }
 
+// conditionPhase returns the phase of the build as given by its conditions, i.e. the type of the condition with
+// status True which transitioned last, or the empty string if there is none.
+func (s Status) conditionPhase() string {
+	var current *Condition
+	for i, c := range s.Conditions {
+		if c.Status != "True" {
+			continue
+		}
+		if current == nil || !c.LastTransitionTime.Before(current.LastTransitionTime) {
+			current = &s.Conditions[i]
+		}
+	}
+	if current == nil {
+		return ""
+	}
+	return current.Type
+}
+
 // GetByType gets condition by its type from Conditions of DCStatus.
 func (s DCStatus) GetByType(t string) (Condition, error) {
 	for _, c := range s.Conditions {
@@ -197,13 +480,28 @@ func (s DCStatus) GetByType(t string) (Condition, error) {
 	return Condition{}, fmt.Errorf("could not find condition '%s'", t)
 }
 
-// Phases are points in the build lifecycle.
+// Phases are points in the build lifecycle, mapped to 1 if a build in that phase is active by default and to 0
+// otherwise.
 var Phases = map[string]int{
 	"Finished":  0,
 	"Complete":  0,
 	"Failed":    0,
 	"Cancelled": 0,
+	"Error":     0,
+	"Timeout":   0,
 	"Pending":   1,
 	"New":       1,
 	"Running":   1,
 }
+
+// ActivePhases returns the sorted phases in which a build is active by default.
+func ActivePhases() []string {
+	var active []string
+	for phase, a := range Phases {
+		if a == 1 {
+			active = append(active, phase)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
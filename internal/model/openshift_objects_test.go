@@ -0,0 +1,171 @@
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_deployment_config_pod_state(t *testing.T) {
+	assert.Equal(t, PodState(PodIdled), DeploymentConfig{}.PodState())
+	assert.Equal(t, PodState(PodStarting), DeploymentConfig{Status: DCStatus{Replicas: 1}}.PodState())
+	assert.Equal(t, PodState(PodRunning), DeploymentConfig{Status: DCStatus{Replicas: 1, ReadyReplicas: 1}}.PodState())
+}
+
+func Test_deployment_config_manually_unidled(t *testing.T) {
+	tests := []struct {
+		name      string
+		replicas  int
+		idledAt   string
+		unIdledAt string
+		manual    bool
+	}{
+		{name: "idled", replicas: 0, idledAt: "2018-04-11T08:00:00Z", manual: false},
+		{name: "never idled", replicas: 1, manual: false},
+		{name: "scaled up without un-idle", replicas: 1, idledAt: "2018-04-11T08:00:00Z", manual: true},
+		{name: "scaled up after earlier un-idle", replicas: 1, idledAt: "2018-04-11T08:00:00Z", unIdledAt: "2018-04-11T07:00:00Z", manual: true},
+		{name: "un-idled by idler", replicas: 1, idledAt: "2018-04-11T08:00:00Z", unIdledAt: "2018-04-11T09:00:00.123Z", manual: false},
+	}
+
+	for _, test := range tests {
+		dc := DeploymentConfig{
+			Metadata: Metadata{Annotations: Annotations{IdledAt: test.idledAt, UnIdledAt: test.unIdledAt}},
+			Spec:     Spec{Replicas: test.replicas},
+		}
+		assert.Equal(t, test.manual, dc.ManuallyUnIdled(), test.name)
+	}
+}
+
+func Test_deployment_config_idling_annotations(t *testing.T) {
+	dc := DeploymentConfig{}
+	assert.False(t, dc.SkipIdling())
+	timeout, err := dc.IdleTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), timeout)
+
+	dc.Metadata.Annotations = Annotations{IdlerSkip: "true", IdlerTimeout: "4h"}
+	assert.True(t, dc.SkipIdling())
+	timeout, err = dc.IdleTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, 4*time.Hour, timeout)
+
+	for _, invalid := range []string{"4 hours", "-1h"} {
+		dc.Metadata.Annotations.IdlerTimeout = invalid
+		_, err = dc.IdleTimeout()
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_deployment_config_rollout_in_progress(t *testing.T) {
+	dc := DeploymentConfig{}
+	require.NoError(t, json.Unmarshal([]byte(`{"spec": {"replicas": 1}, "status": {"conditions": [
+		{"type": "Available", "status": "True"},
+		{"type": "Progressing", "status": "True", "reason": "ReplicationControllerUpdated"}
+	]}}`), &dc))
+	assert.True(t, dc.RolloutInProgress())
+
+	dc.Status.Conditions[1].Reason = "NewReplicationControllerAvailable"
+	assert.False(t, dc.RolloutInProgress(), "a completed rollout should not be in progress")
+
+	dc.Status.Conditions[1] = Condition{Type: "Progressing", Status: "False", Reason: "ProgressDeadlineExceeded"}
+	assert.False(t, dc.RolloutInProgress(), "a failed rollout should not be in progress")
+
+	dc.Status.Conditions = nil
+	assert.False(t, dc.RolloutInProgress())
+}
+
+func Test_deployment_config_resources(t *testing.T) {
+	assert.Equal(t, Resources{}, DeploymentConfig{}.Resources())
+
+	dc := DeploymentConfig{}
+	require.NoError(t, json.Unmarshal([]byte(`{"spec": {"replicas": 1, "template": {"spec": {"containers": [
+		{"name": "jenkins", "resources": {"requests": {"cpu": "500m", "memory": "1Gi"}, "limits": {"cpu": "2", "memory": "2Gi"}}},
+		{"name": "proxy", "resources": {"requests": {"cpu": "100m", "memory": "128Mi"}}}
+	]}}}}`), &dc))
+	assert.Equal(t, Resources{
+		CPURequest:    0.6,
+		CPULimit:      2,
+		MemoryRequest: 1152 * 1024 * 1024,
+		MemoryLimit:   2 * 1024 * 1024 * 1024,
+	}, dc.Resources())
+}
+
+func Test_provenance_annotations(t *testing.T) {
+	p := Provenance{Action: IdleAction, TriggeredBy: TriggeredByIdler, Reason: "inactive for 45m0s", Timestamp: time.Date(2018, 4, 11, 8, 0, 0, 0, time.UTC)}
+	assert.Equal(t, map[string]string{
+		"idler.fabric8.io/last-action":  "idle",
+		"idler.fabric8.io/triggered-by": "idler",
+		"idler.fabric8.io/reason":       "inactive for 45m0s",
+		"idler.fabric8.io/timestamp":    "2018-04-11T08:00:00Z",
+	}, p.Annotations())
+}
+
+func Test_build_payloads_of_cluster_versions(t *testing.T) {
+	tests := []struct {
+		file       string
+		apiVersion string
+		name       string
+		phase      string
+		conditions int
+	}{
+		{"build-3.x.json", LegacyBuildAPIVersion, "ksagathi-preview-app-3", "Complete", 0},
+		{"build-4.x.json", BuildAPIVersion, "ksagathi-preview-app-4", "Running", 3},
+	}
+
+	for _, test := range tests {
+		data, err := ioutil.ReadFile("../testutils/testdata/" + test.file)
+		require.NoError(t, err)
+
+		o := Object{}
+		require.NoError(t, json.Unmarshal(data, &o), test.file)
+		assert.Equal(t, "MODIFIED", o.Type, test.file)
+		assert.Equal(t, test.apiVersion, o.Object.APIVersion, test.file)
+		assert.Equal(t, "Build", o.Object.Kind, test.file)
+		assert.Equal(t, test.name, o.Object.Metadata.Name, test.file)
+		assert.Equal(t, "ksagathi-preview", o.Object.Metadata.Namespace, test.file)
+		assert.Equal(t, "ksagathi-preview-jenkins", o.Object.Metadata.Annotations.JenkinsNamespace, test.file)
+		assert.Equal(t, "JenkinsPipeline", o.Object.Spec.Strategy.Type, test.file)
+		assert.Equal(t, test.phase, o.Object.Status.Phase, test.file)
+		assert.Len(t, o.Object.Status.Conditions, test.conditions, test.file)
+	}
+}
+
+func Test_build_phase_from_conditions(t *testing.T) {
+	status := Status{}
+	require.NoError(t, json.Unmarshal([]byte(`{"conditions": [
+		{"type": "Running", "status": "True", "lastTransitionTime": "2019-11-05T14:02:41Z"},
+		{"type": "Complete", "status": "True", "lastTransitionTime": "2019-11-05T14:09:12Z"},
+		{"type": "Failed", "status": "False", "lastTransitionTime": "2019-11-05T14:10:00Z"}
+	]}`), &status))
+	assert.Equal(t, "Complete", status.Phase, "the condition which transitioned last should give the phase")
+
+	status = Status{}
+	require.NoError(t, json.Unmarshal([]byte(`{"phase": "Failed", "conditions": [{"type": "Running", "status": "True"}]}`), &status))
+	assert.Equal(t, "Failed", status.Phase, "an explicit phase should take precedence")
+
+	status = Status{}
+	require.NoError(t, json.Unmarshal([]byte(`{"conditions": [{"type": "New", "status": "False"}]}`), &status))
+	assert.Equal(t, "", status.Phase)
+}
+
+func Test_build_running_stage(t *testing.T) {
+	build := Build{}
+	require.NoError(t, json.Unmarshal([]byte(`{"metadata": {"name": "foo-1", "annotations": {
+		"openshift.io/jenkins-status-json": "{\"status\": \"PAUSED_PENDING_INPUT\", \"stages\": [{\"name\": \"Build\", \"status\": \"SUCCESS\"}, {\"name\": \"Approve\", \"status\": \"PAUSED_PENDING_INPUT\"}]}"
+	}}}`), &build))
+	stage, ok := build.RunningStage()
+	assert.True(t, ok)
+	assert.Equal(t, PipelineStage{Name: "Approve", Status: "PAUSED_PENDING_INPUT"}, stage)
+
+	build.Metadata.Annotations.JenkinsStatus = `{"stages": [{"name": "Build", "status": "SUCCESS"}]}`
+	_, ok = build.RunningStage()
+	assert.False(t, ok, "a pipeline whose stages finished should not be in a stage")
+
+	build.Metadata.Annotations.JenkinsStatus = ""
+	_, ok = build.RunningStage()
+	assert.False(t, ok)
+}
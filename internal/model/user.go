@@ -14,27 +14,55 @@ type User struct {
 	ActiveBuild       Build
 	DoneBuild         Build
 	JenkinsLastUpdate time.Time
+	ManualUnIdleAt    time.Time
+	IdledAt           time.Time
+	TotalIdleDuration time.Duration
+	Pod               Pod
+	SkipIdling        bool
+	IdleAfter         time.Duration
+	Variant           string
+	VariantIdleAfter  time.Duration
+	PressureIdleAfter time.Duration
 	IdleStatus        IdleStatus
+	Namespaces        []Namespace
+	Overrides         Overrides
+}
+
+// Namespace is one of the namespaces of a user as recorded by the tenant service, e.g. the che, run or stage namespace.
+type Namespace struct {
+	Name       string
+	Type       string
+	ClusterURL string
 }
 
 // IdleStatus contains information about the idle/un-idle status like timestamp
 // and reasons of failure
 type IdleStatus struct {
+	Action    string
 	Timestamp time.Time
 	Success   bool
 	Reason    string
 }
 
+const (
+	// IdleAction is the action of an IdleStatus returned by NewIdleStatus.
+	IdleAction = "idle"
+	// UnidleAction is the action of an IdleStatus returned by NewUnidleStatus.
+	UnidleAction = "unidle"
+)
+
 // NewIdleStatus returns IdleStatus based on the error provided
 func NewIdleStatus(err error) IdleStatus {
 	if err != nil {
 		return IdleStatus{
+			Action:    IdleAction,
 			Timestamp: time.Now().UTC(),
 			Success:   false,
 			Reason:    fmt.Sprintf("Failed to idle with error: %v", err),
 		}
 	}
 	return IdleStatus{
+		Action:    IdleAction,
 		Timestamp: time.Now().UTC(),
 		Success:   true,
 		Reason:    "Successfully idled",
@@ -45,12 +73,14 @@ func NewIdleStatus(err error) IdleStatus {
 func NewUnidleStatus(err error) IdleStatus {
 	if err != nil {
 		return IdleStatus{
+			Action:    UnidleAction,
 			Timestamp: time.Now().UTC(),
 			Success:   false,
 			Reason:    fmt.Sprintf("Failed to un-idle with error: %v", err),
 		}
 	}
 	return IdleStatus{
+		Action:    UnidleAction,
 		Timestamp: time.Now().UTC(),
 		Success:   true,
 		Reason:    "Successfully un-idled",
@@ -67,6 +97,47 @@ func NewUser(id string, name string) User {
 	}
 }
 
+// GetIdleAfter returns the idle timeout configured for this user by its JenkinsIdlerConfig resp. its annotation,
+// falling back to the idle timeout of the experiment variant the user is assigned to and then to the given default if
+// none is configured. While the cluster
+// is under resource pressure, the idle timeout is capped by PressureIdleAfter.
+func (u *User) GetIdleAfter(defaultIdleAfter time.Duration) time.Duration {
+	idleAfter := defaultIdleAfter
+	if u.Overrides.IdleAfter > 0 {
+		idleAfter = u.Overrides.IdleAfter
+	} else if u.IdleAfter > 0 {
+		idleAfter = u.IdleAfter
+	} else if u.VariantIdleAfter > 0 {
+		idleAfter = u.VariantIdleAfter
+	}
+	if u.PressureIdleAfter > 0 && u.PressureIdleAfter < idleAfter {
+		return u.PressureIdleAfter
+	}
+	return idleAfter
+}
+
+// IdleDuration returns how long Jenkins has been idled at the given time, or zero if it is not idled.
+func (u *User) IdleDuration(now time.Time) time.Duration {
+	if u.IdledAt.IsZero() {
+		return 0
+	}
+	return now.Sub(u.IdledAt)
+}
+
+// NamespacesOfType returns the namespaces of the user having one of the given types.
+func (u *User) NamespacesOfType(types ...string) []Namespace {
+	var namespaces []Namespace
+	for _, ns := range u.Namespaces {
+		for _, t := range types {
+			if ns.Type == t {
+				namespaces = append(namespaces, ns)
+				break
+			}
+		}
+	}
+	return namespaces
+}
+
 // HasActiveBuilds checks if current user has any active builds.
 // If so true is returned, otherwise false.
 func (u *User) HasActiveBuilds() bool {
@@ -3,6 +3,7 @@ package model
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,6 +39,9 @@ func TestNewIdleStatus(t *testing.T) {
 
 	for _, test := range tests {
 		output := NewIdleStatus(test.inputError)
+		if output.Action != IdleAction {
+			t.Errorf("Expected action to be %v, got %v", IdleAction, output.Action)
+		}
 		if output.Success != test.success {
 			t.Errorf("Expected success to be %v, got %v", test.success, output.Success)
 		}
@@ -71,6 +75,9 @@ func TestNewUnidleStatus(t *testing.T) {
 
 	for _, test := range tests {
 		output := NewUnidleStatus(test.inputError)
+		if output.Action != UnidleAction {
+			t.Errorf("Expected action to be %v, got %v", UnidleAction, output.Action)
+		}
 		if output.Success != test.success {
 			t.Errorf("Expected success to be %v, got %v", test.success, output.Success)
 		}
@@ -79,3 +86,20 @@ func TestNewUnidleStatus(t *testing.T) {
 		}
 	}
 }
+
+func Test_GetIdleAfter(t *testing.T) {
+	user := User{ID: "42"}
+	assert.Equal(t, 45*time.Minute, user.GetIdleAfter(45*time.Minute), "Default idle timeout expected")
+
+	user.VariantIdleAfter = 30 * time.Minute
+	assert.Equal(t, 30*time.Minute, user.GetIdleAfter(45*time.Minute), "Idle timeout of the variant expected")
+
+	user.IdleAfter = 4 * time.Hour
+	assert.Equal(t, 4*time.Hour, user.GetIdleAfter(45*time.Minute), "Idle timeout of the annotation expected")
+
+	user.PressureIdleAfter = 15 * time.Minute
+	assert.Equal(t, 15*time.Minute, user.GetIdleAfter(45*time.Minute), "Idle timeout shortened due to pressure expected")
+
+	user.IdleAfter = 10 * time.Minute
+	assert.Equal(t, 10*time.Minute, user.GetIdleAfter(45*time.Minute), "Pressure should not extend a shorter idle timeout")
+}
@@ -0,0 +1,73 @@
+package namespace
+
+import (
+	"path"
+)
+
+// Filter decides which namespaces the Idler manages, based on shell patterns as understood by path.Match,
+// e.g. `*-preview`.
+type Filter struct {
+	allow []string
+	deny  []string
+}
+
+// NewFilter creates a Filter. A namespace is managed if it matches any of the allow patterns, or if there are none,
+// and does not match any of the deny patterns.
+func NewFilter(allow []string, deny []string) *Filter {
+	return &Filter{
+		allow: allow,
+		deny:  deny,
+	}
+}
+
+// Allowed returns whether the namespace known under the given names, e.g. the name of the tenant and of its Jenkins
+// namespace, is managed by the Idler. A nil Filter allows all namespaces.
+func (f *Filter) Allowed(names ...string) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, name := range names {
+		if matchesAny(f.deny, name) {
+			return false
+		}
+	}
+
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if matchesAny(f.allow, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Manages returns whether the given Jenkins namespace is managed by the Idler, matching the name of its user as well
+// if it has the Jenkins suffix.
+func (f *Filter) Manages(jenkinsNamespace string) bool {
+	if user, ok := User(jenkinsNamespace); ok {
+		return f.Allowed(user, jenkinsNamespace)
+	}
+	return f.Allowed(jenkinsNamespace)
+}
+
+// Valid returns the first malformed pattern of the given ones, if any.
+func Valid(patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return pattern, false
+		}
+	}
+	return "", true
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_filter_without_patterns_allows_all(t *testing.T) {
+	assert.True(t, NewFilter(nil, nil).Allowed("foo", "foo-jenkins"))
+
+	var f *Filter
+	assert.True(t, f.Allowed("foo"), "nil filter should allow all namespaces")
+}
+
+func Test_filter_denylist(t *testing.T) {
+	f := NewFilter(nil, []string{"*-preview"})
+	assert.False(t, f.Allowed("foo-preview", "foo-preview-jenkins"))
+	assert.True(t, f.Allowed("foo", "foo-jenkins"))
+}
+
+func Test_filter_allowlist(t *testing.T) {
+	f := NewFilter([]string{"*-jenkins"}, []string{"test-*"})
+	assert.True(t, f.Allowed("foo", "foo-jenkins"), "any name should be able to match")
+	assert.False(t, f.Allowed("foo-che"), "namespaces not matching the allowlist should not be managed")
+	assert.False(t, f.Allowed("test-foo", "test-foo-jenkins"), "denylist should take precedence")
+}
+
+func Test_filter_manages(t *testing.T) {
+	f := NewFilter(nil, []string{"*-preview"})
+	assert.False(t, f.Manages("foo-preview-jenkins"), "name of the user should be matched")
+	assert.True(t, f.Manages("foo-jenkins"))
+	assert.False(t, f.Manages("foo-preview"), "namespace without Jenkins suffix should be matched as is")
+}
+
+func Test_valid(t *testing.T) {
+	_, ok := Valid([]string{"*-preview", "foo"})
+	assert.True(t, ok)
+
+	pattern, ok := Valid([]string{"foo", "[-jenkins"})
+	assert.False(t, ok)
+	assert.Equal(t, "[-jenkins", pattern)
+}
@@ -0,0 +1,27 @@
+package namespace
+
+import (
+	"strings"
+)
+
+// DefaultJenkinsSuffix is the suffix of the Jenkins namespaces of the standard tenant layout, e.g. john-jenkins.
+const DefaultJenkinsSuffix = "-jenkins"
+
+// JenkinsSuffix is the suffix appended to the name of a user, resp. its user namespace, to form the name of its
+// Jenkins namespace. It is set on startup for tenant layouts deviating from the standard one.
+var JenkinsSuffix = DefaultJenkinsSuffix
+
+// Jenkins returns the name of the Jenkins namespace of the given user.
+func Jenkins(user string) string {
+	return user + JenkinsSuffix
+}
+
+// User returns the name of the user owning the given Jenkins namespace. It returns false if the namespace is not a
+// Jenkins namespace, i.e. if it lacks the suffix or consists of the suffix only.
+func User(jenkinsNamespace string) (string, bool) {
+	if !strings.HasSuffix(jenkinsNamespace, JenkinsSuffix) {
+		return "", false
+	}
+	user := strings.TrimSuffix(jenkinsNamespace, JenkinsSuffix)
+	return user, user != ""
+}
@@ -0,0 +1,33 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jenkins_namespace_mapping(t *testing.T) {
+	assert.Equal(t, "john-jenkins", Jenkins("john"))
+
+	user, ok := User("john-jenkins")
+	assert.True(t, ok)
+	assert.Equal(t, "john", user)
+
+	for _, ns := range []string{"john", "john-jenkins-stage", "-jenkins", ""} {
+		_, ok := User(ns)
+		assert.False(t, ok, "%q should not be a Jenkins namespace", ns)
+	}
+}
+
+func Test_custom_jenkins_suffix(t *testing.T) {
+	JenkinsSuffix = "-ci"
+	defer func() { JenkinsSuffix = DefaultJenkinsSuffix }()
+
+	assert.Equal(t, "john-ci", Jenkins("john"))
+	user, ok := User("john-ci")
+	assert.True(t, ok)
+	assert.Equal(t, "john", user)
+
+	_, ok = User("john-jenkins")
+	assert.False(t, ok)
+}
@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// UnIdleFailures is the class of events sent when un-idling the Jenkins of a namespace failed repeatedly.
+	UnIdleFailures = "unidle-failures"
+	// ClusterDrained is the class of events sent when idling got disabled for a cluster.
+	ClusterDrained = "cluster-drained"
+	// CapacitySpike is the class of events sent when many un-idle requests got refused due to the cluster capacity.
+	CapacitySpike = "capacity-spike"
+	// TokenFailure is the class of events sent when a token could not be obtained or refreshed.
+	TokenFailure = "token-failure"
+
+	// SlackFormat posts the events as Slack messages.
+	SlackFormat = "slack"
+	// JSONFormat posts the events as JSON objects.
+	JSONFormat = "json"
+
+	// spikeWindow is the period within which the capacity refusals of a cluster are counted.
+	spikeWindow    = 5 * time.Minute
+	webhookTimeout = 10 * time.Second
+)
+
+var logger = logrus.WithField("component", "notify")
+
+// Classes are all classes of notable events.
+var Classes = []string{UnIdleFailures, ClusterDrained, CapacitySpike, TokenFailure}
+
+// Default is the Notifier used by the Idler, nil until configured.
+var Default *Notifier
+
+// Event is a notable event operators should learn about. It is sent as JSON to generic webhooks.
+type Event struct {
+	Class     string    `json:"class"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Notifier posts notable events to a Slack or generic webhook, so that operators learn about them before users
+// complain. A nil Notifier never notifies.
+type Notifier struct {
+	sync.Mutex
+	webhookURL     string
+	format         string
+	classes        map[string]bool
+	spikeThreshold int
+	refusals       map[string][]time.Time
+	httpClient     *http.Client
+	clock          clock.Clock
+	wg             sync.WaitGroup
+}
+
+// New creates a Notifier as configured. It returns nil if no webhook is configured.
+func New(config configuration.Configuration, clock clock.Clock) *Notifier {
+	if config.GetNotifyWebhookURL() == "" {
+		return nil
+	}
+
+	n := &Notifier{
+		webhookURL:     config.GetNotifyWebhookURL(),
+		format:         config.GetNotifyFormat(),
+		classes:        make(map[string]bool),
+		spikeThreshold: config.GetNotifyCapacitySpike(),
+		refusals:       make(map[string][]time.Time),
+		httpClient:     &http.Client{Timeout: webhookTimeout},
+		clock:          clock,
+	}
+	for _, class := range config.GetNotifyEvents() {
+		n.classes[class] = true
+	}
+	return n
+}
+
+// Notify posts the event in the background, provided its class is enabled.
+func (n *Notifier) Notify(e Event) {
+	if n == nil || !n.classes[e.Class] {
+		return
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = n.clock.Now().UTC()
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.post(e); err != nil {
+			logger.WithFields(logrus.Fields{"class": e.Class, "err": err}).Error("Unable to notify webhook")
+		}
+	}()
+}
+
+// CapacityRefused records an un-idle request refused due to the capacity of the cluster. Once the refusals within
+// five minutes reach the configured threshold, a CapacitySpike event is sent and counting starts anew.
+func (n *Notifier) CapacityRefused(cluster string) {
+	if n == nil || n.spikeThreshold <= 0 {
+		return
+	}
+
+	n.Lock()
+	now := n.clock.Now()
+	var recent []time.Time
+	for _, t := range n.refusals[cluster] {
+		if now.Sub(t) < spikeWindow {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	spike := len(recent) >= n.spikeThreshold
+	if spike {
+		recent = nil
+	}
+	n.refusals[cluster] = recent
+	n.Unlock()
+
+	if spike {
+		n.Notify(Event{
+			Class:   CapacitySpike,
+			Cluster: cluster,
+			Message: fmt.Sprintf("%d un-idle requests refused within %v as %s is at capacity", n.spikeThreshold, spikeWindow, cluster),
+		})
+	}
+}
+
+// Wait waits for the notifications in flight to be sent.
+func (n *Notifier) Wait() {
+	if n != nil {
+		n.wg.Wait()
+	}
+}
+
+// post sends the event to the webhook in the configured format.
+func (n *Notifier) post(e Event) error {
+	var payload interface{} = e
+	if n.format == SlackFormat {
+		payload = map[string]string{"text": text(e)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.httpClient.Post(n.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// text renders the event as a chat message.
+func text(e Event) string {
+	message := fmt.Sprintf(":warning: *%s*: %s", e.Class, e.Message)
+	if e.Namespace != "" {
+		message += fmt.Sprintf(" (namespace `%s`)", e.Namespace)
+	}
+	return message
+}
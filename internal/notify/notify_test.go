@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
+func webhook(t *testing.T) (*httptest.Server, *[]map[string]interface{}) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+	}))
+	return server, &received
+}
+
+func Test_nil_notifier(t *testing.T) {
+	n := New(&mock.Config{}, clock.NewFake(now))
+	assert.Nil(t, n, "Notifier should be disabled without webhook")
+	n.Notify(Event{Class: TokenFailure})
+	n.CapacityRefused("https://api.example.com/")
+	n.Wait()
+}
+
+func Test_notify_enabled_classes(t *testing.T) {
+	server, received := webhook(t)
+	defer server.Close()
+
+	n := New(&mock.Config{NotifyWebhookURL: server.URL, NotifyFormat: JSONFormat, NotifyEvents: []string{UnIdleFailures}}, clock.NewFake(now))
+	n.Notify(Event{Class: ClusterDrained, Message: "ignored"})
+	n.Notify(Event{Class: UnIdleFailures, Namespace: "john-jenkins", Message: "failed"})
+	n.Wait()
+
+	require.Len(t, *received, 1)
+	assert.Equal(t, UnIdleFailures, (*received)[0]["class"])
+	assert.Equal(t, "john-jenkins", (*received)[0]["namespace"])
+	assert.Equal(t, "2018-04-11T08:27:15Z", (*received)[0]["timestamp"])
+}
+
+func Test_notify_slack(t *testing.T) {
+	server, received := webhook(t)
+	defer server.Close()
+
+	n := New(&mock.Config{NotifyWebhookURL: server.URL, NotifyFormat: SlackFormat, NotifyEvents: Classes}, clock.NewFake(now))
+	n.Notify(Event{Class: UnIdleFailures, Namespace: "john-jenkins", Message: "Un-idling Jenkins failed 5 times"})
+	n.Wait()
+
+	require.Len(t, *received, 1)
+	assert.Equal(t, ":warning: *unidle-failures*: Un-idling Jenkins failed 5 times (namespace `john-jenkins`)", (*received)[0]["text"])
+}
+
+func Test_capacity_spike(t *testing.T) {
+	server, received := webhook(t)
+	defer server.Close()
+
+	c := clock.NewFake(now)
+	n := New(&mock.Config{NotifyWebhookURL: server.URL, NotifyEvents: Classes, NotifyCapacitySpike: 3}, c)
+	n.CapacityRefused("https://api.example.com/")
+	n.CapacityRefused("https://api.example.com/")
+	c.Advance(6 * time.Minute)
+	n.CapacityRefused("https://api.example.com/")
+	n.CapacityRefused("https://api.example.com/")
+	n.Wait()
+	assert.Empty(t, *received, "Refusals outside of the window should not count")
+
+	n.CapacityRefused("https://api.example.com/")
+	n.CapacityRefused("https://api.other.com/")
+	n.Wait()
+	require.Len(t, *received, 1)
+	assert.Equal(t, CapacitySpike, (*received)[0]["class"])
+	assert.Equal(t, "https://api.example.com/", (*received)[0]["cluster"])
+}
@@ -0,0 +1,54 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type nested struct {
+	Name string `json:"name"`
+}
+
+type sample struct {
+	Flag       bool      `json:"flag"`
+	Count      int       `json:"count,omitempty"`
+	Names      []string  `json:"names"`
+	Nested     *nested   `json:"nested,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Untagged   string
+	Skipped    string `json:"-"`
+	unexported string
+}
+
+func Test_schema_of_struct(t *testing.T) {
+	s := SchemaOf(sample{})
+
+	assert.Equal(t, "object", s.Type)
+	assert.Len(t, s.Properties, 6, "Unexpected number of properties")
+	assert.Equal(t, "boolean", s.Properties["flag"].Type)
+	assert.Equal(t, "integer", s.Properties["count"].Type)
+	assert.Equal(t, "array", s.Properties["names"].Type)
+	assert.Equal(t, "string", s.Properties["names"].Items.Type)
+	assert.Equal(t, "string", s.Properties["nested"].Properties["name"].Type)
+	assert.Equal(t, "date-time", s.Properties["timestamp"].Format)
+	assert.Equal(t, "string", s.Properties["Untagged"].Type)
+}
+
+func Test_add_operation_converts_router_params(t *testing.T) {
+	doc := NewDocument("test", "v1")
+	doc.AddOperation("GET", "/api/foo/:namespace", Operation{OperationID: "getFoo"})
+	doc.AddOperation("POST", "/api/foo/:namespace/", Operation{OperationID: "postFoo"})
+
+	item, ok := doc.Paths["/api/foo/{namespace}"]
+	assert.True(t, ok, "Path should have been converted")
+	assert.Equal(t, "getFoo", item.Get.OperationID)
+	assert.Equal(t, "postFoo", item.Post.OperationID)
+	assert.NotNil(t, item.Get.Responses, "Responses must always be serialized")
+
+	b, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `"openapi":"3.0.0"`)
+}
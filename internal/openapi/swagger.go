@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const swaggerUIVersion = "3.19.0"
+
+const swaggerUITemplate = `<!DOCTYPE html>
+<html>
+<head>
+  <title>%[1]s</title>
+  <link rel="stylesheet" type="text/css" href="https://unpkg.com/swagger-ui-dist@%[2]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@%[2]s/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({url: "%[3]s", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUIHandler returns a handler serving a Swagger UI page which renders the OpenAPI document served under specURL.
+func SwaggerUIHandler(title string, specURL string) http.Handler {
+	page := []byte(fmt.Sprintf(swaggerUITemplate, title, swaggerUIVersion, specURL))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(page)
+	})
+}
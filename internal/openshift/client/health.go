@@ -0,0 +1,80 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// route is the part of an OpenShift route needed to reach the service it exposes.
+type route struct {
+	Spec struct {
+		Host string           `json:"host"`
+		TLS  *json.RawMessage `json:"tls"`
+	} `json:"spec"`
+}
+
+// Health is the result of probing a service via its route.
+type Health struct {
+	// Serving is true if the service actually serves requests.
+	Serving bool
+	// Version is the Jenkins version announced via the X-Jenkins header, if any.
+	Version string
+}
+
+// RouteURL returns the URL, scheme and host, of the route exposing the service in the given namespace.
+func (o *openShift) RouteURL(apiURL string, bearerToken string, namespace string, service string) (string, error) {
+	req, err := o.reqOAPI(apiURL, bearerToken, "GET", namespace, "routes/"+service, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer bodyClose(resp)
+
+	r := route{}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", err
+	}
+	if r.Spec.Host == "" {
+		return "", fmt.Errorf("route %s in namespace %s has no host", service, namespace)
+	}
+	return fmt.Sprintf("%s://%s", o.getScheme(r.Spec.TLS != nil), r.Spec.Host), nil
+}
+
+// Probe probes the given path, e.g. /login, on the route exposing the service in the given namespace and returns
+// whether the service is actually serving requests. In contrast to a running pod, this excludes a Jenkins which is
+// still initializing, as it answers with 503 until it is ready. An error is only returned if the route cannot be
+// determined; a failing probe just reports the service as not serving.
+func (o *openShift) Probe(apiURL string, bearerToken string, namespace string, service string, path string) (Health, error) {
+	routeURL, err := o.RouteURL(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		return Health{}, err
+	}
+
+	url := routeURL + "/" + strings.TrimPrefix(path, "/")
+	probe, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return Health{}, err
+	}
+
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL, "url": url})
+	probeResp, err := o.client.Do(probe)
+	if err != nil {
+		log.WithField("err", err).Debug("Health probe failed")
+		return Health{}, nil
+	}
+	defer bodyClose(probeResp)
+
+	log.WithField("code", probeResp.StatusCode).Debug("Health probe answered")
+	return Health{
+		Serving: probeResp.StatusCode < http.StatusInternalServerError,
+		Version: probeResp.Header.Get("X-Jenkins"),
+	}, nil
+}
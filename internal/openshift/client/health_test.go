@@ -0,0 +1,73 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_probe(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	jenkins := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login", r.URL.Path)
+		w.Header().Set("X-Jenkins", "2.107.3")
+		w.WriteHeader(status)
+	}))
+	defer jenkins.Close()
+
+	jenkinsURL, _ := url.Parse(jenkins.URL)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oapi/v1/namespaces/foo-jenkins/routes/jenkins" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"spec": {"host": "%s"}}`, jenkinsURL.Host)
+	}))
+	defer api.Close()
+
+	o := NewOpenShift().(*openShift)
+
+	health, err := o.Probe(api.URL, "token", "foo-jenkins", "jenkins", "/login")
+	require.NoError(t, err)
+	assert.False(t, health.Serving, "Jenkins answering with 503 is still initializing")
+
+	status = http.StatusOK
+	health, err = o.Probe(api.URL, "token", "foo-jenkins", "jenkins", "/login")
+	require.NoError(t, err)
+	assert.Equal(t, Health{Serving: true, Version: "2.107.3"}, health)
+
+	_, err = o.Probe(api.URL, "token", "bar-jenkins", "jenkins", "/login")
+	assert.Error(t, err, "A missing route should be reported as error")
+}
+
+func Test_route_url(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oapi/v1/namespaces/foo-jenkins/routes/jenkins":
+			fmt.Fprint(w, `{"spec": {"host": "jenkins.foo.apps.example.com", "tls": {"termination": "edge"}}}`)
+		case "/oapi/v1/namespaces/bar-jenkins/routes/jenkins":
+			fmt.Fprint(w, `{"spec": {"host": "jenkins.bar.apps.example.com"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	o := NewOpenShift()
+
+	url, err := o.RouteURL(api.URL, "token", "foo-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, "https://jenkins.foo.apps.example.com", url)
+
+	url, err = o.RouteURL(api.URL, "token", "bar-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, "http://jenkins.bar.apps.example.com", url, "Routes without TLS should be served via http")
+
+	_, err = o.RouteURL(api.URL, "token", "baz-jenkins", "jenkins")
+	assert.Error(t, err, "A missing route should be reported as error")
+}
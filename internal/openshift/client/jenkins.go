@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// computer is the part of the Jenkins computer API telling how many executors are running jobs.
+type computer struct {
+	BusyExecutors int `json:"busyExecutors"`
+}
+
+// BusyExecutors returns the number of executors of the Jenkins exposed by the route of the service in the given
+// namespace which are running jobs. The bearer token needs to grant access to Jenkins.
+func (o *openShift) BusyExecutors(apiURL string, bearerToken string, namespace string, service string) (int, error) {
+	req, err := o.reqJenkins(apiURL, bearerToken, "GET", namespace, service, "/computer/api/json?tree=busyExecutors")
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer bodyClose(resp)
+
+	c := computer{}
+	if err := json.NewDecoder(resp.Body).Decode(&c); err != nil {
+		return 0, err
+	}
+	return c.BusyExecutors, nil
+}
+
+// QuietDown puts the Jenkins exposed by the route of the service in the given namespace in quiet-down mode, in which
+// it finishes the running jobs but does not start new ones.
+func (o *openShift) QuietDown(apiURL string, bearerToken string, namespace string, service string) error {
+	return o.postJenkins(apiURL, bearerToken, namespace, service, "/quietDown")
+}
+
+// CancelQuietDown ends the quiet-down mode of the Jenkins exposed by the route of the service in the given namespace.
+func (o *openShift) CancelQuietDown(apiURL string, bearerToken string, namespace string, service string) error {
+	return o.postJenkins(apiURL, bearerToken, namespace, service, "/cancelQuietDown")
+}
+
+func (o *openShift) postJenkins(apiURL string, bearerToken string, namespace string, service string, path string) error {
+	req, err := o.reqJenkins(apiURL, bearerToken, "POST", namespace, service, path)
+	if err != nil {
+		return err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return err
+	}
+	bodyClose(resp)
+	return nil
+}
+
+// reqJenkins creates a request of the given path of the Jenkins exposed by the route of the service in the given
+// namespace. Jenkins authenticates the request via the OpenShift login plugin.
+func (o *openShift) reqJenkins(apiURL string, bearerToken string, method string, namespace string, service string, path string) (*http.Request, error) {
+	routeURL, err := o.RouteURL(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(method, routeURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_quiet_down(t *testing.T) {
+	var posted []string
+	jenkins := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/computer/api/json":
+			assert.Equal(t, "busyExecutors", r.URL.Query().Get("tree"))
+			fmt.Fprint(w, `{"_class": "hudson.model.ComputerSet", "busyExecutors": 2}`)
+		case r.Method == "POST":
+			posted = append(posted, r.URL.Path)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer jenkins.Close()
+
+	jenkinsURL, _ := url.Parse(jenkins.URL)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oapi/v1/namespaces/foo-jenkins/routes/jenkins" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"spec": {"host": "%s"}}`, jenkinsURL.Host)
+	}))
+	defer api.Close()
+
+	o := NewOpenShift().(*openShift)
+
+	busy, err := o.BusyExecutors(api.URL, "token", "foo-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, 2, busy)
+
+	require.NoError(t, o.QuietDown(api.URL, "token", "foo-jenkins", "jenkins"))
+	require.NoError(t, o.CancelQuietDown(api.URL, "token", "foo-jenkins", "jenkins"))
+	assert.Equal(t, []string{"/quietDown", "/cancelQuietDown"}, posted)
+
+	_, err = o.BusyExecutors(api.URL, "token", "bar-jenkins", "jenkins")
+	assert.Error(t, err, "Without route, Jenkins cannot be reached")
+}
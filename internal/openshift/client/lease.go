@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// microTimeFormat is the format of the MicroTime fields of a Lease.
+const microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// lease is the subset of a coordination.k8s.io/v1 Lease used to serialize the mutations of a namespace.
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+}
+
+// heldByOther returns whether the lease is held by another holder than the given one at the given time.
+func (l *lease) heldByOther(holder string, now time.Time) bool {
+	if l.Spec.HolderIdentity == "" || l.Spec.HolderIdentity == holder {
+		return false
+	}
+	renewed, err := time.Parse(time.RFC3339Nano, l.Spec.RenewTime)
+	if err != nil {
+		return false
+	}
+	return now.Before(renewed.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// AcquireLease acquires the Lease of the given name in the namespace for the holder, creating it if needed. It
+// returns false if the lease is held by another holder which did not let it expire, or if another holder
+// acquired it concurrently.
+func (o *openShift) AcquireLease(apiURL string, bearerToken string, namespace string, name string, holder string, duration time.Duration) (bool, error) {
+	current, err := o.getLease(apiURL, bearerToken, namespace, name)
+	if err != nil {
+		return false, err
+	}
+
+	now := o.clock.Now().UTC()
+	spec := leaseSpec{
+		HolderIdentity:       holder,
+		LeaseDurationSeconds: int(duration.Seconds()),
+		AcquireTime:          now.Format(microTimeFormat),
+		RenewTime:            now.Format(microTimeFormat),
+	}
+
+	if current == nil {
+		l := lease{APIVersion: "coordination.k8s.io/v1", Kind: "Lease", Metadata: leaseMetadata{Name: name}, Spec: spec}
+		return o.sendLease(apiURL, bearerToken, "POST", leasesURL(apiURL, namespace), l)
+	}
+	if current.heldByOther(holder, now) {
+		return false, nil
+	}
+	current.Spec = spec
+	return o.sendLease(apiURL, bearerToken, "PUT", leasesURL(apiURL, namespace)+"/"+name, *current)
+}
+
+// ReleaseLease releases the Lease of the given name in the namespace, provided it is held by the holder.
+func (o *openShift) ReleaseLease(apiURL string, bearerToken string, namespace string, name string, holder string) error {
+	current, err := o.getLease(apiURL, bearerToken, namespace, name)
+	if err != nil || current == nil || current.Spec.HolderIdentity != holder {
+		return err
+	}
+
+	current.Spec = leaseSpec{}
+	// a conflict means another holder took over the lease after it expired
+	_, err = o.sendLease(apiURL, bearerToken, "PUT", leasesURL(apiURL, namespace)+"/"+name, *current)
+	return err
+}
+
+// leasesURL returns the URL of the Leases of the namespace.
+func leasesURL(apiURL string, namespace string) string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", strings.TrimSuffix(apiURL, "/"), namespace)
+}
+
+// getLease returns the Lease of the given name in the namespace, nil if it does not exist.
+func (o *openShift) getLease(apiURL string, bearerToken string, namespace string, name string) (*lease, error) {
+	req, err := http.NewRequest("GET", leasesURL(apiURL, namespace)+"/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(req, apiURL, bearerToken)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		l := &lease{}
+		if err := json.NewDecoder(resp.Body).Decode(l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
+	}
+}
+
+// sendLease creates resp. updates the Lease. It returns false if the Lease got created resp. updated concurrently.
+func (o *openShift) sendLease(apiURL string, bearerToken string, method string, url string, l lease) (bool, error) {
+	body, err := json.Marshal(l)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	authorize(req, apiURL, bearerToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer bodyClose(resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, fmt.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaseServer serves a single Lease, rejecting updates of outdated resource versions.
+func leaseServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	var current *lease
+	version := 0
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case "GET":
+			assert.Equal(t, "/apis/coordination.k8s.io/v1/namespaces/foo-jenkins/leases/mutation", r.URL.Path)
+			if current == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(current)
+		case "POST", "PUT":
+			l := &lease{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(l))
+			if (r.Method == "POST" && current != nil) || (r.Method == "PUT" && l.Metadata.ResourceVersion != current.Metadata.ResourceVersion) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			version++
+			l.Metadata.ResourceVersion = strconv.Itoa(version)
+			current = l
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+}
+
+func Test_lease(t *testing.T) {
+	api := leaseServer(t)
+	defer api.Close()
+	o := NewOpenShift()
+
+	acquired, err := o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-1", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "Lease should be created")
+
+	acquired, err = o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "Lease should not be acquired while held by another holder")
+
+	require.NoError(t, o.ReleaseLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2"))
+	acquired, err = o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "Lease should only be released by its holder")
+
+	require.NoError(t, o.ReleaseLease(api.URL, "token", "foo-jenkins", "mutation", "replica-1"))
+	acquired, err = o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "Released lease should be acquired")
+}
+
+func Test_lease_expires_by_clock(t *testing.T) {
+	api := leaseServer(t)
+	defer api.Close()
+	fake := clock.NewFake(time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC))
+	o := NewOpenShift().(*openShift)
+	o.clock = fake
+
+	acquired, err := o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	fake.Advance(59 * time.Second)
+	acquired, err = o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired, "Lease should be held until it expires")
+
+	fake.Advance(2 * time.Second)
+	acquired, err = o.AcquireLease(api.URL, "token", "foo-jenkins", "mutation", "replica-2", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired, "Expired lease should be taken over")
+}
+
+func Test_expired_lease_taken_over(t *testing.T) {
+	l := &lease{Spec: leaseSpec{
+		HolderIdentity:       "replica-1",
+		LeaseDurationSeconds: 60,
+		RenewTime:            time.Now().Add(-2 * time.Minute).UTC().Format(microTimeFormat),
+	}}
+	assert.False(t, l.heldByOther("replica-2", time.Now()), "Expired lease should be free")
+	assert.True(t, l.heldByOther("replica-2", time.Now().Add(-90*time.Second)), "Lease should be held until it expires")
+	assert.False(t, l.heldByOther("replica-1", time.Now().Add(-90*time.Second)), "Lease should not be held against its holder")
+}
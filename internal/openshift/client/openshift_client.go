@@ -3,18 +3,26 @@ package client
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/fault"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/token"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 var logger = logrus.WithField("component", "openshift-client")
@@ -25,10 +33,38 @@ type OpenShiftClient interface {
 	Idle(apiURL string, bearerToken string, namespace string, service string) error
 	UnIdle(apiURL string, bearerToken string, namespace string, service string) error
 	State(apiURL string, bearerToken string, namespace string, service string) (model.PodState, error)
+	EndpointsIdledAt(apiURL string, bearerToken string, namespace string, service string) (time.Time, error)
+	AcquireLease(apiURL string, bearerToken string, namespace string, name string, holder string, duration time.Duration) (bool, error)
+	ReleaseLease(apiURL string, bearerToken string, namespace string, name string, holder string) error
 	WhoAmI(apiURL string, bearerToken string) (string, error)
-	WatchBuilds(apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error
-	WatchDeploymentConfigs(apiURL string, bearerToken string, namespaceSuffix string, callback func(model.DCObject) error) error
-	Reset(apiURL string, bearerToken string, namespace string) error
+	WatchBuilds(ctx context.Context, apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error
+	WatchDeploymentConfigs(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.DCObject) error) error
+	ListDeploymentConfigs(apiURL string, bearerToken string, namespaceSuffix string) ([]model.DeploymentConfig, error)
+	ListNamespaces(apiURL string, bearerToken string, namespaceSuffix string) ([]string, error)
+	ListBuilds(apiURL string, bearerToken string, namespace string) ([]model.Build, error)
+	Reset(apiURL string, bearerToken string, namespace string, service string, options ResetOptions) error
+	Rollout(apiURL string, bearerToken string, namespace string, service string) (string, error)
+	Annotate(apiURL string, bearerToken string, namespace string, service string, annotations map[string]string) error
+	SetMemoryLimit(apiURL string, bearerToken string, namespace string, service string, limit int64) error
+	Restarts(apiURL string, bearerToken string, namespace string, service string) (model.PodRestarts, error)
+	WatchPods(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.PodObject) error) error
+	WatchIdlerConfigs(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.IdlerConfigObject) error) error
+	Probe(apiURL string, bearerToken string, namespace string, service string, path string) (Health, error)
+	RouteURL(apiURL string, bearerToken string, namespace string, service string) (string, error)
+	BusyExecutors(apiURL string, bearerToken string, namespace string, service string) (int, error)
+	QuietDown(apiURL string, bearerToken string, namespace string, service string) error
+	CancelQuietDown(apiURL string, bearerToken string, namespace string, service string) error
+	NamespaceLabels(apiURL string, bearerToken string, namespace string) (map[string]string, error)
+	RunningPods(apiURL string, bearerToken string, namespace string) (int, error)
+	RolloutsInProgress(apiURL string, bearerToken string, namespace string) ([]string, error)
+	IdleDeployments(apiURL string, bearerToken string, namespace string, labelSelector string) ([]string, error)
+	PodUsage(apiURL string, bearerToken string, namespace string) (model.Usage, error)
+}
+
+// podEvent is a pod watch event.
+type podEvent struct {
+	Type   string `json:"type"`
+	Object v1.Pod `json:"object"`
 }
 
 type user struct {
@@ -39,31 +75,47 @@ type user struct {
 
 // openShift is a hand-rolled implementation of the OpenShiftClient using manually built-up HTTP requets.
 type openShift struct {
-	client *http.Client
+	client    *http.Client
+	selectors WatchSelectors
+	clock     clock.Clock
 }
 
 // NewOpenShift creates new openShift client with new HTTP client.
 func NewOpenShift() OpenShiftClient {
-	c := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost: 20,
-		},
-		Timeout: time.Duration(10) * time.Second,
-	}
+	return NewOpenShiftWithClient(newHTTPClient())
+}
 
-	return NewOpenShiftWithClient(c)
+// NewOpenShiftWithSelectors creates new openShift client with new HTTP client, applying the given selectors
+// to its watches.
+func NewOpenShiftWithSelectors(selectors WatchSelectors) OpenShiftClient {
+	return &openShift{
+		client:    newHTTPClient(),
+		selectors: selectors,
+		clock:     clock.New(),
+	}
 }
 
 // NewOpenShiftWithClient create new openShift client with given HTTP client.
 func NewOpenShiftWithClient(client *http.Client) OpenShiftClient {
 	return &openShift{
-		client: client,
+		client:    client,
+		selectors: DefaultWatchSelectors,
+		clock:     clock.New(),
+	}
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: fault.Transport(fault.OpenShift, &http.Transport{
+			MaxIdleConnsPerHost: 20,
+		}),
+		Timeout: time.Duration(10) * time.Second,
 	}
 }
 
 // Idle scales down the jenkins pod in the given openShift namespace.
 func (o openShift) Idle(apiURL string, bearerToken string, namespace string, service string) (err error) {
-	log := logger.WithField("ns", namespace)
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL})
 	log.Infof("Idling service %s in namespace %s", service, namespace)
 
 	idleAt, err := time.Now().UTC().MarshalText()
@@ -149,54 +201,206 @@ func (o openShift) Idle(apiURL string, bearerToken string, namespace string, ser
 	return
 }
 
-// Reset deletes a pod and start a new one
-func (o *openShift) Reset(apiURL string, bearerToken string, namespace string) error {
-	log := logger.WithField("ns", namespace)
-	log.Info("resetting pods in " + namespace)
+// resetPollInterval is the interval at which Reset checks whether the replacement pods got scheduled.
+var resetPollInterval = time.Second
 
-	req, err := o.reqAPI(apiURL, bearerToken, "GET", namespace, "pods", nil)
+// ResetOptions controls how Reset deletes the pods of a service.
+type ResetOptions struct {
+	// GracePeriod is the time the containers get to terminate gracefully before being killed.
+	GracePeriod time.Duration
+	// Timeout is the time to wait for the replacement pods to get scheduled. If zero, Reset returns right after
+	// deleting the pods.
+	Timeout time.Duration
+}
+
+// DisruptionError is returned by Reset if deleting a pod would violate a PodDisruptionBudget.
+type DisruptionError struct {
+	Pod    string
+	Budget string
+}
+
+func (e *DisruptionError) Error() string {
+	return fmt.Sprintf("deleting pod %s would violate the PodDisruptionBudget %s", e.Pod, e.Budget)
+}
+
+// ReplacementTimeoutError is returned by Reset if the replacement pods did not get scheduled in time.
+type ReplacementTimeoutError struct {
+	Service string
+	Timeout time.Duration
+}
+
+func (e *ReplacementTimeoutError) Error() string {
+	return fmt.Sprintf("replacement pods of %s did not get scheduled within %s", e.Service, e.Timeout)
+}
+
+// Reset deletes the pods of the given service, so that new ones get started. It refuses to delete pods if a
+// PodDisruptionBudget covering them allows no disruption, grants the containers the grace period of the options to
+// terminate and waits for the replacement pods to get scheduled until the timeout of the options elapses.
+func (o *openShift) Reset(apiURL string, bearerToken string, namespace string, service string, options ResetOptions) error {
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL})
+	log.Infof("resetting pods of %s in %s", service, namespace)
+
+	pods, err := o.pods(apiURL, bearerToken, namespace, service)
 	if err != nil {
 		return err
 	}
+
+	var targets []v1.Pod
+	for _, pod := range pods {
+		if !strings.Contains(pod.GetName(), "deploy") {
+			targets = append(targets, pod)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	if err := o.checkDisruptionBudgets(apiURL, bearerToken, namespace, targets); err != nil {
+		return err
+	}
+
+	deleted := make(map[string]bool)
+	for _, pod := range targets {
+		podName := pod.GetName()
+		log.Infof("Resetting pod %q", podName)
+		req, err := o.reqAPI(apiURL, bearerToken, "DELETE", namespace, "pods/"+podName, nil)
+		if err != nil {
+			return err
+		}
+		v := req.URL.Query()
+		v.Add("gracePeriodSeconds", fmt.Sprint(int64(options.GracePeriod/time.Second)))
+		req.URL.RawQuery = v.Encode()
+
+		resp, err := o.do(req)
+		if err != nil {
+			return err
+		}
+		defer bodyClose(resp)
+		deleted[podName] = true
+	}
+
+	if options.Timeout <= 0 {
+		return nil
+	}
+	return o.awaitReplacement(apiURL, bearerToken, namespace, service, deleted, len(targets), options.Timeout)
+}
+
+// checkDisruptionBudgets returns a DisruptionError if one of the given pods is covered by a PodDisruptionBudget which
+// currently allows no disruption.
+func (o *openShift) checkDisruptionBudgets(apiURL string, bearerToken string, namespace string, pods []v1.Pod) error {
+	budgetsURL := fmt.Sprintf("%s/apis/policy/v1beta1/namespaces/%s/poddisruptionbudgets", strings.TrimSuffix(apiURL, "/"), namespace)
+	req, err := http.NewRequest("GET", budgetsURL, nil)
+	if err != nil {
+		return err
+	}
+	authorize(req, apiURL, bearerToken)
 	resp, err := o.do(req)
 	if err != nil {
 		return err
 	}
-
 	defer bodyClose(resp)
 
-	podList := &v1.PodList{}
-	err = json.NewDecoder(resp.Body).Decode(podList)
+	budgets := &policy.PodDisruptionBudgetList{}
+	err = json.NewDecoder(resp.Body).Decode(budgets)
 	if err != nil {
 		return err
 	}
 
-	for _, element := range podList.Items {
-
-		podName := element.GetName()
-		if strings.Contains(podName, "deploy") {
+	for _, budget := range budgets.Items {
+		if budget.Status.DisruptionsAllowed > 0 {
 			continue
 		}
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		for _, pod := range pods {
+			if selector.Matches(labels.Set(pod.GetLabels())) {
+				return &DisruptionError{Pod: pod.GetName(), Budget: budget.GetName()}
+			}
+		}
+	}
+	return nil
+}
 
-		log.Infof("Resetting pod %q", podName)
-		req, err := o.reqAPI(apiURL, bearerToken, "DELETE", namespace, "pods/"+podName, nil)
+// awaitReplacement waits until as many pods of the service as got deleted are scheduled, not counting the deleted
+// ones. It returns a ReplacementTimeoutError if the timeout elapses before.
+func (o *openShift) awaitReplacement(apiURL string, bearerToken string, namespace string, service string, deleted map[string]bool, count int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		pods, err := o.pods(apiURL, bearerToken, namespace, service)
 		if err != nil {
 			return err
 		}
 
-		resp, err = o.do(req)
-		if err != nil {
-			return err
+		scheduled := 0
+		for _, pod := range pods {
+			if !deleted[pod.GetName()] && !strings.Contains(pod.GetName(), "deploy") && pod.Spec.NodeName != "" {
+				scheduled++
+			}
 		}
-		defer bodyClose(resp)
+		if scheduled >= count {
+			logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL}).Infof("Replacement pods of %s got scheduled", service)
+			return nil
+		}
+
+		if time.Now().Add(resetPollInterval).After(deadline) {
+			return &ReplacementTimeoutError{Service: service, Timeout: timeout}
+		}
+		time.Sleep(resetPollInterval)
 	}
-	return nil
+}
+
+// Rollout triggers a new rollout of the deployment config of the given service, which replaces its pods according
+// to the deployment strategy of the deployment config. It returns the name of the new rollout, e.g. jenkins-4.
+func (o *openShift) Rollout(apiURL string, bearerToken string, namespace string, service string) (string, error) {
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL})
+	log.Infof("Rolling out %s in %s", service, namespace)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"kind":       "DeploymentRequest",
+		"apiVersion": "v1",
+		"name":       service,
+		"latest":     true,
+		"force":      true,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := o.reqOAPI(apiURL, bearerToken, "POST", namespace, fmt.Sprintf("deploymentconfigs/%s/instantiate", service), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer bodyClose(resp)
+
+	dc := &model.DeploymentConfig{}
+	err = json.NewDecoder(resp.Body).Decode(dc)
+	if err != nil {
+		return "", err
+	}
+
+	rollout := fmt.Sprintf("%s-%d", service, dc.Status.LatestVersion)
+	log.Infof("Started rollout %s", rollout)
+	return rollout, nil
 }
 
 // UnIdle scales up the jenkins pod in the given openShift namespace.
 func (o *openShift) UnIdle(apiURL string, bearerToken string, namespace string, service string) (err error) {
-	log := logger.WithField("ns", namespace)
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL})
 	log.Infof("Un-idling %s in %s", service, namespace)
+
+	// Record the un-idling, so that it can be told apart from a manual scale up.
+	err = o.annotateUnIdled(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		log.Errorf("failed to annotate %s as un-idled: %v", service, err)
+		return
+	}
+
 	// Scale up
 	s := model.Scale{
 		Kind:       "Scale",
@@ -238,9 +442,69 @@ func (o *openShift) UnIdle(apiURL string, bearerToken string, namespace string,
 	return
 }
 
+// annotateUnIdled records the current time as un-idle time on the deployment config of the given service.
+func (o *openShift) annotateUnIdled(apiURL string, bearerToken string, namespace string, service string) error {
+	unIdledAt, err := time.Now().UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return o.Annotate(apiURL, bearerToken, namespace, service, map[string]string{model.UnIdledAtAnnotation: string(unIdledAt)})
+}
+
+// Annotate adds the given annotations to the deployment config of the given service, replacing existing values.
+func (o *openShift) Annotate(apiURL string, bearerToken string, namespace string, service string, annotations map[string]string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := o.reqOAPI(apiURL, bearerToken, "PATCH", namespace, fmt.Sprintf("deploymentconfigs/%s", service), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, err = o.patch(req)
+	return err
+}
+
+// SetMemoryLimit sets the memory limit of the container named after the given service in its deployment config to
+// the given number of bytes. The change rolls out a new deployment if the deployment config has a config change
+// trigger.
+func (o *openShift) SetMemoryLimit(apiURL string, bearerToken string, namespace string, service string, limit int64) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []map[string]interface{}{{
+						"name": service,
+						"resources": map[string]interface{}{
+							"limits": map[string]string{"memory": strconv.FormatInt(limit, 10)},
+						},
+					}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := o.reqOAPI(apiURL, bearerToken, "PATCH", namespace, fmt.Sprintf("deploymentconfigs/%s", service), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, err = o.patch(req)
+	return err
+}
+
 // State returns `PodIdled` if a service in OpenShift namespace is idled,
 // `PodStarting` if it is in the process of scaling up, `PodRunning`
-// if it is fully up.
+// if it is fully up. If the pods of a service which is scaling up fail to
+// start, `PodCrashLooping`, `PodImagePullFailed`, `PodUnschedulable` or
+// `PodQuotaExceeded` is returned instead of `PodStarting`.
 func (o *openShift) State(apiURL string, bearerToken string, namespace string, service string) (model.PodState, error) {
 	req, err := o.reqOAPI(apiURL, bearerToken, "GET", namespace, "deploymentconfigs/"+service, nil)
 	if err != nil {
@@ -259,13 +523,197 @@ func (o *openShift) State(apiURL string, bearerToken string, namespace string, s
 		return model.PodStateUnknown, err
 	}
 
-	if dc.Status.Replicas == 0 {
-		return model.PodIdled, nil
+	state := dc.PodState()
+	if state != model.PodStarting {
+		return state, nil
+	}
+
+	failure, err := o.podFailureState(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL}).Warnf(
+			"Unable to check pods of %s for failures: %s", service, err)
+		return state, nil
+	}
+	if failure != model.PodStateUnknown {
+		return failure, nil
+	}
+	return state, nil
+}
+
+// EndpointsIdledAt returns the time the endpoints of the given service got annotated as idled, e.g. by
+// `oc idle`, or the zero time if they are not annotated as idled.
+func (o *openShift) EndpointsIdledAt(apiURL string, bearerToken string, namespace string, service string) (time.Time, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", namespace, "endpoints/"+service, nil)
+	if err != nil {
+		return time.Time{}, err
 	}
-	if dc.Status.ReadyReplicas == 0 {
-		return model.PodStarting, nil
+	resp, err := o.do(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer bodyClose(resp)
+
+	e := &model.Endpoint{}
+	if err := json.NewDecoder(resp.Body).Decode(e); err != nil {
+		return time.Time{}, err
+	}
+
+	idledAt, err := time.Parse(time.RFC3339, e.Metadata.Annotations.IdledAt)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return idledAt, nil
+}
+
+// podFailureState returns the failure state of the pods of the given service or `PodStateUnknown` if none of the
+// pods is failing.
+func (o *openShift) podFailureState(apiURL string, bearerToken string, namespace string, service string) (model.PodState, error) {
+	pods, err := o.pods(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		return model.PodStateUnknown, err
+	}
+
+	for _, pod := range pods {
+		if state := podFailure(pod); state != model.PodStateUnknown {
+			return state, nil
+		}
+	}
+
+	if len(pods) == 0 {
+		// pods which cannot be created do not show up at all, the replication controller reports why
+		return o.replicaFailureState(apiURL, bearerToken, namespace, service)
+	}
+	return model.PodStateUnknown, nil
+}
+
+// replicaFailureState returns `PodQuotaExceeded` if a replication controller of the given service fails to
+// create pods since the resource quota of the namespace is exceeded, `PodStateUnknown` otherwise.
+func (o *openShift) replicaFailureState(apiURL string, bearerToken string, namespace string, service string) (model.PodState, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", namespace, "replicationcontrollers", nil)
+	if err != nil {
+		return model.PodStateUnknown, err
+	}
+	v := req.URL.Query()
+	v.Add("labelSelector", "openshift.io/deployment-config.name="+service)
+	req.URL.RawQuery = v.Encode()
+	negotiate(req)
+
+	resp, err := o.do(req)
+	if err != nil {
+		return model.PodStateUnknown, err
+	}
+	defer bodyClose(resp)
+
+	rcList := &v1.ReplicationControllerList{}
+	err = decode(resp, rcList)
+	if err != nil {
+		return model.PodStateUnknown, err
+	}
+
+	for _, rc := range rcList.Items {
+		if quotaExceeded(rc) {
+			return model.PodQuotaExceeded, nil
+		}
+	}
+	return model.PodStateUnknown, nil
+}
+
+// quotaExceeded returns true if the replication controller fails to create pods due to the resource quota.
+func quotaExceeded(rc v1.ReplicationController) bool {
+	for _, cond := range rc.Status.Conditions {
+		if cond.Type == v1.ReplicationControllerReplicaFailure && cond.Status == v1.ConditionTrue &&
+			strings.Contains(cond.Message, "exceeded quota") {
+			return true
+		}
 	}
-	return model.PodRunning, nil
+	return false
+}
+
+// Restarts returns how often the containers of the pods of the given service got restarted and whether they
+// got killed for running out of memory.
+func (o *openShift) Restarts(apiURL string, bearerToken string, namespace string, service string) (model.PodRestarts, error) {
+	restarts := model.PodRestarts{}
+	pods, err := o.pods(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		return restarts, err
+	}
+
+	for _, pod := range pods {
+		r := podRestarts(pod)
+		if r.Count > restarts.Count {
+			restarts.Count = r.Count
+		}
+		restarts.OOMKilled = restarts.OOMKilled || r.OOMKilled
+	}
+	return restarts, nil
+}
+
+// podRestarts returns how often the containers of the given pod got restarted and whether they got killed for
+// running out of memory.
+func podRestarts(pod v1.Pod) model.PodRestarts {
+	restarts := model.PodRestarts{}
+	for _, status := range pod.Status.ContainerStatuses {
+		if int(status.RestartCount) > restarts.Count {
+			restarts.Count = int(status.RestartCount)
+		}
+		if oomKilled(status.State) || oomKilled(status.LastTerminationState) {
+			restarts.OOMKilled = true
+		}
+	}
+	return restarts
+}
+
+func oomKilled(state v1.ContainerState) bool {
+	return state.Terminated != nil && state.Terminated.Reason == "OOMKilled"
+}
+
+// pods returns the pods of the given service.
+func (o *openShift) pods(apiURL string, bearerToken string, namespace string, service string) ([]v1.Pod, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", namespace, "pods", nil)
+	if err != nil {
+		return nil, err
+	}
+	v := req.URL.Query()
+	v.Add("labelSelector", "deploymentconfig="+service)
+	req.URL.RawQuery = v.Encode()
+	negotiate(req)
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	podList := &v1.PodList{}
+	err = decode(resp, podList)
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// podFailure determines why the given pod fails to start, if it does.
+func podFailure(pod v1.Pod) model.PodState {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "CrashLoopBackOff":
+			return model.PodCrashLooping
+		case "ImagePullBackOff", "ErrImagePull":
+			return model.PodImagePullFailed
+		}
+	}
+
+	if pod.Status.Phase == v1.PodPending {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse && cond.Reason == v1.PodReasonUnschedulable {
+				return model.PodUnschedulable
+			}
+		}
+	}
+	return model.PodStateUnknown
 }
 
 // GetScheme converts bool representing whether a route
@@ -279,8 +727,14 @@ func (o openShift) getScheme(tls bool) string {
 	return scheme
 }
 
-// WatchBuilds consumes stream of build events from openShift and calls callback to process them.
-func (o openShift) WatchBuilds(apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error {
+// ErrStopWatch is returned by the callback of a watch to stop watching. As the callback is only called upon events,
+// the watch stops with the next event after it should have been stopped. Cancelling the context of the watch stops
+// it right away.
+var ErrStopWatch = errors.New("watch stopped")
+
+// WatchBuilds consumes stream of build events from openShift and calls callback to process them. It returns once the
+// callback returns ErrStopWatch or the context is done, or with an error once the watch gives up.
+func (o openShift) WatchBuilds(ctx context.Context, apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error {
 	logger.Infof("Watching builds of type %s on cluster %s", buildType, apiURL)
 
 	// Use a HTTP client with disabled timeout.
@@ -290,28 +744,50 @@ func (o openShift) WatchBuilds(apiURL string, bearerToken string, buildType stri
 		},
 		Timeout: time.Duration(0) * time.Second,
 	}
+	position := &watchPosition{}
+	failures := &watchFailures{}
 	for {
+		if ctx.Err() != nil {
+			logger.WithField("cluster", apiURL).Info("Stopped watching builds")
+			return nil
+		}
 		req, err := o.reqOAPIWatch(apiURL, bearerToken, "GET", "", "builds", nil)
 		if err != nil {
-			logger.Fatal(err)
+			return err
 		}
+		o.selectors.Builds.apply(req)
+		position.apply(req)
+		req = req.WithContext(ctx)
 
 		resp, err := c.Do(req)
 		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
 			logger.Errorf("Request failed: %s", err)
+			if err := failures.failed("builds", 0, err); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			logger.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
 			resp.Body.Close()
+			if err := failures.failed("builds", resp.StatusCode, nil); err != nil {
+				return err
+			}
 			continue
 		}
+		failures.succeeded()
 
 		reader := bufio.NewReader(resp.Body)
 		for {
 			line, err := reader.ReadBytes('\n')
 			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
 				// openShift sometimes ends the stream, break to create new request.
 				if err.Error() == "EOF" || err.Error() == "unexpected EOF" {
 					logger.Info("Got error ", err, " but continuing..")
@@ -323,21 +799,38 @@ func (o openShift) WatchBuilds(apiURL string, bearerToken string, buildType stri
 
 			err = json.Unmarshal(line, &o)
 			if err != nil {
+				if isErrorEvent(line) {
+					logger.WithField("error", string(line)).Warning("Watch expired, starting over from the current state")
+					position.observe(eventError, "")
+					break
+				}
 				// This happens with oc CLI tool as well from time to time, take care of it and create new request.
 				if strings.HasPrefix(string(line), "This request caused apisever to panic") {
 					logger.WithField("error", string(line)).Warning("Communication with server failed")
 					break
 				}
+				if decodeFailed(apiURL, "builds", line) {
+					continue
+				}
 				logger.Errorf("Failed to Unmarshal: %s", err)
 				break
 			}
 
 			log := logger.WithFields(logrus.Fields{
-				"data":     o,
-				"ns":       o.Object.Metadata.Namespace,
-				"strategy": o.Object.Spec.Strategy.Type,
+				"data":      o,
+				"namespace": o.Object.Metadata.Namespace,
+				"strategy":  o.Object.Spec.Strategy.Type,
 			})
 
+			if !position.observe(o.Type, o.Object.Metadata.ResourceVersion) {
+				continue
+			}
+
+			if err := validateBuildEvent(o); err != nil {
+				quarantine(apiURL, "builds", line, err)
+				continue
+			}
+
 			// Verify a build has a type we care about.
 			if o.Object.Spec.Strategy.Type != buildType {
 				continue
@@ -345,17 +838,132 @@ func (o openShift) WatchBuilds(apiURL string, bearerToken string, buildType stri
 
 			log.Debug("Handling Build event")
 			err = callback(o)
+			if err == ErrStopWatch {
+				resp.Body.Close()
+				logger.WithField("cluster", apiURL).Info("Stopped watching builds")
+				return nil
+			}
 			if err != nil {
 				log.Errorf("Error from callback: %s", err)
 				continue
 			}
 		}
+		resp.Body.Close()
 		logger.Debug("Fell out of loop for Build")
 	}
 }
 
+// WatchPods consumes stream of Jenkins Pod events from openShift and calls callback to process them.
+// It returns once the callback returns ErrStopWatch or the context is done, or with an error once the watch gives up.
+func (o openShift) WatchPods(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.PodObject) error) error {
+	// Use a HTTP client with disabled timeout.
+	c := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: 20,
+		},
+		Timeout: time.Duration(0) * time.Second,
+	}
+	position := &watchPosition{}
+	failures := &watchFailures{}
+	for {
+		if ctx.Err() != nil {
+			logger.WithField("cluster", apiURL).Info("Stopped watching pods")
+			return nil
+		}
+		req, err := o.reqAPIWatch(apiURL, bearerToken, "GET", "", "pods", nil)
+		if err != nil {
+			return err
+		}
+		o.selectors.Pods.apply(req)
+		position.apply(req)
+		req = req.WithContext(ctx)
+		negotiate(req)
+		resp, err := c.Do(req)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			logger.Errorf("Request failed: %s", err)
+			if err := failures.failed("pods", 0, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			logger.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
+			resp.Body.Close()
+			if err := failures.failed("pods", resp.StatusCode, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		failures.succeeded()
+
+		events := newPodEventReader(resp)
+		for {
+			e, err := events.next()
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				logger.Info("Got error ", err, " but continuing..")
+				break
+			}
+			if err != nil {
+				logger.Errorf("Failed to decode Pod event: %s", err)
+				break
+			}
+			if e.Type == eventError {
+				logger.Warning("Watch expired, starting over from the current state")
+				position.observe(eventError, "")
+				break
+			}
+
+			log := logger.WithFields(logrus.Fields{
+				"pod":       e.Object.Name,
+				"namespace": e.Object.Namespace,
+			})
+
+			if !position.observe(e.Type, e.Object.ResourceVersion) {
+				continue
+			}
+
+			// Filter for a given suffix.
+			if !strings.HasSuffix(e.Object.Namespace, namespaceSuffix) {
+				log.Debug("Skipping Pod event")
+				continue
+			}
+
+			log.Debug("Handling Pod event")
+			err = callback(model.PodObject{Type: e.Type, Object: toPod(e.Object)})
+			if err == ErrStopWatch {
+				resp.Body.Close()
+				logger.WithField("cluster", apiURL).Info("Stopped watching pods")
+				return nil
+			}
+			if err != nil {
+				logger.Errorf("Error from Pod callback: %s", err)
+				continue
+			}
+		}
+		resp.Body.Close()
+		logger.Debug("Fell out of loop for watching Pods")
+	}
+}
+
+// toPod summarizes the given pod.
+func toPod(pod v1.Pod) model.Pod {
+	return model.Pod{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+		Phase:     string(pod.Status.Phase),
+		Restarts:  podRestarts(pod),
+		Failure:   podFailure(pod),
+	}
+}
+
 // WatchDeploymentConfigs consumes stream of DeploymentConfig events from openShift and calls callback to process them.
-func (o openShift) WatchDeploymentConfigs(apiURL string, bearerToken string, namespaceSuffix string, callback func(model.DCObject) error) error {
+// It returns once the callback returns ErrStopWatch or the context is done, or with an error once the watch gives up.
+func (o openShift) WatchDeploymentConfigs(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.DCObject) error) error {
 	// Use a HTTP client with disabled timeout.
 	c := &http.Client{
 		Transport: &http.Transport{
@@ -363,31 +971,50 @@ func (o openShift) WatchDeploymentConfigs(apiURL string, bearerToken string, nam
 		},
 		Timeout: time.Duration(0) * time.Second,
 	}
+	position := &watchPosition{}
+	failures := &watchFailures{}
 	for {
+		if ctx.Err() != nil {
+			logger.WithField("cluster", apiURL).Info("Stopped watching deployment configs")
+			return nil
+		}
 		req, err := o.reqOAPIWatch(apiURL, bearerToken, "GET", "", "deploymentconfigs", nil)
 		if err != nil {
-			logger.Fatal(err)
+			return err
 		}
-		v := req.URL.Query()
-		v.Add("labelSelector", "app=jenkins")
-		req.URL.RawQuery = v.Encode()
+		o.selectors.DeploymentConfigs.apply(req)
+		position.apply(req)
+		req = req.WithContext(ctx)
 		resp, err := c.Do(req)
 
 		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
 			logger.Errorf("Request failed: %s", err)
+			if err := failures.failed("deploymentconfigs", 0, err); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			logger.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
 			resp.Body.Close()
+			if err := failures.failed("deploymentconfigs", resp.StatusCode, nil); err != nil {
+				return err
+			}
 			continue
 		}
+		failures.succeeded()
 
 		reader := bufio.NewReader(resp.Body)
 		for {
 			line, err := reader.ReadBytes('\n')
 			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
 				if err.Error() == "EOF" || err.Error() == "unexpected EOF" {
 					logger.Info("Got error ", err, " but continuing..")
 					break
@@ -398,19 +1025,36 @@ func (o openShift) WatchDeploymentConfigs(apiURL string, bearerToken string, nam
 
 			err = json.Unmarshal(line, &o)
 			if err != nil {
+				if isErrorEvent(line) {
+					logger.WithField("error", string(line)).Warning("Watch expired, starting over from the current state")
+					position.observe(eventError, "")
+					break
+				}
 				if strings.HasPrefix(string(line), "This request caused apisever to panic") {
 					logger.WithField("error", string(line)).Warning("Communication with server failed")
 					break
 				}
+				if decodeFailed(apiURL, "deploymentconfigs", line) {
+					continue
+				}
 				logger.Errorf("Failed to Unmarshal: %s", err)
 				break
 			}
 
 			log := logger.WithFields(logrus.Fields{
-				"data": o,
-				"ns":   o.Object.Metadata.Namespace,
+				"data":      o,
+				"namespace": o.Object.Metadata.Namespace,
 			})
 
+			if !position.observe(o.Type, o.Object.Metadata.ResourceVersion) {
+				continue
+			}
+
+			if err := validateDCEvent(o); err != nil {
+				quarantine(apiURL, "deploymentconfigs", line, err)
+				continue
+			}
+
 			// Filter for a given suffix.
 			if !strings.HasSuffix(o.Object.Metadata.Namespace, namespaceSuffix) {
 				log.Debug("Skipping DC change event")
@@ -419,22 +1063,183 @@ func (o openShift) WatchDeploymentConfigs(apiURL string, bearerToken string, nam
 
 			log.Debug("Handling DC event")
 			err = callback(o)
+			if err == ErrStopWatch {
+				resp.Body.Close()
+				logger.WithField("cluster", apiURL).Info("Stopped watching deployment configs")
+				return nil
+			}
 			if err != nil {
 				logger.Errorf("Error from DC callback: %s", err)
 				continue
 			}
 		}
+		resp.Body.Close()
 		logger.Debug("Fell out of loop for watching DC")
 	}
 }
 
+// WatchIdlerConfigs consumes stream of JenkinsIdlerConfig events from openShift and calls callback to process them.
+// It returns once the callback returns ErrStopWatch or the context is done, or with an error once the watch gives up, e.g. since the custom
+// resource definition is not installed.
+func (o openShift) WatchIdlerConfigs(ctx context.Context, apiURL string, bearerToken string, namespaceSuffix string, callback func(model.IdlerConfigObject) error) error {
+	// Use a HTTP client with disabled timeout.
+	c := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: 20,
+		},
+		Timeout: time.Duration(0) * time.Second,
+	}
+	position := &watchPosition{}
+	failures := &watchFailures{}
+	for {
+		if ctx.Err() != nil {
+			logger.WithField("cluster", apiURL).Info("Stopped watching idler configs")
+			return nil
+		}
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/apis/%s/%s?watch=true", strings.TrimSuffix(apiURL, "/"),
+			model.IdlerConfigGroupVersion, model.IdlerConfigResource), nil)
+		if err != nil {
+			return err
+		}
+		authorize(req, apiURL, bearerToken)
+		position.apply(req)
+		req = req.WithContext(ctx)
+		resp, err := c.Do(req)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			logger.Errorf("Request failed: %s", err)
+			if err := failures.failed(model.IdlerConfigResource, 0, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			logger.Errorf("got status %s (%d) from %s", resp.Status, resp.StatusCode, req.URL)
+			resp.Body.Close()
+			if err := failures.failed(model.IdlerConfigResource, resp.StatusCode, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		failures.succeeded()
+
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil && len(bytes.TrimSpace(line)) == 0 {
+				logger.Info("Got error ", err, " but continuing..")
+				break
+			}
+
+			o := model.IdlerConfigObject{}
+			if err := json.Unmarshal(line, &o); err != nil {
+				if isErrorEvent(line) {
+					logger.WithField("error", string(line)).Warning("Watch expired, starting over from the current state")
+					position.observe(eventError, "")
+					break
+				}
+				if decodeFailed(apiURL, model.IdlerConfigResource, line) {
+					continue
+				}
+				logger.Errorf("Failed to Unmarshal: %s", err)
+				break
+			}
+
+			if !position.observe(o.Type, o.Object.Metadata.ResourceVersion) {
+				continue
+			}
+
+			if err := validateEvent(o.Type, o.Object.Metadata); err != nil {
+				quarantine(apiURL, model.IdlerConfigResource, line, err)
+				continue
+			}
+
+			// Filter for a given suffix.
+			if !strings.HasSuffix(o.Object.Metadata.Namespace, namespaceSuffix) {
+				continue
+			}
+
+			err = callback(o)
+			if err == ErrStopWatch {
+				resp.Body.Close()
+				logger.WithField("cluster", apiURL).Info("Stopped watching idler configs")
+				return nil
+			}
+			if err != nil {
+				logger.Errorf("Error from idler config callback: %s", err)
+			}
+		}
+		resp.Body.Close()
+	}
+}
+
+// ListDeploymentConfigs returns the Jenkins deployment configs of all namespaces with the given suffix.
+func (o *openShift) ListDeploymentConfigs(apiURL string, bearerToken string, namespaceSuffix string) ([]model.DeploymentConfig, error) {
+	req, err := o.reqOAPI(apiURL, bearerToken, "GET", "", "deploymentconfigs", nil)
+	if err != nil {
+		return nil, err
+	}
+	o.selectors.DeploymentConfigs.apply(req)
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	list := model.DeploymentConfigList{}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	var dcs []model.DeploymentConfig
+	for _, dc := range list.Items {
+		if strings.HasSuffix(dc.Metadata.Namespace, namespaceSuffix) {
+			dcs = append(dcs, dc)
+		}
+	}
+	return dcs, nil
+}
+
+// ListNamespaces returns the names of the namespaces of the cluster ending with the given suffix.
+func (o *openShift) ListNamespaces(apiURL string, bearerToken string, namespaceSuffix string) ([]string, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", "", "namespaces", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	list := v1.NamespaceList{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	for _, ns := range list.Items {
+		if strings.HasSuffix(ns.Name, namespaceSuffix) {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+	return namespaces, nil
+}
+
 func (o openShift) WhoAmI(apiURL string, bearerToken string) (string, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apis/user.openshift.io/v1/users/~", strings.TrimSuffix(apiURL, "/")), nil)
 	if err != nil {
 		return "", fmt.Errorf("unable to retrieve the username from the `whoami` API endpoint: %s", err)
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
+	authorize(req, apiURL, bearerToken)
 	client := http.DefaultClient
 	resp, err := client.Do(req)
 	if err != nil {
@@ -451,21 +1256,148 @@ func (o openShift) WhoAmI(apiURL string, bearerToken string) (string, error) {
 	return user.Metadata.Name, nil
 }
 
-// GetBuilds loads builds for a given namespace from openShift.
-func (o openShift) getBuilds(apiURL string, bearerToken string, namespace string) (bl model.BuildList, err error) {
+// NamespaceLabels returns the labels of the given namespace.
+func (o *openShift) NamespaceLabels(apiURL string, bearerToken string, namespace string) (map[string]string, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", "", "namespaces/"+namespace, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	ns := v1.Namespace{}
+	if err := json.NewDecoder(resp.Body).Decode(&ns); err != nil {
+		return nil, err
+	}
+	return ns.Labels, nil
+}
+
+// RunningPods returns the number of running pods in the given namespace.
+func (o *openShift) RunningPods(apiURL string, bearerToken string, namespace string) (int, error) {
+	req, err := o.reqAPI(apiURL, bearerToken, "GET", namespace, "pods", nil)
+	if err != nil {
+		return 0, err
+	}
+	v := req.URL.Query()
+	v.Add("fieldSelector", "status.phase=Running")
+	req.URL.RawQuery = v.Encode()
+	negotiate(req)
+
+	resp, err := o.do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer bodyClose(resp)
+
+	podList := &v1.PodList{}
+	if err := decode(resp, podList); err != nil {
+		return 0, err
+	}
+	return len(podList.Items), nil
+}
+
+// RolloutsInProgress returns the names of the deployment configs in the given namespace whose rollout is in progress.
+func (o *openShift) RolloutsInProgress(apiURL string, bearerToken string, namespace string) ([]string, error) {
+	req, err := o.reqOAPI(apiURL, bearerToken, "GET", namespace, "deploymentconfigs", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	list := model.DeploymentConfigList{}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, dc := range list.Items {
+		if dc.RolloutInProgress() {
+			names = append(names, dc.Metadata.Name)
+		}
+	}
+	return names, nil
+}
+
+// IdleDeployments scales the deployments in the given namespace matching the label selector down to zero replicas
+// and returns the names of those which had been scaled up.
+func (o *openShift) IdleDeployments(apiURL string, bearerToken string, namespace string, labelSelector string) ([]string, error) {
+	log := logger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL})
+
+	deploymentsURL := fmt.Sprintf("%s/apis/apps/v1/namespaces/%s/deployments", strings.TrimSuffix(apiURL, "/"), namespace)
+	req, err := http.NewRequest("GET", deploymentsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(req, apiURL, bearerToken)
+	v := req.URL.Query()
+	v.Add("labelSelector", labelSelector)
+	req.URL.RawQuery = v.Encode()
+
+	resp, err := o.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer bodyClose(resp)
+
+	deployments := struct {
+		Items []struct {
+			Metadata model.Metadata `json:"metadata"`
+			Spec     struct {
+				Replicas *int `json:"replicas"`
+			} `json:"spec"`
+		} `json:"items"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&deployments); err != nil {
+		return nil, err
+	}
+
+	var idled []string
+	for _, d := range deployments.Items {
+		if d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+			continue
+		}
+		log.Infof("Idling deployment %s in namespace %s", d.Metadata.Name, namespace)
+		req, err := http.NewRequest("PATCH", deploymentsURL+"/"+d.Metadata.Name, strings.NewReader(`{"spec":{"replicas":0}}`))
+		if err != nil {
+			return idled, err
+		}
+		authorize(req, apiURL, bearerToken)
+		if _, err := o.patch(req); err != nil {
+			return idled, err
+		}
+		idled = append(idled, d.Metadata.Name)
+	}
+	return idled, nil
+}
+
+// ListBuilds returns the builds of the given namespace, restricted by the build selectors.
+func (o openShift) ListBuilds(apiURL string, bearerToken string, namespace string) ([]model.Build, error) {
 	req, err := o.reqOAPI(apiURL, bearerToken, "GET", namespace, "builds", nil)
 	if err != nil {
-		return
+		return nil, err
 	}
+	o.selectors.Builds.apply(req)
 
 	resp, err := o.do(req)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	defer bodyClose(resp)
-	err = json.NewDecoder(resp.Body).Decode(&bl)
-	return
+	var list model.BuildList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // req constructs a HTTP request for openShift API.
@@ -488,7 +1420,7 @@ func (o *openShift) req(apiURL string, bearerToken string, method string, oapi b
 		return
 	}
 
-	req.Header.Add("Authorization", "Bearer "+bearerToken)
+	authorize(req, apiURL, bearerToken)
 	if watch {
 		v := req.URL.Query()
 		v.Add("watch", "true")
@@ -498,6 +1430,15 @@ func (o *openShift) req(apiURL string, bearerToken string, method string, oapi b
 	return
 }
 
+// authorize adds the bearer token to the request. The token of the cluster provided by a projected volume takes
+// precedence over the given one, so that long-running callers which got the token at startup use the rotated token.
+func authorize(req *http.Request, apiURL string, bearerToken string) {
+	if projected, ok := token.ClusterToken(apiURL); ok {
+		bearerToken = projected
+	}
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+}
+
 // reqOAPI is a helper to construct a request for openShift API.
 func (o *openShift) reqOAPI(apiURL string, bearerToken string, method string, namespace string, command string, body io.Reader) (*http.Request, error) {
 	return o.req(apiURL, bearerToken, method, true, namespace, command, body, false)
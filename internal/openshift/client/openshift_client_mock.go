@@ -5,9 +5,11 @@
 package client
 
 import (
+	context "context"
 	model "github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockOpenShiftClient is a mock of OpenShiftClient interface
@@ -33,6 +35,34 @@ func (m *MockOpenShiftClient) EXPECT() *MockOpenShiftClientMockRecorder {
 	return m.recorder
 }
 
+// Annotate mocks base method
+func (m *MockOpenShiftClient) Annotate(apiURL, bearerToken, namespace, service string, annotations map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Annotate", apiURL, bearerToken, namespace, service, annotations)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Annotate indicates an expected call of Annotate
+func (mr *MockOpenShiftClientMockRecorder) Annotate(apiURL, bearerToken, namespace, service, annotations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Annotate", reflect.TypeOf((*MockOpenShiftClient)(nil).Annotate), apiURL, bearerToken, namespace, service, annotations)
+}
+
+// SetMemoryLimit mocks base method
+func (m *MockOpenShiftClient) SetMemoryLimit(apiURL, bearerToken, namespace, service string, limit int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemoryLimit", apiURL, bearerToken, namespace, service, limit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemoryLimit indicates an expected call of SetMemoryLimit
+func (mr *MockOpenShiftClientMockRecorder) SetMemoryLimit(apiURL, bearerToken, namespace, service, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemoryLimit", reflect.TypeOf((*MockOpenShiftClient)(nil).SetMemoryLimit), apiURL, bearerToken, namespace, service, limit)
+}
+
 // Idle mocks base method
 func (m *MockOpenShiftClient) Idle(apiURL, bearerToken, namespace, service string) error {
 	m.ctrl.T.Helper()
@@ -92,43 +122,338 @@ func (mr *MockOpenShiftClientMockRecorder) WhoAmI(apiURL, bearerToken interface{
 }
 
 // WatchBuilds mocks base method
-func (m *MockOpenShiftClient) WatchBuilds(apiURL, bearerToken, buildType string, callback func(model.Object) error) error {
+func (m *MockOpenShiftClient) WatchBuilds(ctx context.Context, apiURL, bearerToken, buildType string, callback func(model.Object) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WatchBuilds", apiURL, bearerToken, buildType, callback)
+	ret := m.ctrl.Call(m, "WatchBuilds", ctx, apiURL, bearerToken, buildType, callback)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WatchBuilds indicates an expected call of WatchBuilds
-func (mr *MockOpenShiftClientMockRecorder) WatchBuilds(apiURL, bearerToken, buildType, callback interface{}) *gomock.Call {
+func (mr *MockOpenShiftClientMockRecorder) WatchBuilds(ctx, apiURL, bearerToken, buildType, callback interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchBuilds", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchBuilds), apiURL, bearerToken, buildType, callback)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchBuilds", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchBuilds), ctx, apiURL, bearerToken, buildType, callback)
 }
 
 // WatchDeploymentConfigs mocks base method
-func (m *MockOpenShiftClient) WatchDeploymentConfigs(apiURL, bearerToken, namespaceSuffix string, callback func(model.DCObject) error) error {
+func (m *MockOpenShiftClient) WatchDeploymentConfigs(ctx context.Context, apiURL, bearerToken, namespaceSuffix string, callback func(model.DCObject) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WatchDeploymentConfigs", apiURL, bearerToken, namespaceSuffix, callback)
+	ret := m.ctrl.Call(m, "WatchDeploymentConfigs", ctx, apiURL, bearerToken, namespaceSuffix, callback)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WatchDeploymentConfigs indicates an expected call of WatchDeploymentConfigs
-func (mr *MockOpenShiftClientMockRecorder) WatchDeploymentConfigs(apiURL, bearerToken, namespaceSuffix, callback interface{}) *gomock.Call {
+func (mr *MockOpenShiftClientMockRecorder) WatchDeploymentConfigs(ctx, apiURL, bearerToken, namespaceSuffix, callback interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeploymentConfigs", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchDeploymentConfigs), apiURL, bearerToken, namespaceSuffix, callback)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchDeploymentConfigs", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchDeploymentConfigs), ctx, apiURL, bearerToken, namespaceSuffix, callback)
+}
+
+// ListDeploymentConfigs mocks base method
+func (m *MockOpenShiftClient) ListDeploymentConfigs(apiURL, bearerToken, namespaceSuffix string) ([]model.DeploymentConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeploymentConfigs", apiURL, bearerToken, namespaceSuffix)
+	ret0, _ := ret[0].([]model.DeploymentConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeploymentConfigs indicates an expected call of ListDeploymentConfigs
+func (mr *MockOpenShiftClientMockRecorder) ListDeploymentConfigs(apiURL, bearerToken, namespaceSuffix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeploymentConfigs", reflect.TypeOf((*MockOpenShiftClient)(nil).ListDeploymentConfigs), apiURL, bearerToken, namespaceSuffix)
+}
+
+// ListNamespaces mocks base method
+func (m *MockOpenShiftClient) ListNamespaces(apiURL, bearerToken, namespaceSuffix string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNamespaces", apiURL, bearerToken, namespaceSuffix)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNamespaces indicates an expected call of ListNamespaces
+func (mr *MockOpenShiftClientMockRecorder) ListNamespaces(apiURL, bearerToken, namespaceSuffix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNamespaces", reflect.TypeOf((*MockOpenShiftClient)(nil).ListNamespaces), apiURL, bearerToken, namespaceSuffix)
+}
+
+// ListBuilds mocks base method
+func (m *MockOpenShiftClient) ListBuilds(apiURL, bearerToken, namespace string) ([]model.Build, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBuilds", apiURL, bearerToken, namespace)
+	ret0, _ := ret[0].([]model.Build)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBuilds indicates an expected call of ListBuilds
+func (mr *MockOpenShiftClientMockRecorder) ListBuilds(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBuilds", reflect.TypeOf((*MockOpenShiftClient)(nil).ListBuilds), apiURL, bearerToken, namespace)
 }
 
 // Reset mocks base method
-func (m *MockOpenShiftClient) Reset(apiURL, bearerToken, namespace string) error {
+func (m *MockOpenShiftClient) Reset(apiURL, bearerToken, namespace, service string, options ResetOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Reset", apiURL, bearerToken, namespace)
+	ret := m.ctrl.Call(m, "Reset", apiURL, bearerToken, namespace, service, options)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Reset indicates an expected call of Reset
-func (mr *MockOpenShiftClientMockRecorder) Reset(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+func (mr *MockOpenShiftClientMockRecorder) Reset(apiURL, bearerToken, namespace, service, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockOpenShiftClient)(nil).Reset), apiURL, bearerToken, namespace, service, options)
+}
+
+// Restarts mocks base method
+func (m *MockOpenShiftClient) Restarts(apiURL, bearerToken, namespace, service string) (model.PodRestarts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restarts", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(model.PodRestarts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restarts indicates an expected call of Restarts
+func (mr *MockOpenShiftClientMockRecorder) Restarts(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restarts", reflect.TypeOf((*MockOpenShiftClient)(nil).Restarts), apiURL, bearerToken, namespace, service)
+}
+
+// WatchPods mocks base method
+func (m *MockOpenShiftClient) WatchPods(ctx context.Context, apiURL, bearerToken, namespaceSuffix string, callback func(model.PodObject) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchPods", ctx, apiURL, bearerToken, namespaceSuffix, callback)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchPods indicates an expected call of WatchPods
+func (mr *MockOpenShiftClientMockRecorder) WatchPods(ctx, apiURL, bearerToken, namespaceSuffix, callback interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchPods", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchPods), ctx, apiURL, bearerToken, namespaceSuffix, callback)
+}
+
+// WatchIdlerConfigs mocks base method
+func (m *MockOpenShiftClient) WatchIdlerConfigs(ctx context.Context, apiURL, bearerToken, namespaceSuffix string, callback func(model.IdlerConfigObject) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchIdlerConfigs", ctx, apiURL, bearerToken, namespaceSuffix, callback)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchIdlerConfigs indicates an expected call of WatchIdlerConfigs
+func (mr *MockOpenShiftClientMockRecorder) WatchIdlerConfigs(ctx, apiURL, bearerToken, namespaceSuffix, callback interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchIdlerConfigs", reflect.TypeOf((*MockOpenShiftClient)(nil).WatchIdlerConfigs), ctx, apiURL, bearerToken, namespaceSuffix, callback)
+}
+
+// NamespaceLabels mocks base method
+func (m *MockOpenShiftClient) NamespaceLabels(apiURL, bearerToken, namespace string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceLabels", apiURL, bearerToken, namespace)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamespaceLabels indicates an expected call of NamespaceLabels
+func (mr *MockOpenShiftClientMockRecorder) NamespaceLabels(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceLabels", reflect.TypeOf((*MockOpenShiftClient)(nil).NamespaceLabels), apiURL, bearerToken, namespace)
+}
+
+// Rollout mocks base method
+func (m *MockOpenShiftClient) Rollout(apiURL, bearerToken, namespace, service string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollout", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rollout indicates an expected call of Rollout
+func (mr *MockOpenShiftClientMockRecorder) Rollout(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollout", reflect.TypeOf((*MockOpenShiftClient)(nil).Rollout), apiURL, bearerToken, namespace, service)
+}
+
+// RunningPods mocks base method
+func (m *MockOpenShiftClient) RunningPods(apiURL, bearerToken, namespace string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunningPods", apiURL, bearerToken, namespace)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunningPods indicates an expected call of RunningPods
+func (mr *MockOpenShiftClientMockRecorder) RunningPods(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunningPods", reflect.TypeOf((*MockOpenShiftClient)(nil).RunningPods), apiURL, bearerToken, namespace)
+}
+
+// RolloutsInProgress mocks base method
+func (m *MockOpenShiftClient) RolloutsInProgress(apiURL, bearerToken, namespace string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RolloutsInProgress", apiURL, bearerToken, namespace)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RolloutsInProgress indicates an expected call of RolloutsInProgress
+func (mr *MockOpenShiftClientMockRecorder) RolloutsInProgress(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RolloutsInProgress", reflect.TypeOf((*MockOpenShiftClient)(nil).RolloutsInProgress), apiURL, bearerToken, namespace)
+}
+
+// IdleDeployments mocks base method
+func (m *MockOpenShiftClient) IdleDeployments(apiURL, bearerToken, namespace, labelSelector string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdleDeployments", apiURL, bearerToken, namespace, labelSelector)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdleDeployments indicates an expected call of IdleDeployments
+func (mr *MockOpenShiftClientMockRecorder) IdleDeployments(apiURL, bearerToken, namespace, labelSelector interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdleDeployments", reflect.TypeOf((*MockOpenShiftClient)(nil).IdleDeployments), apiURL, bearerToken, namespace, labelSelector)
+}
+
+// PodUsage mocks base method
+func (m *MockOpenShiftClient) PodUsage(apiURL, bearerToken, namespace string) (model.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PodUsage", apiURL, bearerToken, namespace)
+	ret0, _ := ret[0].(model.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PodUsage indicates an expected call of PodUsage
+func (mr *MockOpenShiftClientMockRecorder) PodUsage(apiURL, bearerToken, namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PodUsage", reflect.TypeOf((*MockOpenShiftClient)(nil).PodUsage), apiURL, bearerToken, namespace)
+}
+
+// AcquireLease mocks base method
+func (m *MockOpenShiftClient) AcquireLease(apiURL, bearerToken, namespace, name, holder string, duration time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcquireLease", apiURL, bearerToken, namespace, name, holder, duration)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcquireLease indicates an expected call of AcquireLease
+func (mr *MockOpenShiftClientMockRecorder) AcquireLease(apiURL, bearerToken, namespace, name, holder, duration interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcquireLease", reflect.TypeOf((*MockOpenShiftClient)(nil).AcquireLease), apiURL, bearerToken, namespace, name, holder, duration)
+}
+
+// ReleaseLease mocks base method
+func (m *MockOpenShiftClient) ReleaseLease(apiURL, bearerToken, namespace, name, holder string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseLease", apiURL, bearerToken, namespace, name, holder)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseLease indicates an expected call of ReleaseLease
+func (mr *MockOpenShiftClientMockRecorder) ReleaseLease(apiURL, bearerToken, namespace, name, holder interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseLease", reflect.TypeOf((*MockOpenShiftClient)(nil).ReleaseLease), apiURL, bearerToken, namespace, name, holder)
+}
+
+// EndpointsIdledAt mocks base method
+func (m *MockOpenShiftClient) EndpointsIdledAt(apiURL, bearerToken, namespace, service string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndpointsIdledAt", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndpointsIdledAt indicates an expected call of EndpointsIdledAt
+func (mr *MockOpenShiftClientMockRecorder) EndpointsIdledAt(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndpointsIdledAt", reflect.TypeOf((*MockOpenShiftClient)(nil).EndpointsIdledAt), apiURL, bearerToken, namespace, service)
+}
+
+// RouteURL mocks base method
+func (m *MockOpenShiftClient) RouteURL(apiURL, bearerToken, namespace, service string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RouteURL", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RouteURL indicates an expected call of RouteURL
+func (mr *MockOpenShiftClientMockRecorder) RouteURL(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RouteURL", reflect.TypeOf((*MockOpenShiftClient)(nil).RouteURL), apiURL, bearerToken, namespace, service)
+}
+
+// BusyExecutors mocks base method
+func (m *MockOpenShiftClient) BusyExecutors(apiURL, bearerToken, namespace, service string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BusyExecutors", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BusyExecutors indicates an expected call of BusyExecutors
+func (mr *MockOpenShiftClientMockRecorder) BusyExecutors(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BusyExecutors", reflect.TypeOf((*MockOpenShiftClient)(nil).BusyExecutors), apiURL, bearerToken, namespace, service)
+}
+
+// QuietDown mocks base method
+func (m *MockOpenShiftClient) QuietDown(apiURL, bearerToken, namespace, service string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuietDown", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QuietDown indicates an expected call of QuietDown
+func (mr *MockOpenShiftClientMockRecorder) QuietDown(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuietDown", reflect.TypeOf((*MockOpenShiftClient)(nil).QuietDown), apiURL, bearerToken, namespace, service)
+}
+
+// CancelQuietDown mocks base method
+func (m *MockOpenShiftClient) CancelQuietDown(apiURL, bearerToken, namespace, service string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelQuietDown", apiURL, bearerToken, namespace, service)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelQuietDown indicates an expected call of CancelQuietDown
+func (mr *MockOpenShiftClientMockRecorder) CancelQuietDown(apiURL, bearerToken, namespace, service interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelQuietDown", reflect.TypeOf((*MockOpenShiftClient)(nil).CancelQuietDown), apiURL, bearerToken, namespace, service)
+}
+
+// Probe mocks base method
+func (m *MockOpenShiftClient) Probe(apiURL, bearerToken, namespace, service, path string) (Health, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Probe", apiURL, bearerToken, namespace, service, path)
+	ret0, _ := ret[0].(Health)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Probe indicates an expected call of Probe
+func (mr *MockOpenShiftClientMockRecorder) Probe(apiURL, bearerToken, namespace, service, path interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockOpenShiftClient)(nil).Reset), apiURL, bearerToken, namespace)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Probe", reflect.TypeOf((*MockOpenShiftClient)(nil).Probe), apiURL, bearerToken, namespace, service, path)
 }
@@ -0,0 +1,319 @@
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+)
+
+func waitingPod(reason string) v1.Pod {
+	return v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: reason}}},
+			},
+		},
+	}
+}
+
+func Test_pod_failure(t *testing.T) {
+	unschedulable := v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonUnschedulable},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		pod   v1.Pod
+		state model.PodState
+	}{
+		{name: "starting", pod: waitingPod("ContainerCreating"), state: model.PodStateUnknown},
+		{name: "crash loop", pod: waitingPod("CrashLoopBackOff"), state: model.PodCrashLooping},
+		{name: "image pull back off", pod: waitingPod("ImagePullBackOff"), state: model.PodImagePullFailed},
+		{name: "image pull error", pod: waitingPod("ErrImagePull"), state: model.PodImagePullFailed},
+		{name: "unschedulable", pod: unschedulable, state: model.PodUnschedulable},
+		{name: "pending", pod: v1.Pod{Status: v1.PodStatus{Phase: v1.PodPending}}, state: model.PodStateUnknown},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.state, podFailure(test.pod), test.name)
+	}
+}
+
+func Test_quota_exceeded(t *testing.T) {
+	rc := v1.ReplicationController{}
+	assert.False(t, quotaExceeded(rc))
+
+	rc.Status.Conditions = []v1.ReplicationControllerCondition{
+		{
+			Type:    v1.ReplicationControllerReplicaFailure,
+			Status:  v1.ConditionTrue,
+			Reason:  "FailedCreate",
+			Message: `pods "jenkins-1-abcde" is forbidden: exceeded quota: compute-resources, requested: limits.memory=1Gi`,
+		},
+	}
+	assert.True(t, quotaExceeded(rc))
+
+	rc.Status.Conditions[0].Message = "some other failure"
+	assert.False(t, quotaExceeded(rc))
+}
+
+func Test_to_pod(t *testing.T) {
+	pod := waitingPod("CrashLoopBackOff")
+	pod.Name = "jenkins-1-abcde"
+	pod.Namespace = "foo-jenkins"
+	pod.Status.ContainerStatuses[0].RestartCount = 7
+	pod.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled"},
+	}
+
+	assert.Equal(t, model.Pod{
+		Name:      "jenkins-1-abcde",
+		Namespace: "foo-jenkins",
+		Phase:     "Running",
+		Restarts:  model.PodRestarts{Count: 7, OOMKilled: true},
+		Failure:   model.PodCrashLooping,
+	}, toPod(pod))
+}
+
+func Test_selector_apply(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.example.com/oapi/v1/builds?watch=true", nil)
+	Selector{}.apply(req)
+	assert.Equal(t, "watch=true", req.URL.RawQuery)
+
+	Selector{Label: "openshift.io/build.strategy=jenkinspipeline", Field: "status.phase!=New"}.apply(req)
+	query := req.URL.Query()
+	assert.Equal(t, "true", query.Get("watch"))
+	assert.Equal(t, "openshift.io/build.strategy=jenkinspipeline", query.Get("labelSelector"))
+	assert.Equal(t, "status.phase!=New", query.Get("fieldSelector"))
+}
+
+func Test_idle_deployments(t *testing.T) {
+	var patched []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/apis/apps/v1/namespaces/foo-che/deployments":
+			assert.Equal(t, "che.workspace_id", r.URL.Query().Get("labelSelector"))
+			fmt.Fprint(w, `{"items": [{"metadata": {"name": "ws1"}, "spec": {"replicas": 1}}, {"metadata": {"name": "ws2"}, "spec": {"replicas": 0}}]}`)
+		case r.Method == "PATCH":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.JSONEq(t, `{"spec": {"replicas": 0}}`, string(body))
+			patched = append(patched, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	idled, err := NewOpenShift().IdleDeployments(api.URL, "token", "foo-che", "che.workspace_id")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ws1"}, idled)
+	assert.Equal(t, []string{"/apis/apps/v1/namespaces/foo-che/deployments/ws1"}, patched)
+}
+
+func Test_list_namespaces(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"items": [{"metadata": {"name": "foo-jenkins"}}, {"metadata": {"name": "foo-che"}}, {"metadata": {"name": "bar-jenkins"}}]}`)
+	}))
+	defer api.Close()
+
+	namespaces, err := NewOpenShift().ListNamespaces(api.URL, "token", "-jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo-jenkins", "bar-jenkins"}, namespaces)
+}
+
+func Test_list_builds(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oapi/v1/namespaces/foo/builds", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `{"kind": "BuildList", "items": [{"metadata": {"name": "app-1", "namespace": "foo"}, "status": {"phase": "Complete"}}, {"metadata": {"name": "app-2", "namespace": "foo"}, "status": {"phase": "Running"}}]}`)
+	}))
+	defer api.Close()
+
+	builds, err := NewOpenShift().ListBuilds(api.URL, "token", "foo")
+	require.NoError(t, err)
+	require.Len(t, builds, 2)
+	assert.Equal(t, "app-1", builds[0].Metadata.Name)
+	assert.Equal(t, "Running", builds[1].Status.Phase)
+}
+
+func Test_reset_deletes_pods_of_service(t *testing.T) {
+	var deleted []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/apis/policy/v1beta1/namespaces/foo-jenkins/poddisruptionbudgets":
+			fmt.Fprint(w, `{"items": []}`)
+		case r.Method == "GET":
+			assert.Equal(t, "deploymentconfig=content-repository", r.URL.Query().Get("labelSelector"))
+			fmt.Fprint(w, `{"items": [{"metadata": {"name": "content-repository-1-abcde"}}, {"metadata": {"name": "content-repository-1-deploy"}}]}`)
+		case r.Method == "DELETE":
+			assert.Equal(t, "15", r.URL.Query().Get("gracePeriodSeconds"))
+			deleted = append(deleted, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer api.Close()
+
+	err := NewOpenShift().Reset(api.URL, "token", "foo-jenkins", "content-repository", ResetOptions{GracePeriod: 15 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/api/v1/namespaces/foo-jenkins/pods/content-repository-1-abcde"}, deleted)
+}
+
+func Test_reset_respects_disruption_budget(t *testing.T) {
+	deleted := false
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/apis/policy/v1beta1/namespaces/foo-jenkins/poddisruptionbudgets":
+			fmt.Fprint(w, `{"items": [
+				{"metadata": {"name": "other"}, "spec": {"selector": {"matchLabels": {"app": "other"}}}, "status": {"disruptionsAllowed": 0}},
+				{"metadata": {"name": "jenkins"}, "spec": {"selector": {"matchLabels": {"app": "jenkins"}}}, "status": {"disruptionsAllowed": 0}}
+			]}`)
+		case r.Method == "GET":
+			fmt.Fprint(w, `{"items": [{"metadata": {"name": "jenkins-1-abcde", "labels": {"app": "jenkins"}}}]}`)
+		case r.Method == "DELETE":
+			deleted = true
+		}
+	}))
+	defer api.Close()
+
+	err := NewOpenShift().Reset(api.URL, "token", "foo-jenkins", "jenkins", ResetOptions{})
+	require.Error(t, err)
+	assert.Equal(t, &DisruptionError{Pod: "jenkins-1-abcde", Budget: "jenkins"}, err)
+	assert.False(t, deleted, "Pod should not be deleted if the PodDisruptionBudget allows no disruption")
+}
+
+func Test_reset_awaits_replacement(t *testing.T) {
+	interval := resetPollInterval
+	resetPollInterval = time.Millisecond
+	defer func() { resetPollInterval = interval }()
+
+	polls := 0
+	replacement := `{"metadata": {"name": "jenkins-1-fghij"}, "spec": {"nodeName": "node1"}}`
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/apis/policy/v1beta1/namespaces/foo-jenkins/poddisruptionbudgets":
+			fmt.Fprint(w, `{"items": []}`)
+		case r.Method == "GET":
+			polls++
+			switch polls {
+			case 1:
+				fmt.Fprint(w, `{"items": [{"metadata": {"name": "jenkins-1-abcde"}, "spec": {"nodeName": "node1"}}]}`)
+			case 2:
+				fmt.Fprint(w, `{"items": [{"metadata": {"name": "jenkins-1-abcde"}, "spec": {"nodeName": "node1"}}, {"metadata": {"name": "jenkins-1-fghij"}}]}`)
+			default:
+				fmt.Fprintf(w, `{"items": [%s]}`, replacement)
+			}
+		case r.Method == "DELETE":
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer api.Close()
+
+	err := NewOpenShift().Reset(api.URL, "token", "foo-jenkins", "jenkins", ResetOptions{Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, 3, polls, "Reset should return once the replacement pod got scheduled")
+
+	polls = 0
+	replacement = `{"metadata": {"name": "jenkins-1-fghij"}}`
+	err = NewOpenShift().Reset(api.URL, "token", "foo-jenkins", "jenkins", ResetOptions{Timeout: 10 * time.Millisecond})
+	assert.Equal(t, &ReplacementTimeoutError{Service: "jenkins", Timeout: 10 * time.Millisecond}, err)
+}
+
+func Test_rollout(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/oapi/v1/namespaces/foo-jenkins/deploymentconfigs/jenkins/instantiate", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"kind": "DeploymentRequest", "apiVersion": "v1", "name": "jenkins", "latest": true, "force": true}`, string(body))
+		fmt.Fprint(w, `{"metadata": {"name": "jenkins"}, "status": {"latestVersion": 4}}`)
+	}))
+	defer api.Close()
+
+	rollout, err := NewOpenShift().Rollout(api.URL, "token", "foo-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, "jenkins-4", rollout)
+}
+
+func Test_annotate(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/oapi/v1/namespaces/foo-jenkins/deploymentconfigs/jenkins", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"metadata": {"annotations": {"idler.fabric8.io/last-action": "idle"}}}`, string(body))
+		fmt.Fprint(w, `{}`)
+	}))
+	defer api.Close()
+
+	err := NewOpenShift().Annotate(api.URL, "token", "foo-jenkins", "jenkins", map[string]string{model.LastActionAnnotation: "idle"})
+	require.NoError(t, err)
+}
+
+func Test_set_memory_limit(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/oapi/v1/namespaces/foo-jenkins/deploymentconfigs/jenkins", r.URL.Path)
+		assert.Equal(t, "application/strategic-merge-patch+json", r.Header.Get("Content-Type"))
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.JSONEq(t, `{"spec": {"template": {"spec": {"containers": [
+			{"name": "jenkins", "resources": {"limits": {"memory": "1610612736"}}}
+		]}}}}`, string(body))
+		fmt.Fprint(w, `{}`)
+	}))
+	defer api.Close()
+
+	err := NewOpenShift().SetMemoryLimit(api.URL, "token", "foo-jenkins", "jenkins", 1536<<20)
+	require.NoError(t, err)
+}
+
+func Test_rollouts_in_progress(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/oapi/v1/namespaces/foo-stage/deploymentconfigs", r.URL.Path)
+		fmt.Fprint(w, `{"items": [
+			{"metadata": {"name": "frontend"}, "spec": {"replicas": 1}, "status": {"conditions": [{"type": "Progressing", "status": "True", "reason": "ReplicationControllerUpdated"}]}},
+			{"metadata": {"name": "backend"}, "spec": {"replicas": 1}, "status": {"conditions": [{"type": "Progressing", "status": "True", "reason": "NewReplicationControllerAvailable"}]}}
+		]}`)
+	}))
+	defer api.Close()
+
+	names, err := NewOpenShift().RolloutsInProgress(api.URL, "token", "foo-stage")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend"}, names)
+}
+
+func Test_endpoints_idled_at(t *testing.T) {
+	idled := true
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/namespaces/foo-jenkins/endpoints/jenkins", r.URL.Path)
+		if idled {
+			fmt.Fprint(w, `{"metadata": {"annotations": {"idling.alpha.openshift.io/idled-at": "2018-04-11T08:27:15Z"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"metadata": {"annotations": {}}}`)
+	}))
+	defer api.Close()
+
+	idledAt, err := NewOpenShift().EndpointsIdledAt(api.URL, "token", "foo-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC), idledAt)
+
+	idled = false
+	idledAt, err = NewOpenShift().EndpointsIdledAt(api.URL, "token", "foo-jenkins", "jenkins")
+	require.NoError(t, err)
+	assert.True(t, idledAt.IsZero(), "Endpoints without annotation should not be idled")
+}
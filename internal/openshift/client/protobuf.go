@@ -0,0 +1,140 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// contentTypeProtobuf is the media type of the protobuf encoding supported by the Kubernetes API for its core types.
+	contentTypeProtobuf = "application/vnd.kubernetes.protobuf"
+
+	// acceptProtobuf prefers protobuf, letting the API server fall back to JSON if it does not support protobuf
+	// for the requested resource.
+	acceptProtobuf = contentTypeProtobuf + ", application/json"
+
+	// maxProtobufFrameSize limits the size of a single watch event, protecting against corrupt streams.
+	maxProtobufFrameSize = 16 * 1024 * 1024
+)
+
+// protobufMagic prefixes each protobuf encoded object of the Kubernetes API.
+var protobufMagic = []byte{0x6b, 0x38, 0x73, 0x00}
+
+// protoUnmarshaler is implemented by the generated protobuf types of the Kubernetes API.
+type protoUnmarshaler interface {
+	Unmarshal(data []byte) error
+}
+
+// negotiate asks the API server to answer the given request using protobuf if it can.
+func negotiate(req *http.Request) {
+	req.Header.Set("Accept", acceptProtobuf)
+}
+
+// isProtobuf returns whether the API server answered using protobuf.
+func isProtobuf(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), contentTypeProtobuf)
+}
+
+// decode decodes the body of the given response into obj, using the content type chosen by the API server.
+func decode(resp *http.Response, obj protoUnmarshaler) error {
+	if !isProtobuf(resp) {
+		return json.NewDecoder(resp.Body).Decode(obj)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return unmarshalProtobuf(data, obj)
+}
+
+// unmarshalProtobuf unwraps the runtime.Unknown envelope of a protobuf encoded object and decodes it into obj.
+func unmarshalProtobuf(data []byte, obj protoUnmarshaler) error {
+	if !bytes.HasPrefix(data, protobufMagic) {
+		return fmt.Errorf("protobuf object does not start with the expected prefix")
+	}
+
+	unknown := runtime.Unknown{}
+	if err := unknown.Unmarshal(data[len(protobufMagic):]); err != nil {
+		return err
+	}
+	return obj.Unmarshal(unknown.Raw)
+}
+
+// podEventReader reads the pod events of a watch stream, either newline delimited JSON or length
+// prefixed protobuf frames.
+type podEventReader struct {
+	reader   *bufio.Reader
+	protobuf bool
+}
+
+func newPodEventReader(resp *http.Response) *podEventReader {
+	return &podEventReader{
+		reader:   bufio.NewReader(resp.Body),
+		protobuf: isProtobuf(resp),
+	}
+}
+
+// next returns the next event of the stream. It returns io.EOF once the stream ends.
+func (r *podEventReader) next() (podEvent, error) {
+	if r.protobuf {
+		return r.nextProtobuf()
+	}
+
+	e := podEvent{}
+	line, err := r.reader.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+		return e, err
+	}
+	if err := json.Unmarshal(line, &e); err != nil {
+		if isErrorEvent(line) {
+			return podEvent{Type: eventError}, nil
+		}
+		if strings.HasPrefix(string(line), "This request caused apisever to panic") {
+			return e, fmt.Errorf("communication with server failed: %s", line)
+		}
+		return e, err
+	}
+	return e, nil
+}
+
+func (r *podEventReader) nextProtobuf() (podEvent, error) {
+	e := podEvent{}
+	var size uint32
+	if err := binary.Read(r.reader, binary.BigEndian, &size); err != nil {
+		return e, err
+	}
+	if size > maxProtobufFrameSize {
+		return e, fmt.Errorf("watch event of %d bytes exceeds the maximum size", size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r.reader, frame); err != nil {
+		return e, err
+	}
+
+	// Watch events are framed without envelope, the objects they carry are enveloped.
+	event := metav1.WatchEvent{}
+	if err := event.Unmarshal(frame); err != nil {
+		return e, err
+	}
+	if event.Type == eventError {
+		return podEvent{Type: eventError}, nil
+	}
+	pod := v1.Pod{}
+	if err := unmarshalProtobuf(event.Object.Raw, &pod); err != nil {
+		return e, err
+	}
+	return podEvent{Type: event.Type, Object: pod}, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type protoMarshaler interface {
+	Marshal() ([]byte, error)
+}
+
+func envelope(t *testing.T, obj protoMarshaler) []byte {
+	raw, err := obj.Marshal()
+	require.NoError(t, err)
+	data, err := (&runtime.Unknown{Raw: raw}).Marshal()
+	require.NoError(t, err)
+	return append(append([]byte{}, protobufMagic...), data...)
+}
+
+func response(contentType string, body []byte) *http.Response {
+	return &http.Response{
+		Header: http.Header{"Content-Type": []string{contentType}},
+		Body:   ioutil.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func Test_decode_protobuf(t *testing.T) {
+	pods := &v1.PodList{Items: []v1.Pod{waitingPod("CrashLoopBackOff")}}
+	pods.Items[0].Name = "jenkins-1-abcde"
+
+	decoded := &v1.PodList{}
+	err := decode(response(contentTypeProtobuf, envelope(t, pods)), decoded)
+	require.NoError(t, err)
+	assert.Equal(t, "jenkins-1-abcde", decoded.Items[0].Name)
+	assert.Equal(t, "CrashLoopBackOff", decoded.Items[0].Status.ContainerStatuses[0].State.Waiting.Reason)
+
+	err = decode(response(contentTypeProtobuf, []byte("{}")), decoded)
+	assert.Error(t, err, "protobuf responses without prefix should be rejected")
+}
+
+func Test_decode_falls_back_to_json(t *testing.T) {
+	decoded := &v1.PodList{}
+	err := decode(response("application/json", []byte(`{"items": [{"metadata": {"name": "jenkins-1-abcde"}}]}`)), decoded)
+	require.NoError(t, err)
+	assert.Equal(t, "jenkins-1-abcde", decoded.Items[0].Name)
+}
+
+func Test_pod_event_reader(t *testing.T) {
+	pod := v1.Pod{}
+	pod.Name = "jenkins-1-abcde"
+
+	var stream bytes.Buffer
+	for _, eventType := range []string{"ADDED", "MODIFIED"} {
+		event := &metav1.WatchEvent{Type: eventType, Object: runtime.RawExtension{Raw: envelope(t, &pod)}}
+		frame, err := event.Marshal()
+		require.NoError(t, err)
+		binary.Write(&stream, binary.BigEndian, uint32(len(frame)))
+		stream.Write(frame)
+	}
+
+	events := newPodEventReader(response(contentTypeProtobuf, stream.Bytes()))
+	for _, eventType := range []string{"ADDED", "MODIFIED"} {
+		e, err := events.next()
+		require.NoError(t, err)
+		assert.Equal(t, eventType, e.Type)
+		assert.Equal(t, "jenkins-1-abcde", e.Object.Name)
+	}
+	_, err := events.next()
+	assert.Equal(t, io.EOF, err)
+
+	events = newPodEventReader(response("application/json", []byte(`{"type": "DELETED", "object": {"metadata": {"name": "jenkins-1-abcde"}}}`+"\n")))
+	e, err := events.next()
+	require.NoError(t, err)
+	assert.Equal(t, "DELETED", e.Type)
+	assert.Equal(t, "jenkins-1-abcde", e.Object.Name)
+	_, err = events.next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+)
+
+// maxQuarantinedBytes is the maximum size of the payload of a malformed watch event which gets logged.
+const maxQuarantinedBytes = 4096
+
+// Reasons for rejecting a watch event. They are used as metric labels, so they must not carry any values.
+var (
+	errMalformedObject = errors.New("malformed object")
+	errUnknownType     = errors.New("unknown event type")
+	errUnknownVersion  = errors.New("unsupported api version")
+	errMissingName     = errors.New("missing name")
+	errMissingNs       = errors.New("missing namespace")
+	errUnknownPhase    = errors.New("unknown build phase")
+	errNegativeCount   = errors.New("negative replica count")
+)
+
+// objectEventTypes are the types of the watch events carrying a watched object.
+var objectEventTypes = map[string]bool{"ADDED": true, "MODIFIED": true, "DELETED": true}
+
+// quarantineLogger logs the malformed watch events, so that they can be inspected without being handled.
+var quarantineLogger = logrus.WithField("component", "quarantine")
+
+// Recorder to capture the malformed watch events
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// validateBuildEvent returns why the given build event cannot be handled, nil if it is well-formed. Without it,
+// events decoding into zero-valued builds would be taken for completed builds of unknown users.
+func validateBuildEvent(e model.Object) error {
+	if err := validateEvent(e.Type, e.Object.Metadata); err != nil {
+		return err
+	}
+	if v := e.Object.APIVersion; v != "" && v != model.LegacyBuildAPIVersion && v != model.BuildAPIVersion {
+		return errUnknownVersion
+	}
+	if _, ok := model.Phases[e.Object.Status.Phase]; !ok {
+		return errUnknownPhase
+	}
+	return nil
+}
+
+// validateDCEvent returns why the given deployment config event cannot be handled, nil if it is well-formed.
+func validateDCEvent(e model.DCObject) error {
+	if err := validateEvent(e.Type, e.Object.Metadata); err != nil {
+		return err
+	}
+	status := e.Object.Status
+	if e.Object.Spec.Replicas < 0 || status.Replicas < 0 || status.ReadyReplicas < 0 || status.UnavailableReplicas < 0 {
+		return errNegativeCount
+	}
+	return nil
+}
+
+// validateEvent validates the type and the metadata common to all watch events.
+func validateEvent(eventType string, metadata model.Metadata) error {
+	if !objectEventTypes[eventType] {
+		return errUnknownType
+	}
+	if metadata.Name == "" {
+		return errMissingName
+	}
+	if metadata.Namespace == "" {
+		return errMissingNs
+	}
+	return nil
+}
+
+// quarantine logs the payload of a malformed watch event of the given resource and counts it, instead of handling it.
+func quarantine(apiURL, resource string, line []byte, reason error) {
+	Recorder.RecordRejectedEvent(apiURL, resource, reason.Error())
+
+	payload := line
+	if len(payload) > maxQuarantinedBytes {
+		payload = payload[:maxQuarantinedBytes]
+	}
+	quarantineLogger.WithFields(logrus.Fields{
+		"cluster":  apiURL,
+		"resource": resource,
+		"reason":   reason.Error(),
+		"payload":  redact.String(string(payload)),
+	}).Warn("Quarantined malformed watch event")
+}
+
+// decodeFailed handles a line of a watch stream which did not decode into the watched type. It returns true if the
+// line is valid JSON, i.e. a single malformed object which got quarantined, and false if the stream itself is broken.
+func decodeFailed(apiURL, resource string, line []byte) bool {
+	if !json.Valid(line) {
+		return false
+	}
+	quarantine(apiURL, resource, line, errMalformedObject)
+	return true
+}
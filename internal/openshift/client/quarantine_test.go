@@ -0,0 +1,81 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rejectRecorder records the reasons of the rejected watch events.
+type rejectRecorder struct {
+	metric.PrometheusRecorder
+	reasons []string
+}
+
+func (r *rejectRecorder) RecordRejectedEvent(cluster, resource, reason string) {
+	r.reasons = append(r.reasons, resource+": "+reason)
+}
+
+func Test_validate_build_event(t *testing.T) {
+	tests := []struct {
+		line string
+		err  error
+	}{
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "foo-1", "namespace": "foo"}, "status": {"phase": "Complete"}}}`, nil},
+		{`{"type": "ADDED", "object": {"metadata": {"name": "foo-2", "namespace": "foo"}, "status": {"phase": "Error"}}}`, nil},
+		{`{"type": "MODIFIED", "object": {}}`, errMissingName},
+		{`{"type": "MODIFIED"}`, errMissingName},
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "foo-1"}, "status": {"phase": "Complete"}}}`, errMissingNs},
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "foo-1", "namespace": "foo"}}}`, errUnknownPhase},
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "foo-1", "namespace": "foo"}, "status": {"phase": "Exploded"}}}`, errUnknownPhase},
+		{`{"object": {"metadata": {"name": "foo-1", "namespace": "foo"}, "status": {"phase": "Complete"}}}`, errUnknownType},
+		{`{"type": "MODIFIED", "object": {"apiVersion": "build.openshift.io/v1", "metadata": {"name": "foo-1", "namespace": "foo"}, "status": {"conditions": [{"type": "Running", "status": "True"}]}}}`, nil},
+		{`{"type": "MODIFIED", "object": {"apiVersion": "build.openshift.io/v2", "metadata": {"name": "foo-1", "namespace": "foo"}, "status": {"phase": "Complete"}}}`, errUnknownVersion},
+	}
+
+	for _, test := range tests {
+		o := model.Object{}
+		require.NoError(t, json.Unmarshal([]byte(test.line), &o))
+		assert.Equal(t, test.err, validateBuildEvent(o), test.line)
+	}
+}
+
+func Test_validate_dc_event(t *testing.T) {
+	tests := []struct {
+		line string
+		err  error
+	}{
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "jenkins", "namespace": "foo-jenkins"}, "status": {"replicas": 1, "readyReplicas": 1}}}`, nil},
+		{`{"type": "DELETED", "object": {"metadata": {"namespace": "foo-jenkins"}}}`, errMissingName},
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "jenkins"}}}`, errMissingNs},
+		{`{"type": "MODIFIED", "object": {"metadata": {"name": "jenkins", "namespace": "foo-jenkins"}, "spec": {"replicas": -1}}}`, errNegativeCount},
+	}
+
+	for _, test := range tests {
+		o := model.DCObject{}
+		require.NoError(t, json.Unmarshal([]byte(test.line), &o))
+		assert.Equal(t, test.err, validateDCEvent(o), test.line)
+	}
+}
+
+func Test_decode_failure_quarantines_malformed_objects(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(os.Stderr)
+	recorder := &rejectRecorder{}
+	Recorder = recorder
+	defer func() { Recorder = metric.PrometheusRecorder{} }()
+
+	line := []byte(`{"type": "MODIFIED", "object": {"metadata": {"name": 42}}}`)
+	require.Error(t, json.Unmarshal(line, &model.Object{}))
+	assert.True(t, decodeFailed("https://api.example.com/", "builds", line), "a malformed object should be skipped")
+	assert.False(t, decodeFailed("https://api.example.com/", "builds", []byte(`This request caused apisever to panic`)),
+		"a broken stream should be restarted")
+	assert.Equal(t, []string{"builds: malformed object"}, recorder.reasons)
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+)
+
+// Selector restricts the objects returned by the OpenShift API using a label and a field selector.
+// Empty selectors do not restrict anything.
+type Selector struct {
+	Label string
+	Field string
+}
+
+// WatchSelectors holds the selectors applied to the watches of the different object kinds, so that
+// the API server filters irrelevant events instead of the Idler decoding them.
+type WatchSelectors struct {
+	Builds            Selector
+	DeploymentConfigs Selector
+	Pods              Selector
+}
+
+// DefaultWatchSelectors restricts deployment config and pod watches to Jenkins and does not restrict builds.
+var DefaultWatchSelectors = WatchSelectors{
+	DeploymentConfigs: Selector{Label: "app=jenkins"},
+	Pods:              Selector{Label: "deploymentconfig=jenkins"},
+}
+
+// apply adds the selector to the query of the request.
+func (s Selector) apply(req *http.Request) {
+	v := req.URL.Query()
+	if s.Label != "" {
+		v.Set("labelSelector", s.Label)
+	}
+	if s.Field != "" {
+		v.Set("fieldSelector", s.Field)
+	}
+	req.URL.RawQuery = v.Encode()
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"k8s.io/api/core/v1"
+)
+
+// podMetricsList is the subset of a metrics.k8s.io/v1beta1 PodMetricsList used to sum up the usage of a namespace.
+type podMetricsList struct {
+	Items []struct {
+		Timestamp  time.Time `json:"timestamp"`
+		Containers []struct {
+			Usage v1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// PodUsage returns the CPU and memory the pods in the given namespace currently consume, as reported by the
+// metrics-server of the cluster.
+func (o *openShift) PodUsage(apiURL string, bearerToken string, namespace string) (model.Usage, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", strings.TrimSuffix(apiURL, "/"), namespace), nil)
+	if err != nil {
+		return model.Usage{}, err
+	}
+	authorize(req, apiURL, bearerToken)
+
+	resp, err := o.do(req)
+	if err != nil {
+		return model.Usage{}, err
+	}
+	defer bodyClose(resp)
+
+	metrics := podMetricsList{}
+	if err := json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		return model.Usage{}, err
+	}
+
+	var usage model.Usage
+	for _, pod := range metrics.Items {
+		for _, c := range pod.Containers {
+			usage.CPU += c.Usage.Cpu().AsApproximateFloat64()
+			usage.Memory += c.Usage.Memory().Value()
+		}
+		if pod.Timestamp.After(usage.Time) {
+			usage.Time = pod.Timestamp
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pod_usage(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/apis/metrics.k8s.io/v1beta1/namespaces/foo-jenkins/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"items": [
+			{"timestamp": "2018-06-01T12:00:00Z", "containers": [{"usage": {"cpu": "250m", "memory": "512Mi"}}]},
+			{"timestamp": "2018-06-01T12:00:30Z", "containers": [{"usage": {"cpu": "1500000n", "memory": "64Mi"}}]}
+		]}`)
+	}))
+	defer api.Close()
+
+	o := NewOpenShift()
+
+	usage, err := o.PodUsage(api.URL, "token", "foo-jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, model.Usage{
+		CPU:    0.2515,
+		Memory: 576 * 1024 * 1024,
+		Time:   time.Date(2018, 6, 1, 12, 0, 30, 0, time.UTC),
+	}, usage)
+
+	_, err = o.PodUsage(api.URL, "token", "bar-jenkins")
+	assert.Error(t, err, "A missing metrics API should be reported as error")
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// eventBookmark is the type of the watch events only carrying the current resourceVersion.
+	eventBookmark = "BOOKMARK"
+
+	// eventError is the type of the watch events reporting a failed watch, usually since the requested
+	// resourceVersion is too old.
+	eventError = "ERROR"
+
+	// maxWatchFailures is the number of consecutive failed requests after which a watch gives up, so that it gets
+	// restarted with backoff instead of retrying in a tight loop.
+	maxWatchFailures = 10
+)
+
+// watchPosition tracks the resourceVersion a watch has seen last, so that the watch can resume from it after a
+// disconnect instead of receiving all existing objects again.
+type watchPosition struct {
+	resourceVersion string
+}
+
+// apply makes the watch request resume from the tracked resourceVersion and asks for bookmark events.
+func (p *watchPosition) apply(req *http.Request) {
+	v := req.URL.Query()
+	if p.resourceVersion != "" {
+		v.Set("resourceVersion", p.resourceVersion)
+	}
+	v.Set("allowWatchBookmarks", "true")
+	req.URL.RawQuery = v.Encode()
+}
+
+// observe records the resourceVersion of a watch event and returns whether the event needs to be handled.
+// Bookmark events only move the position. Error events reset it, so that the watch starts over from the
+// current state.
+func (p *watchPosition) observe(eventType string, resourceVersion string) bool {
+	switch eventType {
+	case eventBookmark:
+		p.resourceVersion = resourceVersion
+		return false
+	case eventError:
+		p.resourceVersion = ""
+		return false
+	}
+
+	if resourceVersion != "" {
+		p.resourceVersion = resourceVersion
+	}
+	return true
+}
+
+// isErrorEvent returns whether the given line of a JSON watch stream is an error event. The object of an error
+// event is a Status, which does not decode into the watched type.
+func isErrorEvent(line []byte) bool {
+	e := struct {
+		Type string `json:"type"`
+	}{}
+	return json.Unmarshal(line, &e) == nil && e.Type == eventError
+}
+
+// watchFailures counts the consecutive failed requests of a watch.
+type watchFailures struct {
+	count int
+}
+
+// failed records a failed watch request, given either the error of the request or the status of the response, and
+// returns an error once the watch should give up: right away if the token got rejected, otherwise after
+// maxWatchFailures consecutive failures.
+func (f *watchFailures) failed(resource string, status int, err error) error {
+	if err == nil {
+		err = fmt.Errorf("got status %d", status)
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return fmt.Errorf("token rejected watching %s: %s", resource, err)
+	}
+
+	f.count++
+	if f.count >= maxWatchFailures {
+		return fmt.Errorf("%d consecutive requests failed watching %s, the last one with: %s", f.count, resource, err)
+	}
+	return nil
+}
+
+// succeeded resets the count of consecutive failed requests.
+func (f *watchFailures) succeeded() {
+	f.count = 0
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_watch_position(t *testing.T) {
+	position := &watchPosition{}
+	req, _ := http.NewRequest("GET", "https://api.example.com/oapi/v1/builds?watch=true", nil)
+	position.apply(req)
+	assert.Equal(t, "", req.URL.Query().Get("resourceVersion"), "a new watch should start from the current state")
+	assert.Equal(t, "true", req.URL.Query().Get("allowWatchBookmarks"))
+
+	assert.True(t, position.observe("ADDED", "10"))
+	assert.False(t, position.observe(eventBookmark, "12"), "bookmarks should not be handled")
+	req, _ = http.NewRequest("GET", "https://api.example.com/oapi/v1/builds?watch=true", nil)
+	position.apply(req)
+	assert.Equal(t, "12", req.URL.Query().Get("resourceVersion"), "a watch should resume from the last seen resourceVersion")
+
+	assert.False(t, position.observe(eventError, ""))
+	assert.Equal(t, "", position.resourceVersion, "an expired watch should start over")
+}
+
+func Test_is_error_event(t *testing.T) {
+	assert.True(t, isErrorEvent([]byte(`{"type": "ERROR", "object": {"kind": "Status", "status": "Failure", "code": 410}}`)))
+	assert.False(t, isErrorEvent([]byte(`{"type": "MODIFIED", "object": {"metadata": {"resourceVersion": "10"}}}`)))
+	assert.False(t, isErrorEvent([]byte(`This request caused apisever to panic`)))
+}
+
+func Test_watch_failures(t *testing.T) {
+	failures := &watchFailures{}
+	assert.Error(t, failures.failed("builds", http.StatusUnauthorized, nil), "a rejected token should end the watch right away")
+
+	for i := 1; i < maxWatchFailures; i++ {
+		assert.NoError(t, failures.failed("builds", 0, errors.New("connection refused")))
+	}
+	failures.succeeded()
+	assert.NoError(t, failures.failed("builds", http.StatusInternalServerError, nil), "a successful request should reset the count")
+
+	for i := 2; i < maxWatchFailures; i++ {
+		assert.NoError(t, failures.failed("builds", http.StatusInternalServerError, nil))
+	}
+	assert.EqualError(t, failures.failed("builds", http.StatusInternalServerError, nil),
+		"10 consecutive requests failed watching builds, the last one with: got status 500")
+}
+
+func Test_watch_stops_once_context_is_done(t *testing.T) {
+	streaming := make(chan struct{}, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// an event of a namespace which is filtered, followed by silence
+		fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"name": "jenkins", "namespace": "foo", "resourceVersion": "10"}}}`)
+		w.(http.Flusher).Flush()
+		streaming <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewOpenShift().WatchDeploymentConfigs(ctx, api.URL, "token", "-jenkins", func(model.DCObject) error {
+			return errors.New("no event should be handled")
+		})
+	}()
+
+	<-streaming
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch should have stopped without further events")
+	}
+}
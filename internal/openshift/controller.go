@@ -9,11 +9,17 @@ import (
 	"context"
 	"sync"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,33 +27,50 @@ import (
 type Status bool
 
 const (
-	availableCond          = "Available"
-	channelSendTimeout     = 1
-	jenkinsNamespaceSuffix = "-jenkins"
+	availableCond      = "Available"
+	channelSendTimeout = 1
+	jenkinsServiceName = "jenkins"
 )
 
 var logger = logrus.WithFields(logrus.Fields{"component": "controller"})
 
+// Recorder to capture the idle durations of the Jenkins instances
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// auditLogger logs changes to Jenkins instances which were not made by the idler.
+var auditLogger = logrus.WithFields(logrus.Fields{"component": "audit"})
+
 // Controller defines the interface for watching the openShift cluster for changes.
 type Controller interface {
 	HandleBuild(o model.Object) error
 	HandleDeploymentConfig(dc model.DCObject) error
+	HandlePod(pod model.PodObject) error
+	HandleIdlerConfig(cfg model.IdlerConfigObject) error
+	Reconcile(dc model.DeploymentConfig) error
+	WarmUp(namespace string) error
 }
 
 // controllerImpl watches a single OpenShift cluster for Build and Deployment Config changes. This struct needs to be
 // safe for concurrent use.
 type controllerImpl struct {
-	openshiftURL  string
-	osBearerToken string
-	userIdlers    *UserIdlerMap
-	tenantService tenant.Service
-	features      toggles.Features
-	config        configuration.Configuration
-	wg            *sync.WaitGroup
-	ctx           context.Context
-	cancel        context.CancelFunc
-	unknownUsers  *UnknownUsersMap
-	disabledUsers *model.StringSet
+	openshiftURL     string
+	osBearerToken    string
+	userIdlers       *UserIdlerMap
+	tenantService    tenant.Service
+	features         toggles.Features
+	config           configuration.Configuration
+	wg               *sync.WaitGroup
+	ctx              context.Context
+	cancel           context.CancelFunc
+	unknownUsers     *UnknownUsersMap
+	disabledUsers    *model.StringSet
+	disabledClusters *model.StringSet
+	throttle         *idler.Throttle
+	seenEvents       *SeenEventsMap
+	namespaces       *namespace.Filter
+	activePhases     map[string]bool
+	openShiftClient  client.OpenShiftClient
+	clock            clock.Clock
 }
 
 // NewController creates an instance of controllerImpl.
@@ -60,22 +83,34 @@ func NewController(
 	config configuration.Configuration,
 	wg *sync.WaitGroup,
 	cancel context.CancelFunc,
-	disabledUsers *model.StringSet) Controller {
+	disabledUsers *model.StringSet,
+	disabledClusters *model.StringSet,
+	clock clock.Clock) Controller {
 
 	logger.WithField("cluster", openshiftURL).Info("Creating new controller instance")
 
 	controller := controllerImpl{
-		openshiftURL:  openshiftURL,
-		osBearerToken: osBearerToken,
-		userIdlers:    userIdlers,
-		tenantService: t,
-		features:      features,
-		config:        config,
-		wg:            wg,
-		ctx:           ctx,
-		cancel:        cancel,
-		unknownUsers:  NewUnknownUsersMap(),
-		disabledUsers: disabledUsers,
+		openshiftURL:     openshiftURL,
+		osBearerToken:    osBearerToken,
+		userIdlers:       userIdlers,
+		tenantService:    t,
+		features:         features,
+		config:           config,
+		wg:               wg,
+		ctx:              ctx,
+		cancel:           cancel,
+		unknownUsers:     NewUnknownUsersMap(),
+		disabledUsers:    disabledUsers,
+		disabledClusters: disabledClusters,
+		throttle:         idler.NewThrottle(openshiftURL, config.GetMaxIdlesPerMinute(), clock),
+		seenEvents:       NewSeenEventsMap(),
+		namespaces:       namespace.NewFilter(config.GetNamespaceAllowlist(), config.GetNamespaceDenylist()),
+		activePhases:     make(map[string]bool),
+		openShiftClient:  client.NewOpenShift(),
+		clock:            clock,
+	}
+	for _, phase := range config.GetActiveBuildPhases() {
+		controller.activePhases[phase] = true
 	}
 
 	return &controller
@@ -86,19 +121,29 @@ func NewController(
 // build object is stage timestamp, which we don't care about, so this function
 // just does couple comparisons and returns.
 func (c *controllerImpl) HandleBuild(o model.Object) error {
+	defer c.recordEvent("builds", c.clock.Now())
+
 	ns := o.Object.Metadata.Namespace
 	log := logger.WithFields(logrus.Fields{
-		"ns":        ns,
+		"namespace": ns,
 		"event":     "build",
-		"openshift": c.openshiftURL,
+		"cluster":   c.openshiftURL,
 	})
+	if c.seenEvents.Duplicate(o.Type, "builds", o.Object.Metadata) {
+		log.Debug("Skipping duplicate Build event")
+		c.ignoreEvent("builds", "duplicate")
+		return nil
+	}
+
 	ok, err := c.createIfNotExist(ns)
 	if err != nil {
 		log.Errorf("Creating user-idler record failed: %s", err)
+		c.seenEvents.Forget("builds", o.Object.Metadata)
 		return err
 	}
 
 	if !ok {
+		c.ignoreEvent("builds", "unmanaged")
 		return nil
 	}
 
@@ -112,6 +157,7 @@ func (c *controllerImpl) HandleBuild(o model.Object) error {
 
 	if c.disabledUsers.Has(user.Name) {
 		log.Infof("Status disabled for user: %s", user.Name)
+		c.ignoreEvent("builds", "disabled")
 		return nil
 	}
 	evalConditions := false
@@ -120,7 +166,8 @@ func (c *controllerImpl) HandleBuild(o model.Object) error {
 
 		lastActive := user.ActiveBuild
 		if lastActive.Status.Phase != o.Object.Status.Phase ||
-			lastActive.Metadata.Name != o.Object.Metadata.Name {
+			lastActive.Metadata.Name != o.Object.Metadata.Name ||
+			stageChanged(lastActive, o.Object) {
 
 			user.ActiveBuild = o.Object
 			evalConditions = true
@@ -130,7 +177,8 @@ func (c *controllerImpl) HandleBuild(o model.Object) error {
 
 		lastDone := user.DoneBuild
 		if lastDone.Status.Phase != o.Object.Status.Phase ||
-			lastDone.Metadata.Name != o.Object.Metadata.Name {
+			lastDone.Metadata.Name != o.Object.Metadata.Name ||
+			stageChanged(lastDone, o.Object) {
 
 			user.DoneBuild = o.Object
 			evalConditions = true
@@ -150,42 +198,77 @@ func (c *controllerImpl) HandleBuild(o model.Object) error {
 
 	if evalConditions {
 		log.Infof("Sending user %q to user-idler for evaluating conditions", user.Name)
-		sendUserToIdler(userIdler, user)
+		c.sendUserToIdler(userIdler, user)
+	} else {
+		c.ignoreEvent("builds", "unchanged")
 	}
 
 	return nil
 }
 
+// stageChanged returns whether the running pipeline stage of the build differs from the one last seen, e.g. once a
+// pipeline starts waiting for an approval.
+func stageChanged(last model.Build, build model.Build) bool {
+	lastStage, _ := last.RunningStage()
+	stage, _ := build.RunningStage()
+	return lastStage != stage
+}
+
+// recordEvent records an event of the given resource, received at the given time, once it got processed.
+func (c *controllerImpl) recordEvent(resource string, received time.Time) {
+	Recorder.RecordControllerEvent(c.openshiftURL, resource, c.clock.Since(received).Seconds())
+}
+
+// ignoreEvent counts an event of the given resource which got ignored for the given reason.
+func (c *controllerImpl) ignoreEvent(resource, reason string) {
+	Recorder.RecordIgnoredEvent(c.openshiftURL, resource, reason)
+}
+
 // HandleDeploymentConfig processes new DC event collected from openShift and updates
 // user structure with info about the changes in DC. NOTE: This is important for cases
 // like reset tenantService and update tenantService when DC is updated and Jenkins starts because
 // of ConfigChange or manual intervention.
 func (c *controllerImpl) HandleDeploymentConfig(dc model.DCObject) error {
-	ns := dc.Object.Metadata.Namespace[:len(dc.Object.Metadata.Namespace)-len(jenkinsNamespaceSuffix)]
+	defer c.recordEvent("deploymentconfigs", c.clock.Now())
+
+	ns, ok := namespace.User(dc.Object.Metadata.Namespace)
+	if !ok {
+		c.ignoreEvent("deploymentconfigs", "foreign_namespace")
+		return fmt.Errorf("namespace %s is not a Jenkins namespace", dc.Object.Metadata.Namespace)
+	}
 
 	log := logger.WithFields(logrus.Fields{
 		"event":     "dc",
-		"openshift": c.openshiftURL,
-		"ns":        ns,
+		"cluster":   c.openshiftURL,
+		"namespace": ns,
 	})
+	if c.seenEvents.Duplicate(dc.Type, "deploymentconfigs", dc.Object.Metadata) {
+		log.Debug("Skipping duplicate DC event")
+		c.ignoreEvent("deploymentconfigs", "duplicate")
+		return nil
+	}
 
 	ok, err := c.createIfNotExist(ns)
 	if err != nil {
 		log.Errorf("Creating user-idler record failed: %s", err)
+		c.seenEvents.Forget("deploymentconfigs", dc.Object.Metadata)
 		return err
 	}
 
 	if !ok {
+		c.ignoreEvent("deploymentconfigs", "unmanaged")
 		return nil
 	}
 
 	// ensure user-idler is created for user so that pod would be
 	// idled/unidled even if there aren't any build events
 	userIdler := c.userIdlerForNamespace(ns)
+	userIdler.SetResources(dc.Object.Resources())
 	user := userIdler.GetUser()
 
 	if c.disabledUsers.Has(user.Name) {
 		log.Infof("Status disabled for user: %s", user.Name)
+		c.ignoreEvent("deploymentconfigs", "disabled")
 		return nil
 	}
 
@@ -198,6 +281,7 @@ func (c *controllerImpl) HandleDeploymentConfig(dc model.DCObject) error {
 	if err != nil {
 		// stop processing since the pod isn't available yet
 		log.Errorf("available condition not present in the list of conditions - %s", err)
+		c.ignoreEvent("deploymentconfigs", "unavailable")
 		return nil
 	}
 
@@ -218,42 +302,306 @@ func (c *controllerImpl) HandleDeploymentConfig(dc model.DCObject) error {
 		return err
 	}
 
+	c.trackManualUnIdle(&user, dc.Object)
+	c.trackIdleDuration(&user, dc.Object)
+	c.applyAnnotations(&user, dc.Object, log)
+
 	if available {
 		log.Infof("setting user jenkins-last-update to %v based on available condition", availability.LastUpdateTime)
 		user.JenkinsLastUpdate = availability.LastUpdateTime
 	}
 
 	log.Infof("evaluate conditions for %q due to dc event", user.Name)
-	sendUserToIdler(userIdler, user)
+	c.sendUserToIdler(userIdler, user)
+	return nil
+}
+
+// HandlePod processes new Pod event collected from openShift and updates the user structure with the phase,
+// restarts and failures of the Jenkins pod. The user is only sent to the user-idler if any of those changed.
+func (c *controllerImpl) HandlePod(pod model.PodObject) error {
+	ns, ok := namespace.User(pod.Object.Namespace)
+	if !ok {
+		return fmt.Errorf("namespace %s is not a Jenkins namespace", pod.Object.Namespace)
+	}
+
+	log := logger.WithFields(logrus.Fields{
+		"event":     "pod",
+		"cluster":   c.openshiftURL,
+		"namespace": ns,
+		"pod":       pod.Object.Name,
+	})
+
+	ok, err := c.createIfNotExist(ns)
+	if err != nil {
+		log.Errorf("Creating user-idler record failed: %s", err)
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	userIdler := c.userIdlerForNamespace(ns)
+	user := userIdler.GetUser()
+
+	if c.disabledUsers.Has(user.Name) {
+		log.Infof("Status disabled for user: %s", user.Name)
+		return nil
+	}
+
+	observed := pod.Object
+	if pod.Type == "DELETED" {
+		if user.Pod.Name != observed.Name {
+			// an old pod of a previous deployment went away
+			return nil
+		}
+		observed = model.Pod{}
+	}
+
+	if user.Pod == observed {
+		return nil
+	}
+
+	if observed.Restarts.OOMKilled && !user.Pod.Restarts.OOMKilled {
+		log.Warnf("Jenkins of %s got killed for running out of memory", user.Name)
+	}
+	if observed.Restarts.OOMKilled {
+		resources, _ := userIdler.Resources()
+		bump, err := c.features.IsOOMLimitBumpEnabled(toggles.Target{
+			UserID:     user.ID,
+			Namespace:  pod.Object.Namespace,
+			ClusterURL: c.openshiftURL,
+		})
+		if err != nil {
+			log.Warnf("Unable to determine whether to raise the memory limit of Jenkins of %s: %s", user.Name, err)
+		}
+		remediation.DefaultAdvisor.OOMKilled(c.openShiftClient, c.openshiftURL, c.osBearerToken, jenkinsServiceName, observed, resources.MemoryLimit, bump)
+	}
+
+	user.Pod = observed
+	log.Infof("evaluate conditions for %q due to pod event", user.Name)
+	c.sendUserToIdler(userIdler, user)
 	return nil
 }
 
-// createIfNotExist checks existence of a user in the map, initialise if it does not exist.
+// HandleIdlerConfig processes new JenkinsIdlerConfig event collected from openShift and applies the overrides it
+// specifies to the user structure. Deleting the config reverts to the defaults. Invalid configs are ignored, keeping
+// the overrides previously applied.
+func (c *controllerImpl) HandleIdlerConfig(cfg model.IdlerConfigObject) error {
+	defer c.recordEvent(model.IdlerConfigResource, c.clock.Now())
+
+	meta := cfg.Object.Metadata
+	ns, ok := namespace.User(meta.Namespace)
+	if !ok {
+		return fmt.Errorf("namespace %s is not a Jenkins namespace", meta.Namespace)
+	}
+
+	log := logger.WithFields(logrus.Fields{
+		"event":     "idlerconfig",
+		"cluster":   c.openshiftURL,
+		"namespace": ns,
+		"name":      meta.Name,
+	})
+
+	if meta.Name != model.IdlerConfigName {
+		log.Debugf("Ignoring idler config not named %s", model.IdlerConfigName)
+		c.ignoreEvent(model.IdlerConfigResource, "unnamed")
+		return nil
+	}
+
+	if c.seenEvents.Duplicate(cfg.Type, model.IdlerConfigResource, meta) {
+		log.Debug("Skipping duplicate idler config event")
+		c.ignoreEvent(model.IdlerConfigResource, "duplicate")
+		return nil
+	}
+
+	ok, err := c.createIfNotExist(ns)
+	if err != nil {
+		log.Errorf("Creating user-idler record failed: %s", err)
+		c.seenEvents.Forget(model.IdlerConfigResource, meta)
+		return err
+	}
+
+	if !ok {
+		c.ignoreEvent(model.IdlerConfigResource, "unmanaged")
+		return nil
+	}
+
+	userIdler := c.userIdlerForNamespace(ns)
+	user := userIdler.GetUser()
+
+	overrides := model.Overrides{}
+	if cfg.Type != "DELETED" {
+		overrides, err = cfg.Object.Overrides()
+		if err != nil {
+			log.Warnf("Ignoring invalid idler config: %s", err)
+			c.ignoreEvent(model.IdlerConfigResource, "invalid")
+			return nil
+		}
+	}
+
+	user.Overrides = overrides
+	log.Infof("evaluate conditions for %q due to idler config event", user.Name)
+	c.sendUserToIdler(userIdler, user)
+	return nil
+}
+
+// Reconcile seeds the user-idler of the namespace of the given DC with the current state of Jenkins as found in the
+// cluster and schedules an immediate evaluation of its conditions. It is used on startup, so that Jenkins instances
+// get idled resp. un-idled without waiting for the next build or DC event.
+func (c *controllerImpl) Reconcile(dc model.DeploymentConfig) error {
+	ns, ok := namespace.User(dc.Metadata.Namespace)
+	if !ok {
+		return fmt.Errorf("namespace %s is not a Jenkins namespace", dc.Metadata.Namespace)
+	}
+
+	ok, err := c.createIfNotExist(ns)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"namespace": ns,
+			"cluster":   c.openshiftURL,
+		}).Errorf("Creating user-idler record failed: %s", err)
+		return err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	c.userIdlerForNamespace(ns).Observe(dc.PodState())
+	return c.HandleDeploymentConfig(model.DCObject{Type: "RECONCILED", Object: dc})
+}
+
+// WarmUp creates the user-idler of the given Jenkins namespace, so that its Jenkins is covered before the first event
+// concerning the namespace occurs, e.g. for namespaces lacking a Jenkins deployment config.
+func (c *controllerImpl) WarmUp(jenkinsNamespace string) error {
+	ns, ok := namespace.User(jenkinsNamespace)
+	if !ok {
+		return fmt.Errorf("namespace %s is not a Jenkins namespace", jenkinsNamespace)
+	}
+
+	_, err := c.createIfNotExist(ns)
+	return err
+}
+
+// trackManualUnIdle records when Jenkins got scaled up by something other than the idler, e.g. the user running
+// `oc scale`, so that Jenkins is kept running for a grace period. The record is cleared once Jenkins is scaled down.
+func (c *controllerImpl) trackManualUnIdle(user *model.User, dc model.DeploymentConfig) {
+	if dc.Spec.Replicas == 0 {
+		user.ManualUnIdleAt = time.Time{}
+		return
+	}
+
+	if !dc.ManuallyUnIdled() || user.ManualUnIdleAt.After(dc.IdledAt()) {
+		return
+	}
+
+	user.ManualUnIdleAt = c.clock.Now().UTC()
+	auditLogger.WithFields(logrus.Fields{
+		"id":        user.ID,
+		"name":      user.Name,
+		"namespace": dc.Metadata.Namespace,
+		"cluster":   c.openshiftURL,
+		"idled_at":  dc.Metadata.Annotations.IdledAt,
+	}).Warnf("Jenkins of %s got scaled up without the idler, not idling it for a grace period", user.Name)
+}
+
+// trackIdleDuration keeps track of since when Jenkins is idled, based on the idled-at annotation of the DC, and
+// accumulates the total time Jenkins was idled once it gets scaled up again.
+func (c *controllerImpl) trackIdleDuration(user *model.User, dc model.DeploymentConfig) {
+	if dc.Spec.Replicas == 0 {
+		if idledAt := dc.IdledAt(); !idledAt.IsZero() {
+			user.IdledAt = idledAt
+		}
+		return
+	}
+
+	if user.IdledAt.IsZero() {
+		return
+	}
+
+	idleDuration := user.IdleDuration(c.clock.Now())
+	user.TotalIdleDuration += idleDuration
+	user.IdledAt = time.Time{}
+	Recorder.RecordIdleDuration(user.Variant, idleDuration.Seconds())
+}
+
+// applyAnnotations applies the idling configuration of the idler.openshift.io annotations of the DC to the user.
+func (c *controllerImpl) applyAnnotations(user *model.User, dc model.DeploymentConfig, log *logrus.Entry) {
+	user.SkipIdling = dc.SkipIdling()
+
+	idleAfter, err := dc.IdleTimeout()
+	if err != nil {
+		log.Warnf("ignoring invalid idle timeout annotation: %s", err)
+	}
+	user.IdleAfter = idleAfter
+}
+
+// assignVariant assigns the user to the variant of the idle timeout experiment it takes part in, if any. The payload
+// of the variant is the idle timeout in minutes.
+func (c *controllerImpl) assignVariant(user *model.User, log *logrus.Entry) {
+	experiments, ok := c.features.(toggles.Experiments)
+	if !ok {
+		return
+	}
+
+	variant, ok := experiments.Variant(toggles.IdleAfterExperiment, toggles.Target{
+		UserID:     user.ID,
+		Namespace:  namespace.Jenkins(user.Name),
+		ClusterURL: c.openshiftURL,
+	})
+	if !ok {
+		return
+	}
+
+	minutes, err := strconv.Atoi(variant.Payload)
+	if err != nil || minutes <= 0 {
+		log.Warnf("ignoring variant %s of experiment %s with invalid idle timeout %s", variant.Name, toggles.IdleAfterExperiment, variant.Payload)
+		return
+	}
+	log.Infof("assigned to variant %s of experiment %s", variant.Name, toggles.IdleAfterExperiment)
+	user.Variant = variant.Name
+	user.VariantIdleAfter = time.Duration(minutes) * time.Minute
+}
+
+// createIfNotExist checks existence of a user in the map, initialise if it does not exist. If the user-idler of the
+// namespace manages it on another cluster, it is re-homed to this cluster once the tenant service confirms that the
+// namespace migrated, and the event is ignored otherwise.
 func (c *controllerImpl) createIfNotExist(ns string) (bool, error) {
 
 	log := logger.WithFields(logrus.Fields{
-		"ns":        ns,
-		"openshift": c.openshiftURL,
+		"namespace": ns,
+		"cluster":   c.openshiftURL,
 	})
 
-	if _, exist := c.userIdlers.Load(ns); exist {
+	if !c.namespaces.Allowed(ns, namespace.Jenkins(ns)) {
+		log.Debug("namespace not managed due to namespace allowlist/denylist")
+		return false, nil
+	}
+
+	previous, exist := c.userIdlers.Load(ns)
+	if exist && sameCluster(previous.OpenShiftAPI(), c.openshiftURL) {
 		log.Debug("User idler found in cache")
 		return true, nil
 	}
 
-	if _, exist := c.unknownUsers.Load(ns); exist {
+	if _, unknown := c.unknownUsers.Load(ns); unknown && !exist {
 		log.Debugf("namespace %s listed in unknown users list", ns)
 		return false, nil
 	}
 
-	log.Infof("creating user-idler for cluster %s", c.openshiftURL)
+	if !exist {
+		log.Infof("creating user-idler for cluster %s", c.openshiftURL)
+	}
 
 	ti, err := c.tenantService.GetTenantInfoByNamespace(c.openshiftURL, ns)
 	if err != nil {
+		Recorder.RecordTenantLookupFailure(c.openshiftURL)
 		return false, err
 	}
 
 	if ti.Meta.TotalCount > 1 {
+		Recorder.RecordTenantLookupFailure(c.openshiftURL)
 		return false, fmt.Errorf("could not add new user - Tenant service returned multiple items: %d", ti.Meta.TotalCount)
 	} else if len(ti.Data) == 0 {
 		log.Warnf("adding namespace: %s to unknown users list namespace", ns)
@@ -261,17 +609,42 @@ func (c *controllerImpl) createIfNotExist(ns string) (bool, error) {
 		return false, nil
 	}
 
+	if exist {
+		if !c.migrated(ti.Data[0], ns) {
+			log.WithField("managed_on", previous.OpenShiftAPI()).Debug("Ignoring event of namespace managed on another cluster")
+			return false, nil
+		}
+		log.WithField("from", previous.OpenShiftAPI()).Infof("namespace migrated, re-homing user-idler to cluster %s", c.openshiftURL)
+		previous.Stop()
+		c.userIdlers.Delete(ns)
+	}
+
 	log.Warnf("tenant info from tenant-service %v", ti)
 	user := model.NewUser(ti.Data[0].ID, ns)
+	for _, namespace := range ti.Data[0].Attributes.Namespaces {
+		user.Namespaces = append(user.Namespaces, model.Namespace{
+			Name:       namespace.Name,
+			Type:       namespace.Type,
+			ClusterURL: namespace.ClusterURL,
+		})
+	}
+
+	if exist {
+		carryOver(&user, previous.GetUser())
+	}
+	c.assignVariant(&user, log)
+	c.backfillBuilds(&user, log)
 
 	userIdler := idler.NewUserIdler(
 		user, c.openshiftURL, c.osBearerToken,
-		c.config, c.features, c.tenantService)
+		c.config, c.features, c.tenantService, c.clock)
+	userIdler.DisabledClusters = c.disabledClusters
+	userIdler.Throttle = c.throttle
 
 	c.userIdlers.Store(ns, userIdler)
 
 	userIdler.Run(c.ctx, c.wg, c.cancel,
-		time.Duration(c.config.GetCheckInterval())*time.Minute,
+		idler.CheckInterval(c.config, c.openshiftURL),
 		time.Duration(c.config.GetMaxRetriesQuietInterval())*time.Minute)
 
 	idlerCount := c.userIdlers.Len()
@@ -285,21 +658,78 @@ func (c *controllerImpl) createIfNotExist(ns string) (bool, error) {
 	return true, nil
 }
 
+// migrated returns true if the tenant service locates the Jenkins namespace of the user on the cluster of this
+// controller.
+func (c *controllerImpl) migrated(t tenant.InfoData, ns string) bool {
+	for _, n := range t.Attributes.Namespaces {
+		if n.Name == namespace.Jenkins(ns) {
+			return sameCluster(n.ClusterURL, c.openshiftURL)
+		}
+	}
+	return false
+}
+
+// carryOver copies the state of a user which does not depend on the cluster Jenkins runs on from the user-idler of
+// the cluster the namespace migrated from. The builds, the pod and the annotations are tracked anew on this cluster.
+func carryOver(user *model.User, previous model.User) {
+	user.JenkinsLastUpdate = previous.JenkinsLastUpdate
+	user.TotalIdleDuration = previous.TotalIdleDuration
+	user.IdleStatus = previous.IdleStatus
+}
+
+// sameCluster returns true if both API URLs denote the same cluster, regardless of a trailing slash.
+func sameCluster(apiURL, other string) bool {
+	return util.EnsureSuffix(apiURL, "/") == util.EnsureSuffix(other, "/")
+}
+
 func (c *controllerImpl) userIdlerForNamespace(namespace string) *idler.UserIdler {
 	idler, _ := c.userIdlers.Load(namespace)
 	return idler
 }
 
-// isActive returns true if build phase suggests a build is active, false otherwise.
+// backfillBuilds seeds the active and the last completed build of a new user with the builds currently found in its
+// namespace, so that the first idle decision does not take the user for one without any builds. Failing to list the
+// builds is logged only, the builds being tracked as their events occur anyway.
+func (c *controllerImpl) backfillBuilds(user *model.User, log *logrus.Entry) {
+	builds, err := c.openShiftClient.ListBuilds(c.openshiftURL, c.osBearerToken, user.Name)
+	if err != nil {
+		log.WithField("err", err).Warn("Unable to backfill the builds of the namespace")
+		return
+	}
+
+	for i := range builds {
+		b := builds[i]
+		if b.Spec.Strategy.Type != model.JenkinsPipelineStrategy {
+			continue
+		}
+		if c.isActive(&b) {
+			if user.ActiveBuild.Metadata.Name == "" || b.Status.StartTimestamp.Time.After(user.ActiveBuild.Status.StartTimestamp.Time) {
+				user.ActiveBuild = b
+			}
+		} else if user.DoneBuild.Metadata.Name == "" || b.Status.CompletionTimestamp.Time.After(user.DoneBuild.Status.CompletionTimestamp.Time) {
+			user.DoneBuild = b
+		}
+	}
+	if user.HasBuilds() {
+		log.WithFields(logrus.Fields{
+			"active_build": user.ActiveBuild.Metadata.Name,
+			"done_build":   user.DoneBuild.Metadata.Name,
+		}).Info("Backfilled the builds of the namespace")
+	}
+}
+
+// isActive returns true if the build is in one of the configured active phases, false otherwise.
 func (c *controllerImpl) isActive(b *model.Build) bool {
-	return model.Phases[b.Status.Phase] == 1
+	return c.activePhases[b.Status.Phase]
 }
 
-func sendUserToIdler(idler *idler.UserIdler, user model.User) {
+func (c *controllerImpl) sendUserToIdler(idler *idler.UserIdler, user model.User) {
 	select {
 	case idler.GetChannel() <- user:
-	case <-time.After(channelSendTimeout * time.Second):
-		logger.WithField("ns", user.Name).Warn(
+		Recorder.RecordChannelBacklog(user.Name, len(idler.GetChannel()))
+	case <-c.clock.After(channelSendTimeout * time.Second):
+		logger.WithField("namespace", user.Name).Warn(
 			"Unable to send user to channel. Discarding event.")
+		Recorder.RecordChannelDiscard(c.openshiftURL)
 	}
 }
@@ -9,34 +9,55 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http/httptest"
+	"os"
 	"sync"
+	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/remediation"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/tenant"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/toggles"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 var (
 	tenantService    *httptest.Server
 	openShiftService *httptest.Server
 	controller       Controller
+	userIdlerGroup   *sync.WaitGroup
 	origWriter       io.Writer
 	testUserID       = "2e15e957-0366-4802-bf1e-0d6fe3f11bb6"
 )
 
 type mockFeatureToggle struct {
+	oomLimitBump bool
 }
 
-func (m *mockFeatureToggle) IsIdlerEnabled(uid string) (bool, error) {
-	if uid == testUserID {
+func (m *mockFeatureToggle) IsIdlerEnabled(target toggles.Target) (bool, error) {
+	if target.UserID == testUserID {
 		return true, nil
 	}
 
 	return false, nil
 }
 
+func (m *mockFeatureToggle) IsCheIdlerEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
+func (m *mockFeatureToggle) IsOOMLimitBumpEnabled(target toggles.Target) (bool, error) {
+	return m.oomLimitBump, nil
+}
+
 func Test_handle_build(t *testing.T) {
 	setUp(t)
 	defer tearDown()
@@ -54,6 +75,40 @@ func Test_handle_build(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_active_build_phases(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	build := func(phase string) *model.Build {
+		return &model.Build{Status: model.Status{Phase: phase}}
+	}
+	ci := controller.(*controllerImpl)
+	for _, phase := range []string{"New", "Pending", "Running"} {
+		assert.True(t, ci.isActive(build(phase)), "%s builds should be active by default", phase)
+	}
+	for _, phase := range []string{"Complete", "Failed", "Cancelled", "Error", "Timeout"} {
+		assert.False(t, ci.isActive(build(phase)), "%s builds should be inactive by default", phase)
+	}
+
+	ci = NewController(context.Background(), "", "", NewUserIdlerMap(), nil, nil, &mock.Config{ActiveBuildPhases: []string{"Running", "Timeout"}},
+		&sync.WaitGroup{}, func() {}, model.NewStringSet(), model.NewStringSet(), clock.New()).(*controllerImpl)
+	assert.False(t, ci.isActive(build("New")))
+	assert.True(t, ci.isActive(build("Timeout")))
+}
+
+func Test_stage_changed(t *testing.T) {
+	running := model.Build{Metadata: model.Metadata{Name: "test-1"}, Status: model.Status{Phase: "Running"}}
+	approval := running
+	approval.Metadata.Annotations.JenkinsStatus = `{"stages": [{"name": "Approve", "status": "PAUSED_PENDING_INPUT"}]}`
+	approved := running
+	approved.Metadata.Annotations.JenkinsStatus = `{"stages": [{"name": "Approve", "status": "SUCCESS"}]}`
+
+	assert.False(t, stageChanged(running, running))
+	assert.True(t, stageChanged(running, approval), "A build entering a stage should be sent to the user-idler")
+	assert.False(t, stageChanged(approval, approval))
+	assert.True(t, stageChanged(approval, approved), "A build leaving a stage should be sent to the user-idler")
+}
+
 func TestHandleBuildChannelLength(t *testing.T) {
 	setUp(t)
 	defer tearDown()
@@ -176,7 +231,7 @@ func TestHandleDeploymentConfigChannelLength(t *testing.T) {
 		err := controller.HandleDeploymentConfig(test.object)
 		assert.NoError(t, err)
 
-		ns := test.object.Object.Metadata.Namespace[:len(test.object.Object.Metadata.Namespace)-len(jenkinsNamespaceSuffix)]
+		ns, _ := namespace.User(test.object.Object.Metadata.Namespace)
 		ci := controller.(*controllerImpl)
 		userIdler := ci.userIdlerForNamespace(ns)
 
@@ -193,6 +248,527 @@ func TestHandleDeploymentConfigChannelLength(t *testing.T) {
 	}
 }
 
+func Test_handle_deployment_config_skips_duplicate_events(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	obj := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Namespace:       "test-namespace-jenkins",
+				ResourceVersion: "42",
+			},
+			Spec: model.Spec{
+				Replicas: 1,
+			},
+			Status: model.DCStatus{
+				Conditions: []model.Condition{
+					{
+						Type:   availableCond,
+						Status: "true",
+					},
+				},
+			},
+		},
+		Type: "ADDED",
+	}
+
+	for i := 0; i < 3; i++ {
+		err := controller.HandleDeploymentConfig(obj)
+		assert.NoError(t, err)
+	}
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	assert.Len(t, userIdler.GetChannel(), 1, "Repeated events should be sent to the user-idler once")
+	emptyChannel(userIdler.GetChannel())
+}
+
+// eventRecorder records the events received and ignored by the controller.
+type eventRecorder struct {
+	metric.PrometheusRecorder
+	received []string
+	ignored  []string
+}
+
+func (r *eventRecorder) RecordControllerEvent(cluster, resource string, elapsedTime float64) {
+	r.received = append(r.received, resource)
+}
+
+func (r *eventRecorder) RecordIgnoredEvent(cluster, resource, reason string) {
+	r.ignored = append(r.ignored, resource+": "+reason)
+}
+
+func Test_handle_deployment_config_records_event_metrics(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+	recorder := &eventRecorder{}
+	Recorder = recorder
+	defer func() { Recorder = metric.PrometheusRecorder{} }()
+
+	obj := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Namespace:       "test-namespace-jenkins",
+				ResourceVersion: "42",
+			},
+			Status: model.DCStatus{
+				Conditions: []model.Condition{{Type: availableCond, Status: "true"}},
+			},
+		},
+		Type: "ADDED",
+	}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, controller.HandleDeploymentConfig(obj))
+	}
+	emptyChannel(controller.(*controllerImpl).userIdlerForNamespace("test-namespace").GetChannel())
+
+	assert.Equal(t, []string{"deploymentconfigs", "deploymentconfigs"}, recorder.received)
+	assert.Equal(t, []string{"deploymentconfigs: duplicate"}, recorder.ignored)
+}
+
+func Test_handle_deployment_config_ignores_denylisted_namespace(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	ci := controller.(*controllerImpl)
+	ci.namespaces = namespace.NewFilter(nil, []string{"*-preview"})
+
+	obj := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Namespace: "test-preview-jenkins",
+			},
+		},
+		Type: "MODIFIED",
+	}
+
+	err := controller.HandleDeploymentConfig(obj)
+	assert.NoError(t, err)
+	assert.Nil(t, ci.userIdlerForNamespace("test-preview"), "No user-idler should be created for a denylisted namespace")
+}
+
+func Test_handle_deployment_config_rejects_non_jenkins_namespace(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	for _, ns := range []string{"test", "-jenkins", "test-jenkins-stage"} {
+		obj := model.DCObject{
+			Object: model.DeploymentConfig{Metadata: model.Metadata{Namespace: ns}},
+			Type:   "MODIFIED",
+		}
+		assert.Error(t, controller.HandleDeploymentConfig(obj), "DC event of namespace %s should be rejected", ns)
+	}
+	assert.Equal(t, 0, controller.(*controllerImpl).userIdlers.Len(), "No user-idler should be created for other namespaces")
+}
+
+func Test_handle_deployment_config_with_custom_suffix(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+	namespace.JenkinsSuffix = "-ci"
+	defer func() {
+		// the user-idler reads the suffix until it shut down
+		userIdlerGroup.Wait()
+		namespace.JenkinsSuffix = namespace.DefaultJenkinsSuffix
+	}()
+
+	obj := model.DCObject{
+		Object: model.DeploymentConfig{Metadata: model.Metadata{Namespace: "test-namespace-ci"}},
+		Type:   "MODIFIED",
+	}
+	assert.NoError(t, controller.HandleDeploymentConfig(obj))
+	assert.NotNil(t, controller.(*controllerImpl).userIdlerForNamespace("test-namespace"), "Expected user-idler to be created")
+}
+
+func Test_handle_deployment_config_detects_manual_unidle(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	dc := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Namespace:   "test-namespace-jenkins",
+				Annotations: model.Annotations{IdledAt: "2018-04-11T08:00:00Z"},
+			},
+			Spec: model.Spec{
+				Replicas: 1,
+			},
+			Status: model.DCStatus{
+				Conditions: []model.Condition{
+					{
+						Type:   availableCond,
+						Status: "true",
+					},
+				},
+			},
+		},
+		Type: "MODIFIED",
+	}
+
+	err := controller.HandleDeploymentConfig(dc)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	user := <-userIdler.GetChannel()
+	assert.False(t, user.ManualUnIdleAt.IsZero(), "Expected manual un-idle to be detected")
+}
+
+func Test_handle_deployment_config_records_resources(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	dc := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{Namespace: "test-namespace-jenkins"},
+			Spec: model.Spec{Template: model.PodTemplate{Spec: model.PodSpec{Containers: []model.Container{{
+				Name: "jenkins",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			}}}}},
+		},
+		Type: "MODIFIED",
+	}
+
+	err := controller.HandleDeploymentConfig(dc)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	require.NotNil(t, userIdler, "Expected user-idler to be created")
+	resources, ok := userIdler.Resources()
+	require.True(t, ok, "Expected the resources of the deployment config to be recorded")
+	assert.Equal(t, model.Resources{CPURequest: 0.5, MemoryLimit: 1024 * 1024 * 1024}, resources)
+}
+
+func Test_handle_deployment_config_tracks_idle_duration(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	dc := model.DCObject{
+		Object: model.DeploymentConfig{
+			Metadata: model.Metadata{
+				Namespace:   "test-namespace-jenkins",
+				Annotations: model.Annotations{IdledAt: "2018-04-11T08:00:00Z"},
+			},
+			Status: model.DCStatus{
+				Conditions: []model.Condition{
+					{
+						Type:   availableCond,
+						Status: "false",
+					},
+				},
+			},
+		},
+		Type: "MODIFIED",
+	}
+
+	err := controller.HandleDeploymentConfig(dc)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	user := <-userIdler.GetChannel()
+	assert.Equal(t, "2018-04-11T08:00:00Z", user.IdledAt.Format(time.RFC3339))
+	assert.Equal(t, time.Duration(0), user.TotalIdleDuration)
+}
+
+func Test_assign_variant(t *testing.T) {
+	c := &controllerImpl{features: mock.NewMockExperimentToggle(nil, map[string]toggles.Variant{
+		"1": {Name: "short", Payload: "30"},
+		"2": {Name: "broken", Payload: "soon"},
+	})}
+
+	var variantTests = []struct {
+		id               string
+		variant          string
+		variantIdleAfter time.Duration
+	}{
+		{"1", "short", 30 * time.Minute},
+		{"2", "", 0},
+		{"3", "", 0},
+	}
+
+	for _, variantTest := range variantTests {
+		user := model.NewUser(variantTest.id, "john")
+		c.assignVariant(&user, logger)
+		assert.Equal(t, variantTest.variant, user.Variant, "Unexpected variant of user %s", variantTest.id)
+		assert.Equal(t, variantTest.variantIdleAfter, user.VariantIdleAfter, "Unexpected idle timeout of user %s", variantTest.id)
+	}
+
+	user := model.NewUser("1", "john")
+	(&controllerImpl{features: mock.NewMockFeatureToggle(nil)}).assignVariant(&user, logger)
+	assert.Empty(t, user.Variant, "Features without experiments should not assign variants")
+}
+
+func Test_handle_pod(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	pod := model.PodObject{
+		Type: "MODIFIED",
+		Object: model.Pod{
+			Name:      "jenkins-1-abcde",
+			Namespace: "test-namespace-jenkins",
+			Phase:     "Running",
+			Restarts:  model.PodRestarts{Count: 2, OOMKilled: true},
+			Failure:   model.PodCrashLooping,
+		},
+	}
+
+	err := controller.HandlePod(pod)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	user := <-userIdler.GetChannel()
+	assert.Equal(t, pod.Object, user.Pod)
+
+	pod.Type = "DELETED"
+	pod.Object.Name = "jenkins-0-fghij"
+	err = controller.HandlePod(pod)
+	assert.NoError(t, err)
+	assert.Len(t, userIdler.GetChannel(), 0, "Deletion of an unknown pod should be ignored")
+}
+
+func Test_handle_idler_config(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	cfg := model.IdlerConfigObject{
+		Type: "ADDED",
+		Object: model.IdlerConfig{
+			Metadata: model.Metadata{Name: model.IdlerConfigName, Namespace: "test-namespace-jenkins", ResourceVersion: "1"},
+			Spec:     model.IdlerConfigSpec{IdleTimeout: "4h", Schedule: []string{"Mon-Fri 08:00-18:00"}},
+		},
+	}
+
+	err := controller.HandleIdlerConfig(cfg)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	user := <-userIdler.GetChannel()
+	assert.Equal(t, 4*time.Hour, user.Overrides.IdleAfter)
+	assert.Len(t, user.Overrides.Schedule, 1)
+	assert.False(t, user.Overrides.Disabled)
+
+	err = controller.HandleIdlerConfig(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, userIdler.GetChannel(), 0, "Duplicate events should be ignored")
+
+	invalid := cfg
+	invalid.Type = "MODIFIED"
+	invalid.Object.Metadata.ResourceVersion = "2"
+	invalid.Object.Spec.IdleTimeout = "forever"
+	err = controller.HandleIdlerConfig(invalid)
+	assert.NoError(t, err)
+	assert.Len(t, userIdler.GetChannel(), 0, "Invalid configs should be ignored")
+
+	other := cfg
+	other.Object.Metadata.Name = "other"
+	other.Object.Metadata.ResourceVersion = "3"
+	err = controller.HandleIdlerConfig(other)
+	assert.NoError(t, err)
+	assert.Len(t, userIdler.GetChannel(), 0, "Configs of other names should be ignored")
+
+	deleted := cfg
+	deleted.Type = "DELETED"
+	deleted.Object.Metadata.ResourceVersion = "4"
+	err = controller.HandleIdlerConfig(deleted)
+	assert.NoError(t, err)
+	user = <-userIdler.GetChannel()
+	assert.Equal(t, model.Overrides{}, user.Overrides)
+}
+
+func Test_handle_pod_recommends_memory_limit_after_oom_kills(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+	defer func(a *remediation.Advisor) { remediation.DefaultAdvisor = a }(remediation.DefaultAdvisor)
+	remediation.DefaultAdvisor = remediation.NewAdvisor(&mock.Config{OOMKillThreshold: 2, OOMKillWindow: 60,
+		OOMLimitIncrease: 50, OOMMaxMemoryLimit: 4096}, clock.New())
+	oc := &mock.OpenShiftClient{}
+	controller.(*controllerImpl).openShiftClient = oc
+	controller.(*controllerImpl).features = &mockFeatureToggle{oomLimitBump: true}
+
+	pod := model.PodObject{
+		Type: "MODIFIED",
+		Object: model.Pod{
+			Name:      "jenkins-1-abcde",
+			Namespace: "test-namespace-jenkins",
+			Phase:     "Running",
+			Restarts:  model.PodRestarts{Count: 1, OOMKilled: true},
+		},
+	}
+	require.NoError(t, controller.HandlePod(pod))
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	require.NotNil(t, userIdler, "Expected user-idler to be created")
+	userIdler.SetResources(model.Resources{MemoryLimit: 1 << 30})
+
+	pod.Object.Phase = "Pending"
+	require.NoError(t, controller.HandlePod(pod))
+	assert.Empty(t, remediation.DefaultAdvisor.Recommendations(), "Events of the same OOM kill should be counted once")
+
+	pod.Object.Restarts.Count = 2
+	require.NoError(t, controller.HandlePod(pod))
+	recommendations := remediation.DefaultAdvisor.Recommendations()
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, "test-namespace-jenkins", recommendations[0].Namespace)
+	assert.Equal(t, int64(1536<<20), recommendations[0].SuggestedLimit)
+
+	remediation.DefaultAdvisor.Wait()
+	assert.Equal(t, map[string]int64{"test-namespace-jenkins": 1536 << 20}, oc.MemoryLimits)
+}
+
+func Test_reconcile_seeds_user_idler_with_jenkins_state(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	dc := model.DeploymentConfig{
+		Metadata: model.Metadata{
+			Namespace: "test-namespace-jenkins",
+		},
+		Status: model.DCStatus{
+			Replicas:      1,
+			ReadyReplicas: 1,
+			Conditions: []model.Condition{
+				{
+					Type:   availableCond,
+					Status: "true",
+				},
+			},
+		},
+	}
+
+	err := controller.Reconcile(dc)
+	assert.NoError(t, err)
+
+	userIdler := controller.(*controllerImpl).userIdlerForNamespace("test-namespace")
+	if !assert.NotNil(t, userIdler, "Expected user-idler to be created") {
+		return
+	}
+	assert.Equal(t, idler.StateRunning, userIdler.State())
+	assert.Len(t, userIdler.GetChannel(), 1, "Expected user to be sent to the user-idler")
+	emptyChannel(userIdler.GetChannel())
+}
+
+func Test_reconcile_rejects_non_jenkins_namespace(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	dc := model.DeploymentConfig{
+		Metadata: model.Metadata{
+			Namespace: "test-namespace-che",
+		},
+	}
+
+	err := controller.Reconcile(dc)
+	assert.Error(t, err)
+	assert.Equal(t, 0, controller.(*controllerImpl).userIdlers.Len())
+}
+
+func Test_warm_up_creates_user_idler(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	err := controller.WarmUp("test-namespace-jenkins")
+	assert.NoError(t, err)
+	assert.NotNil(t, controller.(*controllerImpl).userIdlerForNamespace("test-namespace"), "Expected user-idler to be created")
+
+	err = controller.WarmUp("test-namespace-che")
+	assert.Error(t, err)
+	assert.Equal(t, 1, controller.(*controllerImpl).userIdlers.Len())
+}
+
+func Test_new_user_idler_backfills_builds(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	build := func(name, phase string, started, completed time.Time) model.Build {
+		return model.Build{
+			Metadata: model.Metadata{Name: name, Namespace: "test-namespace"},
+			Status:   model.Status{Phase: phase, StartTimestamp: model.BuildTime{Time: started}, CompletionTimestamp: model.BuildTime{Time: completed}},
+			Spec:     model.Spec{Strategy: model.Strategy{Type: model.JenkinsPipelineStrategy}},
+		}
+	}
+	now := time.Now()
+	docker := build("docker-9", "Running", now, time.Time{})
+	docker.Spec.Strategy.Type = "Docker"
+
+	ci := controller.(*controllerImpl)
+	ci.openShiftClient = &mock.OpenShiftClient{Builds: []model.Build{
+		build("app-1", "Complete", now.Add(-3*time.Hour), now.Add(-2*time.Hour)),
+		build("app-2", "Failed", now.Add(-90*time.Minute), now.Add(-time.Hour)),
+		build("app-3", "Running", now.Add(-10*time.Minute), time.Time{}),
+		docker,
+	}}
+
+	require.NoError(t, controller.WarmUp("test-namespace-jenkins"))
+	user := ci.userIdlerForNamespace("test-namespace").GetUser()
+	assert.Equal(t, "app-3", user.ActiveBuild.Metadata.Name, "the running pipeline build should be active")
+	assert.Equal(t, "app-2", user.DoneBuild.Metadata.Name, "the last completed build should be done")
+}
+
+func Test_migrated_namespace_is_rehomed(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tenants := func(cluster string) tenant.Service {
+		f, err := ioutil.TempFile("", "tenants")
+		require.NoError(t, err)
+		defer os.Remove(f.Name())
+		_, err = f.WriteString("tenants:\n- id: john-id\n  namespaces:\n" +
+			"  - name: john\n    type: user\n    cluster-url: " + cluster + "\n" +
+			"  - name: john-jenkins\n    type: jenkins\n    cluster-url: " + cluster + "\n")
+		require.NoError(t, err)
+		f.Close()
+
+		s, err := tenant.NewFileService(f.Name())
+		require.NoError(t, err)
+		return s
+	}
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	userIdlers := NewUserIdlerMap()
+	newController := func(cluster string, t tenant.Service) *controllerImpl {
+		c := NewController(ctx, cluster, "", userIdlers, t, &mockFeatureToggle{}, &mock.Config{}, &wg, cancel,
+			model.NewStringSet(), model.NewStringSet(), clock.New()).(*controllerImpl)
+		c.openShiftClient = &mock.OpenShiftClient{}
+		return c
+	}
+
+	cluster1 := newController("https://api.cluster1.example.com/", tenants("https://api.cluster1.example.com"))
+	require.NoError(t, cluster1.WarmUp("john-jenkins"))
+	previous := cluster1.userIdlerForNamespace("john")
+	require.NotNil(t, previous)
+
+	cluster2 := newController("https://api.cluster2.example.com/", tenants("https://api.cluster1.example.com"))
+	require.NoError(t, cluster2.WarmUp("john-jenkins"))
+	assert.Equal(t, "https://api.cluster1.example.com/", cluster2.userIdlerForNamespace("john").OpenShiftAPI(),
+		"namespace should not be re-homed unless the tenant service locates it on the other cluster")
+
+	cluster2.tenantService = tenants("https://api.cluster2.example.com")
+	require.NoError(t, cluster2.WarmUp("john-jenkins"))
+	rehomed := cluster2.userIdlerForNamespace("john")
+	assert.Equal(t, "https://api.cluster2.example.com/", rehomed.OpenShiftAPI(), "namespace should be re-homed")
+	assert.Equal(t, "john-id", rehomed.GetUser().ID)
+	assert.Equal(t, 1, userIdlers.Len())
+}
+
 func setUp(t *testing.T) {
 	origWriter = log.StandardLogger().Out
 	log.SetOutput(ioutil.Discard)
@@ -210,17 +786,17 @@ func setUp(t *testing.T) {
 	}
 	openShiftService = common.MockServer(deploymentConfigData)
 
-	tenantService := tenant.NewTenantService(tenantService.URL, "")
+	tenantService := tenant.NewTenantService(tenantService.URL, "", 0)
 
 	features := &mockFeatureToggle{}
 
-	var wg sync.WaitGroup
+	userIdlerGroup = &sync.WaitGroup{}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	userIdlers := NewUserIdlerMap()
 	disabledUsers := model.NewStringSet()
-	controller = NewController(ctx, "", "", userIdlers, tenantService, features, &mock.Config{}, &wg, cancel, disabledUsers)
+	controller = NewController(ctx, "", "", userIdlers, tenantService, features, &mock.Config{}, userIdlerGroup, cancel, disabledUsers, model.NewStringSet(), clock.New())
 }
 
 func emptyChannel(ch chan model.User) {
@@ -0,0 +1,59 @@
+package openshift
+
+import (
+	"sync"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+)
+
+// maxSeenObjects limits the number of objects for which the last seen resourceVersion is tracked.
+const maxSeenObjects = 16384
+
+// SeenEventsMap is a type-safe and concurrent map keeping track of the last resourceVersion seen per object, in
+// order to drop repeated identical events, e.g. after a watch got re-established.
+type SeenEventsMap struct {
+	sync.Mutex
+	internal map[string]string
+}
+
+// NewSeenEventsMap creates a new instance of SeenEventsMap.
+func NewSeenEventsMap() *SeenEventsMap {
+	return &SeenEventsMap{
+		internal: make(map[string]string),
+	}
+}
+
+// Duplicate records the resourceVersion of the given object and returns whether an event for this very
+// resourceVersion has been seen already. Objects without resourceVersion are never considered duplicates.
+func (m *SeenEventsMap) Duplicate(eventType string, resource string, meta model.Metadata) bool {
+	if meta.ResourceVersion == "" {
+		return false
+	}
+
+	key := resource + "/" + meta.Namespace + "/" + meta.Name
+	m.Lock()
+	defer m.Unlock()
+
+	if m.internal[key] == meta.ResourceVersion {
+		return true
+	}
+
+	if eventType == "DELETED" {
+		delete(m.internal, key)
+		return false
+	}
+
+	if len(m.internal) >= maxSeenObjects {
+		m.internal = make(map[string]string)
+	}
+	m.internal[key] = meta.ResourceVersion
+	return false
+}
+
+// Forget removes the resourceVersion recorded for the given object, so that an event whose handling failed is not
+// taken for a duplicate once it gets retried or delivered anew.
+func (m *SeenEventsMap) Forget(resource string, meta model.Metadata) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.internal, resource+"/"+meta.Namespace+"/"+meta.Name)
+}
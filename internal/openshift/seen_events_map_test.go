@@ -0,0 +1,38 @@
+package openshift
+
+import (
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_seen_events_map_detects_duplicates(t *testing.T) {
+	m := NewSeenEventsMap()
+	build := model.Metadata{Namespace: "foo", Name: "foo-1", ResourceVersion: "10"}
+
+	assert.False(t, m.Duplicate("ADDED", "builds", build))
+	assert.True(t, m.Duplicate("ADDED", "builds", build), "same resourceVersion should be a duplicate")
+	assert.False(t, m.Duplicate("ADDED", "deploymentconfigs", build), "resources should be tracked separately")
+
+	build.ResourceVersion = "11"
+	assert.False(t, m.Duplicate("MODIFIED", "builds", build))
+
+	build.ResourceVersion = "12"
+	assert.False(t, m.Duplicate("DELETED", "builds", build))
+	assert.False(t, m.Duplicate("ADDED", "builds", build), "deleted objects should be forgotten")
+
+	assert.False(t, m.Duplicate("MODIFIED", "builds", model.Metadata{Namespace: "foo", Name: "foo-2"}))
+	assert.False(t, m.Duplicate("MODIFIED", "builds", model.Metadata{Namespace: "foo", Name: "foo-2"}),
+		"objects without resourceVersion should never be duplicates")
+}
+
+func Test_seen_events_map_forgets_failed_events(t *testing.T) {
+	m := NewSeenEventsMap()
+	build := model.Metadata{Namespace: "foo", Name: "foo-1", ResourceVersion: "10"}
+
+	assert.False(t, m.Duplicate("ADDED", "builds", build))
+	m.Forget("builds", build)
+	assert.False(t, m.Duplicate("ADDED", "builds", build), "a forgotten event should not be a duplicate")
+	assert.True(t, m.Duplicate("ADDED", "builds", build))
+}
@@ -0,0 +1,228 @@
+package openshift
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/recovery"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// minRestartBackoff is the delay before the first restart of a cluster whose run ended unexpectedly.
+	minRestartBackoff = 5 * time.Second
+
+	// maxRestartBackoff is the maximum delay between the restarts of a cluster. A run lasting that long is considered
+	// stable, which marks the cluster as healthy again and resets the backoff.
+	maxRestartBackoff = 5 * time.Minute
+)
+
+var supervisorLogger = logrus.WithFields(logrus.Fields{"component": "supervisor"})
+
+// errRunEnded is the reason a cluster gets degraded for if its run ends without a panic before it got stopped.
+var errRunEnded = errors.New("watches ended unexpectedly")
+
+// ClusterRunner runs the controller and the watches of a single cluster. It needs to block until the given context
+// is done and its watches ended, as the cluster gets restarted once it returns. Calling cancel ends the run of this
+// cluster only.
+type ClusterRunner func(ctx context.Context, cancel context.CancelFunc, c cluster.Cluster)
+
+// Supervisor runs a controller and its watches per cluster, each with its own lifecycle, so that clusters can be
+// started, stopped and restarted without affecting the other clusters. Once the run of a cluster ends, the user
+// idlers of its namespaces are removed. If the run ends before the cluster got stopped, e.g. due to a panic or since
+// its token got rejected, the cluster is marked as degraded and restarted with exponential backoff.
+type Supervisor struct {
+	sync.Mutex
+	ctx        context.Context
+	wg         *sync.WaitGroup
+	userIdlers *UserIdlerMap
+	run        ClusterRunner
+	clock      clock.Clock
+	watches    map[string]*clusterWatch
+}
+
+// clusterWatch is the run of a single cluster.
+type clusterWatch struct {
+	cluster cluster.Cluster
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewSupervisor creates a Supervisor running the clusters with the given runner until the context is done.
+func NewSupervisor(ctx context.Context, wg *sync.WaitGroup, userIdlers *UserIdlerMap, run ClusterRunner, clock clock.Clock) *Supervisor {
+	return &Supervisor{
+		ctx:        ctx,
+		wg:         wg,
+		userIdlers: userIdlers,
+		run:        run,
+		clock:      clock,
+		watches:    make(map[string]*clusterWatch),
+	}
+}
+
+// Start starts the controller and the watches of the cluster. It returns false if the cluster is running already.
+func (s *Supervisor) Start(c cluster.Cluster) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.watches[c.APIURL]; ok {
+		return false
+	}
+	s.start(c)
+	return true
+}
+
+// start runs the cluster in a goroutine, restarting it with backoff until it gets stopped. It needs to be called
+// with the lock held.
+func (s *Supervisor) start(c cluster.Cluster) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	w := &clusterWatch{cluster: c, cancel: cancel, done: make(chan struct{})}
+	s.watches[c.APIURL] = w
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(w.done)
+		defer cluster.DefaultHealth.Recover(c.APIURL)
+
+		log := supervisorLogger.WithField("cluster", c.APIURL)
+		var backoff time.Duration
+		for {
+			started := s.clock.Now()
+			err := s.runOnce(ctx, c)
+			if ctx.Err() != nil {
+				break
+			}
+
+			if s.clock.Since(started) >= maxRestartBackoff {
+				backoff = 0
+			}
+			backoff = nextBackoff(backoff)
+			cluster.DefaultHealth.Degrade(c.APIURL, err.Error())
+			log.WithFields(logrus.Fields{"err": err, "backoff": backoff}).Warn("Watches of the cluster ended, restarting them")
+
+			select {
+			case <-ctx.Done():
+			case <-s.clock.After(backoff):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		s.Lock()
+		if s.watches[c.APIURL] == w {
+			delete(s.watches, c.APIURL)
+		}
+		s.Unlock()
+	}()
+}
+
+// runOnce runs the cluster until its run ends, recovering from panics, and removes the user idlers of the cluster
+// afterwards. It returns the reason the run ended for. Once the run lasted for maxRestartBackoff, the cluster is
+// marked as healthy.
+func (s *Supervisor) runOnce(ctx context.Context, c cluster.Cluster) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	log := supervisorLogger.WithField("cluster", c.APIURL)
+	log.Info("Starting to watch the cluster")
+	Recorder.RecordClusterWatch(c.APIURL, true)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case <-ctx.Done():
+		case <-s.clock.After(maxRestartBackoff):
+			cluster.DefaultHealth.Recover(c.APIURL)
+		}
+	}()
+
+	err := recovery.Guard("supervisor", func() error {
+		s.run(ctx, cancel, c)
+		return errRunEnded
+	})
+	cancel()
+
+	removed := s.userIdlers.RemoveCluster(c.APIURL)
+	Recorder.RecordClusterWatch(c.APIURL, false)
+	log.WithField("user_idlers", removed).Info("Stopped watching the cluster")
+	return err
+}
+
+// nextBackoff doubles the given restart backoff within minRestartBackoff and maxRestartBackoff.
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff < minRestartBackoff {
+		return minRestartBackoff
+	}
+	if backoff > maxRestartBackoff {
+		return maxRestartBackoff
+	}
+	return backoff
+}
+
+// Stop stops the controller, the watches and the user idlers of the cluster with the given API URL and waits until
+// they are done. It returns false if the cluster is not running.
+func (s *Supervisor) Stop(apiURL string) bool {
+	s.Lock()
+	w, ok := s.watches[apiURL]
+	delete(s.watches, apiURL)
+	s.Unlock()
+
+	if !ok {
+		return false
+	}
+	w.cancel()
+	<-w.done
+	return true
+}
+
+// Restart stops the cluster, if it is running, and starts it anew, e.g. once its token or health changed.
+func (s *Supervisor) Restart(c cluster.Cluster) {
+	s.Stop(c.APIURL)
+	s.Start(c)
+}
+
+// Sync starts the clusters which are not running yet, restarts the ones whose token changed and stops the ones
+// which are no longer listed.
+func (s *Supervisor) Sync(clusters []cluster.Cluster) {
+	listed := make(map[string]bool)
+	for _, c := range clusters {
+		listed[c.APIURL] = true
+
+		s.Lock()
+		w, ok := s.watches[c.APIURL]
+		s.Unlock()
+		if !ok {
+			s.Start(c)
+		} else if w.cluster.Token != c.Token {
+			s.Restart(c)
+		}
+	}
+
+	for _, apiURL := range s.Clusters() {
+		if !listed[apiURL] {
+			s.Stop(apiURL)
+		}
+	}
+}
+
+// Clusters returns the sorted API URLs of the running clusters.
+func (s *Supervisor) Clusters() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	clusters := make([]string, 0, len(s.watches))
+	for apiURL := range s.watches {
+		clusters = append(clusters, apiURL)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
@@ -0,0 +1,100 @@
+package openshift
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/cluster"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_supervisor_lifecycle(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	userIdlers := NewUserIdlerMap()
+
+	var mu sync.Mutex
+	runs := make(map[string][]string)
+	run := func(ctx context.Context, cancel context.CancelFunc, c cluster.Cluster) {
+		mu.Lock()
+		runs[c.APIURL] = append(runs[c.APIURL], c.Token)
+		mu.Unlock()
+		userIdlers.Store(c.APIURL+"user", idler.NewUserIdler(model.NewUser("id", "user"), c.APIURL, c.Token, &mock.Config{}, nil, nil, clock.New()))
+		<-ctx.Done()
+	}
+	s := NewSupervisor(ctx, &wg, userIdlers, run, clock.New())
+
+	one := cluster.Cluster{APIURL: "https://api.one.example.com/", Token: "a"}
+	two := cluster.Cluster{APIURL: "https://api.two.example.com/", Token: "b"}
+	s.Sync([]cluster.Cluster{one, two})
+	assert.Equal(t, []string{one.APIURL, two.APIURL}, s.Clusters())
+	assert.False(t, s.Start(one), "Running cluster should not be started twice")
+
+	one.Token = "c"
+	s.Sync([]cluster.Cluster{one})
+	assert.Equal(t, []string{one.APIURL}, s.Clusters(), "Unlisted cluster should be stopped")
+	_, ok := userIdlers.Load(two.APIURL + "user")
+	assert.False(t, ok, "User idlers of a stopped cluster should be removed")
+
+	assert.False(t, s.Stop(two.APIURL), "Stopped cluster should not be stopped twice")
+	cancel()
+	wg.Wait()
+
+	assert.Empty(t, s.Clusters(), "Clusters should be stopped once the context is done")
+	assert.Equal(t, []string{"a", "c"}, runs[one.APIURL], "Cluster should be restarted with the changed token")
+	assert.Equal(t, []string{"b"}, runs[two.APIURL])
+}
+
+func Test_supervisor_restarts_failed_cluster(t *testing.T) {
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	c := clock.NewFake(time.Now())
+
+	var mu sync.Mutex
+	runs := 0
+	run := func(ctx context.Context, cancel context.CancelFunc, _ cluster.Cluster) {
+		mu.Lock()
+		runs++
+		first := runs == 1
+		mu.Unlock()
+		if first {
+			panic("token rejected")
+		}
+		<-ctx.Done()
+	}
+	ranTwice := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return runs == 2
+	}
+
+	apiURL := "https://api.failing.example.com/"
+	s := NewSupervisor(ctx, &wg, NewUserIdlerMap(), run, c)
+	s.Start(cluster.Cluster{APIURL: apiURL})
+
+	// the stable-run timer of the failed run and the restart backoff
+	c.BlockUntil(2)
+	reason, degraded := cluster.DefaultHealth.Degraded(apiURL)
+	assert.True(t, degraded, "Cluster should be degraded once its run failed")
+	assert.Contains(t, reason, "token rejected")
+	assert.Equal(t, []string{apiURL}, s.Clusters(), "Failed cluster should be kept for the restart")
+
+	c.Advance(minRestartBackoff)
+	assert.Eventually(t, ranTwice, time.Second, 10*time.Millisecond, "Cluster should be restarted after the backoff")
+
+	c.BlockUntil(2)
+	c.Advance(maxRestartBackoff)
+	assert.Eventually(t, func() bool {
+		_, degraded := cluster.DefaultHealth.Degraded(apiURL)
+		return !degraded
+	}, time.Second, 10*time.Millisecond, "Cluster should be healthy once its run is stable")
+
+	cancel()
+	wg.Wait()
+}
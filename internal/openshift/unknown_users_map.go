@@ -1,40 +1,33 @@
 package openshift
 
 import (
-	"sync"
+	cmap "github.com/orcaman/concurrent-map"
 )
 
-// UnknownUsersMap is a type-safe and concurrent map keeping track of unknown users.
+// UnknownUsersMap is a type-safe and concurrent map keeping track of unknown users. It is sharded, so that the
+// controllers of different clusters do not contend on a single lock.
 type UnknownUsersMap struct {
-	sync.RWMutex
-	internal map[string]interface{}
+	internal cmap.ConcurrentMap
 }
 
 // NewUnknownUsersMap creates a new instance of UnknownUsersMap.
 func NewUnknownUsersMap() *UnknownUsersMap {
 	return &UnknownUsersMap{
-		internal: make(map[string]interface{}),
+		internal: cmap.New(),
 	}
 }
 
 // Load returns the value stored under specified user name.
 func (m *UnknownUsersMap) Load(user string) (interface{}, bool) {
-	m.RLock()
-	result, ok := m.internal[user]
-	m.RUnlock()
-	return result, ok
+	return m.internal.Get(user)
 }
 
 // Delete deletes the specified user from the map.
 func (m *UnknownUsersMap) Delete(user string) {
-	m.Lock()
-	delete(m.internal, user)
-	m.Unlock()
+	m.internal.Remove(user)
 }
 
 // Store stores the specified value under the key user.
 func (m *UnknownUsersMap) Store(user string, value interface{}) {
-	m.Lock()
-	m.internal[user] = value
-	m.Unlock()
+	m.internal.Set(user, value)
 }
@@ -0,0 +1,37 @@
+package openshift
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_unknown_users_map(t *testing.T) {
+	m := NewUnknownUsersMap()
+	_, ok := m.Load("foo")
+	assert.False(t, ok, "There should be no entry mapped")
+
+	m.Store("foo", 42)
+	v, ok := m.Load("foo")
+	assert.True(t, ok, "There should be an entry mapped")
+	assert.Equal(t, 42, v)
+
+	m.Delete("foo")
+	_, ok = m.Load("foo")
+	assert.False(t, ok, "The entry should be deleted")
+}
+
+func BenchmarkUnknownUsersMap_LoadStore(b *testing.B) {
+	m := NewUnknownUsersMap()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % benchmarkNamespaces)
+			if _, ok := m.Load(key); !ok {
+				m.Store(key, true)
+			}
+			i++
+		}
+	})
+}
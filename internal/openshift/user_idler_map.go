@@ -1,6 +1,8 @@
 package openshift
 
 import (
+	"time"
+
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
 	cmap "github.com/orcaman/concurrent-map"
 )
@@ -40,3 +42,55 @@ func (m *UserIdlerMap) Len() int {
 func (m *UserIdlerMap) Store(namespace string, i *idler.UserIdler) {
 	m.internal.Set(namespace, i)
 }
+
+// Range calls f for each user idler and its namespace. It stops iterating once f returns false.
+func (m *UserIdlerMap) Range(f func(namespace string, i *idler.UserIdler) bool) {
+	for item := range m.internal.IterBuffered() {
+		if !f(item.Key, item.Val.(*idler.UserIdler)) {
+			return
+		}
+	}
+}
+
+// EvictInactive stops and removes the user idlers which did not receive any user data since the given time and
+// whose Jenkins instance is idled or was never observed. They are recreated on the next event for their namespace.
+// It returns the number of evicted user idlers.
+func (m *UserIdlerMap) EvictInactive(since time.Time) int {
+	evicted := 0
+	for item := range m.internal.IterBuffered() {
+		userIdler := item.Val.(*idler.UserIdler)
+		state := userIdler.State()
+		if state != idler.StateIdled && state != idler.StateUnknown {
+			continue
+		}
+		if !userIdler.LastActivity().Before(since) {
+			continue
+		}
+
+		m.internal.Remove(item.Key)
+		userIdler.Stop()
+		Recorder.RecordEviction(string(state))
+		Recorder.RecordChannelRemoved(item.Key)
+		evicted++
+	}
+	return evicted
+}
+
+// RemoveCluster stops and removes the user idlers managing namespaces on the cluster with the given API URL, e.g.
+// once the cluster is no longer watched. It returns the number of removed user idlers.
+func (m *UserIdlerMap) RemoveCluster(apiURL string) int {
+	removed := 0
+	for item := range m.internal.IterBuffered() {
+		userIdler := item.Val.(*idler.UserIdler)
+		if !sameCluster(userIdler.OpenShiftAPI(), apiURL) {
+			continue
+		}
+
+		m.internal.Remove(item.Key)
+		userIdler.Stop()
+		Recorder.RecordEviction(string(userIdler.State()))
+		Recorder.RecordChannelRemoved(item.Key)
+		removed++
+	}
+	return removed
+}
@@ -4,8 +4,12 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,3 +93,82 @@ func TestUserIdlerMap_StoreDelete(t *testing.T) {
 	}()
 	wg.Wait()
 }
+
+func Test_evict_inactive(t *testing.T) {
+	c := clock.NewFake(time.Now())
+	m := NewUserIdlerMap()
+	newIdler := func(name string, state model.PodState) *idler.UserIdler {
+		userIdler := idler.NewUserIdler(model.NewUser(name, name), "", "", &mock.Config{}, nil, nil, c)
+		userIdler.Observe(state)
+		m.Store(name, userIdler)
+		return userIdler
+	}
+
+	newIdler("idled", model.PodIdled)
+	newIdler("running", model.PodRunning)
+	c.Advance(48 * time.Hour)
+	newIdler("recent", model.PodIdled)
+
+	evicted := m.EvictInactive(c.Now().Add(-24 * time.Hour))
+	assert.Equal(t, 1, evicted, "Only the inactive idled user-idler should be evicted")
+	_, ok := m.Load("idled")
+	assert.False(t, ok, "Inactive user-idler should be removed")
+	_, ok = m.Load("running")
+	assert.True(t, ok, "User-idler of running Jenkins should be kept")
+	_, ok = m.Load("recent")
+	assert.True(t, ok, "Recently active user-idler should be kept")
+}
+
+func Test_remove_cluster(t *testing.T) {
+	m := NewUserIdlerMap()
+	for name, apiURL := range map[string]string{"foo": "https://api.one.example.com", "bar": "https://api.one.example.com/", "baz": "https://api.two.example.com/"} {
+		m.Store(name, idler.NewUserIdler(model.NewUser(name, name), apiURL, "", &mock.Config{}, nil, nil, clock.New()))
+	}
+
+	assert.Equal(t, 2, m.RemoveCluster("https://api.one.example.com/"))
+	assert.Equal(t, 1, m.Len())
+	_, ok := m.Load("baz")
+	assert.True(t, ok, "User-idler of another cluster should be kept")
+}
+
+// benchmarkNamespaces is the number of tracked namespaces used by the benchmarks, roughly the number of tenants
+// of a large cluster.
+const benchmarkNamespaces = 5000
+
+func BenchmarkUserIdlerMap_Load(b *testing.B) {
+	m := NewUserIdlerMap()
+	for i := 0; i < benchmarkNamespaces; i++ {
+		m.Store(strconv.Itoa(i), &idler.UserIdler{})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Load(strconv.Itoa(i % benchmarkNamespaces))
+			i++
+		}
+	})
+}
+
+func BenchmarkUserIdlerMap_LoadStore(b *testing.B) {
+	m := NewUserIdlerMap()
+	for i := 0; i < benchmarkNamespaces; i++ {
+		m.Store(strconv.Itoa(i), &idler.UserIdler{})
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := strconv.Itoa(i % benchmarkNamespaces)
+			// one in ten events concerns a namespace without user-idler yet
+			if i%10 == 0 {
+				m.Store(key, &idler.UserIdler{})
+			} else {
+				m.Load(key)
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package pressure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/prometheus"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// clusterPlaceholder stands for the API URL of the cluster in the pressure query.
+	clusterPlaceholder = "{{cluster}}"
+
+	// checkInterval is the interval at which the pressure query is evaluated and relieved pressure is detected.
+	checkInterval = time.Minute
+)
+
+var (
+	logger      = logrus.WithField("component", "pressure")
+	auditLogger = logrus.WithField("component", "audit")
+)
+
+// Default is the Monitor used by the Idler, nil unless adaptive idling is enabled.
+var Default *Monitor
+
+// Recorder to capture the pressure changes
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// Monitor tracks whether the clusters are under resource pressure, so that the Idler can temporarily shorten the
+// idle timeouts and free resources more aggressively. A cluster is under pressure from a pressure signal, i.e. an
+// un-idle request refused due to the cluster capacity or the pressure query reaching the threshold, until no signal
+// occurred for the relax period. A nil Monitor never reports pressure.
+type Monitor struct {
+	sync.Mutex
+	idleAfter     time.Duration
+	relaxAfter    time.Duration
+	client        *prometheus.Client
+	query         string
+	threshold     float64
+	clock         clock.Clock
+	signals       map[string]time.Time
+	underPressure map[string]bool
+}
+
+// New creates a Monitor as configured. It returns nil if adaptive idling is disabled.
+func New(config configuration.Configuration, clock clock.Clock) *Monitor {
+	if !config.GetAdaptiveIdling() {
+		return nil
+	}
+
+	m := &Monitor{
+		idleAfter:     time.Duration(config.GetPressureIdleAfter()) * time.Minute,
+		relaxAfter:    time.Duration(config.GetPressureRelaxAfter()) * time.Minute,
+		threshold:     config.GetPressureThreshold(),
+		clock:         clock,
+		signals:       make(map[string]time.Time),
+		underPressure: make(map[string]bool),
+	}
+	if query := config.GetPressureQuery(); query != "" && config.GetPrometheusURL() != "" {
+		m.client = prometheus.NewClient(config.GetPrometheusURL())
+		m.query = query
+	}
+	return m
+}
+
+// Signal reports the cluster to be under pressure for the given reason, e.g. since an un-idle request got refused
+// due to the cluster capacity.
+func (m *Monitor) Signal(cluster string, reason string) {
+	if m == nil {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	cluster = util.EnsureSuffix(cluster, "/")
+	m.signals[cluster] = m.clock.Now()
+	m.update(cluster, reason)
+}
+
+// IdleAfter returns the idle timeout applying while the cluster is under pressure, zero if it is not.
+func (m *Monitor) IdleAfter(cluster string) time.Duration {
+	if m == nil {
+		return 0
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if !m.update(util.EnsureSuffix(cluster, "/"), "") {
+		return 0
+	}
+	return m.idleAfter
+}
+
+// Start evaluates the pressure query for the given clusters every minute until the context is done. Clusters whose
+// pressure got relieved are detected at the same time.
+func (m *Monitor) Start(ctx context.Context, wg *sync.WaitGroup, clusters []string) {
+	if m == nil {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := m.clock.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			for _, cluster := range clusters {
+				m.check(cluster)
+			}
+			select {
+			case <-ctx.Done():
+				logger.Info("Shutting down pressure monitor.")
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+}
+
+// check signals pressure if the pressure query of the cluster reaches the threshold and detects relieved pressure
+// otherwise.
+func (m *Monitor) check(cluster string) {
+	if m.client != nil {
+		value, err := m.client.Sum(strings.Replace(m.query, clusterPlaceholder, cluster, -1))
+		if err != nil {
+			logger.WithFields(logrus.Fields{"cluster": cluster, "err": err}).Warn("Unable to query the pressure of the cluster")
+		} else if value >= m.threshold {
+			m.Signal(cluster, fmt.Sprintf("pressure query yields %v", value))
+			return
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	m.update(util.EnsureSuffix(cluster, "/"), "")
+}
+
+// update determines whether the cluster is under pressure, auditing and recording changes. It needs to be called
+// with the lock held.
+func (m *Monitor) update(cluster string, reason string) bool {
+	signalled, ok := m.signals[cluster]
+	underPressure := ok && m.clock.Since(signalled) < m.relaxAfter
+	if underPressure == m.underPressure[cluster] {
+		return underPressure
+	}
+
+	m.underPressure[cluster] = underPressure
+	Recorder.RecordClusterPressure(cluster, underPressure)
+	log := auditLogger.WithFields(logrus.Fields{"cluster": cluster, "pressure": underPressure})
+	if underPressure {
+		log.WithFields(logrus.Fields{"reason": reason, "idle_after": m.idleAfter}).Warn(
+			"Cluster is under resource pressure, shortening idle timeouts")
+	} else {
+		log.WithField("last_signal", signalled).Info(
+			"Resource pressure of cluster got relieved, restoring idle timeouts")
+	}
+	return underPressure
+}
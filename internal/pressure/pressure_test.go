@@ -0,0 +1,114 @@
+package pressure
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type pressureRecorder struct {
+	metric.PrometheusRecorder
+	sync.Mutex
+	changes []bool
+}
+
+func (r *pressureRecorder) RecordClusterPressure(cluster string, underPressure bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.changes = append(r.changes, underPressure)
+}
+
+func (r *pressureRecorder) recorded() []bool {
+	r.Lock()
+	defer r.Unlock()
+	return append([]bool{}, r.changes...)
+}
+
+func Test_nil_monitor_reports_no_pressure(t *testing.T) {
+	m := New(&mock.Config{}, clock.New())
+	assert.Nil(t, m, "Monitor should be nil unless adaptive idling is enabled")
+
+	m.Signal("https://api.example.com/", "test")
+	assert.Equal(t, time.Duration(0), m.IdleAfter("https://api.example.com/"))
+}
+
+func Test_signal_shortens_idle_timeout_until_relieved(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(os.Stderr)
+	recorder := &pressureRecorder{}
+	Recorder = recorder
+	defer func() { Recorder = metric.PrometheusRecorder{} }()
+
+	c := clock.NewFake(time.Now())
+	m := New(&mock.Config{AdaptiveIdling: true, PressureIdleAfter: 15, PressureRelaxAfter: 10}, c)
+
+	assert.Equal(t, time.Duration(0), m.IdleAfter("https://api.example.com"), "Cluster should not be under pressure")
+
+	m.Signal("https://api.example.com", "un-idle refused")
+	assert.Equal(t, 15*time.Minute, m.IdleAfter("https://api.example.com/"), "Cluster should be under pressure")
+	assert.Equal(t, time.Duration(0), m.IdleAfter("https://api.other.com/"), "Other clusters should not be affected")
+
+	c.Advance(9 * time.Minute)
+	m.Signal("https://api.example.com/", "un-idle refused")
+	c.Advance(9 * time.Minute)
+	assert.Equal(t, 15*time.Minute, m.IdleAfter("https://api.example.com/"), "Renewed signal should extend the pressure")
+
+	c.Advance(time.Minute)
+	assert.Equal(t, time.Duration(0), m.IdleAfter("https://api.example.com/"), "Pressure should be relieved")
+	assert.Equal(t, []bool{true, false}, recorder.recorded(), "Only changes should be recorded")
+}
+
+func Test_pressure_query(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+	defer logrus.SetOutput(os.Stderr)
+	recorder := &pressureRecorder{}
+	Recorder = recorder
+	defer func() { Recorder = metric.PrometheusRecorder{} }()
+
+	value := "0.95"
+	queries := make(chan string, 10)
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query().Get("query")
+		fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [{"value": [1523434035.5, "%s"]}]}}`, value)
+	}))
+	defer prometheus.Close()
+
+	c := clock.NewFake(time.Now())
+	m := New(&mock.Config{
+		AdaptiveIdling:     true,
+		PressureIdleAfter:  15,
+		PressureRelaxAfter: 5,
+		PrometheusURL:      prometheus.URL,
+		PressureQuery:      `memory_ratio{cluster="{{cluster}}"}`,
+		PressureThreshold:  0.9,
+	}, c)
+
+	m.check("https://api.example.com/")
+	assert.Equal(t, `memory_ratio{cluster="https://api.example.com/"}`, <-queries)
+	assert.Equal(t, 15*time.Minute, m.IdleAfter("https://api.example.com/"), "Query reaching threshold should signal pressure")
+
+	value = "0.5"
+	c.Advance(5 * time.Minute)
+	m.check("https://api.example.com/")
+	<-queries
+	assert.Equal(t, []bool{true, false}, recorder.recorded(), "Check should detect the relieved pressure")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	m.Start(ctx, wg, []string{"https://api.example.com/"})
+	<-queries
+	cancel()
+	wg.Wait()
+}
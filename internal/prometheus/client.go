@@ -0,0 +1,74 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryResponse is the response of the Prometheus instant query API, limited to vector results.
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Client evaluates PromQL queries against a Prometheus instance.
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient creates a Client querying the Prometheus instance at the given URL.
+func NewClient(prometheusURL string) *Client {
+	return &Client{
+		url:        strings.TrimSuffix(prometheusURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Sum evaluates the instant query and returns the sum of the samples it yields. The query needs to yield a vector.
+func (c *Client) Sum(query string) (float64, error) {
+	resp, err := c.httpClient.Get(c.url + "/api/v1/query?query=" + url.QueryEscape(query))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	response := queryResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("got status %s and unreadable body: %s", resp.Status, err)
+	}
+	if response.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", response.Error)
+	}
+	if response.Data.ResultType != "vector" {
+		return 0, fmt.Errorf("query yields %s instead of vector", response.Data.ResultType)
+	}
+
+	sum := 0.0
+	for _, sample := range response.Data.Result {
+		if len(sample.Value) != 2 {
+			return 0, fmt.Errorf("malformed sample %v", sample.Value)
+		}
+		value, ok := sample.Value[1].(string)
+		if !ok {
+			return 0, fmt.Errorf("malformed sample %v", sample.Value)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, err
+		}
+		sum += v
+	}
+	return sum, nil
+}
@@ -0,0 +1,39 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sum(t *testing.T) {
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, "up", r.URL.Query().Get("query"))
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL + "/")
+
+	body = `{"status": "success", "data": {"resultType": "vector", "result": [{"value": [1523434035.5, "0.25"]}, {"value": [1523434035.5, "0.5"]}]}}`
+	sum, err := client.Sum("up")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, sum, "Samples should be summed up")
+
+	body = `{"status": "success", "data": {"resultType": "vector", "result": []}}`
+	sum, err = client.Sum("up")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, sum, "Empty vector should sum up to zero")
+
+	body = `{"status": "success", "data": {"resultType": "scalar", "result": [1523434035.5, "1"]}}`
+	_, err = client.Sum("up")
+	assert.Error(t, err, "Scalar results should be rejected")
+
+	body = `{"status": "error", "error": "parse error"}`
+	_, err = client.Sum("up")
+	assert.Error(t, err, "Failed queries should return an error")
+}
@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+)
+
+// retention is the time after the end of its last window after which a caller is forgotten.
+const retention = time.Hour
+
+// Default is the Limiter of the public API, nil unless rate limiting is enabled.
+var Default *Limiter
+
+// Usage describes the requests of a caller counted by a Limiter.
+type Usage struct {
+	Caller    string    `json:"caller"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Requests  int64     `json:"requests"`
+	Limited   int64     `json:"limited"`
+}
+
+// caller tracks the requests of a caller.
+type caller struct {
+	windowEnd time.Time
+	count     int
+	requests  int64
+	limited   int64
+}
+
+// Limiter allows each caller a fixed number of requests per window, so that a single client polling too eagerly
+// cannot starve the others. The windows of the callers start with their first request. A nil Limiter allows all
+// requests.
+type Limiter struct {
+	sync.Mutex
+	limit     int
+	window    time.Duration
+	clock     clock.Clock
+	callers   map[string]*caller
+	lastSweep time.Time
+}
+
+// New creates a Limiter as configured. It returns nil if rate limiting is disabled.
+func New(config configuration.Configuration, clock clock.Clock) *Limiter {
+	if config.GetRateLimit() == 0 || config.GetRateLimitWindow() == 0 {
+		return nil
+	}
+	return NewLimiter(config.GetRateLimit(), time.Duration(config.GetRateLimitWindow())*time.Second, clock)
+}
+
+// NewLimiter creates a Limiter allowing each caller the given number of requests per window.
+func NewLimiter(limit int, window time.Duration, clock clock.Clock) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		window:    window,
+		clock:     clock,
+		callers:   make(map[string]*caller),
+		lastSweep: clock.Now(),
+	}
+}
+
+// Allow counts a request of the given caller. It returns the usage of the caller including the request and whether
+// the request is within the limit.
+func (l *Limiter) Allow(name string) (Usage, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	c, ok := l.callers[name]
+	if !ok {
+		c = &caller{}
+		l.callers[name] = c
+	}
+	if !now.Before(c.windowEnd) {
+		c.windowEnd = now.Add(l.window)
+		c.count = 0
+	}
+
+	c.count++
+	c.requests++
+	allowed := c.count <= l.limit
+	if !allowed {
+		c.limited++
+	}
+	return l.usage(name, c, now), allowed
+}
+
+// RetryAfter returns the time until the quota of the given usage is full again, as of the clock of the Limiter.
+func (l *Limiter) RetryAfter(usage Usage) time.Duration {
+	return usage.Reset.Sub(l.clock.Now())
+}
+
+// Usage returns the usage of the callers seen within the last hour, ordered by caller.
+func (l *Limiter) Usage() []Usage {
+	if l == nil {
+		return []Usage{}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.clock.Now()
+	usages := make([]Usage, 0, len(l.callers))
+	for name, c := range l.callers {
+		usages = append(usages, l.usage(name, c, now))
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Caller < usages[j].Caller })
+	return usages
+}
+
+// Limit returns the number of requests allowed per window.
+func (l *Limiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return l.limit
+}
+
+// Window returns the window the requests are counted in.
+func (l *Limiter) Window() time.Duration {
+	if l == nil {
+		return 0
+	}
+	return l.window
+}
+
+// usage returns the usage of the caller as of the given time. The quota of a caller whose window ended is full.
+func (l *Limiter) usage(name string, c *caller, now time.Time) Usage {
+	usage := Usage{
+		Caller:    name,
+		Limit:     l.limit,
+		Remaining: l.limit,
+		Reset:     now.Add(l.window).UTC(),
+		Requests:  c.requests,
+		Limited:   c.limited,
+	}
+	if now.Before(c.windowEnd) {
+		usage.Reset = c.windowEnd.UTC()
+		if c.count < l.limit {
+			usage.Remaining = l.limit - c.count
+		} else {
+			usage.Remaining = 0
+		}
+	}
+	return usage
+}
+
+// sweep forgets the callers whose last window ended more than the retention ago, at most once per retention.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < retention {
+		return
+	}
+	for name, c := range l.callers {
+		if now.Sub(c.windowEnd) > retention {
+			delete(l.callers, name)
+		}
+	}
+	l.lastSweep = now
+}
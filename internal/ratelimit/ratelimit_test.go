@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func Test_new_returns_nil_if_disabled(t *testing.T) {
+	assert.Nil(t, New(&mock.Config{RateLimitWindow: 60}, clock.New()))
+	assert.Nil(t, New(&mock.Config{RateLimit: 10}, clock.New()))
+
+	var l *Limiter
+	assert.Equal(t, []Usage{}, l.Usage())
+	assert.Equal(t, 0, l.Limit())
+	assert.Equal(t, time.Duration(0), l.Window())
+}
+
+func Test_allow(t *testing.T) {
+	fake := clock.NewFake(start)
+	l := New(&mock.Config{RateLimit: 2, RateLimitWindow: 60}, fake)
+
+	usage, allowed := l.Allow("10.0.0.1")
+	assert.True(t, allowed)
+	assert.Equal(t, Usage{Caller: "10.0.0.1", Limit: 2, Remaining: 1, Reset: start.Add(time.Minute), Requests: 1}, usage)
+
+	fake.Advance(30 * time.Second)
+	_, allowed = l.Allow("10.0.0.1")
+	assert.True(t, allowed)
+	usage, allowed = l.Allow("10.0.0.1")
+	assert.False(t, allowed, "Requests exceeding the limit should not be allowed")
+	assert.Equal(t, Usage{Caller: "10.0.0.1", Limit: 2, Remaining: 0, Reset: start.Add(time.Minute), Requests: 3, Limited: 1}, usage)
+
+	_, allowed = l.Allow("10.0.0.2")
+	assert.True(t, allowed, "Callers should be limited independently")
+
+	fake.Advance(30 * time.Second)
+	usage, allowed = l.Allow("10.0.0.1")
+	assert.True(t, allowed, "The quota should be restored once the window ended")
+	assert.Equal(t, Usage{Caller: "10.0.0.1", Limit: 2, Remaining: 1, Reset: start.Add(2 * time.Minute), Requests: 4, Limited: 1}, usage)
+}
+
+func Test_usage(t *testing.T) {
+	fake := clock.NewFake(start)
+	l := NewLimiter(2, time.Minute, fake)
+	l.Allow("10.0.0.2")
+	l.Allow("10.0.0.1")
+
+	fake.Advance(time.Minute)
+	assert.Equal(t, []Usage{
+		{Caller: "10.0.0.1", Limit: 2, Remaining: 2, Reset: start.Add(2 * time.Minute), Requests: 1},
+		{Caller: "10.0.0.2", Limit: 2, Remaining: 2, Reset: start.Add(2 * time.Minute), Requests: 1},
+	}, l.Usage(), "The quota of callers whose window ended should be full")
+
+	fake.Advance(time.Hour + time.Second)
+	l.Allow("10.0.0.1")
+	usages := l.Usage()
+	assert.Len(t, usages, 1, "Callers idle for more than the retention should be forgotten")
+	assert.Equal(t, "10.0.0.1", usages[0].Caller)
+}
@@ -0,0 +1,34 @@
+package recovery
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	log "github.com/sirupsen/logrus"
+)
+
+var logger = log.WithFields(log.Fields{"component": "recovery"})
+
+var (
+	// Recorder to count recovered panics
+	Recorder metric.Recorder = metric.PrometheusRecorder{}
+)
+
+// Guard calls fn and recovers from a panic raised by it. The panic gets logged together with its stack trace
+// and counted against the given source. If fn panics, an error describing the panic is returned, otherwise
+// the error returned by fn.
+func Guard(source string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.WithFields(log.Fields{
+				"source": source,
+				"panic":  fmt.Sprintf("%v", r),
+				"stack":  string(debug.Stack()),
+			}).Error("Recovered from panic")
+			Recorder.RecordPanic(source)
+			err = fmt.Errorf("recovered from panic in %s: %v", source, r)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,89 @@
+package recovery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingRecorder struct {
+	panics map[string]int
+}
+
+func (r *countingRecorder) Initialize() {}
+
+func (r *countingRecorder) RecordReqDuration(jenkinsService, operation string, code int, elapsedTime float64) {
+}
+
+func (r *countingRecorder) RecordHTTPRequest(route, method string, code int, elapsedTime float64) {}
+
+func (r *countingRecorder) RecordPanic(source string) {
+	r.panics[source]++
+}
+
+func (r *countingRecorder) RecordStateTransition(from, to, event, variant string) {}
+
+func (r *countingRecorder) RecordIdleDuration(variant string, elapsedTime float64) {}
+
+func (r *countingRecorder) RecordEviction(state string) {}
+
+func (r *countingRecorder) RecordIdleQueued(cluster string) {}
+
+func (r *countingRecorder) RecordIdleDequeued(cluster string) {}
+
+func (r *countingRecorder) RecordTenantLookup(pages int, failed bool, elapsedTime float64) {}
+
+func (r *countingRecorder) RecordChannelBacklog(namespace string, backlog int) {}
+
+func (r *countingRecorder) RecordChannelDiscard(cluster string) {}
+
+func (r *countingRecorder) RecordChannelRemoved(namespace string) {}
+
+func (r *countingRecorder) RecordToggleEvaluation(feature, outcome string) {}
+
+func (r *countingRecorder) RecordClusterPressure(cluster string, underPressure bool) {}
+
+func (r *countingRecorder) RecordPressureIdle(cluster string) {}
+
+func (r *countingRecorder) RecordTokenExpiry(cluster string, seconds float64) {}
+
+func (r *countingRecorder) RecordRejectedEvent(cluster, resource, reason string)                   {}
+func (r *countingRecorder) RecordClusterWatch(cluster string, running bool)                        {}
+func (r *countingRecorder) RecordStateDrift(cluster, believed, observed string)                    {}
+func (r *countingRecorder) RecordControllerEvent(cluster, resource string, elapsedTime float64)    {}
+func (r *countingRecorder) RecordIgnoredEvent(cluster, resource, reason string)                    {}
+func (r *countingRecorder) RecordTenantLookupFailure(cluster string)                               {}
+func (r *countingRecorder) RecordJenkinsResources(namespace, resource, kind string, value float64) {}
+func (r *countingRecorder) RecordJenkinsResourcesRemoved(namespace string)                         {}
+
+func Test_guard_recovers_from_panic(t *testing.T) {
+	recorder := &countingRecorder{panics: map[string]int{}}
+	Recorder = recorder
+
+	testLogger, hook := test.NewNullLogger()
+	logger = testLogger.WithField("component", "recovery")
+
+	err := Guard("controller", func() error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+
+	assert.EqualError(t, err, "recovered from panic in controller: assignment to entry in nil map")
+	assert.Equal(t, 1, recorder.panics["controller"], "panic should have been counted")
+	assert.Equal(t, "Recovered from panic", hook.LastEntry().Message)
+	assert.Contains(t, hook.LastEntry().Data["stack"], "recovery.Test_guard_recovers_from_panic")
+}
+
+func Test_guard_passes_through_error(t *testing.T) {
+	recorder := &countingRecorder{panics: map[string]int{}}
+	Recorder = recorder
+
+	err := Guard("controller", func() error { return errors.New("failed") })
+	assert.EqualError(t, err, "failed")
+	assert.Empty(t, recorder.panics)
+
+	assert.NoError(t, Guard("controller", func() error { return nil }))
+}
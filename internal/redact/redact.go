@@ -0,0 +1,60 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces redacted secrets.
+const Mask = "***"
+
+// minSecretLength is the minimal length of registered secrets, so that short values like test tokens do not cause
+// arbitrary words to be redacted.
+const minSecretLength = 8
+
+var patterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// Authorization headers, e.g. as echoed in a dumped request, a header map or a log field
+	{regexp.MustCompile(`(?i)(authorization"?\s*[:=]\s*\[?"?)(?:bearer\s+|basic\s+)?[^\s",\]}]+`), "${1}" + Mask},
+	// bearer tokens
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Mask},
+	// tokens passed as query parameter
+	{regexp.MustCompile(`(?i)((?:access_)?token=)[^&\s"]+`), "${1}" + Mask},
+	// JWTs, e.g. service account tokens
+	{regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), Mask},
+}
+
+var secrets = struct {
+	sync.RWMutex
+	values map[string]bool
+}{values: make(map[string]bool)}
+
+// Register registers a secret, e.g. the token of a cluster, to be redacted wherever it occurs verbatim.
+func Register(secret string) {
+	if len(secret) < minSecretLength {
+		return
+	}
+	secrets.Lock()
+	secrets.values[secret] = true
+	secrets.Unlock()
+}
+
+// String returns the given string with bearer tokens, Authorization headers, JWTs and registered secrets replaced
+// by the mask.
+func String(s string) string {
+	secrets.RLock()
+	for secret := range secrets.values {
+		if strings.Contains(s, secret) {
+			s = strings.Replace(s, secret, Mask, -1)
+		}
+	}
+	secrets.RUnlock()
+
+	for _, p := range patterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
@@ -0,0 +1,29 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_string(t *testing.T) {
+	tests := map[string]string{
+		"Authorization: Bearer abc.def-ghi":                  "Authorization: ***",
+		`map[Authorization:[Bearer abc] Accept:[*/*]]`:       `map[Authorization:[***] Accept:[*/*]]`,
+		`{"authorization": "Basic dXNlcjpwYXNz"}`:            `{"authorization": "***"}`,
+		"unable to use bearer abc123 for the cluster":        "unable to use bearer *** for the cluster",
+		"GET /api?watch=true&access_token=abc123 failed":     "GET /api?watch=true&access_token=*** failed",
+		"invalid token eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOjF9.c2": "invalid token ***",
+		"Jenkins is idled":                                   "Jenkins is idled",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, String(input), "Unexpected redaction of %s", input)
+	}
+}
+
+func Test_registered_secrets_are_redacted(t *testing.T) {
+	Register("cluster-token-1234")
+	Register("short")
+
+	assert.Equal(t, "whoami failed for *** on short", String("whoami failed for cluster-token-1234 on short"))
+}
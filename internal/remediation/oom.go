@@ -0,0 +1,216 @@
+package remediation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ActionRecommendMemoryLimit is the action of recommending an increase of the memory limit of Jenkins.
+	ActionRecommendMemoryLimit = "recommend-memory-limit"
+
+	// ActionBumpMemoryLimit is the action of increasing the memory limit of Jenkins.
+	ActionBumpMemoryLimit = "bump-memory-limit"
+
+	mebibyte = 1 << 20
+)
+
+// DefaultAdvisor is the Advisor used by the Idler, nil unless recommendations are enabled.
+var DefaultAdvisor *Advisor
+
+// Recommendation recommends increasing the memory limit of a Jenkins which repeatedly got killed for running out of
+// memory.
+type Recommendation struct {
+	Namespace      string    `json:"namespace"`
+	Cluster        string    `json:"cluster"`
+	OOMKills       int       `json:"oom_kills"`
+	CurrentLimit   int64     `json:"current_limit_bytes"`
+	SuggestedLimit int64     `json:"suggested_limit_bytes"`
+	Applied        bool      `json:"applied"`
+	Error          string    `json:"error,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Advisor counts the OOM kills of the Jenkins instances and recommends a bounded increase of the memory limit of
+// those which keep getting killed, applying it to their deployment config if enabled for the user. A nil Advisor
+// never recommends anything.
+type Advisor struct {
+	sync.Mutex
+	threshold       int
+	window          time.Duration
+	increase        int64
+	maxLimit        int64
+	clock           clock.Clock
+	bumps           sync.WaitGroup
+	kills           map[string][]time.Time
+	counted         map[string]string
+	recommendations map[string]Recommendation
+}
+
+// NewAdvisor creates an Advisor as configured. It returns nil if the recommendations are disabled.
+func NewAdvisor(config configuration.Configuration, clock clock.Clock) *Advisor {
+	if config.GetOOMKillThreshold() == 0 {
+		return nil
+	}
+	return &Advisor{
+		threshold:       config.GetOOMKillThreshold(),
+		window:          time.Duration(config.GetOOMKillWindow()) * time.Minute,
+		increase:        int64(config.GetOOMLimitIncrease()),
+		maxLimit:        int64(config.GetOOMMaxMemoryLimit()) * mebibyte,
+		clock:           clock,
+		kills:           make(map[string][]time.Time),
+		counted:         make(map[string]string),
+		recommendations: make(map[string]Recommendation),
+	}
+}
+
+// OOMKilled records that the given Jenkins pod of the given service got killed for running out of memory, the pods
+// being limited to the given number of bytes of memory, 0 if unknown. Each kill, i.e. restart of a pod, is counted
+// once, however often it is observed. Once Jenkins got killed the configured number of times within the window, an
+// increase of the limit by the configured percentage, bounded by the maximum limit, is recommended and the kills
+// counted so far are forgotten. If bump is set, the increase is applied to the deployment config of the service in
+// the background, so that the caller, e.g. the watch of the pods, is not blocked, and the recommendation is updated
+// once it got applied. It returns the recommendation, if any.
+func (a *Advisor) OOMKilled(oc client.OpenShiftClient, apiURL, bearerToken, service string, pod model.Pod, limit int64, bump bool) (Recommendation, bool) {
+	if a == nil || !pod.Restarts.OOMKilled {
+		return Recommendation{}, false
+	}
+
+	namespace := pod.Namespace
+	now := a.clock.Now().UTC()
+	kills, ok := a.count(namespace, fmt.Sprintf("%s/%d", pod.Name, pod.Restarts.Count), now)
+	if !ok {
+		return Recommendation{}, false
+	}
+
+	log := auditLogger.WithFields(logrus.Fields{"namespace": namespace, "cluster": apiURL, "oom_kills": kills})
+	if limit == 0 {
+		log.Warnf("Jenkins in %s got killed %d times for running out of memory without known memory limit", namespace, kills)
+		return Recommendation{}, false
+	}
+	suggested := a.suggest(limit)
+	if suggested <= limit {
+		log.WithField("limit", limit).Warnf("Jenkins in %s got killed %d times for running out of memory at the maximum memory limit", namespace, kills)
+		return Recommendation{}, false
+	}
+
+	recommendation := Recommendation{
+		Namespace:      namespace,
+		Cluster:        apiURL,
+		OOMKills:       kills,
+		CurrentLimit:   limit,
+		SuggestedLimit: suggested,
+		Timestamp:      now,
+	}
+	log = log.WithFields(logrus.Fields{"limit": limit, "suggested_limit": suggested})
+	a.Lock()
+	a.recommendations[namespace] = recommendation
+	a.Unlock()
+
+	if !bump {
+		log.WithField("action", ActionRecommendMemoryLimit).Warnf("Memory limit of Jenkins in %s should be raised from %dMi to %dMi after %d OOM kills",
+			namespace, limit/mebibyte, suggested/mebibyte, kills)
+		return recommendation, true
+	}
+
+	a.bumps.Add(1)
+	go func() {
+		defer a.bumps.Done()
+		a.bump(oc, bearerToken, service, recommendation, log.WithField("action", ActionBumpMemoryLimit))
+	}()
+	return recommendation, true
+}
+
+// bump applies the given recommendation to the deployment config of the service, recording whether it got applied.
+func (a *Advisor) bump(oc client.OpenShiftClient, bearerToken, service string, recommendation Recommendation, log *logrus.Entry) {
+	err := oc.SetMemoryLimit(recommendation.Cluster, bearerToken, recommendation.Namespace, service, recommendation.SuggestedLimit)
+	if err != nil {
+		recommendation.Error = err.Error()
+		log.WithFields(logrus.Fields{"applied": false, "err": err}).Errorf("Memory limit of Jenkins in %s should be raised from %dMi to %dMi after %d OOM kills, raising it failed",
+			recommendation.Namespace, recommendation.CurrentLimit/mebibyte, recommendation.SuggestedLimit/mebibyte, recommendation.OOMKills)
+	} else {
+		recommendation.Applied = true
+		log.WithField("applied", true).Warnf("Raised memory limit of Jenkins in %s from %dMi to %dMi after %d OOM kills",
+			recommendation.Namespace, recommendation.CurrentLimit/mebibyte, recommendation.SuggestedLimit/mebibyte, recommendation.OOMKills)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	// a later recommendation supersedes this one
+	if a.recommendations[recommendation.Namespace].Timestamp.Equal(recommendation.Timestamp) {
+		a.recommendations[recommendation.Namespace] = recommendation
+	}
+}
+
+// Wait waits for the memory limit increases being applied.
+func (a *Advisor) Wait() {
+	if a != nil {
+		a.bumps.Wait()
+	}
+}
+
+// count records the OOM kill of the Jenkins in the namespace identified by the given key at the given time, unless
+// it got counted before. It returns the number of kills within the window and whether it reached the threshold, in
+// which case the kills are forgotten.
+func (a *Advisor) count(namespace string, kill string, now time.Time) (int, bool) {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.counted[namespace] == kill {
+		return len(a.kills[namespace]), false
+	}
+	a.counted[namespace] = kill
+
+	kills := []time.Time{}
+	for _, t := range a.kills[namespace] {
+		if now.Sub(t) < a.window {
+			kills = append(kills, t)
+		}
+	}
+	kills = append(kills, now)
+
+	if len(kills) < a.threshold {
+		a.kills[namespace] = kills
+		return len(kills), false
+	}
+	delete(a.kills, namespace)
+	return len(kills), true
+}
+
+// suggest returns the given limit increased by the configured percentage, rounded up to full MiB and bounded by
+// the maximum limit.
+func (a *Advisor) suggest(limit int64) int64 {
+	suggested := limit + limit*a.increase/100
+	suggested = (suggested + mebibyte - 1) / mebibyte * mebibyte
+	if suggested > a.maxLimit {
+		return a.maxLimit
+	}
+	return suggested
+}
+
+// Recommendations returns the latest recommendation per namespace, ordered by namespace.
+func (a *Advisor) Recommendations() []Recommendation {
+	if a == nil {
+		return []Recommendation{}
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	recommendations := make([]Recommendation, 0, len(a.recommendations))
+	for _, recommendation := range a.recommendations {
+		recommendations = append(recommendations, recommendation)
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].Namespace < recommendations[j].Namespace
+	})
+	return recommendations
+}
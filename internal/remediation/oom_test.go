@@ -0,0 +1,103 @@
+package remediation
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oomKilledPod returns a Jenkins pod in foo-jenkins which got OOMKilled after the given number of restarts.
+func oomKilledPod(restarts int) model.Pod {
+	return model.Pod{
+		Name:      "jenkins-1-abcde",
+		Namespace: "foo-jenkins",
+		Restarts:  model.PodRestarts{Count: restarts, OOMKilled: true},
+	}
+}
+
+func Test_new_advisor_returns_nil_if_disabled(t *testing.T) {
+	a := NewAdvisor(&mock.Config{}, clock.NewFake(now))
+	assert.Nil(t, a)
+
+	_, ok := a.OOMKilled(&mock.OpenShiftClient{}, "", "", "jenkins", oomKilledPod(1), 1<<30, false)
+	assert.False(t, ok, "Disabled advisor should never recommend")
+	assert.Empty(t, a.Recommendations())
+}
+
+func Test_advisor_recommends_bounded_memory_limit(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	fake := clock.NewFake(now)
+	a := NewAdvisor(&mock.Config{OOMKillThreshold: 3, OOMKillWindow: 60, OOMLimitIncrease: 50, OOMMaxMemoryLimit: 2048}, fake)
+	oc := &mock.OpenShiftClient{}
+	restarts := 0
+	kill := func(limit int64) (Recommendation, bool) {
+		restarts++
+		return a.OOMKilled(oc, "https://api.cluster", "", "jenkins", oomKilledPod(restarts), limit, false)
+	}
+
+	_, ok := kill(1 << 30)
+	assert.False(t, ok)
+	_, ok = a.OOMKilled(oc, "https://api.cluster", "", "jenkins", oomKilledPod(restarts), 1<<30, false)
+	assert.False(t, ok, "Observing the same kill again should not count")
+	fake.Advance(61 * time.Minute)
+	_, ok = kill(1 << 30)
+	assert.False(t, ok)
+	_, ok = kill(1 << 30)
+	assert.False(t, ok, "Kills outside of the window should not be counted")
+
+	recommendation, ok := kill(1 << 30)
+	require.True(t, ok)
+	assert.Equal(t, Recommendation{
+		Namespace:      "foo-jenkins",
+		Cluster:        "https://api.cluster",
+		OOMKills:       3,
+		CurrentLimit:   1 << 30,
+		SuggestedLimit: 1536 << 20,
+		Timestamp:      fake.Now(),
+	}, recommendation)
+	assert.Nil(t, oc.MemoryLimits, "Recommendations should not be applied unless enabled")
+	assert.Equal(t, []Recommendation{recommendation}, a.Recommendations())
+
+	for i := 0; i < 2; i++ {
+		_, ok = kill(1536 << 20)
+		assert.False(t, ok, "Kills counted for a recommendation should be forgotten")
+	}
+	recommendation, ok = kill(1536 << 20)
+	require.True(t, ok)
+	assert.Equal(t, int64(2048<<20), recommendation.SuggestedLimit, "Increases should be bounded by the maximum limit")
+
+	for i := 0; i < 3; i++ {
+		_, ok = kill(2048 << 20)
+	}
+	assert.False(t, ok, "No increase should be recommended at the maximum limit")
+	assert.Equal(t, []Recommendation{recommendation}, a.Recommendations())
+}
+
+func Test_advisor_applies_memory_limit_if_enabled(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	a := NewAdvisor(&mock.Config{OOMKillThreshold: 1, OOMKillWindow: 60, OOMLimitIncrease: 25, OOMMaxMemoryLimit: 4096},
+		clock.NewFake(now))
+	oc := &mock.OpenShiftClient{}
+
+	_, ok := a.OOMKilled(oc, "https://api.cluster", "", "jenkins", oomKilledPod(1), 0, true)
+	assert.False(t, ok, "No increase should be recommended without known limit")
+
+	recommendation, ok := a.OOMKilled(oc, "https://api.cluster", "", "jenkins", oomKilledPod(2), 1000<<20, true)
+	require.True(t, ok)
+	assert.False(t, recommendation.Applied, "The increase should be applied in the background")
+	assert.Equal(t, int64(1250<<20), recommendation.SuggestedLimit)
+
+	a.Wait()
+	assert.Equal(t, map[string]int64{"foo-jenkins": 1250 << 20}, oc.MemoryLimits)
+	recommendation.Applied = true
+	assert.Equal(t, []Recommendation{recommendation}, a.Recommendations())
+}
@@ -0,0 +1,135 @@
+package remediation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ActionReset is the action of deleting the Jenkins pod so that a new one gets started.
+	ActionReset = "reset"
+
+	webhookTimeout = 10 * time.Second
+)
+
+var (
+	logger      = logrus.WithField("component", "remediation")
+	auditLogger = logrus.WithField("component", "audit")
+)
+
+// Action describes a remediation performed on a Jenkins instance. It is sent as JSON to the notification webhook.
+type Action struct {
+	Action    string    `json:"action"`
+	Reason    string    `json:"reason"`
+	Namespace string    `json:"namespace"`
+	Cluster   string    `json:"cluster"`
+	Restarts  int       `json:"restarts"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Remediator resets Jenkins pods which keep crashing or running out of memory, so that tenants do not stay
+// broken until someone files a ticket. A nil Remediator never remediates.
+type Remediator struct {
+	maxRestarts int
+	gracePeriod time.Duration
+	webhookURL  string
+	httpClient  *http.Client
+	clock       clock.Clock
+}
+
+// New creates a Remediator as configured. It returns nil if remediation is disabled.
+func New(config configuration.Configuration, clock clock.Clock) *Remediator {
+	if !config.GetRemediationEnabled() {
+		return nil
+	}
+	return &Remediator{
+		maxRestarts: config.GetRemediationMaxRestarts(),
+		gracePeriod: time.Duration(config.GetResetGracePeriod()) * time.Second,
+		webhookURL:  config.GetRemediationWebhookURL(),
+		httpClient:  &http.Client{Timeout: webhookTimeout},
+		clock:       clock,
+	}
+}
+
+// Remediate resets the pods of the given service if they restarted more than the configured number of times while
+// crash-looping or after being killed for running out of memory. It returns whether the pods got reset.
+func (r *Remediator) Remediate(oc client.OpenShiftClient, apiURL, bearerToken, namespace, service string, state model.PodState) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+
+	restarts, err := oc.Restarts(apiURL, bearerToken, namespace, service)
+	if err != nil {
+		return false, err
+	}
+
+	reason := ""
+	switch {
+	case restarts.Count <= r.maxRestarts:
+		return false, nil
+	case restarts.OOMKilled:
+		reason = "OOMKilled"
+	case state == model.PodCrashLooping:
+		reason = "CrashLoopBackOff"
+	default:
+		return false, nil
+	}
+
+	// the user-idler is not blocked waiting for the replacement pods, it observes them via its next check
+	if err := oc.Reset(apiURL, bearerToken, namespace, service, client.ResetOptions{GracePeriod: r.gracePeriod}); err != nil {
+		return false, err
+	}
+
+	action := Action{
+		Action:    ActionReset,
+		Reason:    reason,
+		Namespace: namespace,
+		Cluster:   apiURL,
+		Restarts:  restarts.Count,
+		Timestamp: r.clock.Now().UTC(),
+	}
+	auditLogger.WithFields(logrus.Fields{
+		"action":    action.Action,
+		"reason":    action.Reason,
+		"namespace": namespace,
+		"cluster":   apiURL,
+		"restarts":  restarts.Count,
+	}).Warnf("Reset Jenkins in %s after %d restarts (%s)", namespace, restarts.Count, reason)
+
+	if err := r.notify(action); err != nil {
+		logger.WithFields(logrus.Fields{"namespace": namespace, "err": err}).Error("Unable to notify webhook about remediation")
+	}
+	return true, nil
+}
+
+// notify posts the action to the configured webhook.
+func (r *Remediator) notify(action Action) error {
+	if r.webhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.httpClient.Post(r.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
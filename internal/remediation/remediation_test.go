@@ -0,0 +1,90 @@
+package remediation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+
+func Test_new_returns_nil_if_disabled(t *testing.T) {
+	r := New(&mock.Config{}, clock.NewFake(now))
+	assert.Nil(t, r)
+
+	reset, err := r.Remediate(&mock.OpenShiftClient{}, "", "", "foo-jenkins", "jenkins", model.PodCrashLooping)
+	assert.NoError(t, err)
+	assert.False(t, reset, "Disabled remediator should never reset")
+}
+
+func Test_remediate(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	tests := []struct {
+		name     string
+		restarts model.PodRestarts
+		state    model.PodState
+		reset    bool
+	}{
+		{name: "crash looping below max restarts", restarts: model.PodRestarts{Count: 3}, state: model.PodCrashLooping},
+		{name: "crash looping above max restarts", restarts: model.PodRestarts{Count: 4}, state: model.PodCrashLooping, reset: true},
+		{name: "oom killed above max restarts", restarts: model.PodRestarts{Count: 4, OOMKilled: true}, state: model.PodRunning, reset: true},
+		{name: "restarted above max restarts", restarts: model.PodRestarts{Count: 4}, state: model.PodRunning},
+	}
+
+	r := New(&mock.Config{RemediationEnabled: true, RemediationMaxRestart: 3}, clock.NewFake(now))
+	for _, test := range tests {
+		oc := &mock.OpenShiftClient{PodRestarts: test.restarts}
+		reset, err := r.Remediate(oc, "https://api.cluster", "", "foo-jenkins", "jenkins", test.state)
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.reset, reset, test.name)
+		if test.reset {
+			assert.Equal(t, 1, oc.ResetCallCount, test.name)
+		} else {
+			assert.Equal(t, 0, oc.ResetCallCount, test.name)
+		}
+	}
+}
+
+func Test_remediate_notifies_webhook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	actions := make(chan Action, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := Action{}
+		json.NewDecoder(r.Body).Decode(&action)
+		actions <- action
+	}))
+	defer webhook.Close()
+
+	config := &mock.Config{RemediationEnabled: true, RemediationMaxRestart: 3, RemediationWebhookURL: webhook.URL}
+	r := New(config, clock.NewFake(now))
+	oc := &mock.OpenShiftClient{PodRestarts: model.PodRestarts{Count: 5}}
+
+	reset, err := r.Remediate(oc, "https://api.cluster", "", "foo-jenkins", "jenkins", model.PodCrashLooping)
+	assert.NoError(t, err)
+	assert.True(t, reset)
+
+	select {
+	case action := <-actions:
+		assert.Equal(t, Action{
+			Action:    ActionReset,
+			Reason:    "CrashLoopBackOff",
+			Namespace: "foo-jenkins",
+			Cluster:   "https://api.cluster",
+			Restarts:  5,
+			Timestamp: now,
+		}, action)
+	default:
+		t.Error("Expected webhook to be notified")
+	}
+}
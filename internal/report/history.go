@@ -0,0 +1,191 @@
+package report
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+)
+
+const (
+	idledState   = "idled"
+	errorState   = "error"
+	unknownState = "unknown"
+
+	gibibyte = 1 << 30
+)
+
+// Digest summarizes the activity of the Idler within a period.
+type Digest struct {
+	Schedule         string      `json:"schedule"`
+	From             time.Time   `json:"from"`
+	To               time.Time   `json:"to"`
+	UnIdles          int         `json:"unidles"`
+	Idles            int         `json:"idles"`
+	Failures         int         `json:"failures"`
+	FailedNamespaces []string    `json:"failed_namespaces"`
+	CapacityRefusals int         `json:"capacity_refusals"`
+	NeverIdled       []Namespace `json:"never_idled"`
+	Savings          Savings     `json:"savings"`
+}
+
+// Namespace is a Jenkins namespace which kept running throughout the period of a digest.
+type Namespace struct {
+	Namespace     string    `json:"namespace"`
+	Cluster       string    `json:"cluster"`
+	CPURequest    float64   `json:"cpu_request_cores"`
+	MemoryRequest int64     `json:"memory_request_bytes"`
+	LastActivity  time.Time `json:"last_activity"`
+}
+
+// Savings estimates the resources freed by idling within the period of a digest, i.e. the resources requested by
+// the Jenkins instances multiplied by the time they stayed idled.
+type Savings struct {
+	IdledHours     float64 `json:"idled_hours"`
+	CPUCoreHours   float64 `json:"cpu_core_hours"`
+	MemoryGiBHours float64 `json:"memory_gib_hours"`
+}
+
+// History records the state changes of the Jenkins instances as well as the un-idles refused due to the capacity
+// of their cluster for the retention period, for the digests to be built from.
+type History struct {
+	sync.Mutex
+	retention time.Duration
+	changes   []events.Event
+	refusals  []time.Time
+}
+
+// NewHistory creates an empty History keeping the records for the given period.
+func NewHistory(retention time.Duration) *History {
+	return &History{retention: retention}
+}
+
+// Record records the state change of a Jenkins instance.
+func (h *History) Record(e events.Event) {
+	h.Lock()
+	defer h.Unlock()
+	h.changes = append(h.changes, e)
+	h.prune(e.Time)
+}
+
+// CapacityRefused records an un-idle refused at the given time due to the capacity of the cluster.
+func (h *History) CapacityRefused(t time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	h.refusals = append(h.refusals, t)
+	h.prune(t)
+}
+
+// prune drops the records older than the retention period as of the given time.
+func (h *History) prune(now time.Time) {
+	cutoff := now.Add(-h.retention)
+
+	i := 0
+	for i < len(h.changes) && h.changes[i].Time.Before(cutoff) {
+		i++
+	}
+	h.changes = h.changes[i:]
+
+	i = 0
+	for i < len(h.refusals) && h.refusals[i].Before(cutoff) {
+		i++
+	}
+	h.refusals = h.refusals[i:]
+}
+
+// Digest summarizes the records within the given period, listing up to top namespaces of the given user idlers which
+// never idled, those requesting the most memory first.
+func (h *History) Digest(from, to time.Time, userIdlers *openshift.UserIdlerMap, top int) Digest {
+	digest := Digest{From: from, To: to, FailedNamespaces: []string{}, NeverIdled: []Namespace{}}
+
+	h.Lock()
+	changes := make(map[string][]events.Event)
+	failed := make(map[string]bool)
+	for _, e := range h.changes {
+		if e.Time.Before(from) || !e.Time.Before(to) {
+			continue
+		}
+		changes[e.Namespace] = append(changes[e.Namespace], e)
+
+		switch {
+		case e.State == errorState:
+			digest.Failures++
+			failed[e.Namespace] = true
+		case e.Previous == idledState:
+			digest.UnIdles++
+		case e.State == idledState && e.Previous != unknownState:
+			digest.Idles++
+		}
+	}
+	for _, t := range h.refusals {
+		if !t.Before(from) && t.Before(to) {
+			digest.CapacityRefusals++
+		}
+	}
+	h.Unlock()
+
+	for namespace := range failed {
+		digest.FailedNamespaces = append(digest.FailedNamespaces, namespace)
+	}
+	sort.Strings(digest.FailedNamespaces)
+
+	userIdlers.Range(func(namespace string, userIdler *idler.UserIdler) bool {
+		idled := idledFor(changes[namespace], userIdler.State() == idler.StateIdled, from, to)
+		resources, _ := userIdler.Resources()
+		if idled > 0 {
+			hours := idled.Hours()
+			digest.Savings.IdledHours += hours
+			digest.Savings.CPUCoreHours += hours * resources.CPURequest
+			digest.Savings.MemoryGiBHours += hours * float64(resources.MemoryRequest) / gibibyte
+		} else if userIdler.State() == idler.StateRunning {
+			digest.NeverIdled = append(digest.NeverIdled, Namespace{
+				Namespace:     namespace,
+				Cluster:       userIdler.OpenShiftAPI(),
+				CPURequest:    resources.CPURequest,
+				MemoryRequest: resources.MemoryRequest,
+				LastActivity:  userIdler.LastActivity(),
+			})
+		}
+		return true
+	})
+
+	sort.Slice(digest.NeverIdled, func(i, j int) bool {
+		a, b := digest.NeverIdled[i], digest.NeverIdled[j]
+		if a.MemoryRequest != b.MemoryRequest {
+			return a.MemoryRequest > b.MemoryRequest
+		}
+		return a.Namespace < b.Namespace
+	})
+	if len(digest.NeverIdled) > top {
+		digest.NeverIdled = digest.NeverIdled[:top]
+	}
+	return digest
+}
+
+// idledFor returns how long a Jenkins stayed idled within the given period, given its state changes within the
+// period in chronological order and whether it is idled now.
+func idledFor(changes []events.Event, idledNow bool, from, to time.Time) time.Duration {
+	idled := idledNow
+	if len(changes) > 0 {
+		idled = changes[0].Previous == idledState
+	}
+
+	var total time.Duration
+	since := from
+	for _, e := range changes {
+		switch {
+		case !idled && e.State == idledState:
+			idled, since = true, e.Time
+		case idled && e.State != idledState:
+			idled = false
+			total += e.Time.Sub(since)
+		}
+	}
+	if idled {
+		total += to.Sub(since)
+	}
+	return total
+}
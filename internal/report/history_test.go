@@ -0,0 +1,90 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/idler"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const apiURL = "https://api.example.com/"
+
+var from = time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// newUserIdlers creates user idlers for the given users in the given states.
+func newUserIdlers(states map[string]model.PodState) *openshift.UserIdlerMap {
+	userIdlers := openshift.NewUserIdlerMap()
+	for name, state := range states {
+		userIdler := idler.NewUserIdler(model.NewUser(name, name), apiURL, "", &mock.Config{},
+			mock.NewMockFeatureToggle(nil), &mock.TenantService{}, clock.New())
+		userIdler.Observe(state)
+		userIdlers.Store(name+"-jenkins", userIdler)
+	}
+	return userIdlers
+}
+
+func Test_history_digest(t *testing.T) {
+	userIdlers := newUserIdlers(map[string]model.PodState{
+		"foo": model.PodRunning,
+		"bar": model.PodIdled,
+		"baz": model.PodIdled,
+		"qux": model.PodRunning,
+	})
+	foo, _ := userIdlers.Load("foo-jenkins")
+	foo.SetResources(model.Resources{CPURequest: 0.5, MemoryRequest: 2 << 30})
+	bar, _ := userIdlers.Load("bar-jenkins")
+	bar.SetResources(model.Resources{CPURequest: 1, MemoryRequest: 1 << 30})
+	qux, _ := userIdlers.Load("qux-jenkins")
+
+	h := NewHistory(24 * time.Hour)
+	change := func(namespace, previous, state string, at time.Duration) {
+		h.Record(events.Event{Namespace: namespace, Previous: previous, State: state, Time: from.Add(at)})
+	}
+	change("foo-jenkins", "idled", "starting", -time.Hour)
+	change("bar-jenkins", "running", "idled", 2*time.Hour)
+	change("bar-jenkins", "idled", "starting", 8*time.Hour)
+	change("bar-jenkins", "starting", "running", 8*time.Hour+5*time.Minute)
+	change("qux-jenkins", "running", "error", 10*time.Hour)
+	change("qux-jenkins", "error", "running", 10*time.Hour+time.Minute)
+	change("bar-jenkins", "running", "idled", 20*time.Hour)
+	h.CapacityRefused(from.Add(-time.Minute))
+	h.CapacityRefused(from.Add(9 * time.Hour))
+	h.CapacityRefused(from.Add(9*time.Hour + time.Minute))
+
+	digest := h.Digest(from, from.Add(24*time.Hour), userIdlers, 10)
+	assert.Equal(t, Digest{
+		From:             from,
+		To:               from.Add(24 * time.Hour),
+		UnIdles:          1,
+		Idles:            2,
+		Failures:         1,
+		FailedNamespaces: []string{"qux-jenkins"},
+		CapacityRefusals: 2,
+		NeverIdled: []Namespace{
+			{Namespace: "foo-jenkins", Cluster: apiURL, CPURequest: 0.5, MemoryRequest: 2 << 30, LastActivity: foo.LastActivity()},
+			{Namespace: "qux-jenkins", Cluster: apiURL, LastActivity: qux.LastActivity()},
+		},
+		Savings: Savings{IdledHours: 34, CPUCoreHours: 10, MemoryGiBHours: 10},
+	}, digest, "Changes before the period should be ignored")
+
+	digest = h.Digest(from, from.Add(24*time.Hour), userIdlers, 1)
+	assert.Len(t, digest.NeverIdled, 1, "Only the top namespaces should be listed")
+	assert.Equal(t, "foo-jenkins", digest.NeverIdled[0].Namespace, "Namespaces requesting the most memory should come first")
+}
+
+func Test_history_prunes_expired_records(t *testing.T) {
+	h := NewHistory(24 * time.Hour)
+	h.Record(events.Event{Namespace: "foo-jenkins", Previous: "running", State: "idled", Time: from})
+	h.CapacityRefused(from)
+	h.Record(events.Event{Namespace: "foo-jenkins", Previous: "idled", State: "starting", Time: from.Add(25 * time.Hour)})
+	h.CapacityRefused(from.Add(25 * time.Hour))
+
+	assert.Len(t, h.changes, 1)
+	assert.Len(t, h.refusals, 1)
+}
@@ -0,0 +1,245 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/notify"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Daily sends a digest of the previous day every midnight UTC.
+	Daily = "daily"
+	// Weekly sends a digest of the previous week every Monday at midnight UTC.
+	Weekly = "weekly"
+
+	webhookTimeout = 10 * time.Second
+)
+
+var logger = logrus.WithField("component", "report")
+
+// sendMail sends an email via SMTP, replaced in tests.
+var sendMail = smtp.SendMail
+
+// Default is the Reporter used by the Idler, nil unless digests are enabled.
+var Default *Reporter
+
+// Reporter sends a daily or weekly digest of the activity of the Idler to a Slack or generic webhook and by email,
+// so that operators learn how well idling works without watching the dashboards. A nil Reporter never reports.
+type Reporter struct {
+	schedule    string
+	top         int
+	history     *History
+	webhookURL  string
+	format      string
+	emailTo     []string
+	emailFrom   string
+	smtpAddress string
+	smtpAuth    smtp.Auth
+	httpClient  *http.Client
+	clock       clock.Clock
+	wg          sync.WaitGroup
+}
+
+// New creates a Reporter as configured. It returns nil if the digests are disabled.
+func New(config configuration.Configuration, clock clock.Clock) *Reporter {
+	schedule := config.GetDigestSchedule()
+	if schedule == "" {
+		return nil
+	}
+
+	r := &Reporter{
+		schedule:    schedule,
+		top:         config.GetDigestTop(),
+		history:     NewHistory(period(schedule)),
+		webhookURL:  config.GetDigestWebhookURL(),
+		format:      config.GetNotifyFormat(),
+		emailTo:     config.GetDigestEmailTo(),
+		emailFrom:   config.GetDigestEmailFrom(),
+		smtpAddress: config.GetDigestSMTPAddress(),
+		httpClient:  &http.Client{Timeout: webhookTimeout},
+		clock:       clock,
+	}
+	if username := config.GetDigestSMTPUsername(); username != "" {
+		host := strings.Split(r.smtpAddress, ":")[0]
+		r.smtpAuth = smtp.PlainAuth("", username, config.GetDigestSMTPPassword(), host)
+	}
+	if r.webhookURL == "" && len(r.emailTo) == 0 {
+		logger.Warn("Digests are enabled, but neither a webhook nor email recipients are configured")
+	}
+	return r
+}
+
+// period returns the period covered by the digests of the given schedule.
+func period(schedule string) time.Duration {
+	if schedule == Weekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// next returns the time the next digest of the given schedule is due after the given time.
+func next(schedule string, now time.Time) time.Time {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	if schedule == Weekly {
+		for midnight.Weekday() != time.Monday {
+			midnight = midnight.AddDate(0, 0, 1)
+		}
+	}
+	return midnight
+}
+
+// CapacityRefused records an un-idle refused due to the capacity of the cluster.
+func (r *Reporter) CapacityRefused() {
+	if r == nil {
+		return
+	}
+	r.history.CapacityRefused(r.clock.Now().UTC())
+}
+
+// Start records the state changes published by the hub and sends a digest built from them and the given user idlers
+// as scheduled until the context is done.
+func (r *Reporter) Start(ctx context.Context, wg *sync.WaitGroup, hub *events.Hub, userIdlers *openshift.UserIdlerMap) {
+	if r == nil {
+		return
+	}
+	changes, cancel := hub.Subscribe("")
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+
+		due := r.clock.After(next(r.schedule, r.clock.Now()).Sub(r.clock.Now()))
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Shutting down reporter.")
+				r.wg.Wait()
+				return
+			case e := <-changes:
+				r.history.Record(e)
+			case <-due:
+				r.report(userIdlers)
+				due = r.clock.After(next(r.schedule, r.clock.Now()).Sub(r.clock.Now()))
+			}
+		}
+	}()
+}
+
+// report builds the digest of the past period and sends it in the background.
+func (r *Reporter) report(userIdlers *openshift.UserIdlerMap) {
+	to := r.clock.Now().UTC().Truncate(time.Minute)
+	digest := r.history.Digest(to.Add(-period(r.schedule)), to, userIdlers, r.top)
+	digest.Schedule = r.schedule
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.send(digest)
+	}()
+}
+
+// send posts the digest to the webhook and emails it to the recipients, as configured. The digest counts as sent if
+// at least one of its deliveries succeeded.
+func (r *Reporter) send(digest Digest) {
+	sent := false
+	if r.webhookURL != "" {
+		if err := r.post(digest); err != nil {
+			logger.WithField("err", err).Error("Unable to post digest to webhook")
+		} else {
+			sent = true
+		}
+	}
+	if len(r.emailTo) > 0 {
+		if err := r.email(digest); err != nil {
+			logger.WithField("err", err).Error("Unable to email digest")
+		} else {
+			sent = true
+		}
+	}
+
+	log := logger.WithFields(logrus.Fields{"schedule": digest.Schedule, "from": digest.From, "to": digest.To})
+	if !sent {
+		log.Warn("Digest not sent to any webhook or recipient")
+		return
+	}
+	log.Info("Sent digest")
+}
+
+// post sends the digest to the webhook in the format of the notifications.
+func (r *Reporter) post(digest Digest) error {
+	var payload interface{} = digest
+	if r.format == notify.SlackFormat {
+		payload = map[string]string{"text": text(digest)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.httpClient.Post(r.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// email sends the digest as plain text email to the recipients.
+func (r *Reporter) email(digest Digest) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", r.emailFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(r.emailTo, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject(digest))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.Replace(text(digest), "\n", "\r\n", -1))
+	return sendMail(r.smtpAddress, r.smtpAuth, r.emailFrom, r.emailTo, msg.Bytes())
+}
+
+// subject returns the title of the digest.
+func subject(digest Digest) string {
+	return fmt.Sprintf("Jenkins Idler %s digest %s - %s", digest.Schedule,
+		digest.From.Format("2006-01-02 15:04"), digest.To.Format("2006-01-02 15:04 MST"))
+}
+
+// text renders the digest as plain text.
+func text(digest Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", subject(digest))
+	fmt.Fprintf(&b, "Un-idles: %d\n", digest.UnIdles)
+	fmt.Fprintf(&b, "Idles: %d\n", digest.Idles)
+	fmt.Fprintf(&b, "Failures: %d", digest.Failures)
+	if len(digest.FailedNamespaces) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(digest.FailedNamespaces, ", "))
+	}
+	fmt.Fprintf(&b, "\nUn-idles refused due to the capacity: %d\n", digest.CapacityRefusals)
+	fmt.Fprintf(&b, "Estimated savings: %.1f idled hours, %.1f CPU core hours, %.1f GiB hours of memory\n",
+		digest.Savings.IdledHours, digest.Savings.CPUCoreHours, digest.Savings.MemoryGiBHours)
+	if len(digest.NeverIdled) > 0 {
+		b.WriteString("\nNever idled:\n")
+		for _, ns := range digest.NeverIdled {
+			fmt.Fprintf(&b, "- %s on %s, requesting %.2f CPU cores and %.1f GiB of memory\n",
+				ns.Namespace, ns.Cluster, ns.CPURequest, float64(ns.MemoryRequest)/gibibyte)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/events"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_new_returns_nil_if_disabled(t *testing.T) {
+	r := New(&mock.Config{}, clock.New())
+	assert.Nil(t, r)
+	r.CapacityRefused()
+}
+
+func Test_next(t *testing.T) {
+	friday := time.Date(2018, 6, 1, 13, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC), next(Daily, friday))
+	assert.Equal(t, time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC), next(Weekly, friday))
+
+	monday := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2018, 6, 11, 0, 0, 0, 0, time.UTC), next(Weekly, monday), "A digest just sent should not be due again")
+}
+
+func Test_reporter_sends_digest_as_scheduled(t *testing.T) {
+	digests := make(chan Digest, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var digest Digest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&digest))
+		digests <- digest
+	}))
+	defer ts.Close()
+
+	type mail struct {
+		addr string
+		from string
+		to   []string
+		msg  string
+	}
+	mails := make(chan mail, 1)
+	defer func(f func(string, smtp.Auth, string, []string, []byte) error) { sendMail = f }(sendMail)
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		mails <- mail{addr, from, to, string(msg)}
+		return nil
+	}
+
+	fake := clock.NewFake(from.Add(-30 * time.Minute))
+	r := New(&mock.Config{DigestSchedule: Daily, DigestTop: 10, DigestWebhookURL: ts.URL, NotifyFormat: "json",
+		DigestEmailTo: []string{"ops@example.com"}, DigestEmailFrom: "idler@example.com", DigestSMTPAddress: "smtp.example.com:25"}, fake)
+	require.NotNil(t, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	r.Start(ctx, &wg, events.NewHub(), newUserIdlers(map[string]model.PodState{"foo": model.PodRunning}))
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	r.CapacityRefused()
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Minute)
+
+	select {
+	case digest := <-digests:
+		assert.Equal(t, Daily, digest.Schedule)
+		assert.Equal(t, from.Add(-24*time.Hour), digest.From)
+		assert.Equal(t, from, digest.To)
+		assert.Equal(t, 1, digest.CapacityRefusals)
+		require.Len(t, digest.NeverIdled, 1)
+		assert.Equal(t, "foo-jenkins", digest.NeverIdled[0].Namespace)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Digest was not posted")
+	}
+
+	select {
+	case m := <-mails:
+		assert.Equal(t, "smtp.example.com:25", m.addr)
+		assert.Equal(t, "idler@example.com", m.from)
+		assert.Equal(t, []string{"ops@example.com"}, m.to)
+		assert.Contains(t, m.msg, "Subject: Jenkins Idler daily digest 2018-05-31 00:00 - 2018-06-01 00:00 UTC\r\n")
+		assert.Contains(t, m.msg, "Un-idles refused due to the capacity: 1\r\n")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Digest was not emailed")
+	}
+}
+
+func Test_text(t *testing.T) {
+	digest := Digest{
+		Schedule:         Weekly,
+		From:             from.Add(-7 * 24 * time.Hour),
+		To:               from,
+		UnIdles:          12,
+		Idles:            30,
+		Failures:         2,
+		FailedNamespaces: []string{"bar-jenkins", "baz-jenkins"},
+		CapacityRefusals: 3,
+		NeverIdled:       []Namespace{{Namespace: "foo-jenkins", Cluster: apiURL, CPURequest: 0.5, MemoryRequest: 2 << 30}},
+		Savings:          Savings{IdledHours: 120.5, CPUCoreHours: 60.25, MemoryGiBHours: 241},
+	}
+
+	expected := `Jenkins Idler weekly digest 2018-05-25 00:00 - 2018-06-01 00:00 UTC
+
+Un-idles: 12
+Idles: 30
+Failures: 2 (bar-jenkins, baz-jenkins)
+Un-idles refused due to the capacity: 3
+Estimated savings: 120.5 idled hours, 60.2 CPU core hours, 241.0 GiB hours of memory
+
+Never idled:
+- foo-jenkins on https://api.example.com/, requesting 0.50 CPU cores and 2.0 GiB of memory
+`
+	assert.Equal(t, expected, text(digest))
+}
+
+func Test_post_slack_digest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"text":"Jenkins Idler daily digest`)
+	}))
+	defer ts.Close()
+
+	r := New(&mock.Config{DigestSchedule: Daily, DigestWebhookURL: ts.URL, NotifyFormat: "slack"}, clock.New())
+	assert.NoError(t, r.post(Digest{Schedule: Daily, From: from.Add(-24 * time.Hour), To: from}))
+}
+
+func Test_digest_is_only_logged_as_sent_if_delivered(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	defer func(l *logrus.Entry) { logger = l }(logger)
+	logger = testLogger.WithField("component", "report")
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	succeeding := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer succeeding.Close()
+
+	digest := Digest{Schedule: Daily, From: from.Add(-24 * time.Hour), To: from}
+
+	New(&mock.Config{DigestSchedule: Daily}, clock.New()).send(digest)
+	assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level, "Digest without webhook or recipients should not be logged as sent")
+	assert.Equal(t, "Digest not sent to any webhook or recipient", hook.LastEntry().Message)
+
+	hook.Reset()
+	New(&mock.Config{DigestSchedule: Daily, DigestWebhookURL: failing.URL, NotifyFormat: "json"}, clock.New()).send(digest)
+	assert.Equal(t, "Digest not sent to any webhook or recipient", hook.LastEntry().Message, "Digest whose deliveries failed should not be logged as sent")
+
+	hook.Reset()
+	New(&mock.Config{DigestSchedule: Daily, DigestWebhookURL: succeeding.URL, NotifyFormat: "json"}, clock.New()).send(digest)
+	assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+	assert.Equal(t, "Sent digest", hook.LastEntry().Message)
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+var accessLogger = log.WithFields(log.Fields{"component": "access-log"})
+
+// statusRecorder is a http.ResponseWriter which keeps track of the status code written to it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// accessLog records each API request as metric and, unless configured to be metrics only, as access
+// log entry. Successful requests are sampled, failed requests are always logged.
+type accessLog struct {
+	sampleRate  float64
+	metricsOnly bool
+	recorder    metric.Recorder
+	sample      func() float64
+	proxies     []*net.IPNet
+}
+
+func newAccessLog(config configuration.Configuration) *accessLog {
+	return &accessLog{
+		sampleRate:  config.GetAccessLogSampleRate(),
+		metricsOnly: config.GetAccessLogMetricsOnly(),
+		recorder:    metric.PrometheusRecorder{},
+		sample:      rand.Float64,
+		proxies:     trustedProxies(config),
+	}
+}
+
+// middleware returns the access log middleware for the route of the given name.
+func (a *accessLog) middleware(name string) Middleware {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			next(rec, r, ps)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			elapsed := time.Since(start)
+			a.recorder.RecordHTTPRequest(name, r.Method, rec.status, elapsed.Seconds())
+
+			if !a.shouldLog(rec.status) {
+				return
+			}
+			accessLogger.WithFields(log.Fields{
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"route":      name,
+				"namespace":  ps.ByName("namespace"),
+				"caller":     caller(r, a.proxies),
+				"user_agent": r.UserAgent(),
+				"status":     rec.status,
+				"latency_ms": float64(elapsed.Nanoseconds()) / float64(time.Millisecond),
+			}).Info("Request served")
+		}
+	}
+}
+
+func (a *accessLog) shouldLog(status int) bool {
+	if a.metricsOnly {
+		return false
+	}
+	if status >= http.StatusBadRequest {
+		return true
+	}
+	return a.sample() < a.sampleRate
+}
+
+// caller returns the host of the client which made the request, without the port. If the request passed the given
+// proxies, the client is the last hop of X-Forwarded-For not being one of them, since the hops before are under the
+// control of the client. X-Forwarded-For of clients not being one of the proxies is ignored.
+func caller(r *http.Request, proxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trusted(host, proxies) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		host = hop
+		if !trusted(hop, proxies) {
+			break
+		}
+	}
+	return host
+}
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestRecorder struct {
+	requests []string
+}
+
+func (r *requestRecorder) Initialize() {}
+
+func (r *requestRecorder) RecordReqDuration(jenkinsService, operation string, code int, elapsedTime float64) {
+}
+
+func (r *requestRecorder) RecordHTTPRequest(route, method string, code int, elapsedTime float64) {
+	r.requests = append(r.requests, route)
+}
+
+func (r *requestRecorder) RecordPanic(source string) {}
+
+func (r *requestRecorder) RecordStateTransition(from, to, event, variant string) {}
+
+func (r *requestRecorder) RecordIdleDuration(variant string, elapsedTime float64) {}
+
+func (r *requestRecorder) RecordEviction(state string) {}
+
+func (r *requestRecorder) RecordIdleQueued(cluster string) {}
+
+func (r *requestRecorder) RecordIdleDequeued(cluster string) {}
+
+func (r *requestRecorder) RecordTenantLookup(pages int, failed bool, elapsedTime float64) {}
+
+func (r *requestRecorder) RecordChannelBacklog(namespace string, backlog int) {}
+
+func (r *requestRecorder) RecordChannelDiscard(cluster string) {}
+
+func (r *requestRecorder) RecordChannelRemoved(namespace string) {}
+
+func (r *requestRecorder) RecordToggleEvaluation(feature, outcome string) {}
+
+func (r *requestRecorder) RecordClusterPressure(cluster string, underPressure bool) {}
+
+func (r *requestRecorder) RecordPressureIdle(cluster string) {}
+
+func (r *requestRecorder) RecordTokenExpiry(cluster string, seconds float64) {}
+
+func (r *requestRecorder) RecordRejectedEvent(cluster, resource, reason string)                   {}
+func (r *requestRecorder) RecordClusterWatch(cluster string, running bool)                        {}
+func (r *requestRecorder) RecordStateDrift(cluster, believed, observed string)                    {}
+func (r *requestRecorder) RecordControllerEvent(cluster, resource string, elapsedTime float64)    {}
+func (r *requestRecorder) RecordIgnoredEvent(cluster, resource, reason string)                    {}
+func (r *requestRecorder) RecordTenantLookupFailure(cluster string)                               {}
+func (r *requestRecorder) RecordJenkinsResources(namespace, resource, kind string, value float64) {}
+func (r *requestRecorder) RecordJenkinsResourcesRemoved(namespace string)                         {}
+
+func respondWith(status int) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.WriteHeader(status)
+	}
+}
+
+func Test_access_log_entry(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	accessLogger = testLogger.WithFields(log.Fields{"component": "access-log"})
+
+	recorder := &requestRecorder{}
+	_, proxy, _ := net.ParseCIDR("192.0.2.0/24")
+	a := &accessLog{sampleRate: 1, recorder: recorder, sample: func() float64 { return 0.5 }, proxies: []*net.IPNet{proxy}}
+	handle := a.middleware("Status")(respondWith(http.StatusOK))
+
+	req := httptest.NewRequest("GET", "/api/idler/status/foo", nil)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	handle(httptest.NewRecorder(), req, httprouter.Params{{Key: "namespace", Value: "foo"}})
+
+	assert.Equal(t, []string{"Status"}, recorder.requests)
+	entry := hook.LastEntry()
+	assert.NotNil(t, entry, "access log entry expected")
+	assert.Equal(t, "GET", entry.Data["method"])
+	assert.Equal(t, "/api/idler/status/foo", entry.Data["path"])
+	assert.Equal(t, "foo", entry.Data["namespace"])
+	assert.Equal(t, "10.0.0.1", entry.Data["caller"])
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+	assert.Contains(t, entry.Data, "latency_ms")
+}
+
+func Test_access_log_caller_ignores_forwarded_for_of_untrusted_clients(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	accessLogger = testLogger.WithFields(log.Fields{"component": "access-log"})
+
+	_, proxy, _ := net.ParseCIDR("172.16.0.0/12")
+	a := &accessLog{sampleRate: 1, recorder: &requestRecorder{}, sample: func() float64 { return 0.5 }, proxies: []*net.IPNet{proxy}}
+	handle := a.middleware("Status")(respondWith(http.StatusOK))
+
+	req := httptest.NewRequest("GET", "/api/idler/status/foo", nil)
+	req.RemoteAddr = "198.51.100.7:4711"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	handle(httptest.NewRecorder(), req, httprouter.Params{{Key: "namespace", Value: "foo"}})
+	assert.Equal(t, "198.51.100.7", hook.LastEntry().Data["caller"], "X-Forwarded-For of a client not being a trusted proxy should be ignored")
+
+	req = httptest.NewRequest("GET", "/api/idler/status/foo", nil)
+	req.RemoteAddr = "172.17.0.2:4711"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 198.51.100.7")
+	handle(httptest.NewRecorder(), req, httprouter.Params{{Key: "namespace", Value: "foo"}})
+	assert.Equal(t, "198.51.100.7", hook.LastEntry().Data["caller"], "the last hop not being a trusted proxy should be the caller")
+}
+
+func Test_access_log_sampling(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	accessLogger = testLogger.WithFields(log.Fields{"component": "access-log"})
+
+	recorder := &requestRecorder{}
+	a := &accessLog{sampleRate: 0.1, recorder: recorder, sample: func() float64 { return 0.5 }}
+
+	a.middleware("Status")(respondWith(http.StatusOK))(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	assert.Empty(t, hook.AllEntries(), "successful request should have been sampled out")
+
+	a.middleware("Status")(respondWith(http.StatusInternalServerError))(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	assert.Len(t, hook.AllEntries(), 1, "failed requests are always logged")
+	assert.Len(t, recorder.requests, 2, "all requests should be recorded as metric")
+}
+
+func Test_access_log_metrics_only(t *testing.T) {
+	testLogger, hook := test.NewNullLogger()
+	accessLogger = testLogger.WithFields(log.Fields{"component": "access-log"})
+
+	recorder := &requestRecorder{}
+	a := &accessLog{sampleRate: 1, metricsOnly: true, recorder: recorder, sample: func() float64 { return 0 }}
+
+	a.middleware("Idle")(respondWith(http.StatusInternalServerError))(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	assert.Empty(t, hook.AllEntries(), "no access log expected")
+	assert.Equal(t, []string{"Idle"}, recorder.requests)
+}
@@ -0,0 +1,60 @@
+package router
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/julienschmidt/httprouter"
+)
+
+const bearerPrefix = "Bearer "
+
+// bearerAuth restricts access to a listener to clients presenting the configured bearer token or one of the scoped
+// tokens, which restrict their callers to a set of namespaces resp. clusters.
+type bearerAuth struct {
+	token  string
+	scopes *scope.Registry
+}
+
+// enabled returns whether a token is configured and hence authentication required.
+func (a *bearerAuth) enabled() bool {
+	return a.token != ""
+}
+
+// bearerToken returns the bearer token the request carries, if any.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(bearerPrefix) || header[:len(bearerPrefix)] != bearerPrefix {
+		return ""
+	}
+	return header[len(bearerPrefix):]
+}
+
+// authorized returns whether the request carries the configured bearer token.
+func (a *bearerAuth) authorized(r *http.Request) bool {
+	token := bearerToken(r)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// middleware responds with 401 to requests carrying neither the configured bearer token nor a scoped token. The
+// scope of a scoped token is passed to the handlers in the context of the request.
+func (a *bearerAuth) middleware(next httprouter.Handle) httprouter.Handle {
+	if !a.enabled() && a.scopes == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if s, ok := a.scopes.Lookup(bearerToken(r)); ok {
+			next(w, r.WithContext(scope.NewContext(r.Context(), s)), ps)
+			return
+		}
+		if a.enabled() && !a.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="jenkins-idler"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "unauthorized"}`))
+			return
+		}
+		next(w, r, ps)
+	}
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_listeners_have_independent_auth(t *testing.T) {
+	config := &mock.Config{APIToken: "public-token", AdminAPIToken: "admin-token"}
+	publicRouter := CreateAPIRouter(&mock.IdlerAPI{}, config)
+	adminRouter := CreateAdminRouter(&mock.IdlerAPI{}, config)
+
+	var requests = []struct {
+		router        http.Handler
+		url           string
+		authorization string
+		status        int
+	}{
+		{publicRouter, "/api/version", "", http.StatusUnauthorized},
+		{publicRouter, "/api/version", "Bearer admin-token", http.StatusUnauthorized},
+		{publicRouter, "/api/version", "public-token", http.StatusUnauthorized},
+		{publicRouter, "/api/version", "Bearer public-token", http.StatusOK},
+		{adminRouter, "/api/version", "Bearer public-token", http.StatusUnauthorized},
+		{adminRouter, "/api/version", "Bearer admin-token", http.StatusOK},
+		{adminRouter, "/debug/pprof/", "", http.StatusUnauthorized},
+		{adminRouter, "/debug/pprof/", "Bearer admin-token", http.StatusOK},
+	}
+
+	for _, request := range requests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", request.url, nil)
+		if request.authorization != "" {
+			req.Header.Set("Authorization", request.authorization)
+		}
+		request.router.ServeHTTP(w, req)
+
+		assert.Equal(t, request.status, w.Code, "Unexpected status for %s with '%s'", request.url, request.authorization)
+		if request.status == http.StatusUnauthorized {
+			assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func Test_no_auth_required_without_token(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/version", nil)
+	CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_scoped_tokens(t *testing.T) {
+	scopes := scope.Default
+	defer func() { scope.Default = scopes }()
+	var err error
+	scope.Default, err = scope.NewRegistry([]scope.Scope{{Name: "team-a", Token: "team-token", Namespaces: []string{"foo-jenkins"}}})
+	require.NoError(t, err)
+
+	var requests = []struct {
+		config        *mock.Config
+		authorization string
+		status        int
+	}{
+		{&mock.Config{APIToken: "public-token"}, "Bearer team-token", http.StatusOK},
+		{&mock.Config{APIToken: "public-token"}, "Bearer public-token", http.StatusOK},
+		{&mock.Config{APIToken: "public-token"}, "Bearer other-token", http.StatusUnauthorized},
+		{&mock.Config{}, "Bearer team-token", http.StatusOK},
+	}
+
+	for _, request := range requests {
+		var scoped *scope.Scope
+		api := &mock.IdlerAPI{}
+		router := CreateAPIRouter(&scopeRecorder{IdlerAPI: api, scope: &scoped}, request.config)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/version", nil)
+		req.Header.Set("Authorization", request.authorization)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, request.status, w.Code, "Unexpected status for '%s'", request.authorization)
+		if request.authorization == "Bearer team-token" {
+			require.NotNil(t, scoped, "Scope of the token should be passed to the handler")
+			assert.Equal(t, "team-a", scoped.Name)
+		} else {
+			assert.Nil(t, scoped)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/version", nil)
+	req.Header.Set("Authorization", "Bearer team-token")
+	CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{AdminAPIToken: "admin-token"}).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Scoped tokens should not grant access to the admin API")
+}
+
+// scopeRecorder records the scope passed to the Version handler.
+type scopeRecorder struct {
+	*mock.IdlerAPI
+	scope **scope.Scope
+}
+
+func (s *scopeRecorder) Version(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	*s.scope = scope.FromContext(r.Context())
+	s.IdlerAPI.Version(w, r, ps)
+}
@@ -0,0 +1,41 @@
+package router
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// minCompressSize is the minimum response size in bytes for which compression pays off.
+const minCompressSize = 1024
+
+// compressor gzip compresses responses of at least minCompressSize bytes for clients accepting gzip encoding.
+func compressor(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next(w, r, ps)
+			return
+		}
+
+		buf := &bufferedWriter{ResponseWriter: w}
+		next(buf, r, ps)
+
+		w.Header().Add("Vary", "Accept-Encoding")
+		if buf.body.Len() < minCompressSize || w.Header().Get("Content-Encoding") != "" {
+			w.WriteHeader(buf.statusCode())
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(buf.statusCode())
+		gz := gzip.NewWriter(w)
+		if _, err := gz.Write(buf.body.Bytes()); err != nil {
+			routerLogger.WithField("err", err).Error("Unable to compress response")
+		}
+		gz.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func respondWithBody(body string) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}
+}
+
+func Test_large_responses_get_compressed(t *testing.T) {
+	body := strings.Repeat(`{"APIURL":"https://api.example.com/","AppDNS":"example.com"}`, 50)
+	handle := compressor(respondWithBody(body))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/idler/cluster", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	handle(w, req, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.True(t, w.Body.Len() < len(body), "Response should have been compressed")
+
+	gz, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+	uncompressed, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(uncompressed))
+}
+
+func Test_small_responses_are_not_compressed(t *testing.T) {
+	handle := compressor(respondWithBody(`{"is_idle":true}`))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/idler/isidle/foo", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handle(w, req, nil)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"is_idle":true}`, w.Body.String())
+}
+
+func Test_no_compression_without_accept_encoding(t *testing.T) {
+	body := strings.Repeat("a", 2*minCompressSize)
+	handle := compressor(respondWithBody(body))
+
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/", nil), nil)
+
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, body, w.Body.String())
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// maxTrackedResources limits the number of resources for which the modification time is tracked.
+const maxTrackedResources = 4096
+
+type resourceVersion struct {
+	sum      uint64
+	modified time.Time
+}
+
+// conditional implements Last-Modified/If-Modified-Since handling for GET requests. The API resources are
+// computed on each request, hence the modification time of a resource is the time its current content got
+// served first.
+type conditional struct {
+	sync.Mutex
+	versions map[string]resourceVersion
+	now      func() time.Time
+}
+
+func newConditional() *conditional {
+	return &conditional{
+		versions: make(map[string]resourceVersion),
+		now:      time.Now,
+	}
+}
+
+// lastModified returns the modification time of the resource identified by key with the given content checksum.
+func (c *conditional) lastModified(key string, sum uint64) time.Time {
+	c.Lock()
+	defer c.Unlock()
+
+	if v, ok := c.versions[key]; ok && v.sum == sum {
+		return v.modified
+	}
+
+	if len(c.versions) >= maxTrackedResources {
+		c.versions = make(map[string]resourceVersion)
+	}
+	modified := c.now().UTC().Truncate(time.Second)
+	c.versions[key] = resourceVersion{sum: sum, modified: modified}
+	return modified
+}
+
+func (c *conditional) middleware(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if r.Method != "GET" {
+			next(w, r, ps)
+			return
+		}
+
+		buf := &bufferedWriter{ResponseWriter: w}
+		next(buf, r, ps)
+
+		if buf.statusCode() != http.StatusOK {
+			w.WriteHeader(buf.statusCode())
+			w.Write(buf.body.Bytes())
+			return
+		}
+
+		h := fnv.New64a()
+		h.Write(buf.body.Bytes())
+		modified := c.lastModified(r.URL.RequestURI(), h.Sum64())
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+
+		if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !modified.After(since) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.body.Bytes())
+	}
+}
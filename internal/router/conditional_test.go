@@ -0,0 +1,61 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_last_modified_and_if_modified_since(t *testing.T) {
+	now := time.Date(2018, 4, 11, 8, 27, 15, 0, time.UTC)
+	c := newConditional()
+	c.now = func() time.Time { return now }
+
+	body := `{"is_idle":true}`
+	handle := c.middleware(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Write([]byte(body))
+	})
+
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/api/idler/isidle/foo", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, now.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	assert.Equal(t, body, w.Body.String())
+
+	// unchanged content is not modified since the first response
+	now = now.Add(time.Minute)
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/idler/isidle/foo", nil)
+	req.Header.Set("If-Modified-Since", now.Add(-30*time.Second).Format(http.TimeFormat))
+	handle(w, req, nil)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// changed content gets a new modification time
+	body = `{"is_idle":false}`
+	w = httptest.NewRecorder()
+	handle(w, req, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, now.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	assert.Equal(t, body, w.Body.String())
+}
+
+func Test_conditional_ignores_errors_and_non_get_requests(t *testing.T) {
+	c := newConditional()
+
+	handle := c.middleware(respondWith(http.StatusInternalServerError))
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest("GET", "/", nil), nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+
+	handle = c.middleware(respondWith(http.StatusOK))
+	w = httptest.NewRecorder()
+	handle(w, httptest.NewRequest("POST", "/", nil), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+}
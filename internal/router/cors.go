@@ -0,0 +1,71 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/julienschmidt/httprouter"
+)
+
+const corsMaxAge = "600"
+
+// cors implements Cross-Origin Resource Sharing for the API, allowing browser based consumers to call
+// the API directly.
+type cors struct {
+	origins []string
+	methods string
+	headers string
+}
+
+func newCORS(config configuration.Configuration) *cors {
+	return &cors{
+		origins: config.GetCORSAllowedOrigins(),
+		methods: strings.Join(config.GetCORSAllowedMethods(), ", "),
+		headers: strings.Join(config.GetCORSAllowedHeaders(), ", "),
+	}
+}
+
+// enabled returns whether any origin is allowed to make cross-origin requests.
+func (c *cors) enabled() bool {
+	return len(c.origins) > 0
+}
+
+// allowOrigin sets the Access-Control-Allow-Origin header if the origin of the request is allowed and
+// returns whether it did so.
+func (c *cors) allowOrigin(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	w.Header().Add("Vary", "Origin")
+	for _, allowed := range c.origins {
+		if allowed == "*" || allowed == origin {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			return true
+		}
+	}
+	return false
+}
+
+// middleware adds the CORS headers to the responses of cross-origin requests from allowed origins.
+func (c *cors) middleware(next httprouter.Handle) httprouter.Handle {
+	if !c.enabled() {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		c.allowOrigin(w, r)
+		next(w, r, ps)
+	}
+}
+
+// preflight answers CORS preflight requests.
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if c.allowOrigin(w, r) {
+		w.Header().Set("Access-Control-Allow-Methods", c.methods)
+		w.Header().Set("Access-Control-Allow-Headers", c.headers)
+		w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
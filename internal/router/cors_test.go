@@ -0,0 +1,64 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cors_disabled_by_default(t *testing.T) {
+	router := CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/version", nil)
+	req.Header.Set("Origin", "https://openshift.io")
+	router.ServeHTTP(w, req)
+
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func Test_cors_allowed_origin(t *testing.T) {
+	config := &mock.Config{
+		CORSAllowedOrigins: []string{"https://openshift.io"},
+		CORSAllowedMethods: []string{"GET", "POST"},
+		CORSAllowedHeaders: []string{"Content-Type"},
+	}
+	router := CreateAPIRouter(&mock.IdlerAPI{}, config)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/api/idler/status/foo", nil)
+	req.Header.Set("Origin", "https://openshift.io")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://openshift.io", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.Header.Set("Origin", "https://openshift.io")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "https://openshift.io", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), "Origin should not be allowed")
+}
+
+func Test_cors_wildcard_origin(t *testing.T) {
+	c := &cors{origins: []string{"*"}}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	assert.True(t, c.allowOrigin(w, req))
+	assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+}
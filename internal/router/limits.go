@@ -0,0 +1,80 @@
+package router
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+)
+
+var errListenerClosed = errors.New("listener closed")
+
+// Option configures a Router.
+type Option func(r *Router)
+
+// WithServerLimits applies the configured timeouts and limits to the HTTP server of the router, protecting it
+// against clients which hold on to connections without completing their requests.
+func WithServerLimits(config configuration.Configuration) Option {
+	return func(r *Router) {
+		r.srv.ReadTimeout = time.Duration(config.GetHTTPReadTimeout()) * time.Second
+		r.srv.WriteTimeout = time.Duration(config.GetHTTPWriteTimeout()) * time.Second
+		r.srv.IdleTimeout = time.Duration(config.GetHTTPIdleTimeout()) * time.Second
+		r.srv.MaxHeaderBytes = config.GetHTTPMaxHeaderBytes()
+		r.maxConnections = config.GetHTTPMaxConnections()
+	}
+}
+
+// limitListener accepts at most a fixed number of concurrent connections. Further connections are only accepted
+// once an accepted one got closed.
+type limitListener struct {
+	net.Listener
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimitListener(l net.Listener, n int) *limitListener {
+	return &limitListener{
+		Listener: l,
+		sem:      make(chan struct{}, n),
+		done:     make(chan struct{}),
+	}
+}
+
+// Accept waits for a free connection slot and the next connection.
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, errListenerClosed
+	}
+
+	c, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+	return &limitListenerConn{Conn: c, release: func() { <-l.sem }}, nil
+}
+
+// Close closes the listener, unblocking a pending Accept.
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitListenerConn frees its connection slot when closed.
+type limitListenerConn struct {
+	net.Conn
+	releaseOnce sync.Once
+	release     func()
+}
+
+func (c *limitListenerConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(c.release)
+	return err
+}
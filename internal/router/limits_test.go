@@ -0,0 +1,88 @@
+package router
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_server_limits_are_applied(t *testing.T) {
+	config := &mock.Config{
+		HTTPReadTimeout:    5,
+		HTTPWriteTimeout:   10,
+		HTTPIdleTimeout:    30,
+		HTTPMaxHeaderBytes: 4096,
+		HTTPMaxConnections: 8,
+	}
+	router := NewRouterWithAddress(httprouter.New(), ":0", WithServerLimits(config))
+
+	assert.Equal(t, 5*time.Second, router.srv.ReadTimeout)
+	assert.Equal(t, 10*time.Second, router.srv.WriteTimeout)
+	assert.Equal(t, 30*time.Second, router.srv.IdleTimeout)
+	assert.Equal(t, 4096, router.srv.MaxHeaderBytes)
+	assert.Equal(t, 8, router.maxConnections)
+}
+
+func Test_limit_listener_limits_concurrent_connections(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	limited := newLimitListener(l, 1)
+	defer limited.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			c, err := limited.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", l.Addr().String())
+		assert.NoError(t, err)
+		defer c.Close()
+	}
+
+	first := <-accepted
+	select {
+	case <-accepted:
+		t.Fatal("Second connection should not be accepted while the first one is open")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(time.Second):
+		t.Fatal("Second connection should be accepted once the first one got closed")
+	}
+}
+
+func Test_limit_listener_close_unblocks_accept(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	limited := newLimitListener(l, 1)
+	limited.sem <- struct{}{}
+
+	done := make(chan error)
+	go func() {
+		_, err := limited.Accept()
+		done <- err
+	}()
+
+	limited.Close()
+	select {
+	case err := <-done:
+		assert.Equal(t, errListenerClosed, err)
+	case <-time.After(time.Second):
+		t.Fatal("Accept should return once the listener got closed")
+	}
+}
@@ -0,0 +1,102 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openapi"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/recovery"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/validation"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// Middleware wraps a httprouter.Handle to add behaviour before and/or after the wrapped handle is called.
+type Middleware func(httprouter.Handle) httprouter.Handle
+
+// chain applies the given middlewares to the handle. The first middleware is the outermost one.
+func chain(handle httprouter.Handle, middlewares ...Middleware) httprouter.Handle {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handle = middlewares[i](handle)
+	}
+	return handle
+}
+
+// recoverer recovers from panics of the wrapped handle and responds with 500 instead.
+func recoverer(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		err := recovery.Guard("api", func() error {
+			next(w, r, ps)
+			return nil
+		})
+		if err != nil {
+			routerLogger.WithFields(log.Fields{
+				"method": r.Method,
+				"path":   r.URL.Path,
+			}).Error("Request handling panicked")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "internal server error"}`))
+		}
+	}
+}
+
+type validationErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields validation.Errors `json:"fields"`
+}
+
+// validator validates the request parameters against the ones declared by the given operation and responds
+// with 400 listing all invalid fields on failure. The request body of operations expecting one is limited to
+// maxBodyBytes.
+func validator(op openapi.Operation, maxBodyBytes int64) Middleware {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			errs := validation.Request(op, r, ps)
+			if op.RequestBody != nil {
+				errs = append(errs, validation.Body(r, maxBodyBytes)...)
+				if maxBodyBytes > 0 {
+					r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+				}
+			}
+
+			if len(errs) > 0 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(validationErrorResponse{Error: errs.Error(), Fields: errs})
+				return
+			}
+			next(w, r, ps)
+		}
+	}
+}
+
+// bufferedWriter is a http.ResponseWriter which buffers status and body, so that a middleware can
+// process the complete response before passing it on. Headers are written through to the wrapped writer.
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// statusCode returns the buffered status code, defaulting to 200 like net/http does.
+func (b *bufferedWriter) statusCode() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
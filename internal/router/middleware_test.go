@@ -0,0 +1,45 @@
+package router
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_chain_applies_middlewares_in_order(t *testing.T) {
+	var calls []string
+	tracing := func(name string) Middleware {
+		return func(next httprouter.Handle) httprouter.Handle {
+			return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+				calls = append(calls, name)
+				next(w, r, ps)
+			}
+		}
+	}
+
+	handle := chain(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		calls = append(calls, "handle")
+	}, tracing("outer"), tracing("inner"))
+	handle(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+
+	assert.Equal(t, []string{"outer", "inner", "handle"}, calls)
+}
+
+func Test_recoverer_responds_with_internal_server_error(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	handle := recoverer(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		handle(w, httptest.NewRequest("GET", "/api/idler/status/foo", nil), nil)
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error": "internal server error"}`, w.Body.String())
+}
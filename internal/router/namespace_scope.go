@@ -0,0 +1,26 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/julienschmidt/httprouter"
+)
+
+// namespaceScope responds with 403 to requests for namespaces which are not managed by the Idler according
+// to the given filter.
+func namespaceScope(filter *namespace.Filter) Middleware {
+	return func(next httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			ns := ps.ByName("namespace")
+			if ns != "" && !filter.Manages(ns) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(fmt.Sprintf(`{"error": "namespace %s is not managed by the idler"}`, ns)))
+				return
+			}
+			next(w, r, ps)
+		}
+	}
+}
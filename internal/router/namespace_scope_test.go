@@ -0,0 +1,31 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_namespace_scope(t *testing.T) {
+	config := &mock.Config{NamespaceDenylist: []string{"*-preview"}}
+	router := CreateAPIRouter(&mock.IdlerAPI{}, config)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/idler/status/foo-preview-jenkins?openshift_api_url=https://api.example.com/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "Denylisted namespace should be rejected")
+	assert.JSONEq(t, `{"error": "namespace foo-preview-jenkins is not managed by the idler"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/idler/status/foo-preview?openshift_api_url=https://api.example.com/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code, "Denylisted namespace without Jenkins suffix should be rejected")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/idler/status/foo-jenkins?openshift_api_url=https://api.example.com/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Other namespaces should be served")
+}
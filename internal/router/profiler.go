@@ -0,0 +1,26 @@
+package router
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const profilerPath = "/debug/pprof/"
+
+// profiler serves the runtime profiling data of the Idler in the format expected by the pprof tool.
+func profiler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	switch ps.ByName("profile") {
+	case "/cmdline":
+		pprof.Cmdline(w, r)
+	case "/profile":
+		pprof.Profile(w, r)
+	case "/symbol":
+		pprof.Symbol(w, r)
+	case "/trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/julienschmidt/httprouter"
+)
+
+// rateLimit counts the requests of each caller with the given limiter, stating the usage of the caller in the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of the responses. It responds with 429 to
+// requests exceeding the limit. A nil limiter disables rate limiting. The X-Forwarded-For header is only trusted if
+// set by one of the given proxies.
+func rateLimit(limiter *ratelimit.Limiter, proxies []*net.IPNet) Middleware {
+	return func(next httprouter.Handle) httprouter.Handle {
+		if limiter == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			usage, allowed := limiter.Allow(rateLimitCaller(r, proxies))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(usage.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(usage.Reset.Unix(), 10))
+			if !allowed {
+				retryAfter := int(limiter.RetryAfter(usage).Seconds() + 1)
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error": "rate limit exceeded"}`))
+				return
+			}
+			next(w, r, ps)
+		}
+	}
+}
+
+// rateLimitCaller identifies the caller of the request. Callers presenting a scoped token are identified by its scope,
+// regardless of the address they call from. Otherwise, the caller is the client which made the request as determined
+// by caller, so that the requests of a client are counted together regardless of the connection they were made on.
+func rateLimitCaller(r *http.Request, proxies []*net.IPNet) string {
+	if s := scope.FromContext(r.Context()); s != nil {
+		return "scope:" + s.Name
+	}
+	return caller(r, proxies)
+}
+
+// trusted returns whether the given host is one of the given proxies.
+func trusted(host string, proxies []*net.IPNet) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
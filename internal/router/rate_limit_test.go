@@ -0,0 +1,80 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/testutils/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rate_limit(t *testing.T) {
+	limiter := ratelimit.Default
+	defer func() { ratelimit.Default = limiter }()
+	now := time.Now()
+	ratelimit.Default = ratelimit.NewLimiter(1, time.Minute, clock.NewFake(now))
+	router := CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{TrustedProxies: []string{"10.0.0.1"}})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/version", nil)
+	req.RemoteAddr = "10.0.0.1:40000"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, strconv.FormatInt(now.Add(time.Minute).Unix(), 10), w.Header().Get("X-RateLimit-Reset"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.RemoteAddr = "10.0.0.1:40001"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Requests of a caller should be counted across connections")
+	assert.Equal(t, "61", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error": "rate limit exceeded"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.RemoteAddr = "10.0.0.1:40002"
+	req.Header.Set("X-Forwarded-For", "10.0.0.2, 10.0.0.1")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Requests passing a proxy should be counted for the originating client")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.RemoteAddr = "10.0.0.1:40003"
+	req.Header.Set("X-Forwarded-For", "10.0.0.3, 10.0.0.2")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "Hops before the client should not be trusted")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/version", nil)
+	req.RemoteAddr = "10.0.0.4:40000"
+	req.Header.Set("X-Forwarded-For", "10.0.0.5")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	req.Header.Set("X-Forwarded-For", "10.0.0.6")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "X-Forwarded-For should only be trusted from the proxies")
+
+	w = httptest.NewRecorder()
+	router = CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{})
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "The admin API should not be rate limited")
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
+
+func Test_rate_limit_counts_scoped_tokens_per_scope(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/version", nil)
+	r.RemoteAddr = "10.0.0.1:40000"
+	assert.Equal(t, "10.0.0.1", rateLimitCaller(r, nil))
+
+	r = r.WithContext(scope.NewContext(r.Context(), &scope.Scope{Name: "team-a"}))
+	assert.Equal(t, "scope:team-a", rateLimitCaller(r, nil), "Callers with a scoped token should be counted by scope")
+}
@@ -2,12 +2,21 @@ package router
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/api"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openapi"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/ratelimit"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/scope"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/version"
 	"github.com/julienschmidt/httprouter"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -18,12 +27,17 @@ var routerLogger = log.WithFields(log.Fields{"component": "router"})
 const (
 	defaultHTTPServerPort = 8080
 	shutdownTimeout       = 5
+
+	openAPITitle  = "Jenkins Idler API"
+	openAPIPath   = "/api/openapi.json"
+	swaggerUIPath = "/api/swagger-ui"
 )
 
 // Router implements an HTTP server, exposing the REST API of the Idler.
 type Router struct {
-	port int
-	srv  *http.Server
+	address        string
+	srv            *http.Server
+	maxConnections int
 }
 
 // NewRouter creates a new HTTP router for the Idler on the default port.
@@ -33,12 +47,21 @@ func NewRouter(router *httprouter.Router) *Router {
 
 // NewRouterWithPort creates a new HTTP router for the Idler on the specified port.
 func NewRouterWithPort(router *httprouter.Router, port int) *Router {
+	return NewRouterWithAddress(router, fmt.Sprintf(":%d", port))
+}
+
+// NewRouterWithAddress creates a new HTTP router for the Idler listening on the specified address, [host]:port.
+func NewRouterWithAddress(router *httprouter.Router, address string, options ...Option) *Router {
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
+		Addr:    address,
 		Handler: router,
 	}
 
-	return &Router{port: port, srv: srv}
+	r := &Router{address: address, srv: srv}
+	for _, option := range options {
+		option(r)
+	}
+	return r
 }
 
 // AddMetrics add metrics handler to serve promotheus metrics
@@ -46,14 +69,35 @@ func (r *Router) AddMetrics(router *httprouter.Router) {
 	router.Handler("GET", "/metrics", prometheus.Handler())
 }
 
+// AddReadiness adds the /readyz handler, which answers 503 stating the reason while the given check fails, e.g.
+// since the token of a cluster has expired. Degradations, e.g. of single clusters, are listed without failing the
+// check. The degraded function may be nil.
+func (r *Router) AddReadiness(router *httprouter.Router, ready func() error, degraded func() []string) {
+	router.GET("/readyz", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := ready(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+		if degraded != nil {
+			for _, d := range degraded() {
+				fmt.Fprintln(w, "degraded:", d)
+			}
+		}
+	})
+}
+
 // Start starts the HTTP router.
 func (r *Router) Start(ctx context.Context, wg *sync.WaitGroup, cancel context.CancelFunc) {
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		go func() {
-			routerLogger.Infof("Starting API router on port %d.", r.port)
-			if err := r.srv.ListenAndServe(); err != nil {
+			routerLogger.Infof("Starting API router on %s.", r.address)
+			if err := r.listenAndServe(); err != nil {
 				cancel()
 				return
 			}
@@ -62,7 +106,7 @@ func (r *Router) Start(ctx context.Context, wg *sync.WaitGroup, cancel context.C
 		for {
 			select {
 			case <-ctx.Done():
-				routerLogger.Infof("Shutting down API router on port %d.", r.port)
+				routerLogger.Infof("Shutting down API router on %s.", r.address)
 				ctx, cancel := context.WithTimeout(ctx, shutdownTimeout*time.Second)
 				r.srv.Shutdown(ctx)
 				cancel()
@@ -72,6 +116,21 @@ func (r *Router) Start(ctx context.Context, wg *sync.WaitGroup, cancel context.C
 	}()
 }
 
+// listenAndServe serves HTTP requests on the address of the router, accepting at most maxConnections
+// concurrent connections if set.
+func (r *Router) listenAndServe() error {
+	l, err := net.Listen("tcp", r.address)
+	if err != nil {
+		routerLogger.WithField("err", err).Errorf("Unable to listen on %s", r.address)
+		return err
+	}
+
+	if r.maxConnections > 0 {
+		l = newLimitListener(l, r.maxConnections)
+	}
+	return r.srv.Serve(l)
+}
+
 // Shutdown shuts down the idler router.
 func (r *Router) Shutdown() {
 	routerLogger.Info("Idler router shutting down.")
@@ -82,33 +141,179 @@ func (r *Router) Shutdown() {
 	cancel()
 }
 
-// CreateAPIRouter a pointer to the http router for the idler APIs.
-func CreateAPIRouter(api api.IdlerAPI) *httprouter.Router {
-	router := httprouter.New()
+// route binds an IdlerAPI handler to a method and path. The name refers to the documentation of the handler
+// in api.Operations.
+type route struct {
+	method string
+	path   string
+	name   string
+	handle httprouter.Handle
+}
+
+// apiMiddlewares bundles the middlewares applied to all routes of an API listener.
+type apiMiddlewares struct {
+	cors         *cors
+	auth         *bearerAuth
+	accessLog    *accessLog
+	conditional  *conditional
+	namespaces   *namespace.Filter
+	limiter      *ratelimit.Limiter
+	proxies      []*net.IPNet
+	maxBodyBytes int64
+}
+
+func newAPIMiddlewares(config configuration.Configuration, token string) apiMiddlewares {
+	return apiMiddlewares{
+		cors:         newCORS(config),
+		auth:         &bearerAuth{token: token},
+		accessLog:    newAccessLog(config),
+		conditional:  newConditional(),
+		namespaces:   namespace.NewFilter(config.GetNamespaceAllowlist(), config.GetNamespaceDenylist()),
+		proxies:      trustedProxies(config),
+		maxBodyBytes: int64(config.GetMaxRequestBodyBytes()),
+	}
+}
+
+// trustedProxies returns the proxies in front of the API whose X-Forwarded-For header is trusted. The configuration
+// is verified on startup, should the proxies be malformed nonetheless, none is trusted.
+func trustedProxies(config configuration.Configuration) []*net.IPNet {
+	proxies, _ := util.ParseNetworks(config.GetTrustedProxies())
+	return proxies
+}
+
+// streamingRoutes names the routes whose responses are streamed, so that they must not be buffered by the
+// compressor and conditional middlewares.
+var streamingRoutes = map[string]bool{
+	"EventStream": true,
+}
+
+// wrap applies the middlewares to the handle of the given route.
+func (m apiMiddlewares) wrap(r route) httprouter.Handle {
+	if streamingRoutes[r.name] {
+		return chain(r.handle,
+			m.cors.middleware,
+			m.accessLog.middleware(r.name),
+			recoverer,
+			m.auth.middleware,
+			rateLimit(m.limiter, m.proxies),
+			validator(api.Operations[r.name], m.maxBodyBytes),
+		)
+	}
+	return chain(r.handle,
+		m.cors.middleware,
+		m.accessLog.middleware(r.name),
+		recoverer,
+		m.auth.middleware,
+		rateLimit(m.limiter, m.proxies),
+		namespaceScope(m.namespaces),
+		compressor,
+		m.conditional.middleware,
+		validator(api.Operations[r.name], m.maxBodyBytes),
+	)
+}
 
-	router.GET("/api/idler/idle/:namespace", api.Idle)
-	router.GET("/api/idler/idle/:namespace/", api.Idle)
+// CreateAPIRouter creates the http router for the public Idler API, which allows to query the idle state of
+// Jenkins, to un-idle and reserve it and to register callbacks notified once it is running.
+func CreateAPIRouter(api api.IdlerAPI, config configuration.Configuration) *httprouter.Router {
+	routes := []route{
+		{"GET", "/api/idler/unidle/:namespace", "UnIdle", api.UnIdle},
+		{"GET", "/api/idler/isidle/:namespace", "IsIdle", api.IsIdle},
+		{"GET", "/api/idler/status/:namespace", "Status", api.Status},
+		{"GET", "/api/events/stream", "EventStream", api.EventStream},
+		{"POST", "/api/unidle/:namespace/reserve", "Reserve", api.Reserve},
+		{"GET", "/api/unidle/:namespace/reserve/:token", "Reservation", api.Reservation},
+		{"DELETE", "/api/unidle/:namespace/reserve/:token", "ReleaseReservation", api.ReleaseReservation},
+		{"POST", "/api/idler/callback/:namespace", "RegisterCallback", api.RegisterCallback},
+		{"DELETE", "/api/idler/callback/:namespace", "UnregisterCallback", api.UnregisterCallback},
+		{"GET", "/api/metrics/idlers", "IdlerTimers", api.IdlerTimers},
+		{"GET", "/api/version", "Version", api.Version},
+	}
+
+	middlewares := newAPIMiddlewares(config, config.GetAPIToken())
+	middlewares.auth.scopes = scope.Default
+	middlewares.limiter = ratelimit.Default
+	return newAPIRouter(routes, middlewares)
+}
+
+// CreateAdminRouter creates the http router for the admin Idler API, which allows to idle Jenkins, to reset it,
+// to control the user idlers and the log levels, to view the clusters, the feature toggles and the effective
+// configuration as well as to profile the Idler. It is meant to be served on a separate listener, so that access to
+// it can be restricted independently.
+func CreateAdminRouter(api api.IdlerAPI, config configuration.Configuration) *httprouter.Router {
+	routes := []route{
+		{"GET", "/api/idler/idle/:namespace", "Idle", api.Idle},
+		{"GET", "/api/idler/cluster", "ClusterDNSView", api.ClusterDNSView},
+		{"GET", "/api/idler/route/:namespace", "JenkinsRoute", api.JenkinsRoute},
+		{"POST", "/api/idler/reset/:namespace", "Reset", api.Reset},
+		{"GET", "/api/idler/userstatus", "GetDisabledUserIdlers", api.GetDisabledUserIdlers},
+		{"POST", "/api/idler/userstatus", "SetUserIdlerStatus", api.SetUserIdlerStatus},
+		{"GET", "/api/idler/clusterstatus", "GetDisabledClusters", api.GetDisabledClusters},
+		{"GET", "/api/idler/jenkinsversions", "JenkinsVersions", api.JenkinsVersions},
+		{"GET", "/api/idler/deadletters", "DeadLetters", api.DeadLetters},
+		{"GET", "/api/idler/recommendations", "MemoryRecommendations", api.MemoryRecommendations},
+		{"GET", "/api/idler/ratelimits", "RateLimits", api.RateLimits},
+		{"GET", "/api/idler/faults", "Faults", api.Faults},
+		{"PUT", "/api/idler/faults", "SetFault", api.SetFault},
+		{"DELETE", "/api/idler/faults", "ClearFaults", api.ClearFaults},
+		{"GET", "/api/status/aggregate", "AggregateStatus", api.AggregateStatus},
+		{"POST", "/api/idler/clusterstatus", "SetClusterStatus", api.SetClusterStatus},
+		{"GET", "/api/logging", "LogLevel", api.LogLevel},
+		{"PUT", "/api/logging", "SetLogLevel", api.SetLogLevel},
+		{"GET", "/api/toggles", "Toggles", api.Toggles},
+		{"GET", "/api/config", "EffectiveConfig", api.EffectiveConfig},
+		{"GET", "/api/version", "Version", api.Version},
+	}
 
-	router.GET("/api/idler/unidle/:namespace", api.UnIdle)
-	router.GET("/api/idler/unidle/:namespace/", api.UnIdle)
+	middlewares := newAPIMiddlewares(config, config.GetAdminAPIToken())
+	router := newAPIRouter(routes, middlewares)
 
-	router.GET("/api/idler/isidle/:namespace", api.IsIdle)
-	router.GET("/api/idler/isidle/:namespace/", api.IsIdle)
+	profile := chain(profiler, middlewares.accessLog.middleware("Profiler"), middlewares.auth.middleware)
+	router.GET(profilerPath+"*profile", profile)
+	router.POST(profilerPath+"*profile", profile)
 
-	router.GET("/api/idler/status/:namespace", api.Status)
-	router.GET("/api/idler/status/:namespace/", api.Status)
+	return router
+}
 
-	router.GET("/api/idler/cluster", api.ClusterDNSView)
-	router.GET("/api/idler/cluster/", api.ClusterDNSView)
+// newAPIRouter creates a http router serving the given routes wrapped by the middlewares.
+func newAPIRouter(routes []route, middlewares apiMiddlewares) *httprouter.Router {
+	router := httprouter.New()
 
-	router.POST("/api/idler/reset/:namespace", api.Reset)
-	router.POST("/api/idler/reset/:namespace/", api.Reset)
+	preflightPaths := make(map[string]bool)
+	for _, r := range routes {
+		handle := middlewares.wrap(r)
+		router.Handle(r.method, r.path, handle)
+		router.Handle(r.method, r.path+"/", handle)
 
-	router.GET("/api/idler/userstatus", api.GetDisabledUserIdlers)
-	router.GET("/api/idler/userstatus/", api.GetDisabledUserIdlers)
+		if middlewares.cors.enabled() && !preflightPaths[r.path] {
+			router.OPTIONS(r.path, middlewares.cors.preflight)
+			router.OPTIONS(r.path+"/", middlewares.cors.preflight)
+			preflightPaths[r.path] = true
+		}
+	}
 
-	router.POST("/api/idler/userstatus", api.SetUserIdlerStatus)
-	router.POST("/api/idler/userstatus/", api.SetUserIdlerStatus)
+	addOpenAPI(router, routes)
 
 	return router
 }
+
+// addOpenAPI serves the OpenAPI document describing the given routes as well as a Swagger UI rendering it.
+func addOpenAPI(router *httprouter.Router, routes []route) {
+	doc := openapi.NewDocument(openAPITitle, version.GetVersion())
+	doc.AddSchemas(api.Schemas)
+	for _, r := range routes {
+		doc.AddOperation(r.method, r.path, api.Operations[r.name])
+	}
+
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		routerLogger.WithField("err", err).Error("Unable to serialize the OpenAPI document")
+		return
+	}
+
+	router.GET(openAPIPath, func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(spec)
+	})
+	router.Handler("GET", swaggerUIPath, openapi.SwaggerUIHandler(openAPITitle, openAPIPath))
+}
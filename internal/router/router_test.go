@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -29,46 +30,228 @@ const (
 	testPortBase = 48080
 )
 
+type testRoute struct {
+	route  string
+	target string
+}
+
+func assertRoutes(t *testing.T, router http.Handler, routes []testRoute) {
+	for _, testRoute := range routes {
+		w := new(mock.ResponseWriter)
+		method := "GET"
+		switch testRoute.target {
+		case "SetUserIdlerStatus", "RegisterCallback", "Reserve":
+			method = "POST"
+		case "UnregisterCallback", "ReleaseReservation", "ClearFaults":
+			method = "DELETE"
+		case "SetLogLevel", "SetFault":
+			method = "PUT"
+		}
+		req, _ := http.NewRequest(method, testRoute.route+"?openshift_api_url=http://localhost/", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, testRoute.target, w.GetBody(), fmt.Sprintf("Routing failed for %s", testRoute.route))
+	}
+}
+
 func Test_all_routes_are_setup(t *testing.T) {
-	router := CreateAPIRouter(&mock.IdlerAPI{})
+	router := CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{})
 
-	var routes = []struct {
-		route  string
-		target string
-	}{
-		{"/api/idler/idle/my-namepace", "Idle"},
-		{"/api/idler/idle/my-namepace/", "Idle"},
+	assertRoutes(t, router, []testRoute{
 		{"/api/idler/unidle/my-namepace", "UnIdle"},
 		{"/api/idler/unidle/my-namepace/", "UnIdle"},
 		{"/api/idler/isidle/my-namepace", "IsIdle"},
 		{"/api/idler/isidle/my-namepace/", "IsIdle"},
+		{"/api/unidle/my-namepace/reserve", "Reserve"},
+		{"/api/unidle/my-namepace/reserve/", "Reserve"},
+		{"/api/unidle/my-namepace/reserve/0123abcd", "Reservation"},
+		{"/api/unidle/my-namepace/reserve/0123abcd", "ReleaseReservation"},
+		{"/api/idler/callback/my-namepace", "RegisterCallback"},
+		{"/api/idler/callback/my-namepace/", "RegisterCallback"},
+		{"/api/idler/callback/my-namepace", "UnregisterCallback"},
+		{"/api/idler/callback/my-namepace/", "UnregisterCallback"},
+		{"/api/metrics/idlers", "IdlerTimers"},
+		{"/api/metrics/idlers/", "IdlerTimers"},
+		{"/api/version", "Version"},
+		{"/api/version/", "Version"},
+
+		{"/api/idler/foo", "404 page not found\n"},
+		{"/api/idler/builds/foo/bar", "404 page not found\n"},
+		{"/api/idler/idle/my-namepace", "404 page not found\n"},
+		{"/api/idler/cluster", "404 page not found\n"},
+		{"/api/idler/userstatus", "404 page not found\n"},
+		{"/api/logging", "404 page not found\n"},
+		{"/api/toggles", "404 page not found\n"},
+	})
+}
+
+func Test_all_admin_routes_are_setup(t *testing.T) {
+	router := CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{})
+
+	assertRoutes(t, router, []testRoute{
+		{"/api/idler/idle/my-namepace", "Idle"},
+		{"/api/idler/idle/my-namepace/", "Idle"},
 		{"/api/idler/cluster", "GetClusterDNSView"},
 		{"/api/idler/cluster/", "GetClusterDNSView"},
+		{"/api/idler/route/my-namepace", "JenkinsRoute"},
+		{"/api/idler/route/my-namepace/", "JenkinsRoute"},
 		{"/api/idler/userstatus", "SetUserIdlerStatus"},
 		{"/api/idler/userstatus/", "SetUserIdlerStatus"},
 		{"/api/idler/userstatus", "GetDisabledUserIdlers"},
 		{"/api/idler/userstatus/", "GetDisabledUserIdlers"},
+		{"/api/logging", "LogLevel"},
+		{"/api/logging/", "LogLevel"},
+		{"/api/logging", "SetLogLevel"},
+		{"/api/logging/", "SetLogLevel"},
+		{"/api/toggles", "Toggles"},
+		{"/api/toggles/", "Toggles"},
+		{"/api/config", "EffectiveConfig"},
+		{"/api/config/", "EffectiveConfig"},
+		{"/api/status/aggregate", "AggregateStatus"},
+		{"/api/status/aggregate/", "AggregateStatus"},
+		{"/api/idler/deadletters", "DeadLetters"},
+		{"/api/idler/deadletters/", "DeadLetters"},
+		{"/api/idler/recommendations", "MemoryRecommendations"},
+		{"/api/idler/recommendations/", "MemoryRecommendations"},
+		{"/api/idler/ratelimits", "RateLimits"},
+		{"/api/idler/ratelimits/", "RateLimits"},
+		{"/api/idler/faults", "Faults"},
+		{"/api/idler/faults", "SetFault"},
+		{"/api/idler/faults", "ClearFaults"},
+		{"/api/version", "Version"},
+		{"/api/version/", "Version"},
+
+		{"/api/idler/unidle/my-namepace", "404 page not found\n"},
+		{"/api/idler/isidle/my-namepace", "404 page not found\n"},
+	})
+}
 
-		{"/api/idler/foo", "404 page not found\n"},
-		{"/api/idler/builds/foo/bar", "404 page not found\n"},
+func Test_profiler_is_served_on_admin_router(t *testing.T) {
+	router := CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/debug/pprof/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Contains(t, w.Body.String(), "goroutine", "Profile index should list the goroutine profile")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/debug/pprof/cmdline", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/debug/pprof/", nil)
+	CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{}).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Profiler should not be served on the public router")
+}
+
+func Test_readiness(t *testing.T) {
+	apiRouter := CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{})
+	var ready error
+	var degraded []string
+	NewRouter(apiRouter).AddReadiness(apiRouter, func() error { return ready }, func() []string { return degraded })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	apiRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Equal(t, "ok\n", w.Body.String())
+
+	ready = fmt.Errorf("token of cluster expired")
+	w = httptest.NewRecorder()
+	apiRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "Unexpected HTTP status code")
+	assert.Equal(t, "token of cluster expired\n", w.Body.String())
+
+	ready, degraded = nil, []string{"https://api.example.com/ (token rejected watching builds)"}
+	w = httptest.NewRecorder()
+	apiRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Degraded cluster should not fail the readiness")
+	assert.Equal(t, "ok\ndegraded: https://api.example.com/ (token rejected watching builds)\n", w.Body.String())
+}
+
+func Test_invalid_requests_are_rejected(t *testing.T) {
+	router := CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{MaxRequestBodyBytes: 16})
+
+	var requests = []struct {
+		method string
+		url    string
+		body   string
+		field  string
+	}{
+		{"GET", "/api/idler/idle/My_Namespace?openshift_api_url=http://localhost/", "", "namespace"},
+		{"GET", "/api/idler/idle/foo-jenkins?openshift_api_url=localhost", "", "openshift_api_url"},
+		{"POST", "/api/idler/userstatus", `{"disable": ["foo", "bar"]}`, "body"},
 	}
 
-	for _, testRoute := range routes {
-		w := new(mock.ResponseWriter)
-		if testRoute.target == "SetUserIdlerStatus" {
-			req, _ := http.NewRequest("POST", testRoute.route, nil)
-			router.ServeHTTP(w, req)
+	for _, request := range requests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(request.method, request.url, strings.NewReader(request.body))
+		router.ServeHTTP(w, req)
 
-			assert.Equal(t, testRoute.target, w.GetBody(), fmt.Sprintf("Routing failed for %s", testRoute.route))
-		} else {
-			req, _ := http.NewRequest("GET", testRoute.route, nil)
-			router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, fmt.Sprintf("Request to %s should have been rejected", request.url))
 
-			assert.Equal(t, testRoute.target, w.GetBody(), fmt.Sprintf("Routing failed for %s", testRoute.route))
+		var resp struct {
+			Fields []map[string]string `json:"fields"`
 		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Fields, 1)
+		assert.Equal(t, request.field, resp.Fields[0]["field"])
 	}
 }
 
+func Test_openapi_document_is_served(t *testing.T) {
+	router := CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", openAPIPath, nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+
+	var doc map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	assert.NoError(t, err, "OpenAPI document should be valid JSON")
+
+	paths := doc["paths"].(map[string]interface{})
+	for _, path := range []string{
+		"/api/idler/unidle/{namespace}",
+		"/api/idler/isidle/{namespace}",
+		"/api/idler/status/{namespace}",
+		"/api/idler/callback/{namespace}",
+		"/api/unidle/{namespace}/reserve",
+		"/api/unidle/{namespace}/reserve/{token}",
+	} {
+		assert.Contains(t, paths, path, "Path should be documented")
+	}
+	assert.NotContains(t, paths, "/api/idler/reset/{namespace}", "Admin paths should not be documented")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", openAPIPath, nil)
+	CreateAdminRouter(&mock.IdlerAPI{}, &mock.Config{}).ServeHTTP(w, req)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc), "OpenAPI document should be valid JSON")
+
+	paths = doc["paths"].(map[string]interface{})
+	for _, path := range []string{
+		"/api/idler/idle/{namespace}",
+		"/api/idler/cluster",
+		"/api/idler/route/{namespace}",
+		"/api/idler/reset/{namespace}",
+		"/api/idler/userstatus",
+		"/api/idler/deadletters",
+		"/api/idler/faults",
+	} {
+		assert.Contains(t, paths, path, "Admin path should be documented")
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", swaggerUIPath, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "Unexpected HTTP status code")
+	assert.Contains(t, w.Body.String(), openAPIPath, "Swagger UI should load the OpenAPI document")
+}
+
 func Test_router_start(t *testing.T) {
 	testPort := testPortBase + 1
 	log.SetOutput(ioutil.Discard)
@@ -78,7 +261,7 @@ func Test_router_start(t *testing.T) {
 
 	assert.True(t, isTCPPortAvailable(testPort), fmt.Sprintf("Port '%d' should be free.", testPort))
 
-	router := NewRouterWithPort(CreateAPIRouter(&mock.IdlerAPI{}), testPort)
+	router := NewRouterWithPort(CreateAPIRouter(&mock.IdlerAPI{}, &mock.Config{}), testPort)
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
@@ -101,7 +284,7 @@ func Test_router_start(t *testing.T) {
 
 	wg.Wait()
 
-	assert.Equal(t, fmt.Sprintf("Shutting down API router on port %d.", testPort), hook.LastEntry().Message)
+	assert.Equal(t, fmt.Sprintf("Shutting down API router on :%d.", testPort), hook.LastEntry().Message)
 }
 
 func Test_cluster_dns_view(t *testing.T) {
@@ -119,8 +302,8 @@ func Test_cluster_dns_view(t *testing.T) {
 	tenantService, cleanup := stubTenantService()
 	defer cleanup()
 
-	idlerAPI := api.NewIdlerAPI(openshift.NewUserIdlerMap(), clusterView, tenantService, model.NewStringSet())
-	router := NewRouterWithPort(CreateAPIRouter(idlerAPI), testPort)
+	idlerAPI := api.NewIdlerAPI(openshift.NewUserIdlerMap(), clusterView, tenantService, mock.NewMockFeatureToggle(nil), model.NewStringSet(), model.NewStringSet(), &mock.Config{})
+	router := NewRouterWithPort(CreateAdminRouter(idlerAPI, &mock.Config{}), testPort)
 
 	var wg sync.WaitGroup
 	ctx, cancel := context.WithCancel(context.Background())
@@ -133,7 +316,7 @@ func Test_cluster_dns_view(t *testing.T) {
 	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/api/idler/cluster", testPort))
 	assert.NoError(t, err, "The call to the API should have succeeded.")
 	body, err := ioutil.ReadAll(resp.Body)
-	expectedResponse := `[{"APIURL":"http://localhost","AppDNS":"example.com"}]
+	expectedResponse := `[{"APIURL":"http://localhost","AppDNS":"example.com","RouteTemplate":"jenkins-{namespace}.example.com","RouteSource":"template"}]
 `
 	assert.Equal(t, expectedResponse, string(body), "Unexpected result from HTTP request")
 	cancel()
@@ -154,6 +337,7 @@ func Test_openshift_url_parameter_is_used(t *testing.T) {
 	tenantService := tenant.NewTenantService(
 		util.EnsureSuffix(tenantTestServer.URL, "/"),
 		"mysecret",
+		0,
 	)
 
 	// a dummy HTTP server for the OpenShift API request which is going to occur
@@ -169,8 +353,8 @@ func Test_openshift_url_parameter_is_used(t *testing.T) {
 
 	clusterView := cluster.NewView([]cluster.Cluster{dummyCluster})
 
-	idlerAPI := api.NewIdlerAPI(openshift.NewUserIdlerMap(), clusterView, tenantService, model.NewStringSet())
-	router := NewRouterWithPort(CreateAPIRouter(idlerAPI), testPort)
+	idlerAPI := api.NewIdlerAPI(openshift.NewUserIdlerMap(), clusterView, tenantService, mock.NewMockFeatureToggle(nil), model.NewStringSet(), model.NewStringSet(), &mock.Config{})
+	router := NewRouterWithPort(CreateAPIRouter(idlerAPI, &mock.Config{}), testPort)
 
 	// start the router
 	var wg sync.WaitGroup
@@ -218,6 +402,7 @@ func stubTenantService() (tenant.Service, func()) {
 	tenantService := tenant.NewTenantService(
 		util.EnsureSuffix(tenantServer.URL, "/"),
 		"mysecret",
+		0,
 	)
 	return tenantService, tenantServer.Close
 }
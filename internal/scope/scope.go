@@ -0,0 +1,135 @@
+package scope
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"gopkg.in/yaml.v2"
+)
+
+// Default holds the scoped tokens of the public API, nil unless configured.
+var Default *Registry
+
+// contextKey is the key of the Scope in the context of a request.
+type contextKey struct{}
+
+// scopesFile is the format of the file read by Load, e.g.
+//
+//	scopes:
+//	- name: team-a-dashboard
+//	  token: 3b4e9c1f...
+//	  namespaces:
+//	  - alice-jenkins
+//	  - team-a-*
+//	  clusters:
+//	  - https://api.starter-us-east-2.openshift.com/
+type scopesFile struct {
+	Scopes []Scope `yaml:"scopes"`
+}
+
+// Scope restricts the callers presenting its token to the namespaces matching any of its patterns, as understood by
+// path.Match, on any of its clusters. Without patterns resp. clusters, any namespace resp. cluster is in scope. A nil
+// Scope is unrestricted.
+type Scope struct {
+	Name       string   `yaml:"name"`
+	Token      string   `yaml:"token"`
+	Namespaces []string `yaml:"namespaces"`
+	Clusters   []string `yaml:"clusters"`
+}
+
+// Allows returns whether the Jenkins namespace, resp. the namespace of the given user, on the cluster with the given
+// API URL is in scope. An empty API URL, i.e. an unknown cluster, is only in scope if the clusters are unrestricted.
+func (s *Scope) Allows(jenkinsNamespace, apiURL string) bool {
+	if s == nil {
+		return true
+	}
+
+	jenkinsNamespace = util.EnsureSuffix(jenkinsNamespace, namespace.JenkinsSuffix)
+	user, _ := namespace.User(jenkinsNamespace)
+	if !namespace.NewFilter(s.Namespaces, nil).Allowed(user, jenkinsNamespace) {
+		return false
+	}
+
+	if len(s.Clusters) == 0 {
+		return true
+	}
+	for _, cluster := range s.Clusters {
+		if apiURL != "" && util.EnsureSuffix(cluster, "/") == util.EnsureSuffix(apiURL, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry resolves the scoped tokens. A nil Registry knows no tokens.
+type Registry struct {
+	scopes []Scope
+}
+
+// Load reads the scoped tokens from the given YAML file.
+func Load(path string) (*Registry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := scopesFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse scopes file %s: %s", path, err)
+	}
+	return NewRegistry(file.Scopes)
+}
+
+// NewRegistry creates a Registry of the given scopes. Each scope needs a name, a unique token and to restrict its
+// callers to some namespaces or clusters.
+func NewRegistry(scopes []Scope) (*Registry, error) {
+	tokens := make(map[string]bool)
+	for _, s := range scopes {
+		switch {
+		case s.Name == "":
+			return nil, fmt.Errorf("scope without name")
+		case s.Token == "":
+			return nil, fmt.Errorf("scope %s has no token", s.Name)
+		case tokens[s.Token]:
+			return nil, fmt.Errorf("scope %s shares its token with another scope", s.Name)
+		case len(s.Namespaces) == 0 && len(s.Clusters) == 0:
+			return nil, fmt.Errorf("scope %s restricts neither namespaces nor clusters", s.Name)
+		}
+		if pattern, ok := namespace.Valid(s.Namespaces); !ok {
+			return nil, fmt.Errorf("scope %s has malformed namespace pattern %s", s.Name, pattern)
+		}
+		tokens[s.Token] = true
+	}
+	return &Registry{scopes: scopes}, nil
+}
+
+// Lookup returns the scope of the given token, if any.
+func (r *Registry) Lookup(token string) (*Scope, bool) {
+	if r == nil || token == "" {
+		return nil, false
+	}
+
+	var found *Scope
+	for i := range r.scopes {
+		// Compare all tokens in constant time, so that the timing does not tell which one got matched.
+		if subtle.ConstantTimeCompare([]byte(r.scopes[i].Token), []byte(token)) == 1 {
+			found = &r.scopes[i]
+		}
+	}
+	return found, found != nil
+}
+
+// NewContext returns a copy of the context carrying the scope the caller is restricted to.
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the scope the caller is restricted to, nil if it is unrestricted.
+func FromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(contextKey{}).(*Scope)
+	return s
+}
@@ -0,0 +1,91 @@
+package scope
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_allows(t *testing.T) {
+	var unrestricted *Scope
+	assert.True(t, unrestricted.Allows("foo-jenkins", ""))
+
+	team := &Scope{Name: "team-a", Namespaces: []string{"alice", "team-a-*"}, Clusters: []string{"https://api.cluster1.example.com"}}
+	assert.True(t, team.Allows("alice-jenkins", "https://api.cluster1.example.com/"), "Namespaces should match by user")
+	assert.True(t, team.Allows("team-a-bob", "https://api.cluster1.example.com/"), "Namespaces should be completed with the Jenkins suffix")
+	assert.False(t, team.Allows("bob-jenkins", "https://api.cluster1.example.com/"))
+	assert.False(t, team.Allows("alice-jenkins", "https://api.cluster2.example.com/"))
+	assert.False(t, team.Allows("alice-jenkins", ""), "Unknown clusters should be out of scope")
+
+	cluster := &Scope{Name: "cluster1", Clusters: []string{"https://api.cluster1.example.com/"}}
+	assert.True(t, cluster.Allows("bob-jenkins", "https://api.cluster1.example.com/"))
+}
+
+func Test_new_registry_validates_scopes(t *testing.T) {
+	var scopes = []struct {
+		scopes []Scope
+		err    string
+	}{
+		{[]Scope{{Token: "a", Namespaces: []string{"foo"}}}, "scope without name"},
+		{[]Scope{{Name: "a", Namespaces: []string{"foo"}}}, "scope a has no token"},
+		{[]Scope{{Name: "a", Token: "a", Namespaces: []string{"foo"}}, {Name: "b", Token: "a", Namespaces: []string{"bar"}}}, "scope b shares its token with another scope"},
+		{[]Scope{{Name: "a", Token: "a"}}, "scope a restricts neither namespaces nor clusters"},
+		{[]Scope{{Name: "a", Token: "a", Namespaces: []string{"[foo"}}}, "scope a has malformed namespace pattern [foo"},
+	}
+
+	for _, s := range scopes {
+		_, err := NewRegistry(s.scopes)
+		assert.EqualError(t, err, s.err)
+	}
+}
+
+func Test_load(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scopes")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "scopes.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`scopes:
+- name: team-a
+  token: team-a-token
+  namespaces:
+  - alice-jenkins
+- name: cluster1
+  token: cluster1-token
+  clusters:
+  - https://api.cluster1.example.com/
+`), 0600))
+
+	registry, err := Load(path)
+	require.NoError(t, err)
+
+	s, ok := registry.Lookup("team-a-token")
+	require.True(t, ok)
+	assert.Equal(t, "team-a", s.Name)
+	assert.Equal(t, []string{"alice-jenkins"}, s.Namespaces)
+
+	s, ok = registry.Lookup("cluster1-token")
+	require.True(t, ok)
+	assert.Equal(t, "cluster1", s.Name)
+
+	_, ok = registry.Lookup("other-token")
+	assert.False(t, ok)
+	_, ok = registry.Lookup("")
+	assert.False(t, ok)
+
+	var none *Registry
+	_, ok = none.Lookup("team-a-token")
+	assert.False(t, ok)
+}
+
+func Test_context(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+
+	s := &Scope{Name: "team-a"}
+	assert.Equal(t, s, FromContext(NewContext(context.Background(), s)))
+}
@@ -0,0 +1,96 @@
+package tenant
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+var capacityLogger = logrus.WithFields(logrus.Fields{"component": "capacity-cache"})
+
+// capacity is the cached capacity state of a cluster.
+type capacity struct {
+	exhausted  bool
+	checkedAt  time.Time
+	refreshing bool
+}
+
+// CapacityCache is a Service caching the result of HasReachedMaxCapacity per cluster. Within the TTL the cached
+// result is returned right away, afterwards the stale result is returned while it gets refreshed in the background.
+// This way a burst of un-idle requests does not query the backend serially for the same cluster.
+type CapacityCache struct {
+	Service
+	sync.Mutex
+	ttl      time.Duration
+	clock    clock.Clock
+	clusters map[string]*capacity
+}
+
+// NewCapacityCache returns a CapacityCache caching the capacity of the clusters as looked up by the given backend for
+// the given TTL.
+func NewCapacityCache(s Service, ttl time.Duration, clock clock.Clock) *CapacityCache {
+	return &CapacityCache{
+		Service:  s,
+		ttl:      ttl,
+		clock:    clock,
+		clusters: make(map[string]*capacity),
+	}
+}
+
+// HasReachedMaxCapacity returns true if the cluster the ns is on has reached maximum capacity. Only the first lookup
+// of a cluster, or one after a failed lookup, waits for the backend.
+func (c *CapacityCache) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	cluster := util.EnsureSuffix(apiURL, "/")
+
+	c.Lock()
+	cached, ok := c.clusters[cluster]
+	if ok && !cached.refreshing && c.clock.Since(cached.checkedAt) >= c.ttl {
+		cached.refreshing = true
+		go c.refresh(cluster, apiURL, ns)
+	}
+	c.Unlock()
+
+	if ok {
+		// entries get replaced rather than updated on refresh
+		return cached.exhausted, nil
+	}
+
+	exhausted, err := c.Service.HasReachedMaxCapacity(apiURL, ns)
+	if err != nil {
+		return exhausted, err
+	}
+	c.store(cluster, exhausted)
+	return exhausted, nil
+}
+
+// refresh looks up the capacity of the cluster, keeping the stale result if the lookup fails.
+func (c *CapacityCache) refresh(cluster, apiURL, ns string) {
+	exhausted, err := c.Service.HasReachedMaxCapacity(apiURL, ns)
+	if err != nil {
+		capacityLogger.WithFields(logrus.Fields{"cluster": apiURL, "namespace": ns, "err": err}).
+			Warn("Refreshing cluster capacity failed, keeping the previous result")
+		c.Lock()
+		c.clusters[cluster].refreshing = false
+		c.Unlock()
+		return
+	}
+	c.store(cluster, exhausted)
+}
+
+// Lookup returns the user owning the namespace and the cluster it lives on, provided the backend indexed the namespace.
+func (c *CapacityCache) Lookup(namespace string) (IndexEntry, bool) {
+	if locator, ok := c.Service.(Locator); ok {
+		return locator.Lookup(namespace)
+	}
+	return IndexEntry{}, false
+}
+
+func (c *CapacityCache) store(cluster string, exhausted bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.clusters[cluster] = &capacity{exhausted: exhausted, checkedAt: c.clock.Now()}
+}
@@ -0,0 +1,60 @@
+package tenant
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capacityBackend reports the configured capacity and signals each lookup.
+type capacityBackend struct {
+	Service
+	exhausted bool
+	err       error
+	lookups   chan string
+}
+
+func (b *capacityBackend) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	b.lookups <- ns
+	return b.exhausted, b.err
+}
+
+func Test_capacity_cache(t *testing.T) {
+	backend := &capacityBackend{lookups: make(chan string, 10)}
+	c := clock.NewFake(time.Date(2018, 4, 11, 8, 0, 0, 0, time.UTC))
+	cache := NewCapacityCache(backend, 30*time.Second, c)
+
+	backend.err = errors.New("tenant service unavailable")
+	_, err := cache.HasReachedMaxCapacity("https://api.example.com", "john-jenkins")
+	assert.Error(t, err, "Failed lookup should not be cached")
+	assert.Equal(t, "john-jenkins", <-backend.lookups)
+
+	backend.err = nil
+	exhausted, err := cache.HasReachedMaxCapacity("https://api.example.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.False(t, exhausted)
+	<-backend.lookups
+
+	backend.exhausted = true
+	exhausted, err = cache.HasReachedMaxCapacity("https://api.example.com/", "jane-jenkins")
+	require.NoError(t, err)
+	assert.False(t, exhausted, "Cached result should be returned within the TTL")
+	assert.Len(t, backend.lookups, 0, "Cluster should not be looked up within the TTL")
+
+	c.Advance(31 * time.Second)
+	exhausted, err = cache.HasReachedMaxCapacity("https://api.example.com", "jane-jenkins")
+	require.NoError(t, err)
+	assert.False(t, exhausted, "Stale result should be returned while refreshing")
+	assert.Equal(t, "jane-jenkins", <-backend.lookups, "Cluster should be refreshed in the background")
+
+	for deadline := time.Now().Add(time.Second); !exhausted && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		exhausted, err = cache.HasReachedMaxCapacity("https://api.example.com", "jane-jenkins")
+		require.NoError(t, err)
+	}
+	assert.True(t, exhausted, "Refreshed result should be returned")
+}
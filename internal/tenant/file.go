@@ -0,0 +1,99 @@
+package tenant
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"gopkg.in/yaml.v2"
+)
+
+// tenantFile is the format of the file read by NewFileService, e.g.
+//
+//	tenants:
+//	- id: 8c55f0a5-6a0b-4a3b-9b4b-3e1b5a0c2f01
+//	  email: john@example.com
+//	  namespaces:
+//	  - name: john-jenkins
+//	    cluster-url: https://api.starter-us-east-2a.openshift.com/
+//	    type: jenkins
+type tenantFile struct {
+	Tenants []struct {
+		ID         string `yaml:"id"`
+		Email      string `yaml:"email"`
+		Namespaces []struct {
+			Name                     string `yaml:"name"`
+			ClusterURL               string `yaml:"cluster-url"`
+			Type                     string `yaml:"type"`
+			ClusterCapacityExhausted bool   `yaml:"cluster-capacity-exhausted"`
+		} `yaml:"namespaces"`
+	} `yaml:"tenants"`
+}
+
+// fileService resolves tenants using a static list, for deployments without fabric8-tenant service.
+type fileService struct {
+	tenants []InfoData
+}
+
+// NewFileService returns a Service resolving the tenants listed in the given YAML file. A namespace without
+// cluster-url matches on any cluster.
+func NewFileService(path string) (Service, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := tenantFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse tenant file %s: %s", path, err)
+	}
+
+	s := &fileService{}
+	for _, t := range file.Tenants {
+		if t.ID == "" {
+			return nil, fmt.Errorf("tenant file %s contains a tenant without id", path)
+		}
+		info := InfoData{ID: t.ID, Type: "tenants", Attributes: Attributes{Email: t.Email}}
+		for _, ns := range t.Namespaces {
+			info.Attributes.Namespaces = append(info.Attributes.Namespaces, Namespace{
+				Name:                     ns.Name,
+				ClusterURL:               ns.ClusterURL,
+				State:                    "ready",
+				Type:                     ns.Type,
+				ClusterCapacityExhausted: ns.ClusterCapacityExhausted,
+			})
+		}
+		s.tenants = append(s.tenants, info)
+	}
+	return s, nil
+}
+
+// GetTenantInfoByNamespace returns the tenants owning the namespace on the given cluster.
+func (s *fileService) GetTenantInfoByNamespace(apiURL string, ns string) (InfoList, error) {
+	tenantInfo := InfoList{}
+	for _, t := range s.tenants {
+		for _, namespace := range t.Attributes.Namespaces {
+			if namespace.Name == ns && (namespace.ClusterURL == "" || util.EnsureSuffix(namespace.ClusterURL, "/") == util.EnsureSuffix(apiURL, "/")) {
+				tenantInfo.Data = append(tenantInfo.Data, t)
+				break
+			}
+		}
+	}
+	tenantInfo.Meta.TotalCount = len(tenantInfo.Data)
+	return tenantInfo, nil
+}
+
+// GetTenantInfoByUserID returns the tenant with the given ID.
+func (s *fileService) GetTenantInfoByUserID(userID string) (Info, error) {
+	for _, t := range s.tenants {
+		if t.ID == userID {
+			return Info{Data: t}, nil
+		}
+	}
+	return Info{}, fmt.Errorf("unknown tenant %s", userID)
+}
+
+// HasReachedMaxCapacity returns true if the namespace is marked as being on a cluster with exhausted capacity.
+func (s *fileService) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	return hasReachedMaxCapacity(s, apiURL, ns)
+}
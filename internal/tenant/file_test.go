@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tenants = `
+tenants:
+- id: john-id
+  email: john@example.com
+  namespaces:
+  - name: john-jenkins
+    cluster-url: https://api.starter-us-east-2a.openshift.com
+    type: jenkins
+    cluster-capacity-exhausted: true
+- id: jane-id
+  namespaces:
+  - name: jane-jenkins
+    type: jenkins
+`
+
+func Test_file_service(t *testing.T) {
+	f, err := ioutil.TempFile("", "tenants")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(tenants)
+	require.NoError(t, err)
+	f.Close()
+
+	s, err := NewFileService(f.Name())
+	require.NoError(t, err)
+
+	ti, err := s.GetTenantInfoByNamespace("https://api.starter-us-east-2a.openshift.com/", "john-jenkins")
+	require.NoError(t, err)
+	require.Len(t, ti.Data, 1)
+	assert.Equal(t, "john-id", ti.Data[0].ID)
+	assert.Equal(t, "john@example.com", ti.Data[0].Attributes.Email)
+
+	ti, err = s.GetTenantInfoByNamespace("https://api.starter-us-east-1a.openshift.com/", "john-jenkins")
+	require.NoError(t, err)
+	assert.Empty(t, ti.Data, "Namespace on another cluster should not match")
+
+	ti, err = s.GetTenantInfoByNamespace("https://api.starter-us-east-1a.openshift.com/", "jane-jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ti.Meta.TotalCount, "Namespace without cluster should match any cluster")
+
+	full, err := s.HasReachedMaxCapacity("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.True(t, full)
+
+	full, err = s.HasReachedMaxCapacity("https://api.starter-us-east-2a.openshift.com", "jane-jenkins")
+	require.NoError(t, err)
+	assert.False(t, full)
+
+	_, err = s.HasReachedMaxCapacity("https://api.starter-us-east-2a.openshift.com", "joe-jenkins")
+	assert.Error(t, err, "Unknown namespace should be reported")
+}
+
+func Test_file_service_rejects_invalid_files(t *testing.T) {
+	_, err := NewFileService("does-not-exist.yaml")
+	assert.Error(t, err)
+
+	f, err := ioutil.TempFile("", "tenants")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("tenants:\n- email: john@example.com\n")
+	f.Close()
+
+	_, err = NewFileService(f.Name())
+	assert.Error(t, err, "Tenant without id should be rejected")
+}
@@ -0,0 +1,106 @@
+package tenant
+
+import (
+	"sync"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+)
+
+// IndexEntry locates a tenant namespace.
+type IndexEntry struct {
+	UserID     string
+	ClusterURL string
+}
+
+// Locator locates the namespaces indexed by a Service.
+type Locator interface {
+	// Lookup returns the user owning the namespace and the cluster it lives on, provided the namespace got indexed.
+	Lookup(namespace string) (IndexEntry, bool)
+}
+
+// IndexedService is a Service maintaining a reverse index of the tenants passing through its lookups, mapping each
+// namespace to the user owning it and the cluster it lives on as well as each user to its namespaces. This allows
+// mapping identities to namespaces and vice versa without further round trips to the backend.
+type IndexedService struct {
+	Service
+	sync.RWMutex
+	namespaces map[string]IndexEntry
+	users      map[string][]Namespace
+}
+
+// NewIndexedService returns an IndexedService looking up tenants using the given backend.
+func NewIndexedService(s Service) *IndexedService {
+	return &IndexedService{
+		Service:    s,
+		namespaces: make(map[string]IndexEntry),
+		users:      make(map[string][]Namespace),
+	}
+}
+
+// GetTenantInfoByNamespace looks up the tenants owning the namespace using the backend and indexes them.
+func (s *IndexedService) GetTenantInfoByNamespace(apiURL string, ns string) (InfoList, error) {
+	tenantInfo, err := s.Service.GetTenantInfoByNamespace(apiURL, ns)
+	if err == nil && tenantInfo.Meta.TotalCount <= 1 {
+		s.index(tenantInfo.Data...)
+	}
+	return tenantInfo, err
+}
+
+// GetTenantInfoByUserID looks up the tenant of the user using the backend and indexes it.
+func (s *IndexedService) GetTenantInfoByUserID(userID string) (Info, error) {
+	tenantInfo, err := s.Service.GetTenantInfoByUserID(userID)
+	if err == nil {
+		s.index(tenantInfo.Data)
+	}
+	return tenantInfo, err
+}
+
+// HasReachedMaxCapacity returns true if the cluster the ns is on has reached maximum capacity.
+func (s *IndexedService) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	return hasReachedMaxCapacity(s, apiURL, ns)
+}
+
+// Lookup returns the user owning the namespace and the cluster it lives on, provided the namespace got indexed.
+func (s *IndexedService) Lookup(namespace string) (IndexEntry, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	entry, ok := s.namespaces[namespace]
+	return entry, ok
+}
+
+// NamespacesByUserID returns the namespaces of the user with the given OSIO user ID, looking up the tenant using
+// the backend unless it is indexed already.
+func (s *IndexedService) NamespacesByUserID(userID string) ([]Namespace, error) {
+	s.RLock()
+	namespaces, ok := s.users[userID]
+	s.RUnlock()
+	if ok {
+		return namespaces, nil
+	}
+
+	tenantInfo, err := s.GetTenantInfoByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return tenantInfo.Data.Attributes.Namespaces, nil
+}
+
+func (s *IndexedService) index(tenants ...InfoData) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, t := range tenants {
+		if t.ID == "" {
+			continue
+		}
+		// forget the namespaces the tenant no longer has
+		for _, ns := range s.users[t.ID] {
+			delete(s.namespaces, ns.Name)
+		}
+		s.users[t.ID] = t.Attributes.Namespaces
+		for _, ns := range t.Attributes.Namespaces {
+			s.namespaces[ns.Name] = IndexEntry{UserID: t.ID, ClusterURL: util.EnsureSuffix(ns.ClusterURL, "/")}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package tenant
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_indexed_service(t *testing.T) {
+	f, err := ioutil.TempFile("", "tenants")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(tenants)
+	require.NoError(t, err)
+	f.Close()
+
+	backend, err := NewFileService(f.Name())
+	require.NoError(t, err)
+	s := NewIndexedService(backend)
+
+	_, ok := s.Lookup("john-jenkins")
+	assert.False(t, ok, "Namespace should not be indexed before a lookup")
+
+	_, err = s.GetTenantInfoByNamespace("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	entry, ok := s.Lookup("john-jenkins")
+	require.True(t, ok)
+	assert.Equal(t, IndexEntry{UserID: "john-id", ClusterURL: "https://api.starter-us-east-2a.openshift.com/"}, entry)
+
+	namespaces, err := s.NamespacesByUserID("jane-id")
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	assert.Equal(t, "jane-jenkins", namespaces[0].Name)
+	entry, ok = s.Lookup("jane-jenkins")
+	require.True(t, ok)
+	assert.Equal(t, "jane-id", entry.UserID)
+
+	_, err = s.NamespacesByUserID("unknown-id")
+	assert.Error(t, err)
+
+	maxed, err := s.HasReachedMaxCapacity("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.True(t, maxed)
+}
@@ -0,0 +1,61 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+)
+
+// namespaceLabelService resolves tenants using the labels of their namespaces on the cluster itself.
+type namespaceLabelService struct {
+	openShiftClient client.OpenShiftClient
+	getToken        func(apiURL string) (string, bool)
+	label           string
+}
+
+// NewNamespaceLabelService returns a Service treating namespaces carrying the given label as tenant namespaces,
+// with the label value as ID of the tenant. The getToken function provides the bearer token of a cluster, e.g.
+// cluster.View.GetToken. As the cluster capacity is unknown, it is never reported as being exhausted.
+func NewNamespaceLabelService(openShiftClient client.OpenShiftClient, getToken func(apiURL string) (string, bool), label string) Service {
+	return &namespaceLabelService{
+		openShiftClient: openShiftClient,
+		getToken:        getToken,
+		label:           label,
+	}
+}
+
+// GetTenantInfoByNamespace returns the tenant owning the namespace, no tenant if the namespace lacks the label.
+func (s *namespaceLabelService) GetTenantInfoByNamespace(apiURL string, ns string) (InfoList, error) {
+	token, ok := s.getToken(apiURL)
+	if !ok {
+		return InfoList{}, fmt.Errorf("Unknown or invalid OpenShift API URL: %s", apiURL)
+	}
+
+	labels, err := s.openShiftClient.NamespaceLabels(apiURL, token, ns)
+	if err != nil {
+		return InfoList{}, err
+	}
+
+	tenantInfo := InfoList{}
+	if id := labels[s.label]; id != "" {
+		tenantInfo.Data = []InfoData{{
+			ID:   id,
+			Type: "tenants",
+			Attributes: Attributes{
+				Namespaces: []Namespace{{Name: ns, ClusterURL: apiURL, State: "ready"}},
+			},
+		}}
+	}
+	tenantInfo.Meta.TotalCount = len(tenantInfo.Data)
+	return tenantInfo, nil
+}
+
+// GetTenantInfoByUserID is not supported, as it would require to search the namespaces of all clusters.
+func (s *namespaceLabelService) GetTenantInfoByUserID(userID string) (Info, error) {
+	return Info{}, fmt.Errorf("looking up tenant %s by user ID is not supported by the namespace label backend", userID)
+}
+
+// HasReachedMaxCapacity returns false as long as the namespace belongs to a tenant.
+func (s *namespaceLabelService) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	return hasReachedMaxCapacity(s, apiURL, ns)
+}
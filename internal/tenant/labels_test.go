@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_namespace_label_service(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	openShiftClient := client.NewMockOpenShiftClient(ctrl)
+	gomock.InOrder(
+		openShiftClient.EXPECT().NamespaceLabels("https://api.starter-us-east-2a.openshift.com", "token", "john-jenkins").
+			Return(map[string]string{"idler.fabric8.io/user-id": "john-id"}, nil).Times(2),
+		openShiftClient.EXPECT().NamespaceLabels("https://api.starter-us-east-2a.openshift.com", "token", "john-jenkins").
+			Return(map[string]string{}, nil),
+	)
+	getToken := func(apiURL string) (string, bool) {
+		return "token", apiURL == "https://api.starter-us-east-2a.openshift.com"
+	}
+	s := NewNamespaceLabelService(openShiftClient, getToken, "idler.fabric8.io/user-id")
+
+	ti, err := s.GetTenantInfoByNamespace("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	require.Len(t, ti.Data, 1)
+	assert.Equal(t, "john-id", ti.Data[0].ID)
+
+	full, err := s.HasReachedMaxCapacity("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.False(t, full)
+
+	_, err = s.GetTenantInfoByNamespace("https://api.starter-us-east-1a.openshift.com", "john-jenkins")
+	assert.Error(t, err, "Unknown cluster should be reported")
+
+	ti, err = s.GetTenantInfoByNamespace("https://api.starter-us-east-2a.openshift.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.Empty(t, ti.Data, "Namespace without label should not belong to a tenant")
+}
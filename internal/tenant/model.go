@@ -9,6 +9,12 @@ type InfoList struct {
 		TotalCount int
 	}
 	Errors []Error `json:"errors"`
+	Links  Links   `json:"links"`
+}
+
+// Links refers to the further pages of a paginated result.
+type Links struct {
+	Next string `json:"next"`
 }
 
 // Info defines a single tenant information.
@@ -5,69 +5,161 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/fault"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+)
+
+const (
+	// Fabric8Backend resolves tenants using the fabric8-tenant service.
+	Fabric8Backend = "fabric8"
+
+	// FileBackend resolves tenants using a static file, see NewFileService.
+	FileBackend = "file"
+
+	// KubernetesBackend resolves tenants using the labels of their namespaces, see NewNamespaceLabelService.
+	KubernetesBackend = "kubernetes"
 )
 
 // Service the interface for the cluster service
 type Service interface {
 	GetTenantInfoByNamespace(apiURL string, ns string) (InfoList, error)
+	GetTenantInfoByUserID(userID string) (Info, error)
 	HasReachedMaxCapacity(apiURL, ns string) (bool, error)
 }
 
+// Recorder to capture the tenant lookups
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
 // Tenant is a simple client for the fabric8-tenant service.
 // The idea is to make this use a Goa client at this point. See issue #105.
 type tenantService struct {
 	tenantServiceURL string
 	authToken        string
+	maxPages         int
 }
 
-// NewTenantService returns an instance implementing Service.
-func NewTenantService(tenantServiceURL string, authToken string) Service {
+// NewTenantService returns an instance implementing Service. Paginated results are followed for at most maxPages
+// pages, 0 meaning no limit.
+func NewTenantService(tenantServiceURL string, authToken string, maxPages int) Service {
 	return &tenantService{
 		authToken:        authToken,
 		tenantServiceURL: tenantServiceURL,
+		maxPages:         maxPages,
 	}
 }
 
-// GetTenantInfoByNamespace gets you InfoList of a tenant given a namespace and api url.
-func (t tenantService) GetTenantInfoByNamespace(apiURL string, ns string) (InfoList, error) {
+// GetTenantInfoByNamespace gets you InfoList of a tenant given a namespace and api url. Paginated results are
+// followed via their next link, the returned InfoList holding the data and errors of all pages.
+func (t tenantService) GetTenantInfoByNamespace(apiURL string, ns string) (tenantInfo InfoList, err error) {
+	startTime := time.Now()
+	pages := 0
+	defer func() {
+		Recorder.RecordTenantLookup(pages, err != nil, time.Since(startTime).Seconds())
+	}()
+
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/tenants", t.tenantServiceURL), nil)
 	if err != nil {
 		return InfoList{}, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.authToken))
-
 	q := req.URL.Query()
 	q.Add("master_url", util.EnsureSuffix(apiURL, "/"))
 	q.Add("namespace", ns)
 	req.URL.RawQuery = q.Encode()
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	next := req.URL
+	for next != nil {
+		if t.maxPages > 0 && pages >= t.maxPages {
+			return InfoList{}, fmt.Errorf("tenant info for %s exceeds %d pages", ns, t.maxPages)
+		}
+
+		page, err := t.getPage(next)
+		pages++
+		if err != nil {
+			return InfoList{}, err
+		}
+
+		if pages == 1 {
+			tenantInfo.Meta = page.Meta
+		}
+		tenantInfo.Data = append(tenantInfo.Data, page.Data...)
+		tenantInfo.Errors = append(tenantInfo.Errors, page.Errors...)
+
+		next = nil
+		if page.Links.Next != "" {
+			link, err := url.Parse(page.Links.Next)
+			if err != nil {
+				return InfoList{}, fmt.Errorf("invalid next link %s in tenant info: %s", page.Links.Next, err)
+			}
+			next = req.URL.ResolveReference(link)
+		}
+	}
+
+	return tenantInfo, nil
+}
+
+// GetTenantInfoByUserID gets you the Info of the tenant of the user with the given OSIO user ID.
+func (t tenantService) GetTenantInfoByUserID(userID string) (Info, error) {
+	pageURL, err := url.Parse(fmt.Sprintf("%s/api/tenants/%s", strings.TrimSuffix(t.tenantServiceURL, "/"), url.PathEscape(userID)))
 	if err != nil {
+		return Info{}, err
+	}
+
+	tenantInfo := Info{}
+	if err := t.get(pageURL, &tenantInfo); err != nil {
+		return Info{}, err
+	}
+	if len(tenantInfo.Errors) != 0 {
+		return Info{}, fmt.Errorf("%s - %s", tenantInfo.Errors[0].Code, tenantInfo.Errors[0].Detail)
+	}
+	return tenantInfo, nil
+}
+
+// getPage fetches a single page of tenant information.
+func (t tenantService) getPage(pageURL *url.URL) (InfoList, error) {
+	tenantInfo := InfoList{}
+	if err := t.get(pageURL, &tenantInfo); err != nil {
 		return InfoList{}, err
 	}
+	return tenantInfo, nil
+}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+// get decodes the response to a GET request of the given URL into v.
+func (t tenantService) get(u *url.URL, v interface{}) error {
+	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return InfoList{}, err
+		return err
 	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.authToken))
 
-	tenantInfo := InfoList{}
-	err = json.Unmarshal(body, &tenantInfo)
+	client := &http.Client{Transport: fault.Transport(fault.Tenant, nil)}
+	resp, err := client.Do(req)
 	if err != nil {
-		return InfoList{}, err
+		return err
 	}
 
-	return tenantInfo, nil
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
 }
 
 // returns true if the cluster the ns is on has reached maximum capacity
 func (t tenantService) HasReachedMaxCapacity(apiURL, ns string) (bool, error) {
+	return hasReachedMaxCapacity(t, apiURL, ns)
+}
 
-	ti, err := t.GetTenantInfoByNamespace(apiURL, ns)
+// hasReachedMaxCapacity returns true if the tenant information of the service reports the cluster the ns is on as
+// having reached its maximum capacity.
+func hasReachedMaxCapacity(s Service, apiURL, ns string) (bool, error) {
+	ti, err := s.GetTenantInfoByNamespace(apiURL, ns)
 	if err != nil {
 		return true, err
 	}
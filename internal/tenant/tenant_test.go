@@ -0,0 +1,62 @@
+package tenant
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tenant_info_follows_next_links(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		switch page {
+		case "":
+			fmt.Fprint(w, `{"data": [{"id": "1"}], "meta": {"totalCount": 3}, "links": {"next": "/api/tenants?page=2"}}`)
+		case "2":
+			fmt.Fprintf(w, `{"data": [{"id": "2"}], "links": {"next": "%s/api/tenants?page=3"}}`, "http://"+r.Host)
+		default:
+			fmt.Fprint(w, `{"data": [{"id": "3"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	ti, err := NewTenantService(server.URL, "secret", 0).GetTenantInfoByNamespace("https://api.example.com", "john-jenkins")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "2", "3"}, pages)
+	assert.Equal(t, 3, ti.Meta.TotalCount)
+	require.Len(t, ti.Data, 3)
+	assert.Equal(t, "3", ti.Data[2].ID)
+
+	pages = nil
+	_, err = NewTenantService(server.URL, "secret", 2).GetTenantInfoByNamespace("https://api.example.com", "john-jenkins")
+	assert.Error(t, err, "Lookup should stop at the page limit")
+	assert.Len(t, pages, 2)
+}
+
+func Test_tenant_info_by_user_id(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tenants/john-id":
+			fmt.Fprint(w, `{"data": {"id": "john-id", "attributes": {"namespaces": [{"name": "john-jenkins"}]}}}`)
+		default:
+			fmt.Fprint(w, `{"errors": [{"code": "not_found", "detail": "unknown tenant"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	s := NewTenantService(server.URL, "secret", 0)
+	ti, err := s.GetTenantInfoByUserID("john-id")
+	require.NoError(t, err)
+	require.Len(t, ti.Data.Attributes.Namespaces, 1)
+	assert.Equal(t, "john-jenkins", ti.Data.Attributes.Namespaces[0].Name)
+
+	_, err = s.GetTenantInfoByUserID("jane-id")
+	assert.Error(t, err)
+}
@@ -1,26 +1,142 @@
 package mock
 
 import (
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/namespace"
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
 )
 
 // Config a mock implementation of the configuration.Configuration interface.
 // It can be used in tests where any field can be explicitly set to return the needed value.
 type Config struct {
-	ProxyURL              string
-	TenantURL             string
-	ToggleURL             string
-	IdleAfter             int
-	IdleLongBuild         int
-	MaxRetries            int
-	MaxRetriesQuietPeriod int
-	CheckInterval         int
-	Debug                 bool
-	FixedUuids            []string
-	AuthURL               string
-	ServiceAccountID      string
-	ServiceAccountSecret  string
-	AuthTokenKey          string
+	ProxyURL                   string
+	TenantURL                  string
+	TenantBackend              string
+	TenantFile                 string
+	TenantUserLabel            string
+	TenantMaxPages             int
+	CapacityCacheTTL           int
+	CapacityRetryAfter         int
+	ToggleProvider             string
+	ToggleURL                  string
+	ToggleBackupPath           string
+	ToggleBootstrapFile        string
+	ToggleFile                 string
+	LaunchDarklyURL            string
+	LaunchDarklyClientID       string
+	IdleAfter                  int
+	IdleLongBuild              int
+	QuietDownTimeout           int
+	ManualUnIdleGrace          int
+	EvictInactiveAfter         int
+	WarmUpConcurrency          int
+	NamespaceAllowlist         []string
+	NamespaceDenylist          []string
+	DisabledClusters           []string
+	MaxIdlesPerMinute          int
+	JenkinsHealthProbe         bool
+	JenkinsHealthPath          string
+	RouteTemplate              string
+	RouteSource                string
+	RouteCheckInterval         int
+	RouteCheckTimeout          int
+	UnidleOnly                 bool
+	UnidleOnlyClusters         []string
+	ActivityNsTypes            []string
+	RolloutNamespaceTypes      []string
+	RolloutHold                int
+	JenkinsNsSuffix            string
+	ActiveBuildPhases          []string
+	HoldStages                 []string
+	HoldStageMax               int
+	MaxRetries                 int
+	MaxRetriesQuietPeriod      int
+	CheckInterval              int
+	DriftCheckInterval         int
+	ClusterCheckIntervals      map[string]int
+	CheckJitter                int
+	Debug                      bool
+	FixedUuids                 []string
+	AuthURL                    string
+	ServiceAccountID           string
+	ServiceAccountSecret       string
+	AuthTokenKey               string
+	ClusterTokenDir            string
+	ClusterTokenExchange       bool
+	ClusterTokenTTL            int
+	TokenExpiryWarning         int
+	Settings                   []configuration.Setting
+	Profile                    string
+	LogLevel                   string
+	LogFormat                  string
+	LogComponentLevels         map[string]string
+	AccessLogSampleRate        float64
+	AccessLogMetricsOnly       bool
+	MaxRequestBodyBytes        int
+	CORSAllowedOrigins         []string
+	CORSAllowedMethods         []string
+	CORSAllowedHeaders         []string
+	APIAddress                 string
+	APIToken                   string
+	APIScopesFile              string
+	AdminAPIAddress            string
+	AdminAPIToken              string
+	GRPCAddress                string
+	HTTPReadTimeout            int
+	HTTPWriteTimeout           int
+	HTTPIdleTimeout            int
+	HTTPMaxHeaderBytes         int
+	HTTPMaxConnections         int
+	RateLimit                  int
+	RateLimitWindow            int
+	TrustedProxies             []string
+	RemediationEnabled         bool
+	RemediationMaxRestart      int
+	RemediationWebhookURL      string
+	OOMKillThreshold           int
+	OOMKillWindow              int
+	OOMLimitIncrease           int
+	OOMMaxMemoryLimit          int
+	WatchIdlerConfigs          bool
+	ResetGracePeriod           int
+	ResetTimeout               int
+	ReservationTTL             int
+	MutationLock               bool
+	MutationLockTimeout        int
+	DLQMaxRetries              int
+	DLQSize                    int
+	PrometheusURL              string
+	ActivityQuery              string
+	ActivityThreshold          float64
+	ContentRepositoryIdleAfter int
+	ContentRepositoryQuery     string
+	AdaptiveIdling             bool
+	PressureIdleAfter          int
+	PressureRelaxAfter         int
+	PressureQuery              string
+	PressureThreshold          float64
+	FootprintInterval          int
+	FootprintCPUQuery          string
+	FootprintMemoryQuery       string
+	NotifyWebhookURL           string
+	NotifyFormat               string
+	NotifyEvents               []string
+	NotifyCapacitySpike        int
+	DigestSchedule             string
+	DigestTop                  int
+	DigestWebhookURL           string
+	DigestEmailTo              []string
+	DigestEmailFrom            string
+	DigestSMTPAddress          string
+	DigestSMTPUsername         string
+	DigestSMTPPassword         string
+	BuildLabelSelector         string
+	BuildFieldSelector         string
+	DCLabelSelector            string
+	DCFieldSelector            string
+	PodLabelSelector           string
+	PodFieldSelector           string
 }
 
 // GetProxyURL returns the Jenkins Proxy API URL.
@@ -38,21 +154,209 @@ func (c *Config) GetAuthURL() string {
 	return c.AuthURL
 }
 
+// GetTenantBackend returns the source of the tenant information.
+func (c *Config) GetTenantBackend() string {
+	return c.TenantBackend
+}
+
+// GetTenantFile returns the path of the tenant file.
+func (c *Config) GetTenantFile() string {
+	return c.TenantFile
+}
+
+// GetTenantUserLabel returns the namespace label carrying the user ID.
+func (c *Config) GetTenantUserLabel() string {
+	return c.TenantUserLabel
+}
+
+// GetTenantMaxPages returns the maximum number of result pages followed per tenant lookup.
+func (c *Config) GetTenantMaxPages() int {
+	return c.TenantMaxPages
+}
+
+// GetCapacityCacheTTL returns the number of seconds the capacity of a cluster is cached.
+func (c *Config) GetCapacityCacheTTL() int {
+	return c.CapacityCacheTTL
+}
+
+// GetCapacityRetryAfter returns the number of seconds to wait before retrying an un-idle refused due to capacity.
+func (c *Config) GetCapacityRetryAfter() int {
+	return c.CapacityRetryAfter
+}
+
+// GetToggleProvider returns the name of the feature toggle provider.
+func (c *Config) GetToggleProvider() string {
+	return c.ToggleProvider
+}
+
 // GetToggleURL returns the Toggle Service URL.
 func (c *Config) GetToggleURL() string {
 	return c.ToggleURL
 }
 
+// GetToggleBackupPath returns the directory the toggle definitions are backed up to.
+func (c *Config) GetToggleBackupPath() string {
+	return c.ToggleBackupPath
+}
+
+// GetToggleBootstrapFile returns the file the toggle definitions are loaded from unless backed up before.
+func (c *Config) GetToggleBootstrapFile() string {
+	return c.ToggleBootstrapFile
+}
+
+// GetToggleFile returns the YAML file defining the features of the configmap toggle provider.
+func (c *Config) GetToggleFile() string {
+	return c.ToggleFile
+}
+
+// GetLaunchDarklyURL returns the base URL of the LaunchDarkly client-side SDK endpoints.
+func (c *Config) GetLaunchDarklyURL() string {
+	return c.LaunchDarklyURL
+}
+
+// GetLaunchDarklyClientID returns the client-side ID of the LaunchDarkly environment.
+func (c *Config) GetLaunchDarklyClientID() string {
+	return c.LaunchDarklyClientID
+}
+
 // GetIdleAfter returns the number of minutes before Jenkins is idled.
 func (c *Config) GetIdleAfter() int {
 	return c.IdleAfter
 }
 
+// GetManualUnIdleGracePeriod returns the number of minutes Jenkins is not idled after it got un-idled manually.
+func (c *Config) GetManualUnIdleGracePeriod() int {
+	return c.ManualUnIdleGrace
+}
+
+// GetEvictInactiveAfter returns the number of days after which the user-idler of an inactive namespace is evicted.
+func (c *Config) GetEvictInactiveAfter() int {
+	return c.EvictInactiveAfter
+}
+
+// GetWarmUpConcurrency returns the number of Jenkins namespaces of a cluster warmed up concurrently at startup.
+func (c *Config) GetWarmUpConcurrency() int {
+	return c.WarmUpConcurrency
+}
+
+// GetNamespaceAllowlist returns the patterns of the namespaces managed by the Idler.
+func (c *Config) GetNamespaceAllowlist() []string {
+	return c.NamespaceAllowlist
+}
+
+// GetNamespaceDenylist returns the patterns of the namespaces ignored by the Idler.
+func (c *Config) GetNamespaceDenylist() []string {
+	return c.NamespaceDenylist
+}
+
+// GetDisabledClusters returns the API URLs of the clusters for which idling is disabled on startup.
+func (c *Config) GetDisabledClusters() []string {
+	return c.DisabledClusters
+}
+
+// GetMaxIdlesPerMinute returns the maximum number of idle operations per minute and cluster.
+func (c *Config) GetMaxIdlesPerMinute() int {
+	return c.MaxIdlesPerMinute
+}
+
+// GetJenkinsHealthProbe returns `true` if a running Jenkins is only considered running once it serves requests.
+func (c *Config) GetJenkinsHealthProbe() bool {
+	return c.JenkinsHealthProbe
+}
+
+// GetJenkinsHealthPath returns the path probed on the Jenkins route.
+func (c *Config) GetJenkinsHealthPath() string {
+	return c.JenkinsHealthPath
+}
+
+// GetRouteTemplate returns the template of the host names of the Jenkins routes, jenkins-{namespace}.{appDomain}
+// unless set.
+func (c *Config) GetRouteTemplate() string {
+	if c.RouteTemplate == "" {
+		return "jenkins-{namespace}.{appDomain}"
+	}
+	return c.RouteTemplate
+}
+
+// GetRouteSource returns how the Jenkins routes are determined, template unless set.
+func (c *Config) GetRouteSource() string {
+	if c.RouteSource == "" {
+		return "template"
+	}
+	return c.RouteSource
+}
+
+// GetRouteCheckInterval returns the number of minutes between the checks of the Jenkins routes.
+func (c *Config) GetRouteCheckInterval() int {
+	return c.RouteCheckInterval
+}
+
+// GetRouteCheckTimeout returns the number of seconds a check of a Jenkins route may take.
+func (c *Config) GetRouteCheckTimeout() int {
+	return c.RouteCheckTimeout
+}
+
+// GetUnidleOnly returns `true` if Jenkins instances are never idled on any cluster.
+func (c *Config) GetUnidleOnly() bool {
+	return c.UnidleOnly
+}
+
+// GetUnidleOnlyClusters returns the API URLs of the clusters on which Jenkins instances are never idled.
+func (c *Config) GetUnidleOnlyClusters() []string {
+	return c.UnidleOnlyClusters
+}
+
+// GetActivityNamespaceTypes returns the types of the user namespaces in which running pods keep Jenkins from being idled.
+func (c *Config) GetActivityNamespaceTypes() []string {
+	return c.ActivityNsTypes
+}
+
+// GetRolloutNamespaceTypes returns the types of the user namespaces whose rollouts keep Jenkins from being idled.
+func (c *Config) GetRolloutNamespaceTypes() []string {
+	return c.RolloutNamespaceTypes
+}
+
+// GetRolloutHold returns the number of minutes after a build during which rollouts keep Jenkins from being idled.
+func (c *Config) GetRolloutHold() int {
+	return c.RolloutHold
+}
+
+// GetJenkinsNamespaceSuffix returns the suffix of the Jenkins namespaces, -jenkins unless set.
+func (c *Config) GetJenkinsNamespaceSuffix() string {
+	if c.JenkinsNsSuffix == "" {
+		return namespace.DefaultJenkinsSuffix
+	}
+	return c.JenkinsNsSuffix
+}
+
+// GetActiveBuildPhases returns the build phases in which a build counts as activity, the default ones unless set.
+func (c *Config) GetActiveBuildPhases() []string {
+	if c.ActiveBuildPhases == nil {
+		return model.ActivePhases()
+	}
+	return c.ActiveBuildPhases
+}
+
+// GetHoldStages returns the patterns of the pipeline stages during which Jenkins is not idled.
+func (c *Config) GetHoldStages() []string {
+	return c.HoldStages
+}
+
+// GetHoldStageMax returns the number of hours a pipeline stage keeps Jenkins from being idled at most.
+func (c *Config) GetHoldStageMax() int {
+	return c.HoldStageMax
+}
+
 // GetIdleLongBuild returns the number of minutes before Jenkins is idled.
 func (c *Config) GetIdleLongBuild() int {
 	return c.IdleLongBuild
 }
 
+// GetQuietDownTimeout returns the number of minutes running jobs are waited for before idling.
+func (c *Config) GetQuietDownTimeout() int {
+	return c.QuietDownTimeout
+}
+
 // GetMaxRetries returns the maximum number of retries to idle resp. un-idle the Jenkins service.
 func (c *Config) GetMaxRetries() int {
 	return c.MaxRetries
@@ -63,11 +367,26 @@ func (c *Config) GetMaxRetriesQuietInterval() int {
 	return c.MaxRetriesQuietPeriod
 }
 
+// GetDriftCheckInterval returns the number of minutes between the drift checks.
+func (c *Config) GetDriftCheckInterval() int {
+	return c.DriftCheckInterval
+}
+
 // GetCheckInterval returns the number of minutes after which a regular idle check occurs.
 func (c *Config) GetCheckInterval() int {
 	return c.CheckInterval
 }
 
+// GetClusterCheckIntervals returns the check intervals of the clusters deviating from the global check interval.
+func (c *Config) GetClusterCheckIntervals() map[string]int {
+	return c.ClusterCheckIntervals
+}
+
+// GetCheckJitter returns the percentage by which the check interval is randomly shifted.
+func (c *Config) GetCheckJitter() int {
+	return c.CheckJitter
+}
+
 // GetDebugMode returns if debug mode should be enabled.
 func (c *Config) GetDebugMode() bool {
 	return c.Debug
@@ -94,12 +413,153 @@ func (c *Config) GetAuthTokenKey() string {
 	return c.AuthTokenKey
 }
 
+// GetClusterTokenDir returns the directory of the per-cluster token files.
+func (c *Config) GetClusterTokenDir() string {
+	return c.ClusterTokenDir
+}
+
+// GetClusterTokenExchange returns whether the cluster tokens are exchanged on demand.
+func (c *Config) GetClusterTokenExchange() bool {
+	return c.ClusterTokenExchange
+}
+
+// GetClusterTokenTTL returns the number of minutes an exchanged cluster token is cached.
+func (c *Config) GetClusterTokenTTL() int {
+	return c.ClusterTokenTTL
+}
+
+// GetTokenExpiryWarning returns the number of minutes before the expiry of a cluster token at which warnings are
+// logged.
+func (c *Config) GetTokenExpiryWarning() int {
+	return c.TokenExpiryWarning
+}
+
 // GetAuthGrantType returns the fabric8-auth Grant type used while retrieving
 // user account token
 func (c *Config) GetAuthGrantType() string {
 	return "client_credentials"
 }
 
+// GetProfile returns the name of the configuration profile the Idler is running with.
+func (c *Config) GetProfile() string {
+	return c.Profile
+}
+
+// GetLogLevel returns the global log level.
+func (c *Config) GetLogLevel() string {
+	return c.LogLevel
+}
+
+// GetLogFormat returns the log output format, either 'json' or 'text'.
+func (c *Config) GetLogFormat() string {
+	return c.LogFormat
+}
+
+// GetLogComponentLevels returns the log level overrides keyed against the component name.
+func (c *Config) GetLogComponentLevels() map[string]string {
+	return c.LogComponentLevels
+}
+
+// GetAccessLogSampleRate returns the fraction (0.0 - 1.0) of successful API requests which get access logged.
+func (c *Config) GetAccessLogSampleRate() float64 {
+	return c.AccessLogSampleRate
+}
+
+// GetAccessLogMetricsOnly returns `true` if API requests should only be recorded as metrics.
+func (c *Config) GetAccessLogMetricsOnly() bool {
+	return c.AccessLogMetricsOnly
+}
+
+// GetMaxRequestBodyBytes returns the maximum size in bytes of an API request body.
+func (c *Config) GetMaxRequestBodyBytes() int {
+	return c.MaxRequestBodyBytes
+}
+
+// GetCORSAllowedOrigins returns the origins allowed to make cross-origin requests against the API.
+func (c *Config) GetCORSAllowedOrigins() []string {
+	return c.CORSAllowedOrigins
+}
+
+// GetCORSAllowedMethods returns the HTTP methods allowed for cross-origin requests.
+func (c *Config) GetCORSAllowedMethods() []string {
+	return c.CORSAllowedMethods
+}
+
+// GetCORSAllowedHeaders returns the request headers allowed for cross-origin requests.
+func (c *Config) GetCORSAllowedHeaders() []string {
+	return c.CORSAllowedHeaders
+}
+
+// GetAPIAddress returns the address the public API listens on.
+func (c *Config) GetAPIAddress() string {
+	return c.APIAddress
+}
+
+// GetAPIToken returns the bearer token required to call the public API.
+func (c *Config) GetAPIToken() string {
+	return c.APIToken
+}
+
+// GetAPIScopesFile returns the path of the YAML file defining the scoped tokens of the public API.
+func (c *Config) GetAPIScopesFile() string {
+	return c.APIScopesFile
+}
+
+// GetGRPCAddress returns the address the gRPC API listens on.
+func (c *Config) GetGRPCAddress() string {
+	return c.GRPCAddress
+}
+
+// GetAdminAPIAddress returns the address the admin API listens on.
+func (c *Config) GetAdminAPIAddress() string {
+	return c.AdminAPIAddress
+}
+
+// GetAdminAPIToken returns the bearer token required to call the admin API.
+func (c *Config) GetAdminAPIToken() string {
+	return c.AdminAPIToken
+}
+
+// GetHTTPReadTimeout returns the number of seconds the API server waits for a complete request.
+func (c *Config) GetHTTPReadTimeout() int {
+	return c.HTTPReadTimeout
+}
+
+// GetHTTPWriteTimeout returns the number of seconds within which the API server needs to have written the response.
+func (c *Config) GetHTTPWriteTimeout() int {
+	return c.HTTPWriteTimeout
+}
+
+// GetHTTPIdleTimeout returns the number of seconds the API server keeps an idle keep-alive connection open.
+func (c *Config) GetHTTPIdleTimeout() int {
+	return c.HTTPIdleTimeout
+}
+
+// GetHTTPMaxHeaderBytes returns the maximum size in bytes of the request headers accepted by the API server.
+func (c *Config) GetHTTPMaxHeaderBytes() int {
+	return c.HTTPMaxHeaderBytes
+}
+
+// GetHTTPMaxConnections returns the maximum number of concurrent connections per API listener.
+func (c *Config) GetHTTPMaxConnections() int {
+	return c.HTTPMaxConnections
+}
+
+// GetRateLimit returns the number of requests each caller may make per rate limit window.
+func (c *Config) GetRateLimit() int {
+	return c.RateLimit
+}
+
+// GetRateLimitWindow returns the number of seconds of the rate limit window.
+func (c *Config) GetRateLimitWindow() int {
+	return c.RateLimitWindow
+}
+
+// GetTrustedProxies returns the proxies whose X-Forwarded-For header is trusted.
+func (c *Config) GetTrustedProxies() []string {
+	return c.TrustedProxies
+}
+
 // Verify validates the configuration and returns an error in case the configuration is missing required settings
 // or contains invalid settings. If the configuration is correct nil is returned.
 func (c *Config) Verify() util.MultiError {
@@ -110,3 +570,238 @@ func (c *Config) Verify() util.MultiError {
 func (c *Config) String() string {
 	return "mockConfig"
 }
+
+// Effective returns the effective configuration settings.
+func (c *Config) Effective() []configuration.Setting {
+	return c.Settings
+}
+
+// GetRemediationEnabled returns `true` if crash-looping Jenkins pods should be reset automatically.
+func (c *Config) GetRemediationEnabled() bool {
+	return c.RemediationEnabled
+}
+
+// GetRemediationMaxRestarts returns the number of restarts of a crash-looping Jenkins pod after which it gets reset.
+func (c *Config) GetRemediationMaxRestarts() int {
+	return c.RemediationMaxRestart
+}
+
+// GetRemediationWebhookURL returns the URL notified about remediation actions.
+func (c *Config) GetRemediationWebhookURL() string {
+	return c.RemediationWebhookURL
+}
+
+// GetOOMKillThreshold returns the number of OOM kills after which a memory limit increase is recommended.
+func (c *Config) GetOOMKillThreshold() int {
+	return c.OOMKillThreshold
+}
+
+// GetOOMKillWindow returns the number of minutes within which the OOM kills are counted.
+func (c *Config) GetOOMKillWindow() int {
+	return c.OOMKillWindow
+}
+
+// GetOOMLimitIncrease returns the percentage by which the memory limit is recommended to be increased.
+func (c *Config) GetOOMLimitIncrease() int {
+	return c.OOMLimitIncrease
+}
+
+// GetOOMMaxMemoryLimit returns the maximum memory limit in MiB recommended for Jenkins.
+func (c *Config) GetOOMMaxMemoryLimit() int {
+	return c.OOMMaxMemoryLimit
+}
+
+// GetWatchIdlerConfigs returns whether the JenkinsIdlerConfig custom resources are watched.
+func (c *Config) GetWatchIdlerConfigs() bool {
+	return c.WatchIdlerConfigs
+}
+
+// GetResetGracePeriod returns the number of seconds the containers of a reset pod get to terminate gracefully.
+func (c *Config) GetResetGracePeriod() int {
+	return c.ResetGracePeriod
+}
+
+// GetResetTimeout returns the number of seconds a reset waits for the replacement pods to get scheduled.
+func (c *Config) GetResetTimeout() int {
+	return c.ResetTimeout
+}
+
+// GetReservationTTL returns the number of seconds an un-idle reservation keeps Jenkins from being idled.
+func (c *Config) GetReservationTTL() int {
+	return c.ReservationTTL
+}
+
+// GetMutationLock returns `true` if the mutations of Jenkins are serialized per namespace.
+func (c *Config) GetMutationLock() bool {
+	return c.MutationLock
+}
+
+// GetMutationLockTimeout returns the number of seconds a mutation waits for the lock of its namespace.
+func (c *Config) GetMutationLockTimeout() int {
+	return c.MutationLockTimeout
+}
+
+// GetDLQMaxRetries returns the number of times a failed event is retried.
+func (c *Config) GetDLQMaxRetries() int {
+	return c.DLQMaxRetries
+}
+
+// GetDLQSize returns the maximum number of failed events kept.
+func (c *Config) GetDLQSize() int {
+	return c.DLQSize
+}
+
+// GetPrometheusURL returns the URL of the Prometheus instance queried for the activity of Jenkins.
+func (c *Config) GetPrometheusURL() string {
+	return c.PrometheusURL
+}
+
+// GetActivityQuery returns the PromQL query yielding the activity of Jenkins.
+func (c *Config) GetActivityQuery() string {
+	return c.ActivityQuery
+}
+
+// GetActivityThreshold returns the value of the activity query above which Jenkins is considered active.
+func (c *Config) GetActivityThreshold() float64 {
+	return c.ActivityThreshold
+}
+
+// GetContentRepositoryIdleAfter returns the minutes without requests after which the content-repository is idled.
+func (c *Config) GetContentRepositoryIdleAfter() int {
+	return c.ContentRepositoryIdleAfter
+}
+
+// GetContentRepositoryQuery returns the PromQL query yielding the requests to the content-repository.
+func (c *Config) GetContentRepositoryQuery() string {
+	return c.ContentRepositoryQuery
+}
+
+// GetAdaptiveIdling returns true if idle timeouts are shortened while a cluster is under resource pressure.
+func (c *Config) GetAdaptiveIdling() bool {
+	return c.AdaptiveIdling
+}
+
+// GetPressureIdleAfter returns the idle timeout in minutes while a cluster is under resource pressure.
+func (c *Config) GetPressureIdleAfter() int {
+	return c.PressureIdleAfter
+}
+
+// GetPressureRelaxAfter returns the number of minutes without pressure signal after which the pressure is relieved.
+func (c *Config) GetPressureRelaxAfter() int {
+	return c.PressureRelaxAfter
+}
+
+// GetPressureQuery returns the PromQL query yielding the resource pressure of a cluster.
+func (c *Config) GetPressureQuery() string {
+	return c.PressureQuery
+}
+
+// GetPressureThreshold returns the value of the pressure query from which on a cluster is considered under pressure.
+func (c *Config) GetPressureThreshold() float64 {
+	return c.PressureThreshold
+}
+
+// GetFootprintInterval returns the number of minutes between the measurements of the resource usage.
+func (c *Config) GetFootprintInterval() int {
+	return c.FootprintInterval
+}
+
+// GetFootprintCPUQuery returns the PromQL query yielding the CPU cores consumed by Jenkins.
+func (c *Config) GetFootprintCPUQuery() string {
+	return c.FootprintCPUQuery
+}
+
+// GetFootprintMemoryQuery returns the PromQL query yielding the bytes of memory consumed by Jenkins.
+func (c *Config) GetFootprintMemoryQuery() string {
+	return c.FootprintMemoryQuery
+}
+
+// GetNotifyWebhookURL returns the URL of the webhook notified about notable events.
+func (c *Config) GetNotifyWebhookURL() string {
+	return c.NotifyWebhookURL
+}
+
+// GetNotifyFormat returns the format of the notifications.
+func (c *Config) GetNotifyFormat() string {
+	return c.NotifyFormat
+}
+
+// GetNotifyEvents returns the classes of the events to notify about.
+func (c *Config) GetNotifyEvents() []string {
+	return c.NotifyEvents
+}
+
+// GetNotifyCapacitySpike returns the number of capacity refusals within five minutes which is notified about.
+func (c *Config) GetNotifyCapacitySpike() int {
+	return c.NotifyCapacitySpike
+}
+
+// GetDigestSchedule returns how often a digest of the activity of the Idler is sent.
+func (c *Config) GetDigestSchedule() string {
+	return c.DigestSchedule
+}
+
+// GetDigestTop returns the number of never idling namespaces listed in a digest.
+func (c *Config) GetDigestTop() int {
+	return c.DigestTop
+}
+
+// GetDigestWebhookURL returns the URL of the webhook the digests are posted to.
+func (c *Config) GetDigestWebhookURL() string {
+	return c.DigestWebhookURL
+}
+
+// GetDigestEmailTo returns the addresses the digests are emailed to.
+func (c *Config) GetDigestEmailTo() []string {
+	return c.DigestEmailTo
+}
+
+// GetDigestEmailFrom returns the sender address of the digest emails.
+func (c *Config) GetDigestEmailFrom() string {
+	return c.DigestEmailFrom
+}
+
+// GetDigestSMTPAddress returns the address of the SMTP server the digest emails are sent through.
+func (c *Config) GetDigestSMTPAddress() string {
+	return c.DigestSMTPAddress
+}
+
+// GetDigestSMTPUsername returns the username authenticating the Idler to the SMTP server.
+func (c *Config) GetDigestSMTPUsername() string {
+	return c.DigestSMTPUsername
+}
+
+// GetDigestSMTPPassword returns the password authenticating the Idler to the SMTP server.
+func (c *Config) GetDigestSMTPPassword() string {
+	return c.DigestSMTPPassword
+}
+
+// GetBuildLabelSelector returns the label selector restricting the watched builds.
+func (c *Config) GetBuildLabelSelector() string {
+	return c.BuildLabelSelector
+}
+
+// GetBuildFieldSelector returns the field selector restricting the watched builds.
+func (c *Config) GetBuildFieldSelector() string {
+	return c.BuildFieldSelector
+}
+
+// GetDCLabelSelector returns the label selector restricting the watched deployment configs.
+func (c *Config) GetDCLabelSelector() string {
+	return c.DCLabelSelector
+}
+
+// GetDCFieldSelector returns the field selector restricting the watched deployment configs.
+func (c *Config) GetDCFieldSelector() string {
+	return c.DCFieldSelector
+}
+
+// GetPodLabelSelector returns the label selector restricting the watched pods.
+func (c *Config) GetPodLabelSelector() string {
+	return c.PodLabelSelector
+}
+
+// GetPodFieldSelector returns the field selector restricting the watched pods.
+func (c *Config) GetPodFieldSelector() string {
+	return c.PodFieldSelector
+}
@@ -6,7 +6,8 @@ import (
 )
 
 type featureToggle struct {
-	uuids []string
+	uuids    []string
+	cheUuids []string
 }
 
 // NewMockFeatureToggle returns a new instance of featureToggle(toggles.Features).
@@ -14,6 +15,35 @@ func NewMockFeatureToggle(validIds []string) toggles.Features {
 	return &featureToggle{uuids: validIds}
 }
 
-func (m *featureToggle) IsIdlerEnabled(uid string) (bool, error) {
-	return util.Contains(m.uuids, uid), nil
+// NewMockCheFeatureToggle returns a new instance of featureToggle(toggles.Features) enabling Che idling as well.
+func NewMockCheFeatureToggle(validIds []string) toggles.Features {
+	return &featureToggle{uuids: validIds, cheUuids: validIds}
+}
+
+func (m *featureToggle) IsIdlerEnabled(target toggles.Target) (bool, error) {
+	return util.Contains(m.uuids, target.UserID), nil
+}
+
+func (m *featureToggle) IsCheIdlerEnabled(target toggles.Target) (bool, error) {
+	return util.Contains(m.cheUuids, target.UserID), nil
+}
+
+func (m *featureToggle) IsOOMLimitBumpEnabled(target toggles.Target) (bool, error) {
+	return false, nil
+}
+
+type experimentToggle struct {
+	featureToggle
+	variants map[string]toggles.Variant
+}
+
+// NewMockExperimentToggle returns a new instance of featureToggle(toggles.Features) assigning the users to the
+// variants of all experiments as given by the map keyed against the user ID.
+func NewMockExperimentToggle(validIds []string, variants map[string]toggles.Variant) toggles.Features {
+	return &experimentToggle{featureToggle: featureToggle{uuids: validIds}, variants: variants}
+}
+
+func (m *experimentToggle) Variant(experiment string, target toggles.Target) (toggles.Variant, bool) {
+	variant, ok := m.variants[target.UserID]
+	return variant, ok
 }
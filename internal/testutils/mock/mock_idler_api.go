@@ -54,6 +54,11 @@ func (i *IdlerAPI) ClusterDNSView(w http.ResponseWriter, req *http.Request, ps h
 	w.WriteHeader(http.StatusOK)
 }
 
+// JenkinsRoute writes the URL of the Jenkins route of the namespace.
+func (i *IdlerAPI) JenkinsRoute(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	w.Write([]byte("JenkinsRoute"))
+}
+
 //SetUserIdlerStatus sets the user status
 func (i *IdlerAPI) SetUserIdlerStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	_, err := w.Write([]byte("SetUserIdlerStatus"))
@@ -70,3 +75,127 @@ func (i *IdlerAPI) GetDisabledUserIdlers(w http.ResponseWriter, r *http.Request,
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// SetClusterStatus enables resp. disables idling for whole clusters.
+func (i *IdlerAPI) SetClusterStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("SetClusterStatus"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetDisabledClusters writes the clusters for which idling is disabled.
+func (i *IdlerAPI) GetDisabledClusters(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("GetDisabledClusters"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// EventStream writes the state change events to the response writer.
+func (i *IdlerAPI) EventStream(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("EventStream"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reserve un-idles and reserves Jenkins.
+func (i *IdlerAPI) Reserve(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("Reserve"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Reservation checks a reservation.
+func (i *IdlerAPI) Reservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("Reservation"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReleaseReservation releases a reservation.
+func (i *IdlerAPI) ReleaseReservation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("ReleaseReservation"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeadLetters writes the failed events.
+func (i *IdlerAPI) DeadLetters(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("DeadLetters"))
+}
+
+// MemoryRecommendations writes the recommended memory limit increases.
+func (i *IdlerAPI) MemoryRecommendations(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("MemoryRecommendations"))
+}
+
+// RateLimits writes the rate limit usage of the callers.
+func (i *IdlerAPI) RateLimits(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("RateLimits"))
+}
+
+// Faults writes the fault injection rules.
+func (i *IdlerAPI) Faults(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("Faults"))
+}
+
+// SetFault adds a fault injection rule.
+func (i *IdlerAPI) SetFault(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("SetFault"))
+}
+
+// ClearFaults removes fault injection rules.
+func (i *IdlerAPI) ClearFaults(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("ClearFaults"))
+}
+
+// RegisterCallback registers a callback.
+func (i *IdlerAPI) RegisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("RegisterCallback"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// UnregisterCallback removes a callback.
+func (i *IdlerAPI) UnregisterCallback(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("UnregisterCallback"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// JenkinsVersions writes the Jenkins versions to the response writer.
+func (i *IdlerAPI) JenkinsVersions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("JenkinsVersions"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// AggregateStatus writes the aggregate status to the response writer.
+func (i *IdlerAPI) AggregateStatus(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("AggregateStatus"))
+}
+
+// LogLevel writes the log levels to the response writer.
+func (i *IdlerAPI) LogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("LogLevel"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// SetLogLevel changes the log level.
+func (i *IdlerAPI) SetLogLevel(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("SetLogLevel"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// IdlerTimers writes the idler timers to the response writer.
+func (i *IdlerAPI) IdlerTimers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("IdlerTimers"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Version writes the build and runtime information of the Idler to the response writer.
+func (i *IdlerAPI) Version(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("Version"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// Toggles writes the feature toggle definitions to the response writer.
+func (i *IdlerAPI) Toggles(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("Toggles"))
+	w.WriteHeader(http.StatusOK)
+}
+
+// EffectiveConfig writes the effective configuration to the response writer.
+func (i *IdlerAPI) EffectiveConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Write([]byte("EffectiveConfig"))
+}
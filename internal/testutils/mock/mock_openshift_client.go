@@ -1,9 +1,12 @@
 package mock
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/model"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openshift/client"
 )
 
 // OpenShiftClient is a client for OpenShift API
@@ -13,6 +16,28 @@ type OpenShiftClient struct {
 	IdleCallCount   int
 	UnIdleCallCount int
 	IdleError       string
+	DCs             []model.DeploymentConfig
+	Namespaces      []string
+	Builds          []model.Build
+	PodRestarts     model.PodRestarts
+	ResetCallCount  int
+	ResetOptions    client.ResetOptions
+	RolloutCount    int
+	Unhealthy       bool
+	JenkinsVersion  string
+	Labels          map[string]string
+	Annotations     map[string]map[string]string
+	PodsRunning     map[string]int
+	Rollouts        map[string][]string
+	IdledNamespaces []string
+	EndpointsIdled  time.Time
+	LeaseHolders    map[string]string
+	RouteURLs       map[string]string
+	Usages          map[string]model.Usage
+	MemoryLimits    map[string]int64
+	RunningJobs     int
+	QuietDownCount  int
+	QuietingDown    bool
 }
 
 // Idle mocks Idle method of client.OpenShiftClient.
@@ -43,14 +68,71 @@ func (c *OpenShiftClient) State(apiURL string, bearerToken string, namespace str
 	return c.IdleState, nil
 }
 
+// EndpointsIdledAt returns EndpointsIdled.
+func (c *OpenShiftClient) EndpointsIdledAt(apiURL string, bearerToken string, namespace string, service string) (time.Time, error) {
+	return c.EndpointsIdled, nil
+}
+
+// AcquireLease acquires the lease of the namespace unless LeaseHolders lists another holder for it.
+func (c *OpenShiftClient) AcquireLease(apiURL string, bearerToken string, namespace string, name string, holder string, duration time.Duration) (bool, error) {
+	if c.LeaseHolders == nil {
+		c.LeaseHolders = make(map[string]string)
+	}
+	if current, ok := c.LeaseHolders[namespace]; ok && current != holder {
+		return false, nil
+	}
+	c.LeaseHolders[namespace] = holder
+	return true, nil
+}
+
+// ReleaseLease releases the lease of the namespace, provided it is held by the holder.
+func (c *OpenShiftClient) ReleaseLease(apiURL string, bearerToken string, namespace string, name string, holder string) error {
+	if c.LeaseHolders[namespace] == holder {
+		delete(c.LeaseHolders, namespace)
+	}
+	return nil
+}
+
 // Reset deletes a pod and start a new one
-func (c *OpenShiftClient) Reset(apiURL string, bearerToken string, namespace string) error {
+func (c *OpenShiftClient) Reset(apiURL string, bearerToken string, namespace string, service string, options client.ResetOptions) error {
+	c.ResetCallCount++
+	c.ResetOptions = options
 	if c.IdleError != "" {
 		return fmt.Errorf(c.IdleError)
 	}
 	return nil
 }
 
+// Rollout mocks Rollout method of client.OpenShiftClient.
+// It increases RolloutCount by 1 and names the rollouts after their count.
+func (c *OpenShiftClient) Rollout(apiURL string, bearerToken string, namespace string, service string) (string, error) {
+	if c.IdleError != "" {
+		return "", fmt.Errorf(c.IdleError)
+	}
+	c.RolloutCount++
+	return fmt.Sprintf("%s-%d", service, c.RolloutCount), nil
+}
+
+// Annotate mocks Annotate method of client.OpenShiftClient.
+// It records the annotations per service in Annotations.
+func (c *OpenShiftClient) Annotate(apiURL string, bearerToken string, namespace string, service string, annotations map[string]string) error {
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]map[string]string)
+	}
+	c.Annotations[service] = annotations
+	return nil
+}
+
+// SetMemoryLimit mocks SetMemoryLimit method of client.OpenShiftClient.
+// It records the memory limit per namespace in MemoryLimits.
+func (c *OpenShiftClient) SetMemoryLimit(apiURL string, bearerToken string, namespace string, service string, limit int64) error {
+	if c.MemoryLimits == nil {
+		c.MemoryLimits = make(map[string]int64)
+	}
+	c.MemoryLimits[namespace] = limit
+	return nil
+}
+
 // WhoAmI returns the name of the logged in user, aka the owner of the bearer token.
 func (c *OpenShiftClient) WhoAmI(apiURL string, bearerToken string) (string, error) {
 	if c.IdleError != "" {
@@ -61,7 +143,50 @@ func (c *OpenShiftClient) WhoAmI(apiURL string, bearerToken string) (string, err
 
 // WatchBuilds mocks WatchBuilds method of client.OpenShiftClient.
 // It always returns nil.
-func (c *OpenShiftClient) WatchBuilds(apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error {
+func (c *OpenShiftClient) WatchBuilds(ctx context.Context, apiURL string, bearerToken string, buildType string, callback func(model.Object) error) error {
+	if c.IdleError != "" {
+		return fmt.Errorf(c.IdleError)
+	}
+	return nil
+}
+
+// ListDeploymentConfigs mocks ListDeploymentConfigs method of client.OpenShiftClient.
+// It returns the configured DCs.
+func (c *OpenShiftClient) ListDeploymentConfigs(apiURL string, bearerToken string, nsSuffix string) ([]model.DeploymentConfig, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	return c.DCs, nil
+}
+
+// ListNamespaces mocks ListNamespaces method of client.OpenShiftClient.
+// It returns the configured Namespaces.
+func (c *OpenShiftClient) ListNamespaces(apiURL string, bearerToken string, nsSuffix string) ([]string, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	return c.Namespaces, nil
+}
+
+// ListBuilds mocks ListBuilds method of client.OpenShiftClient.
+// It returns the configured Builds.
+func (c *OpenShiftClient) ListBuilds(apiURL string, bearerToken string, namespace string) ([]model.Build, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	return c.Builds, nil
+}
+
+// WatchPods mocks WatchPods method of client.OpenShiftClient.
+func (c *OpenShiftClient) WatchPods(ctx context.Context, apiURL string, bearerToken string, nsSuffix string, callback func(model.PodObject) error) error {
+	if c.IdleError != "" {
+		return fmt.Errorf(c.IdleError)
+	}
+	return nil
+}
+
+// WatchIdlerConfigs mocks WatchIdlerConfigs method of client.OpenShiftClient.
+func (c *OpenShiftClient) WatchIdlerConfigs(ctx context.Context, apiURL string, bearerToken string, nsSuffix string, callback func(model.IdlerConfigObject) error) error {
 	if c.IdleError != "" {
 		return fmt.Errorf(c.IdleError)
 	}
@@ -70,7 +195,7 @@ func (c *OpenShiftClient) WatchBuilds(apiURL string, bearerToken string, buildTy
 
 // WatchDeploymentConfigs mocks WatchDeploymentConfigs method of client.OpenShiftClient.
 // It always returns nil.
-func (c *OpenShiftClient) WatchDeploymentConfigs(apiURL string, bearerToken string, nsSuffix string, callback func(model.DCObject) error) error {
+func (c *OpenShiftClient) WatchDeploymentConfigs(ctx context.Context, apiURL string, bearerToken string, nsSuffix string, callback func(model.DCObject) error) error {
 	if c.IdleError != "" {
 		return fmt.Errorf(c.IdleError)
 	}
@@ -81,6 +206,102 @@ func (c *OpenShiftClient) WatchDeploymentConfigs(apiURL string, bearerToken stri
 func (c *OpenShiftClient) ResetCounts() {
 	c.UnIdleCallCount = 0
 	c.IdleCallCount = 0
+	c.ResetCallCount = 0
+}
+
+// Restarts mocks Restarts method of client.OpenShiftClient.
+// It returns the configured PodRestarts.
+func (c *OpenShiftClient) Restarts(apiURL string, bearerToken string, namespace string, service string) (model.PodRestarts, error) {
+	if c.IdleError != "" {
+		return model.PodRestarts{}, fmt.Errorf(c.IdleError)
+	}
+	return c.PodRestarts, nil
+}
+
+// RouteURL mocks RouteURL method of client.OpenShiftClient.
+// It returns the configured RouteURLs of the namespace.
+func (c *OpenShiftClient) RouteURL(apiURL string, bearerToken string, namespace string, service string) (string, error) {
+	url, ok := c.RouteURLs[namespace]
+	if !ok {
+		return "", fmt.Errorf("route %s not found in namespace %s", service, namespace)
+	}
+	return url, nil
+}
+
+// BusyExecutors mocks BusyExecutors method of client.OpenShiftClient.
+// It returns the configured RunningJobs.
+func (c *OpenShiftClient) BusyExecutors(apiURL string, bearerToken string, namespace string, service string) (int, error) {
+	return c.RunningJobs, nil
+}
+
+// QuietDown mocks QuietDown method of client.OpenShiftClient.
+// It increases QuietDownCount by 1 and sets QuietingDown.
+func (c *OpenShiftClient) QuietDown(apiURL string, bearerToken string, namespace string, service string) error {
+	c.QuietDownCount++
+	c.QuietingDown = true
+	return nil
+}
+
+// CancelQuietDown mocks CancelQuietDown method of client.OpenShiftClient.
+// It resets QuietingDown.
+func (c *OpenShiftClient) CancelQuietDown(apiURL string, bearerToken string, namespace string, service string) error {
+	c.QuietingDown = false
+	return nil
+}
+
+// Probe mocks Probe method of client.OpenShiftClient.
+// It reports the service as serving with the configured JenkinsVersion unless Unhealthy is set.
+func (c *OpenShiftClient) Probe(apiURL string, bearerToken string, namespace string, service string, path string) (client.Health, error) {
+	if c.IdleError != "" {
+		return client.Health{}, fmt.Errorf(c.IdleError)
+	}
+	return client.Health{Serving: !c.Unhealthy, Version: c.JenkinsVersion}, nil
+}
+
+// NamespaceLabels mocks NamespaceLabels method of client.OpenShiftClient.
+// It returns the configured Labels.
+func (c *OpenShiftClient) NamespaceLabels(apiURL string, bearerToken string, namespace string) (map[string]string, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	return c.Labels, nil
+}
+
+// RunningPods mocks RunningPods method of client.OpenShiftClient.
+// It returns the configured PodsRunning of the namespace.
+func (c *OpenShiftClient) RunningPods(apiURL string, bearerToken string, namespace string) (int, error) {
+	if c.IdleError != "" {
+		return 0, fmt.Errorf(c.IdleError)
+	}
+	return c.PodsRunning[namespace], nil
+}
+
+// RolloutsInProgress mocks RolloutsInProgress method of client.OpenShiftClient.
+// It returns the configured Rollouts of the namespace.
+func (c *OpenShiftClient) RolloutsInProgress(apiURL string, bearerToken string, namespace string) ([]string, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	return c.Rollouts[namespace], nil
+}
+
+// IdleDeployments mocks IdleDeployments method of client.OpenShiftClient.
+// It records the namespace in IdledNamespaces.
+func (c *OpenShiftClient) IdleDeployments(apiURL string, bearerToken string, namespace string, labelSelector string) ([]string, error) {
+	if c.IdleError != "" {
+		return nil, fmt.Errorf(c.IdleError)
+	}
+	c.IdledNamespaces = append(c.IdledNamespaces, namespace)
+	return nil, nil
+}
+
+// PodUsage mocks PodUsage method of client.OpenShiftClient.
+// It returns the configured Usages of the namespace.
+func (c *OpenShiftClient) PodUsage(apiURL string, bearerToken string, namespace string) (model.Usage, error) {
+	if c.IdleError != "" {
+		return model.Usage{}, fmt.Errorf(c.IdleError)
+	}
+	return c.Usages[namespace], nil
 }
 
 // String return name of the OpenShiftClient.
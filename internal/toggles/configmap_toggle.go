@@ -0,0 +1,203 @@
+package toggles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"gopkg.in/yaml.v2"
+)
+
+// reloadInterval is the interval at which the configmap toggle checks whether its file changed.
+const reloadInterval = 10 * time.Second
+
+// featureFile is the format of the file read by NewConfigMapToggle, e.g.
+//
+//	features:
+//	  jenkins.idler:
+//	    enabled: true
+//	  jenkins.idler.che:
+//	    users: [8c55f0a5-6a0b-4a3b-9b4b-3e1b5a0c2f01]
+//	    namespaces: [john-jenkins]
+//	    clusters: [https://api.starter-us-east-2a.openshift.com/]
+//	  jenkins.idler.idle-after:
+//	    enabled: true
+//	    variants: short:30,long:90
+type featureFile struct {
+	Features map[string]featureRule `yaml:"features"`
+}
+
+// featureRule enables a feature for all users or for the listed users, namespaces and clusters.
+type featureRule struct {
+	Enabled    bool     `yaml:"enabled"`
+	Users      []string `yaml:"users"`
+	Namespaces []string `yaml:"namespaces"`
+	Clusters   []string `yaml:"clusters"`
+	Variants   string   `yaml:"variants"`
+}
+
+// configMapToggle evaluates the features defined by a YAML file, which is reloaded once it changes so that a
+// mounted ConfigMap can be updated in place.
+type configMapToggle struct {
+	sync.Mutex
+	path      string
+	modTime   time.Time
+	checkedAt time.Time
+	features  map[string]featureRule
+}
+
+// NewConfigMapToggle returns Features evaluating the features defined by the given YAML file. Features not defined
+// by the file fall back to their default.
+func NewConfigMapToggle(path string) (Features, error) {
+	t := &configMapToggle{path: path}
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// IsIdlerEnabled checks the jenkins.idler feature.
+func (t *configMapToggle) IsIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(IdlerFeature, target), nil
+}
+
+// IsCheIdlerEnabled checks the jenkins.idler.che feature.
+func (t *configMapToggle) IsCheIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(CheIdlerFeature, target), nil
+}
+
+// IsOOMLimitBumpEnabled checks the jenkins.idler.oom-limit-bump feature.
+func (t *configMapToggle) IsOOMLimitBumpEnabled(target Target) (bool, error) {
+	return t.isEnabled(OOMLimitBumpFeature, target), nil
+}
+
+// Variant returns the variant of the experiment the target is assigned to, as defined by the variants of the feature.
+func (t *configMapToggle) Variant(experiment string, target Target) (Variant, bool) {
+	if !t.isEnabled(experiment, target) {
+		return Variant{}, false
+	}
+
+	rule, _ := t.rule(experiment)
+	variants, err := parseVariants(map[string]interface{}{"variants": rule.Variants})
+	if err != nil {
+		log.WithField("err", err).Warnf("Ignoring variants of experiment %s", experiment)
+		return Variant{}, false
+	}
+	if len(variants) == 0 {
+		return Variant{}, false
+	}
+	return selectVariant(experiment, target.UserID, variants), true
+}
+
+// Definitions describes the features defined by the file.
+func (t *configMapToggle) Definitions() []Definition {
+	t.reload()
+
+	t.Lock()
+	defer t.Unlock()
+
+	definitions := []Definition{}
+	for name, rule := range t.features {
+		parameters := map[string]interface{}{}
+		for key, values := range map[string][]string{"users": rule.Users, "namespaces": rule.Namespaces, "clusters": rule.Clusters} {
+			if len(values) > 0 {
+				parameters[key] = strings.Join(values, ",")
+			}
+		}
+		if rule.Variants != "" {
+			parameters["variants"] = rule.Variants
+		}
+		definitions = append(definitions, Definition{
+			Name:        name,
+			Description: fmt.Sprintf("Defined by %s", t.path),
+			Enabled:     true,
+			Strategies:  []Strategy{{Name: "configmap", Parameters: parameters}},
+		})
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// isEnabled evaluates the feature for the target and records the outcome.
+func (t *configMapToggle) isEnabled(feature string, target Target) bool {
+	rule, ok := t.rule(feature)
+	if !ok {
+		enabled := fallback(feature)
+		record(feature, enabled, true)
+		return enabled
+	}
+
+	enabled := rule.Enabled ||
+		util.Contains(rule.Users, target.UserID) ||
+		util.Contains(rule.Namespaces, target.Namespace)
+	for _, cluster := range rule.Clusters {
+		if target.ClusterURL != "" && util.EnsureSuffix(cluster, "/") == util.EnsureSuffix(target.ClusterURL, "/") {
+			enabled = true
+		}
+	}
+	record(feature, enabled, false)
+	return enabled
+}
+
+// rule returns the rule of the feature, reloading the file if it changed.
+func (t *configMapToggle) rule(feature string) (featureRule, bool) {
+	t.reload()
+
+	t.Lock()
+	defer t.Unlock()
+
+	rule, ok := t.features[feature]
+	return rule, ok
+}
+
+// reload reloads the file if it changed since it was last loaded, checking at most every reloadInterval. If the
+// file cannot be reloaded, the features defined before remain in effect.
+func (t *configMapToggle) reload() {
+	t.Lock()
+	due := time.Since(t.checkedAt) >= reloadInterval
+	t.Unlock()
+	if !due {
+		return
+	}
+
+	if err := t.load(); err != nil {
+		log.WithField("err", err).Warnf("Unable to reload toggle file %s", t.path)
+	}
+}
+
+// load loads the file unless it did not change.
+func (t *configMapToggle) load() error {
+	t.Lock()
+	defer t.Unlock()
+
+	t.checkedAt = time.Now()
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+	if t.features != nil && info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+	file := featureFile{}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("unable to parse toggle file %s: %s", t.path, err)
+	}
+	if file.Features == nil {
+		file.Features = map[string]featureRule{}
+	}
+
+	log.Infof("Loaded %d features from %s", len(file.Features), t.path)
+	t.features = file.Features
+	t.modTime = info.ModTime()
+	return nil
+}
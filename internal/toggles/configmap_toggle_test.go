@@ -0,0 +1,66 @@
+package toggles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const featureFileData = `features:
+  jenkins.idler.che:
+    users: ["42"]
+    namespaces: [jane-jenkins]
+    clusters: [https://api.starter-us-east-2a.openshift.com/]
+  jenkins.idler.idle-after:
+    enabled: true
+    variants: short:30
+`
+
+func Test_configmap_toggle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "toggles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "toggles.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(featureFileData), 0600))
+
+	features, err := NewConfigMapToggle(path)
+	require.NoError(t, err)
+
+	var toggleTests = []struct {
+		target Target
+		che    bool
+	}{
+		{Target{UserID: "42", Namespace: "john-jenkins"}, true},
+		{Target{UserID: "1001", Namespace: "jane-jenkins"}, true},
+		{Target{UserID: "7", Namespace: "joe-jenkins", ClusterURL: "https://api.starter-us-east-2a.openshift.com"}, true},
+		{Target{UserID: "7", Namespace: "joe-jenkins", ClusterURL: "https://api.starter-us-east-2.openshift.com"}, false},
+	}
+	for _, toggleTest := range toggleTests {
+		enabled, err := features.IsCheIdlerEnabled(toggleTest.target)
+		assert.NoError(t, err)
+		assert.Equal(t, toggleTest.che, enabled, "Unexpected Che idler toggle for %v", toggleTest.target)
+
+		enabled, _ = features.IsIdlerEnabled(toggleTest.target)
+		assert.True(t, enabled, "Undefined idler feature should fall back to enabled")
+	}
+
+	variant, ok := features.(Experiments).Variant(IdleAfterExperiment, Target{UserID: "42"})
+	assert.True(t, ok, "All users should take part in the experiment")
+	assert.Equal(t, Variant{Name: "short", Payload: "30"}, variant)
+	assert.Len(t, features.(Inspector).Definitions(), 2)
+
+	// the file is reloaded once it changed
+	require.NoError(t, ioutil.WriteFile(path, []byte("features:\n  jenkins.idler:\n    enabled: false\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	features.(*configMapToggle).checkedAt = time.Time{}
+	enabled, _ := features.IsIdlerEnabled(Target{UserID: "42"})
+	assert.False(t, enabled, "Idler should be disabled after reload")
+	enabled, _ = features.IsCheIdlerEnabled(Target{UserID: "42"})
+	assert.False(t, enabled, "Undefined Che idler feature should fall back to disabled")
+}
@@ -0,0 +1,84 @@
+package toggles
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/Unleash/unleash-client-go/context"
+)
+
+// IdleAfterExperiment is the feature assigning users to variants of the idle timeout. The payload of each variant is
+// the idle timeout in minutes.
+const IdleAfterExperiment = "jenkins.idler.idle-after"
+
+// variantsStrategyName is the name of the strategy defining the variants of an experiment.
+const variantsStrategyName = "variants"
+
+// Variant is the variant of an experiment a user is assigned to.
+type Variant struct {
+	Name    string `json:"name"`
+	Payload string `json:"payload"`
+}
+
+// Experiments is implemented by Features able to assign users to the variants of an experiment.
+type Experiments interface {
+	// Variant returns the variant of the experiment the target is assigned to, false if the target does not take
+	// part in the experiment.
+	Variant(experiment string, target Target) (Variant, bool)
+}
+
+// variantsStrategy enables an experiment for the share of users given by the "percentage" parameter, by default all
+// of them. Its "variants" parameter lists the variants of the experiment as comma-separated name:payload pairs,
+// e.g. "short:30,long:90". The users taking part are evenly distributed across the variants.
+type variantsStrategy struct{}
+
+// Name returns the name of the strategy.
+func (s variantsStrategy) Name() string {
+	return variantsStrategyName
+}
+
+// IsEnabled returns true if the user falls into the configured percentage and variants are defined.
+func (s variantsStrategy) IsEnabled(params map[string]interface{}, ctx *context.Context) bool {
+	if ctx == nil || ctx.UserId == "" {
+		return false
+	}
+	variants, err := parseVariants(params)
+	if err != nil || len(variants) == 0 {
+		return false
+	}
+
+	percentage := 100
+	if value, ok := params["percentage"].(string); ok && value != "" {
+		if percentage, err = strconv.Atoi(value); err != nil {
+			return false
+		}
+	}
+	return int(hash(variantsStrategyName, ctx.UserId)%100) < percentage
+}
+
+// selectVariant deterministically assigns the user to one of the variants.
+func selectVariant(experiment string, userID string, variants []Variant) Variant {
+	return variants[hash(experiment, userID)%uint32(len(variants))]
+}
+
+// parseVariants parses the "variants" parameter of the variants strategy.
+func parseVariants(params map[string]interface{}) ([]Variant, error) {
+	var variants []Variant
+	for _, v := range list(params, "variants") {
+		parts := strings.SplitN(v, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("malformed variant %s, expected name:payload", v)
+		}
+		variants = append(variants, Variant{Name: strings.TrimSpace(parts[0]), Payload: strings.TrimSpace(parts[1])})
+	}
+	return variants, nil
+}
+
+// hash returns a stable hash of the user ID within the given group.
+func hash(group string, userID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(group + ":" + userID))
+	return h.Sum32()
+}
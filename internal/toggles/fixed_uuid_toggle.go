@@ -1,6 +1,8 @@
 package toggles
 
 import (
+	"strings"
+
 	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
 )
 
@@ -14,6 +16,33 @@ func NewFixedUUIDToggle(uuids []string) (Features, error) {
 }
 
 // IsIdlerEnabled checks if idler is enabled for current fixedUUIDToggle.
-func (t *fixedUUIDToggle) IsIdlerEnabled(uuid string) (bool, error) {
-	return util.Contains(t.uuids, uuid), nil
+func (t *fixedUUIDToggle) IsIdlerEnabled(target Target) (bool, error) {
+	enabled := util.Contains(t.uuids, target.UserID)
+	record(IdlerFeature, enabled, false)
+	return enabled, nil
+}
+
+// IsCheIdlerEnabled returns false, as the fixed UUID list only enables Jenkins idling.
+func (t *fixedUUIDToggle) IsCheIdlerEnabled(target Target) (bool, error) {
+	record(CheIdlerFeature, false, false)
+	return false, nil
+}
+
+// IsOOMLimitBumpEnabled returns false, as the fixed UUID list only enables Jenkins idling.
+func (t *fixedUUIDToggle) IsOOMLimitBumpEnabled(target Target) (bool, error) {
+	record(OOMLimitBumpFeature, false, false)
+	return false, nil
+}
+
+// Definitions describes the fixed UUID list as the Jenkins idler feature enabled for the listed user IDs.
+func (t *fixedUUIDToggle) Definitions() []Definition {
+	return []Definition{{
+		Name:        IdlerFeature,
+		Description: "Fixed list of user IDs",
+		Enabled:     true,
+		Strategies: []Strategy{{
+			Name:       "userWithId",
+			Parameters: map[string]interface{}{"userIds": strings.Join(t.uuids, ",")},
+		}},
+	}}
 }
@@ -24,8 +24,12 @@ func Test_default_values(t *testing.T) {
 	}
 
 	for _, toggleTest := range toggleTests {
-		result, err := toggle.IsIdlerEnabled(toggleTest.uuid)
+		result, err := toggle.IsIdlerEnabled(Target{UserID: toggleTest.uuid})
 		assert.NoError(t, err, "IsIdlerEnabled call failed unexpectedly.")
 		assert.Equal(t, toggleTest.enabled, result, "Unexpected result for IsIdlerEnabled")
+
+		result, err = toggle.IsCheIdlerEnabled(Target{UserID: toggleTest.uuid})
+		assert.NoError(t, err, "IsCheIdlerEnabled call failed unexpectedly.")
+		assert.False(t, result, "Che idling should never be enabled by a fixed UUID list")
 	}
 }
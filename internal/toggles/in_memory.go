@@ -0,0 +1,133 @@
+package toggles
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// InMemory is a Features implementation whose features are enabled per user programmatically, for services
+// embedding the Idler as well as for tests which should not depend on an Unleash server. All features are disabled
+// unless enabled for a user. It is safe for concurrent use.
+type InMemory struct {
+	sync.RWMutex
+	enabled  map[string]map[string]bool
+	variants map[string]map[string]Variant
+}
+
+// NewInMemory creates an InMemory with all features disabled.
+func NewInMemory() *InMemory {
+	return &InMemory{
+		enabled:  make(map[string]map[string]bool),
+		variants: make(map[string]map[string]Variant),
+	}
+}
+
+// SetEnabled enables the feature for the user with the given uid.
+func (t *InMemory) SetEnabled(uid string, feature string) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.enabled[feature] == nil {
+		t.enabled[feature] = make(map[string]bool)
+	}
+	t.enabled[feature][uid] = true
+}
+
+// SetDisabled disables the feature for the user with the given uid.
+func (t *InMemory) SetDisabled(uid string, feature string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.enabled[feature], uid)
+}
+
+// SetVariant assigns the user with the given uid to the variant of the experiment, which implies enabling the
+// experiment for the user.
+func (t *InMemory) SetVariant(uid string, experiment string, variant Variant) {
+	t.SetEnabled(uid, experiment)
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.variants[experiment] == nil {
+		t.variants[experiment] = make(map[string]Variant)
+	}
+	t.variants[experiment][uid] = variant
+}
+
+// IsIdlerEnabled returns true if the Jenkins idler feature is enabled for the user of the target.
+func (t *InMemory) IsIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(IdlerFeature, target.UserID), nil
+}
+
+// IsCheIdlerEnabled returns true if the Che idler feature is enabled for the user of the target.
+func (t *InMemory) IsCheIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(CheIdlerFeature, target.UserID), nil
+}
+
+// IsOOMLimitBumpEnabled returns true if the memory limit bump feature is enabled for the user of the target.
+func (t *InMemory) IsOOMLimitBumpEnabled(target Target) (bool, error) {
+	return t.isEnabled(OOMLimitBumpFeature, target.UserID), nil
+}
+
+// Variant returns the variant of the experiment the user of the target is assigned to, provided the experiment is
+// enabled for the user.
+func (t *InMemory) Variant(experiment string, target Target) (Variant, bool) {
+	if !t.isEnabled(experiment, target.UserID) {
+		return Variant{}, false
+	}
+
+	t.RLock()
+	defer t.RUnlock()
+
+	variant, ok := t.variants[experiment][target.UserID]
+	return variant, ok
+}
+
+// Snapshot returns the uids of the users each feature is enabled for, sorted and keyed against the feature.
+func (t *InMemory) Snapshot() map[string][]string {
+	t.RLock()
+	defer t.RUnlock()
+
+	snapshot := make(map[string][]string)
+	for feature, uids := range t.enabled {
+		for uid := range uids {
+			snapshot[feature] = append(snapshot[feature], uid)
+		}
+		if len(snapshot[feature]) > 0 {
+			sort.Strings(snapshot[feature])
+		} else {
+			delete(snapshot, feature)
+		}
+	}
+	return snapshot
+}
+
+// Definitions describes each feature as enabled for the users it got enabled for.
+func (t *InMemory) Definitions() []Definition {
+	definitions := []Definition{}
+	for feature, uids := range t.Snapshot() {
+		definitions = append(definitions, Definition{
+			Name:        feature,
+			Description: "In-memory feature",
+			Enabled:     true,
+			Strategies: []Strategy{{
+				Name:       "userWithId",
+				Parameters: map[string]interface{}{"userIds": strings.Join(uids, ",")},
+			}},
+		})
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// isEnabled returns whether the feature is enabled for the user and records the outcome.
+func (t *InMemory) isEnabled(feature string, uid string) bool {
+	t.RLock()
+	enabled := t.enabled[feature][uid]
+	t.RUnlock()
+
+	record(feature, enabled, false)
+	return enabled
+}
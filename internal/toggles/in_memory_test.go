@@ -0,0 +1,44 @@
+package toggles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_in_memory(t *testing.T) {
+	var features Features = NewInMemory()
+	toggle := features.(*InMemory)
+	john := Target{UserID: "42", Namespace: "john-jenkins"}
+	jane := Target{UserID: "1001", Namespace: "jane-jenkins"}
+
+	enabled, err := features.IsIdlerEnabled(john)
+	assert.NoError(t, err)
+	assert.False(t, enabled, "Features should be disabled by default")
+
+	toggle.SetEnabled(john.UserID, IdlerFeature)
+	toggle.SetEnabled(jane.UserID, IdlerFeature)
+	toggle.SetEnabled(jane.UserID, CheIdlerFeature)
+	enabled, _ = features.IsIdlerEnabled(john)
+	assert.True(t, enabled, "Idler should be enabled for john")
+	enabled, _ = features.IsCheIdlerEnabled(john)
+	assert.False(t, enabled, "Che idler should be disabled for john")
+	enabled, _ = features.IsCheIdlerEnabled(jane)
+	assert.True(t, enabled, "Che idler should be enabled for jane")
+	enabled, _ = features.IsOOMLimitBumpEnabled(jane)
+	assert.False(t, enabled, "Memory limit bump should be opt-in")
+
+	toggle.SetVariant(john.UserID, IdleAfterExperiment, Variant{Name: "short", Payload: "30"})
+	variant, ok := toggle.Variant(IdleAfterExperiment, john)
+	assert.True(t, ok, "John should take part in the experiment")
+	assert.Equal(t, "short", variant.Name)
+	_, ok = toggle.Variant(IdleAfterExperiment, jane)
+	assert.False(t, ok, "Jane should not take part in the experiment")
+
+	toggle.SetDisabled(jane.UserID, CheIdlerFeature)
+	assert.Equal(t, map[string][]string{
+		IdlerFeature:        {"1001", "42"},
+		IdleAfterExperiment: {"42"},
+	}, toggle.Snapshot())
+	assert.Equal(t, []string{IdlerFeature, IdleAfterExperiment}, []string{toggle.Definitions()[0].Name, toggle.Definitions()[1].Name})
+}
@@ -0,0 +1,160 @@
+package toggles
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// launchDarklyCacheTTL is how long the flags evaluated for a target are cached.
+	launchDarklyCacheTTL = 30 * time.Second
+	launchDarklyTimeout  = 5 * time.Second
+)
+
+// launchDarklyFlag is the result of evaluating a flag as returned by the client-side SDK endpoints.
+type launchDarklyFlag struct {
+	Value     interface{} `json:"value"`
+	Variation *int        `json:"variation"`
+}
+
+// launchDarklyUser is the user the flags are evaluated for. The namespace and the cluster are passed as custom
+// attributes, so that flag rules can target them.
+type launchDarklyUser struct {
+	Key    string            `json:"key"`
+	Custom map[string]string `json:"custom"`
+}
+
+type evaluatedFlags struct {
+	flags     map[string]launchDarklyFlag
+	fetchedAt time.Time
+}
+
+// launchDarklyToggle evaluates the features using the client-side SDK endpoints of LaunchDarkly, which evaluate all
+// flags available to client-side SDKs for a user. The results are cached per target for launchDarklyCacheTTL.
+type launchDarklyToggle struct {
+	sync.Mutex
+	baseURL    string
+	clientID   string
+	httpClient *http.Client
+	cache      map[Target]evaluatedFlags
+}
+
+// NewLaunchDarklyToggle returns Features evaluated by LaunchDarkly, or a relay proxy, at the given base URL for the
+// environment with the given client-side ID. Flags which cannot be evaluated fall back to their default.
+func NewLaunchDarklyToggle(baseURL string, clientID string) (Features, error) {
+	if clientID == "" {
+		return nil, fmt.Errorf("LaunchDarkly client-side ID is required")
+	}
+	return &launchDarklyToggle{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		clientID:   clientID,
+		httpClient: &http.Client{Timeout: launchDarklyTimeout},
+		cache:      make(map[Target]evaluatedFlags),
+	}, nil
+}
+
+// IsIdlerEnabled checks the jenkins.idler flag.
+func (t *launchDarklyToggle) IsIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(IdlerFeature, target), nil
+}
+
+// IsCheIdlerEnabled checks the jenkins.idler.che flag.
+func (t *launchDarklyToggle) IsCheIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(CheIdlerFeature, target), nil
+}
+
+// IsOOMLimitBumpEnabled checks the jenkins.idler.oom-limit-bump flag.
+func (t *launchDarklyToggle) IsOOMLimitBumpEnabled(target Target) (bool, error) {
+	return t.isEnabled(OOMLimitBumpFeature, target), nil
+}
+
+// Variant returns the variant of the experiment the target is assigned to. The experiment is a string flag whose
+// variations are name:payload pairs, e.g. "short:30", whereas an empty value means not taking part.
+func (t *launchDarklyToggle) Variant(experiment string, target Target) (Variant, bool) {
+	flag, ok := t.flag(experiment, target)
+	value, _ := flag.Value.(string)
+	if !ok || value == "" {
+		record(experiment, false, !ok)
+		return Variant{}, false
+	}
+
+	variants, err := parseVariants(map[string]interface{}{"variants": value})
+	if err != nil || len(variants) != 1 {
+		log.Warnf("Ignoring malformed variation %s of experiment %s", value, experiment)
+		record(experiment, false, false)
+		return Variant{}, false
+	}
+	record(experiment, true, false)
+	return variants[0], true
+}
+
+// isEnabled evaluates the boolean flag for the target and records the outcome.
+func (t *launchDarklyToggle) isEnabled(feature string, target Target) bool {
+	flag, ok := t.flag(feature, target)
+	enabled, isBool := flag.Value.(bool)
+	if !ok || !isBool {
+		enabled = fallback(feature)
+		record(feature, enabled, true)
+		return enabled
+	}
+	record(feature, enabled, false)
+	return enabled
+}
+
+// flag returns the evaluation of the flag for the target, false if it is unknown or cannot be evaluated.
+func (t *launchDarklyToggle) flag(feature string, target Target) (launchDarklyFlag, bool) {
+	t.Lock()
+	cached, ok := t.cache[target]
+	t.Unlock()
+
+	if !ok || time.Since(cached.fetchedAt) >= launchDarklyCacheTTL {
+		flags, err := t.evaluate(target)
+		if err != nil {
+			log.WithField("err", err).Warnf("Unable to evaluate LaunchDarkly flags for %s", target.UserID)
+			return launchDarklyFlag{}, false
+		}
+		cached = evaluatedFlags{flags: flags, fetchedAt: time.Now()}
+
+		t.Lock()
+		t.cache[target] = cached
+		t.Unlock()
+	}
+
+	flag, ok := cached.flags[feature]
+	return flag, ok
+}
+
+// evaluate requests the evaluation of all flags for the target.
+func (t *launchDarklyToggle) evaluate(target Target) (map[string]launchDarklyFlag, error) {
+	user, err := json.Marshal(launchDarklyUser{
+		Key: target.UserID,
+		Custom: map[string]string{
+			namespaceProperty:  target.Namespace,
+			clusterURLProperty: target.ClusterURL,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/sdk/evalx/%s/users/%s", t.baseURL, t.clientID, base64.URLEncoding.EncodeToString(user))
+	resp, err := t.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LaunchDarkly responded with status %d", resp.StatusCode)
+	}
+
+	flags := map[string]launchDarklyFlag{}
+	if err := json.NewDecoder(resp.Body).Decode(&flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
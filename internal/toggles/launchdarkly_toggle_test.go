@@ -0,0 +1,56 @@
+package toggles
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_launchdarkly_toggle(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.True(t, strings.HasPrefix(r.URL.Path, "/sdk/evalx/client-id/users/"), "Unexpected path %s", r.URL.Path)
+		data, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(r.URL.Path, "/sdk/evalx/client-id/users/"))
+		require.NoError(t, err)
+		user := launchDarklyUser{}
+		require.NoError(t, json.Unmarshal(data, &user))
+
+		if user.Key == "42" {
+			w.Write([]byte(`{"jenkins.idler": {"value": false, "variation": 1}, "jenkins.idler.che": {"value": true, "variation": 0},` +
+				`"jenkins.idler.idle-after": {"value": "short:30", "variation": 0}}`))
+			return
+		}
+		assert.Equal(t, "jane-jenkins", user.Custom["namespace"])
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := NewLaunchDarklyToggle(server.URL, "")
+	assert.Error(t, err, "Client-side ID should be required")
+
+	features, err := NewLaunchDarklyToggle(server.URL+"/", "client-id")
+	require.NoError(t, err)
+
+	john := Target{UserID: "42", Namespace: "john-jenkins"}
+	enabled, _ := features.IsIdlerEnabled(john)
+	assert.False(t, enabled, "Idler should be disabled for john")
+	enabled, _ = features.IsCheIdlerEnabled(john)
+	assert.True(t, enabled, "Che idler should be enabled for john")
+	variant, ok := features.(Experiments).Variant(IdleAfterExperiment, john)
+	assert.True(t, ok, "John should take part in the experiment")
+	assert.Equal(t, Variant{Name: "short", Payload: "30"}, variant)
+	assert.Equal(t, 1, requests, "Evaluations should be cached")
+
+	jane := Target{UserID: "1001", Namespace: "jane-jenkins"}
+	enabled, _ = features.IsIdlerEnabled(jane)
+	assert.True(t, enabled, "Idler should fall back to enabled")
+	enabled, _ = features.IsCheIdlerEnabled(jane)
+	assert.False(t, enabled, "Che idler should fall back to disabled")
+}
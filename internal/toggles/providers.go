@@ -0,0 +1,60 @@
+package toggles
+
+import (
+	"fmt"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+)
+
+const (
+	// UnleashProvider evaluates the features using an Unleash server.
+	UnleashProvider = "unleash"
+	// ConfigMapProvider evaluates the features defined by a YAML file, usually mounted from a ConfigMap.
+	ConfigMapProvider = "configmap"
+	// LaunchDarklyProvider evaluates the features using LaunchDarkly.
+	LaunchDarklyProvider = "launchdarkly"
+	// StaticProvider enables the Jenkins idler for the fixed list of user IDs only.
+	StaticProvider = "static"
+)
+
+// Provider creates the Features of a toggle provider from the configuration.
+type Provider func(config configuration.Configuration) (Features, error)
+
+// providers are the available toggle providers keyed against their name.
+var providers = map[string]Provider{
+	UnleashProvider: func(config configuration.Configuration) (Features, error) {
+		return NewUnleashToggle(config.GetToggleURL(), config.GetToggleBackupPath(), config.GetToggleBootstrapFile())
+	},
+	ConfigMapProvider: func(config configuration.Configuration) (Features, error) {
+		return NewConfigMapToggle(config.GetToggleFile())
+	},
+	LaunchDarklyProvider: func(config configuration.Configuration) (Features, error) {
+		return NewLaunchDarklyToggle(config.GetLaunchDarklyURL(), config.GetLaunchDarklyClientID())
+	},
+	StaticProvider: func(config configuration.Configuration) (Features, error) {
+		return NewFixedUUIDToggle(config.GetFixedUuids())
+	},
+}
+
+// ProviderName returns the name of the toggle provider chosen by the configuration. As the fixed UUID list used to
+// take precedence over Unleash, it still does unless another provider is configured.
+func ProviderName(config configuration.Configuration) string {
+	name := config.GetToggleProvider()
+	if (name == "" || name == UnleashProvider) && len(config.GetFixedUuids()) > 0 {
+		return StaticProvider
+	}
+	if name == "" {
+		return UnleashProvider
+	}
+	return name
+}
+
+// New creates the Features of the toggle provider chosen by the configuration.
+func New(config configuration.Configuration) (Features, error) {
+	name := ProviderName(config)
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown toggle provider %s", name)
+	}
+	return provider(config)
+}
@@ -0,0 +1,56 @@
+package toggles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_provider_name(t *testing.T) {
+	config, err := configuration.New("")
+	require.NoError(t, err)
+	assert.Equal(t, UnleashProvider, ProviderName(config), "Unleash should be the default provider")
+
+	os.Setenv("JC_FIXED_UUIDS", "42")
+	defer os.Unsetenv("JC_FIXED_UUIDS")
+	config, _ = configuration.New("")
+	assert.Equal(t, StaticProvider, ProviderName(config), "Fixed UUID list should take precedence over Unleash")
+
+	features, err := New(config)
+	require.NoError(t, err)
+	enabled, _ := features.IsIdlerEnabled(Target{UserID: "42"})
+	assert.True(t, enabled, "Idler should be enabled for fixed UUID")
+
+	os.Setenv("JC_TOGGLE_PROVIDER", ConfigMapProvider)
+	defer os.Unsetenv("JC_TOGGLE_PROVIDER")
+	config, _ = configuration.New("")
+	assert.Equal(t, ConfigMapProvider, ProviderName(config))
+	_, err = New(config)
+	assert.Error(t, err, "Missing toggle file should fail")
+}
+
+func Test_new_configmap_provider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "toggles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "toggles.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("features:\n  jenkins.idler:\n    users: [\"42\"]\n"), 0600))
+
+	os.Setenv("JC_TOGGLE_PROVIDER", ConfigMapProvider)
+	defer os.Unsetenv("JC_TOGGLE_PROVIDER")
+	os.Setenv("JC_TOGGLE_FILE", path)
+	defer os.Unsetenv("JC_TOGGLE_FILE")
+	config, _ := configuration.New("")
+
+	features, err := New(config)
+	require.NoError(t, err)
+	enabled, _ := features.IsIdlerEnabled(Target{UserID: "42"})
+	assert.True(t, enabled, "Idler should be enabled for listed user")
+	enabled, _ = features.IsIdlerEnabled(Target{UserID: "1001"})
+	assert.False(t, enabled, "Idler should be disabled for other users")
+}
@@ -0,0 +1,115 @@
+package toggles
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/Unleash/unleash-client-go"
+)
+
+// backupFileName is the name of the file unleash.DefaultStorage backs up the toggle definitions to.
+const backupFileName = "unleash-repo-schema-v1-%s.json"
+
+// definitionStore is the storage of the Unleash client. It backs up the toggle definitions fetched from the Unleash
+// server to disk and loads them at startup, so that the features are evaluated as before while the Unleash server is
+// unreachable. Without backup, the definitions are seeded from the bootstrap file, if any.
+type definitionStore struct {
+	sync.RWMutex
+	storage       unleash.DefaultStorage
+	backupFile    string
+	bootstrapFile string
+}
+
+func newDefinitionStore(bootstrapFile string) *definitionStore {
+	return &definitionStore{bootstrapFile: bootstrapFile}
+}
+
+// Init initializes the backup of the definitions in the backupPath directory.
+func (s *definitionStore) Init(backupPath string, appName string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.backupFile = filepath.Join(backupPath, fmt.Sprintf(backupFileName, appName))
+	s.storage.Init(backupPath, appName)
+}
+
+// Reset replaces the definitions by the ones fetched from the Unleash server.
+func (s *definitionStore) Reset(data map[string]interface{}, persist bool) error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.storage.Reset(data, persist)
+}
+
+// Load loads the definitions from the backup, which is seeded from the bootstrap file if missing.
+func (s *definitionStore) Load() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := os.Stat(s.backupFile); os.IsNotExist(err) && s.bootstrapFile != "" {
+		log.Infof("Bootstrapping toggle definitions from %s", s.bootstrapFile)
+		data, err := ioutil.ReadFile(s.bootstrapFile)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(s.backupFile, data, 0600); err != nil {
+			return err
+		}
+	}
+
+	err := s.storage.Load()
+	if err != nil {
+		log.WithField("err", err).Warnf("Unable to load toggle definitions from %s", s.backupFile)
+	}
+	return err
+}
+
+// Persist backs up the definitions.
+func (s *definitionStore) Persist() error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.storage.Persist()
+}
+
+// Get returns the definition of the given feature.
+func (s *definitionStore) Get(key string) (interface{}, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.storage.Get(key)
+}
+
+// List returns the definitions of all features.
+func (s *definitionStore) List() []interface{} {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.storage.List()
+}
+
+// Definitions returns the definitions sorted by feature name. As the Unleash client keeps them in an internal type,
+// they are converted via their JSON representation.
+func (s *definitionStore) Definitions() []Definition {
+	definitions := []Definition{}
+	for _, value := range s.List() {
+		data, err := json.Marshal(value)
+		if err != nil {
+			log.WithField("err", err).Warn("Unable to serialize toggle definition")
+			continue
+		}
+		definition := Definition{}
+		if err := json.Unmarshal(data, &definition); err != nil {
+			log.WithField("err", err).Warn("Unable to parse toggle definition")
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
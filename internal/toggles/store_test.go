@@ -0,0 +1,77 @@
+package toggles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feature and featureStrategy mimic the internal types in which the Unleash client stores the toggle definitions.
+type feature struct {
+	Name       string            `json:"name"`
+	Enabled    bool              `json:"enabled"`
+	CreatedAt  string            `json:"createdAt"`
+	Strategies []featureStrategy `json:"strategies"`
+}
+
+type featureStrategy struct {
+	ID         int                    `json:"id"`
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+func Test_definition_store(t *testing.T) {
+	dir, err := ioutil.TempDir("", "toggles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	che := feature{Name: CheIdlerFeature}
+	idler := feature{Name: IdlerFeature, Enabled: true, CreatedAt: "2018-04-11T08:27:15Z", Strategies: []featureStrategy{
+		{ID: 1, Name: "gradualRolloutUserId", Parameters: map[string]interface{}{"percentage": "50"}},
+	}}
+	expected := []Definition{
+		{Name: IdlerFeature, Enabled: true, Strategies: []Strategy{
+			{Name: "gradualRolloutUserId", Parameters: map[string]interface{}{"percentage": "50"}},
+		}},
+		{Name: CheIdlerFeature},
+	}
+
+	store := newDefinitionStore("")
+	store.Init(dir, appName)
+	assert.Error(t, store.Load(), "Loading should fail without backup")
+	assert.Empty(t, store.Definitions())
+
+	require.NoError(t, store.Reset(map[string]interface{}{CheIdlerFeature: che, IdlerFeature: idler}, true))
+	_, ok := store.Get(IdlerFeature)
+	assert.True(t, ok, "Feature should be known")
+	_, ok = store.Get("unknown")
+	assert.False(t, ok, "Feature should be unknown")
+	assert.Equal(t, expected, store.Definitions())
+
+	store = newDefinitionStore(filepath.Join(dir, "missing.json"))
+	store.Init(dir, appName)
+	require.NoError(t, store.Load(), "Backup should take precedence over bootstrap file")
+	assert.Equal(t, expected, store.Definitions())
+}
+
+func Test_definition_store_bootstrap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "toggles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bootstrapFile := filepath.Join(dir, "bootstrap.json")
+	bootstrap := `{"jenkins.idler": {"name": "jenkins.idler", "enabled": true, "strategies": [{"name": "default"}]}}`
+	require.NoError(t, ioutil.WriteFile(bootstrapFile, []byte(bootstrap), 0600))
+
+	store := newDefinitionStore(bootstrapFile)
+	store.Init(dir, appName)
+	require.NoError(t, store.Load())
+	assert.Equal(t, []Definition{{Name: IdlerFeature, Enabled: true, Strategies: []Strategy{{Name: "default"}}}}, store.Definitions())
+	_, err = os.Stat(filepath.Join(dir, fmt.Sprintf(backupFileName, appName)))
+	assert.NoError(t, err, "Backup should be seeded")
+}
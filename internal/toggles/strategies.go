@@ -0,0 +1,111 @@
+package toggles
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Unleash/unleash-client-go/context"
+	"github.com/Unleash/unleash-client-go/strategy"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+)
+
+const (
+	// namespaceProperty is the context property holding the Jenkins namespace.
+	namespaceProperty = "namespace"
+	// clusterURLProperty is the context property holding the API URL of the cluster of the Jenkins namespace.
+	clusterURLProperty = "clusterURL"
+)
+
+// strategies are the custom strategies registered with the Unleash client, in addition to the built-in ones
+// targeting user IDs. They allow to roll out features per namespace resp. per cluster and to run experiments.
+var strategies = []strategy.Strategy{
+	namespaceListStrategy{},
+	clusterURLStrategy{},
+	namespaceRegexStrategy{},
+	variantsStrategy{},
+}
+
+// namespaceListStrategy enables a feature for the comma-separated list of namespaces of the "namespaces" parameter.
+type namespaceListStrategy struct{}
+
+// Name returns the name of the strategy.
+func (s namespaceListStrategy) Name() string {
+	return "namespaceList"
+}
+
+// IsEnabled returns true if the namespace is listed.
+func (s namespaceListStrategy) IsEnabled(params map[string]interface{}, ctx *context.Context) bool {
+	namespace := property(ctx, namespaceProperty)
+	if namespace == "" {
+		return false
+	}
+	return util.Contains(list(params, "namespaces"), namespace)
+}
+
+// clusterURLStrategy enables a feature for all namespaces on the comma-separated list of cluster API URLs of the
+// "clusterURLs" parameter. A trailing slash does not matter.
+type clusterURLStrategy struct{}
+
+// Name returns the name of the strategy.
+func (s clusterURLStrategy) Name() string {
+	return "clusterURL"
+}
+
+// IsEnabled returns true if the cluster is listed.
+func (s clusterURLStrategy) IsEnabled(params map[string]interface{}, ctx *context.Context) bool {
+	clusterURL := property(ctx, clusterURLProperty)
+	if clusterURL == "" {
+		return false
+	}
+	for _, u := range list(params, "clusterURLs") {
+		if util.EnsureSuffix(u, "/") == util.EnsureSuffix(clusterURL, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceRegexStrategy enables a feature for the namespaces matching the regular expression of the "pattern"
+// parameter as a whole.
+type namespaceRegexStrategy struct{}
+
+// Name returns the name of the strategy.
+func (s namespaceRegexStrategy) Name() string {
+	return "namespaceRegex"
+}
+
+// IsEnabled returns true if the namespace matches the pattern. An invalid pattern matches no namespace.
+func (s namespaceRegexStrategy) IsEnabled(params map[string]interface{}, ctx *context.Context) bool {
+	namespace := property(ctx, namespaceProperty)
+	pattern, _ := params["pattern"].(string)
+	if namespace == "" || pattern == "" {
+		return false
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		log.WithField("err", err).Warnf("Invalid pattern %s of strategy %s", pattern, s.Name())
+		return false
+	}
+	return re.MatchString(namespace)
+}
+
+// property returns the value of the context property, empty if unset.
+func property(ctx *context.Context, name string) string {
+	if ctx == nil || ctx.Properties == nil {
+		return ""
+	}
+	return ctx.Properties[name]
+}
+
+// list splits the comma-separated parameter into its trimmed, non-empty values.
+func list(params map[string]interface{}, name string) []string {
+	value, _ := params[name].(string)
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,77 @@
+package toggles
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Unleash/unleash-client-go/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_custom_strategies(t *testing.T) {
+	ctx := &context.Context{
+		UserId: "42",
+		Properties: map[string]string{
+			namespaceProperty:  "john-jenkins",
+			clusterURLProperty: "https://api.starter-us-east-2a.openshift.com",
+		},
+	}
+
+	var strategyTests = []struct {
+		strategy string
+		params   map[string]interface{}
+		ctx      *context.Context
+		enabled  bool
+	}{
+		{"namespaceList", map[string]interface{}{"namespaces": "jane-jenkins, john-jenkins"}, ctx, true},
+		{"namespaceList", map[string]interface{}{"namespaces": "jane-jenkins"}, ctx, false},
+		{"namespaceList", map[string]interface{}{}, ctx, false},
+		{"namespaceList", map[string]interface{}{"namespaces": "john-jenkins"}, &context.Context{UserId: "42"}, false},
+		{"clusterURL", map[string]interface{}{"clusterURLs": "https://api.starter-us-east-2a.openshift.com/"}, ctx, true},
+		{"clusterURL", map[string]interface{}{"clusterURLs": "https://api.starter-us-east-2.openshift.com/"}, ctx, false},
+		{"clusterURL", map[string]interface{}{"clusterURLs": "https://api.starter-us-east-2a.openshift.com/"}, nil, false},
+		{"namespaceRegex", map[string]interface{}{"pattern": "j.*-jenkins"}, ctx, true},
+		{"namespaceRegex", map[string]interface{}{"pattern": "john"}, ctx, false},
+		{"namespaceRegex", map[string]interface{}{"pattern": "("}, ctx, false},
+	}
+
+	byName := map[string]bool{}
+	for _, s := range strategies {
+		byName[s.Name()] = true
+	}
+	for _, strategyTest := range strategyTests {
+		assert.True(t, byName[strategyTest.strategy], "Strategy %s should be registered", strategyTest.strategy)
+		for _, s := range strategies {
+			if s.Name() == strategyTest.strategy {
+				assert.Equal(t, strategyTest.enabled, s.IsEnabled(strategyTest.params, strategyTest.ctx),
+					"Unexpected result of strategy %s with %v", strategyTest.strategy, strategyTest.params)
+			}
+		}
+	}
+}
+
+func Test_variants_strategy(t *testing.T) {
+	s := variantsStrategy{}
+	params := map[string]interface{}{"variants": "short:30, long:90"}
+	assert.True(t, s.IsEnabled(params, &context.Context{UserId: "42"}), "All users should take part by default")
+	assert.False(t, s.IsEnabled(params, &context.Context{}), "Users without ID should not take part")
+	assert.False(t, s.IsEnabled(map[string]interface{}{"variants": "short"}, &context.Context{UserId: "42"}), "Malformed variants should disable the experiment")
+	assert.False(t, s.IsEnabled(map[string]interface{}{"variants": "short:30", "percentage": "0"}, &context.Context{UserId: "42"}))
+
+	participants := 0
+	assigned := map[string]int{}
+	variants, err := parseVariants(params)
+	assert.NoError(t, err)
+	assert.Equal(t, []Variant{{Name: "short", Payload: "30"}, {Name: "long", Payload: "90"}}, variants)
+	for i := 0; i < 1000; i++ {
+		id := strconv.Itoa(i)
+		if s.IsEnabled(map[string]interface{}{"variants": "short:30,long:90", "percentage": "50"}, &context.Context{UserId: id}) {
+			participants++
+		}
+		variant := selectVariant(IdleAfterExperiment, id, variants)
+		assert.Equal(t, variant, selectVariant(IdleAfterExperiment, id, variants), "Assignment should be stable")
+		assigned[variant.Name]++
+	}
+	assert.InDelta(t, 500, participants, 100, "About half of the users should take part")
+	assert.InDelta(t, 500, assigned["short"], 100, "Users should be evenly distributed across variants")
+}
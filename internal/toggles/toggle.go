@@ -1,8 +1,86 @@
 package toggles
 
+import (
+	"github.com/fabric8-services/fabric8-jenkins-idler/metric"
+)
+
+const (
+	// IdlerFeature enables the Jenkins idler.
+	IdlerFeature = "jenkins.idler"
+	// CheIdlerFeature enables idling the Che workspaces along with Jenkins.
+	CheIdlerFeature = "jenkins.idler.che"
+	// OOMLimitBumpFeature enables applying the recommended memory limit increases to the deployment config of Jenkins.
+	OOMLimitBumpFeature = "jenkins.idler.oom-limit-bump"
+)
+
+const (
+	// OutcomeEnabled is recorded for evaluations enabling the feature.
+	OutcomeEnabled = "enabled"
+	// OutcomeDisabled is recorded for evaluations disabling the feature.
+	OutcomeDisabled = "disabled"
+	// OutcomeFallback is recorded for evaluations of unknown features, which yield the fallback value.
+	OutcomeFallback = "fallback"
+)
+
+// Recorder to capture the toggle evaluations
+var Recorder metric.Recorder = metric.PrometheusRecorder{}
+
+// Target identifies the user and the Jenkins namespace a feature is evaluated for.
+type Target struct {
+	UserID     string
+	Namespace  string
+	ClusterURL string
+}
+
 // Features is an interface which allows you to enable specific behaviour for a given user.
 // In particular, it controls whether Jenkins idling is enabled for a specific user.
 type Features interface {
-	// IsIdlerEnabled returns true if the Jenkins idler is enabled for the user with the specified uid, false otherwise.
-	IsIdlerEnabled(uid string) (bool, error)
+	// IsIdlerEnabled returns true if the Jenkins idler is enabled for the specified target, false otherwise.
+	IsIdlerEnabled(target Target) (bool, error)
+
+	// IsCheIdlerEnabled returns true if the Che workspaces of the specified target are idled along with Jenkins,
+	// false otherwise.
+	IsCheIdlerEnabled(target Target) (bool, error)
+
+	// IsOOMLimitBumpEnabled returns true if the recommended memory limit increases are applied to the Jenkins of the
+	// specified target, false otherwise.
+	IsOOMLimitBumpEnabled(target Target) (bool, error)
+}
+
+// Inspector is implemented by Features which are able to list the toggle definitions they evaluate.
+type Inspector interface {
+	// Definitions returns the toggle definitions currently in use.
+	Definitions() []Definition
+}
+
+// Definition describes a feature toggle and the strategies enabling it.
+type Definition struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	Strategies  []Strategy `json:"strategies"`
+}
+
+// Strategy describes a strategy enabling a feature toggle for some users.
+type Strategy struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// fallback returns whether the feature is enabled if its provider does not know it. The Jenkins idler is enabled
+// for all users unless explicitly disabled, whereas all other features are opt-in.
+func fallback(feature string) bool {
+	return feature == IdlerFeature
+}
+
+// record records the outcome of evaluating the feature.
+func record(feature string, enabled bool, fallback bool) {
+	outcome := OutcomeDisabled
+	switch {
+	case fallback:
+		outcome = OutcomeFallback
+	case enabled:
+		outcome = OutcomeEnabled
+	}
+	Recorder.RecordToggleEvaluation(feature, outcome)
 }
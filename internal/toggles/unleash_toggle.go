@@ -15,7 +15,6 @@ import (
 
 const (
 	appName         = "jenkins-idler"
-	toggleFeature   = "jenkins.idler"
 	maxWaitForReady = 10
 )
 
@@ -24,16 +23,29 @@ var log = logrus.WithField("component", "unleash")
 type unleashToggle struct {
 	Features
 	unleashClient *unleash.Client
+	store         *definitionStore
 }
 
-// NewUnleashToggle creates a new instance of unleashToggle.
-func NewUnleashToggle(hostURL string) (Features, error) {
-	unleashClient, err := unleash.NewClient(unleash.WithAppName(appName),
+// NewUnleashToggle creates a new instance of unleashToggle. The toggle definitions are backed up to the backupPath
+// directory, by default the temporary directory. Without backup, they are seeded from the bootstrapFile, if given.
+// If the Unleash server does not become reachable in time, the toggle is still created as long as definitions
+// could be loaded that way.
+func NewUnleashToggle(hostURL string, backupPath string, bootstrapFile string) (Features, error) {
+	store := newDefinitionStore(bootstrapFile)
+	options := []unleash.ConfigOption{
+		unleash.WithAppName(appName),
 		unleash.WithListener(&listener{}),
+		unleash.WithStorage(store),
+		unleash.WithStrategies(strategies...),
 		unleash.WithInstanceId(os.Getenv("HOSTNAME")),
 		unleash.WithUrl(hostURL),
-		unleash.WithMetricsInterval(1*time.Minute),
-		unleash.WithRefreshInterval(10*time.Second))
+		unleash.WithMetricsInterval(1 * time.Minute),
+		unleash.WithRefreshInterval(10 * time.Second),
+	}
+	if backupPath != "" {
+		options = append(options, unleash.WithBackupPath(backupPath))
+	}
+	unleashClient, err := unleash.NewClient(options...)
 
 	if err != nil {
 		log.Error("Unable to initialize Unleash client.", err)
@@ -45,26 +57,86 @@ func NewUnleashToggle(hostURL string) (Features, error) {
 	case <-readyChan:
 		log.Info("Unleash client initialized and ready.")
 	case <-time.After(time.Second * maxWaitForReady):
-		return nil, fmt.Errorf("unleash client initalization timed out after %d seconds", maxWaitForReady)
+		if len(store.List()) == 0 {
+			return nil, fmt.Errorf("unleash client initalization timed out after %d seconds", maxWaitForReady)
+		}
+		log.Warnf("Unleash client not ready after %d seconds, evaluating %d cached toggle definitions.", maxWaitForReady, len(store.List()))
 	}
 
-	return &unleashToggle{unleashClient: unleashClient}, nil
+	return &unleashToggle{unleashClient: unleashClient, store: store}, nil
+}
+
+func (t *unleashToggle) IsIdlerEnabled(target Target) (bool, error) {
+	// NOTE: Enabled for all users unless explictly disabled
+	return t.isEnabled(IdlerFeature, target, true), nil
+}
+
+// IsCheIdlerEnabled checks the jenkins.idler.che feature, which is opt-in.
+func (t *unleashToggle) IsCheIdlerEnabled(target Target) (bool, error) {
+	return t.isEnabled(CheIdlerFeature, target, false), nil
+}
+
+// IsOOMLimitBumpEnabled checks the jenkins.idler.oom-limit-bump feature, which is opt-in.
+func (t *unleashToggle) IsOOMLimitBumpEnabled(target Target) (bool, error) {
+	return t.isEnabled(OOMLimitBumpFeature, target, false), nil
+}
+
+// Definitions returns the toggle definitions last fetched from the Unleash server.
+func (t *unleashToggle) Definitions() []Definition {
+	return t.store.Definitions()
 }
 
-func (t *unleashToggle) IsIdlerEnabled(uid string) (bool, error) {
+// Variant returns the variant of the experiment the target is assigned to. The target takes part in the experiment
+// if the feature is enabled for it, and the variants are defined by the variants strategy of the feature, as the
+// Unleash client evaluates strategies only.
+func (t *unleashToggle) Variant(experiment string, target Target) (Variant, bool) {
+	if !t.isEnabled(experiment, target, false) {
+		return Variant{}, false
+	}
+
+	for _, definition := range t.store.Definitions() {
+		if definition.Name != experiment {
+			continue
+		}
+		for _, s := range definition.Strategies {
+			if s.Name != variantsStrategyName {
+				continue
+			}
+			variants, err := parseVariants(s.Parameters)
+			if err != nil {
+				log.WithField("err", err).Warnf("Ignoring variants of experiment %s", experiment)
+				return Variant{}, false
+			}
+			if len(variants) > 0 {
+				return selectVariant(experiment, target.UserID, variants), true
+			}
+		}
+	}
+	return Variant{}, false
+}
 
+// isEnabled evaluates the feature for the target and records the outcome, which is the fallback value if the
+// feature is unknown to the Unleash server.
+func (t *unleashToggle) isEnabled(feature string, target Target, fallback bool) bool {
 	enabled := t.unleashClient.IsEnabled(
-		toggleFeature,
-		withContext(uid),
-		unleash.WithFallback(true)) // NOTE: Enabled for all users unless explictly disabled
+		feature,
+		withContext(target),
+		unleash.WithFallback(fallback))
 
-	return enabled, nil
+	_, known := t.store.Get(feature)
+	record(feature, enabled, !known)
+	return enabled
 }
 
-// withContext creates a context based toggle with the user id as key.
-func withContext(uid string) unleash.FeatureOption {
+// withContext creates a context based toggle with the user id as key. The namespace and the cluster are passed as
+// properties, which are evaluated by the custom strategies.
+func withContext(target Target) unleash.FeatureOption {
 	ctx := context.Context{
-		UserId: uid,
+		UserId: target.UserID,
+		Properties: map[string]string{
+			namespaceProperty:  target.Namespace,
+			clusterURLProperty: target.ClusterURL,
+		},
 	}
 
 	return unleash.WithContext(ctx)
@@ -91,8 +163,9 @@ func (l listener) OnReady() {
 	log.Info("Unleash client ready")
 }
 
-// OnCount prints to the console when the feature is queried.
+// OnCount logs the evaluations of features, whose outcomes are recorded by unleashToggle.
 func (l listener) OnCount(name string, enabled bool) {
+	log.WithFields(logrus.Fields{"feature": name, "enabled": enabled}).Debug("OnCount")
 }
 
 // OnSent prints to the console when the server has uploaded metrics.
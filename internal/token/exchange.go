@@ -0,0 +1,104 @@
+package token
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+// retryInterval is the interval at which a failed token exchange is retried.
+const retryInterval = time.Minute
+
+// Exchanger provides the bearer tokens of the clusters by exchanging the service token of the Idler with the Auth
+// service on demand. The exchanged tokens are cached for the configured TTL and exchanged anew afterwards. If an
+// exchange fails, the service token is renewed and the exchange retried once. Until an exchange succeeds, the
+// previous token of the cluster keeps being provided. A nil Exchanger provides no tokens.
+type Exchanger struct {
+	sync.Mutex
+	resolve      Resolve
+	decode       Decode
+	serviceToken string
+	renew        func() (string, error)
+	ttl          time.Duration
+	clock        clock.Clock
+	tokens       map[string]*exchangedToken
+}
+
+// exchangedToken is a cached cluster token.
+type exchangedToken struct {
+	token   string
+	expires time.Time
+}
+
+// NewExchanger creates an Exchanger exchanging the given service token using the resolve function. The renew
+// function obtains a new service token once the current one got rejected.
+func NewExchanger(resolve Resolve, decode Decode, serviceToken string, renew func() (string, error), ttl time.Duration, clock clock.Clock) *Exchanger {
+	return &Exchanger{
+		resolve:      resolve,
+		decode:       decode,
+		serviceToken: serviceToken,
+		renew:        renew,
+		ttl:          ttl,
+		clock:        clock,
+		tokens:       make(map[string]*exchangedToken),
+	}
+}
+
+// Token returns the current token of the cluster with the given API URL, exchanging it if it is not cached or
+// expired. It returns false if no token could be obtained for the cluster.
+func (e *Exchanger) Token(apiURL string) (string, bool) {
+	if e == nil {
+		return "", false
+	}
+
+	apiURL = util.EnsureSuffix(apiURL, "/")
+	e.Lock()
+	defer e.Unlock()
+
+	cached, ok := e.tokens[apiURL]
+	if ok && e.clock.Now().Before(cached.expires) {
+		return cached.token, cached.token != ""
+	}
+	if !ok {
+		cached = &exchangedToken{}
+		e.tokens[apiURL] = cached
+	}
+
+	token, err := e.exchange(apiURL)
+	if err != nil {
+		logger.WithFields(logrus.Fields{"cluster": apiURL, "err": err}).Warn("Unable to exchange the token of the cluster")
+		cached.expires = e.clock.Now().Add(retryInterval)
+		return cached.token, cached.token != ""
+	}
+	if cached.token != "" && cached.token != token {
+		logger.WithField("cluster", apiURL).Info("Refreshed exchanged token of the cluster")
+	}
+	redact.Register(token)
+	cached.token = token
+	cached.expires = e.clock.Now().Add(e.ttl)
+	return cached.token, true
+}
+
+// exchange obtains the token of the cluster from the Auth service, renewing the service token if the exchange fails.
+// It needs to be called with the lock held.
+func (e *Exchanger) exchange(apiURL string) (string, error) {
+	_, token, err := e.resolve(context.Background(), apiURL, e.serviceToken, false, e.decode)
+	if err == nil || e.renew == nil {
+		return token, err
+	}
+
+	serviceToken, renewErr := e.renew()
+	if renewErr != nil {
+		logger.WithField("err", renewErr).Warn("Unable to renew the service token")
+		return "", err
+	}
+	redact.Register(serviceToken)
+	e.serviceToken = serviceToken
+	_, token, err = e.resolve(context.Background(), apiURL, e.serviceToken, false, e.decode)
+	return token, err
+}
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_exchanger_caches_and_refreshes_tokens(t *testing.T) {
+	exchanges := 0
+	resolve := func(ctx context.Context, target, token string, forcePull bool, decode Decode) (string, string, error) {
+		if token != "renewed" {
+			return "", "", errors.New("token expired")
+		}
+		exchanges++
+		return "sa", target + "#" + string(rune('0'+exchanges)), nil
+	}
+	renewals := 0
+	renew := func() (string, error) {
+		renewals++
+		return "renewed", nil
+	}
+
+	fakeClock := clock.NewFake(time.Now())
+	e := NewExchanger(resolve, PlainText, "expired", renew, 30*time.Minute, fakeClock)
+
+	token, ok := e.Token("https://api.starter-us-east-2.openshift.com")
+	require.True(t, ok)
+	assert.Equal(t, "https://api.starter-us-east-2.openshift.com/#1", token)
+	assert.Equal(t, 1, renewals, "the rejected service token should have been renewed")
+
+	fakeClock.Advance(29 * time.Minute)
+	token, _ = e.Token("https://api.starter-us-east-2.openshift.com/")
+	assert.Equal(t, "https://api.starter-us-east-2.openshift.com/#1", token, "the token should be cached")
+
+	fakeClock.Advance(time.Minute)
+	token, _ = e.Token("https://api.starter-us-east-2.openshift.com/")
+	assert.Equal(t, "https://api.starter-us-east-2.openshift.com/#2", token, "the token should have been exchanged anew")
+	assert.Equal(t, 1, renewals)
+}
+
+func Test_exchanger_keeps_token_on_failure(t *testing.T) {
+	fail := false
+	resolve := func(ctx context.Context, target, token string, forcePull bool, decode Decode) (string, string, error) {
+		if fail {
+			return "", "", errors.New("auth unavailable")
+		}
+		return "sa", "cluster-token", nil
+	}
+
+	fakeClock := clock.NewFake(time.Now())
+	e := NewExchanger(resolve, PlainText, "service-token", nil, time.Minute, fakeClock)
+
+	_, ok := e.Token("https://api.starter-us-east-2.openshift.com/")
+	require.True(t, ok)
+
+	fail = true
+	fakeClock.Advance(time.Minute)
+	token, ok := e.Token("https://api.starter-us-east-2.openshift.com/")
+	require.True(t, ok)
+	assert.Equal(t, "cluster-token", token, "the previous token should be kept")
+
+	_, ok = e.Token("https://api.starter-us-east-1.openshift.com/")
+	assert.False(t, ok, "no token should be provided if the exchange fails")
+
+	var none *Exchanger
+	_, ok = none.Token("https://api.starter-us-east-2.openshift.com/")
+	assert.False(t, ok, "a nil Exchanger should provide no tokens")
+}
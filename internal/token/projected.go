@@ -0,0 +1,99 @@
+package token
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/redact"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadInterval is the interval at which the token files are checked for rotation.
+const reloadInterval = 30 * time.Second
+
+var logger = logrus.WithField("component", "token")
+
+// Projected provides the bearer tokens of the clusters from the files of a directory, e.g. the service account
+// tokens of a projected volume. The file of a cluster is named after the host of its API URL, e.g.
+// api.starter-us-east-2.openshift.com. As projected tokens expire and get rotated by the kubelet, the files are
+// re-read once modified. A nil Projected provides no tokens.
+type Projected struct {
+	sync.Mutex
+	dir    string
+	clock  clock.Clock
+	tokens map[string]*projectedToken
+}
+
+// projectedToken is the cached content of a token file.
+type projectedToken struct {
+	token   string
+	modTime time.Time
+	checked time.Time
+}
+
+// NewProjected creates a Projected reading the token files from the given directory.
+func NewProjected(dir string, clock clock.Clock) *Projected {
+	return &Projected{
+		dir:    dir,
+		clock:  clock,
+		tokens: make(map[string]*projectedToken),
+	}
+}
+
+// Token returns the current token of the cluster with the given API URL, false if the directory provides no token
+// for the cluster.
+func (p *Projected) Token(apiURL string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Hostname() == "" {
+		return "", false
+	}
+	host := u.Hostname()
+
+	p.Lock()
+	defer p.Unlock()
+
+	cached, ok := p.tokens[host]
+	if ok && p.clock.Since(cached.checked) < reloadInterval {
+		return cached.token, cached.token != ""
+	}
+	if !ok {
+		cached = &projectedToken{}
+		p.tokens[host] = cached
+	}
+	cached.checked = p.clock.Now()
+
+	path := filepath.Join(p.dir, host)
+	info, err := os.Stat(path)
+	if err != nil {
+		if cached.token != "" || !os.IsNotExist(err) {
+			logger.WithFields(logrus.Fields{"file": path, "err": err}).Warn("Unable to access the token file of the cluster")
+		}
+		return cached.token, cached.token != ""
+	}
+	if info.ModTime().Equal(cached.modTime) {
+		return cached.token, cached.token != ""
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.WithFields(logrus.Fields{"file": path, "err": err}).Warn("Unable to read the token file of the cluster")
+		return cached.token, cached.token != ""
+	}
+	if cached.token != "" {
+		logger.WithField("cluster", apiURL).Info("Reloaded rotated token of the cluster")
+	}
+	cached.token = strings.TrimSpace(string(data))
+	redact.Register(cached.token)
+	cached.modTime = info.ModTime()
+	return cached.token, cached.token != ""
+}
@@ -0,0 +1,49 @@
+package token
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_projected_token_reloads_on_rotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "projected")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "api.starter-us-east-2.openshift.com")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0600))
+
+	fakeClock := clock.NewFake(time.Now())
+	p := NewProjected(dir, fakeClock)
+
+	token, ok := p.Token("https://api.starter-us-east-2.openshift.com/")
+	require.True(t, ok)
+	assert.Equal(t, "first", token)
+
+	// rotate the token
+	require.NoError(t, ioutil.WriteFile(path, []byte("second\n"), 0600))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	token, _ = p.Token("https://api.starter-us-east-2.openshift.com/")
+	assert.Equal(t, "first", token, "the file should not be checked before the reload interval")
+
+	fakeClock.Advance(reloadInterval)
+	token, ok = p.Token("https://api.starter-us-east-2.openshift.com/")
+	require.True(t, ok)
+	assert.Equal(t, "second", token)
+
+	_, ok = p.Token("https://api.starter-us-east-1.openshift.com/")
+	assert.False(t, ok, "a cluster without token file should have no token")
+
+	var none *Projected
+	_, ok = none.Token("https://api.starter-us-east-2.openshift.com/")
+	assert.False(t, ok, "a nil Projected should provide no tokens")
+}
@@ -0,0 +1,20 @@
+package token
+
+// Source provides the bearer tokens of the clusters.
+type Source interface {
+	// Token returns the current token of the cluster with the given API URL, false if the source provides no token
+	// for the cluster.
+	Token(apiURL string) (string, bool)
+}
+
+// Default is the Source of the cluster tokens, which take precedence over the tokens of the cluster view. It is nil
+// unless the tokens are read from projected volumes or exchanged with the Auth service.
+var Default Source
+
+// ClusterToken returns the token of the cluster provided by the Default source, false if there is none.
+func ClusterToken(apiURL string) (string, bool) {
+	if Default == nil {
+		return "", false
+	}
+	return Default.Token(apiURL)
+}
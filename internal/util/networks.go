@@ -0,0 +1,27 @@
+package util
+
+import (
+	"fmt"
+	"net"
+)
+
+// ParseNetworks parses the given addresses resp. CIDR ranges. A single address is a network of its own.
+func ParseNetworks(list []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, s := range list {
+		if _, network, err := net.ParseCIDR(s); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("%s is neither an address nor a CIDR range", s)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return networks, nil
+}
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.True(t, networks[0].Contains(net.ParseIP("10.1.2.3")))
+	assert.True(t, networks[1].Contains(net.ParseIP("192.168.1.1")))
+	assert.False(t, networks[1].Contains(net.ParseIP("192.168.1.2")))
+	assert.True(t, networks[2].Contains(net.ParseIP("::1")))
+
+	_, err = ParseNetworks([]string{"proxy.example.com"})
+	assert.Error(t, err)
+}
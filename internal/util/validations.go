@@ -56,3 +56,31 @@ func IsBool(value interface{}, key string) error {
 	}
 	return nil
 }
+
+// IsOneOf checks if value associated with the current key is one of the allowed values.
+func IsOneOf(value interface{}, key string, allowed ...string) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("value for %s needs to be a string", key)
+	}
+
+	for _, a := range allowed {
+		if s == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("value for %s needs to be one of %s", key, strings.Join(allowed, ", "))
+}
+
+// IsNotNegative checks if value associated with the current key is an integer greater than or equal to 0.
+func IsNotNegative(value interface{}, key string) error {
+	i, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil {
+		return fmt.Errorf("value for %s needs to be an integer", key)
+	}
+
+	if i < 0 {
+		return fmt.Errorf("value for %s cannot be negative", key)
+	}
+	return nil
+}
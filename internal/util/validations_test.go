@@ -60,3 +60,16 @@ func Test_IsBool(t *testing.T) {
 		assert.Equal(t, testBool.errors, errors, fmt.Sprintf("Unexpected error for %s", testBool.value))
 	}
 }
+
+func Test_IsOneOf(t *testing.T) {
+	assert.NoError(t, IsOneOf("json", "FOO", "json", "text"))
+	assert.EqualError(t, IsOneOf("xml", "FOO", "json", "text"), "value for FOO needs to be one of json, text")
+	assert.EqualError(t, IsOneOf(42, "FOO", "json", "text"), "value for FOO needs to be a string")
+}
+
+func Test_IsNotNegative(t *testing.T) {
+	assert.NoError(t, IsNotNegative(0, "FOO"))
+	assert.NoError(t, IsNotNegative("30", "FOO"))
+	assert.EqualError(t, IsNotNegative("-1", "FOO"), "value for FOO cannot be negative")
+	assert.EqualError(t, IsNotNegative("ten", "FOO"), "value for FOO needs to be an integer")
+}
@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openapi"
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/util"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// DNS1123LabelPattern is the pattern a DNS-1123 label, e.g. a namespace name, has to match.
+	DNS1123LabelPattern = "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+
+	// DNS1123LabelMaxLength is the maximum length of a DNS-1123 label.
+	DNS1123LabelMaxLength = 63
+
+	// BodyField is the field name used to report errors concerning the request body.
+	BodyField = "body"
+)
+
+// FieldError describes why the value of a single request field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of FieldError.
+type Errors []FieldError
+
+// Error returns all field errors as a single string.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fmt.Sprintf("%s %s", fe.Field, fe.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Request validates the path and query parameters of the given request against the parameters
+// declared by the operation.
+func Request(op openapi.Operation, r *http.Request, ps httprouter.Params) Errors {
+	var errs Errors
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		var value string
+		switch param.In {
+		case "path":
+			value = strings.TrimSpace(ps.ByName(param.Name))
+		case "query":
+			value = strings.TrimSpace(query.Get(param.Name))
+		default:
+			continue
+		}
+
+		if value == "" {
+			if param.Required {
+				errs = append(errs, FieldError{Field: param.Name, In: param.In, Message: "is required"})
+			}
+			continue
+		}
+
+		if msg := checkSchema(param.Schema, value); msg != "" {
+			errs = append(errs, FieldError{Field: param.Name, In: param.In, Message: msg})
+		}
+	}
+	return errs
+}
+
+// Body checks the size of the request body announced by the request against the given maximum.
+func Body(r *http.Request, maxBytes int64) Errors {
+	if maxBytes > 0 && r.ContentLength > maxBytes {
+		return Errors{{
+			Field:   BodyField,
+			In:      BodyField,
+			Message: fmt.Sprintf("must not exceed %d bytes", maxBytes),
+		}}
+	}
+	return nil
+}
+
+func checkSchema(schema *openapi.Schema, value string) string {
+	if schema == nil {
+		return ""
+	}
+
+	if schema.MaxLength > 0 && len(value) > schema.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		matched, err := regexp.MatchString(schema.Pattern, value)
+		if err != nil || !matched {
+			return fmt.Sprintf("must match %s", schema.Pattern)
+		}
+	}
+
+	if schema.Format == "uri" && util.IsURL(value, "") != nil {
+		return "must be a valid URL"
+	}
+
+	if len(schema.Enum) > 0 && util.IsOneOf(value, "", schema.Enum...) != nil {
+		return fmt.Sprintf("must be one of %s", strings.Join(schema.Enum, ", "))
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fabric8-services/fabric8-jenkins-idler/internal/openapi"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+var testOp = openapi.Operation{
+	Parameters: []openapi.Parameter{
+		{
+			Name:     "namespace",
+			In:       "path",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string", Pattern: DNS1123LabelPattern, MaxLength: DNS1123LabelMaxLength},
+		},
+		{
+			Name:     "openshift_api_url",
+			In:       "query",
+			Required: true,
+			Schema:   &openapi.Schema{Type: "string", Format: "uri"},
+		},
+		{
+			Name:   "strategy",
+			In:     "query",
+			Schema: &openapi.Schema{Type: "string", Enum: []string{"delete", "rollout"}},
+		},
+	},
+}
+
+func Test_valid_request(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/idler/idle/foo-jenkins?openshift_api_url=https://api.example.com/", nil)
+	errs := Request(testOp, r, httprouter.Params{{Key: "namespace", Value: "foo-jenkins"}})
+	assert.Empty(t, errs)
+}
+
+func Test_invalid_request(t *testing.T) {
+	var tests = []struct {
+		namespace string
+		query     string
+		expected  Errors
+	}{
+		{"", "openshift_api_url=https://api.example.com/", Errors{
+			{Field: "namespace", In: "path", Message: "is required"},
+		}},
+		{"Foo_Jenkins", "openshift_api_url=https://api.example.com/", Errors{
+			{Field: "namespace", In: "path", Message: "must match " + DNS1123LabelPattern},
+		}},
+		{strings.Repeat("a", 64), "openshift_api_url=https://api.example.com/", Errors{
+			{Field: "namespace", In: "path", Message: "must be at most 63 characters"},
+		}},
+		{"foo-jenkins", "", Errors{
+			{Field: "openshift_api_url", In: "query", Message: "is required"},
+		}},
+		{"-foo", "openshift_api_url=api.example.com", Errors{
+			{Field: "namespace", In: "path", Message: "must match " + DNS1123LabelPattern},
+			{Field: "openshift_api_url", In: "query", Message: "must be a valid URL"},
+		}},
+		{"foo-jenkins", "openshift_api_url=https://api.example.com/&strategy=recreate", Errors{
+			{Field: "strategy", In: "query", Message: "must be one of delete, rollout"},
+		}},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/?"+test.query, nil)
+		errs := Request(testOp, r, httprouter.Params{{Key: "namespace", Value: test.namespace}})
+		assert.Equal(t, test.expected, errs, "Unexpected errors for namespace '%s' and query '%s'", test.namespace, test.query)
+	}
+}
+
+func Test_body_size(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"disable": ["foo"]}`))
+	assert.Empty(t, Body(r, 1024))
+
+	errs := Body(r, 10)
+	assert.Equal(t, Errors{{Field: BodyField, In: BodyField, Message: "must not exceed 10 bytes"}}, errs)
+	assert.EqualError(t, errs, "body must not exceed 10 bytes")
+}
@@ -1,10 +1,22 @@
 package version
 
 var (
-	version = "unset"
+	version   = "unset"
+	commit    = "unset"
+	buildTime = "unset"
 )
 
 // GetVersion gets you the current version of Jenkins Idler.
 func GetVersion() string {
 	return version
 }
+
+// GetCommit gets you the git commit the Jenkins Idler got built from.
+func GetCommit() string {
+	return commit
+}
+
+// GetBuildTime gets you the timestamp at which the Jenkins Idler got built.
+func GetBuildTime() string {
+	return buildTime
+}
@@ -23,10 +23,230 @@ var (
 		Help:      "Bucketed histogram of processing time (s) of requests.",
 		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 8),
 	}, reqLabels)
+
+	httpReqLabels   = []string{"route", "method", "code"}
+	httpReqDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_http_request_duration_seconds",
+		Help:      "Bucketed histogram of processing time (s) of API requests.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 10),
+	}, httpReqLabels)
+
+	panicLabels = []string{"source"}
+	panics      = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_panics_total",
+		Help:      "Number of recovered panics.",
+	}, panicLabels)
+
+	transitionLabels = []string{"from", "to", "event", "variant"}
+	transitions      = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_state_transitions_total",
+		Help:      "Number of state transitions of the user idlers.",
+	}, transitionLabels)
+
+	stateLabels = []string{"state"}
+	states      = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_user_idlers",
+		Help:      "Number of user idlers per state of the Jenkins instance.",
+	}, stateLabels)
+
+	evictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_user_idler_evictions_total",
+		Help:      "Number of user idlers evicted due to inactivity, per state of the Jenkins instance.",
+	}, stateLabels)
+
+	idleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_jenkins_idle_duration_seconds",
+		Help:      "Bucketed histogram of the time (s) Jenkins instances were idled before getting scaled up again.",
+		Buckets:   prometheus.ExponentialBuckets(60, 2, 12),
+	}, []string{"variant"})
+
+	clusterLabels = []string{"cluster"}
+	idleQueue     = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_idle_queue",
+		Help:      "Number of idle operations currently queued per cluster since they exceed the idle rate.",
+	}, clusterLabels)
+
+	throttledIdles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_throttled_idles_total",
+		Help:      "Number of idle operations delayed per cluster since they exceeded the idle rate.",
+	}, clusterLabels)
+
+	tenantLookupLabels   = []string{"result"}
+	tenantLookupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_tenant_lookup_duration_seconds",
+		Help:      "Bucketed histogram of the time (s) tenant lookups took, including all result pages.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10),
+	}, tenantLookupLabels)
+
+	tenantLookupPages = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_tenant_lookup_pages_total",
+		Help:      "Number of result pages fetched from the tenant service.",
+	})
+
+	channelBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_user_channel_backlog",
+		Help:      "Number of user events waiting in the channel of the user idler per namespace.",
+	}, []string{"namespace"})
+
+	channelDiscards = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_user_channel_discards_total",
+		Help:      "Number of user events discarded per cluster since the channel of the user idler stayed full.",
+	}, clusterLabels)
+
+	toggleEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_toggle_evaluations_total",
+		Help:      "Number of feature toggle evaluations per feature and outcome, i.e. enabled, disabled or fallback.",
+	}, []string{"feature", "outcome"})
+
+	clusterPressure = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_cluster_pressure",
+		Help:      "Whether a cluster is considered under resource pressure (1) so that idle timeouts are shortened or not (0).",
+	}, clusterLabels)
+
+	pressureChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_pressure_changes_total",
+		Help:      "Number of times a cluster came under resource pressure resp. the pressure got relieved.",
+	}, []string{"cluster", "pressure"})
+
+	pressureIdles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_pressure_idles_total",
+		Help:      "Number of Jenkins instances idled per cluster with an idle timeout shortened due to resource pressure.",
+	}, clusterLabels)
+
+	tokenExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_token_expiry_seconds",
+		Help:      "Number of seconds until the bearer token of the cluster expires, negative once expired.",
+	}, clusterLabels)
+
+	rejectedEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_watch_events_rejected_total",
+		Help:      "Number of malformed watch events quarantined instead of being handled, per cluster, resource and reason.",
+	}, []string{"cluster", "resource", "reason"})
+
+	clusterWatches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_cluster_watch_up",
+		Help:      "Whether the controller and the watches of the cluster are running (1) or stopped (0).",
+	}, clusterLabels)
+
+	clusterWatchStarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_cluster_watch_starts_total",
+		Help:      "Number of times the controller and the watches of the cluster got started, including restarts.",
+	}, clusterLabels)
+
+	driftLabels = []string{"cluster", "believed", "observed"}
+	stateDrifts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_state_drift_total",
+		Help:      "Number of times the state of Jenkins believed by the idler diverged from the observed one and got corrected.",
+	}, driftLabels)
+
+	eventLabels      = []string{"cluster", "resource"}
+	controllerEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_controller_events_total",
+		Help:      "Number of build resp. deployment config events received by the controller of a cluster.",
+	}, eventLabels)
+
+	ignoredEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_controller_events_ignored_total",
+		Help:      "Number of events the controller of a cluster ignored, per resource and reason.",
+	}, []string{"cluster", "resource", "reason"})
+
+	eventDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_controller_event_duration_seconds",
+		Help:      "Bucketed histogram of the time (s) the controller of a cluster took to process an event.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, eventLabels)
+
+	userIdlerCreationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_controller_tenant_lookup_failures_total",
+		Help:      "Number of user-idlers the controller of a cluster failed to create since the tenant lookup failed.",
+	}, clusterLabels)
+
+	jenkinsResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "idler_jenkins_resources",
+		Help:      "CPU cores resp. memory bytes requested by, limited for or used by Jenkins per namespace.",
+	}, []string{"namespace", "resource", "type"})
 )
 
 func registerMetrics() {
 	reqDuration = register(reqDuration, "idler_request_duration_seconds").(*prometheus.HistogramVec)
+	httpReqDuration = register(httpReqDuration, "idler_http_request_duration_seconds").(*prometheus.HistogramVec)
+	panics = register(panics, "idler_panics_total").(*prometheus.CounterVec)
+	transitions = register(transitions, "idler_state_transitions_total").(*prometheus.CounterVec)
+	states = register(states, "idler_user_idlers").(*prometheus.GaugeVec)
+	evictions = register(evictions, "idler_user_idler_evictions_total").(*prometheus.CounterVec)
+	idleDuration = register(idleDuration, "idler_jenkins_idle_duration_seconds").(*prometheus.HistogramVec)
+	idleQueue = register(idleQueue, "idler_idle_queue").(*prometheus.GaugeVec)
+	throttledIdles = register(throttledIdles, "idler_throttled_idles_total").(*prometheus.CounterVec)
+	tenantLookupDuration = register(tenantLookupDuration, "idler_tenant_lookup_duration_seconds").(*prometheus.HistogramVec)
+	tenantLookupPages = register(tenantLookupPages, "idler_tenant_lookup_pages_total").(prometheus.Counter)
+	channelBacklog = register(channelBacklog, "idler_user_channel_backlog").(*prometheus.GaugeVec)
+	channelDiscards = register(channelDiscards, "idler_user_channel_discards_total").(*prometheus.CounterVec)
+	toggleEvaluations = register(toggleEvaluations, "idler_toggle_evaluations_total").(*prometheus.CounterVec)
+	clusterPressure = register(clusterPressure, "idler_cluster_pressure").(*prometheus.GaugeVec)
+	pressureChanges = register(pressureChanges, "idler_pressure_changes_total").(*prometheus.CounterVec)
+	pressureIdles = register(pressureIdles, "idler_pressure_idles_total").(*prometheus.CounterVec)
+	tokenExpiry = register(tokenExpiry, "idler_token_expiry_seconds").(*prometheus.GaugeVec)
+	rejectedEvents = register(rejectedEvents, "idler_watch_events_rejected_total").(*prometheus.CounterVec)
+	clusterWatches = register(clusterWatches, "idler_cluster_watch_up").(*prometheus.GaugeVec)
+	clusterWatchStarts = register(clusterWatchStarts, "idler_cluster_watch_starts_total").(*prometheus.CounterVec)
+	stateDrifts = register(stateDrifts, "idler_state_drift_total").(*prometheus.CounterVec)
+	controllerEvents = register(controllerEvents, "idler_controller_events_total").(*prometheus.CounterVec)
+	ignoredEvents = register(ignoredEvents, "idler_controller_events_ignored_total").(*prometheus.CounterVec)
+	eventDuration = register(eventDuration, "idler_controller_event_duration_seconds").(*prometheus.HistogramVec)
+	userIdlerCreationFailures = register(userIdlerCreationFailures, "idler_controller_tenant_lookup_failures_total").(*prometheus.CounterVec)
+	jenkinsResources = register(jenkinsResources, "idler_jenkins_resources").(*prometheus.GaugeVec)
 }
 
 func register(c prometheus.Collector, name string) prometheus.Collector {
@@ -52,7 +272,143 @@ func reportRequestDuration(jenkinsService, operation string, code int, elapsedTi
 	}
 }
 
+func reportHTTPRequest(route, method string, code int, elapsedTime float64) {
+	if route != "" && method != "" && code != 0 {
+		httpReqDuration.WithLabelValues(route, method, codeVal(code)).Observe(elapsedTime)
+	}
+}
+
+func reportPanic(source string) {
+	if source != "" {
+		panics.WithLabelValues(source).Inc()
+	}
+}
+
+func reportStateTransition(from, to, event, variant string) {
+	if to == "" {
+		return
+	}
+	if from != "" {
+		states.WithLabelValues(from).Dec()
+		transitions.WithLabelValues(from, to, event, variantVal(variant)).Inc()
+	}
+	states.WithLabelValues(to).Inc()
+}
+
+func reportEviction(state string) {
+	states.WithLabelValues(state).Dec()
+	evictions.WithLabelValues(state).Inc()
+}
+
+func reportIdleDuration(variant string, elapsedTime float64) {
+	if elapsedTime > 0 {
+		idleDuration.WithLabelValues(variantVal(variant)).Observe(elapsedTime)
+	}
+}
+
+func reportIdleQueued(cluster string) {
+	idleQueue.WithLabelValues(cluster).Inc()
+	throttledIdles.WithLabelValues(cluster).Inc()
+}
+
+func reportIdleDequeued(cluster string) {
+	idleQueue.WithLabelValues(cluster).Dec()
+}
+
+func reportTenantLookup(pages int, failed bool, elapsedTime float64) {
+	result := "success"
+	if failed {
+		result = "error"
+	}
+	tenantLookupDuration.WithLabelValues(result).Observe(elapsedTime)
+	tenantLookupPages.Add(float64(pages))
+}
+
+func reportChannelBacklog(namespace string, backlog int) {
+	channelBacklog.WithLabelValues(namespace).Set(float64(backlog))
+}
+
+func reportChannelDiscard(cluster string) {
+	channelDiscards.WithLabelValues(cluster).Inc()
+}
+
+func reportChannelRemoved(namespace string) {
+	channelBacklog.DeleteLabelValues(namespace)
+}
+
 func codeVal(status int) string {
 	code := (status - (status % 100)) / 100
 	return strconv.Itoa(code) + "xx"
 }
+
+// variantVal returns the label value of the experiment variant, "none" for users not taking part in an experiment.
+func variantVal(variant string) string {
+	if variant == "" {
+		return "none"
+	}
+	return variant
+}
+
+func reportToggleEvaluation(feature, outcome string) {
+	toggleEvaluations.WithLabelValues(feature, outcome).Inc()
+}
+
+func reportClusterPressure(cluster string, underPressure bool) {
+	if underPressure {
+		clusterPressure.WithLabelValues(cluster).Set(1)
+		pressureChanges.WithLabelValues(cluster, "on").Inc()
+		return
+	}
+	clusterPressure.WithLabelValues(cluster).Set(0)
+	pressureChanges.WithLabelValues(cluster, "off").Inc()
+}
+
+func reportPressureIdle(cluster string) {
+	pressureIdles.WithLabelValues(cluster).Inc()
+}
+
+func reportTokenExpiry(cluster string, seconds float64) {
+	tokenExpiry.WithLabelValues(cluster).Set(seconds)
+}
+
+func reportRejectedEvent(cluster, resource, reason string) {
+	rejectedEvents.WithLabelValues(cluster, resource, reason).Inc()
+}
+
+func reportClusterWatch(cluster string, running bool) {
+	if running {
+		clusterWatches.WithLabelValues(cluster).Set(1)
+		clusterWatchStarts.WithLabelValues(cluster).Inc()
+		return
+	}
+	clusterWatches.WithLabelValues(cluster).Set(0)
+}
+
+func reportStateDrift(cluster, believed, observed string) {
+	stateDrifts.WithLabelValues(cluster, believed, observed).Inc()
+}
+
+func reportControllerEvent(cluster, resource string, elapsedTime float64) {
+	controllerEvents.WithLabelValues(cluster, resource).Inc()
+	eventDuration.WithLabelValues(cluster, resource).Observe(elapsedTime)
+}
+
+func reportIgnoredEvent(cluster, resource, reason string) {
+	ignoredEvents.WithLabelValues(cluster, resource, reason).Inc()
+}
+
+func reportTenantLookupFailure(cluster string) {
+	userIdlerCreationFailures.WithLabelValues(cluster).Inc()
+}
+
+func reportJenkinsResources(namespace, resource, kind string, value float64) {
+	jenkinsResources.WithLabelValues(namespace, resource, kind).Set(value)
+}
+
+func reportJenkinsResourcesRemoved(namespace string) {
+	for _, resource := range []string{"cpu", "memory"} {
+		for _, kind := range []string{"request", "limit", "usage"} {
+			jenkinsResources.DeleteLabelValues(namespace, resource, kind)
+		}
+	}
+}
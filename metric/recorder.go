@@ -1,21 +1,172 @@
 package metric
 
+import "sync"
+
 // Recorder interface that encapsulates all logic of metrics
 type Recorder interface {
 	Initialize()
 	RecordReqDuration(jenkinsService, operation string, code int, elapsedTime float64)
+	RecordHTTPRequest(route, method string, code int, elapsedTime float64)
+	RecordPanic(source string)
+	RecordStateTransition(from, to, event, variant string)
+	RecordIdleDuration(variant string, elapsedTime float64)
+	RecordEviction(state string)
+	RecordIdleQueued(cluster string)
+	RecordIdleDequeued(cluster string)
+	RecordTenantLookup(pages int, failed bool, elapsedTime float64)
+	RecordChannelBacklog(namespace string, backlog int)
+	RecordChannelDiscard(cluster string)
+	RecordChannelRemoved(namespace string)
+	RecordToggleEvaluation(feature, outcome string)
+	RecordClusterPressure(cluster string, underPressure bool)
+	RecordPressureIdle(cluster string)
+	RecordTokenExpiry(cluster string, seconds float64)
+	RecordRejectedEvent(cluster, resource, reason string)
+	RecordClusterWatch(cluster string, running bool)
+	RecordStateDrift(cluster, believed, observed string)
+	RecordControllerEvent(cluster, resource string, elapsedTime float64)
+	RecordIgnoredEvent(cluster, resource, reason string)
+	RecordTenantLookupFailure(cluster string)
+	RecordJenkinsResources(namespace, resource, kind string, value float64)
+	RecordJenkinsResourcesRemoved(namespace string)
 }
 
 // PrometheusRecorder struct used to record metrics to be consumed by Prometheus
 type PrometheusRecorder struct {
 }
 
-// Initialize all metrics
+// initialized guards the registration of the metrics, which swaps the collectors of the package for the registered
+// ones and hence must not happen while they are in use.
+var initialized sync.Once
+
+// Initialize all metrics, once
 func (pr PrometheusRecorder) Initialize() {
-	registerMetrics()
+	initialized.Do(registerMetrics)
 }
 
 // RecordReqDuration records the duration of given operation in metrics system
 func (pr PrometheusRecorder) RecordReqDuration(jenkinsService, operation string, code int, elapsedTime float64) {
 	reportRequestDuration(jenkinsService, operation, code, elapsedTime)
 }
+
+// RecordHTTPRequest records the duration of an API request against the route it got served by
+func (pr PrometheusRecorder) RecordHTTPRequest(route, method string, code int, elapsedTime float64) {
+	reportHTTPRequest(route, method, code, elapsedTime)
+}
+
+// RecordPanic counts a recovered panic which occurred in the given source
+func (pr PrometheusRecorder) RecordPanic(source string) {
+	reportPanic(source)
+}
+
+// RecordStateTransition records the transition of a user idler from one state to another, tagged with the variant of
+// the idle timeout experiment the user is assigned to, if any. An empty from state records a new user idler starting
+// in the to state.
+func (pr PrometheusRecorder) RecordStateTransition(from, to, event, variant string) {
+	reportStateTransition(from, to, event, variant)
+}
+
+// RecordIdleDuration records how long (s) a Jenkins instance was idled before it got scaled up again, tagged with the
+// variant of the idle timeout experiment the user is assigned to, if any
+func (pr PrometheusRecorder) RecordIdleDuration(variant string, elapsedTime float64) {
+	reportIdleDuration(variant, elapsedTime)
+}
+
+// RecordEviction records the eviction of an inactive user idler whose Jenkins instance is in the given state
+func (pr PrometheusRecorder) RecordEviction(state string) {
+	reportEviction(state)
+}
+
+// RecordIdleQueued records an idle operation on the given cluster getting queued since it exceeds the idle rate
+func (pr PrometheusRecorder) RecordIdleQueued(cluster string) {
+	reportIdleQueued(cluster)
+}
+
+// RecordIdleDequeued records a queued idle operation on the given cluster leaving the queue
+func (pr PrometheusRecorder) RecordIdleDequeued(cluster string) {
+	reportIdleDequeued(cluster)
+}
+
+// RecordTenantLookup records the duration (s) of a tenant lookup and the number of result pages it fetched
+func (pr PrometheusRecorder) RecordTenantLookup(pages int, failed bool, elapsedTime float64) {
+	reportTenantLookup(pages, failed, elapsedTime)
+}
+
+// RecordChannelBacklog records the number of user events waiting in the channel of the user idler of a namespace
+func (pr PrometheusRecorder) RecordChannelBacklog(namespace string, backlog int) {
+	reportChannelBacklog(namespace, backlog)
+}
+
+// RecordChannelDiscard records a user event on the given cluster being discarded since the channel stayed full
+func (pr PrometheusRecorder) RecordChannelDiscard(cluster string) {
+	reportChannelDiscard(cluster)
+}
+
+// RecordChannelRemoved records the user idler of a namespace being removed along with its channel
+func (pr PrometheusRecorder) RecordChannelRemoved(namespace string) {
+	reportChannelRemoved(namespace)
+}
+
+// RecordToggleEvaluation records the outcome of evaluating a feature toggle, i.e. enabled, disabled or fallback
+func (pr PrometheusRecorder) RecordToggleEvaluation(feature, outcome string) {
+	reportToggleEvaluation(feature, outcome)
+}
+
+// RecordClusterPressure records a cluster coming under resource pressure resp. the pressure being relieved
+func (pr PrometheusRecorder) RecordClusterPressure(cluster string, underPressure bool) {
+	reportClusterPressure(cluster, underPressure)
+}
+
+// RecordPressureIdle records Jenkins on the given cluster being idled with an idle timeout shortened due to pressure
+func (pr PrometheusRecorder) RecordPressureIdle(cluster string) {
+	reportPressureIdle(cluster)
+}
+
+// RecordTokenExpiry records the number of seconds until the bearer token of the given cluster expires
+func (pr PrometheusRecorder) RecordTokenExpiry(cluster string, seconds float64) {
+	reportTokenExpiry(cluster, seconds)
+}
+
+// RecordRejectedEvent counts a malformed watch event of the given resource which got quarantined for the given reason
+func (pr PrometheusRecorder) RecordRejectedEvent(cluster, resource, reason string) {
+	reportRejectedEvent(cluster, resource, reason)
+}
+
+// RecordClusterWatch records the controller and the watches of the given cluster being started resp. stopped
+func (pr PrometheusRecorder) RecordClusterWatch(cluster string, running bool) {
+	reportClusterWatch(cluster, running)
+}
+
+// RecordStateDrift counts a divergence between the believed and the observed state of Jenkins on the given cluster
+func (pr PrometheusRecorder) RecordStateDrift(cluster, believed, observed string) {
+	reportStateDrift(cluster, believed, observed)
+}
+
+// RecordControllerEvent records an event of the given resource, e.g. builds, received by the controller of the given
+// cluster along with the time (s) it took to process it
+func (pr PrometheusRecorder) RecordControllerEvent(cluster, resource string, elapsedTime float64) {
+	reportControllerEvent(cluster, resource, elapsedTime)
+}
+
+// RecordIgnoredEvent counts an event the controller of the given cluster ignored for the given reason, e.g. duplicate
+func (pr PrometheusRecorder) RecordIgnoredEvent(cluster, resource, reason string) {
+	reportIgnoredEvent(cluster, resource, reason)
+}
+
+// RecordTenantLookupFailure counts a user-idler the controller of the given cluster failed to create since the tenant
+// of the namespace could not be looked up
+func (pr PrometheusRecorder) RecordTenantLookupFailure(cluster string) {
+	reportTenantLookupFailure(cluster)
+}
+
+// RecordJenkinsResources records the CPU cores resp. memory bytes of the given kind, i.e. request, limit or usage, of
+// the Jenkins in the given namespace
+func (pr PrometheusRecorder) RecordJenkinsResources(namespace, resource, kind string, value float64) {
+	reportJenkinsResources(namespace, resource, kind, value)
+}
+
+// RecordJenkinsResourcesRemoved removes the resources recorded for the Jenkins in the given namespace, e.g. since its
+// user idler got evicted
+func (pr PrometheusRecorder) RecordJenkinsResourcesRemoved(namespace string) {
+	reportJenkinsResourcesRemoved(namespace)
+}
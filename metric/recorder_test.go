@@ -44,6 +44,177 @@ func TestReqDurationMetric(t *testing.T) {
 	checkHistogram(t, m, uint64(len(reqTimes)), expectedBound, expectedCnt)
 }
 
+func TestPanicMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordPanic("api")
+	recorder.RecordPanic("api")
+	recorder.RecordPanic("")
+
+	panicMetric, _ := panics.GetMetricWithLabelValues("api")
+	m := &dto.Metric{}
+	panicMetric.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Panic count was incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestStateTransitionMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordStateTransition("", "unknown", "", "")
+	recorder.RecordStateTransition("", "unknown", "", "")
+	recorder.RecordStateTransition("unknown", "running", "pod_running", "")
+	recorder.RecordStateTransition("running", "idling", "idle", "short")
+
+	m := &dto.Metric{}
+	unknown, _ := states.GetMetricWithLabelValues("unknown")
+	unknown.Write(m)
+	if m.Gauge.GetValue() != 1 {
+		t.Errorf("Unknown state count was incorrect, want: 1, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	transition, _ := transitions.GetMetricWithLabelValues("unknown", "running", "pod_running", "none")
+	transition.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Transition count was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+
+	m = &dto.Metric{}
+	transition, _ = transitions.GetMetricWithLabelValues("running", "idling", "idle", "short")
+	transition.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Transition count of variant was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestIdleDurationMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordIdleDuration("", 90)
+	recorder.RecordIdleDuration("", 0)
+	recorder.RecordIdleDuration("short", 60)
+
+	m := &dto.Metric{}
+	none, _ := idleDuration.GetMetricWithLabelValues("none")
+	none.Write(m)
+	if m.Histogram.GetSampleCount() != 1 {
+		t.Errorf("Idle duration count was incorrect, want: 1, got: %d", m.Histogram.GetSampleCount())
+	}
+
+	m = &dto.Metric{}
+	short, _ := idleDuration.GetMetricWithLabelValues("short")
+	short.Write(m)
+	if m.Histogram.GetSampleCount() != 1 {
+		t.Errorf("Idle duration count of variant was incorrect, want: 1, got: %d", m.Histogram.GetSampleCount())
+	}
+}
+
+func TestEvictionMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordStateTransition("", "idled", "", "")
+	recorder.RecordStateTransition("", "idled", "", "")
+	recorder.RecordEviction("idled")
+
+	m := &dto.Metric{}
+	idled, _ := states.GetMetricWithLabelValues("idled")
+	idled.Write(m)
+	if m.Gauge.GetValue() != 1 {
+		t.Errorf("Idled state count was incorrect, want: 1, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	eviction, _ := evictions.GetMetricWithLabelValues("idled")
+	eviction.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Eviction count was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestIdleQueueMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordIdleQueued("https://api.example.com/")
+	recorder.RecordIdleQueued("https://api.example.com/")
+	recorder.RecordIdleDequeued("https://api.example.com/")
+
+	m := &dto.Metric{}
+	queued, _ := idleQueue.GetMetricWithLabelValues("https://api.example.com/")
+	queued.Write(m)
+	if m.Gauge.GetValue() != 1 {
+		t.Errorf("Idle queue length was incorrect, want: 1, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	throttled, _ := throttledIdles.GetMetricWithLabelValues("https://api.example.com/")
+	throttled.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Throttled idle count was incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestTenantLookupMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordTenantLookup(3, false, 0.2)
+	recorder.RecordTenantLookup(1, true, 0.1)
+
+	m := &dto.Metric{}
+	tenantLookupPages.Write(m)
+	if m.Counter.GetValue() != 4 {
+		t.Errorf("Tenant lookup page count was incorrect, want: 4, got: %f", m.Counter.GetValue())
+	}
+
+	m = &dto.Metric{}
+	failed, _ := tenantLookupDuration.GetMetricWithLabelValues("error")
+	failed.Write(m)
+	if m.Histogram.GetSampleCount() != 1 {
+		t.Errorf("Failed tenant lookup count was incorrect, want: 1, got: %d", m.Histogram.GetSampleCount())
+	}
+}
+
+func TestChannelMetrics(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordChannelBacklog("john", 3)
+	recorder.RecordChannelDiscard("https://api.example.com/")
+
+	m := &dto.Metric{}
+	backlog, _ := channelBacklog.GetMetricWithLabelValues("john")
+	backlog.Write(m)
+	if m.Gauge.GetValue() != 3 {
+		t.Errorf("Channel backlog was incorrect, want: 3, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	discards, _ := channelDiscards.GetMetricWithLabelValues("https://api.example.com/")
+	discards.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Channel discard count was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+
+	recorder.RecordChannelRemoved("john")
+	if channelBacklog.DeleteLabelValues("john") {
+		t.Error("Channel backlog of removed user idler should be deleted")
+	}
+}
+
+func TestToggleEvaluationMetrics(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordToggleEvaluation("jenkins.idler", "enabled")
+	recorder.RecordToggleEvaluation("jenkins.idler", "enabled")
+	recorder.RecordToggleEvaluation("jenkins.idler", "fallback")
+
+	m := &dto.Metric{}
+	enabled, _ := toggleEvaluations.GetMetricWithLabelValues("jenkins.idler", "enabled")
+	enabled.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Enabled toggle evaluation count was incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+
+	m = &dto.Metric{}
+	fallback, _ := toggleEvaluations.GetMetricWithLabelValues("jenkins.idler", "fallback")
+	fallback.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Fallback toggle evaluation count was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+}
+
 func checkHistogram(t *testing.T, m *dto.Metric, expectedCount uint64, expectedBound []float64, expectedCnt []uint64) {
 	if expectedCount != m.Histogram.GetSampleCount() {
 		t.Errorf("Histogram count was incorrect, want: %d, got: %d",
@@ -60,3 +231,150 @@ func checkHistogram(t *testing.T, m *dto.Metric, expectedCount uint64, expectedB
 		}
 	}
 }
+
+func TestPressureMetrics(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordClusterPressure("https://api.example.com/", true)
+	recorder.RecordPressureIdle("https://api.example.com/")
+	recorder.RecordPressureIdle("https://api.example.com/")
+
+	m := &dto.Metric{}
+	gauge, _ := clusterPressure.GetMetricWithLabelValues("https://api.example.com/")
+	gauge.Write(m)
+	if m.Gauge.GetValue() != 1 {
+		t.Errorf("Cluster pressure was incorrect, want: 1, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	idles, _ := pressureIdles.GetMetricWithLabelValues("https://api.example.com/")
+	idles.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Pressure idle count was incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+
+	recorder.RecordClusterPressure("https://api.example.com/", false)
+	m = &dto.Metric{}
+	gauge.Write(m)
+	if m.Gauge.GetValue() != 0 {
+		t.Errorf("Cluster pressure was incorrect, want: 0, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	changes, _ := pressureChanges.GetMetricWithLabelValues("https://api.example.com/", "off")
+	changes.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Pressure change count was incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestTokenExpiryMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordTokenExpiry("https://api.example.com/", 3600)
+
+	m := &dto.Metric{}
+	gauge, _ := tokenExpiry.GetMetricWithLabelValues("https://api.example.com/")
+	gauge.Write(m)
+	if m.Gauge.GetValue() != 3600 {
+		t.Errorf("Token expiry was incorrect, want: 3600, got: %f", m.Gauge.GetValue())
+	}
+}
+
+func TestRejectedEventMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordRejectedEvent("https://api.example.com/", "builds", "missing namespace")
+	recorder.RecordRejectedEvent("https://api.example.com/", "builds", "missing namespace")
+
+	m := &dto.Metric{}
+	counter, _ := rejectedEvents.GetMetricWithLabelValues("https://api.example.com/", "builds", "missing namespace")
+	counter.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Rejected events were incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestClusterWatchMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordClusterWatch("https://api.example.com/", true)
+	recorder.RecordClusterWatch("https://api.example.com/", false)
+	recorder.RecordClusterWatch("https://api.example.com/", true)
+
+	m := &dto.Metric{}
+	gauge, _ := clusterWatches.GetMetricWithLabelValues("https://api.example.com/")
+	gauge.Write(m)
+	if m.Gauge.GetValue() != 1 {
+		t.Errorf("Cluster watch state was incorrect, want: 1, got: %f", m.Gauge.GetValue())
+	}
+
+	m = &dto.Metric{}
+	counter, _ := clusterWatchStarts.GetMetricWithLabelValues("https://api.example.com/")
+	counter.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Cluster watch starts were incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestStateDriftMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordStateDrift("https://api.example.com/", "running", "idled")
+	recorder.RecordStateDrift("https://api.example.com/", "running", "idled")
+
+	m := &dto.Metric{}
+	counter, _ := stateDrifts.GetMetricWithLabelValues("https://api.example.com/", "running", "idled")
+	counter.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("State drifts were incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestControllerEventMetrics(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordControllerEvent("https://api.example.com/", "builds", 0.002)
+	recorder.RecordControllerEvent("https://api.example.com/", "builds", 0.5)
+	recorder.RecordIgnoredEvent("https://api.example.com/", "builds", "duplicate")
+	recorder.RecordTenantLookupFailure("https://api.example.com/")
+
+	m := &dto.Metric{}
+	counter, _ := controllerEvents.GetMetricWithLabelValues("https://api.example.com/", "builds")
+	counter.Write(m)
+	if m.Counter.GetValue() != 2 {
+		t.Errorf("Controller events were incorrect, want: 2, got: %f", m.Counter.GetValue())
+	}
+
+	m = &dto.Metric{}
+	histogram, _ := eventDuration.GetMetricWithLabelValues("https://api.example.com/", "builds")
+	histogram.Write(m)
+	if m.Histogram.GetSampleCount() != 2 {
+		t.Errorf("Event durations were incorrect, want: 2, got: %d", m.Histogram.GetSampleCount())
+	}
+
+	m = &dto.Metric{}
+	counter, _ = ignoredEvents.GetMetricWithLabelValues("https://api.example.com/", "builds", "duplicate")
+	counter.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Ignored events were incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+
+	m = &dto.Metric{}
+	counter, _ = userIdlerCreationFailures.GetMetricWithLabelValues("https://api.example.com/")
+	counter.Write(m)
+	if m.Counter.GetValue() != 1 {
+		t.Errorf("Tenant lookup failures were incorrect, want: 1, got: %f", m.Counter.GetValue())
+	}
+}
+
+func TestJenkinsResourcesMetric(t *testing.T) {
+	recorder := PrometheusRecorder{}
+	recorder.RecordJenkinsResources("foo-jenkins", "memory", "limit", 1073741824)
+
+	m := &dto.Metric{}
+	gauge, _ := jenkinsResources.GetMetricWithLabelValues("foo-jenkins", "memory", "limit")
+	gauge.Write(m)
+	if m.Gauge.GetValue() != 1073741824 {
+		t.Errorf("Jenkins resources were incorrect, want: 1073741824, got: %f", m.Gauge.GetValue())
+	}
+
+	recorder.RecordJenkinsResourcesRemoved("foo-jenkins")
+	if jenkinsResources.DeleteLabelValues("foo-jenkins", "memory", "limit") {
+		t.Error("Jenkins resources should have been removed")
+	}
+}